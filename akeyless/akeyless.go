@@ -0,0 +1,170 @@
+// Package akeyless provides a secret provider that reads from Akeyless.
+package akeyless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// DefaultGatewayURL is the Akeyless public API.
+const DefaultGatewayURL = "https://api.akeyless.io"
+
+// Client abstracts the Akeyless API.
+type Client interface {
+	// GetSecretValue returns the value of the static secret name.
+	GetSecretValue(ctx context.Context, name string) (string, error)
+}
+
+// DynamicClient is implemented by Clients that can generate dynamic secrets.
+// The default client uses the get-dynamic-secret-value operation.
+type DynamicClient interface {
+	// GetDynamicSecretValue generates credentials from the dynamic secret
+	// name and returns them as a JSON object.
+	GetDynamicSecretValue(ctx context.Context, name string) ([]byte, error)
+}
+
+// ProviderOption configures the akeyless Provider.
+type ProviderOption func(*Provider)
+
+// WithGatewayURL sets the URL of the Akeyless API, such as that of a
+// customer gateway's /api/v2 endpoint. Defaults to the AKEYLESS_GATEWAY_URL
+// environment variable, or DefaultGatewayURL.
+func WithGatewayURL(url string) ProviderOption {
+	return func(p *Provider) {
+		p.gatewayURL = url
+	}
+}
+
+// WithAPIKey authenticates with an API key auth method's access ID and
+// access key. Defaults to the AKEYLESS_ACCESS_ID and AKEYLESS_ACCESS_KEY
+// environment variables when no other auth method is given.
+func WithAPIKey(accessID, accessKey string) ProviderOption {
+	return func(p *Provider) {
+		p.auth = &authMethod{accessID: accessID, accessType: "access_key", accessKey: accessKey}
+	}
+}
+
+// WithAWSIAM authenticates with an AWS IAM auth method, proving the identity
+// of the AWS credentials from the default credential chain.
+func WithAWSIAM(accessID string) ProviderOption {
+	return func(p *Provider) {
+		p.auth = &authMethod{accessID: accessID, accessType: "aws_iam", cloudID: awsCloudID}
+	}
+}
+
+// WithAzureAD authenticates with an Azure AD auth method, proving the
+// identity of the VM's or pod's managed identity.
+func WithAzureAD(accessID string) ProviderOption {
+	return func(p *Provider) {
+		p.auth = &authMethod{accessID: accessID, accessType: "azure_ad", cloudID: azureCloudID}
+	}
+}
+
+// WithGCP authenticates with a GCP auth method, proving the identity of the
+// instance's service account with an ID token for audience, which defaults
+// to "akeyless.io" if empty.
+func WithGCP(accessID, audience string) ProviderOption {
+	return func(p *Provider) {
+		if audience == "" {
+			audience = "akeyless.io"
+		}
+		p.auth = &authMethod{accessID: accessID, accessType: "gcp", gcpAudience: audience, cloudID: gcpCloudID}
+	}
+}
+
+// WithDynamicSecrets makes the provider generate credentials from dynamic
+// secrets rather than read static secrets. Every Get issues new
+// credentials, returned as a JSON object, so resolve them once rather than
+// watching them.
+func WithDynamicSecrets() ProviderOption {
+	return func(p *Provider) {
+		p.dynamic = true
+	}
+}
+
+// WithHTTPClient sets the HTTP client of the default client, which also
+// fetches cloud identities from instance metadata services.
+func WithHTTPClient(c *http.Client) ProviderOption {
+	return func(p *Provider) {
+		p.httpClient = c
+	}
+}
+
+// WithClient injects a custom Client implementation.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider reads secrets from Akeyless. Keys are item names, such as
+// "/prod/db-password"; the leading slash is optional.
+// It implements secrets.Provider.
+type Provider struct {
+	gatewayURL string
+	auth       *authMethod
+	dynamic    bool
+	httpClient *http.Client
+	client     Client
+}
+
+// New creates a new Akeyless Provider. It authenticates on first use, and
+// again whenever its token expires.
+func New(opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		if p.gatewayURL == "" {
+			p.gatewayURL = os.Getenv("AKEYLESS_GATEWAY_URL")
+		}
+		if p.gatewayURL == "" {
+			p.gatewayURL = DefaultGatewayURL
+		}
+		if p.auth == nil {
+			if id := os.Getenv("AKEYLESS_ACCESS_ID"); id != "" {
+				WithAPIKey(id, os.Getenv("AKEYLESS_ACCESS_KEY"))(p)
+			}
+		}
+		if p.auth == nil {
+			return nil, errors.New("akeyless: an auth method is required (use WithAPIKey, WithAWSIAM, WithAzureAD, or WithGCP)")
+		}
+		if p.httpClient == nil {
+			p.httpClient = http.DefaultClient
+		}
+		p.client = &apiClient{
+			url:  strings.TrimSuffix(p.gatewayURL, "/"),
+			auth: p.auth,
+			http: p.httpClient,
+		}
+	}
+	return p, nil
+}
+
+// Get retrieves the value of a static secret, or generates credentials from
+// a dynamic secret with WithDynamicSecrets.
+// Returns secrets.ErrNotFound (wrapped) if the item does not exist.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	name := "/" + strings.TrimPrefix(key, "/")
+	if p.dynamic {
+		dc, ok := p.client.(DynamicClient)
+		if !ok {
+			return nil, fmt.Errorf("akeyless: dynamic secret %q: %w", key, errors.ErrUnsupported)
+		}
+		val, err := dc.GetDynamicSecretValue(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("akeyless: dynamic secret %q: %w", key, err)
+		}
+		return val, nil
+	}
+	val, err := p.client.GetSecretValue(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("akeyless: secret %q: %w", key, err)
+	}
+	return []byte(val), nil
+}
@@ -0,0 +1,112 @@
+package akeyless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// mockClient implements Client and DynamicClient for testing.
+type mockClient struct {
+	static  map[string]string
+	dynamic map[string]string // name to JSON credentials
+}
+
+func (m *mockClient) GetSecretValue(_ context.Context, name string) (string, error) {
+	val, ok := m.static[name]
+	if !ok {
+		return "", fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return val, nil
+}
+
+func (m *mockClient) GetDynamicSecretValue(_ context.Context, name string) ([]byte, error) {
+	val, ok := m.dynamic[name]
+	if !ok {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return []byte(val), nil
+}
+
+func newMock() *mockClient {
+	return &mockClient{
+		static:  map[string]string{"/prod/api-key": "k1"},
+		dynamic: map[string]string{"/prod/db": `{"user":"tmp-1","password":"p"}`},
+	}
+}
+
+func TestGet(t *testing.T) {
+	p, err := New(WithClient(newMock()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, key := range []string{"prod/api-key", "/prod/api-key"} {
+		got, err := p.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		if string(got) != "k1" {
+			t.Errorf("Get(%q) = %q, want k1", key, got)
+		}
+	}
+	if _, err := p.Get(context.Background(), "prod/db"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("dynamic secret read as static: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestGet_Dynamic(t *testing.T) {
+	p, err := New(WithClient(newMock()), WithDynamicSecrets())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got, err := p.Get(context.Background(), "prod/db")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != `{"user":"tmp-1","password":"p"}` {
+		t.Errorf("Get = %s", got)
+	}
+}
+
+// staticOnly implements only Client.
+type staticOnly struct{ Client }
+
+func TestGet_DynamicUnsupported(t *testing.T) {
+	p, err := New(WithClient(staticOnly{newMock()}), WithDynamicSecrets())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := p.Get(context.Background(), "prod/db"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("got %v, want ErrUnsupported", err)
+	}
+}
+
+func TestNew_Auth(t *testing.T) {
+	t.Setenv("AKEYLESS_ACCESS_ID", "")
+	t.Setenv("AKEYLESS_GATEWAY_URL", "")
+	if _, err := New(); err == nil {
+		t.Error("New without an auth method succeeded")
+	}
+
+	t.Setenv("AKEYLESS_ACCESS_ID", "p-123")
+	t.Setenv("AKEYLESS_ACCESS_KEY", "key")
+	p, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c := p.client.(*apiClient)
+	if c.url != DefaultGatewayURL || c.auth.accessID != "p-123" || c.auth.accessType != "access_key" || c.auth.accessKey != "key" {
+		t.Errorf("client = %q, %+v", c.url, c.auth)
+	}
+
+	p, err = New(WithGCP("p-456", ""))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if a := p.client.(*apiClient).auth; a.accessID != "p-456" || a.accessType != "gcp" || a.gcpAudience != "akeyless.io" {
+		t.Errorf("auth = %+v, want GCP auth for audience akeyless.io", a)
+	}
+}
@@ -0,0 +1,163 @@
+package akeyless
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// authMethod holds the credentials of an Akeyless auth method.
+type authMethod struct {
+	accessID    string
+	accessType  string // "access_key", "aws_iam", "azure_ad", or "gcp"
+	accessKey   string
+	gcpAudience string
+	// cloudID returns the proof of cloud identity of the cloud auth methods.
+	cloudID func(ctx context.Context, a *authMethod, c *http.Client) (string, error)
+}
+
+// apiClient calls the Akeyless REST API.
+type apiClient struct {
+	url  string // such as "https://api.akeyless.io"
+	auth *authMethod
+	http *http.Client
+
+	mu    sync.Mutex
+	token string
+}
+
+func (c *apiClient) GetSecretValue(ctx context.Context, name string) (string, error) {
+	var out map[string]string
+	if err := c.call(ctx, "/get-secret-value", map[string]any{"names": []string{name}}, &out); err != nil {
+		return "", err
+	}
+	val, ok := out[name]
+	if !ok {
+		return "", fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return val, nil
+}
+
+func (c *apiClient) GetDynamicSecretValue(ctx context.Context, name string) ([]byte, error) {
+	var out json.RawMessage
+	if err := c.call(ctx, "/get-dynamic-secret-value", map[string]any{"name": name}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// call sends an operation with the current token, authenticating first if
+// there is none, and again once if the token has expired.
+func (c *apiClient) call(ctx context.Context, op string, in map[string]any, out any) error {
+	for attempt := 0; ; attempt++ {
+		token, err := c.accessToken(ctx)
+		if err != nil {
+			return err
+		}
+		in["token"] = token
+		err = c.post(ctx, op, in, out)
+		var pe *secrets.ErrProvider
+		if attempt == 0 && errors.As(err, &pe) && pe.StatusCode == http.StatusUnauthorized {
+			c.mu.Lock()
+			if c.token == token {
+				c.token = ""
+			}
+			c.mu.Unlock()
+			continue
+		}
+		return err
+	}
+}
+
+// accessToken returns the cached token, authenticating if there is none.
+func (c *apiClient) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" {
+		return c.token, nil
+	}
+	in := map[string]any{"access-id": c.auth.accessID, "access-type": c.auth.accessType}
+	if c.auth.cloudID != nil {
+		id, err := c.auth.cloudID(ctx, c.auth, c.http)
+		if err != nil {
+			return "", fmt.Errorf("authenticate: %s cloud identity: %w", c.auth.accessType, err)
+		}
+		in["cloud-id"] = id
+	} else {
+		in["access-key"] = c.auth.accessKey
+	}
+	var out struct {
+		Token string `json:"token"`
+	}
+	if err := c.post(ctx, "/auth", in, &out); err != nil {
+		return "", fmt.Errorf("authenticate: %w", err)
+	}
+	if out.Token == "" {
+		return "", errors.New("authenticate: response has no token")
+	}
+	c.token = out.Token
+	return c.token, nil
+}
+
+// post sends the JSON body in to the operation op and decodes the JSON
+// response into out.
+func (c *apiClient) post(ctx context.Context, op string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+op, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if ua := secrets.UserAgentFromContext(ctx); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// apiError returns the error described by a failed response:
+// secrets.ErrNotFound for 404, and a *secrets.ErrProvider otherwise.
+func apiError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	msg := resp.Status
+	if json.Unmarshal(data, &body) == nil {
+		if body.Message != "" {
+			msg = body.Message
+		} else if body.Error != "" {
+			msg = body.Error
+		}
+	} else if s := string(bytes.TrimSpace(data)); s != "" {
+		msg = s
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", msg, secrets.ErrNotFound)
+	}
+	pe := &secrets.ErrProvider{StatusCode: resp.StatusCode, Err: errors.New(msg)}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		pe.RetryAfter = time.Duration(s) * time.Second
+	}
+	return pe
+}
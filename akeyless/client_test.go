@@ -0,0 +1,119 @@
+package akeyless
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// fakeAPI fakes the Akeyless API for the access ID p-123 with access key
+// "key", issuing tokens t1, t2, ... of which only the latest is valid.
+type fakeAPI struct {
+	*httptest.Server
+	logins atomic.Int64
+}
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	f := &fakeAPI{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]any
+		json.NewDecoder(r.Body).Decode(&in)
+		if r.URL.Path == "/auth" {
+			if in["access-id"] != "p-123" || (in["access-key"] != "key" && in["cloud-id"] == nil) {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error": "access denied"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"token": "t%d"}`, f.logins.Add(1))
+			return
+		}
+		if in["token"] != fmt.Sprintf("t%d", f.logins.Load()) {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "token expired"}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/get-secret-value":
+			if names, _ := in["names"].([]any); len(names) == 1 && names[0] == "/prod/api-key" {
+				fmt.Fprint(w, `{"/prod/api-key": "k1"}`)
+				return
+			}
+		case "/get-dynamic-secret-value":
+			if in["name"] == "/prod/db" {
+				fmt.Fprint(w, `{"user": "tmp-1", "password": "p", "ttl_in_minutes": "60"}`)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": "item not found"}`)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestAPIClient(t *testing.T) {
+	api := newFakeAPI(t)
+	p, err := New(WithGatewayURL(api.URL+"/"), WithAPIKey("p-123", "key"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	got, err := p.Get(ctx, "prod/api-key")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != "k1" {
+		t.Errorf("Get = %q, want k1", got)
+	}
+	if _, err := p.Get(ctx, "prod/missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("missing secret: got %v, want ErrNotFound", err)
+	}
+	if n := api.logins.Load(); n != 1 {
+		t.Errorf("logins = %d, want 1", n)
+	}
+
+	// Expire the token: the client authenticates again and retries.
+	api.logins.Add(1)
+	if _, err := p.Get(ctx, "prod/api-key"); err != nil {
+		t.Fatalf("Get after expiry: %v", err)
+	}
+	if n := api.logins.Load(); n != 3 {
+		t.Errorf("logins = %d, want 3", n)
+	}
+}
+
+func TestAPIClient_Dynamic(t *testing.T) {
+	api := newFakeAPI(t)
+	p, err := New(WithGatewayURL(api.URL), WithAPIKey("p-123", "key"), WithDynamicSecrets())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got, err := p.Get(context.Background(), "prod/db")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	var creds map[string]string
+	if err := json.Unmarshal(got, &creds); err != nil || creds["user"] != "tmp-1" {
+		t.Errorf("Get = %s, %v; want credentials for tmp-1", got, err)
+	}
+}
+
+func TestAPIClient_AuthError(t *testing.T) {
+	api := newFakeAPI(t)
+	p, err := New(WithGatewayURL(api.URL), WithAPIKey("p-123", "wrong"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = p.Get(context.Background(), "prod/api-key")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.StatusCode != http.StatusUnauthorized || pe.Err.Error() != "access denied" {
+		t.Errorf("got %v, want a 401 ErrProvider", err)
+	}
+}
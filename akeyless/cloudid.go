@@ -0,0 +1,127 @@
+package akeyless
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// Endpoints the cloud identities are obtained from, variables for testing.
+var (
+	stsURL         = "https://sts.amazonaws.com/"
+	azureIMDSURL   = "http://169.254.169.254/metadata/identity/oauth2/token"
+	gcpMetadataURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/identity"
+
+	loadAWSCredentials = func(ctx context.Context) (aws.CredentialsProvider, error) {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return cfg.Credentials, nil
+	}
+)
+
+// awsCloudID returns a signed STS GetCallerIdentity request, which Akeyless
+// sends to AWS to learn the caller's IAM identity, encoded as Akeyless
+// expects.
+func awsCloudID(ctx context.Context, _ *authMethod, _ *http.Client) (string, error) {
+	provider, err := loadAWSCredentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	creds, err := provider.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+	body := "Action=GetCallerIdentity&Version=2011-06-15"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, stsURL, strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	hash := sha256.Sum256([]byte(body))
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "sts", "us-east-1", time.Now()); err != nil {
+		return "", err
+	}
+	headers, err := json.Marshal(req.Header)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(map[string]string{
+		"sts_request_method":  req.Method,
+		"sts_request_url":     base64.StdEncoding.EncodeToString([]byte(req.URL.String())),
+		"sts_request_body":    base64.StdEncoding.EncodeToString([]byte(body)),
+		"sts_request_headers": base64.StdEncoding.EncodeToString(headers),
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// azureCloudID returns a managed identity access token for Azure Resource
+// Manager from the instance metadata service.
+func azureCloudID(ctx context.Context, _ *authMethod, c *http.Client) (string, error) {
+	query := url.Values{"api-version": {"2018-02-01"}, "resource": {"https://management.azure.com/"}}
+	data, err := metadata(ctx, c, azureIMDSURL+"?"+query.Encode(), "Metadata", "true")
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return "", err
+	}
+	if out.AccessToken == "" {
+		return "", errors.New("response has no access token")
+	}
+	return base64.StdEncoding.EncodeToString([]byte(out.AccessToken)), nil
+}
+
+// gcpCloudID returns an ID token of the instance's service account from the
+// metadata server.
+func gcpCloudID(ctx context.Context, a *authMethod, c *http.Client) (string, error) {
+	query := url.Values{"audience": {a.gcpAudience}, "format": {"full"}}
+	token, err := metadata(ctx, c, gcpMetadataURL+"?"+query.Encode(), "Metadata-Flavor", "Google")
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(token), nil
+}
+
+// metadata sends a GET request to a metadata service with the header it
+// requires and returns the response body.
+func metadata(ctx context.Context, c *http.Client, u, header, value string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(header, value)
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata service: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
@@ -0,0 +1,124 @@
+package akeyless
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// decode returns the base64-decoded s.
+func decode(t *testing.T, s string) string {
+	t.Helper()
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decode %q: %v", s, err)
+	}
+	return string(b)
+}
+
+func TestAWSCloudID(t *testing.T) {
+	orig := loadAWSCredentials
+	t.Cleanup(func() { loadAWSCredentials = orig })
+	loadAWSCredentials = func(context.Context) (aws.CredentialsProvider, error) {
+		return credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""), nil
+	}
+
+	id, err := awsCloudID(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("awsCloudID: %v", err)
+	}
+	var data map[string]string
+	if err := json.Unmarshal([]byte(decode(t, id)), &data); err != nil {
+		t.Fatalf("cloud ID is not JSON: %v", err)
+	}
+	if data["sts_request_method"] != "POST" || decode(t, data["sts_request_url"]) != stsURL {
+		t.Errorf("request = %s %s", data["sts_request_method"], decode(t, data["sts_request_url"]))
+	}
+	if body := decode(t, data["sts_request_body"]); body != "Action=GetCallerIdentity&Version=2011-06-15" {
+		t.Errorf("body = %q", body)
+	}
+	var headers http.Header
+	if err := json.Unmarshal([]byte(decode(t, data["sts_request_headers"])), &headers); err != nil {
+		t.Fatalf("headers: %v", err)
+	}
+	if auth := headers.Get("Authorization"); !strings.Contains(auth, "Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/us-east-1/sts/") {
+		t.Errorf("Authorization = %q, want a SigV4 signature for sts in us-east-1", auth)
+	}
+}
+
+func TestAzureCloudID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("resource") != "https://management.azure.com/" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token": "eyJ.azure"}`))
+	}))
+	defer srv.Close()
+	orig := azureIMDSURL
+	t.Cleanup(func() { azureIMDSURL = orig })
+	azureIMDSURL = srv.URL
+
+	id, err := azureCloudID(context.Background(), nil, srv.Client())
+	if err != nil {
+		t.Fatalf("azureCloudID: %v", err)
+	}
+	if got := decode(t, id); got != "eyJ.azure" {
+		t.Errorf("cloud ID = %q, want the encoded access token", got)
+	}
+}
+
+func TestGCPCloudID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" || r.URL.Query().Get("audience") != "akeyless.io" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("eyJ.gcp"))
+	}))
+	defer srv.Close()
+	orig := gcpMetadataURL
+	t.Cleanup(func() { gcpMetadataURL = orig })
+	gcpMetadataURL = srv.URL
+
+	id, err := gcpCloudID(context.Background(), &authMethod{gcpAudience: "akeyless.io"}, srv.Client())
+	if err != nil {
+		t.Fatalf("gcpCloudID: %v", err)
+	}
+	if got := decode(t, id); got != "eyJ.gcp" {
+		t.Errorf("cloud ID = %q, want the encoded ID token", got)
+	}
+
+	if _, err := gcpCloudID(context.Background(), &authMethod{gcpAudience: "other"}, srv.Client()); err == nil {
+		t.Error("gcpCloudID succeeded despite a metadata server error")
+	}
+}
+
+func TestCloudIDAuth(t *testing.T) {
+	meta := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("eyJ.gcp"))
+	}))
+	defer meta.Close()
+	orig := gcpMetadataURL
+	t.Cleanup(func() { gcpMetadataURL = orig })
+	gcpMetadataURL = meta.URL
+
+	api := newFakeAPI(t)
+	p, err := New(WithGatewayURL(api.URL), WithGCP("p-123", ""))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := p.Get(context.Background(), "prod/api-key"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if n := api.logins.Load(); n != 1 {
+		t.Errorf("logins = %d, want 1", n)
+	}
+}
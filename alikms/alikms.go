@@ -0,0 +1,243 @@
+// Package alikms provides a secret provider that reads from Alibaba Cloud
+// KMS Secrets Manager.
+package alikms
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Client abstracts the Secrets Manager API of Alibaba Cloud KMS.
+// Implement this interface to provide a custom client or for testing.
+type Client interface {
+	// GetSecretValue returns the value of the version of secret name with ID
+	// versionID if it is not empty, or else the version in versionStage.
+	GetSecretValue(ctx context.Context, name, versionStage, versionID string) ([]byte, error)
+}
+
+// ProviderOption configures the alikms Provider.
+type ProviderOption func(*Provider)
+
+// WithRegion sets the region, such as "cn-hangzhou". Defaults to the
+// ALIBABA_CLOUD_REGION_ID environment variable.
+func WithRegion(region string) ProviderOption {
+	return func(p *Provider) {
+		p.region = region
+	}
+}
+
+// WithEndpoint sends requests to url instead of the public KMS endpoint of
+// the region, such as a VPC endpoint or a dedicated KMS instance.
+func WithEndpoint(url string) ProviderOption {
+	return func(p *Provider) {
+		p.endpoint = url
+	}
+}
+
+// WithAccessKey authenticates with an AccessKey pair, and securityToken if
+// the pair is temporary. Defaults to the ALIBABA_CLOUD_ACCESS_KEY_ID,
+// ALIBABA_CLOUD_ACCESS_KEY_SECRET, and ALIBABA_CLOUD_SECURITY_TOKEN
+// environment variables.
+func WithAccessKey(id, secret, securityToken string) ProviderOption {
+	return func(p *Provider) {
+		p.accessKeyID = id
+		p.accessKeySecret = secret
+		p.securityToken = securityToken
+	}
+}
+
+// WithECSRAMRole authenticates as the RAM role role attached to the ECS
+// instance, with credentials from the instance metadata service, refreshed
+// before they expire. Defaults to the ALIBABA_CLOUD_ECS_METADATA environment
+// variable when no AccessKey is given.
+func WithECSRAMRole(role string) ProviderOption {
+	return func(p *Provider) {
+		p.ecsRole = role
+	}
+}
+
+// WithAssumeRole authenticates as the RAM role roleARN, such as a role in a
+// central secrets account, with temporary credentials from STS AssumeRole
+// obtained with the AccessKey or ECS RAM role. They are refreshed before
+// they expire. Defaults to the ALIBABA_CLOUD_ROLE_ARN and
+// ALIBABA_CLOUD_ROLE_SESSION_NAME environment variables; sessionName
+// defaults to "go-secrets".
+func WithAssumeRole(roleARN, sessionName string) ProviderOption {
+	return func(p *Provider) {
+		p.roleARN = roleARN
+		p.sessionName = sessionName
+	}
+}
+
+// WithHTTPClient sets the HTTP client of the default client.
+func WithHTTPClient(c *http.Client) ProviderOption {
+	return func(p *Provider) {
+		p.httpClient = c
+	}
+}
+
+// WithClient injects a custom Client implementation.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider reads secrets from Alibaba Cloud KMS Secrets Manager.
+// It implements secrets.Provider and secrets.VersionedProvider.
+//
+// Keys are secret names. Binary secrets are returned decoded.
+type Provider struct {
+	region          string
+	endpoint        string
+	accessKeyID     string
+	accessKeySecret string
+	securityToken   string
+	ecsRole         string
+	roleARN         string
+	sessionName     string
+	httpClient      *http.Client
+	client          Client
+}
+
+// New creates a new Alibaba Cloud KMS Provider.
+// A region (or endpoint) and credentials are required when not providing a
+// custom Client via WithClient.
+func New(opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		if p.region == "" {
+			p.region = os.Getenv("ALIBABA_CLOUD_REGION_ID")
+		}
+		if p.endpoint == "" {
+			if p.region == "" {
+				return nil, errors.New("alikms: region is required (use WithRegion or ALIBABA_CLOUD_REGION_ID)")
+			}
+			p.endpoint = "https://kms." + p.region + ".aliyuncs.com"
+		}
+		if p.httpClient == nil {
+			p.httpClient = http.DefaultClient
+		}
+		creds, err := p.credentials()
+		if err != nil {
+			return nil, err
+		}
+		p.client = &rpcClientAdapter{&rpcClient{
+			endpoint: p.endpoint,
+			version:  "2016-01-20",
+			creds:    creds,
+			http:     p.httpClient,
+		}}
+	}
+	return p, nil
+}
+
+// credentials returns the credentials provider the options and environment
+// configure.
+func (p *Provider) credentials() (credentialsProvider, error) {
+	if p.accessKeyID == "" && p.ecsRole == "" {
+		p.accessKeyID = os.Getenv("ALIBABA_CLOUD_ACCESS_KEY_ID")
+		p.accessKeySecret = os.Getenv("ALIBABA_CLOUD_ACCESS_KEY_SECRET")
+		p.securityToken = os.Getenv("ALIBABA_CLOUD_SECURITY_TOKEN")
+		if p.accessKeyID == "" {
+			p.ecsRole = os.Getenv("ALIBABA_CLOUD_ECS_METADATA")
+		}
+	}
+	var base credentialsProvider
+	switch {
+	case p.accessKeyID != "":
+		base = staticCredentials{AccessKeyID: p.accessKeyID, AccessKeySecret: p.accessKeySecret, SecurityToken: p.securityToken}
+	case p.ecsRole != "":
+		base = ecsRAMRole(p.httpClient, p.ecsRole)
+	default:
+		return nil, errors.New("alikms: credentials are required (use WithAccessKey or WithECSRAMRole)")
+	}
+	if p.roleARN == "" {
+		p.roleARN = os.Getenv("ALIBABA_CLOUD_ROLE_ARN")
+		if p.sessionName == "" {
+			p.sessionName = os.Getenv("ALIBABA_CLOUD_ROLE_SESSION_NAME")
+		}
+	}
+	if p.roleARN == "" {
+		return base, nil
+	}
+	if p.sessionName == "" {
+		p.sessionName = "go-secrets"
+	}
+	return assumeRole(p.httpClient, base, p.roleARN, p.sessionName), nil
+}
+
+// versionStage maps user-facing version strings to Secrets Manager version
+// stages.
+var versionStage = map[string]string{
+	"current":  "ACSCurrent",
+	"previous": "ACSPrevious",
+	"pending":  "ACSPending",
+}
+
+// Get retrieves the current version of the secret.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.GetVersion(ctx, key, "current")
+}
+
+// GetVersion retrieves a version of the secret. version is one of:
+//   - "current" (ACSCurrent), "previous" (ACSPrevious), or "pending"
+//     (ACSPending);
+//   - "id:" and a version ID, to pin an exact version;
+//   - any other version stage, such as a custom "rollback" stage.
+//
+// Returns secrets.ErrNotFound (wrapped) if the secret or version does not
+// exist.
+func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	if version == "" {
+		return nil, fmt.Errorf("alikms: secret %q: unsupported version %q", key, version)
+	}
+	var stage, id string
+	if v, ok := strings.CutPrefix(version, "id:"); ok {
+		id = v
+	} else if stage, ok = versionStage[version]; !ok {
+		stage = version
+	}
+	val, err := p.client.GetSecretValue(ctx, key, stage, id)
+	if err != nil {
+		if version == "current" {
+			return nil, fmt.Errorf("alikms: secret %q: %w", key, err)
+		}
+		return nil, fmt.Errorf("alikms: secret %q version %q: %w", key, version, err)
+	}
+	return val, nil
+}
+
+// rpcClientAdapter implements Client with the GetSecretValue RPC action.
+type rpcClientAdapter struct {
+	rpc *rpcClient
+}
+
+func (c *rpcClientAdapter) GetSecretValue(ctx context.Context, name, versionStage, versionID string) ([]byte, error) {
+	params := map[string]string{"SecretName": name}
+	if versionID != "" {
+		params["VersionId"] = versionID
+	} else {
+		params["VersionStage"] = versionStage
+	}
+	var out struct {
+		SecretData     string `json:"SecretData"`
+		SecretDataType string `json:"SecretDataType"` // "text" or "binary"
+	}
+	if err := c.rpc.call(ctx, "GetSecretValue", params, &out); err != nil {
+		return nil, err
+	}
+	if out.SecretDataType == "binary" {
+		return base64.StdEncoding.DecodeString(out.SecretData)
+	}
+	return []byte(out.SecretData), nil
+}
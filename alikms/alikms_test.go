@@ -0,0 +1,132 @@
+package alikms
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// fakeClient serves versions of secrets by name and stage or version ID.
+type fakeClient struct {
+	versions map[string]string // name@stage or name#id -> value
+}
+
+func (c *fakeClient) GetSecretValue(_ context.Context, name, versionStage, versionID string) ([]byte, error) {
+	key := name + "@" + versionStage
+	if versionID != "" {
+		key = name + "#" + versionID
+	}
+	v, ok := c.versions[key]
+	if !ok {
+		return nil, secrets.ErrNotFound
+	}
+	return []byte(v), nil
+}
+
+func TestGetVersion(t *testing.T) {
+	p, err := New(WithClient(&fakeClient{versions: map[string]string{
+		"db@ACSCurrent":  "pw-2",
+		"db@ACSPrevious": "pw-1",
+		"db@ACSPending":  "pw-3",
+		"db@rollback":    "pw-0",
+		"db#v1":          "pw-1",
+	}}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	tests := map[string]string{
+		"current":  "pw-2",
+		"previous": "pw-1",
+		"pending":  "pw-3",
+		"rollback": "pw-0",
+		"id:v1":    "pw-1",
+	}
+	for version, want := range tests {
+		got, err := p.GetVersion(ctx, "db", version)
+		if err != nil || string(got) != want {
+			t.Errorf("GetVersion(db, %s) = %q, %v; want %q", version, got, err, want)
+		}
+	}
+	if got, err := p.Get(ctx, "db"); err != nil || string(got) != "pw-2" {
+		t.Errorf("Get(db) = %q, %v; want pw-2", got, err)
+	}
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, err := p.GetVersion(ctx, "db", "id:v9"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("GetVersion(id:v9) error = %v, want ErrNotFound", err)
+	}
+	if _, err := p.GetVersion(ctx, "db", ""); err == nil {
+		t.Error("GetVersion with an empty version succeeded")
+	}
+}
+
+func TestNew_Config(t *testing.T) {
+	t.Setenv("ALIBABA_CLOUD_REGION_ID", "")
+	t.Setenv("ALIBABA_CLOUD_ACCESS_KEY_ID", "")
+	t.Setenv("ALIBABA_CLOUD_ECS_METADATA", "")
+	t.Setenv("ALIBABA_CLOUD_ROLE_ARN", "")
+	if _, err := New(WithAccessKey("ak", "sk", "")); err == nil {
+		t.Error("New without a region succeeded")
+	}
+	if _, err := New(WithRegion("cn-hangzhou")); err == nil {
+		t.Error("New without credentials succeeded")
+	}
+
+	t.Setenv("ALIBABA_CLOUD_REGION_ID", "cn-shanghai")
+	t.Setenv("ALIBABA_CLOUD_ACCESS_KEY_ID", "ak")
+	t.Setenv("ALIBABA_CLOUD_ACCESS_KEY_SECRET", "sk")
+	p, err := New()
+	if err != nil {
+		t.Fatalf("New from the environment: %v", err)
+	}
+	if p.endpoint != "https://kms.cn-shanghai.aliyuncs.com" {
+		t.Errorf("endpoint = %q", p.endpoint)
+	}
+}
+
+func TestRPCClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("Action") != "GetSecretValue" || q.Get("Version") != "2016-01-20" {
+			t.Errorf("query = %v", q)
+		}
+		switch {
+		case q.Get("SecretName") == "cert" && q.Get("VersionStage") == "ACSCurrent":
+			fmt.Fprintf(w, `{"SecretData": %q, "SecretDataType": "binary"}`, base64.StdEncoding.EncodeToString([]byte{0, 1, 2}))
+		case q.Get("SecretName") == "db" && q.Get("VersionId") == "v1" && !q.Has("VersionStage"):
+			fmt.Fprint(w, `{"SecretData": "pw-1", "SecretDataType": "text"}`)
+		case q.Get("SecretName") == "db" && q.Get("VersionStage") == "ACSCurrent":
+			fmt.Fprint(w, `{"SecretData": "pw-2", "SecretDataType": "text"}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"Code": "Forbidden.ResourceNotFound", "Message": "The resource not exists."}`)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := New(WithEndpoint(srv.URL), WithAccessKey("ak", "sk", ""), WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	if got, err := p.Get(ctx, "db"); err != nil || string(got) != "pw-2" {
+		t.Errorf("Get(db) = %q, %v; want pw-2", got, err)
+	}
+	if got, err := p.GetVersion(ctx, "db", "id:v1"); err != nil || string(got) != "pw-1" {
+		t.Errorf("GetVersion(db, id:v1) = %q, %v; want pw-1", got, err)
+	}
+	if got, err := p.Get(ctx, "cert"); err != nil || string(got) != "\x00\x01\x02" {
+		t.Errorf("Get(cert) = %q, %v; want the decoded binary", got, err)
+	}
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,132 @@
+package alikms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Endpoints credentials are obtained from, variables for testing.
+var (
+	stsEndpoint    = "https://sts.aliyuncs.com"
+	ecsMetadataURL = "http://100.100.100.200/latest/meta-data/ram/security-credentials/"
+)
+
+// credentials are an AccessKey pair, with a security token if they are
+// temporary.
+type credentials struct {
+	AccessKeyID     string
+	AccessKeySecret string
+	SecurityToken   string
+	Expiration      time.Time // zero for long-lived credentials
+}
+
+// credentialsProvider returns the credentials to sign requests with.
+type credentialsProvider interface {
+	credentials(ctx context.Context) (credentials, error)
+}
+
+// staticCredentials are credentials that never change.
+type staticCredentials credentials
+
+func (c staticCredentials) credentials(context.Context) (credentials, error) {
+	return credentials(c), nil
+}
+
+// cachedCredentials caches temporary credentials from fetch, fetching new
+// ones five minutes before they expire.
+type cachedCredentials struct {
+	fetch func(ctx context.Context) (credentials, error)
+
+	mu    sync.Mutex
+	creds credentials
+}
+
+func (c *cachedCredentials) credentials(ctx context.Context) (credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.creds.AccessKeyID != "" && time.Until(c.creds.Expiration) > 5*time.Minute {
+		return c.creds, nil
+	}
+	creds, err := c.fetch(ctx)
+	if err != nil {
+		return credentials{}, err
+	}
+	c.creds = creds
+	return creds, nil
+}
+
+// ecsRAMRole returns the credentials of the RAM role attached to the ECS
+// instance, from the instance metadata service.
+func ecsRAMRole(hc *http.Client, role string) credentialsProvider {
+	return &cachedCredentials{fetch: func(ctx context.Context) (credentials, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ecsMetadataURL+url.PathEscape(role), nil)
+		if err != nil {
+			return credentials{}, err
+		}
+		resp, err := hc.Do(req)
+		if err != nil {
+			return credentials{}, fmt.Errorf("ECS RAM role %q: %w", role, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return credentials{}, fmt.Errorf("ECS RAM role %q: %w", role, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return credentials{}, fmt.Errorf("ECS RAM role %q: %s", role, resp.Status)
+		}
+		var out struct {
+			Code            string `json:"Code"`
+			AccessKeyID     string `json:"AccessKeyId"`
+			AccessKeySecret string `json:"AccessKeySecret"`
+			SecurityToken   string `json:"SecurityToken"`
+			Expiration      time.Time
+		}
+		if err := json.Unmarshal(body, &out); err != nil {
+			return credentials{}, fmt.Errorf("ECS RAM role %q: %w", role, err)
+		}
+		if out.Code != "Success" || out.AccessKeyID == "" {
+			return credentials{}, fmt.Errorf("ECS RAM role %q: metadata service returned %q", role, out.Code)
+		}
+		return credentials{out.AccessKeyID, out.AccessKeySecret, out.SecurityToken, out.Expiration}, nil
+	}}
+}
+
+// assumeRole returns temporary credentials for the RAM role roleARN,
+// obtained with STS AssumeRole using the credentials of base.
+func assumeRole(hc *http.Client, base credentialsProvider, roleARN, sessionName string) credentialsProvider {
+	return &cachedCredentials{fetch: func(ctx context.Context) (credentials, error) {
+		creds, err := base.credentials(ctx)
+		if err != nil {
+			return credentials{}, err
+		}
+		params := map[string]string{
+			"RoleArn":         roleARN,
+			"RoleSessionName": sessionName,
+			"DurationSeconds": "3600",
+		}
+		var out struct {
+			Credentials struct {
+				AccessKeyID     string `json:"AccessKeyId"`
+				AccessKeySecret string `json:"AccessKeySecret"`
+				SecurityToken   string `json:"SecurityToken"`
+				Expiration      time.Time
+			} `json:"Credentials"`
+		}
+		if err := callRPC(ctx, hc, stsEndpoint, "2015-04-01", "AssumeRole", params, creds, &out); err != nil {
+			return credentials{}, fmt.Errorf("assume role %q: %w", roleARN, err)
+		}
+		c := out.Credentials
+		if c.AccessKeyID == "" {
+			return credentials{}, errors.New("assume role: response has no credentials")
+		}
+		return credentials{c.AccessKeyID, c.AccessKeySecret, c.SecurityToken, c.Expiration}, nil
+	}}
+}
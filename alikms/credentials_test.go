@@ -0,0 +1,92 @@
+package alikms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestECSRAMRole(t *testing.T) {
+	var fetches atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/app-role" {
+			http.NotFound(w, r)
+			return
+		}
+		n := fetches.Add(1)
+		fmt.Fprintf(w, `{"Code": "Success", "AccessKeyId": "STS.ak%d", "AccessKeySecret": "sk", "SecurityToken": "tok", "Expiration": %q}`,
+			n, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer srv.Close()
+	old := ecsMetadataURL
+	ecsMetadataURL = srv.URL + "/"
+	defer func() { ecsMetadataURL = old }()
+
+	cp := ecsRAMRole(srv.Client(), "app-role")
+	for range 2 {
+		c, err := cp.credentials(context.Background())
+		if err != nil {
+			t.Fatalf("credentials: %v", err)
+		}
+		if c.AccessKeyID != "STS.ak1" || c.SecurityToken != "tok" {
+			t.Errorf("credentials = %+v", c)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetches = %d, want 1 (cached)", n)
+	}
+
+	if _, err := ecsRAMRole(srv.Client(), "other").credentials(context.Background()); err == nil {
+		t.Error("credentials of an unattached role succeeded")
+	}
+}
+
+func TestCachedCredentials_Refresh(t *testing.T) {
+	var fetches int
+	cp := &cachedCredentials{fetch: func(context.Context) (credentials, error) {
+		fetches++
+		// Expires within the refresh window, so each call fetches again.
+		return credentials{AccessKeyID: "ak", Expiration: time.Now().Add(time.Minute)}, nil
+	}}
+	cp.credentials(context.Background())
+	cp.credentials(context.Background())
+	if fetches != 2 {
+		t.Errorf("fetches = %d, want 2", fetches)
+	}
+}
+
+func TestAssumeRole(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("Action") != "AssumeRole" || q.Get("AccessKeyId") != "base" {
+			t.Errorf("query = %v", q)
+		}
+		if q.Get("RoleArn") != "acs:ram::123:role/secrets" || q.Get("RoleSessionName") != "app" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"Code": "NoPermission", "Message": "denied"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"Credentials": {"AccessKeyId": "STS.assumed", "AccessKeySecret": "sk", "SecurityToken": "tok", "Expiration": %q}}`,
+			time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer srv.Close()
+	old := stsEndpoint
+	stsEndpoint = srv.URL
+	defer func() { stsEndpoint = old }()
+
+	base := staticCredentials{AccessKeyID: "base", AccessKeySecret: "secret"}
+	c, err := assumeRole(srv.Client(), base, "acs:ram::123:role/secrets", "app").credentials(context.Background())
+	if err != nil {
+		t.Fatalf("credentials: %v", err)
+	}
+	if c.AccessKeyID != "STS.assumed" || c.SecurityToken != "tok" {
+		t.Errorf("credentials = %+v", c)
+	}
+	if _, err := assumeRole(srv.Client(), base, "acs:ram::123:role/other", "app").credentials(context.Background()); err == nil {
+		t.Error("assuming a forbidden role succeeded")
+	}
+}
@@ -0,0 +1,143 @@
+package alikms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// rpcClient calls Alibaba Cloud RPC-style APIs, signed with signature
+// version 1.0 (HMAC-SHA1).
+type rpcClient struct {
+	endpoint string // such as "https://kms.cn-hangzhou.aliyuncs.com"
+	version  string // API version, such as "2016-01-20"
+	creds    credentialsProvider
+	http     *http.Client
+}
+
+// call sends action with params and decodes the JSON response into out.
+func (c *rpcClient) call(ctx context.Context, action string, params map[string]string, out any) error {
+	creds, err := c.creds.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	return callRPC(ctx, c.http, c.endpoint, c.version, action, params, creds, out)
+}
+
+// callRPC sends a signed RPC request to endpoint.
+func callRPC(ctx context.Context, hc *http.Client, endpoint, version, action string, params map[string]string, creds credentials, out any) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	query := url.Values{
+		"Action":           {action},
+		"Format":           {"JSON"},
+		"Version":          {version},
+		"AccessKeyId":      {creds.AccessKeyID},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureVersion": {"1.0"},
+		"SignatureNonce":   {hex.EncodeToString(nonce)},
+		"Timestamp":        {time.Now().UTC().Format("2006-01-02T15:04:05Z")},
+	}
+	if creds.SecurityToken != "" {
+		query.Set("SecurityToken", creds.SecurityToken)
+	}
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	query.Set("Signature", sign(http.MethodGet, query, creds.AccessKeySecret))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/?"+canonicalQuery(query), nil)
+	if err != nil {
+		return err
+	}
+	if ua := secrets.UserAgentFromContext(ctx); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return rpcError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// sign returns the signature of a request with query: the base64 HMAC-SHA1,
+// keyed with the secret and "&", of the method, "/", and the canonical query,
+// each percent-encoded and joined with "&".
+func sign(method string, query url.Values, secret string) string {
+	stringToSign := method + "&" + percentEncode("/") + "&" + percentEncode(canonicalQuery(query))
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// canonicalQuery returns query sorted by name and percent-encoded as the
+// signature requires.
+func canonicalQuery(query url.Values) string {
+	var b strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(query)) {
+		if b.Len() > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(percentEncode(k) + "=" + percentEncode(query.Get(k)))
+	}
+	return b.String()
+}
+
+// percentEncode encodes s as RFC 3986 requires: everything but letters,
+// digits, '-', '_', '.', and '~'.
+func percentEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	return strings.ReplaceAll(s, "%7E", "~")
+}
+
+// rpcError returns the error described by a failed response:
+// secrets.ErrNotFound for Forbidden.ResourceNotFound and other 404s, and a
+// *secrets.ErrProvider otherwise.
+func rpcError(resp *http.Response) error {
+	var body struct {
+		Code      string `json:"Code"`
+		Message   string `json:"Message"`
+		RequestID string `json:"RequestId"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	msg := body.Message
+	if msg == "" {
+		msg = resp.Status
+	}
+	if resp.StatusCode == http.StatusNotFound || body.Code == "Forbidden.ResourceNotFound" {
+		return fmt.Errorf("%s: %w", msg, secrets.ErrNotFound)
+	}
+	pe := &secrets.ErrProvider{
+		StatusCode: resp.StatusCode,
+		Code:       body.Code,
+		RequestID:  body.RequestID,
+		Err:        errors.New(msg),
+	}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		pe.RetryAfter = time.Duration(s) * time.Second
+	}
+	return pe
+}
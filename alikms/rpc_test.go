@@ -0,0 +1,113 @@
+package alikms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+func TestSign(t *testing.T) {
+	// The example request from the Alibaba Cloud signature documentation.
+	query := url.Values{
+		"AccessKeyId":      {"testid"},
+		"Action":           {"DescribeRegions"},
+		"Format":           {"XML"},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureNonce":   {"3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf"},
+		"SignatureVersion": {"1.0"},
+		"Timestamp":        {"2016-02-23T12:46:24Z"},
+		"Version":          {"2014-05-26"},
+	}
+	if got, want := sign(http.MethodGet, query, "testsecret"), "OLeaidS1JvxuMvnyHOwuJ+uX5qY="; got != want {
+		t.Errorf("sign = %q, want %q", got, want)
+	}
+}
+
+func TestPercentEncode(t *testing.T) {
+	tests := map[string]string{
+		"a-b_c.d~e": "a-b_c.d~e",
+		"a b":       "a%20b",
+		"a*b":       "a%2Ab",
+		"a+b/c=":    "a%2Bb%2Fc%3D",
+		"ü":         "%C3%BC",
+	}
+	for in, want := range tests {
+		if got := percentEncode(in); got != want {
+			t.Errorf("percentEncode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCallRPC(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		sig := q.Get("Signature")
+		q.Del("Signature")
+		if want := sign(http.MethodGet, q, "sk"); sig != want {
+			t.Errorf("Signature = %q, want %q", sig, want)
+		}
+		if q.Get("AccessKeyId") != "ak" || q.Get("SecurityToken") != "tok" || q.Get("Action") != "Echo" || q.Get("Name") != "a b" {
+			t.Errorf("query = %v", q)
+		}
+		w.Write([]byte(`{"Name": "` + q.Get("Name") + `"}`))
+	}))
+	defer srv.Close()
+
+	var out struct{ Name string }
+	creds := credentials{AccessKeyID: "ak", AccessKeySecret: "sk", SecurityToken: "tok"}
+	if err := callRPC(context.Background(), srv.Client(), srv.URL, "2016-01-20", "Echo", map[string]string{"Name": "a b"}, creds, &out); err != nil {
+		t.Fatalf("callRPC: %v", err)
+	}
+	if out.Name != "a b" {
+		t.Errorf("Name = %q, want %q", out.Name, "a b")
+	}
+}
+
+func TestRPCError(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusBadRequest, `{"Code": "Forbidden.ResourceNotFound", "Message": "no such secret"}`},
+		{http.StatusNotFound, `{}`},
+		{http.StatusForbidden, `{"Code": "Forbidden.NoPermission", "Message": "denied", "RequestId": "req-1"}`},
+		{http.StatusServiceUnavailable, `{"Code": "Throttling", "Message": "slow down"}`},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		err := callRPC(context.Background(), srv.Client(), srv.URL, "v", "Get", nil, credentials{AccessKeyID: "ak"}, &struct{}{})
+		srv.Close()
+
+		var pe *secrets.ErrProvider
+		switch {
+		case tt.status == http.StatusNotFound || tt.status == http.StatusBadRequest:
+			if !errors.Is(err, secrets.ErrNotFound) {
+				t.Errorf("status %d: error = %v, want ErrNotFound", tt.status, err)
+			}
+		case !errors.As(err, &pe):
+			t.Errorf("status %d: error = %v, want ErrProvider", tt.status, err)
+		case pe.StatusCode != tt.status || pe.RetryAfter != 3*time.Second:
+			t.Errorf("status %d: ErrProvider = %+v", tt.status, pe)
+		}
+	}
+	var pe *secrets.ErrProvider
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"Code": "Forbidden.NoPermission", "Message": "denied", "RequestId": "req-1"}`))
+	}))
+	defer srv.Close()
+	err := callRPC(context.Background(), srv.Client(), srv.URL, "v", "Get", nil, credentials{AccessKeyID: "ak"}, &struct{}{})
+	if !errors.As(err, &pe) || pe.Code != "Forbidden.NoPermission" || pe.RequestID != "req-1" {
+		t.Errorf("error = %v, want ErrProvider with code and request ID", err)
+	}
+}
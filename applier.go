@@ -0,0 +1,196 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Resource is a consumer of secret values registered with an Applier, such as
+// a database pool, a TLS listener, or a JWT signer.
+type Resource struct {
+	// Name identifies the resource in DependsOn lists and errors.
+	Name string
+	// Fields lists the struct fields (as reported in ChangeEvent.Field) whose
+	// changes the resource must react to.
+	Fields []string
+	// DependsOn lists resources that must be updated before this one. A
+	// resource is also updated whenever one of its dependencies is.
+	DependsOn []string
+	// Update applies new secret values. changes holds the events for the
+	// resource's own Fields and is empty if the update was triggered only by a
+	// dependency.
+	Update func(ctx context.Context, changes []ChangeEvent) error
+}
+
+// Applier turns change events into ordered Update calls on the resources
+// that depend on the changed fields. Resources are updated in dependency
+// order, so that, for example, a connection pool is rebuilt only after the
+// credentials provider it reads from has been updated. If a resource's
+// Update fails, the resources that depend on it are skipped.
+//
+// Applier is safe for concurrent use; Apply calls are serialized.
+type Applier struct {
+	mu        sync.Mutex
+	resources []*Resource
+}
+
+// NewApplier returns an Applier with no resources.
+func NewApplier() *Applier {
+	return &Applier{}
+}
+
+// Register adds res to the Applier. It returns an error if res has no name or
+// Update function, or if a resource with the same name is already registered.
+// Dependencies may be registered in any order.
+func (a *Applier) Register(res Resource) error {
+	if res.Name == "" {
+		return errors.New("secrets: applier: resource has no name")
+	}
+	if res.Update == nil {
+		return fmt.Errorf("secrets: applier: resource %q has no Update function", res.Name)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lookup(res.Name) != nil {
+		return fmt.Errorf("secrets: applier: resource %q already registered", res.Name)
+	}
+	a.resources = append(a.resources, &res)
+	return nil
+}
+
+// Apply updates the resources affected by events, in dependency order. Errors
+// from Update functions are joined and returned; an unknown dependency or a
+// dependency cycle is reported before any resource is updated.
+func (a *Applier) Apply(ctx context.Context, events []ChangeEvent) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	order, err := a.order()
+	if err != nil {
+		return err
+	}
+
+	updated := make(map[string]bool)
+	failed := make(map[string]bool)
+	var errs []error
+	for _, res := range order {
+		var changes []ChangeEvent
+		for _, ev := range events {
+			if slices.Contains(res.Fields, ev.Field) {
+				changes = append(changes, ev)
+			}
+		}
+		triggered := len(changes) > 0
+		skip := false
+		for _, dep := range res.DependsOn {
+			triggered = triggered || updated[dep] || failed[dep]
+			skip = skip || failed[dep]
+		}
+		if !triggered {
+			continue
+		}
+		if skip {
+			failed[res.Name] = true
+			errs = append(errs, fmt.Errorf("secrets: applier: resource %q: skipped after dependency failed", res.Name))
+			continue
+		}
+		if err := res.Update(ctx, changes); err != nil {
+			failed[res.Name] = true
+			errs = append(errs, fmt.Errorf("secrets: applier: resource %q: %w", res.Name, err))
+			continue
+		}
+		updated[res.Name] = true
+	}
+	return errors.Join(errs...)
+}
+
+// Run applies events received from changes, typically Watcher.Changes(),
+// until the channel is closed or ctx is done. Events that arrive together
+// (from the same poll) are applied as one batch. Errors returned by Apply are
+// passed to onError, which may be nil.
+func (a *Applier) Run(ctx context.Context, changes <-chan ChangeEvent, onError func(error)) {
+	for {
+		var batch []ChangeEvent
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-changes:
+			if !ok {
+				return
+			}
+			batch = append(batch, ev)
+		}
+	more:
+		for {
+			select {
+			case ev, ok := <-changes:
+				if !ok {
+					break more
+				}
+				batch = append(batch, ev)
+			default:
+				break more
+			}
+		}
+		if err := a.Apply(ctx, batch); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// lookup returns the resource named name, or nil. Callers must hold a.mu.
+func (a *Applier) lookup(name string) *Resource {
+	for _, res := range a.resources {
+		if res.Name == name {
+			return res
+		}
+	}
+	return nil
+}
+
+// order returns the resources sorted so that every resource follows its
+// dependencies, keeping registration order otherwise. Callers must hold a.mu.
+func (a *Applier) order() ([]*Resource, error) {
+	for _, res := range a.resources {
+		for _, dep := range res.DependsOn {
+			if a.lookup(dep) == nil {
+				return nil, fmt.Errorf("secrets: applier: resource %q depends on unknown resource %q", res.Name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	order := make([]*Resource, 0, len(a.resources))
+	var visit func(res *Resource) error
+	visit = func(res *Resource) error {
+		switch state[res.Name] {
+		case visiting:
+			return fmt.Errorf("secrets: applier: dependency cycle through resource %q", res.Name)
+		case visited:
+			return nil
+		}
+		state[res.Name] = visiting
+		for _, dep := range res.DependsOn {
+			if err := visit(a.lookup(dep)); err != nil {
+				return err
+			}
+		}
+		state[res.Name] = visited
+		order = append(order, res)
+		return nil
+	}
+	for _, res := range a.resources {
+		if err := visit(res); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
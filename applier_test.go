@@ -0,0 +1,179 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingApplier registers resources that append their name to calls.
+type recordingApplier struct {
+	*Applier
+	mu    sync.Mutex
+	calls []string
+	fail  map[string]error
+}
+
+func newRecordingApplier() *recordingApplier {
+	return &recordingApplier{Applier: NewApplier(), fail: make(map[string]error)}
+}
+
+func (a *recordingApplier) add(t *testing.T, name string, fields []string, dependsOn ...string) {
+	t.Helper()
+	err := a.Register(Resource{
+		Name:      name,
+		Fields:    fields,
+		DependsOn: dependsOn,
+		Update: func(_ context.Context, changes []ChangeEvent) error {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			a.calls = append(a.calls, name)
+			return a.fail[name]
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register(%q): %v", name, err)
+	}
+}
+
+func TestApplier_DependencyOrder(t *testing.T) {
+	a := newRecordingApplier()
+	// Registered out of order: the listener depends on the cert loader.
+	a.add(t, "listener", nil, "certs")
+	a.add(t, "db", []string{"DBPass"})
+	a.add(t, "certs", []string{"TLSCert", "TLSKey"})
+
+	err := a.Apply(context.Background(), []ChangeEvent{{Field: "TLSKey"}, {Field: "TLSCert"}})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if want := []string{"certs", "listener"}; !slices.Equal(a.calls, want) {
+		t.Errorf("calls = %v, want %v", a.calls, want)
+	}
+}
+
+func TestApplier_PassesOwnChanges(t *testing.T) {
+	a := NewApplier()
+	var got []ChangeEvent
+	if err := a.Register(Resource{
+		Name:   "db",
+		Fields: []string{"DBPass"},
+		Update: func(_ context.Context, changes []ChangeEvent) error {
+			got = changes
+			return nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	events := []ChangeEvent{{Field: "DBPass", NewValue: []byte("new")}, {Field: "APIKey"}}
+	if err := a.Apply(context.Background(), events); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(got) != 1 || got[0].Field != "DBPass" || string(got[0].NewValue) != "new" {
+		t.Errorf("changes = %+v, want only the DBPass event", got)
+	}
+}
+
+func TestApplier_FailureSkipsDependents(t *testing.T) {
+	a := newRecordingApplier()
+	a.add(t, "certs", []string{"TLSCert"})
+	a.add(t, "listener", nil, "certs")
+	a.add(t, "db", []string{"DBPass"})
+	a.fail["certs"] = errors.New("bad certificate")
+
+	err := a.Apply(context.Background(), []ChangeEvent{{Field: "TLSCert"}, {Field: "DBPass"}})
+	if err == nil || !strings.Contains(err.Error(), "bad certificate") || !strings.Contains(err.Error(), `"listener": skipped`) {
+		t.Errorf("Apply error = %v", err)
+	}
+	if want := []string{"certs", "db"}; !slices.Equal(a.calls, want) {
+		t.Errorf("calls = %v, want %v", a.calls, want)
+	}
+}
+
+func TestApplier_Errors(t *testing.T) {
+	noop := func(context.Context, []ChangeEvent) error { return nil }
+
+	a := NewApplier()
+	if err := a.Register(Resource{Update: noop}); err == nil {
+		t.Error("expected error for unnamed resource")
+	}
+	if err := a.Register(Resource{Name: "x"}); err == nil {
+		t.Error("expected error for missing Update")
+	}
+	if err := a.Register(Resource{Name: "x", Update: noop}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Register(Resource{Name: "x", Update: noop}); err == nil {
+		t.Error("expected error for duplicate resource")
+	}
+
+	a = NewApplier()
+	_ = a.Register(Resource{Name: "a", DependsOn: []string{"missing"}, Update: noop})
+	if err := a.Apply(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "unknown resource") {
+		t.Errorf("Apply error = %v, want unknown resource", err)
+	}
+
+	a = NewApplier()
+	_ = a.Register(Resource{Name: "a", DependsOn: []string{"b"}, Update: noop})
+	_ = a.Register(Resource{Name: "b", DependsOn: []string{"a"}, Update: noop})
+	if err := a.Apply(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Apply error = %v, want cycle", err)
+	}
+}
+
+func TestApplier_RunWithWatcher(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("cert", []byte("c1"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		TLSCert string `secret:"cert"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	updated := make(chan string, 1)
+	a := NewApplier()
+	_ = a.Register(Resource{
+		Name:   "certs",
+		Fields: []string{"TLSCert"},
+		Update: func(_ context.Context, changes []ChangeEvent) error {
+			updated <- string(changes[0].NewValue)
+			return nil
+		},
+	})
+	done := make(chan struct{})
+	go func() {
+		a.Run(ctx, w.Changes(), nil)
+		close(done)
+	}()
+
+	store.Store("cert", []byte("c2"))
+	select {
+	case v := <-updated:
+		if v != "c2" {
+			t.Errorf("updated with %q, want %q", v, "c2")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for update")
+	}
+
+	w.Stop()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("Run did not return after the watcher stopped")
+	}
+}
@@ -0,0 +1,163 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthPlaceholder is replaced by the secret in the header template of an
+// AuthRoundTripper.
+const AuthPlaceholder = "{secret}"
+
+// AuthOption configures an AuthRoundTripper.
+type AuthOption func(*AuthRoundTripper)
+
+// WithAuthBase sets the RoundTripper that sends requests once the header is
+// set. Defaults to http.DefaultTransport.
+func WithAuthBase(base http.RoundTripper) AuthOption {
+	return func(t *AuthRoundTripper) {
+		t.base = base
+	}
+}
+
+// AuthRoundTripper is an http.RoundTripper that authenticates outbound
+// requests with a header holding a secret, such as a bearer token or an API
+// key:
+//
+//	rt, err := secrets.NewAuthRoundTripper(r, "awssm://prod/stripe#key,ttl=1h", "Authorization: Bearer {secret}")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	client := &http.Client{Transport: rt}
+//
+// The secret is fetched on the first request and kept, or fetched again once
+// it is older than the `ttl=` option of the reference. If the server answers
+// 401 Unauthorized, as it does once a rotated token has been revoked, the
+// secret is fetched again and, if it changed, the request is retried with it,
+// provided its body can be replayed. It is safe for concurrent use.
+type AuthRoundTripper struct {
+	r      *Resolver
+	ref    Ref
+	header string // canonical header name
+	value  string // header value template containing AuthPlaceholder
+	base   http.RoundTripper
+
+	mu      sync.Mutex
+	secret  string
+	fetched time.Time // zero until the first successful fetch
+}
+
+// NewAuthRoundTripper returns an AuthRoundTripper that resolves uri, a
+// reference in the syntax of the `secret` struct tag, with r, and sets the
+// header headerTemplate describes: a header name, a colon, and a value in
+// which AuthPlaceholder stands for the secret, such as
+// "Authorization: Bearer {secret}" or "X-API-Key: {secret}". Whitespace
+// around the secret, such as the newline that ends a secret file, is
+// trimmed, as header values cannot hold it.
+func NewAuthRoundTripper(r *Resolver, uri, headerTemplate string, opts ...AuthOption) (*AuthRoundTripper, error) {
+	ref, err := ParseRef(uri)
+	if err != nil {
+		return nil, err
+	}
+	name, value, ok := strings.Cut(headerTemplate, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return nil, fmt.Errorf("secrets: header template %q: want \"Name: value\"", headerTemplate)
+	}
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, AuthPlaceholder) {
+		return nil, fmt.Errorf("secrets: header template %q has no %s", headerTemplate, AuthPlaceholder)
+	}
+	t := &AuthRoundTripper{
+		r:      r,
+		ref:    ref,
+		header: textproto.CanonicalMIMEHeaderKey(name),
+		value:  value,
+		base:   http.DefaultTransport,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t, nil
+}
+
+// RoundTrip sends req with the header set, leaving req itself unchanged.
+func (t *AuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	secret, err := t.get(ctx, "")
+	if err != nil {
+		closeBody(req)
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(t.authorize(req, secret))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil // the body cannot be sent again
+	}
+	fresh, err := t.get(ctx, secret)
+	if err != nil || fresh == secret {
+		return resp, nil
+	}
+	retry := t.authorize(req, fresh)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	resp.Body.Close()
+	return t.base.RoundTrip(retry)
+}
+
+// Invalidate discards the kept secret, so that the next request fetches it
+// again.
+func (t *AuthRoundTripper) Invalidate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.secret = ""
+	t.fetched = time.Time{}
+}
+
+// get returns the secret, fetching it if none is kept, the kept one has
+// outlived the TTL, or it is stale, the one a server has just refused.
+// Concurrent callers share a fetch, and a caller whose stale secret has
+// already been replaced gets the replacement.
+func (t *AuthRoundTripper) get(ctx context.Context, stale string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.fetched.IsZero() && (stale == "" || t.secret != stale) && (t.ref.tag.TTL == 0 || time.Since(t.fetched) < t.ref.tag.TTL) {
+		return t.secret, nil
+	}
+	data, err := t.r.ResolveRef(ctx, t.ref)
+	if err != nil {
+		return "", err
+	}
+	t.secret = strings.TrimSpace(string(data))
+	t.fetched = time.Now()
+	return t.secret, nil
+}
+
+// authorize returns a shallow copy of req with the header set to secret.
+func (t *AuthRoundTripper) authorize(req *http.Request, secret string) *http.Request {
+	out := req.Clone(req.Context())
+	out.Header.Set(t.header, strings.ReplaceAll(t.value, AuthPlaceholder, secret))
+	return out
+}
+
+// closeBody closes the body of a request RoundTrip will not send, as the
+// http.RoundTripper contract requires.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -0,0 +1,143 @@
+package secrets
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// authTestProvider serves a token that a test can rotate.
+type authTestProvider struct {
+	mu      sync.Mutex
+	token   string
+	fetches int
+}
+
+func (p *authTestProvider) Get(context.Context, string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fetches++
+	return []byte(p.token + "\n"), nil
+}
+
+func (p *authTestProvider) set(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = token
+}
+
+func TestAuthRoundTripper(t *testing.T) {
+	var mu sync.Mutex
+	valid := "tok-1"
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	p := &authTestProvider{token: "tok-1"}
+	rt, err := NewAuthRoundTripper(NewResolver(WithDefault(p)), "api-token", "authorization: Bearer {secret}", WithAuthBase(srv.Client().Transport))
+	if err != nil {
+		t.Fatalf("NewAuthRoundTripper: %v", err)
+	}
+	client := &http.Client{Transport: rt}
+
+	for range 2 {
+		resp, err := client.Get(srv.URL)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("Get = %v, %v; want 200", resp, err)
+		}
+		resp.Body.Close()
+	}
+	if p.fetches != 1 {
+		t.Errorf("fetches = %d, want the token kept after the first", p.fetches)
+	}
+
+	// The token rotates and the old one is revoked: the 401 triggers a
+	// fetch and the request, body included, is retried.
+	mu.Lock()
+	valid = "tok-2"
+	mu.Unlock()
+	p.set("tok-2")
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Post after rotation = %v, %v; want 200", resp, err)
+	}
+	resp.Body.Close()
+	if got := bodies[len(bodies)-2:]; got[0] != "payload" || got[1] != "payload" {
+		t.Errorf("bodies = %q, want the payload sent twice", got)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Error("RoundTrip modified the request")
+	}
+
+	// A token the server refuses outright is not retried forever.
+	mu.Lock()
+	valid = "tok-3"
+	mu.Unlock()
+	resp, err = client.Get(srv.URL)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Get with a refused token = %v, %v; want 401", resp, err)
+	}
+	resp.Body.Close()
+}
+
+func TestAuthRoundTripper_TTL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("X-Api-Key"))
+	}))
+	defer srv.Close()
+
+	p := &authTestProvider{token: "k1"}
+	rt, err := NewAuthRoundTripper(NewResolver(WithDefault(p)), "api-key,ttl=1ns", "X-API-Key: {secret}", WithAuthBase(srv.Client().Transport))
+	if err != nil {
+		t.Fatalf("NewAuthRoundTripper: %v", err)
+	}
+	client := &http.Client{Transport: rt}
+	get := func() string {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	if got := get(); got != "k1" {
+		t.Errorf("X-API-Key = %q, want k1", got)
+	}
+	p.set("k2")
+	if got := get(); got != "k2" {
+		t.Errorf("X-API-Key after the TTL = %q, want k2", got)
+	}
+
+	rt.Invalidate()
+	if got := get(); got != "k2" || p.fetches != 3 {
+		t.Errorf("after Invalidate: X-API-Key = %q, fetches = %d", got, p.fetches)
+	}
+}
+
+func TestNewAuthRoundTripper_Invalid(t *testing.T) {
+	r := NewResolver()
+	for _, tmpl := range []string{"Bearer {secret}", ": {secret}", "Authorization: Bearer", "X Key: {secret}"} {
+		if _, err := NewAuthRoundTripper(r, "token", tmpl); err == nil {
+			t.Errorf("NewAuthRoundTripper(%q) succeeded", tmpl)
+		}
+	}
+	if _, err := NewAuthRoundTripper(r, "", "Authorization: {secret}"); err == nil {
+		t.Error("NewAuthRoundTripper with an empty reference succeeded")
+	}
+}
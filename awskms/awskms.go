@@ -0,0 +1,329 @@
+// Package awskms provides a secret provider that decrypts AWS KMS
+// ciphertexts, for envelope-encrypted secrets kept in configuration
+// repositories.
+package awskms
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+	"github.com/brwse/go-secrets"
+)
+
+// Client abstracts the AWS KMS API.
+// Implement this interface to provide a custom or pre-configured client.
+type Client interface {
+	// Decrypt returns the plaintext of ciphertext. keyID is empty unless
+	// set with WithKeyID.
+	Decrypt(ctx context.Context, ciphertext []byte, keyID string, encryptionContext map[string]string) ([]byte, error)
+}
+
+// EncryptClient is implemented by Clients that can encrypt, for Encrypt. The
+// default SDK client uses the KMS Encrypt operation.
+type EncryptClient interface {
+	Encrypt(ctx context.Context, keyID string, plaintext []byte, encryptionContext map[string]string) ([]byte, error)
+}
+
+// ProviderOption configures the awskms Provider.
+type ProviderOption func(*Provider)
+
+// WithRegion configures the AWS region for the KMS client.
+func WithRegion(region string) ProviderOption {
+	return func(p *Provider) {
+		p.region = region
+	}
+}
+
+// WithEndpoint sends requests made by the default SDK client to url instead
+// of the regional AWS endpoint, such as "http://localhost:4566" for
+// LocalStack. It has no effect on a Client injected with WithClient.
+func WithEndpoint(url string) ProviderOption {
+	return func(p *Provider) {
+		p.endpoint = url
+	}
+}
+
+// WithCredentialsProvider makes the default SDK client sign requests with
+// credentials from cp instead of the default AWS credential chain. It has no
+// effect on a Client injected with WithClient.
+func WithCredentialsProvider(cp aws.CredentialsProvider) ProviderOption {
+	return func(p *Provider) {
+		p.credentials = cp
+	}
+}
+
+// WithAssumeRole makes the default SDK client use temporary credentials for
+// the IAM role roleARN, obtained with STS AssumeRole using the default
+// credential chain. externalID is passed to AssumeRole if not empty. It has
+// no effect on a Client injected with WithClient.
+func WithAssumeRole(roleARN, externalID string) ProviderOption {
+	return func(p *Provider) {
+		p.roleARN = roleARN
+		p.externalID = externalID
+	}
+}
+
+// WithUserAgent appends ua to the User-Agent of requests made by the default
+// SDK client. A user agent set on the Resolver with secrets.WithUserAgent
+// takes precedence. It has no effect on a Client injected with WithClient.
+func WithUserAgent(ua string) ProviderOption {
+	return func(p *Provider) {
+		p.userAgent = ua
+	}
+}
+
+// WithKeyID sets the KMS key ciphertexts must have been encrypted with: a key
+// ID, key ARN, alias name, or alias ARN. Ciphertexts record their key, so it
+// is not needed to decrypt them, but with it KMS refuses ciphertexts of any
+// other key.
+func WithKeyID(keyID string) ProviderOption {
+	return func(p *Provider) {
+		p.keyID = keyID
+	}
+}
+
+// WithEncryptionContext sets the encryption context ciphertexts were
+// encrypted with, which KMS requires to decrypt them.
+func WithEncryptionContext(ec map[string]string) ProviderOption {
+	return func(p *Provider) {
+		p.encryptionContext = ec
+	}
+}
+
+// WithBaseDir sets the directory "file:" keys are relative to. Defaults to
+// the working directory.
+func WithBaseDir(dir string) ProviderOption {
+	return func(p *Provider) {
+		p.baseDir = dir
+	}
+}
+
+// WithClient injects a custom Client implementation.
+// Use this to provide a pre-configured AWS client or for testing.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider decrypts secrets with AWS KMS.
+// It implements secrets.Provider.
+//
+// Keys are base64-encoded ciphertexts, as printed by
+// "aws kms encrypt --query CiphertextBlob --output text", or "file:" and the
+// path of a file holding one, in base64 or raw:
+//
+//	AQICAHh...           // the ciphertext itself
+//	file:secrets/db.enc  // a file relative to the base directory
+type Provider struct {
+	region            string
+	endpoint          string
+	credentials       aws.CredentialsProvider
+	roleARN           string
+	externalID        string
+	userAgent         string
+	keyID             string
+	encryptionContext map[string]string
+	baseDir           string
+	client            Client
+}
+
+// New creates a new AWS KMS Provider with the given options.
+// If no Client is provided via WithClient, a real AWS SDK client is created
+// using the default AWS credential chain.
+func New(opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		var cfgOpts []func(*awsconfig.LoadOptions) error
+		if p.region != "" {
+			cfgOpts = append(cfgOpts, awsconfig.WithRegion(p.region))
+		}
+		if p.credentials != nil {
+			cfgOpts = append(cfgOpts, awsconfig.WithCredentialsProvider(p.credentials))
+		}
+		cfg, err := awsconfig.LoadDefaultConfig(context.Background(), cfgOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("awskms: load AWS config: %w", err)
+		}
+		if p.roleARN != "" {
+			cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), p.roleARN, func(o *stscreds.AssumeRoleOptions) {
+				if p.externalID != "" {
+					o.ExternalID = aws.String(p.externalID)
+				}
+			}))
+		}
+		var kmsOpts []func(*kms.Options)
+		if p.endpoint != "" {
+			kmsOpts = append(kmsOpts, func(o *kms.Options) {
+				o.BaseEndpoint = aws.String(p.endpoint)
+			})
+		}
+		p.client = &sdkClient{kms: kms.NewFromConfig(cfg, kmsOpts...), userAgent: p.userAgent}
+	}
+	return p, nil
+}
+
+// Get decrypts the ciphertext key, or the ciphertext in the file it names.
+// Returns secrets.ErrNotFound (wrapped) if the file does not exist.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	ciphertext, err := p.ciphertext(key)
+	if err != nil {
+		return nil, fmt.Errorf("awskms: secret %q: %w", redact(key), err)
+	}
+	plain, err := p.client.Decrypt(ctx, ciphertext, p.keyID, p.encryptionContext)
+	if err != nil {
+		return nil, fmt.Errorf("awskms: secret %q: %w", redact(key), providerError(err))
+	}
+	return plain, nil
+}
+
+// Encrypt encrypts plaintext with the KMS key keyID and the configured
+// encryption context, returning the base64 ciphertext to use as a key.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// EncryptClient.
+func (p *Provider) Encrypt(ctx context.Context, keyID string, plaintext []byte) (string, error) {
+	ec, ok := p.client.(EncryptClient)
+	if !ok {
+		return "", fmt.Errorf("awskms: encrypt: %w", errors.ErrUnsupported)
+	}
+	ciphertext, err := ec.Encrypt(ctx, keyID, plaintext, p.encryptionContext)
+	if err != nil {
+		return "", fmt.Errorf("awskms: encrypt: %w", providerError(err))
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// ciphertext returns the ciphertext key holds or names.
+func (p *Provider) ciphertext(key string) ([]byte, error) {
+	path, ok := strings.CutPrefix(key, "file:")
+	if !ok {
+		ciphertext, err := decodeBase64(key)
+		if err != nil {
+			return nil, errors.New("key is neither base64 nor a file: path")
+		}
+		return ciphertext, nil
+	}
+	if path == "" {
+		return nil, errors.New("empty file path")
+	}
+	if p.baseDir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(p.baseDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ciphertext, err := decodeBase64(string(data)); err == nil {
+		return ciphertext, nil
+	}
+	return data, nil
+}
+
+// decodeBase64 decodes standard or URL-safe base64, padded or not, ignoring
+// whitespace such as line breaks.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.Join(strings.Fields(s), "")
+	if s == "" {
+		return nil, errors.New("empty")
+	}
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	}
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// redact shortens inline ciphertexts in error messages, which are long and
+// of no use to a reader; file keys are kept whole.
+func redact(key string) string {
+	if strings.HasPrefix(key, "file:") || len(key) <= 16 {
+		return key
+	}
+	return key[:12] + "..."
+}
+
+// sdkClient implements Client using the AWS SDK v2.
+type sdkClient struct {
+	kms       *kms.Client
+	userAgent string
+}
+
+// providerError wraps an error returned by the AWS SDK in a
+// *secrets.ErrProvider carrying the HTTP status, error code, and request ID
+// of the failed call. Other errors are returned unchanged.
+func providerError(err error) error {
+	var re *awshttp.ResponseError
+	if !errors.As(err, &re) {
+		return err
+	}
+	pe := &secrets.ErrProvider{StatusCode: re.HTTPStatusCode(), RequestID: re.ServiceRequestID(), Err: err}
+	var ae smithy.APIError
+	if errors.As(err, &ae) {
+		pe.Code = ae.ErrorCode()
+	}
+	return pe
+}
+
+// optFns returns per-request options that add the user agent carried by ctx,
+// or else the configured one, to the request's User-Agent.
+func (c *sdkClient) optFns(ctx context.Context) []func(*kms.Options) {
+	ua := secrets.UserAgentFromContext(ctx)
+	if ua == "" {
+		ua = c.userAgent
+	}
+	if ua == "" {
+		return nil
+	}
+	return []func(*kms.Options){func(o *kms.Options) {
+		for _, product := range strings.Fields(ua) {
+			if name, version, ok := strings.Cut(product, "/"); ok {
+				o.APIOptions = append(o.APIOptions, awsmiddleware.AddUserAgentKeyValue(name, version))
+			} else {
+				o.APIOptions = append(o.APIOptions, awsmiddleware.AddUserAgentKey(product))
+			}
+		}
+	}}
+}
+
+func (c *sdkClient) Decrypt(ctx context.Context, ciphertext []byte, keyID string, encryptionContext map[string]string) ([]byte, error) {
+	in := &kms.DecryptInput{CiphertextBlob: ciphertext, EncryptionContext: encryptionContext}
+	if keyID != "" {
+		in.KeyId = aws.String(keyID)
+	}
+	out, err := c.kms.Decrypt(ctx, in, c.optFns(ctx)...)
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
+
+func (c *sdkClient) Encrypt(ctx context.Context, keyID string, plaintext []byte, encryptionContext map[string]string) ([]byte, error) {
+	out, err := c.kms.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:             aws.String(keyID),
+		Plaintext:         plaintext,
+		EncryptionContext: encryptionContext,
+	}, c.optFns(ctx)...)
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
@@ -0,0 +1,209 @@
+package awskms
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// fakeClient "encrypts" by prefixing the key ID and encryption context.
+type fakeClient struct {
+	keyIDs []string
+}
+
+func seal(keyID string, ec map[string]string, plaintext string) []byte {
+	return fmt.Appendf(nil, "%s|%v|%s", keyID, ec, plaintext)
+}
+
+func (c *fakeClient) Decrypt(_ context.Context, ciphertext []byte, keyID string, ec map[string]string) ([]byte, error) {
+	c.keyIDs = append(c.keyIDs, keyID)
+	parts := strings.SplitN(string(ciphertext), "|", 3)
+	if len(parts) != 3 || parts[1] != fmt.Sprint(ec) || (keyID != "" && keyID != parts[0]) {
+		return nil, errors.New("InvalidCiphertextException")
+	}
+	return []byte(parts[2]), nil
+}
+
+func (c *fakeClient) Encrypt(_ context.Context, keyID string, plaintext []byte, ec map[string]string) ([]byte, error) {
+	return seal(keyID, ec, string(plaintext)), nil
+}
+
+func TestGet_Inline(t *testing.T) {
+	ec := map[string]string{"app": "billing"}
+	fc := &fakeClient{}
+	p, err := New(WithClient(fc), WithEncryptionContext(ec))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	ct := seal("alias/app", ec, "hunter2")
+
+	for _, key := range []string{
+		base64.StdEncoding.EncodeToString(ct),
+		base64.RawURLEncoding.EncodeToString(ct),
+	} {
+		got, err := p.Get(ctx, key)
+		if err != nil || string(got) != "hunter2" {
+			t.Errorf("Get(%s) = %q, %v; want hunter2", key, got, err)
+		}
+	}
+	if _, err := p.Get(ctx, "not base64!"); err == nil {
+		t.Error("Get of a key that is not base64 succeeded")
+	}
+
+	// The wrong encryption context fails to decrypt.
+	other := newProvider(t, WithClient(fc), WithEncryptionContext(map[string]string{"app": "other"}))
+	if _, err := other.Get(ctx, base64.StdEncoding.EncodeToString(ct)); err == nil {
+		t.Error("Get with the wrong encryption context succeeded")
+	}
+}
+
+// New2 is New that fails the test on error.
+func newProvider(t *testing.T, opts ...ProviderOption) *Provider {
+	t.Helper()
+	p, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+func TestGet_File(t *testing.T) {
+	dir := t.TempDir()
+	ct := seal("k", nil, "from-file")
+	b64 := base64.StdEncoding.EncodeToString(ct)
+	wrapped := b64[:10] + "\n" + b64[10:] + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "db.enc"), []byte(wrapped), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "db.bin"), ct, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fc := &fakeClient{}
+	p := newProvider(t, WithClient(fc), WithBaseDir(dir), WithKeyID("k"))
+	ctx := context.Background()
+
+	for _, key := range []string{"file:db.enc", "file:db.bin", "file:" + filepath.Join(dir, "db.enc")} {
+		got, err := p.Get(ctx, key)
+		if err != nil || string(got) != "from-file" {
+			t.Errorf("Get(%s) = %q, %v; want from-file", key, got, err)
+		}
+	}
+	if fc.keyIDs[0] != "k" {
+		t.Errorf("key ID = %q, want k", fc.keyIDs[0])
+	}
+	if _, err := p.Get(ctx, "file:missing.enc"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(file:missing.enc) error = %v, want ErrNotFound", err)
+	}
+	if _, err := p.Get(ctx, "file:"); err == nil || errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(file:) error = %v", err)
+	}
+}
+
+func TestGet_ErrorRedactsCiphertext(t *testing.T) {
+	p := newProvider(t, WithClient(&fakeClient{}))
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", 100)))
+	_, err := p.Get(context.Background(), key)
+	if err == nil || strings.Contains(err.Error(), key) {
+		t.Errorf("error = %v, want it to leave out the ciphertext", err)
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	ec := map[string]string{"env": "prod"}
+	p := newProvider(t, WithClient(&fakeClient{}), WithEncryptionContext(ec))
+	ctx := context.Background()
+	key, err := p.Encrypt(ctx, "alias/app", []byte("round-trip"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if got, err := p.Get(ctx, key); err != nil || string(got) != "round-trip" {
+		t.Errorf("Get(Encrypt(...)) = %q, %v", got, err)
+	}
+
+	type decryptOnly struct{ Client }
+	p = newProvider(t, WithClient(decryptOnly{&fakeClient{}}))
+	if _, err := p.Encrypt(ctx, "k", nil); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Encrypt error = %v, want ErrUnsupported", err)
+	}
+}
+
+// newSDKProvider returns a Provider whose SDK client sends requests to
+// handler.
+func newSDKProvider(t *testing.T, handler http.HandlerFunc, opts ...ProviderOption) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	return newProvider(t, append([]ProviderOption{WithRegion("us-east-1"), WithEndpoint(srv.URL)}, opts...)...)
+}
+
+func TestSDKClient_Decrypt(t *testing.T) {
+	var req map[string]any
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Amz-Target"); got != "TrentService.Decrypt" {
+			t.Errorf("X-Amz-Target = %q", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &req)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprintf(w, `{"KeyId": "arn:aws:kms:us-east-1:1:key/k", "Plaintext": %q}`, base64.StdEncoding.EncodeToString([]byte("s3cret")))
+	}, WithKeyID("alias/app"), WithEncryptionContext(map[string]string{"app": "billing"}))
+
+	ct := base64.StdEncoding.EncodeToString([]byte("ciphertext"))
+	got, err := p.Get(context.Background(), ct)
+	if err != nil || string(got) != "s3cret" {
+		t.Fatalf("Get = %q, %v; want s3cret", got, err)
+	}
+	if req["CiphertextBlob"] != ct || req["KeyId"] != "alias/app" {
+		t.Errorf("request = %v", req)
+	}
+	if ec, _ := req["EncryptionContext"].(map[string]any); !maps.Equal(ec, map[string]any{"app": "billing"}) {
+		t.Errorf("EncryptionContext = %v", req["EncryptionContext"])
+	}
+}
+
+func TestSDKClient_ErrProvider(t *testing.T) {
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Header().Set("X-Amzn-Requestid", "req-123")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"__type":"InvalidCiphertextException","message":""}`)
+	})
+	_, err := p.Get(context.Background(), base64.StdEncoding.EncodeToString([]byte("bad")))
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.Code != "InvalidCiphertextException" || pe.RequestID != "req-123" {
+		t.Errorf("error = %v, want ErrProvider with code and request ID", err)
+	}
+}
+
+func TestSDKClient_Encrypt(t *testing.T) {
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Amz-Target"); got != "TrentService.Encrypt" {
+			t.Errorf("X-Amz-Target = %q", got)
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprintf(w, `{"CiphertextBlob": %q}`, base64.StdEncoding.EncodeToString([]byte("blob")))
+	})
+	got, err := p.Encrypt(context.Background(), "alias/app", []byte("x"))
+	if want := base64.StdEncoding.EncodeToString([]byte("blob")); err != nil || got != want {
+		t.Errorf("Encrypt = %q, %v; want %q", got, err, want)
+	}
+}
@@ -3,21 +3,77 @@ package awsps
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
 	"github.com/brwse/go-secrets"
 )
 
 // Client abstracts the AWS SSM Parameter Store API.
+// name may carry a ":version" or ":label" selector.
 type Client interface {
 	GetParameter(ctx context.Context, name string, decrypt bool) (string, error)
 }
 
+// VersionClient is implemented by Clients that can report the version number
+// of a parameter along with its value, which GetVersion needs to find the
+// previous version. The default SDK client uses GetParameter.
+type VersionClient interface {
+	GetParameterVersion(ctx context.Context, name string, decrypt bool) (string, int64, error)
+}
+
+// CheckClient is implemented by Clients that can verify a parameter exists
+// without retrieving its value. The default SDK client uses DescribeParameters.
+type CheckClient interface {
+	DescribeParameter(ctx context.Context, name string) error
+}
+
+// MetadataClient is implemented by Clients that can describe a parameter
+// without retrieving its value. The default SDK client uses
+// DescribeParameters and ListTagsForResource.
+type MetadataClient interface {
+	Metadata(ctx context.Context, name string) (secrets.Metadata, error)
+}
+
+// ListerClient is implemented by Clients that can enumerate parameters. The
+// default SDK client uses GetParametersByPath.
+type ListerClient interface {
+	// ListParameters returns the names of the parameters under path, at any
+	// depth.
+	ListParameters(ctx context.Context, path string) ([]string, error)
+}
+
+// PathClient is implemented by Clients that can retrieve every parameter
+// under a path at once. The default SDK client uses GetParametersByPath.
+type PathClient interface {
+	// GetParametersByPath returns the values of the parameters under path,
+	// at any depth, keyed by their full names.
+	GetParametersByPath(ctx context.Context, path string, decrypt bool) (map[string]string, error)
+}
+
+// WriterClient is implemented by Clients that can create, update, and delete
+// parameters. The default SDK client uses PutParameter and DeleteParameter.
+type WriterClient interface {
+	PutParameter(ctx context.Context, name, value string) error
+	DeleteParameter(ctx context.Context, name string) error
+}
+
 // ProviderOption configures the awsps Provider.
 type ProviderOption func(*Provider)
 
@@ -43,12 +99,39 @@ func WithClient(c Client) ProviderOption {
 	}
 }
 
+// WithUserAgent appends ua, such as "billing-api/1.4.2", to the User-Agent of
+// requests made by the default SDK client, for attribution in CloudTrail. A
+// user agent set on the Resolver with secrets.WithUserAgent takes precedence.
+// It has no effect on a Client injected with WithClient.
+func WithUserAgent(ua string) ProviderOption {
+	return func(p *Provider) {
+		p.userAgent = ua
+	}
+}
+
+// WithAssumeRole makes the default SDK client use temporary credentials for
+// the IAM role roleARN, such as a role in a central secrets account, obtained
+// with STS AssumeRole using the default credential chain. externalID is
+// passed to AssumeRole if not empty. The credentials are refreshed before
+// they expire. It has no effect on a Client injected with WithClient.
+func WithAssumeRole(roleARN, externalID string) ProviderOption {
+	return func(p *Provider) {
+		p.roleARN = roleARN
+		p.externalID = externalID
+	}
+}
+
 // Provider reads secrets from AWS Systems Manager Parameter Store.
-// It implements secrets.Provider.
+// It implements secrets.Provider, secrets.VersionedProvider,
+// secrets.CheckerProvider, secrets.MetadataProvider, secrets.ListerProvider,
+// and secrets.WriterProvider.
 type Provider struct {
-	region  string
-	decrypt bool
-	client  Client
+	region     string
+	decrypt    bool
+	userAgent  string
+	roleARN    string
+	externalID string
+	client     Client
 }
 
 // New creates a new AWS Parameter Store Provider with the given options.
@@ -70,40 +153,416 @@ func New(opts ...ProviderOption) (*Provider, error) {
 		if err != nil {
 			return nil, fmt.Errorf("awsps: load AWS config: %w", err)
 		}
-		p.client = &sdkClient{ssm: ssm.NewFromConfig(cfg)}
+		if p.roleARN != "" {
+			cfg.Credentials = assumeRole(cfg, p.roleARN, p.externalID)
+		}
+		p.client = &sdkClient{ssm: ssm.NewFromConfig(cfg), userAgent: p.userAgent}
 	}
 	return p, nil
 }
 
+// assumeRole returns cached credentials for roleARN, obtained with STS
+// AssumeRole using the credentials of cfg.
+func assumeRole(cfg aws.Config, roleARN, externalID string) aws.CredentialsProvider {
+	return aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	}))
+}
+
 // Get retrieves the parameter value for the given key.
-// Returns secrets.ErrNotFound (wrapped) if the parameter does not exist.
+//
+// A key ending in "/*", such as "/prod/app/*", retrieves every parameter
+// under the path with GetParametersByPath, which requires
+// ssm:GetParametersByPath, and returns them as a JSON object nested by path
+// segment: "/prod/app/db/password" is at fragment "db.password". A leading
+// "/" is implied, so "prod/app/*" is the same path.
+//
+// Returns secrets.ErrNotFound (wrapped) if the parameter, or every parameter
+// under the path, does not exist, and errors.ErrUnsupported (wrapped) for a
+// path if the Client does not implement PathClient.
 func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	if path, ok := strings.CutSuffix(key, "/*"); ok {
+		return p.getPath(ctx, key, "/"+strings.TrimPrefix(path, "/"))
+	}
 	val, err := p.client.GetParameter(ctx, key, p.decrypt)
 	if err != nil {
-		return nil, fmt.Errorf("awsps: parameter %q: %w", key, err)
+		return nil, fmt.Errorf("awsps: parameter %q: %w", key, providerError(err))
+	}
+	return []byte(val), nil
+}
+
+// GetVersion retrieves a specific version of the parameter using a
+// "name:selector" GetParameter request. version is one of:
+//   - "current", the latest version;
+//   - "previous", the version before the latest, which requires the Client
+//     to implement VersionClient;
+//   - a version number, such as "3";
+//   - a parameter label, such as "stable".
+//
+// Returns secrets.ErrNotFound (wrapped) if the parameter or version does not
+// exist, including "previous" for a parameter with a single version or whose
+// previous version has aged out of its history, and errors.ErrUnsupported
+// (wrapped) for "previous" if the Client does not implement VersionClient.
+func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	switch {
+	case version == "current":
+		return p.Get(ctx, key)
+	case version == "":
+		return nil, fmt.Errorf("awsps: parameter %q: unsupported version %q", key, version)
+	case strings.HasSuffix(key, "/*"):
+		return nil, fmt.Errorf("awsps: parameter %q: paths are not versioned", key)
+	}
+	if version == "previous" {
+		vc, ok := p.client.(VersionClient)
+		if !ok {
+			return nil, fmt.Errorf("awsps: parameter %q version %q: %w", key, version, errors.ErrUnsupported)
+		}
+		_, current, err := vc.GetParameterVersion(ctx, key, false)
+		if err != nil {
+			return nil, fmt.Errorf("awsps: parameter %q version %q: %w", key, version, providerError(err))
+		}
+		if current <= 1 {
+			return nil, fmt.Errorf("awsps: parameter %q version %q: %w", key, version, secrets.ErrNotFound)
+		}
+		version = strconv.FormatInt(current-1, 10)
+	}
+	val, err := p.client.GetParameter(ctx, key+":"+version, p.decrypt)
+	if err != nil {
+		return nil, fmt.Errorf("awsps: parameter %q version %q: %w", key, version, providerError(err))
 	}
 	return []byte(val), nil
 }
 
+// getPath returns the parameters under path as a JSON object.
+func (p *Provider) getPath(ctx context.Context, key, path string) ([]byte, error) {
+	pc, ok := p.client.(PathClient)
+	if !ok {
+		return nil, fmt.Errorf("awsps: parameter %q: %w", key, errors.ErrUnsupported)
+	}
+	params, err := pc.GetParametersByPath(ctx, path, p.decrypt)
+	if err != nil {
+		return nil, fmt.Errorf("awsps: parameter %q: %w", key, providerError(err))
+	}
+	if len(params) == 0 {
+		return nil, fmt.Errorf("awsps: parameter %q: %w", key, secrets.ErrNotFound)
+	}
+	root := make(map[string]any)
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	// In name order, a parameter is inserted before any under its own path,
+	// where it is found in place of an object.
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		segments := strings.Split(strings.TrimPrefix(name, prefix), "/")
+		obj := root
+		for i, seg := range segments {
+			if i == len(segments)-1 {
+				obj[seg] = params[name]
+				break
+			}
+			child, ok := obj[seg].(map[string]any)
+			if !ok {
+				if _, exists := obj[seg]; exists {
+					return nil, fmt.Errorf("awsps: parameter %q: %s is both a parameter and a path", key, prefix+strings.Join(segments[:i+1], "/"))
+				}
+				child = make(map[string]any)
+				obj[seg] = child
+			}
+			obj = child
+		}
+	}
+	return json.Marshal(root)
+}
+
+// Check verifies the parameter exists and is accessible using
+// DescribeParameters, without retrieving its value. This requires
+// ssm:DescribeParameters rather than ssm:GetParameter.
+// Returns secrets.ErrNotFound (wrapped) if the parameter does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement CheckClient.
+func (p *Provider) Check(ctx context.Context, key string) error {
+	cc, ok := p.client.(CheckClient)
+	if !ok {
+		return fmt.Errorf("awsps: parameter %q: %w", key, errors.ErrUnsupported)
+	}
+	if err := cc.DescribeParameter(ctx, key); err != nil {
+		return fmt.Errorf("awsps: parameter %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// GetMetadata describes the parameter using DescribeParameters and
+// ListTagsForResource, without retrieving its value. Parameter Store does not
+// record when a parameter was created, so only RotatedAt (the last
+// modification) is set; Version is the parameter version number, and
+// ExpiresAt comes from an Expiration parameter policy, if any.
+// Returns secrets.ErrNotFound (wrapped) if the parameter does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement
+// MetadataClient.
+func (p *Provider) GetMetadata(ctx context.Context, key string) (secrets.Metadata, error) {
+	mc, ok := p.client.(MetadataClient)
+	if !ok {
+		return secrets.Metadata{}, fmt.Errorf("awsps: parameter %q: %w", key, errors.ErrUnsupported)
+	}
+	md, err := mc.Metadata(ctx, key)
+	if err != nil {
+		return secrets.Metadata{}, fmt.Errorf("awsps: parameter %q: %w", key, providerError(err))
+	}
+	return md, nil
+}
+
+// List returns the names of the parameters under the hierarchy path prefix,
+// such as "/prod" or "/prod/db/", at any depth. An empty prefix lists every
+// parameter in a hierarchy under "/". Parameter Store lists by path, so a
+// prefix that ends partway through a path segment, such as "/prod/db-",
+// matches nothing. This requires ssm:GetParametersByPath.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// ListerClient.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	lc, ok := p.client.(ListerClient)
+	if !ok {
+		return nil, fmt.Errorf("awsps: list %q: %w", prefix, errors.ErrUnsupported)
+	}
+	path := strings.TrimSuffix(prefix, "/")
+	if path == "" {
+		path = "/"
+	}
+	names, err := lc.ListParameters(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("awsps: list %q: %w", prefix, providerError(err))
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// Set stores value as a SecureString parameter, creating it or overwriting its
+// current value. Parameter values are text, so value is stored as a string.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient.
+func (p *Provider) Set(ctx context.Context, key string, value []byte) error {
+	wc, ok := p.client.(WriterClient)
+	if !ok {
+		return fmt.Errorf("awsps: parameter %q: %w", key, errors.ErrUnsupported)
+	}
+	if err := wc.PutParameter(ctx, key, string(value)); err != nil {
+		return fmt.Errorf("awsps: parameter %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// Delete deletes the parameter.
+// Returns secrets.ErrNotFound (wrapped) if the parameter does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient.
+func (p *Provider) Delete(ctx context.Context, key string) error {
+	wc, ok := p.client.(WriterClient)
+	if !ok {
+		return fmt.Errorf("awsps: parameter %q: %w", key, errors.ErrUnsupported)
+	}
+	if err := wc.DeleteParameter(ctx, key); err != nil {
+		return fmt.Errorf("awsps: parameter %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
 // sdkClient wraps the real AWS SSM SDK.
 type sdkClient struct {
-	ssm *ssm.Client
+	ssm       *ssm.Client
+	userAgent string
+}
+
+// providerError wraps an error returned by the AWS SDK in a
+// *secrets.ErrProvider carrying the HTTP status, error code, and request ID
+// of the failed call. Other errors are returned unchanged.
+func providerError(err error) error {
+	var re *awshttp.ResponseError
+	if !errors.As(err, &re) {
+		return err
+	}
+	pe := &secrets.ErrProvider{StatusCode: re.HTTPStatusCode(), RequestID: re.ServiceRequestID(), Err: err}
+	var ae smithy.APIError
+	if errors.As(err, &ae) {
+		pe.Code = ae.ErrorCode()
+	}
+	return pe
+}
+
+// optFns returns per-request options that add the user agent carried by ctx,
+// or else the configured one, to the request's User-Agent.
+func (c *sdkClient) optFns(ctx context.Context) []func(*ssm.Options) {
+	ua := secrets.UserAgentFromContext(ctx)
+	if ua == "" {
+		ua = c.userAgent
+	}
+	if ua == "" {
+		return nil
+	}
+	return []func(*ssm.Options){func(o *ssm.Options) {
+		o.APIOptions = append(o.APIOptions, userAgentAPIOptions(ua)...)
+	}}
+}
+
+// userAgentAPIOptions returns middleware that adds each "name/version"
+// product of ua to the User-Agent.
+func userAgentAPIOptions(ua string) []func(*middleware.Stack) error {
+	var fns []func(*middleware.Stack) error
+	for _, product := range strings.Fields(ua) {
+		if name, version, ok := strings.Cut(product, "/"); ok {
+			fns = append(fns, awsmiddleware.AddUserAgentKeyValue(name, version))
+		} else {
+			fns = append(fns, awsmiddleware.AddUserAgentKey(product))
+		}
+	}
+	return fns
 }
 
 func (c *sdkClient) GetParameter(ctx context.Context, name string, decrypt bool) (string, error) {
+	val, _, err := c.GetParameterVersion(ctx, name, decrypt)
+	return val, err
+}
+
+func (c *sdkClient) GetParameterVersion(ctx context.Context, name string, decrypt bool) (string, int64, error) {
 	out, err := c.ssm.GetParameter(ctx, &ssm.GetParameterInput{
 		Name:           aws.String(name),
 		WithDecryption: aws.Bool(decrypt),
-	})
+	}, c.optFns(ctx)...)
 	if err != nil {
 		var pnf *ssmtypes.ParameterNotFound
-		if errors.As(err, &pnf) {
-			return "", fmt.Errorf("%w", secrets.ErrNotFound)
+		var vnf *ssmtypes.ParameterVersionNotFound
+		if errors.As(err, &pnf) || errors.As(err, &vnf) {
+			return "", 0, fmt.Errorf("%w", secrets.ErrNotFound)
 		}
-		return "", err
+		return "", 0, err
 	}
 	if out.Parameter == nil || out.Parameter.Value == nil {
-		return "", fmt.Errorf("%w", secrets.ErrNotFound)
+		return "", 0, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return *out.Parameter.Value, out.Parameter.Version, nil
+}
+
+func (c *sdkClient) DescribeParameter(ctx context.Context, name string) error {
+	out, err := c.ssm.DescribeParameters(ctx, &ssm.DescribeParametersInput{
+		ParameterFilters: []ssmtypes.ParameterStringFilter{{
+			Key:    aws.String("Name"),
+			Option: aws.String("Equals"),
+			Values: []string{name},
+		}},
+	}, c.optFns(ctx)...)
+	if err != nil {
+		return err
+	}
+	if len(out.Parameters) == 0 {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return nil
+}
+
+func (c *sdkClient) Metadata(ctx context.Context, name string) (secrets.Metadata, error) {
+	out, err := c.ssm.DescribeParameters(ctx, &ssm.DescribeParametersInput{
+		ParameterFilters: []ssmtypes.ParameterStringFilter{{
+			Key:    aws.String("Name"),
+			Option: aws.String("Equals"),
+			Values: []string{name},
+		}},
+	}, c.optFns(ctx)...)
+	if err != nil {
+		return secrets.Metadata{}, err
+	}
+	if len(out.Parameters) == 0 {
+		return secrets.Metadata{}, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	param := out.Parameters[0]
+	md := secrets.Metadata{
+		RotatedAt: aws.ToTime(param.LastModifiedDate),
+		Version:   strconv.FormatInt(param.Version, 10),
+	}
+	for _, policy := range param.Policies {
+		if aws.ToString(policy.PolicyType) != "Expiration" {
+			continue
+		}
+		var text struct {
+			Attributes struct {
+				Timestamp time.Time
+			}
+		}
+		if err := json.Unmarshal([]byte(aws.ToString(policy.PolicyText)), &text); err != nil {
+			return secrets.Metadata{}, fmt.Errorf("parse expiration policy: %w", err)
+		}
+		md.ExpiresAt = text.Attributes.Timestamp
+	}
+
+	tags, err := c.ssm.ListTagsForResource(ctx, &ssm.ListTagsForResourceInput{
+		ResourceType: ssmtypes.ResourceTypeForTaggingParameter,
+		ResourceId:   aws.String(name),
+	}, c.optFns(ctx)...)
+	if err != nil {
+		return secrets.Metadata{}, err
+	}
+	if len(tags.TagList) > 0 {
+		md.Tags = make(map[string]string, len(tags.TagList))
+		for _, tag := range tags.TagList {
+			md.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+	return md, nil
+}
+
+func (c *sdkClient) PutParameter(ctx context.Context, name, value string) error {
+	_, err := c.ssm.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(value),
+		Type:      ssmtypes.ParameterTypeSecureString,
+		Overwrite: aws.Bool(true),
+	}, c.optFns(ctx)...)
+	return err
+}
+
+func (c *sdkClient) DeleteParameter(ctx context.Context, name string) error {
+	_, err := c.ssm.DeleteParameter(ctx, &ssm.DeleteParameterInput{
+		Name: aws.String(name),
+	}, c.optFns(ctx)...)
+	if err != nil {
+		var pnf *ssmtypes.ParameterNotFound
+		if errors.As(err, &pnf) {
+			return fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *sdkClient) GetParametersByPath(ctx context.Context, path string, decrypt bool) (map[string]string, error) {
+	params := make(map[string]string)
+	pages := ssm.NewGetParametersByPathPaginator(c.ssm, &ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(decrypt),
+	})
+	for pages.HasMorePages() {
+		out, err := pages.NextPage(ctx, c.optFns(ctx)...)
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range out.Parameters {
+			params[aws.ToString(param.Name)] = aws.ToString(param.Value)
+		}
+	}
+	return params, nil
+}
+
+func (c *sdkClient) ListParameters(ctx context.Context, path string) ([]string, error) {
+	var names []string
+	pages := ssm.NewGetParametersByPathPaginator(c.ssm, &ssm.GetParametersByPathInput{
+		Path:      aws.String(path),
+		Recursive: aws.Bool(true),
+	})
+	for pages.HasMorePages() {
+		out, err := pages.NextPage(ctx, c.optFns(ctx)...)
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range out.Parameters {
+			names = append(names, aws.ToString(param.Name))
+		}
 	}
-	return *out.Parameter.Value, nil
+	return names, nil
 }
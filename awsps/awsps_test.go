@@ -2,17 +2,32 @@ package awsps
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/brwse/go-secrets"
 )
 
 // mockSSMClient implements Client for testing.
 type mockSSMClient struct {
-	// params maps parameter name to its value.
+	// params maps parameter name, with or without a selector such as
+	// "/app/key:1", to its value.
 	params map[string]string
+	// versions maps parameter name to its latest version number.
+	versions map[string]int64
+}
+
+func (m *mockSSMClient) GetParameterVersion(ctx context.Context, name string, decrypt bool) (string, int64, error) {
+	val, err := m.GetParameter(ctx, name, decrypt)
+	return val, m.versions[name], err
 }
 
 func (m *mockSSMClient) GetParameter(_ context.Context, name string, _ bool) (string, error) {
@@ -23,6 +38,49 @@ func (m *mockSSMClient) GetParameter(_ context.Context, name string, _ bool) (st
 	return val, nil
 }
 
+func (m *mockSSMClient) DescribeParameter(_ context.Context, name string) error {
+	if _, ok := m.params[name]; !ok {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return nil
+}
+
+func (m *mockSSMClient) PutParameter(_ context.Context, name, value string) error {
+	if m.params == nil {
+		m.params = make(map[string]string)
+	}
+	m.params[name] = value
+	return nil
+}
+
+func (m *mockSSMClient) DeleteParameter(_ context.Context, name string) error {
+	if _, ok := m.params[name]; !ok {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	delete(m.params, name)
+	return nil
+}
+
+func (m *mockSSMClient) GetParametersByPath(_ context.Context, path string, _ bool) (map[string]string, error) {
+	params := make(map[string]string)
+	for name, val := range m.params {
+		if strings.HasPrefix(name, strings.TrimSuffix(path, "/")+"/") {
+			params[name] = val
+		}
+	}
+	return params, nil
+}
+
+func (m *mockSSMClient) ListParameters(_ context.Context, path string) ([]string, error) {
+	var names []string
+	for name := range m.params {
+		if path == "/" || strings.HasPrefix(name, path+"/") {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
 func TestGet_Existing(t *testing.T) {
 	mock := &mockSSMClient{
 		params: map[string]string{
@@ -99,3 +157,418 @@ func (m *capturingSSMClient) GetParameter(ctx context.Context, name string, decr
 	}
 	return val, nil
 }
+
+func TestCheck(t *testing.T) {
+	mock := &mockSSMClient{
+		params: map[string]string{"/prod/db-password": "s3cret"},
+	}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "/prod/db-password"); err != nil {
+		t.Errorf("Check existing: %v", err)
+	}
+	if err := p.Check(context.Background(), "/nonexistent"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check missing: expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestCheck_Unsupported(t *testing.T) {
+	// Embedding hides the mock's CheckClient method.
+	p, err := New(WithClient(struct{ Client }{&mockSSMClient{}}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "/prod/db-password"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	mock := &mockSSMClient{params: map[string]string{
+		"/prod/db/password": "a",
+		"/prod/api-key":     "b",
+		"/staging/api-key":  "c",
+	}}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for prefix, want := range map[string][]string{
+		"":       {"/prod/api-key", "/prod/db/password", "/staging/api-key"},
+		"/prod":  {"/prod/api-key", "/prod/db/password"},
+		"/prod/": {"/prod/api-key", "/prod/db/password"},
+	} {
+		got, err := p.List(context.Background(), prefix)
+		if err != nil {
+			t.Fatalf("List(%q): %v", prefix, err)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("List(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+
+	ro, _ := New(WithClient(struct{ Client }{mock}))
+	if _, err := ro.List(context.Background(), ""); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("List: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	mock := &mockSSMClient{}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "/prod/api-key", []byte("s3cret")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := p.Get(ctx, "/prod/api-key"); err != nil || string(got) != "s3cret" {
+		t.Errorf("Get = %q, %v, want %q", got, err, "s3cret")
+	}
+	if err := p.Delete(ctx, "/prod/api-key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := p.Delete(ctx, "/prod/api-key"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Delete missing: expected ErrNotFound, got: %v", err)
+	}
+
+	ro, _ := New(WithClient(struct{ Client }{mock}))
+	if err := ro.Set(ctx, "/prod/api-key", nil); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Set: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+// newSDKProvider returns a Provider whose SDK client sends requests to
+// handler.
+func newSDKProvider(t *testing.T, handler http.HandlerFunc, opts ...ProviderOption) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+
+	p, err := New(append([]ProviderOption{WithRegion("us-east-1")}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+func TestGetMetadata(t *testing.T) {
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		switch r.Header.Get("X-Amz-Target") {
+		case "AmazonSSM.DescribeParameters":
+			fmt.Fprint(w, `{"Parameters":[{
+				"Name": "/app/db",
+				"LastModifiedDate": 1710000000,
+				"Version": 3,
+				"Policies": [{
+					"PolicyType": "Expiration",
+					"PolicyText": "{\"Type\":\"Expiration\",\"Version\":\"1.0\",\"Attributes\":{\"Timestamp\":\"2024-06-01T00:00:00Z\"}}"
+				}]
+			}]}`)
+		case "AmazonSSM.ListTagsForResource":
+			fmt.Fprint(w, `{"TagList":[{"Key":"team","Value":"billing"}]}`)
+		default:
+			t.Errorf("unexpected operation %q", r.Header.Get("X-Amz-Target"))
+		}
+	})
+
+	md, err := p.GetMetadata(context.Background(), "/app/db")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if !md.RotatedAt.Equal(time.Unix(1710000000, 0)) {
+		t.Errorf("RotatedAt = %v", md.RotatedAt)
+	}
+	if !md.CreatedAt.IsZero() {
+		t.Errorf("CreatedAt = %v, want zero", md.CreatedAt)
+	}
+	if md.Version != "3" {
+		t.Errorf("Version = %q, want 3", md.Version)
+	}
+	if want := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC); !md.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", md.ExpiresAt, want)
+	}
+	if md.Tags["team"] != "billing" {
+		t.Errorf("Tags = %v", md.Tags)
+	}
+}
+
+func TestGetMetadata_Missing(t *testing.T) {
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprint(w, `{"Parameters":[]}`)
+	})
+
+	if _, err := p.GetMetadata(context.Background(), "/app/db"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestNew_UserAgent(t *testing.T) {
+	var got []string
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprint(w, `{"Parameter":{"Name":"/app/db","Value":"s3cret"}}`)
+	}, WithUserAgent("billing-api/1.4.2"))
+	if _, err := p.Get(context.Background(), "/app/db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	ctx := secrets.ContextWithUserAgent(context.Background(), "billing-worker/2.0")
+	if _, err := p.Get(ctx, "/app/db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d requests, want 2", len(got))
+	}
+	if !strings.Contains(got[0], " billing-api/1.4.2") {
+		t.Errorf("User-Agent = %q, want it to contain billing-api/1.4.2", got[0])
+	}
+	if !strings.Contains(got[1], " billing-worker/2.0") || strings.Contains(got[1], "billing-api") {
+		t.Errorf("User-Agent = %q, want it to contain only the context user agent", got[1])
+	}
+}
+
+func TestSDKClient_ErrProvider(t *testing.T) {
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Header().Set("X-Amzn-Requestid", "req-123")
+		w.Header().Set("X-Amzn-Errortype", "AccessDeniedException")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"__type":"AccessDeniedException","message":"User is not authorized to perform ssm:GetParameter"}`)
+	})
+
+	_, err := p.Get(context.Background(), "/prod/db")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected ErrProvider, got %v", err)
+	}
+	if pe.StatusCode != http.StatusBadRequest || pe.Code != "AccessDeniedException" || pe.RequestID != "req-123" {
+		t.Errorf("ErrProvider = {%d %q %q}, want {400 \"AccessDeniedException\" \"req-123\"}", pe.StatusCode, pe.Code, pe.RequestID)
+	}
+	if errors.Is(err, secrets.ErrNotFound) {
+		t.Error("access denied should not be ErrNotFound")
+	}
+}
+
+func TestWithAssumeRole(t *testing.T) {
+	var roles, auth []string
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") == "" { // STS
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("parse STS request: %v", err)
+			}
+			roles = append(roles, r.Form.Get("RoleArn"))
+			if r.Form.Has("ExternalId") {
+				t.Errorf("ExternalId = %q, want none", r.Form.Get("ExternalId"))
+			}
+			w.Header().Set("Content-Type", "text/xml")
+			fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleResult>
+<Credentials><AccessKeyId>ASIAASSUMED</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>token</SessionToken><Expiration>%s</Expiration></Credentials>
+</AssumeRoleResult></AssumeRoleResponse>`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+			return
+		}
+		auth = append(auth, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprint(w, `{"Parameter":{"Name":"/app/db","Value":"s3cret"}}`)
+	}, WithAssumeRole("arn:aws:iam::111122223333:role/reader", ""))
+
+	if val, err := p.Get(context.Background(), "/app/db"); err != nil || string(val) != "s3cret" {
+		t.Fatalf("Get = %q, %v", val, err)
+	}
+	if len(roles) != 1 || roles[0] != "arn:aws:iam::111122223333:role/reader" {
+		t.Errorf("assumed roles = %q", roles)
+	}
+	if len(auth) != 1 || !strings.Contains(auth[0], "Credential=ASIAASSUMED/") {
+		t.Errorf("Authorization = %q, want it signed with the assumed role's credentials", auth)
+	}
+}
+
+func TestGet_Path(t *testing.T) {
+	mock := &mockSSMClient{params: map[string]string{
+		"/prod/app/db/host":     "db.internal",
+		"/prod/app/db/password": "s3cret",
+		"/prod/app/api-key":     "key",
+		"/prod/other/token":     "tok",
+	}}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for _, key := range []string{"/prod/app/*", "prod/app/*"} {
+		got, err := p.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		if want := `{"api-key":"key","db":{"host":"db.internal","password":"s3cret"}}`; string(got) != want {
+			t.Errorf("Get(%q) = %s, want %s", key, got, want)
+		}
+	}
+	if _, err := p.Get(context.Background(), "/staging/*"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("empty path: expected ErrNotFound, got: %v", err)
+	}
+
+	mock.params["/prod/app/db"] = "conflict"
+	if _, err := p.Get(context.Background(), "/prod/app/*"); err == nil || !strings.Contains(err.Error(), "/prod/app/db is both") {
+		t.Errorf("expected a conflict error, got: %v", err)
+	}
+
+	// Embedding hides the mock's PathClient method.
+	p, _ = New(WithClient(struct{ Client }{mock}))
+	if _, err := p.Get(context.Background(), "/prod/app/*"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestGet_PathFragments(t *testing.T) {
+	p, err := New(WithClient(&mockSSMClient{params: map[string]string{
+		"/prod/app/db/password": "s3cret",
+		"/prod/app/api-key":     "key",
+	}}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	r := secrets.NewResolver(secrets.WithProvider("awsps", p))
+	var cfg struct {
+		DBPass string `secret:"awsps://prod/app/*#db.password"`
+		APIKey string `secret:"awsps://prod/app/*#api-key"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.DBPass != "s3cret" || cfg.APIKey != "key" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+}
+
+func TestSDKClient_GetParametersByPath(t *testing.T) {
+	var inputs []map[string]any
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		inputs = append(inputs, in)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if in["NextToken"] == nil {
+			fmt.Fprint(w, `{"Parameters":[{"Name":"/prod/app/a","Value":"1"}],"NextToken":"t"}`)
+			return
+		}
+		fmt.Fprint(w, `{"Parameters":[{"Name":"/prod/app/b/c","Value":"2"}]}`)
+	})
+
+	got, err := p.Get(context.Background(), "/prod/app/*")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if want := `{"a":"1","b":{"c":"2"}}`; string(got) != want {
+		t.Errorf("Get = %s, want %s", got, want)
+	}
+	if len(inputs) != 2 || inputs[0]["Path"] != "/prod/app" || inputs[0]["Recursive"] != true || inputs[0]["WithDecryption"] != true {
+		t.Errorf("requests = %v", inputs)
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	mock := &mockSSMClient{
+		params: map[string]string{
+			"/app/key":        "v3",
+			"/app/key:2":      "v2",
+			"/app/key:1":      "v1",
+			"/app/key:stable": "v2",
+			"/app/new":        "only",
+		},
+		versions: map[string]int64{"/app/key": 3, "/app/new": 1},
+	}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for version, want := range map[string]string{"current": "v3", "previous": "v2", "1": "v1", "stable": "v2"} {
+		val, err := p.GetVersion(context.Background(), "/app/key", version)
+		if err != nil || string(val) != want {
+			t.Errorf("GetVersion(%q) = %q, %v; want %q", version, val, err, want)
+		}
+	}
+	if _, err := p.GetVersion(context.Background(), "/app/new", "previous"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("previous of a single version: expected ErrNotFound, got: %v", err)
+	}
+	if _, err := p.GetVersion(context.Background(), "/app/key", "7"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("missing version: expected ErrNotFound, got: %v", err)
+	}
+	if _, err := p.GetVersion(context.Background(), "/app/*", "1"); err == nil {
+		t.Error("expected an error for a versioned path")
+	}
+
+	// Embedding hides the mock's VersionClient method.
+	p, _ = New(WithClient(struct{ Client }{mock}))
+	if _, err := p.GetVersion(context.Background(), "/app/key", "previous"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+	if val, err := p.GetVersion(context.Background(), "/app/key", "2"); err != nil || string(val) != "v2" {
+		t.Errorf("GetVersion(2) without VersionClient = %q, %v", val, err)
+	}
+}
+
+func TestGetVersion_Versioned(t *testing.T) {
+	p, err := New(WithClient(&mockSSMClient{
+		params:   map[string]string{"/app/key": "new", "/app/key:4": "old"},
+		versions: map[string]int64{"/app/key": 5},
+	}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	r := secrets.NewResolver(secrets.WithProvider("awsps", p))
+	var cfg struct {
+		Key secrets.Versioned[string] `secret:"awsps:///app/key"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.Key.Current != "new" || cfg.Key.Previous != "old" {
+		t.Errorf("Key = %+v", cfg.Key)
+	}
+}
+
+func TestSDKClient_GetVersion(t *testing.T) {
+	var names []string
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var in struct{ Name string }
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		names = append(names, in.Name)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if in.Name == "/app/key:1" {
+			w.Header().Set("X-Amzn-Errortype", "ParameterVersionNotFound")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type":"ParameterVersionNotFound","message":"version 1 not found"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"Parameter":{"Name":"/app/key","Value":"v","Version":2}}`)
+	})
+
+	if _, err := p.GetVersion(context.Background(), "/app/key", "previous"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("aged-out previous version: expected ErrNotFound, got: %v", err)
+	}
+	if want := []string{"/app/key", "/app/key:1"}; !slices.Equal(names, want) {
+		t.Errorf("requested names = %q, want %q", names, want)
+	}
+}
@@ -5,11 +5,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
 	"github.com/brwse/go-secrets"
 )
 
@@ -19,6 +29,41 @@ type Client interface {
 	GetSecretValue(ctx context.Context, name, versionStage string) (string, error)
 }
 
+// CheckClient is implemented by Clients that can verify a secret exists
+// without retrieving its value. The default SDK client uses DescribeSecret.
+type CheckClient interface {
+	DescribeSecret(ctx context.Context, name string) error
+}
+
+// VersionIDClient is implemented by Clients that can retrieve a secret
+// version by its version ID rather than a staging label. The default SDK
+// client passes VersionId to GetSecretValue.
+type VersionIDClient interface {
+	GetSecretValueByID(ctx context.Context, name, versionID string) (string, error)
+}
+
+// MetadataClient is implemented by Clients that can describe a secret without
+// retrieving its value. The default SDK client uses DescribeSecret.
+type MetadataClient interface {
+	Metadata(ctx context.Context, name string) (secrets.Metadata, error)
+}
+
+// BatchClient is implemented by Clients that can retrieve the current values
+// of several secrets at once, keyed by the name or ARN they were requested by.
+// Secrets that could not be retrieved are omitted. The default SDK client uses
+// BatchGetSecretValue, 20 secrets per request.
+type BatchClient interface {
+	BatchGetSecretValues(ctx context.Context, names []string) (map[string]string, error)
+}
+
+// WriterClient is implemented by Clients that can create, update, and delete
+// secrets. The default SDK client uses PutSecretValue, falling back to
+// CreateSecret for new secrets, and DeleteSecret.
+type WriterClient interface {
+	PutSecretValue(ctx context.Context, name string, value []byte) error
+	DeleteSecret(ctx context.Context, name string) error
+}
+
 // ProviderOption configures the awssm Provider.
 type ProviderOption func(*Provider)
 
@@ -29,6 +74,49 @@ func WithRegion(region string) ProviderOption {
 	}
 }
 
+// WithEndpoint sends requests made by the default SDK client to url instead
+// of the regional AWS endpoint, such as "http://localhost:4566" for
+// LocalStack. It has no effect on a Client injected with WithClient.
+func WithEndpoint(url string) ProviderOption {
+	return func(p *Provider) {
+		p.endpoint = url
+	}
+}
+
+// WithCredentialsProvider makes the default SDK client sign requests with
+// credentials from cp instead of the default AWS credential chain, such as an
+// stscreds.AssumeRoleProvider for assumed-role credentials. The credentials
+// are cached and refreshed before they expire. It has no effect on a Client
+// injected with WithClient.
+func WithCredentialsProvider(cp aws.CredentialsProvider) ProviderOption {
+	return func(p *Provider) {
+		p.credentials = cp
+	}
+}
+
+// WithAssumeRole makes the default SDK client use temporary credentials for
+// the IAM role roleARN, such as a role in a central secrets account, obtained
+// with STS AssumeRole using the default credential chain (or the credentials
+// given with WithCredentialsProvider). externalID is passed to AssumeRole if
+// not empty. The credentials are refreshed before they expire. It has no
+// effect on a Client injected with WithClient.
+func WithAssumeRole(roleARN, externalID string) ProviderOption {
+	return func(p *Provider) {
+		p.roleARN = roleARN
+		p.externalID = externalID
+	}
+}
+
+// WithHTTPClient makes the default SDK client send requests with hc, to
+// configure proxies, TLS, or timeouts. The SDK cannot add a CA bundle named
+// by AWS_CA_BUNDLE to hc, so New fails if that is set; configure hc's TLS
+// instead. It has no effect on a Client injected with WithClient.
+func WithHTTPClient(hc *http.Client) ProviderOption {
+	return func(p *Provider) {
+		p.httpClient = hc
+	}
+}
+
 // WithClient injects a custom Client implementation.
 // Use this to provide a pre-configured AWS client or for testing.
 func WithClient(c Client) ProviderOption {
@@ -37,11 +125,29 @@ func WithClient(c Client) ProviderOption {
 	}
 }
 
+// WithUserAgent appends ua, such as "billing-api/1.4.2", to the User-Agent of
+// requests made by the default SDK client, for attribution in CloudTrail. A
+// user agent set on the Resolver with secrets.WithUserAgent takes precedence.
+// It has no effect on a Client injected with WithClient.
+func WithUserAgent(ua string) ProviderOption {
+	return func(p *Provider) {
+		p.userAgent = ua
+	}
+}
+
 // Provider reads secrets from AWS Secrets Manager.
-// It implements secrets.Provider and secrets.VersionedProvider.
+// It implements secrets.Provider, secrets.VersionedProvider,
+// secrets.BatchProvider, secrets.CheckerProvider, secrets.MetadataProvider,
+// and secrets.WriterProvider.
 type Provider struct {
-	region string
-	client Client
+	region      string
+	userAgent   string
+	endpoint    string
+	credentials aws.CredentialsProvider
+	roleARN     string
+	externalID  string
+	httpClient  *http.Client
+	client      Client
 }
 
 // New creates a new AWS Secrets Manager Provider with the given options.
@@ -57,15 +163,40 @@ func New(opts ...ProviderOption) (*Provider, error) {
 		if p.region != "" {
 			cfgOpts = append(cfgOpts, awsconfig.WithRegion(p.region))
 		}
+		if p.credentials != nil {
+			cfgOpts = append(cfgOpts, awsconfig.WithCredentialsProvider(p.credentials))
+		}
+		if p.httpClient != nil {
+			cfgOpts = append(cfgOpts, awsconfig.WithHTTPClient(p.httpClient))
+		}
 		cfg, err := awsconfig.LoadDefaultConfig(context.Background(), cfgOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("awssm: load AWS config: %w", err)
 		}
-		p.client = &sdkClient{sm: secretsmanager.NewFromConfig(cfg)}
+		if p.roleARN != "" {
+			cfg.Credentials = assumeRole(cfg, p.roleARN, p.externalID)
+		}
+		var smOpts []func(*secretsmanager.Options)
+		if p.endpoint != "" {
+			smOpts = append(smOpts, func(o *secretsmanager.Options) {
+				o.BaseEndpoint = aws.String(p.endpoint)
+			})
+		}
+		p.client = &sdkClient{sm: secretsmanager.NewFromConfig(cfg, smOpts...), userAgent: p.userAgent}
 	}
 	return p, nil
 }
 
+// assumeRole returns cached credentials for roleARN, obtained with STS
+// AssumeRole using the credentials of cfg.
+func assumeRole(cfg aws.Config, roleARN, externalID string) aws.CredentialsProvider {
+	return aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	}))
+}
+
 // versionStage maps user-facing version strings to AWS version stages.
 var versionStage = map[string]string{
 	"current":  "AWSCURRENT",
@@ -79,32 +210,227 @@ func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
 	return p.GetVersion(ctx, key, "current")
 }
 
-// GetVersion retrieves a specific version stage of the secret.
-// Supported versions: "current" (AWSCURRENT), "previous" (AWSPREVIOUS), "pending" (AWSPENDING).
-// Returns secrets.ErrNotFound (wrapped) if the secret or version does not exist.
+// GetVersion retrieves a specific version of the secret. version is one of:
+//   - "current" (AWSCURRENT), "previous" (AWSPREVIOUS), or "pending" (AWSPENDING);
+//   - a version ID, which AWS generates as a UUID, to pin an exact version;
+//     prefix an ID that is not a UUID with "id:";
+//   - any other staging label, such as a custom "rollback" label.
+//
+// Returns secrets.ErrNotFound (wrapped) if the secret or version does not
+// exist, and errors.ErrUnsupported (wrapped) for a version ID if the Client
+// does not implement VersionIDClient.
 func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
-	stage, ok := versionStage[version]
-	if !ok {
+	if version == "" {
 		return nil, fmt.Errorf("awssm: secret %q: unsupported version %q", key, version)
 	}
-	val, err := p.client.GetSecretValue(ctx, key, stage)
+	var val string
+	var err error
+	if id, ok := versionID(version); ok {
+		vc, ok := p.client.(VersionIDClient)
+		if !ok {
+			return nil, fmt.Errorf("awssm: secret %q version %q: %w", key, version, errors.ErrUnsupported)
+		}
+		val, err = vc.GetSecretValueByID(ctx, key, id)
+	} else {
+		stage, ok := versionStage[version]
+		if !ok {
+			stage = version
+		}
+		val, err = p.client.GetSecretValue(ctx, key, stage)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("awssm: secret %q: %w", key, err)
+		return nil, fmt.Errorf("awssm: secret %q: %w", key, providerError(err))
 	}
 	return []byte(val), nil
 }
 
+// GetBatch retrieves the current versions of the secrets with BatchGetSecretValue,
+// in requests of up to 20 secrets, which requires
+// secretsmanager:BatchGetSecretValue in addition to
+// secretsmanager:GetSecretValue on each secret. Secrets that do not exist or
+// could not be retrieved are omitted from the result.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// BatchClient.
+func (p *Provider) GetBatch(ctx context.Context, keys []string) (map[string][]byte, error) {
+	bc, ok := p.client.(BatchClient)
+	if !ok {
+		return nil, fmt.Errorf("awssm: batch get: %w", errors.ErrUnsupported)
+	}
+	vals, err := bc.BatchGetSecretValues(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("awssm: batch get: %w", providerError(err))
+	}
+	out := make(map[string][]byte, len(vals))
+	for key, val := range vals {
+		out[key] = []byte(val)
+	}
+	return out, nil
+}
+
+// versionID reports whether version names a version ID rather than a staging
+// label, and returns the ID.
+func versionID(version string) (string, bool) {
+	if id, ok := strings.CutPrefix(version, "id:"); ok {
+		return id, true
+	}
+	return version, isUUID(version)
+}
+
+// isUUID reports whether s has the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+// with hexadecimal digits.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := range len(s) {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", rune(s[i])) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Check verifies the secret exists and is accessible using DescribeSecret,
+// without retrieving its value. This requires secretsmanager:DescribeSecret
+// rather than secretsmanager:GetSecretValue.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement CheckClient.
+func (p *Provider) Check(ctx context.Context, key string) error {
+	cc, ok := p.client.(CheckClient)
+	if !ok {
+		return fmt.Errorf("awssm: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	if err := cc.DescribeSecret(ctx, key); err != nil {
+		return fmt.Errorf("awssm: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// GetMetadata describes the secret using DescribeSecret, without retrieving
+// its value. RotatedAt is the last rotation by Secrets Manager or, for secrets
+// that are not rotated automatically, the last change; Version is the version
+// ID labelled AWSCURRENT, and ExpiresAt the scheduled deletion date, if any.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement
+// MetadataClient.
+func (p *Provider) GetMetadata(ctx context.Context, key string) (secrets.Metadata, error) {
+	mc, ok := p.client.(MetadataClient)
+	if !ok {
+		return secrets.Metadata{}, fmt.Errorf("awssm: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	md, err := mc.Metadata(ctx, key)
+	if err != nil {
+		return secrets.Metadata{}, fmt.Errorf("awssm: secret %q: %w", key, providerError(err))
+	}
+	return md, nil
+}
+
+// Set stores value as the new AWSCURRENT version of the secret, creating the
+// secret if it does not exist. Values that are valid UTF-8 are stored as
+// SecretString, others as SecretBinary.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient.
+func (p *Provider) Set(ctx context.Context, key string, value []byte) error {
+	wc, ok := p.client.(WriterClient)
+	if !ok {
+		return fmt.Errorf("awssm: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	if err := wc.PutSecretValue(ctx, key, value); err != nil {
+		return fmt.Errorf("awssm: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// Delete schedules the secret for deletion after the default 30 day recovery
+// window, during which its name cannot be reused.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient.
+func (p *Provider) Delete(ctx context.Context, key string) error {
+	wc, ok := p.client.(WriterClient)
+	if !ok {
+		return fmt.Errorf("awssm: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	if err := wc.DeleteSecret(ctx, key); err != nil {
+		return fmt.Errorf("awssm: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
 // sdkClient wraps the real AWS Secrets Manager SDK.
 type sdkClient struct {
-	sm *secretsmanager.Client
+	sm        *secretsmanager.Client
+	userAgent string
+}
+
+// providerError wraps an error returned by the AWS SDK in a
+// *secrets.ErrProvider carrying the HTTP status, error code, and request ID
+// of the failed call. Other errors are returned unchanged.
+func providerError(err error) error {
+	var re *awshttp.ResponseError
+	if !errors.As(err, &re) {
+		return err
+	}
+	pe := &secrets.ErrProvider{StatusCode: re.HTTPStatusCode(), RequestID: re.ServiceRequestID(), Err: err}
+	var ae smithy.APIError
+	if errors.As(err, &ae) {
+		pe.Code = ae.ErrorCode()
+	}
+	return pe
+}
+
+// optFns returns per-request options that add the user agent carried by ctx,
+// or else the configured one, to the request's User-Agent.
+func (c *sdkClient) optFns(ctx context.Context) []func(*secretsmanager.Options) {
+	ua := secrets.UserAgentFromContext(ctx)
+	if ua == "" {
+		ua = c.userAgent
+	}
+	if ua == "" {
+		return nil
+	}
+	return []func(*secretsmanager.Options){func(o *secretsmanager.Options) {
+		o.APIOptions = append(o.APIOptions, userAgentAPIOptions(ua)...)
+	}}
+}
+
+// userAgentAPIOptions returns middleware that adds each "name/version"
+// product of ua to the User-Agent.
+func userAgentAPIOptions(ua string) []func(*middleware.Stack) error {
+	var fns []func(*middleware.Stack) error
+	for _, product := range strings.Fields(ua) {
+		if name, version, ok := strings.Cut(product, "/"); ok {
+			fns = append(fns, awsmiddleware.AddUserAgentKeyValue(name, version))
+		} else {
+			fns = append(fns, awsmiddleware.AddUserAgentKey(product))
+		}
+	}
+	return fns
 }
 
 func (c *sdkClient) GetSecretValue(ctx context.Context, name, versionStage string) (string, error) {
-	input := &secretsmanager.GetSecretValueInput{
+	return c.getSecretValue(ctx, &secretsmanager.GetSecretValueInput{
 		SecretId:     aws.String(name),
 		VersionStage: aws.String(versionStage),
-	}
-	out, err := c.sm.GetSecretValue(ctx, input)
+	})
+}
+
+func (c *sdkClient) GetSecretValueByID(ctx context.Context, name, versionID string) (string, error) {
+	return c.getSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:  aws.String(name),
+		VersionId: aws.String(versionID),
+	})
+}
+
+func (c *sdkClient) getSecretValue(ctx context.Context, input *secretsmanager.GetSecretValueInput) (string, error) {
+	out, err := c.sm.GetSecretValue(ctx, input, c.optFns(ctx)...)
 	if err != nil {
 		var rnf *smtypes.ResourceNotFoundException
 		if errors.As(err, &rnf) {
@@ -117,3 +443,115 @@ func (c *sdkClient) GetSecretValue(ctx context.Context, name, versionStage strin
 	}
 	return string(out.SecretBinary), nil
 }
+
+// batchSize is the most secrets BatchGetSecretValue accepts in SecretIdList.
+const batchSize = 20
+
+func (c *sdkClient) BatchGetSecretValues(ctx context.Context, names []string) (map[string]string, error) {
+	vals := make(map[string]string, len(names))
+	for chunk := range slices.Chunk(names, batchSize) {
+		input := &secretsmanager.BatchGetSecretValueInput{SecretIdList: chunk}
+		for {
+			out, err := c.sm.BatchGetSecretValue(ctx, input, c.optFns(ctx)...)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range out.SecretValues {
+				val := string(e.SecretBinary)
+				if e.SecretString != nil {
+					val = *e.SecretString
+				}
+				// Report each value under the name or ARN it was requested by.
+				for _, name := range chunk {
+					if name == aws.ToString(e.Name) || name == aws.ToString(e.ARN) {
+						vals[name] = val
+					}
+				}
+			}
+			if out.NextToken == nil {
+				break
+			}
+			input.NextToken = out.NextToken
+		}
+	}
+	return vals, nil
+}
+
+func (c *sdkClient) DescribeSecret(ctx context.Context, name string) error {
+	_, err := c.sm.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(name),
+	}, c.optFns(ctx)...)
+	if err != nil {
+		var rnf *smtypes.ResourceNotFoundException
+		if errors.As(err, &rnf) {
+			return fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *sdkClient) Metadata(ctx context.Context, name string) (secrets.Metadata, error) {
+	out, err := c.sm.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(name),
+	}, c.optFns(ctx)...)
+	if err != nil {
+		var rnf *smtypes.ResourceNotFoundException
+		if errors.As(err, &rnf) {
+			return secrets.Metadata{}, fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return secrets.Metadata{}, err
+	}
+	md := secrets.Metadata{
+		CreatedAt: aws.ToTime(out.CreatedDate),
+		RotatedAt: aws.ToTime(out.LastRotatedDate),
+		ExpiresAt: aws.ToTime(out.DeletedDate),
+	}
+	if md.RotatedAt.IsZero() {
+		md.RotatedAt = aws.ToTime(out.LastChangedDate)
+	}
+	for id, stages := range out.VersionIdsToStages {
+		if slices.Contains(stages, "AWSCURRENT") {
+			md.Version = id
+		}
+	}
+	if len(out.Tags) > 0 {
+		md.Tags = make(map[string]string, len(out.Tags))
+		for _, tag := range out.Tags {
+			md.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+	return md, nil
+}
+
+func (c *sdkClient) PutSecretValue(ctx context.Context, name string, value []byte) error {
+	put := &secretsmanager.PutSecretValueInput{SecretId: aws.String(name)}
+	create := &secretsmanager.CreateSecretInput{Name: aws.String(name)}
+	if utf8.Valid(value) {
+		put.SecretString = aws.String(string(value))
+		create.SecretString = aws.String(string(value))
+	} else {
+		put.SecretBinary = value
+		create.SecretBinary = value
+	}
+	_, err := c.sm.PutSecretValue(ctx, put, c.optFns(ctx)...)
+	var rnf *smtypes.ResourceNotFoundException
+	if errors.As(err, &rnf) {
+		_, err = c.sm.CreateSecret(ctx, create, c.optFns(ctx)...)
+	}
+	return err
+}
+
+func (c *sdkClient) DeleteSecret(ctx context.Context, name string) error {
+	_, err := c.sm.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId: aws.String(name),
+	}, c.optFns(ctx)...)
+	if err != nil {
+		var rnf *smtypes.ResourceNotFoundException
+		if errors.As(err, &rnf) {
+			return fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
@@ -2,19 +2,32 @@ package awssm
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/brwse/go-secrets"
 )
 
 // mockSMClient implements Client for testing.
 type mockSMClient struct {
-	// secrets maps (name, versionStage) to secret value.
+	// secrets maps (name, versionStage or version ID) to secret value.
 	secrets map[string]map[string]string
 }
 
+func (m *mockSMClient) GetSecretValueByID(ctx context.Context, name, versionID string) (string, error) {
+	return m.GetSecretValue(ctx, name, versionID)
+}
+
 func (m *mockSMClient) GetSecretValue(_ context.Context, name, versionStage string) (string, error) {
 	stages, ok := m.secrets[name]
 	if !ok {
@@ -27,6 +40,47 @@ func (m *mockSMClient) GetSecretValue(_ context.Context, name, versionStage stri
 	return val, nil
 }
 
+func (m *mockSMClient) PutSecretValue(_ context.Context, name string, value []byte) error {
+	if m.secrets == nil {
+		m.secrets = make(map[string]map[string]string)
+	}
+	stages := m.secrets[name]
+	if stages == nil {
+		stages = make(map[string]string)
+		m.secrets[name] = stages
+	}
+	if cur, ok := stages["AWSCURRENT"]; ok {
+		stages["AWSPREVIOUS"] = cur
+	}
+	stages["AWSCURRENT"] = string(value)
+	return nil
+}
+
+func (m *mockSMClient) DeleteSecret(_ context.Context, name string) error {
+	if _, ok := m.secrets[name]; !ok {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	delete(m.secrets, name)
+	return nil
+}
+
+func (m *mockSMClient) BatchGetSecretValues(_ context.Context, names []string) (map[string]string, error) {
+	vals := make(map[string]string)
+	for _, name := range names {
+		if val, ok := m.secrets[name]["AWSCURRENT"]; ok {
+			vals[name] = val
+		}
+	}
+	return vals, nil
+}
+
+func (m *mockSMClient) DescribeSecret(_ context.Context, name string) error {
+	if _, ok := m.secrets[name]; !ok {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return nil
+}
+
 func TestGet_Existing(t *testing.T) {
 	mock := &mockSMClient{
 		secrets: map[string]map[string]string{
@@ -99,8 +153,452 @@ func TestGetVersion_UnsupportedVersion(t *testing.T) {
 		t.Fatalf("New: %v", err)
 	}
 
-	_, err = p.GetVersion(context.Background(), "key", "invalid")
+	_, err = p.GetVersion(context.Background(), "key", "")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 }
+
+func TestGetVersion_IDAndLabel(t *testing.T) {
+	const id = "3f1c9b2e-90ab-4def-8edc-ba9876543210"
+	mock := &mockSMClient{
+		secrets: map[string]map[string]string{
+			"prod/api-key": {
+				"AWSCURRENT": "new-key",
+				"rollback":   "pinned-key",
+				id:           "old-key",
+				"build-42":   "custom-id-key",
+			},
+		},
+	}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for version, want := range map[string]string{
+		"rollback":    "pinned-key",
+		"AWSCURRENT":  "new-key",
+		id:            "old-key",
+		"id:build-42": "custom-id-key",
+	} {
+		val, err := p.GetVersion(context.Background(), "prod/api-key", version)
+		if err != nil || string(val) != want {
+			t.Errorf("GetVersion(%q) = %q, %v; want %q", version, val, err, want)
+		}
+	}
+	if _, err := p.GetVersion(context.Background(), "prod/api-key", "other"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("unknown label: expected ErrNotFound, got: %v", err)
+	}
+
+	// Embedding hides the mock's VersionIDClient method.
+	p, _ = New(WithClient(struct{ Client }{mock}))
+	if _, err := p.GetVersion(context.Background(), "prod/api-key", id); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+	if _, err := p.GetVersion(context.Background(), "prod/api-key", "rollback"); err != nil {
+		t.Errorf("staging label without VersionIDClient: %v", err)
+	}
+}
+
+func TestSDKClient_GetVersionID(t *testing.T) {
+	var got []map[string]string
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		got = append(got, in)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprint(w, `{"SecretString":"s3cret"}`)
+	})
+
+	const id = "a1b2c3d4-5678-90ab-cdef-0123456789AB"
+	for _, version := range []string{id, "rollback"} {
+		if _, err := p.GetVersion(context.Background(), "prod/db", version); err != nil {
+			t.Fatalf("GetVersion(%q): %v", version, err)
+		}
+	}
+	want := []map[string]string{
+		{"SecretId": "prod/db", "VersionId": id},
+		{"SecretId": "prod/db", "VersionStage": "rollback"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requests = %v, want %v", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	mock := &mockSMClient{
+		secrets: map[string]map[string]string{
+			"prod/db-password": {"AWSCURRENT": "s3cret"},
+		},
+	}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "prod/db-password"); err != nil {
+		t.Errorf("Check existing: %v", err)
+	}
+	if err := p.Check(context.Background(), "nonexistent"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check missing: expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestCheck_Unsupported(t *testing.T) {
+	// Embedding hides the mock's CheckClient method.
+	p, err := New(WithClient(struct{ Client }{&mockSMClient{}}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "prod/db-password"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+}
+
+// newSDKProvider returns a Provider whose SDK client sends requests to
+// handler.
+func newSDKProvider(t *testing.T, handler http.HandlerFunc, opts ...ProviderOption) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL", srv.URL)
+
+	p, err := New(append([]ProviderOption{WithRegion("us-east-1")}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+func TestSet(t *testing.T) {
+	mock := &mockSMClient{}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, v := range []string{"v1", "v2"} {
+		if err := p.Set(ctx, "prod/api-key", []byte(v)); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if got, err := p.Get(ctx, "prod/api-key"); err != nil || string(got) != "v2" {
+		t.Errorf("Get = %q, %v, want %q", got, err, "v2")
+	}
+	if got, err := p.GetVersion(ctx, "prod/api-key", "previous"); err != nil || string(got) != "v1" {
+		t.Errorf("GetVersion(previous) = %q, %v, want %q", got, err, "v1")
+	}
+
+	if err := p.Delete(ctx, "prod/api-key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := p.Delete(ctx, "prod/api-key"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Delete missing: expected ErrNotFound, got: %v", err)
+	}
+
+	ro, _ := New(WithClient(struct{ Client }{mock}))
+	if err := ro.Set(ctx, "prod/api-key", nil); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Set: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestSDKClient_SetCreatesSecret(t *testing.T) {
+	var ops []string
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		op := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "secretsmanager.")
+		ops = append(ops, op)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if op == "PutSecretValue" {
+			w.Header().Set("X-Amzn-Errortype", "ResourceNotFoundException")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	})
+
+	if err := p.Set(context.Background(), "prod/new", []byte("s3cret")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if want := []string{"PutSecretValue", "CreateSecret"}; strings.Join(ops, ",") != strings.Join(want, ",") {
+		t.Errorf("operations = %q, want %q", ops, want)
+	}
+}
+
+func TestGetMetadata(t *testing.T) {
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprint(w, `{
+			"Name": "prod/db",
+			"CreatedDate": 1700000000,
+			"LastChangedDate": 1710000000,
+			"DeletedDate": 1720000000,
+			"Tags": [{"Key": "team", "Value": "billing"}],
+			"VersionIdsToStages": {"v1": ["AWSPREVIOUS"], "v2": ["AWSCURRENT"]}
+		}`)
+	})
+
+	md, err := p.GetMetadata(context.Background(), "prod/db")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if !md.CreatedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("CreatedAt = %v", md.CreatedAt)
+	}
+	if !md.RotatedAt.Equal(time.Unix(1710000000, 0)) {
+		t.Errorf("RotatedAt = %v, want the last change", md.RotatedAt)
+	}
+	if !md.ExpiresAt.Equal(time.Unix(1720000000, 0)) {
+		t.Errorf("ExpiresAt = %v", md.ExpiresAt)
+	}
+	if md.Version != "v2" {
+		t.Errorf("Version = %q, want v2", md.Version)
+	}
+	if md.Tags["team"] != "billing" {
+		t.Errorf("Tags = %v", md.Tags)
+	}
+}
+
+func TestGetMetadata_Unsupported(t *testing.T) {
+	p, err := New(WithClient(&mockSMClient{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if _, err := p.GetMetadata(context.Background(), "prod/db"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestNew_UserAgent(t *testing.T) {
+	var got []string
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprint(w, `{"SecretString":"s3cret"}`)
+	}, WithUserAgent("billing-api/1.4.2"))
+	if _, err := p.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	ctx := secrets.ContextWithUserAgent(context.Background(), "billing-worker/2.0 batch")
+	if _, err := p.Get(ctx, "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d requests, want 2", len(got))
+	}
+	if !strings.Contains(got[0], " billing-api/1.4.2") {
+		t.Errorf("User-Agent = %q, want it to contain billing-api/1.4.2", got[0])
+	}
+	if !strings.Contains(got[1], " billing-worker/2.0 batch") || strings.Contains(got[1], "billing-api") {
+		t.Errorf("User-Agent = %q, want it to contain only the context user agent", got[1])
+	}
+}
+
+func TestSDKClient_ErrProvider(t *testing.T) {
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Header().Set("X-Amzn-Requestid", "req-123")
+		w.Header().Set("X-Amzn-Errortype", "AccessDeniedException")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"__type":"AccessDeniedException","message":"User is not authorized to perform secretsmanager:GetSecretValue"}`)
+	})
+
+	_, err := p.Get(context.Background(), "prod/db")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected ErrProvider, got %v", err)
+	}
+	if pe.StatusCode != http.StatusBadRequest || pe.Code != "AccessDeniedException" || pe.RequestID != "req-123" {
+		t.Errorf("ErrProvider = {%d %q %q}, want {400 \"AccessDeniedException\" \"req-123\"}", pe.StatusCode, pe.Code, pe.RequestID)
+	}
+	if errors.Is(err, secrets.ErrNotFound) {
+		t.Error("access denied should not be ErrNotFound")
+	}
+}
+
+func TestGetBatch(t *testing.T) {
+	mock := &mockSMClient{
+		secrets: map[string]map[string]string{
+			"prod/db":  {"AWSCURRENT": "db-pass"},
+			"prod/api": {"AWSCURRENT": "api-key"},
+		},
+	}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	got, err := p.GetBatch(context.Background(), []string{"prod/db", "prod/api", "missing"})
+	if err != nil {
+		t.Fatalf("GetBatch: %v", err)
+	}
+	if len(got) != 2 || string(got["prod/db"]) != "db-pass" || string(got["prod/api"]) != "api-key" {
+		t.Errorf("GetBatch = %q, want prod/db and prod/api only", got)
+	}
+
+	// Embedding hides the mock's BatchClient method.
+	ro, _ := New(WithClient(struct{ Client }{mock}))
+	if _, err := ro.GetBatch(context.Background(), []string{"prod/db"}); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestSDKClient_GetBatch(t *testing.T) {
+	var requests [][]string
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			SecretIdList []string
+			NextToken    string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		requests = append(requests, in.SecretIdList)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+
+		// Return the first secret of each chunk on a second page. Secrets
+		// requested by ARN are named by what follows "arn:".
+		var entries []string
+		if in.NextToken == "" {
+			for _, id := range in.SecretIdList[1:] {
+				name := strings.TrimPrefix(id, "arn:")
+				entries = append(entries, fmt.Sprintf(`{"Name": %q, "ARN": "arn:%s", "SecretString": "v-%s"}`, name, name, name))
+			}
+			fmt.Fprintf(w, `{"SecretValues": [%s], "NextToken": "page2"}`, strings.Join(entries, ","))
+			return
+		}
+		id := in.SecretIdList[0]
+		fmt.Fprintf(w, `{"SecretValues": [{"Name": %q, "SecretBinary": %q}]}`, id, base64.StdEncoding.EncodeToString([]byte("b-"+id)))
+	})
+
+	keys := make([]string, 25)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("s%d", i)
+	}
+	keys[24] = "arn:s24"
+	got, err := p.GetBatch(context.Background(), keys)
+	if err != nil {
+		t.Fatalf("GetBatch: %v", err)
+	}
+	if len(requests) != 4 || len(requests[0]) != 20 || len(requests[2]) != 5 {
+		t.Errorf("requests = %d with sizes %d and %d, want 4 with chunks of 20 and 5", len(requests), len(requests[0]), len(requests[2]))
+	}
+	if len(got) != 25 {
+		t.Errorf("got %d values, want 25", len(got))
+	}
+	if string(got["s0"]) != "b-s0" || string(got["s1"]) != "v-s1" || string(got["arn:s24"]) != "v-s24" {
+		t.Errorf("GetBatch = %q", got)
+	}
+}
+
+// roundTripFunc is an http.RoundTripper backed by a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestNew_EndpointCredentialsHTTPClient(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprint(w, `{"SecretString":"s3cret"}`)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	var creds, roundTrips int
+	cp := aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		creds++
+		return aws.Credentials{AccessKeyID: "AKIDASSUMED", SecretAccessKey: "secret", CanExpire: true, Expires: time.Now().Add(time.Hour)}, nil
+	})
+	hc := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		roundTrips++
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	p, err := New(WithRegion("us-east-1"), WithEndpoint(srv.URL), WithCredentialsProvider(cp), WithHTTPClient(hc))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for range 2 {
+		if val, err := p.Get(context.Background(), "prod/db"); err != nil || string(val) != "s3cret" {
+			t.Fatalf("Get = %q, %v", val, err)
+		}
+	}
+	if !strings.Contains(auth, "Credential=AKIDASSUMED/") {
+		t.Errorf("Authorization = %q, want it signed with the provided credentials", auth)
+	}
+	if creds != 1 {
+		t.Errorf("credentials retrieved %d times, want 1 (cached)", creds)
+	}
+	if roundTrips != 2 {
+		t.Errorf("round trips through the HTTP client = %d, want 2", roundTrips)
+	}
+}
+
+// stsHandler answers STS AssumeRole requests with temporary credentials whose
+// access key ID is akid, recording their form values in calls, and passes
+// other requests to next.
+func stsHandler(t *testing.T, akid string, calls *[]map[string]string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "" {
+			next(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse STS request: %v", err)
+		}
+		*calls = append(*calls, map[string]string{
+			"Action":     r.Form.Get("Action"),
+			"RoleArn":    r.Form.Get("RoleArn"),
+			"ExternalId": r.Form.Get("ExternalId"),
+		})
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleResult>
+<Credentials><AccessKeyId>%s</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>token</SessionToken><Expiration>%s</Expiration></Credentials>
+<AssumedRoleUser><Arn>arn:aws:sts::111122223333:assumed-role/reader/s</Arn><AssumedRoleId>AROA:s</AssumedRoleId></AssumedRoleUser>
+</AssumeRoleResult></AssumeRoleResponse>`, akid, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}
+}
+
+func TestWithAssumeRole(t *testing.T) {
+	var calls []map[string]string
+	var auth []string
+	p := newSDKProvider(t, stsHandler(t, "ASIAASSUMED", &calls, func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprint(w, `{"SecretString":"s3cret"}`)
+	}), WithAssumeRole("arn:aws:iam::111122223333:role/reader", "ext-42"))
+
+	for range 2 {
+		if val, err := p.Get(context.Background(), "prod/db"); err != nil || string(val) != "s3cret" {
+			t.Fatalf("Get = %q, %v", val, err)
+		}
+	}
+	want := []map[string]string{{"Action": "AssumeRole", "RoleArn": "arn:aws:iam::111122223333:role/reader", "ExternalId": "ext-42"}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("STS calls = %v, want %v (credentials cached)", calls, want)
+	}
+	for _, a := range auth {
+		if !strings.Contains(a, "Credential=ASIAASSUMED/") {
+			t.Errorf("Authorization = %q, want it signed with the assumed role's credentials", a)
+		}
+	}
+}
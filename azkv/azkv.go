@@ -6,9 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azcertificates"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	"github.com/brwse/go-secrets"
 )
@@ -18,6 +25,34 @@ type Client interface {
 	GetSecret(ctx context.Context, name, version string) (string, error)
 }
 
+// CheckClient is implemented by Clients that can verify a secret exists
+// without retrieving its value. The default SDK client lists the secret's
+// version properties.
+type CheckClient interface {
+	GetSecretProperties(ctx context.Context, name string) error
+}
+
+// MetadataClient is implemented by Clients that can describe a secret without
+// retrieving its value. The default SDK client lists the secret's version
+// properties.
+type MetadataClient interface {
+	Metadata(ctx context.Context, name string) (secrets.Metadata, error)
+}
+
+// ListerClient is implemented by Clients that can enumerate secrets. The
+// default SDK client lists secret properties.
+type ListerClient interface {
+	// ListSecrets returns the names of the secrets in the vault.
+	ListSecrets(ctx context.Context) ([]string, error)
+}
+
+// WriterClient is implemented by Clients that can create, update, and delete
+// secrets. The default SDK client uses SetSecret and DeleteSecret.
+type WriterClient interface {
+	SetSecret(ctx context.Context, name, value string) error
+	DeleteSecret(ctx context.Context, name string) error
+}
+
 // ProviderOption configures the azkv Provider.
 type ProviderOption func(*Provider)
 
@@ -28,6 +63,50 @@ func WithVaultURL(url string) ProviderOption {
 	}
 }
 
+// WithUserAgent adds ua, such as "billing-api/1.4.2", to the User-Agent of
+// requests made by the default SDK client, for attribution in Key Vault's
+// diagnostic logs. A user agent set on the Resolver with
+// secrets.WithUserAgent takes precedence. It has no effect on a Client
+// injected with WithClient.
+func WithUserAgent(ua string) ProviderOption {
+	return func(p *Provider) {
+		p.userAgent = ua
+	}
+}
+
+// WithCredential authenticates the default SDK client with cred instead of
+// DefaultAzureCredential.
+func WithCredential(cred azcore.TokenCredential) ProviderOption {
+	return func(p *Provider) {
+		p.newCredential = func() (azcore.TokenCredential, error) { return cred, nil }
+	}
+}
+
+// WithClientSecretCredential authenticates the default SDK client as the
+// service principal clientID in tenantID, using a client secret.
+func WithClientSecretCredential(tenantID, clientID, secret string) ProviderOption {
+	return func(p *Provider) {
+		p.newCredential = func() (azcore.TokenCredential, error) {
+			return azidentity.NewClientSecretCredential(tenantID, clientID, secret, nil)
+		}
+	}
+}
+
+// WithManagedIdentity authenticates the default SDK client with the
+// user-assigned managed identity clientID, or with the system-assigned
+// identity if clientID is empty.
+func WithManagedIdentity(clientID string) ProviderOption {
+	return func(p *Provider) {
+		p.newCredential = func() (azcore.TokenCredential, error) {
+			var opts azidentity.ManagedIdentityCredentialOptions
+			if clientID != "" {
+				opts.ID = azidentity.ClientID(clientID)
+			}
+			return azidentity.NewManagedIdentityCredential(&opts)
+		}
+	}
+}
+
 // WithClient injects a custom Client implementation.
 func WithClient(c Client) ProviderOption {
 	return func(p *Provider) {
@@ -36,15 +115,33 @@ func WithClient(c Client) ProviderOption {
 }
 
 // Provider reads secrets from Azure Key Vault.
+// It implements secrets.Provider, secrets.VersionedProvider,
+// secrets.CheckerProvider, secrets.MetadataProvider, secrets.ListerProvider,
+// and secrets.WriterProvider.
+//
+// Keys are secret names, or name other Key Vault objects, read as PEM for
+// []byte TLS fields:
+//   - "certificates/<name>": the certificate, which requires the
+//     certificates get permission;
+//   - "certificates/<name>/keypair": the certificate chain followed by the
+//     private key, read from the secret that backs the certificate, which
+//     requires the secrets get permission and an exportable key;
+//   - "keys/<name>": the public part of an RSA or EC key, which requires the
+//     keys get permission.
+//
+// Only secrets can be checked, described, listed, or written.
 type Provider struct {
-	vaultURL string
-	client   Client
+	vaultURL      string
+	userAgent     string
+	newCredential func() (azcore.TokenCredential, error) // nil for DefaultAzureCredential
+	client        Client
 }
 
 // New creates a new Azure Key Vault Provider.
 // WithVaultURL is required when not providing a custom Client via WithClient.
 // If no Client is provided, a real Azure SDK client is created
-// using DefaultAzureCredential.
+// using DefaultAzureCredential, unless WithCredential,
+// WithClientSecretCredential, or WithManagedIdentity gives another.
 func New(opts ...ProviderOption) (*Provider, error) {
 	p := &Provider{}
 	for _, opt := range opts {
@@ -54,40 +151,256 @@ func New(opts ...ProviderOption) (*Provider, error) {
 		if p.vaultURL == "" {
 			return nil, fmt.Errorf("azkv: vault URL is required (use WithVaultURL)")
 		}
-		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		cred, err := p.credential()
 		if err != nil {
 			return nil, fmt.Errorf("azkv: create Azure credential: %w", err)
 		}
-		azClient, err := azsecrets.NewClient(p.vaultURL, cred, nil)
+		c, err := newSDKClient(p.vaultURL, cred, p.clientOptions())
 		if err != nil {
 			return nil, fmt.Errorf("azkv: create Key Vault client: %w", err)
 		}
-		p.client = &sdkClient{kv: azClient}
+		p.client = c
 	}
 	return p, nil
 }
 
+// credential returns the credential the default SDK client authenticates
+// with.
+func (p *Provider) credential() (azcore.TokenCredential, error) {
+	if p.newCredential != nil {
+		return p.newCredential()
+	}
+	return azidentity.NewDefaultAzureCredential(nil)
+}
+
+// clientOptions returns the SDK client options for the provider options.
+func (p *Provider) clientOptions() *azsecrets.ClientOptions {
+	return &azsecrets.ClientOptions{
+		ClientOptions: azcore.ClientOptions{
+			PerCallPolicies: []policy.Policy{userAgentPolicy{ua: p.userAgent}},
+		},
+	}
+}
+
+// userAgentPolicy prefixes the SDK's User-Agent with the user agent carried
+// by the request context, or else ua.
+type userAgentPolicy struct {
+	ua string
+}
+
+func (up userAgentPolicy) Do(req *policy.Request) (*http.Response, error) {
+	ua := secrets.UserAgentFromContext(req.Raw().Context())
+	if ua == "" {
+		ua = up.ua
+	}
+	if ua != "" {
+		h := req.Raw().Header
+		if sdk := h.Get("User-Agent"); sdk != "" {
+			ua += " " + sdk
+		}
+		h.Set("User-Agent", ua)
+	}
+	return req.Next()
+}
+
 // Get retrieves the current version of the secret.
 func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
 	return p.GetVersion(ctx, key, "current")
 }
 
-// GetVersion retrieves a specific version of the secret.
+// GetVersion retrieves a specific version of the secret, or of the
+// certificate or key that key names.
 func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
 	v := version
 	if v == "current" {
 		v = ""
 	}
+	kind, name, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case kindCertificate:
+		return p.getCertificate(ctx, key, name, v)
+	case kindKeypair:
+		return p.getKeypair(ctx, key, name, v)
+	case kindKey:
+		return p.getKey(ctx, key, name, v)
+	}
 	val, err := p.client.GetSecret(ctx, key, v)
 	if err != nil {
-		return nil, fmt.Errorf("azkv: secret %q: %w", key, err)
+		return nil, fmt.Errorf("azkv: secret %q: %w", key, providerError(err))
 	}
 	return []byte(val), nil
 }
 
+// Check verifies the secret exists and is accessible by listing its version
+// properties, without retrieving its value. This requires the secrets list
+// permission rather than get.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement CheckClient.
+func (p *Provider) Check(ctx context.Context, key string) error {
+	if err := secretOnly(key); err != nil {
+		return err
+	}
+	cc, ok := p.client.(CheckClient)
+	if !ok {
+		return fmt.Errorf("azkv: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	if err := cc.GetSecretProperties(ctx, key); err != nil {
+		return fmt.Errorf("azkv: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// GetMetadata describes the secret by listing its version properties, without
+// retrieving its value. CreatedAt is the creation time of the first version;
+// RotatedAt, Version, ExpiresAt, and Tags describe the most recently created
+// enabled version, which is the one Get returns. This requires the secrets
+// list permission rather than get.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement
+// MetadataClient.
+func (p *Provider) GetMetadata(ctx context.Context, key string) (secrets.Metadata, error) {
+	if err := secretOnly(key); err != nil {
+		return secrets.Metadata{}, err
+	}
+	mc, ok := p.client.(MetadataClient)
+	if !ok {
+		return secrets.Metadata{}, fmt.Errorf("azkv: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	md, err := mc.Metadata(ctx, key)
+	if err != nil {
+		return secrets.Metadata{}, fmt.Errorf("azkv: secret %q: %w", key, providerError(err))
+	}
+	return md, nil
+}
+
+// List returns the names of the secrets in the vault that start with prefix.
+// This requires the secrets list permission.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// ListerClient.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	lc, ok := p.client.(ListerClient)
+	if !ok {
+		return nil, fmt.Errorf("azkv: list %q: %w", prefix, errors.ErrUnsupported)
+	}
+	names, err := lc.ListSecrets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("azkv: list %q: %w", prefix, providerError(err))
+	}
+	var keys []string
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			keys = append(keys, name)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Set stores value as a new version of the secret, creating the secret if it
+// does not exist. Key Vault secret values are text, so value is stored as a
+// string. This requires the secrets set permission.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient.
+func (p *Provider) Set(ctx context.Context, key string, value []byte) error {
+	if err := secretOnly(key); err != nil {
+		return err
+	}
+	wc, ok := p.client.(WriterClient)
+	if !ok {
+		return fmt.Errorf("azkv: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	if err := wc.SetSecret(ctx, key, string(value)); err != nil {
+		return fmt.Errorf("azkv: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// Delete deletes the secret and all of its versions. In vaults with soft
+// delete enabled the secret is recoverable, and its name cannot be reused,
+// until it is purged. This requires the secrets delete permission.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient.
+func (p *Provider) Delete(ctx context.Context, key string) error {
+	if err := secretOnly(key); err != nil {
+		return err
+	}
+	wc, ok := p.client.(WriterClient)
+	if !ok {
+		return fmt.Errorf("azkv: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	if err := wc.DeleteSecret(ctx, key); err != nil {
+		return fmt.Errorf("azkv: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// providerError wraps an *azcore.ResponseError in a *secrets.ErrProvider
+// carrying the status, error code, request ID, and retry delay of the failed
+// call. Other errors are returned unchanged.
+func providerError(err error) error {
+	var re *azcore.ResponseError
+	if !errors.As(err, &re) {
+		return err
+	}
+	pe := &secrets.ErrProvider{StatusCode: re.StatusCode, Code: re.ErrorCode, Err: err}
+	if re.RawResponse != nil {
+		pe.RequestID = re.RawResponse.Header.Get("x-ms-request-id")
+		pe.RetryAfter = retryAfter(re.RawResponse.Header)
+	}
+	return pe
+}
+
+// retryAfter returns the delay requested by the retry-after-ms,
+// x-ms-retry-after-ms, or Retry-After header of a response, or 0.
+func retryAfter(h http.Header) time.Duration {
+	for _, name := range []string{"retry-after-ms", "x-ms-retry-after-ms"} {
+		if ms, err := strconv.Atoi(h.Get(name)); err == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond
+		}
+	}
+	v := h.Get("Retry-After")
+	if s, err := strconv.Atoi(v); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
 // sdkClient wraps the real Azure Key Vault SDK.
 type sdkClient struct {
-	kv *azsecrets.Client
+	kv    *azsecrets.Client
+	certs *azcertificates.Client
+	keys  *azkeys.Client
+}
+
+// newSDKClient returns an sdkClient for the vault at url, configured with
+// opts.
+func newSDKClient(url string, cred azcore.TokenCredential, opts *azsecrets.ClientOptions) (*sdkClient, error) {
+	kv, err := azsecrets.NewClient(url, cred, opts)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := azcertificates.NewClient(url, cred, &azcertificates.ClientOptions{
+		ClientOptions:                        opts.ClientOptions,
+		DisableChallengeResourceVerification: opts.DisableChallengeResourceVerification,
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys, err := azkeys.NewClient(url, cred, &azkeys.ClientOptions{
+		ClientOptions:                        opts.ClientOptions,
+		DisableChallengeResourceVerification: opts.DisableChallengeResourceVerification,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sdkClient{kv: kv, certs: certs, keys: keys}, nil
 }
 
 func (c *sdkClient) GetSecret(ctx context.Context, name, version string) (string, error) {
@@ -104,3 +417,105 @@ func (c *sdkClient) GetSecret(ctx context.Context, name, version string) (string
 	}
 	return *resp.Value, nil
 }
+
+func (c *sdkClient) GetSecretProperties(ctx context.Context, name string) error {
+	pager := c.kv.NewListSecretPropertiesVersionsPager(name, nil)
+	page, err := pager.NextPage(ctx)
+	if err != nil {
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return err
+	}
+	if len(page.Value) == 0 {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return nil
+}
+
+func (c *sdkClient) Metadata(ctx context.Context, name string) (secrets.Metadata, error) {
+	var md secrets.Metadata
+	found := false
+	pager := c.kv.NewListSecretPropertiesVersionsPager(name, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			var respErr *azcore.ResponseError
+			if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+				return secrets.Metadata{}, fmt.Errorf("%w", secrets.ErrNotFound)
+			}
+			return secrets.Metadata{}, err
+		}
+		for _, props := range page.Value {
+			attrs := props.Attributes
+			if attrs == nil || attrs.Created == nil {
+				continue
+			}
+			found = true
+			if md.CreatedAt.IsZero() || attrs.Created.Before(md.CreatedAt) {
+				md.CreatedAt = *attrs.Created
+			}
+			if (attrs.Enabled != nil && !*attrs.Enabled) || !attrs.Created.After(md.RotatedAt) {
+				continue
+			}
+			md.RotatedAt = *attrs.Created
+			md.Version = ""
+			if props.ID != nil {
+				md.Version = props.ID.Version()
+			}
+			md.ExpiresAt = time.Time{}
+			if attrs.Expires != nil {
+				md.ExpiresAt = *attrs.Expires
+			}
+			md.Tags = nil
+			for k, v := range props.Tags {
+				if v == nil {
+					continue
+				}
+				if md.Tags == nil {
+					md.Tags = make(map[string]string, len(props.Tags))
+				}
+				md.Tags[k] = *v
+			}
+		}
+	}
+	if !found {
+		return secrets.Metadata{}, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return md, nil
+}
+
+func (c *sdkClient) SetSecret(ctx context.Context, name, value string) error {
+	_, err := c.kv.SetSecret(ctx, name, azsecrets.SetSecretParameters{Value: &value}, nil)
+	return err
+}
+
+func (c *sdkClient) DeleteSecret(ctx context.Context, name string) error {
+	_, err := c.kv.DeleteSecret(ctx, name, nil)
+	if err != nil {
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *sdkClient) ListSecrets(ctx context.Context) ([]string, error) {
+	var names []string
+	pager := c.kv.NewListSecretPropertiesPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, props := range page.Value {
+			if props.ID != nil {
+				names = append(names, props.ID.Name())
+			}
+		}
+	}
+	return names, nil
+}
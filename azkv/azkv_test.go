@@ -4,8 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/brwse/go-secrets"
 )
 
@@ -28,6 +35,40 @@ func (m *mockKVClient) GetSecret(_ context.Context, name, version string) (strin
 	return val, nil
 }
 
+func (m *mockKVClient) GetSecretProperties(_ context.Context, name string) error {
+	if _, ok := m.secrets[name]; !ok {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return nil
+}
+
+func (m *mockKVClient) SetSecret(_ context.Context, name, value string) error {
+	if m.secrets == nil {
+		m.secrets = make(map[string]map[string]string)
+	}
+	if m.secrets[name] == nil {
+		m.secrets[name] = make(map[string]string)
+	}
+	m.secrets[name][""] = value
+	return nil
+}
+
+func (m *mockKVClient) DeleteSecret(_ context.Context, name string) error {
+	if _, ok := m.secrets[name]; !ok {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	delete(m.secrets, name)
+	return nil
+}
+
+func (m *mockKVClient) ListSecrets(context.Context) ([]string, error) {
+	var names []string
+	for name := range m.secrets {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
 func TestGet_Existing(t *testing.T) {
 	mock := &mockKVClient{
 		secrets: map[string]map[string]string{
@@ -90,3 +131,254 @@ func TestGetVersion_Specific(t *testing.T) {
 		t.Errorf("GetVersion = %q, want %q", val, "old-key")
 	}
 }
+
+func TestCheck(t *testing.T) {
+	mock := &mockKVClient{
+		secrets: map[string]map[string]string{
+			"db-password": {"": "s3cret"},
+		},
+	}
+	p, err := New(WithVaultURL("https://my-vault.vault.azure.net"), WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "db-password"); err != nil {
+		t.Errorf("Check existing: %v", err)
+	}
+	if err := p.Check(context.Background(), "nonexistent"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check missing: expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestCheck_Unsupported(t *testing.T) {
+	// Embedding hides the mock's CheckClient method.
+	p, err := New(WithVaultURL("https://my-vault.vault.azure.net"), WithClient(struct{ Client }{&mockKVClient{}}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "db-password"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+}
+
+// staticCredential is an azcore.TokenCredential returning a fixed token.
+func TestList(t *testing.T) {
+	mock := &mockKVClient{secrets: map[string]map[string]string{
+		"db-password": {"": "a"},
+		"db-user":     {"": "b"},
+		"api-key":     {"": "c"},
+	}}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	got, err := p.List(context.Background(), "db-")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := "db-password,db-user"; strings.Join(got, ",") != want {
+		t.Errorf("List(db-) = %q, want %q", got, want)
+	}
+
+	ro, _ := New(WithClient(struct{ Client }{mock}))
+	if _, err := ro.List(context.Background(), ""); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("List: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	mock := &mockKVClient{}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "api-key", []byte("s3cret")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := p.Get(ctx, "api-key"); err != nil || string(got) != "s3cret" {
+		t.Errorf("Get = %q, %v, want %q", got, err, "s3cret")
+	}
+	if err := p.Delete(ctx, "api-key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := p.Delete(ctx, "api-key"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Delete missing: expected ErrNotFound, got: %v", err)
+	}
+
+	ro, _ := New(WithClient(struct{ Client }{mock}))
+	if err := ro.Set(ctx, "api-key", nil); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Set: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+type staticCredential struct{}
+
+func (staticCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// newSDKProvider returns a Provider with the given user agent whose SDK
+// client sends authenticated requests to handler.
+func newSDKProvider(t *testing.T, userAgent string, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			// Key Vault clients authenticate in response to a challenge.
+			w.Header().Set("WWW-Authenticate", `Bearer authorization="https://login.microsoftonline.com/tenant", resource="https://vault.azure.net"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := &Provider{userAgent: userAgent}
+	opts := p.clientOptions()
+	opts.Transport = srv.Client()
+	opts.DisableChallengeResourceVerification = true
+	c, err := newSDKClient(srv.URL, staticCredential{}, opts)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	p.client = c
+	return p
+}
+
+func TestGetMetadata(t *testing.T) {
+	p := newSDKProvider(t, "", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/secrets/db/versions" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":"SecretNotFound"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"value":[
+			{"id":"https://vault/secrets/db/v1","attributes":{"enabled":true,"created":1700000000}},
+			{"id":"https://vault/secrets/db/v3","attributes":{"enabled":false,"created":1715000000}},
+			{"id":"https://vault/secrets/db/v2","attributes":{"enabled":true,"created":1710000000,"exp":1720000000},"tags":{"team":"billing"}}
+		]}`)
+	})
+
+	md, err := p.GetMetadata(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if !md.CreatedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("CreatedAt = %v, want the first version's creation time", md.CreatedAt)
+	}
+	if !md.RotatedAt.Equal(time.Unix(1710000000, 0)) {
+		t.Errorf("RotatedAt = %v, want the latest enabled version's creation time", md.RotatedAt)
+	}
+	if md.Version != "v2" {
+		t.Errorf("Version = %q, want v2", md.Version)
+	}
+	if !md.ExpiresAt.Equal(time.Unix(1720000000, 0)) {
+		t.Errorf("ExpiresAt = %v", md.ExpiresAt)
+	}
+	if md.Tags["team"] != "billing" {
+		t.Errorf("Tags = %v", md.Tags)
+	}
+
+	if _, err := p.GetMetadata(context.Background(), "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	var got []string
+	p := newSDKProvider(t, "billing-api/1.4.2", func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+		fmt.Fprint(w, `{"value":"s3cret","id":"https://vault/secrets/db/1"}`)
+	})
+	if _, err := p.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	ctx := secrets.ContextWithUserAgent(context.Background(), "billing-worker/2.0")
+	if _, err := p.Get(ctx, "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d requests, want 2", len(got))
+	}
+	if !strings.HasPrefix(got[0], "billing-api/1.4.2 azsdk-go-") {
+		t.Errorf("User-Agent = %q, want billing-api/1.4.2 before the SDK's", got[0])
+	}
+	if !strings.HasPrefix(got[1], "billing-worker/2.0 azsdk-go-") {
+		t.Errorf("User-Agent = %q, want billing-worker/2.0 before the SDK's", got[1])
+	}
+}
+
+func TestSDKClient_ErrProvider(t *testing.T) {
+	p := newSDKProvider(t, "", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-ms-request-id", "req-123")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"error":{"code":"Forbidden","message":"The user does not have secrets get permission"}}`)
+	})
+
+	_, err := p.Get(context.Background(), "db")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected ErrProvider, got %v", err)
+	}
+	if pe.StatusCode != http.StatusForbidden || pe.Code != "Forbidden" || pe.RequestID != "req-123" {
+		t.Errorf("ErrProvider = {%d %q %q}, want {403 \"Forbidden\" \"req-123\"}", pe.StatusCode, pe.Code, pe.RequestID)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		want   time.Duration
+	}{
+		{http.Header{}, 0},
+		{http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{http.Header{"Retry-After": {"3"}, "Retry-After-Ms": {"1500"}}, 1500 * time.Millisecond},
+		{http.Header{"X-Ms-Retry-After-Ms": {"250"}}, 250 * time.Millisecond},
+		{http.Header{"Retry-After": {"Mon, 02 Jan 2006 15:04:05 GMT"}}, 0},
+		{http.Header{"Retry-After": {"soon"}}, 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.header); got != tt.want {
+			t.Errorf("retryAfter(%v) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCredentialOptions(t *testing.T) {
+	p, err := New(WithVaultURL("https://example.vault.azure.net"), WithCredential(staticCredential{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, ok := p.client.(*sdkClient); !ok {
+		t.Errorf("client = %T, want the SDK client", p.client)
+	}
+	if cred, err := p.credential(); err != nil || cred != (staticCredential{}) {
+		t.Errorf("credential() = %v, %v; want the injected credential", cred, err)
+	}
+
+	p = &Provider{}
+	WithClientSecretCredential("tenant", "client", "secret")(p)
+	if cred, err := p.credential(); err != nil {
+		t.Errorf("client secret credential: %v", err)
+	} else if _, ok := cred.(*azidentity.ClientSecretCredential); !ok {
+		t.Errorf("credential() = %T, want *azidentity.ClientSecretCredential", cred)
+	}
+	WithClientSecretCredential("not a tenant!", "client", "secret")(p)
+	if _, err := p.credential(); err == nil {
+		t.Error("credential() succeeded with an invalid tenant ID")
+	}
+
+	for _, clientID := range []string{"", "00000000-0000-0000-0000-000000000001"} {
+		WithManagedIdentity(clientID)(p)
+		if cred, err := p.credential(); err != nil {
+			t.Errorf("managed identity %q: %v", clientID, err)
+		} else if _, ok := cred.(*azidentity.ManagedIdentityCredential); !ok {
+			t.Errorf("credential() = %T, want *azidentity.ManagedIdentityCredential", cred)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package azkv
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/brwse/go-secrets"
+	"golang.org/x/crypto/pkcs12"
+)
+
+// CertificateClient is implemented by Clients that can retrieve Key Vault
+// certificates. The default SDK client uses the azcertificates GetCertificate
+// operation.
+type CertificateClient interface {
+	// GetCertificate returns the DER-encoded X.509 certificate.
+	GetCertificate(ctx context.Context, name, version string) ([]byte, error)
+}
+
+// KeyClient is implemented by Clients that can retrieve Key Vault keys. The
+// default SDK client uses the azkeys GetKey operation.
+type KeyClient interface {
+	// GetKey returns the public part of the key.
+	GetKey(ctx context.Context, name, version string) (crypto.PublicKey, error)
+}
+
+// objectKind is the kind of Key Vault object a key names.
+type objectKind int
+
+const (
+	kindSecret      objectKind = iota // "<name>"
+	kindCertificate                   // "certificates/<name>"
+	kindKeypair                       // "certificates/<name>/keypair"
+	kindKey                           // "keys/<name>"
+)
+
+// parseKey returns the kind and name of the Key Vault object key names.
+func parseKey(key string) (objectKind, string, error) {
+	kind, name := kindSecret, key
+	if rest, ok := strings.CutPrefix(key, "certificates/"); ok {
+		kind, name = kindCertificate, rest
+		if rest, ok := strings.CutSuffix(rest, "/keypair"); ok {
+			kind, name = kindKeypair, rest
+		}
+	} else if rest, ok := strings.CutPrefix(key, "keys/"); ok {
+		kind, name = kindKey, rest
+	}
+	if name == "" || strings.Contains(name, "/") {
+		return 0, "", fmt.Errorf("azkv: %q: invalid key: want <secret>, certificates/<name>[/keypair], or keys/<name>", key)
+	}
+	return kind, name, nil
+}
+
+// secretOnly returns an error wrapping errors.ErrUnsupported if key names a
+// certificate or key rather than a secret.
+func secretOnly(key string) error {
+	kind, _, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+	if kind != kindSecret {
+		return fmt.Errorf("azkv: %q: only secrets support this operation: %w", key, errors.ErrUnsupported)
+	}
+	return nil
+}
+
+// getCertificate returns the certificate name as PEM.
+func (p *Provider) getCertificate(ctx context.Context, key, name, version string) ([]byte, error) {
+	cc, ok := p.client.(CertificateClient)
+	if !ok {
+		return nil, fmt.Errorf("azkv: certificate %q: %w", key, errors.ErrUnsupported)
+	}
+	der, err := cc.GetCertificate(ctx, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("azkv: certificate %q: %w", key, providerError(err))
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// getKeypair returns the certificate chain and private key of certificate
+// name as PEM, read from the secret that backs the certificate.
+func (p *Provider) getKeypair(ctx context.Context, key, name, version string) ([]byte, error) {
+	val, err := p.client.GetSecret(ctx, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("azkv: certificate %q: %w", key, providerError(err))
+	}
+	// Certificates created with the PEM content type are stored as PEM;
+	// others as base64-encoded PKCS#12.
+	if strings.HasPrefix(strings.TrimSpace(val), "-----BEGIN") {
+		return []byte(val), nil
+	}
+	pfx, err := base64.StdEncoding.DecodeString(val)
+	if err != nil {
+		return nil, fmt.Errorf("azkv: certificate %q: decode PKCS#12: %w", key, err)
+	}
+	blocks, err := pkcs12.ToPEM(pfx, "")
+	if err != nil {
+		return nil, fmt.Errorf("azkv: certificate %q: %w", key, err)
+	}
+	// Certificates first, then the key, as tls.X509KeyPair expects them.
+	var certs, keys bytes.Buffer
+	for _, b := range blocks {
+		b.Headers = nil
+		if b.Type == "CERTIFICATE" {
+			pem.Encode(&certs, b)
+		} else {
+			pem.Encode(&keys, b)
+		}
+	}
+	return append(certs.Bytes(), keys.Bytes()...), nil
+}
+
+// getKey returns the public part of key name as PEM.
+func (p *Provider) getKey(ctx context.Context, key, name, version string) ([]byte, error) {
+	kc, ok := p.client.(KeyClient)
+	if !ok {
+		return nil, fmt.Errorf("azkv: key %q: %w", key, errors.ErrUnsupported)
+	}
+	pub, err := kc.GetKey(ctx, name, version)
+	if err != nil {
+		return nil, fmt.Errorf("azkv: key %q: %w", key, providerError(err))
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("azkv: key %q: %w", key, err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// publicKey returns the public key of an RSA or EC JSON Web Key.
+func publicKey(jwk *azkeys.JSONWebKey) (crypto.PublicKey, error) {
+	if jwk == nil || jwk.Kty == nil {
+		return nil, errors.New("response has no key")
+	}
+	switch *jwk.Kty {
+	case azkeys.KeyTypeRSA, azkeys.KeyTypeRSAHSM:
+		if len(jwk.N) == 0 || len(jwk.E) == 0 {
+			return nil, errors.New("RSA key has no modulus or exponent")
+		}
+		e := new(big.Int).SetBytes(jwk.E)
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA key exponent is too large")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(jwk.N), E: int(e.Int64())}, nil
+	case azkeys.KeyTypeEC, azkeys.KeyTypeECHSM:
+		var curve elliptic.Curve
+		switch c := curveName(jwk.Crv); c {
+		case azkeys.CurveNameP256:
+			curve = elliptic.P256()
+		case azkeys.CurveNameP384:
+			curve = elliptic.P384()
+		case azkeys.CurveNameP521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", c)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(jwk.X), Y: new(big.Int).SetBytes(jwk.Y)}, nil
+	default:
+		// Symmetric (oct) keys never leave the vault.
+		return nil, fmt.Errorf("unsupported key type %q", *jwk.Kty)
+	}
+}
+
+func curveName(c *azkeys.CurveName) azkeys.CurveName {
+	if c == nil {
+		return ""
+	}
+	return *c
+}
+
+func (c *sdkClient) GetCertificate(ctx context.Context, name, version string) ([]byte, error) {
+	resp, err := c.certs.GetCertificate(ctx, name, version, nil)
+	if err != nil {
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return nil, err
+	}
+	if len(resp.CER) == 0 {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return resp.CER, nil
+}
+
+func (c *sdkClient) GetKey(ctx context.Context, name, version string) (crypto.PublicKey, error) {
+	resp, err := c.keys.GetKey(ctx, name, version, nil)
+	if err != nil {
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return nil, err
+	}
+	return publicKey(resp.Key)
+}
@@ -0,0 +1,254 @@
+package azkv
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// testPFX is a PKCS#12 archive without a password holding a self-signed EC
+// certificate for api.example.com and its key, as Key Vault stores the
+// secret backing a certificate.
+const testPFX = `
+MIIDigIBAzCCA1AGCSqGSIb3DQEHAaCCA0EEggM9MIIDOTCCAi8GCSqGSIb3DQEHBqCCAiAwggIc
+AgEAMIICFQYJKoZIhvcNAQcBMBwGCiqGSIb3DQEMAQYwDgQI3JSdnei409UCAggAgIIB6NoN9pQQ
+tMTcqZvbIU5839AImX3V5scA/QvIHicSGKDCHIKBONLGd2FZRGiE5vMEyF8vAs0MrnOdMOfO/Bij
+rTGOj8Zp4ggyOGmr2Yz5M7ExN0qVUGdnjUcv3O0uHYZEGB301S/q5lIe+eTlflw4j1K6cPalJLYU
+FmuPIhYgu5RmBwRIq6YPJRdSXxf5t6l/+AsMn+0pS0LdOg8HvpTQ283NpgR7c9CJGKaJp6AsOGGg
+Y1zHG66cAOyTrQ6DxsO8wF+sxvcsisibiOv4Lp+vTB0aM1nxCAdoKF5a0unDuOk9zxFQq8SxmgtH
+agF/fuUa3hvjL+xro1SilF2+pYlmi21sBYSL6Y2ecPx+p5TkcnB5fqv7aqyqqV0I9Z8XzSGHJnzb
+6mdqp9ru5onTLnVYhRbmiRhiv0OZaO0wbsvoYfpNVF8aGbO+ytBSEVqZ8I+sc1Yu1/kGxcvy9oU8
+bYGtCogX2eVLAZqBxNrLiFmZLPP7F9PjIu8G92ivxI6AR5FNnrqiI+KTHmVPnQWpEKw2JRNKGaPq
+QwbJJgdo7HBKSlrBrlSSFOO5INOHiogeqx9WcX3uVV/5b49VEiw85iHwRWuN4JfBVwS/XOKFdZdp
+p7rlBKz7f7+kgFQfIgVwAP49LtuKCMIj77RKMIIBAgYJKoZIhvcNAQcBoIH0BIHxMIHuMIHrBgsq
+hkiG9w0BDAoBAqCBtDCBsTAcBgoqhkiG9w0BDAEDMA4ECMR9BkSyQAruAgIIAASBkERcMea9O2k7
+Jx8zOmhmPaQi9k3DrDW8hC8StLT4quktMb22FH4xgy1yRRfD0BObuqN9zjEL3ZZlEI46BixoR2mX
+0vBxYy8Aq9eZJacKh0dZUvuB/KCwwWZArtoxw3OEaYB+FaZ/PFJiqp+S40mVe3Qm5OUGg0rPz2HG
+IS9u8F5kUN5WG1Nj39RFd1eproAH3TElMCMGCSqGSIb3DQEJFTEWBBToN99A397q/f7U+/LmIyJg
+eQoNcDAxMCEwCQYFKw4DAhoFAAQUghK7Rnzj3wuEdF0EA50k2rw48i8ECJUd8JkTrYQ4AgIIAA==
+`
+
+// mockObjectClient adds certificates and keys to mockKVClient.
+type mockObjectClient struct {
+	mockKVClient
+	certs map[string][]byte           // name to DER
+	keys  map[string]crypto.PublicKey // name to public key
+}
+
+func (m *mockObjectClient) GetCertificate(_ context.Context, name, _ string) ([]byte, error) {
+	der, ok := m.certs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return der, nil
+}
+
+func (m *mockObjectClient) GetKey(_ context.Context, name, _ string) (crypto.PublicKey, error) {
+	pub, ok := m.keys[name]
+	if !ok {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return pub, nil
+}
+
+// selfSigned returns a DER-encoded self-signed certificate for priv.
+func selfSigned(t *testing.T, priv *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "api.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		kind objectKind
+		name string
+	}{
+		{"db", kindSecret, "db"},
+		{"certificates/api", kindCertificate, "api"},
+		{"certificates/api/keypair", kindKeypair, "api"},
+		{"keys/signing", kindKey, "signing"},
+	}
+	for _, tc := range tests {
+		kind, name, err := parseKey(tc.key)
+		if err != nil || kind != tc.kind || name != tc.name {
+			t.Errorf("parseKey(%q) = %v, %q, %v; want %v, %q", tc.key, kind, name, err, tc.kind, tc.name)
+		}
+	}
+	for _, key := range []string{"certificates/", "keys/", "keys/a/b", "certificates/a/pem", "certificates//keypair"} {
+		if _, _, err := parseKey(key); err == nil {
+			t.Errorf("parseKey(%q) succeeded, want error", key)
+		}
+	}
+}
+
+func TestGet_Certificate(t *testing.T) {
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der := selfSigned(t, priv)
+	p, _ := New(WithClient(&mockObjectClient{certs: map[string][]byte{"api": der}}))
+
+	val, err := p.Get(context.Background(), "certificates/api")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	block, rest := pem.Decode(val)
+	if block == nil || block.Type != "CERTIFICATE" || string(block.Bytes) != string(der) || len(rest) != 0 {
+		t.Errorf("Get = %q, want the certificate as a single PEM block", val)
+	}
+
+	if _, err := p.Get(context.Background(), "certificates/missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestGet_Keypair(t *testing.T) {
+	pfx := strings.Join(strings.Fields(testPFX), "")
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pemPair := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: selfSigned(t, priv)})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	p, _ := New(WithClient(&mockKVClient{secrets: map[string]map[string]string{
+		"api":     {"": pfx},
+		"api-pem": {"": pemPair},
+		"bad":     {"": "not base64!"},
+	}}))
+	ctx := context.Background()
+
+	val, err := p.Get(ctx, "certificates/api/keypair")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	pair, err := tls.X509KeyPair(val, val)
+	if err != nil {
+		t.Fatalf("X509KeyPair: %v\n%s", err, val)
+	}
+	if pair.Leaf.Subject.CommonName != "api.example.com" {
+		t.Errorf("CommonName = %q, want api.example.com", pair.Leaf.Subject.CommonName)
+	}
+	if strings.Contains(string(val), "localKeyId") {
+		t.Errorf("Get = %q, want PEM without PKCS#12 attributes", val)
+	}
+
+	if val, err := p.Get(ctx, "certificates/api-pem/keypair"); err != nil || string(val) != pemPair {
+		t.Errorf("Get = %q, %v; want the PEM secret unchanged", val, err)
+	}
+	if _, err := p.Get(ctx, "certificates/bad/keypair"); err == nil || !strings.Contains(err.Error(), "decode PKCS#12") {
+		t.Errorf("err = %v, want a decode error", err)
+	}
+	if _, err := p.Get(ctx, "certificates/missing/keypair"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestGet_Key(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p, _ := New(WithClient(&mockObjectClient{keys: map[string]crypto.PublicKey{
+		"ec":  &ecKey.PublicKey,
+		"rsa": &rsaKey.PublicKey,
+	}}))
+
+	for name, want := range map[string]crypto.PublicKey{"ec": &ecKey.PublicKey, "rsa": &rsaKey.PublicKey} {
+		val, err := p.Get(context.Background(), "keys/"+name)
+		if err != nil {
+			t.Fatalf("Get %s: %v", name, err)
+		}
+		block, _ := pem.Decode(val)
+		if block == nil || block.Type != "PUBLIC KEY" {
+			t.Fatalf("Get %s = %q, want a PUBLIC KEY block", name, val)
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil || !pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(want) {
+			t.Errorf("Get %s: key = %v, %v; want the stored key", name, pub, err)
+		}
+	}
+}
+
+func TestObjects_Unsupported(t *testing.T) {
+	p, _ := New(WithClient(&mockKVClient{}))
+	ctx := context.Background()
+	for _, key := range []string{"certificates/api", "keys/signing"} {
+		if _, err := p.Get(ctx, key); !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("Get %s: expected ErrUnsupported, got: %v", key, err)
+		}
+	}
+
+	p, _ = New(WithClient(&mockObjectClient{}))
+	if err := p.Set(ctx, "certificates/api", []byte("x")); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Set: expected ErrUnsupported, got: %v", err)
+	}
+	if err := p.Delete(ctx, "keys/signing"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Delete: expected ErrUnsupported, got: %v", err)
+	}
+	if _, err := p.Get(ctx, "keys/a/b"); err == nil || errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Get: err = %v, want an invalid key error", err)
+	}
+}
+
+func TestSDKClient_Objects(t *testing.T) {
+	priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der := selfSigned(t, priv)
+	b64 := base64.RawURLEncoding.EncodeToString
+	p := newSDKProvider(t, "", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/certificates/api/":
+			fmt.Fprintf(w, `{"id":"https://vault/certificates/api/1","cer":%q}`, base64.StdEncoding.EncodeToString(der))
+		case "/keys/signing/":
+			fmt.Fprintf(w, `{"key":{"kid":"https://vault/keys/signing/1","kty":"EC","crv":"P-256","x":%q,"y":%q}}`,
+				b64(priv.X.FillBytes(make([]byte, 32))), b64(priv.Y.FillBytes(make([]byte, 32))))
+		case "/keys/secret/":
+			fmt.Fprint(w, `{"key":{"kid":"https://vault/keys/secret/1","kty":"oct"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":"NotFound"}}`)
+		}
+	})
+	ctx := context.Background()
+
+	val, err := p.Get(ctx, "certificates/api")
+	if block, _ := pem.Decode(val); err != nil || block == nil || string(block.Bytes) != string(der) {
+		t.Errorf("Get certificate = %q, %v", val, err)
+	}
+	val, err = p.Get(ctx, "keys/signing")
+	if err != nil {
+		t.Fatalf("Get key: %v", err)
+	}
+	block, _ := pem.Decode(val)
+	if pub, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil || !priv.PublicKey.Equal(pub) {
+		t.Errorf("Get key = %v, %v; want the vault's key", pub, err)
+	}
+	if _, err := p.Get(ctx, "keys/secret"); err == nil || !strings.Contains(err.Error(), "unsupported key type") {
+		t.Errorf("err = %v, want unsupported key type", err)
+	}
+	for _, key := range []string{"certificates/missing", "keys/missing"} {
+		if _, err := p.Get(ctx, key); !errors.Is(err, secrets.ErrNotFound) {
+			t.Errorf("Get %s: expected ErrNotFound, got: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// maxBootstrapLength bounds the length of generated values.
+const maxBootstrapLength = 4096
+
+// passwordAlphabet is the alphabet of generated passwords. It avoids
+// characters that need quoting in shells, URLs, and connection strings.
+const passwordAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// WithBootstrap enables first-run bootstrap. When a field tagged
+// `bootstrap=generate:N` refers to a secret that does not exist, Resolve
+// generates a random value, stores it with the field's provider, and uses it,
+// so a new service can be deployed before its keys have been created:
+//
+//	type Config struct {
+//		SessionKey []byte `secret:"awssm://billing/session-key,bootstrap=generate:32:base64,transform=base64"`
+//		AdminPass  string `secret:"awssm://billing/admin,bootstrap=generate:24"`
+//	}
+//
+// generate:N generates a password of N letters and digits; generate:N:hex and
+// generate:N:base64 generate N random bytes in that encoding. The provider
+// must implement WriterProvider. Without WithBootstrap, the option is ignored
+// and a missing secret is an error as usual.
+//
+// Bootstrap does not coordinate replicas: two instances that start together
+// may each generate and store a value, and the first one's is overwritten.
+// Enable it for a single instance or a deployment job.
+func WithBootstrap() Option {
+	return func(c *resolverConfig) {
+		c.bootstrap = true
+	}
+}
+
+// bootstrapSpec describes a value generated by a `bootstrap=` tag option.
+type bootstrapSpec struct {
+	length   int
+	encoding string // "" for a password, or "hex" or "base64" for random bytes
+}
+
+// parseBootstrap parses the value of a `bootstrap=` tag option:
+// generate:N[:hex|:base64].
+func parseBootstrap(s string) (bootstrapSpec, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "generate" {
+		return bootstrapSpec{}, fmt.Errorf("want generate:N[:hex|:base64]")
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil || n < 1 || n > maxBootstrapLength {
+		return bootstrapSpec{}, fmt.Errorf("length must be between 1 and %d", maxBootstrapLength)
+	}
+	spec := bootstrapSpec{length: n}
+	if len(parts) == 3 {
+		if parts[2] != "hex" && parts[2] != "base64" {
+			return bootstrapSpec{}, fmt.Errorf("unsupported encoding %q", parts[2])
+		}
+		spec.encoding = parts[2]
+	}
+	return spec, nil
+}
+
+// generate returns a new random value as described by s.
+func (s bootstrapSpec) generate() []byte {
+	if s.encoding == "" {
+		out := make([]byte, s.length)
+		for i := range out {
+			out[i] = passwordAlphabet[randIndex(len(passwordAlphabet))]
+		}
+		return out
+	}
+	b := make([]byte, s.length)
+	rand.Read(b)
+	if s.encoding == "hex" {
+		return []byte(hex.EncodeToString(b))
+	}
+	return []byte(base64.StdEncoding.EncodeToString(b))
+}
+
+// randIndex returns a uniformly random integer in [0, n) for n <= 256.
+func randIndex(n int) int {
+	limit := 256 - 256%n
+	var b [1]byte
+	for {
+		rand.Read(b[:])
+		if int(b[0]) < limit {
+			return int(b[0]) % n
+		}
+	}
+}
+
+// bootstrapValue generates the value for a missing secret tagged with
+// `bootstrap=` and stores it with p.
+func (r *Resolver) bootstrapValue(ctx context.Context, p Provider, providerName string, tag parsedTag) ([]byte, error) {
+	wp, ok := p.(WriterProvider)
+	if !ok {
+		return nil, fmt.Errorf("bootstrap: provider %q cannot store secrets: %w", providerName, errors.ErrUnsupported)
+	}
+	spec, err := parseBootstrap(tag.Bootstrap)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap: %w", err)
+	}
+	if !r.acquire() {
+		return nil, ErrClosed
+	}
+	defer r.inflight.Done()
+	value := spec.generate()
+	if err := wp.Set(ctx, tag.Key, value); err != nil {
+		return nil, fmt.Errorf("bootstrap: %w", err)
+	}
+	r.log(ctx, slog.LevelInfo, "secret bootstrapped", slog.String("provider", providerName), slog.String("key", tag.Key))
+	return value, nil
+}
@@ -0,0 +1,134 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseBootstrap(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    bootstrapSpec
+		wantErr bool
+	}{
+		{in: "generate:32", want: bootstrapSpec{length: 32}},
+		{in: "generate:16:hex", want: bootstrapSpec{length: 16, encoding: "hex"}},
+		{in: "generate:32:base64", want: bootstrapSpec{length: 32, encoding: "base64"}},
+		{in: "generate", wantErr: true},
+		{in: "generate:0", wantErr: true},
+		{in: "generate:5000", wantErr: true},
+		{in: "generate:x", wantErr: true},
+		{in: "generate:8:rot13", wantErr: true},
+		{in: "random:8", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseBootstrap(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBootstrap(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseBootstrap(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBootstrapSpec_Generate(t *testing.T) {
+	pw := bootstrapSpec{length: 40}.generate()
+	if len(pw) != 40 || strings.Trim(string(pw), passwordAlphabet) != "" {
+		t.Errorf("password = %q, want 40 letters and digits", pw)
+	}
+	if other := (bootstrapSpec{length: 40}).generate(); string(other) == string(pw) {
+		t.Error("two generated passwords are equal")
+	}
+
+	b, err := hex.DecodeString(string(bootstrapSpec{length: 16, encoding: "hex"}.generate()))
+	if err != nil || len(b) != 16 {
+		t.Errorf("hex value decodes to %d bytes, %v; want 16", len(b), err)
+	}
+	b, err = base64.StdEncoding.DecodeString(string(bootstrapSpec{length: 32, encoding: "base64"}.generate()))
+	if err != nil || len(b) != 32 {
+		t.Errorf("base64 value decodes to %d bytes, %v; want 32", len(b), err)
+	}
+}
+
+func TestWithBootstrap(t *testing.T) {
+	p := &writableProvider{}
+	p.data.Store("existing", []byte("keep-me"))
+	r := NewResolver(WithDefault(p), WithBootstrap())
+
+	type Config struct {
+		Password string         `secret:"admin,bootstrap=generate:24"`
+		Again    string         `secret:"admin,bootstrap=generate:24"`
+		Key      []byte         `secret:"session-key,bootstrap=generate:32:base64,transform=base64"`
+		Existing string         `secret:"existing,bootstrap=generate:24"`
+		Lazy     Secret[string] `secret:"lazy,bootstrap=generate:8:hex"`
+	}
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	stored, _ := p.data.Load("admin")
+	if len(cfg.Password) != 24 || cfg.Password != string(stored.([]byte)) {
+		t.Errorf("Password = %q, stored %q; want the same 24-character value", cfg.Password, stored)
+	}
+	if cfg.Again != cfg.Password {
+		t.Errorf("fields sharing a secret got different values %q and %q", cfg.Password, cfg.Again)
+	}
+	if len(cfg.Key) != 32 {
+		t.Errorf("Key has %d bytes, want 32", len(cfg.Key))
+	}
+	if cfg.Existing != "keep-me" {
+		t.Errorf("Existing = %q, want the stored value", cfg.Existing)
+	}
+	lazy, err := cfg.Lazy.Get(context.Background())
+	if err != nil || len(lazy) != 16 {
+		t.Errorf("Lazy.Get = %q, %v; want 16 hex digits", lazy, err)
+	}
+
+	// A second resolve reads the stored values back.
+	var again Config
+	if err := r.Resolve(context.Background(), &again); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if again.Password != cfg.Password || string(again.Key) != string(cfg.Key) {
+		t.Error("second Resolve generated new values")
+	}
+	if r.redactor.Redact(cfg.Password) == cfg.Password {
+		t.Error("generated value not registered with the Redactor")
+	}
+}
+
+func TestWithBootstrap_Disabled(t *testing.T) {
+	p := &writableProvider{}
+	r := NewResolver(WithDefault(p))
+
+	var cfg struct {
+		Password string `secret:"admin,bootstrap=generate:24"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound without WithBootstrap, got %v", err)
+	}
+	if _, ok := p.data.Load("admin"); ok {
+		t.Error("secret stored without WithBootstrap")
+	}
+}
+
+func TestWithBootstrap_ReadOnlyProvider(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{data: map[string][]byte{}}), WithBootstrap())
+
+	var cfg struct {
+		Password string `secret:"admin,bootstrap=generate:24"`
+	}
+	if err := r.Validate(&cfg); err == nil || !strings.Contains(err.Error(), "requires a provider that implements WriterProvider") {
+		t.Errorf("Validate: got %v", err)
+	}
+	if err := r.Resolve(context.Background(), &cfg); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Resolve: expected ErrUnsupported, got %v", err)
+	}
+}
@@ -1,8 +1,16 @@
 package secrets
 
 import (
+	"container/list"
 	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"strings"
 	"sync"
 	"time"
 )
@@ -12,40 +20,207 @@ import (
 // This is useful for cloud providers (AWS SM, GCP SM, Vault, etc.)
 // to avoid redundant API calls and potential rate limiting.
 //
+// The cache can be bounded by entry count (WithMaxEntries) and total value
+// size (WithMaxBytes). When a bound is exceeded, the least recently used
+// entries are evicted. With WithRefreshAhead, entries that are read late in
+// their lifetime are refreshed in the background before they expire.
+// With WithEncryption, cached values are held encrypted in memory.
+//
 // CachedProvider is safe for concurrent use.
 type CachedProvider struct {
-	provider Provider
-	ttl      time.Duration
-	mu       sync.RWMutex
-	entries  map[string]*cacheEntry
+	provider    Provider
+	ttl         time.Duration
+	maxEntries  int
+	maxBytes    int
+	mu          sync.RWMutex
+	entries     map[string]*list.Element // key -> element holding *cacheEntry
+	lru         *list.List               // front = most recently used
+	bytes       int                      // total size of cached values
+	hook        func(CacheEvent)
+	logger      *slog.Logger
+	metrics     Recorder
+	metricsName string
+	hits        uint64
+	misses      uint64
+	evictions   uint64
+
+	refreshAhead float64            // fraction of ttl after which reads trigger a refresh
+	refreshCtx   context.Context    // cancelled by Close to abort background refreshes
+	stopRefresh  context.CancelFunc // cancels refreshCtx
+	refreshWG    sync.WaitGroup     // tracks background refreshes
+	closed       bool               // set by Close; guarded by mu
+
+	aead cipher.AEAD // non-nil if WithEncryption is set
+
+	clock Clock
 }
 
 type cacheEntry struct {
-	data    []byte
-	expires time.Time
+	key        string
+	data       []byte
+	expires    time.Time
+	refreshAt  time.Time // zero if refresh-ahead is disabled
+	refreshing bool      // true while a background refresh is in flight
+}
+
+// CacheOption configures a CachedProvider.
+type CacheOption func(*CachedProvider)
+
+// WithMaxEntries bounds the cache to at most n entries.
+// When the bound is exceeded, the least recently used entry is evicted.
+// n <= 0 means unbounded (the default).
+func WithMaxEntries(n int) CacheOption {
+	return func(c *CachedProvider) {
+		c.maxEntries = n
+	}
+}
+
+// WithMaxBytes bounds the total size of cached values to n bytes.
+// When the bound is exceeded, least recently used entries are evicted.
+// Values larger than n are never cached. n <= 0 means unbounded (the default).
+func WithMaxBytes(n int) CacheOption {
+	return func(c *CachedProvider) {
+		c.maxBytes = n
+	}
+}
+
+// WithCacheHook registers fn to be called on every cache hit, miss, and
+// eviction. It is intended for exporting cache efficiency to a metrics system.
+// fn is called synchronously and must be safe for concurrent use.
+func WithCacheHook(fn func(CacheEvent)) CacheOption {
+	return func(c *CachedProvider) {
+		c.hook = fn
+	}
+}
+
+// WithCacheLogger logs every cache hit, miss, and eviction to l at Debug
+// level, and failed background refreshes at Warn level. Records carry the key
+// and version, never values.
+func WithCacheLogger(l *slog.Logger) CacheOption {
+	return func(c *CachedProvider) {
+		c.logger = l
+	}
+}
+
+// WithCacheClock makes the cache read the time from clk instead of the
+// system clock when it stores entries and checks their expiry and
+// refresh-ahead deadlines. It is intended for tests: with a fake clock such as
+// secrettest.Clock, a test can expire entries by advancing the clock rather
+// than sleeping for the TTL.
+func WithCacheClock(clk Clock) CacheOption {
+	return func(c *CachedProvider) {
+		c.clock = clk
+	}
+}
+
+// WithRefreshAhead enables background refresh of entries that are read after
+// fraction of their TTL has elapsed. For example, 0.8 with a 5 minute TTL
+// re-fetches an entry in the background when it is read more than 4 minutes
+// after it was stored, while the caller is served the still-fresh cached value.
+// Hot secrets therefore never incur a synchronous provider round trip.
+// Only one refresh per entry is in flight at a time. If a refresh fails, the
+// cached value is kept until it expires and the next read retries the refresh.
+// fraction must be in (0, 1); other values disable refresh-ahead.
+func WithRefreshAhead(fraction float64) CacheOption {
+	return func(c *CachedProvider) {
+		if fraction <= 0 || fraction >= 1 {
+			fraction = 0
+		}
+		c.refreshAhead = fraction
+	}
+}
+
+// WithEncryption stores cached values encrypted with AES-256-GCM under a
+// random key generated for this CachedProvider, which never leaves process
+// memory. This keeps plaintext secrets out of the cache's own memory, reducing
+// their exposure in heap dumps and core files. Values are decrypted into a
+// fresh buffer on every read; the caller owns that buffer and may zero it once
+// it is no longer needed. Evicted and cleared entries are zeroed.
+func WithEncryption() CacheOption {
+	return func(c *CachedProvider) {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic("secrets: generate cache encryption key: " + err.Error())
+		}
+		block, err := aes.NewCipher(key)
+		clear(key)
+		if err != nil {
+			panic("secrets: create cache cipher: " + err.Error())
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			panic("secrets: create cache cipher: " + err.Error())
+		}
+		c.aead = aead
+	}
+}
+
+// CacheEventKind identifies the kind of a CacheEvent.
+type CacheEventKind int
+
+const (
+	// CacheHit indicates a fresh value was served from the cache.
+	CacheHit CacheEventKind = iota
+	// CacheMiss indicates the value was absent or expired and was fetched
+	// from the underlying provider.
+	CacheMiss
+	// CacheEviction indicates an entry was evicted to satisfy the
+	// WithMaxEntries or WithMaxBytes bounds.
+	CacheEviction
+)
+
+// String returns the lower-case name of the event kind.
+func (k CacheEventKind) String() string {
+	switch k {
+	case CacheHit:
+		return "hit"
+	case CacheMiss:
+		return "miss"
+	case CacheEviction:
+		return "eviction"
+	default:
+		return "unknown"
+	}
+}
+
+// CacheEvent describes a single cache operation reported to a WithCacheHook callback.
+type CacheEvent struct {
+	Kind    CacheEventKind
+	Key     string // the secret key
+	Version string // the requested version, empty for Get
+}
+
+// CacheStats is a point-in-time snapshot of cache statistics.
+type CacheStats struct {
+	Hits      uint64 // lookups served from the cache
+	Misses    uint64 // lookups that fell through to the provider
+	Evictions uint64 // entries evicted by size or count bounds
+	Entries   int    // entries currently cached
+	Bytes     int    // total size of currently cached values
 }
 
 // NewCachedProvider wraps p with a cache that holds results for ttl.
 // Only successful results (err == nil) are cached.
-func NewCachedProvider(p Provider, ttl time.Duration) *CachedProvider {
-	return &CachedProvider{
+func NewCachedProvider(p Provider, ttl time.Duration, opts ...CacheOption) *CachedProvider {
+	c := &CachedProvider{
 		provider: p,
 		ttl:      ttl,
-		entries:  make(map[string]*cacheEntry),
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		clock:    systemClock{},
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	c.refreshCtx, c.stopRefresh = context.WithCancel(context.Background())
+	return c
 }
 
 // Get retrieves the secret for key, returning a cached value if fresh.
 func (c *CachedProvider) Get(ctx context.Context, key string) ([]byte, error) {
-	if data, ok := c.get(key); ok {
-		return data, nil
-	}
-	data, err := c.provider.Get(ctx, key)
-	if err != nil {
-		return nil, err
-	}
-	c.set(key, data)
-	return data, nil
+	return c.getOrFetch(ctx, key, func(ctx context.Context) ([]byte, error) {
+		return c.provider.Get(ctx, key)
+	})
 }
 
 // GetVersion retrieves a versioned secret, returning a cached value if fresh.
@@ -56,28 +231,101 @@ func (c *CachedProvider) GetVersion(ctx context.Context, key, version string) ([
 	if !ok {
 		return nil, &ErrVersioningNotSupported{Provider: "cached"}
 	}
-	cacheKey := key + "\x00" + version
-	if data, ok := c.get(cacheKey); ok {
-		return data, nil
+	return c.getOrFetch(ctx, key+"\x00"+version, func(ctx context.Context) ([]byte, error) {
+		return vp.GetVersion(ctx, key, version)
+	})
+}
+
+// Set stores value under key with the underlying provider, which must
+// implement WriterProvider, and drops the cached values of key so that the
+// next Get fetches the new value.
+func (c *CachedProvider) Set(ctx context.Context, key string, value []byte) error {
+	wp, ok := c.provider.(WriterProvider)
+	if !ok {
+		return fmt.Errorf("secrets: cached provider: %w", errors.ErrUnsupported)
 	}
-	data, err := vp.GetVersion(ctx, key, version)
-	if err != nil {
-		return nil, err
+	defer c.forget(key)
+	return wp.Set(ctx, key, value)
+}
+
+// Delete deletes key with the underlying provider, which must implement
+// WriterProvider, and drops the cached values of key.
+func (c *CachedProvider) Delete(ctx context.Context, key string) error {
+	wp, ok := c.provider.(WriterProvider)
+	if !ok {
+		return fmt.Errorf("secrets: cached provider: %w", errors.ErrUnsupported)
+	}
+	defer c.forget(key)
+	return wp.Delete(ctx, key)
+}
+
+// List lists keys with the underlying provider, which must implement
+// ListerProvider. Listings are not cached.
+func (c *CachedProvider) List(ctx context.Context, prefix string) ([]string, error) {
+	lp, ok := c.provider.(ListerProvider)
+	if !ok {
+		return nil, fmt.Errorf("secrets: cached provider: %w", errors.ErrUnsupported)
+	}
+	return lp.List(ctx, prefix)
+}
+
+// GetMetadata describes key with the underlying provider, which must implement
+// MetadataProvider. Metadata is not cached.
+func (c *CachedProvider) GetMetadata(ctx context.Context, key string) (Metadata, error) {
+	mp, ok := c.provider.(MetadataProvider)
+	if !ok {
+		return Metadata{}, fmt.Errorf("secrets: cached provider: %w", errors.ErrUnsupported)
+	}
+	return mp.GetMetadata(ctx, key)
+}
+
+// forget removes the cached values of key, at every version.
+func (c *CachedProvider) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for cacheKey, elem := range c.entries {
+		if cacheKey == key || strings.HasPrefix(cacheKey, key+"\x00") {
+			c.removeElement(elem)
+		}
+	}
+}
+
+// Stats returns a snapshot of the cache statistics.
+// Counters are cumulative and are not reset by Clear.
+func (c *CachedProvider) Stats() CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   c.lru.Len(),
+		Bytes:     c.bytes,
 	}
-	c.set(cacheKey, data)
-	return data, nil
 }
 
 // Clear removes all entries from the cache.
 func (c *CachedProvider) Clear() {
 	c.mu.Lock()
-	c.entries = make(map[string]*cacheEntry)
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		c.wipe(elem.Value.(*cacheEntry))
+	}
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
 	c.mu.Unlock()
 }
 
-// Close clears the cache and, if the underlying provider implements
-// io.Closer, closes it.
+// Close stops any background refreshes, clears the cache and, if the
+// underlying provider implements io.Closer, closes it.
 func (c *CachedProvider) Close() error {
+	// Once closed is set, refresh starts no goroutine, so Wait does not race
+	// with Add.
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	c.stopRefresh()
+	c.refreshWG.Wait()
 	c.Clear()
 	if cl, ok := c.provider.(io.Closer); ok {
 		return cl.Close()
@@ -85,21 +333,197 @@ func (c *CachedProvider) Close() error {
 	return nil
 }
 
-func (c *CachedProvider) get(key string) ([]byte, bool) {
-	c.mu.RLock()
-	entry, ok := c.entries[key]
-	c.mu.RUnlock()
-	if !ok || time.Now().After(entry.expires) {
-		return nil, false
+// getOrFetch returns the cached value for cacheKey or calls fetch and caches
+// the result.
+func (c *CachedProvider) getOrFetch(ctx context.Context, cacheKey string, fetch func(context.Context) ([]byte, error)) ([]byte, error) {
+	data, ok, refresh := c.lookup(cacheKey)
+	kind := CacheMiss
+	if ok {
+		kind = CacheHit
+	}
+	c.emit(kind, cacheKey)
+	if refresh {
+		c.refresh(ctx, cacheKey, fetch)
+	}
+	if ok {
+		return data, nil
+	}
+
+	data, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.set(cacheKey, data)
+	return data, nil
+}
+
+// refresh re-fetches cacheKey in the background. The refresh keeps the values
+// of ctx but not its cancellation, since the caller's request may finish long
+// before the refresh does; it is instead cancelled by Close, after which no
+// refresh starts.
+func (c *CachedProvider) refresh(ctx context.Context, cacheKey string, fetch func(context.Context) ([]byte, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.refreshWG.Add(1)
+	go func() {
+		defer c.refreshWG.Done()
+		rctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		defer cancel()
+		stop := context.AfterFunc(c.refreshCtx, cancel)
+		defer stop()
+
+		data, err := fetch(rctx)
+		if err == nil && c.refreshCtx.Err() == nil {
+			c.set(cacheKey, data)
+			return
+		}
+		if err != nil && c.logger != nil {
+			key, version, _ := strings.Cut(cacheKey, "\x00")
+			c.logger.LogAttrs(rctx, slog.LevelWarn, "secret cache refresh failed",
+				slog.String("key", key), slog.String("version", version), slog.Any("error", err))
+		}
+		c.mu.Lock()
+		if elem, ok := c.entries[cacheKey]; ok {
+			elem.Value.(*cacheEntry).refreshing = false
+		}
+		c.mu.Unlock()
+	}()
+}
+
+// lookup returns the cached value for key, if fresh. refresh reports whether
+// the caller should start a background refresh of the entry.
+func (c *CachedProvider) lookup(key string) (data []byte, ok, refresh bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, found := c.entries[key]
+	if !found {
+		c.misses++
+		return nil, false, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	now := c.clock.Now()
+	if now.After(entry.expires) {
+		c.removeElement(elem)
+		c.misses++
+		return nil, false, false
+	}
+	data, err := c.open(key, entry.data)
+	if err != nil {
+		c.removeElement(elem)
+		c.misses++
+		return nil, false, false
+	}
+	c.lru.MoveToFront(elem)
+	c.hits++
+	if !entry.refreshAt.IsZero() && !entry.refreshing && !now.Before(entry.refreshAt) {
+		entry.refreshing = true
+		refresh = true
+	}
+	return data, true, refresh
+}
+
+// seal returns the representation of data stored in the cache: a
+// nonce-prefixed ciphertext bound to key if encryption is enabled, or data itself.
+func (c *CachedProvider) seal(key string, data []byte) []byte {
+	if c.aead == nil {
+		return data
+	}
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(data)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		panic("secrets: generate cache nonce: " + err.Error())
+	}
+	return c.aead.Seal(nonce, nonce, data, []byte(key))
+}
+
+// open reverses seal.
+func (c *CachedProvider) open(key string, stored []byte) ([]byte, error) {
+	if c.aead == nil {
+		return stored, nil
+	}
+	n := c.aead.NonceSize()
+	return c.aead.Open(nil, stored[:n], stored[n:], []byte(key))
+}
+
+// wipe zeroes an encrypted entry's stored bytes. Unencrypted entries share
+// their buffer with callers and are left untouched.
+func (c *CachedProvider) wipe(entry *cacheEntry) {
+	if c.aead != nil {
+		clear(entry.data)
 	}
-	return entry.data, true
 }
 
 func (c *CachedProvider) set(key string, data []byte) {
+	stored := c.seal(key, data)
+	if c.maxBytes > 0 && len(stored) > c.maxBytes {
+		return
+	}
+	var evicted []string
 	c.mu.Lock()
-	c.entries[key] = &cacheEntry{
-		data:    data,
-		expires: time.Now().Add(c.ttl),
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+	now := c.clock.Now()
+	entry := &cacheEntry{
+		key:     key,
+		data:    stored,
+		expires: now.Add(c.ttl),
+	}
+	if c.refreshAhead > 0 {
+		entry.refreshAt = now.Add(time.Duration(float64(c.ttl) * c.refreshAhead))
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.bytes += len(stored)
+	for c.overLimit() {
+		evicted = append(evicted, c.removeElement(c.lru.Back()))
+		c.evictions++
 	}
 	c.mu.Unlock()
+
+	for _, k := range evicted {
+		c.emit(CacheEviction, k)
+	}
+}
+
+// emit reports a cache event to the hook, logger, and metrics recorder, if
+// configured.
+// cacheKey is the internal key, which has the form "key\x00version" for
+// versioned lookups.
+func (c *CachedProvider) emit(kind CacheEventKind, cacheKey string) {
+	if c.hook == nil && c.logger == nil && c.metrics == nil {
+		return
+	}
+	key, version, _ := strings.Cut(cacheKey, "\x00")
+	if c.logger != nil {
+		c.logger.LogAttrs(context.Background(), slog.LevelDebug, "secret cache "+kind.String(),
+			slog.String("key", key), slog.String("version", version))
+	}
+	ev := CacheEvent{Kind: kind, Key: key, Version: version}
+	if c.hook != nil {
+		c.hook(ev)
+	}
+	if c.metrics != nil {
+		c.metrics.Cache(c.metricsName, ev)
+	}
+}
+
+// overLimit reports whether the cache exceeds its configured bounds.
+// Callers must hold c.mu.
+func (c *CachedProvider) overLimit() bool {
+	if c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		return true
+	}
+	return c.maxBytes > 0 && c.bytes > c.maxBytes
+}
+
+// removeElement drops elem from the cache and returns its key.
+// Callers must hold c.mu.
+func (c *CachedProvider) removeElement(elem *list.Element) string {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= len(entry.data)
+	c.wipe(entry)
+	return entry.key
 }
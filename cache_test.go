@@ -1,8 +1,11 @@
 package secrets
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -253,3 +256,344 @@ func TestCachedProvider_Concurrent(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestCachedProvider_MaxEntriesEvictsLRU(t *testing.T) {
+	p := &cacheTestProvider{data: map[string][]byte{
+		"a": []byte("1"),
+		"b": []byte("2"),
+		"c": []byte("3"),
+	}}
+	cp := NewCachedProvider(p, time.Minute, WithMaxEntries(2))
+
+	ctx := context.Background()
+	for _, k := range []string{"a", "b", "a", "c"} {
+		if _, err := cp.Get(ctx, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// "b" was least recently used when "c" was added.
+	if p.calls != 3 {
+		t.Fatalf("expected 3 provider calls, got %d", p.calls)
+	}
+	if _, err := cp.Get(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if p.calls != 3 {
+		t.Fatalf("expected \"a\" to still be cached, got %d calls", p.calls)
+	}
+	if _, err := cp.Get(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if p.calls != 4 {
+		t.Fatalf("expected \"b\" to be evicted, got %d calls", p.calls)
+	}
+}
+
+func TestCachedProvider_MaxBytes(t *testing.T) {
+	p := &cacheTestProvider{data: map[string][]byte{
+		"small1": []byte("aaaa"),
+		"small2": []byte("bbbb"),
+		"big":    []byte("cccccccccccc"),
+	}}
+	cp := NewCachedProvider(p, time.Minute, WithMaxBytes(8))
+
+	ctx := context.Background()
+	for _, k := range []string{"small1", "small2"} {
+		if _, err := cp.Get(ctx, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cp.mu.RLock()
+	n, size := len(cp.entries), cp.bytes
+	cp.mu.RUnlock()
+	if n != 2 || size != 8 {
+		t.Fatalf("expected 2 entries / 8 bytes, got %d / %d", n, size)
+	}
+
+	// Values larger than the bound are returned but never cached.
+	got, err := cp.Get(ctx, "big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "cccccccccccc" {
+		t.Fatalf("unexpected value %q", got)
+	}
+	cp.mu.RLock()
+	n = len(cp.entries)
+	cp.mu.RUnlock()
+	if n != 2 {
+		t.Fatalf("expected oversized value not to be cached, got %d entries", n)
+	}
+
+	// Replacing an entry must not double-count its size.
+	p.data["small3"] = []byte("dddd")
+	if _, err := cp.Get(ctx, "small3"); err != nil {
+		t.Fatal(err)
+	}
+	cp.mu.RLock()
+	n, size = len(cp.entries), cp.bytes
+	cp.mu.RUnlock()
+	if n != 2 || size != 8 {
+		t.Fatalf("expected 2 entries / 8 bytes after eviction, got %d / %d", n, size)
+	}
+}
+
+func TestCachedProvider_Stats(t *testing.T) {
+	p := &cacheTestProvider{data: map[string][]byte{
+		"a": []byte("1"),
+		"b": []byte("22"),
+	}}
+	var (
+		mu     sync.Mutex
+		events []CacheEvent
+	)
+	cp := NewCachedProvider(p, time.Minute,
+		WithMaxEntries(1),
+		WithCacheHook(func(ev CacheEvent) {
+			mu.Lock()
+			events = append(events, ev)
+			mu.Unlock()
+		}),
+	)
+
+	ctx := context.Background()
+	for _, k := range []string{"a", "a", "b"} {
+		if _, err := cp.Get(ctx, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := CacheStats{Hits: 1, Misses: 2, Evictions: 1, Entries: 1, Bytes: 2}
+	if got := cp.Stats(); got != want {
+		t.Fatalf("Stats = %+v, want %+v", got, want)
+	}
+
+	wantEvents := []CacheEvent{
+		{Kind: CacheMiss, Key: "a"},
+		{Kind: CacheHit, Key: "a"},
+		{Kind: CacheMiss, Key: "b"},
+		{Kind: CacheEviction, Key: "a"},
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != len(wantEvents) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(wantEvents), events)
+	}
+	for i := range wantEvents {
+		if events[i] != wantEvents[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], wantEvents[i])
+		}
+	}
+}
+
+func TestCachedProvider_HookVersion(t *testing.T) {
+	p := &cacheTestVersionedProvider{
+		versions: map[string][]byte{"k\x00prev": []byte("previous")},
+	}
+	var got CacheEvent
+	cp := NewCachedProvider(p, time.Minute, WithCacheHook(func(ev CacheEvent) { got = ev }))
+
+	if _, err := cp.GetVersion(context.Background(), "k", "prev"); err != nil {
+		t.Fatal(err)
+	}
+	want := CacheEvent{Kind: CacheMiss, Key: "k", Version: "prev"}
+	if got != want {
+		t.Fatalf("event = %+v, want %+v", got, want)
+	}
+}
+
+func TestCachedProvider_Logger(t *testing.T) {
+	logger, buf := newTestLogger()
+	p := &syncMapProvider{}
+	p.Store("k", []byte("hunter22"))
+	cp := NewCachedProvider(p, time.Minute, WithCacheLogger(logger), WithMaxEntries(1))
+
+	ctx := context.Background()
+	for _, key := range []string{"k", "k", "other"} {
+		_, _ = cp.Get(ctx, key)
+	}
+	p.Store("other", []byte("v"))
+	_, _ = cp.Get(ctx, "other") // evicts k
+
+	out := buf.String()
+	for _, want := range []string{
+		`level=DEBUG msg="secret cache miss" key=k version=""`,
+		`level=DEBUG msg="secret cache hit" key=k version=""`,
+		`level=DEBUG msg="secret cache eviction" key=k version=""`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hunter22") {
+		t.Errorf("log reveals a secret value:\n%s", out)
+	}
+}
+
+func TestCachedProvider_RefreshAhead(t *testing.T) {
+	p := &syncMapProvider{}
+	p.Store("k", []byte("v1"))
+	cp := NewCachedProvider(p, 200*time.Millisecond, WithRefreshAhead(0.25))
+	defer func() {
+		if err := cp.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	ctx := context.Background()
+	if _, err := cp.Get(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(75 * time.Millisecond)
+	p.Store("k", []byte("v2"))
+
+	// Past the refresh point: the cached value is served and a refresh starts.
+	got, err := cp.Get(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "v1" {
+		t.Fatalf("expected cached %q, got %q", "v1", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		got, err = cp.Get(ctx, "k")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for background refresh")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if misses := cp.Stats().Misses; misses != 1 {
+		t.Fatalf("expected refreshed value to be served from cache, got %d misses", misses)
+	}
+}
+
+func TestCachedProvider_RefreshAheadDisabledByDefault(t *testing.T) {
+	p := &cacheTestProvider{data: map[string][]byte{"k": []byte("v")}}
+	cp := NewCachedProvider(p, time.Minute, WithRefreshAhead(1.5))
+
+	ctx := context.Background()
+	for range 3 {
+		if _, err := cp.Get(ctx, "k"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if p.calls != 1 {
+		t.Fatalf("expected 1 provider call, got %d", p.calls)
+	}
+}
+
+func TestCachedProvider_Encryption(t *testing.T) {
+	p := &cacheTestProvider{data: map[string][]byte{"k": []byte("s3cret-value")}}
+	cp := NewCachedProvider(p, time.Minute, WithEncryption())
+
+	ctx := context.Background()
+	if _, err := cp.Get(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	cp.mu.RLock()
+	stored := cp.entries["k"].Value.(*cacheEntry).data
+	cp.mu.RUnlock()
+	if bytes.Contains(stored, []byte("s3cret-value")) {
+		t.Fatal("cache holds plaintext value")
+	}
+
+	got, err := cp.Get(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "s3cret-value" {
+		t.Fatalf("expected %q, got %q", "s3cret-value", got)
+	}
+	if p.calls != 1 {
+		t.Fatalf("expected 1 provider call, got %d", p.calls)
+	}
+
+	// Each hit decrypts into a fresh buffer the caller may zero.
+	clear(got)
+	again, err := cp.Get(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != "s3cret-value" {
+		t.Fatalf("zeroing a returned buffer corrupted the cache: %q", again)
+	}
+
+	cp.Clear()
+	if !bytes.Equal(stored, make([]byte, len(stored))) {
+		t.Fatal("expected cleared entry to be zeroed")
+	}
+}
+
+// listingProvider is a mockProvider that implements ListerProvider.
+type listingProvider struct {
+	mockProvider
+}
+
+func (p *listingProvider) List(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for k := range p.data {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func TestCachedProvider_List(t *testing.T) {
+	p := &listingProvider{mockProvider{data: map[string][]byte{"db-pass": nil, "db-user": nil, "api": nil}}}
+	c := NewCachedProvider(p, time.Minute)
+	defer c.Close()
+
+	got, err := c.List(context.Background(), "db-")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := []string{"db-pass", "db-user"}; !slices.Equal(got, want) {
+		t.Errorf("List = %q, want %q", got, want)
+	}
+
+	ro := NewCachedProvider(&mockProvider{}, time.Minute)
+	defer ro.Close()
+	if _, err := ro.List(context.Background(), ""); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("List on non-lister: expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestCachedProvider_GetMetadata(t *testing.T) {
+	rotated := time.Date(2024, 3, 9, 16, 0, 0, 0, time.UTC)
+	p := &metadataProvider{metadata: map[string]Metadata{"db": {RotatedAt: rotated}}}
+	c := NewCachedProvider(p, time.Minute)
+	defer c.Close()
+
+	for range 2 {
+		md, err := c.GetMetadata(context.Background(), "db")
+		if err != nil {
+			t.Fatalf("GetMetadata: %v", err)
+		}
+		if !md.RotatedAt.Equal(rotated) {
+			t.Errorf("RotatedAt = %v, want %v", md.RotatedAt, rotated)
+		}
+	}
+	if got := p.calls.Load(); got != 2 {
+		t.Errorf("GetMetadata calls = %d, want 2 (uncached)", got)
+	}
+
+	plain := NewCachedProvider(&mockProvider{}, time.Minute)
+	defer plain.Close()
+	if _, err := plain.GetMetadata(context.Background(), "db"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("GetMetadata on plain provider: expected ErrUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+// Package civars provides a secret provider that reads the variables CI
+// systems such as GitHub Actions and GitLab CI pass to jobs, including
+// GitLab's file-type variables and the NAME_FILE convention.
+package civars
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/brwse/go-secrets"
+)
+
+// ProviderOption configures the civars Provider.
+type ProviderOption func(*Provider)
+
+// WithPrefix configures a prefix that is prepended to all key lookups, as
+// env.WithPrefix does.
+func WithPrefix(prefix string) ProviderOption {
+	return func(p *Provider) {
+		p.prefix = prefix
+	}
+}
+
+// WithFileDir sets the directory the CI system writes file-type variables
+// to. A variable whose value is the path of a file in it is read from the
+// file. Defaults to GitLab's, "$CI_PROJECT_DIR.tmp", in GitLab CI jobs, and
+// to none elsewhere.
+func WithFileDir(dir string) ProviderOption {
+	return func(p *Provider) {
+		p.fileDir = dir
+	}
+}
+
+// WithFileSuffix sets the suffix of variables that name a file holding the
+// value of an unset variable. Defaults to "_FILE", so that DB_PASSWORD is
+// read from the file named by DB_PASSWORD_FILE when DB_PASSWORD is not set.
+// An empty suffix disables the lookup.
+func WithFileSuffix(suffix string) ProviderOption {
+	return func(p *Provider) {
+		p.fileSuffix = suffix
+	}
+}
+
+// Provider reads secrets from CI variables.
+// It implements secrets.Provider and secrets.CheckerProvider.
+//
+// Keys are variable names. A variable is read in the first of these ways
+// that applies:
+//   - a file-type variable, whose value is the path of a file in the file
+//     directory (see WithFileDir), is read from that file;
+//   - any other variable that is set, masked or not, is its value;
+//   - a variable named by the key and the file suffix (see WithFileSuffix)
+//     names a file to read.
+//
+// GitHub Actions passes secrets only as the environment variables a
+// workflow sets from them, so the same struct tags resolve a secret in a
+// GitHub job, a GitLab job given it as a file-type variable, and a
+// container given DB_PASSWORD_FILE.
+type Provider struct {
+	prefix     string
+	fileDir    string
+	fileSuffix string
+}
+
+// New creates a new civars Provider with the given options.
+func New(opts ...ProviderOption) *Provider {
+	p := &Provider{fileSuffix: "_FILE"}
+	if os.Getenv("GITLAB_CI") == "true" {
+		if dir := os.Getenv("CI_PROJECT_DIR"); dir != "" {
+			p.fileDir = dir + ".tmp"
+		}
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Get retrieves the value of the variable named by key (with any configured
+// prefix prepended).
+// Returns secrets.ErrNotFound (wrapped) if the variable is not set and no
+// file holds it.
+func (p *Provider) Get(_ context.Context, key string) ([]byte, error) {
+	name := p.prefix + key
+	path, val, err := p.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return []byte(val), nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("civars: %q: file %s: %w", name, path, secrets.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("civars: %q: %w", name, err)
+	}
+	return data, nil
+}
+
+// Check reports whether the variable named by key (with any configured
+// prefix prepended) is set, or the file that holds it exists.
+// Returns secrets.ErrNotFound (wrapped) if not.
+func (p *Provider) Check(_ context.Context, key string) error {
+	name := p.prefix + key
+	path, _, err := p.lookup(name)
+	if err != nil || path == "" {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("civars: %q: file %s: %w", name, path, secrets.ErrNotFound)
+	} else if err != nil {
+		return fmt.Errorf("civars: %q: %w", name, err)
+	}
+	return nil
+}
+
+// lookup returns the path of the file that holds the variable name, or else
+// its value.
+func (p *Provider) lookup(name string) (path, val string, err error) {
+	if val, ok := os.LookupEnv(name); ok {
+		if p.isFileVar(val) {
+			return val, "", nil
+		}
+		return "", val, nil
+	}
+	if p.fileSuffix != "" {
+		if path, ok := os.LookupEnv(name + p.fileSuffix); ok && path != "" {
+			return path, "", nil
+		}
+	}
+	return "", "", fmt.Errorf("civars: %q: %w", name, secrets.ErrNotFound)
+}
+
+// isFileVar reports whether val, the value of a variable, is the path of a
+// file-type variable.
+func (p *Provider) isFileVar(val string) bool {
+	if p.fileDir == "" || !filepath.IsAbs(val) {
+		return false
+	}
+	return filepath.Dir(filepath.Clean(val)) == filepath.Clean(p.fileDir)
+}
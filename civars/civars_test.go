@@ -0,0 +1,135 @@
+package civars
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// gitlabJob fakes the environment of a GitLab CI job and returns the
+// directory its file-type variables are written to.
+func gitlabJob(t *testing.T) string {
+	t.Helper()
+	builds := t.TempDir()
+	project := filepath.Join(builds, "group", "app")
+	tmp := project + ".tmp"
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("CI_PROJECT_DIR", project)
+	return tmp
+}
+
+func TestGet_Variable(t *testing.T) {
+	t.Setenv("GITLAB_CI", "")
+	t.Setenv("API_TOKEN", "t0k")
+	t.Setenv("APP_API_TOKEN", "prefixed")
+	ctx := context.Background()
+
+	got, err := New().Get(ctx, "API_TOKEN")
+	if err != nil || string(got) != "t0k" {
+		t.Errorf("Get = %q, %v; want t0k", got, err)
+	}
+	got, err = New(WithPrefix("APP_")).Get(ctx, "API_TOKEN")
+	if err != nil || string(got) != "prefixed" {
+		t.Errorf("Get with prefix = %q, %v; want prefixed", got, err)
+	}
+	if _, err := New().Get(ctx, "CIVARS_MISSING"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGet_GitLabFileVariable(t *testing.T) {
+	tmp := gitlabJob(t)
+	path := filepath.Join(tmp, "KUBECONFIG_PROD")
+	if err := os.WriteFile(path, []byte("apiVersion: v1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG_PROD", path)
+	t.Setenv("OTHER_PATH", "/etc/hostname")
+	ctx := context.Background()
+
+	p := New()
+	got, err := p.Get(ctx, "KUBECONFIG_PROD")
+	if err != nil || string(got) != "apiVersion: v1\n" {
+		t.Errorf("Get(file variable) = %q, %v", got, err)
+	}
+	// A path outside the file directory is an ordinary value.
+	if got, _ := p.Get(ctx, "OTHER_PATH"); string(got) != "/etc/hostname" {
+		t.Errorf("Get(OTHER_PATH) = %q, want the path itself", got)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Get(ctx, "KUBECONFIG_PROD"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get of a removed file variable: error = %v, want ErrNotFound", err)
+	}
+	if err := p.Check(ctx, "KUBECONFIG_PROD"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check of a removed file variable: error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGet_WithFileDir(t *testing.T) {
+	t.Setenv("GITLAB_CI", "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "CERT")
+	if err := os.WriteFile(path, []byte("pem"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CERT", path)
+	if got, _ := New().Get(context.Background(), "CERT"); string(got) != path {
+		t.Errorf("Get without a file directory = %q, want the path", got)
+	}
+	if got, err := New(WithFileDir(dir)).Get(context.Background(), "CERT"); err != nil || string(got) != "pem" {
+		t.Errorf("Get with WithFileDir = %q, %v; want pem", got, err)
+	}
+}
+
+func TestGet_FileSuffix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db-password")
+	if err := os.WriteFile(path, []byte("hunter2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DB_PASSWORD_FILE", path)
+	t.Setenv("DB_PASSWORD_PATH", path)
+	ctx := context.Background()
+
+	if got, err := New().Get(ctx, "DB_PASSWORD"); err != nil || string(got) != "hunter2" {
+		t.Errorf("Get via DB_PASSWORD_FILE = %q, %v", got, err)
+	}
+	if got, err := New(WithFileSuffix("_PATH")).Get(ctx, "DB_PASSWORD"); err != nil || string(got) != "hunter2" {
+		t.Errorf("Get via DB_PASSWORD_PATH = %q, %v", got, err)
+	}
+	if _, err := New(WithFileSuffix("")).Get(ctx, "DB_PASSWORD"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get with the suffix disabled: error = %v, want ErrNotFound", err)
+	}
+
+	// The variable itself takes precedence.
+	t.Setenv("DB_PASSWORD", "direct")
+	if got, _ := New().Get(ctx, "DB_PASSWORD"); string(got) != "direct" {
+		t.Errorf("Get = %q, want direct", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Setenv("SET_VAR", "")
+	t.Setenv("GONE_FILE", filepath.Join(t.TempDir(), "missing"))
+	p := New()
+	ctx := context.Background()
+	if err := p.Check(ctx, "SET_VAR"); err != nil {
+		t.Errorf("Check(SET_VAR): %v", err)
+	}
+	if err := p.Check(ctx, "GONE"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check(GONE) error = %v, want ErrNotFound", err)
+	}
+	if err := p.Check(ctx, "CIVARS_MISSING"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,38 @@
+package secrets
+
+import "time"
+
+// Clock is the source of time for CachedProvider expiry and Watcher polling.
+// WithCacheClock and WatchClock replace the system clock with one a test
+// controls, such as secrettest.Clock, so that TTL expiry, refresh-ahead,
+// jittered polling, and backoff can be tested without sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTimer returns a Timer that sends the current time on its channel
+	// once d has passed.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a Clock's counterpart of time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is sent when the Timer fires.
+	C() <-chan time.Time
+	// Stop prevents the Timer from firing. It reports whether the Timer was
+	// active.
+	Stop() bool
+	// Reset changes the Timer to fire once d has passed. It reports whether
+	// the Timer was active.
+	Reset(d time.Duration) bool
+}
+
+// systemClock is the Clock backed by package time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+type systemTimer struct{ *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time { return t.Timer.C }
@@ -0,0 +1,202 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+	"github.com/brwse/go-secrets/secrettest"
+)
+
+var epoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestWithCacheClock_Expiry(t *testing.T) {
+	p := secrettest.NewProvider(map[string]string{"key": "v1"})
+	clk := secrettest.NewClock(epoch)
+	c := secrets.NewCachedProvider(p, time.Minute, secrets.WithCacheClock(clk))
+	defer c.Close()
+	ctx := context.Background()
+
+	get := func() string {
+		t.Helper()
+		v, err := c.Get(ctx, "key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(v)
+	}
+	get()
+	p.Put("key", "v2")
+	clk.Advance(time.Minute)
+	if got := get(); got != "v1" {
+		t.Errorf("at TTL: got %q, want cached %q", got, "v1")
+	}
+	clk.Advance(time.Nanosecond)
+	if got := get(); got != "v2" {
+		t.Errorf("after TTL: got %q, want %q", got, "v2")
+	}
+	if n := p.Fetches("key"); n != 2 {
+		t.Errorf("fetches = %d, want 2", n)
+	}
+}
+
+func TestWithCacheClock_RefreshAhead(t *testing.T) {
+	p := secrettest.NewProvider(map[string]string{"key": "v1"})
+	clk := secrettest.NewClock(epoch)
+	c := secrets.NewCachedProvider(p, time.Minute, secrets.WithCacheClock(clk), secrets.WithRefreshAhead(0.5))
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(29 * time.Second)
+	if _, err := c.Get(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if n := p.Fetches("key"); n != 1 {
+		t.Fatalf("before refresh point: fetches = %d, want 1", n)
+	}
+	clk.Advance(time.Second)
+	if _, err := c.Get(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	c.Close() // waits for the background refresh
+	if n := p.Fetches("key"); n != 2 {
+		t.Errorf("after refresh point: fetches = %d, want 2", n)
+	}
+}
+
+func TestWithCacheClock_NoRefreshAfterClose(t *testing.T) {
+	p := secrettest.NewProvider(map[string]string{"key": "v1"})
+	clk := secrettest.NewClock(epoch)
+	c := secrets.NewCachedProvider(p, time.Minute, secrets.WithCacheClock(clk), secrets.WithRefreshAhead(0.5))
+	ctx := context.Background()
+
+	c.Close()
+	if _, err := c.Get(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(30 * time.Second)
+	if _, err := c.Get(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if n := p.Fetches("key"); n != 1 {
+		t.Errorf("fetches = %d, want 1: no refresh should start after Close", n)
+	}
+}
+
+func TestWatchClock_Poll(t *testing.T) {
+	var cfg struct {
+		APIKey string `secret:"api-key"`
+	}
+	p := secrettest.NewProvider(map[string]string{"api-key": "v1"})
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	clk := secrettest.NewClock(epoch)
+	w, err := r.Watch(context.Background(), &cfg, secrets.WatchInterval(time.Minute), secrets.WatchClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	clk.BlockUntil(1)
+	if next, _ := clk.Next(); !next.Equal(epoch.Add(time.Minute)) {
+		t.Fatalf("first poll at %v, want %v", next, epoch.Add(time.Minute))
+	}
+	p.Put("api-key", "v2")
+	clk.Advance(time.Minute)
+	ev := <-w.Changes()
+	if ev.Field != "APIKey" || string(ev.NewValue) != "v2" {
+		t.Errorf("event = %s %q, want APIKey %q", ev.Field, ev.NewValue, "v2")
+	}
+	clk.BlockUntil(1)
+	if next, _ := clk.Next(); !next.Equal(epoch.Add(2 * time.Minute)) {
+		t.Errorf("second poll at %v, want %v", next, epoch.Add(2*time.Minute))
+	}
+}
+
+func TestWatchClock_Jitter(t *testing.T) {
+	var cfg struct {
+		APIKey string `secret:"api-key"`
+	}
+	p := secrettest.NewProvider(map[string]string{"api-key": "v1"})
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	for range 20 {
+		clk := secrettest.NewClock(epoch)
+		w, err := r.Watch(context.Background(), &cfg,
+			secrets.WatchInterval(time.Minute), secrets.WatchJitter(0.25), secrets.WatchClock(clk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		clk.BlockUntil(1)
+		next, _ := clk.Next()
+		w.Stop()
+		if d := next.Sub(epoch); d < 45*time.Second || d > 75*time.Second {
+			t.Fatalf("poll delay %v, want within 60s ±25%%", d)
+		}
+	}
+}
+
+func TestWatchClock_Backoff(t *testing.T) {
+	var cfg struct {
+		APIKey string `secret:"api-key"`
+	}
+	p := secrettest.NewProvider(map[string]string{"api-key": "v1"})
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	clk := secrettest.NewClock(epoch)
+	w, err := r.Watch(context.Background(), &cfg,
+		secrets.WatchInterval(time.Minute), secrets.WatchMaxBackoff(3*time.Minute), secrets.WatchClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	p.Fail("api-key", errors.New("503"))
+	now := epoch
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		clk.BlockUntil(1)
+		next, _ := clk.Next()
+		if d := next.Sub(now); d != want {
+			t.Fatalf("poll delay %v, want %v", d, want)
+		}
+		clk.Advance(want)
+		now = now.Add(want)
+	}
+}
+
+func TestWatchClock_InitialRetry(t *testing.T) {
+	var cfg struct {
+		APIKey string `secret:"api-key"`
+	}
+	p := secrettest.NewProvider(nil)
+	p.Fail("api-key", errors.New("503"))
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	clk := secrettest.NewClock(epoch)
+
+	type result struct {
+		w   *secrets.Watcher
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		w, err := r.Watch(context.Background(), &cfg, secrets.WatchClock(clk),
+			secrets.WithInitialRetry(secrets.RetryPolicy{InitialInterval: 5 * time.Second}))
+		done <- result{w, err}
+	}()
+
+	clk.BlockUntil(1)
+	if next, _ := clk.Next(); !next.Equal(epoch.Add(5 * time.Second)) {
+		t.Fatalf("retry at %v, want %v", next, epoch.Add(5*time.Second))
+	}
+	p.Put("api-key", "v1")
+	clk.Advance(5 * time.Second)
+	res := <-done
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	res.w.Stop()
+	if cfg.APIKey != "v1" {
+		t.Errorf("APIKey = %q, want %q", cfg.APIKey, "v1")
+	}
+}
@@ -0,0 +1,159 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+)
+
+// ErrClosed is returned by fetches made after the Resolver has been closed.
+var ErrClosed = errors.New("resolver closed")
+
+// Close closes the Resolver without a deadline. See CloseContext.
+func (r *Resolver) Close() error {
+	return r.CloseContext(context.Background())
+}
+
+// CloseContext shuts the Resolver down in order:
+//
+//  1. New fetches and Watch calls are refused with ErrClosed, and every
+//     running Watcher is told to stop.
+//  2. The Watchers' poll loops, and fetches already in flight, including those
+//     of Resolve and Secret.Get calls, are waited for.
+//  3. The Recorder set with WithMetrics is flushed, if it has a
+//     Flush(context.Context) error method.
+//  4. Providers that implement io.Closer are closed in the reverse of their
+//     registration order, so that a provider registered after another, and
+//     possibly depending on it, is closed first.
+//
+// If ctx is done before watchers stop or fetches complete, CloseContext stops
+// waiting, still closes the providers, and returns ctx.Err() joined with any
+// close errors. Calls after the first return nil.
+func (r *Resolver) CloseContext(ctx context.Context) error {
+	r.life.Lock()
+	if r.closed {
+		r.life.Unlock()
+		return nil
+	}
+	r.closed = true
+	watchers := make([]*Watcher, 0, len(r.watchers))
+	for w := range r.watchers {
+		watchers = append(watchers, w)
+	}
+	r.life.Unlock()
+
+	var errs []error
+	for _, w := range watchers {
+		w.signalStop()
+	}
+	if err := r.drain(ctx, watchers); err != nil {
+		errs = append(errs, err)
+	}
+	if f, ok := r.cfg.metrics.(interface{ Flush(context.Context) error }); ok {
+		if err := f.Flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := closeProviders(&r.cfg); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// drain waits until watchers have stopped and no fetch is in flight, or until
+// ctx is done.
+func (r *Resolver) drain(ctx context.Context, watchers []*Watcher) error {
+	for _, w := range watchers {
+		select {
+		case <-w.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	idle := make(chan struct{})
+	go func() {
+		r.inflight.Wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// acquire registers an in-flight fetch, reporting false if the Resolver is
+// closed. Callers must call r.inflight.Done when the fetch completes.
+func (r *Resolver) acquire() bool {
+	r.life.Lock()
+	defer r.life.Unlock()
+	if r.closed {
+		return false
+	}
+	r.inflight.Add(1)
+	return true
+}
+
+// trackWatcher registers w to be stopped by CloseContext, reporting false if
+// the Resolver is closed.
+func (r *Resolver) trackWatcher(w *Watcher) bool {
+	r.life.Lock()
+	defer r.life.Unlock()
+	if r.closed {
+		return false
+	}
+	if r.watchers == nil {
+		r.watchers = make(map[*Watcher]struct{})
+	}
+	r.watchers[w] = struct{}{}
+	return true
+}
+
+// untrackWatcher removes w once it has stopped.
+func (r *Resolver) untrackWatcher(w *Watcher) {
+	r.life.Lock()
+	defer r.life.Unlock()
+	delete(r.watchers, w)
+}
+
+// registered records scheme ("" for the default provider) in the provider
+// registration order, if it is not already there.
+func (c *resolverConfig) registered(scheme string) {
+	if !slices.Contains(c.order, scheme) {
+		c.order = append(c.order, scheme)
+	}
+}
+
+// unregistered removes scheme from the provider registration order.
+func (c *resolverConfig) unregistered(scheme string) {
+	c.order = slices.DeleteFunc(c.order, func(s string) bool { return s == scheme })
+}
+
+// closeProviders closes all providers that implement io.Closer, in the reverse
+// of their registration order. A provider registered more than once is closed
+// once.
+func closeProviders(cfg *resolverConfig) error {
+	var errs []error
+	seen := make(map[Provider]bool)
+	for _, scheme := range slices.Backward(cfg.order) {
+		p := cfg.defaultProvider
+		if scheme != "" {
+			p = cfg.providers[scheme]
+		}
+		if p == nil || seen[p] {
+			continue
+		}
+		seen[p] = true
+		if c, ok := p.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,150 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// orderedCloser records the order in which providers are closed.
+type orderedCloser struct {
+	name  string
+	order *[]string
+}
+
+func (p *orderedCloser) Get(_ context.Context, key string) ([]byte, error) {
+	return []byte(p.name), nil
+}
+
+func (p *orderedCloser) Close() error {
+	*p.order = append(*p.order, p.name)
+	return nil
+}
+
+// gateProvider blocks each Get until release is closed.
+type gateProvider struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (p *gateProvider) Get(ctx context.Context, _ string) ([]byte, error) {
+	p.entered <- struct{}{}
+	<-p.release
+	return []byte("value"), nil
+}
+
+// flushRecorder is a Recorder with a Flush method.
+type flushRecorder struct {
+	recordingRecorder
+	flushed bool
+}
+
+func (r *flushRecorder) Flush(context.Context) error {
+	r.flushed = true
+	return nil
+}
+
+func TestCloseContext_ReverseRegistrationOrder(t *testing.T) {
+	var order []string
+	a := &orderedCloser{name: "a", order: &order}
+	b := &orderedCloser{name: "b", order: &order}
+	c := &orderedCloser{name: "c", order: &order}
+	d := &orderedCloser{name: "d", order: &order}
+	r := NewResolver(WithDefault(a), WithProvider("b", b), WithProvider("c", c), WithProvider("also-a", a))
+	r.ReplaceProvider("d", d)
+	r.ReplaceProvider("c", nil)
+
+	if err := r.CloseContext(context.Background()); err != nil {
+		t.Fatalf("CloseContext: %v", err)
+	}
+	if want := []string{"d", "a", "b"}; !slices.Equal(order, want) {
+		t.Errorf("close order = %q, want %q", order, want)
+	}
+	if err := r.Close(); err != nil || len(order) != 3 {
+		t.Errorf("second Close = %v, closed %q, want no-op", err, order)
+	}
+}
+
+func TestCloseContext_WaitsForInFlightFetches(t *testing.T) {
+	p := &gateProvider{entered: make(chan struct{}), release: make(chan struct{})}
+	rec := &flushRecorder{}
+	r := NewResolver(WithDefault(p), WithMetrics(rec))
+
+	var cfg struct {
+		Key string `secret:"key"`
+	}
+	resolved := make(chan error)
+	go func() { resolved <- r.Resolve(context.Background(), &cfg) }()
+	<-p.entered
+
+	closed := make(chan error)
+	go func() { closed <- r.CloseContext(context.Background()) }()
+	select {
+	case err := <-closed:
+		t.Fatalf("CloseContext returned %v before the fetch completed", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(p.release)
+	if err := <-resolved; err != nil {
+		t.Errorf("Resolve: %v", err)
+	}
+	if err := <-closed; err != nil {
+		t.Errorf("CloseContext: %v", err)
+	}
+	if !rec.flushed {
+		t.Error("recorder was not flushed")
+	}
+
+	if err := r.Resolve(context.Background(), &cfg); !errors.Is(err, ErrClosed) {
+		t.Errorf("Resolve after close: expected ErrClosed, got %v", err)
+	}
+}
+
+func TestCloseContext_Deadline(t *testing.T) {
+	p := &gateProvider{entered: make(chan struct{}), release: make(chan struct{})}
+	defer close(p.release)
+	cp := &closableProvider{}
+	r := NewResolver(WithDefault(p), WithProvider("c", cp))
+
+	var cfg struct {
+		Key string `secret:"key"`
+	}
+	go func() { _ = r.Resolve(context.Background(), &cfg) }()
+	<-p.entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.CloseContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CloseContext: expected DeadlineExceeded, got %v", err)
+	}
+	if !cp.closed {
+		t.Error("provider was not closed after the deadline")
+	}
+}
+
+func TestCloseContext_StopsWatchers(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("key", []byte("value"))
+	r := NewResolver(WithDefault(store))
+
+	var cfg struct {
+		Key string `secret:"key"`
+	}
+	w, err := r.Watch(context.Background(), &cfg, WatchInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if err := r.CloseContext(context.Background()); err != nil {
+		t.Fatalf("CloseContext: %v", err)
+	}
+	if _, ok := <-w.Changes(); ok {
+		t.Error("Changes channel still open after CloseContext")
+	}
+	w.Stop() // stopping again is safe
+
+	if _, err := r.Watch(context.Background(), &cfg); !errors.Is(err, ErrClosed) {
+		t.Errorf("Watch after close: expected ErrClosed, got %v", err)
+	}
+}
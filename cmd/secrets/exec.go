@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+)
+
+var execCmd = &command{
+	name:  "exec",
+	usage: "[-default scheme] -e NAME=ref... -- command [args...]",
+	short: "run a command with secrets added to its environment",
+	run:   runExec,
+}
+
+// envFlag collects repeated -e NAME=ref flags.
+type envFlag map[string]string
+
+func (f envFlag) String() string { return "" }
+
+func (f envFlag) Set(s string) error {
+	name, ref, ok := strings.Cut(s, "=")
+	if !ok {
+		return errors.New("want NAME=ref")
+	}
+	if _, dup := f[name]; dup {
+		return fmt.Errorf("%s set more than once", name)
+	}
+	f[name] = ref
+	return nil
+}
+
+func runExec(ctx context.Context, c *cli, cmd *command, args []string) error {
+	fs := c.flagSet(cmd)
+	defaultScheme := fs.String("default", "", "provider `scheme` that serves references without one")
+	mapping := envFlag{}
+	fs.Var(mapping, "e", "set environment variable `NAME=ref` to the value of ref (repeatable)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return &usageError{"no command given"}
+	}
+
+	r, err := newResolver(*defaultScheme)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+	defer r.Close()
+	child := exec.Command(fs.Arg(0), fs.Args()[1:]...)
+	child.Stdin, child.Stdout, child.Stderr = c.stdin, c.stdout, c.stderr
+	err = secrets.Exec(ctx, r, mapping, child)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code := exitErr.ExitCode()
+		if code < 0 {
+			code = 1 // killed by a signal
+		}
+		return &exitError{code: code}
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The test binary doubles as the child process of exec tests: with
+// SECRETS_TEST_CHILD set, it prints the variables named by its arguments and
+// exits with the status in SECRETS_TEST_CHILD.
+func TestMain(m *testing.M) {
+	if status := os.Getenv("SECRETS_TEST_CHILD"); status != "" {
+		for _, name := range os.Args[1:] {
+			fmt.Printf("%s=%s\n", name, os.Getenv(name))
+		}
+		code, _ := strconv.Atoi(status)
+		os.Exit(code)
+	}
+	os.Exit(m.Run())
+}
+
+func TestExec(t *testing.T) {
+	t.Setenv("SECRETS_TEST_DB", `{"password":"s3cret"}`)
+	t.Setenv("SECRETS_TEST_CHILD", "0")
+
+	code, stdout, stderr := runCLI(t, "", "exec",
+		"-e", "DB_PASSWORD=env://SECRETS_TEST_DB#password",
+		"--", os.Args[0], "DB_PASSWORD", "SECRETS_TEST_CHILD")
+	if code != 0 {
+		t.Fatalf("exit status = %d: %s", code, stderr)
+	}
+	if want := "DB_PASSWORD=s3cret\nSECRETS_TEST_CHILD=0\n"; stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+}
+
+func TestExec_ExitStatus(t *testing.T) {
+	t.Setenv("SECRETS_TEST_CHILD", "3")
+
+	if code, _, stderr := runCLI(t, "", "exec", "--", os.Args[0]); code != 3 {
+		t.Errorf("exit status = %d, want the child's status 3: %s", code, stderr)
+	}
+}
+
+func TestExec_Errors(t *testing.T) {
+	t.Setenv("SECRETS_TEST_CHILD", "0")
+
+	code, stdout, stderr := runCLI(t, "", "exec", "-e", "X=env://SECRETS_TEST_MISSING", "--", os.Args[0], "X")
+	if code != 1 || !strings.Contains(stderr, "SECRETS_TEST_MISSING") || stdout != "" {
+		t.Errorf("missing secret: exit status = %d, stdout = %q, stderr = %q", code, stdout, stderr)
+	}
+	if code, _, _ := runCLI(t, "", "exec", "-e", "X=env://A"); code != 2 {
+		t.Errorf("no command: exit status = %d, want 2", code)
+	}
+	if code, _, _ := runCLI(t, "", "exec", "-e", "X", "--", os.Args[0]); code != 2 {
+		t.Errorf("bad -e: exit status = %d, want 2", code)
+	}
+}
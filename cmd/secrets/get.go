@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brwse/go-secrets"
+)
+
+var getCmd = &command{
+	name:  "get",
+	usage: "[-default scheme] [-n] ref...",
+	short: "print the values of secret references",
+	run:   runGet,
+}
+
+func runGet(ctx context.Context, c *cli, cmd *command, args []string) error {
+	fs := c.flagSet(cmd)
+	defaultScheme := fs.String("default", "", "provider `scheme` that serves references without one")
+	noNewline := fs.Bool("n", false, "do not print a newline after each value")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return &usageError{"no references given"}
+	}
+
+	refs := make([]secrets.Ref, fs.NArg())
+	for i, arg := range fs.Args() {
+		ref, err := secrets.ParseRef(arg)
+		if err != nil {
+			return &usageError{fmt.Sprintf("reference %q: %v", arg, err)}
+		}
+		refs[i] = ref
+	}
+
+	r, err := newResolver(*defaultScheme)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+	defer r.Close()
+	values := make([][]byte, len(refs))
+	for i, ref := range refs {
+		if values[i], err = r.ResolveRef(ctx, ref); err != nil {
+			return err
+		}
+	}
+	for _, value := range values {
+		if _, err := c.stdout.Write(value); err != nil {
+			return err
+		}
+		if !*noNewline {
+			if _, err := fmt.Fprintln(c.stdout); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	t.Setenv("SECRETS_TEST_DB", `{"user":"admin","password":"s3cret"}`)
+	t.Setenv("SECRETS_TEST_KEY", "k3y")
+
+	code, stdout, stderr := runCLI(t, "", "get", "env://SECRETS_TEST_DB#password", "env://SECRETS_TEST_KEY")
+	if code != 0 {
+		t.Fatalf("exit status = %d: %s", code, stderr)
+	}
+	if stdout != "s3cret\nk3y\n" {
+		t.Errorf("stdout = %q, want %q", stdout, "s3cret\nk3y\n")
+	}
+
+	code, stdout, _ = runCLI(t, "", "get", "-n", "-default", "env", "SECRETS_TEST_KEY")
+	if code != 0 || stdout != "k3y" {
+		t.Errorf("get -n -default env: exit status = %d, stdout = %q", code, stdout)
+	}
+}
+
+func TestGet_Errors(t *testing.T) {
+	t.Setenv("SECRETS_TEST_KEY", "k3y")
+
+	code, stdout, stderr := runCLI(t, "", "get", "env://SECRETS_TEST_KEY", "env://SECRETS_TEST_MISSING")
+	if code != 1 || !strings.Contains(stderr, "SECRETS_TEST_MISSING") {
+		t.Errorf("missing secret: exit status = %d, stderr = %q", code, stderr)
+	}
+	if stdout != "" {
+		t.Errorf("stdout = %q, want nothing printed when a reference fails", stdout)
+	}
+
+	if code, _, _ := runCLI(t, "", "get"); code != 2 {
+		t.Errorf("no references: exit status = %d, want 2", code)
+	}
+	if code, _, _ := runCLI(t, "", "get", "env://X,bogus"); code != 2 {
+		t.Errorf("bad reference: exit status = %d, want 2", code)
+	}
+	if code, _, _ := runCLI(t, "", "get", "-default", "nope", "key"); code != 2 {
+		t.Errorf("unknown default: exit status = %d, want 2", code)
+	}
+}
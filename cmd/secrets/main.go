@@ -0,0 +1,165 @@
+// Command secrets reads secrets from the providers supported by
+// github.com/brwse/go-secrets, using the reference syntax of its `secret`
+// struct tags, for use in shell scripts and CI:
+//
+//	secrets get awssm://prod/db#password
+//	secrets resolve -f config.tmpl -o config.yaml
+//	secrets exec -e DB_PASSWORD=awssm://prod/db#password -- ./migrate
+//	secrets validate ./...
+//
+// References name a provider by URI scheme: awssm, awsps, awskms, gcpsm, azkv,
+// ibmsm, alikms (Alibaba Cloud), tencentssm (Tencent Cloud), vault, vaultagent,
+// k8s, k8scm (Kubernetes ConfigMaps), consul, redis, akeyless, keeper, pkcs11,
+// dockersecret (Docker and Podman secrets), systemdcreds (systemd service
+// credentials), op (1Password), env, dotenv (./.env), civars (CI variables),
+// keyring (the OS keyring), or file. Each provider is configured from the
+// environment as its SDK usually is, such as AWS_REGION, GOOGLE_CLOUD_PROJECT,
+// VAULT_ADDR and VAULT_TOKEN, KUBECONFIG, CONSUL_HTTP_ADDR, REDIS_URL,
+// AKEYLESS_ACCESS_ID and AKEYLESS_ACCESS_KEY, or PKCS11_MODULE; azkv reads its
+// vault URL from AZURE_KEYVAULT_URL.
+//
+// Run "secrets help <command>" for the flags of a command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// command is a subcommand of the CLI.
+type command struct {
+	name  string
+	usage string // argument synopsis
+	short string // one-line description
+	run   func(ctx context.Context, c *cli, cmd *command, args []string) error
+}
+
+var commands = []*command{getCmd, resolveCmd, execCmd, validateCmd}
+
+// cli holds the streams of a CLI invocation.
+type cli struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+// usageError reports invalid command-line arguments.
+type usageError struct{ msg string }
+
+func (e *usageError) Error() string { return e.msg }
+
+// exitError carries the exit status of a child process run by exec.
+type exitError struct{ code int }
+
+func (e *exitError) Error() string { return fmt.Sprintf("exit status %d", e.code) }
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	code := run(ctx, &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+// run runs the command named by args[0] and returns the exit status: 0 on
+// success, 2 for invalid arguments, the child's status for exec, and 1 for
+// any other error.
+func run(ctx context.Context, c *cli, args []string) int {
+	if len(args) == 0 {
+		c.usage()
+		return 2
+	}
+	name, args := args[0], args[1:]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		if len(args) > 0 {
+			if cmd := lookup(args[0]); cmd != nil {
+				cmd.run(ctx, c, cmd, []string{"-h"})
+				return 0
+			}
+		}
+		c.usage()
+		return 0
+	}
+	cmd := lookup(name)
+	if cmd == nil {
+		fmt.Fprintf(c.stderr, "secrets: unknown command %q\n", name)
+		c.usage()
+		return 2
+	}
+
+	err := cmd.run(ctx, c, cmd, args)
+	var usageErr *usageError
+	var exitErr *exitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(c.stderr, "secrets %s: %v\nusage: secrets %s %s\n", cmd.name, err, cmd.name, cmd.usage)
+		return 2
+	case errors.As(err, &exitErr):
+		return exitErr.code
+	default:
+		fmt.Fprintf(c.stderr, "secrets %s: %v\n", cmd.name, err)
+		return 1
+	}
+}
+
+func lookup(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func (c *cli) usage() {
+	var b strings.Builder
+	b.WriteString("usage: secrets <command> [arguments]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-10s %s\n", cmd.name, cmd.short)
+	}
+	b.WriteString("\nRun \"secrets help <command>\" for the flags of a command.\n")
+	io.WriteString(c.stderr, b.String())
+}
+
+// flagSet returns a flag set for cmd whose usage message is written to
+// c.stderr.
+func (c *cli) flagSet(cmd *command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: secrets %s %s\n\n%s\n", cmd.name, cmd.usage, cmd.short)
+		if hasFlags(fs) {
+			fmt.Fprintln(c.stderr, "\nflags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	n := 0
+	fs.VisitAll(func(*flag.Flag) { n++ })
+	return n > 0
+}
+
+// parseFlags parses args with fs. A parse error has already been reported
+// with the usage message, so it is returned as flag.ErrHelp for -h and as an
+// exit status of 2 otherwise.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &exitError{code: 2}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// runCLI runs the CLI with args and returns its exit status and output.
+func runCLI(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	c := &cli{stdin: strings.NewReader(stdin), stdout: &out, stderr: &errOut}
+	code = run(context.Background(), c, args)
+	return code, out.String(), errOut.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	code, _, stderr := runCLI(t, "")
+	if code != 2 {
+		t.Errorf("exit status = %d, want 2", code)
+	}
+	for _, cmd := range commands {
+		if !strings.Contains(stderr, cmd.name) {
+			t.Errorf("usage does not list %s:\n%s", cmd.name, stderr)
+		}
+	}
+
+	if code, _, _ := runCLI(t, "", "help"); code != 0 {
+		t.Errorf("help: exit status = %d, want 0", code)
+	}
+	code, _, stderr = runCLI(t, "", "help", "exec")
+	if code != 0 || !strings.Contains(stderr, "usage: secrets exec") || !strings.Contains(stderr, "-e NAME=ref") {
+		t.Errorf("help exec: exit status = %d, output:\n%s", code, stderr)
+	}
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	code, _, stderr := runCLI(t, "", "frobnicate")
+	if code != 2 || !strings.Contains(stderr, `unknown command "frobnicate"`) {
+		t.Errorf("exit status = %d, output:\n%s", code, stderr)
+	}
+}
+
+func TestRun_BadFlag(t *testing.T) {
+	code, _, stderr := runCLI(t, "", "get", "-bogus", "env://X")
+	if code != 2 || !strings.Contains(stderr, "usage: secrets get") {
+		t.Errorf("exit status = %d, output:\n%s", code, stderr)
+	}
+}
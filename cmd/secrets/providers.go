@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/brwse/go-secrets"
+	"github.com/brwse/go-secrets/akeyless"
+	"github.com/brwse/go-secrets/alikms"
+	"github.com/brwse/go-secrets/awskms"
+	"github.com/brwse/go-secrets/awsps"
+	"github.com/brwse/go-secrets/awssm"
+	"github.com/brwse/go-secrets/azkv"
+	"github.com/brwse/go-secrets/civars"
+	"github.com/brwse/go-secrets/consul"
+	"github.com/brwse/go-secrets/dockersecret"
+	"github.com/brwse/go-secrets/dotenv"
+	"github.com/brwse/go-secrets/env"
+	"github.com/brwse/go-secrets/file"
+	"github.com/brwse/go-secrets/gcpsm"
+	"github.com/brwse/go-secrets/ibmsm"
+	"github.com/brwse/go-secrets/k8s"
+	"github.com/brwse/go-secrets/keeper"
+	"github.com/brwse/go-secrets/keyring"
+	"github.com/brwse/go-secrets/onepassword"
+	"github.com/brwse/go-secrets/pkcs11"
+	"github.com/brwse/go-secrets/redis"
+	"github.com/brwse/go-secrets/systemdcreds"
+	"github.com/brwse/go-secrets/tencentssm"
+	"github.com/brwse/go-secrets/vault"
+	"github.com/brwse/go-secrets/vaultagent"
+)
+
+// providers maps each URI scheme the CLI understands to a constructor for its
+// provider. Providers are configured from the environment in the usual way
+// for their SDK (AWS_REGION, GOOGLE_CLOUD_PROJECT, VAULT_ADDR, KUBECONFIG,
+// CONSUL_HTTP_ADDR, REDIS_URL, AKEYLESS_ACCESS_ID, PKCS11_MODULE, and so
+// on); azkv reads the vault URL from AZURE_KEYVAULT_URL, and op uses a
+// Connect server if OP_CONNECT_HOST is set.
+var providers = map[string]func() (secrets.Provider, error){
+	"awssm":  func() (secrets.Provider, error) { return awssm.New() },
+	"awsps":  func() (secrets.Provider, error) { return awsps.New() },
+	"awskms": func() (secrets.Provider, error) { return awskms.New() },
+	"gcpsm":  func() (secrets.Provider, error) { return gcpsm.New() },
+	"azkv": func() (secrets.Provider, error) {
+		return azkv.New(azkv.WithVaultURL(os.Getenv("AZURE_KEYVAULT_URL")))
+	},
+	"ibmsm":        func() (secrets.Provider, error) { return ibmsm.New() },
+	"alikms":       func() (secrets.Provider, error) { return alikms.New() },
+	"tencentssm":   func() (secrets.Provider, error) { return tencentssm.New() },
+	"vault":        func() (secrets.Provider, error) { return vault.New() },
+	"vaultagent":   func() (secrets.Provider, error) { return vaultagent.New(), nil },
+	"k8s":          func() (secrets.Provider, error) { return k8s.New() },
+	"k8scm":        func() (secrets.Provider, error) { return k8s.New(k8s.WithConfigMaps()) },
+	"consul":       func() (secrets.Provider, error) { return consul.New(), nil },
+	"redis":        func() (secrets.Provider, error) { return redis.New() },
+	"akeyless":     func() (secrets.Provider, error) { return akeyless.New() },
+	"keeper":       func() (secrets.Provider, error) { return keeper.New(), nil },
+	"pkcs11":       func() (secrets.Provider, error) { return pkcs11.New() },
+	"dockersecret": func() (secrets.Provider, error) { return dockersecret.New(), nil },
+	"systemdcreds": func() (secrets.Provider, error) { return systemdcreds.New() },
+	"op": func() (secrets.Provider, error) {
+		if host := os.Getenv("OP_CONNECT_HOST"); host != "" {
+			return onepassword.New(onepassword.WithConnect(host, os.Getenv("OP_CONNECT_TOKEN"))), nil
+		}
+		return onepassword.New(), nil
+	},
+	"env":     func() (secrets.Provider, error) { return env.New(), nil },
+	"dotenv":  func() (secrets.Provider, error) { return dotenv.New(), nil },
+	"civars":  func() (secrets.Provider, error) { return civars.New(), nil },
+	"keyring": func() (secrets.Provider, error) { return keyring.New(), nil },
+	"file":    func() (secrets.Provider, error) { return file.New(), nil },
+}
+
+// newResolver returns a Resolver with every provider registered under its
+// scheme. Providers are created on first use, so that a command reading only
+// env:// secrets does not need cloud credentials. If defaultScheme is not
+// empty, bare keys are served by that provider.
+func newResolver(defaultScheme string) (*secrets.Resolver, error) {
+	opts := []secrets.Option{secrets.WithUserAgent("go-secrets-cli")}
+	for scheme, newProvider := range providers {
+		p := &lazyProvider{scheme: scheme, new: newProvider}
+		opts = append(opts, secrets.WithProvider(scheme, p))
+		if scheme == defaultScheme {
+			opts = append(opts, secrets.WithDefault(p))
+		}
+	}
+	if _, ok := providers[defaultScheme]; defaultScheme != "" && !ok {
+		return nil, fmt.Errorf("unknown default provider %q", defaultScheme)
+	}
+	return secrets.NewResolver(opts...), nil
+}
+
+// lazyProvider creates its provider on first use.
+type lazyProvider struct {
+	scheme string
+	new    func() (secrets.Provider, error)
+
+	once sync.Once
+	p    secrets.Provider
+	err  error
+}
+
+func (l *lazyProvider) provider() (secrets.Provider, error) {
+	l.once.Do(func() {
+		l.p, l.err = l.new()
+		if l.err != nil {
+			l.err = fmt.Errorf("create %s provider: %w", l.scheme, l.err)
+		}
+	})
+	return l.p, l.err
+}
+
+func (l *lazyProvider) Get(ctx context.Context, key string) ([]byte, error) {
+	p, err := l.provider()
+	if err != nil {
+		return nil, err
+	}
+	return p.Get(ctx, key)
+}
+
+// GetVersion returns an ErrVersioningNotSupported error if the provider does
+// not implement secrets.VersionedProvider.
+func (l *lazyProvider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	p, err := l.provider()
+	if err != nil {
+		return nil, err
+	}
+	vp, ok := p.(secrets.VersionedProvider)
+	if !ok {
+		return nil, &secrets.ErrVersioningNotSupported{Provider: l.scheme}
+	}
+	return vp.GetVersion(ctx, key, version)
+}
+
+// Close closes the provider if it was created and implements io.Closer. It
+// must not be called concurrently with Get.
+func (l *lazyProvider) Close() error {
+	if c, ok := l.p.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// plainProvider is a provider without versions.
+type plainProvider struct{}
+
+func (plainProvider) Get(context.Context, string) ([]byte, error) { return []byte("v"), nil }
+
+func TestLazyProvider(t *testing.T) {
+	created := 0
+	l := &lazyProvider{scheme: "plain", new: func() (secrets.Provider, error) {
+		created++
+		return plainProvider{}, nil
+	}}
+	if created != 0 {
+		t.Fatal("provider created before use")
+	}
+	for range 2 {
+		if v, err := l.Get(context.Background(), "k"); err != nil || string(v) != "v" {
+			t.Errorf("Get = %q, %v", v, err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("provider created %d times, want 1", created)
+	}
+	var verr *secrets.ErrVersioningNotSupported
+	if _, err := l.GetVersion(context.Background(), "k", "previous"); !errors.As(err, &verr) {
+		t.Errorf("GetVersion: expected ErrVersioningNotSupported, got %v", err)
+	}
+}
+
+func TestLazyProvider_Error(t *testing.T) {
+	l := &lazyProvider{scheme: "broken", new: func() (secrets.Provider, error) {
+		return nil, errors.New("no credentials")
+	}}
+	_, err := l.Get(context.Background(), "k")
+	if err == nil || err.Error() != "create broken provider: no credentials" {
+		t.Errorf("Get: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/brwse/go-secrets/render"
+)
+
+var resolveCmd = &command{
+	name:  "resolve",
+	usage: "[-default scheme] [-f file] [-o file]",
+	short: "render a template, replacing {{ secret \"ref\" }} with secret values",
+	run:   runResolve,
+}
+
+func runResolve(ctx context.Context, c *cli, cmd *command, args []string) error {
+	fs := c.flagSet(cmd)
+	defaultScheme := fs.String("default", "", "provider `scheme` that serves references without one")
+	in := fs.String("f", "-", "template `file` to read, or - for standard input")
+	out := fs.String("o", "-", "`file` to write with mode 0600, or - for standard output")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return &usageError{fmt.Sprintf("unexpected argument %q", fs.Arg(0))}
+	}
+
+	var tmpl *render.Template
+	if *in == "-" {
+		text, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return err
+		}
+		tmpl, err = render.Parse("stdin", string(text))
+		if err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if tmpl, err = render.ParseFile(*in); err != nil {
+			return err
+		}
+	}
+
+	r, err := newResolver(*defaultScheme)
+	if err != nil {
+		return &usageError{err.Error()}
+	}
+	defer r.Close()
+	if *out == "-" {
+		return tmpl.Execute(ctx, r, c.stdout, nil)
+	}
+	return tmpl.ExecuteFile(ctx, r, *out, nil)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("SECRETS_TEST_DB", `{"user":"admin","password":"s3cret"}`)
+	tmpl := `user: {{ secret "env://SECRETS_TEST_DB#user" }}
+password: {{ secret "env://SECRETS_TEST_DB#password" | printf "%q" }}
+`
+	code, stdout, stderr := runCLI(t, tmpl, "resolve")
+	if code != 0 {
+		t.Fatalf("exit status = %d: %s", code, stderr)
+	}
+	if want := "user: admin\npassword: \"s3cret\"\n"; stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+}
+
+func TestResolve_Files(t *testing.T) {
+	t.Setenv("SECRETS_TEST_KEY", "k3y")
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "config.tmpl"), filepath.Join(dir, "config")
+	if err := os.WriteFile(in, []byte(`key={{ secret "env://SECRETS_TEST_KEY" }}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, _, stderr := runCLI(t, "", "resolve", "-f", in, "-o", out); code != 0 {
+		t.Fatalf("exit status = %d: %s", code, stderr)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "key=k3y" {
+		t.Errorf("output = %q, want %q", got, "key=k3y")
+	}
+	if fi, err := os.Stat(out); err == nil && fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", fi.Mode().Perm())
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	code, stdout, stderr := runCLI(t, `a {{ secret "env://SECRETS_TEST_MISSING" }}`, "resolve")
+	if code != 1 || !strings.Contains(stderr, "SECRETS_TEST_MISSING") {
+		t.Errorf("missing secret: exit status = %d, stderr = %q", code, stderr)
+	}
+	if stdout != "" {
+		t.Errorf("stdout = %q, want nothing written on error", stdout)
+	}
+
+	if code, _, _ := runCLI(t, `{{ secret }`, "resolve"); code != 1 {
+		t.Errorf("bad template: exit status = %d, want 1", code)
+	}
+}
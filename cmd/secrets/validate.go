@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+)
+
+var validateCmd = &command{
+	name:  "validate",
+	usage: "[-schemes list] [packages]",
+	short: "check the secret struct tags in Go source files",
+	run:   runValidate,
+}
+
+func runValidate(_ context.Context, c *cli, cmd *command, args []string) error {
+	fs := c.flagSet(cmd)
+	extra := fs.String("schemes", "", "comma-separated `list` of additional provider schemes the application registers")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	schemes := make(map[string]bool, len(providers))
+	for scheme := range providers {
+		schemes[scheme] = true
+	}
+	for scheme := range strings.SplitSeq(*extra, ",") {
+		if scheme = strings.TrimSpace(scheme); scheme != "" {
+			schemes[scheme] = true
+		}
+	}
+
+	var files []string
+	for _, pattern := range patterns {
+		matched, err := goFiles(pattern)
+		if err != nil {
+			return err
+		}
+		files = append(files, matched...)
+	}
+
+	fset := token.NewFileSet()
+	problems := 0
+	for _, path := range files {
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		for _, p := range checkTags(fset, f, schemes) {
+			fmt.Fprintln(c.stderr, p)
+			problems++
+		}
+	}
+	if problems > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// goFiles returns the Go source files matched by pattern: a file, a directory,
+// or a directory followed by "/..." for it and its subdirectories. Like the go
+// command, the walk skips testdata and vendor directories and those whose
+// names begin with "." or "_".
+func goFiles(pattern string) ([]string, error) {
+	root, recursive := strings.CutSuffix(pattern, "...")
+	if recursive {
+		root = filepath.Clean(strings.TrimSuffix(root, "/"))
+		if root == "" {
+			root = "."
+		}
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			name := d.Name()
+			if !recursive || name == "testdata" || name == "vendor" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// checkTags returns a message for each secret struct tag in f that does not
+// parse, or that names a provider scheme not in schemes.
+func checkTags(fset *token.FileSet, f *ast.File, schemes map[string]bool) []string {
+	var problems []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok || field.Tag == nil {
+			return true
+		}
+		tags, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return true
+		}
+		raw, ok := reflect.StructTag(tags).Lookup("secret")
+		if !ok {
+			return true
+		}
+
+		name := "embedded field"
+		if len(field.Names) > 0 {
+			name = "field " + field.Names[0].Name
+		}
+		report := func(format string, args ...any) {
+			problems = append(problems, fmt.Sprintf("%s: %s: %s", fset.Position(field.Tag.Pos()), name, fmt.Sprintf(format, args...)))
+		}
+		ref, err := secrets.ParseRef(raw)
+		switch {
+		case err != nil:
+			report("%v", err)
+		case ref.Scheme() != "" && !schemes[ref.Scheme()]:
+			report("unknown provider scheme %q", ref.Scheme())
+		}
+		return true
+	})
+	return problems
+}
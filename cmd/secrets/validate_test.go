@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.go"), "package app\n\ntype Config struct {\n"+
+		"\tDB     string `secret:\"awssm://prod/db#password\"`\n"+
+		"\tKey    string `json:\"key\" secret:\"key,optional\"`\n"+
+		"\tBad    string `secret:\"key,sometimes\"`\n"+
+		"\tTypo   string `secret:\"awsms://prod/db\"`\n"+
+		"\tCustom string `secret:\"db://users\"`\n"+
+		"\tPlain  string `json:\"plain\"`\n"+
+		"}\n")
+	writeFile(t, filepath.Join(dir, "sub", "sub.go"), "package sub\n\nvar v struct {\n\tX string `secret:\"\"`\n}\n")
+	writeFile(t, filepath.Join(dir, "testdata", "skip.go"), "package skip\n\nvar v struct {\n\tX string `secret:\"\"`\n}\n")
+
+	code, _, stderr := runCLI(t, "", "validate", "-schemes", "db", dir+"/...")
+	if code != 1 {
+		t.Errorf("exit status = %d, want 1", code)
+	}
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	want := []string{
+		"config.go:6:16: field Bad: secrets: unknown tag option \"sometimes\"",
+		"config.go:7:16: field Typo: unknown provider scheme \"awsms\"",
+		"sub.go:4:11: field X: secrets: empty tag",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("output:\n%s\nwant %d problems", stderr, len(want))
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(lines[i], w) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], w)
+		}
+	}
+
+	// Without "/...", subdirectories are not checked.
+	code, _, stderr = runCLI(t, "", "validate", "-schemes", "db", dir)
+	if code != 1 || strings.Contains(stderr, "sub.go") {
+		t.Errorf("exit status = %d, output:\n%s", code, stderr)
+	}
+}
+
+func TestValidate_Clean(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.go"), "package app\n\ntype Config struct {\n\tDB string `secret:\"vault://db#password,transform=trim\"`\n}\n")
+
+	if code, stdout, stderr := runCLI(t, "", "validate", filepath.Join(dir, "config.go")); code != 0 || stdout+stderr != "" {
+		t.Errorf("exit status = %d, output: %q", code, stdout+stderr)
+	}
+}
@@ -0,0 +1,571 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/brwse/go-secrets"
+)
+
+// secretsPath is the import path of the secrets package.
+const secretsPath = "github.com/brwse/go-secrets"
+
+// pkg is a parsed package.
+type pkg struct {
+	fset    *token.FileSet
+	name    string
+	types   map[string]*typeDecl
+	methods map[string]map[string]bool // receiver type name -> method names
+}
+
+// typeDecl is a type declared in the package.
+type typeDecl struct {
+	spec *ast.TypeSpec
+	file *ast.File
+}
+
+// parsePackage parses the non-test Go files in dir other than output.
+func parsePackage(dir, output string) (*pkg, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	p := &pkg{
+		fset:    token.NewFileSet(),
+		types:   make(map[string]*typeDecl),
+		methods: make(map[string]map[string]bool),
+	}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == filepath.Base(output) {
+			continue
+		}
+		f, err := parser.ParseFile(p.fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if p.name != "" && f.Name.Name != p.name {
+			return nil, fmt.Errorf("%s: found packages %s and %s", dir, p.name, f.Name.Name)
+		}
+		p.name = f.Name.Name
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						p.types[ts.Name.Name] = &typeDecl{spec: ts, file: f}
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv == nil || len(decl.Recv.List) == 0 {
+					continue
+				}
+				recv := decl.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+				if ident, ok := recv.(*ast.Ident); ok {
+					if p.methods[ident.Name] == nil {
+						p.methods[ident.Name] = make(map[string]bool)
+					}
+					p.methods[ident.Name][decl.Name.Name] = true
+				}
+			}
+		}
+	}
+	if p.name == "" {
+		return nil, fmt.Errorf("%s: no Go files", dir)
+	}
+	return p, nil
+}
+
+// loader describes the function generated for a type.
+type loader struct {
+	typeName string
+	funcName string
+	allocs   []alloc           // nil pointers to nested structs, outermost first
+	fields   []*field          // secret-tagged fields, in declaration order
+	imports  map[string]string // import path -> name, for field types
+}
+
+// alloc is a pointer to a nested struct that the loader allocates.
+type alloc struct {
+	expr string // the pointer, such as "c.DB"
+	typ  string // the struct type
+}
+
+// field is a secret-tagged field.
+type field struct {
+	name string // dotted path from the type, such as "DB.Password"
+	expr string // Go expression for the field, such as "c.DB.Password"
+	uri  string
+	ref  secrets.Ref
+	conv conversion
+}
+
+// conversion describes how a field is set from the secret's bytes.
+type conversion struct {
+	kind    string // "string", "bytes", "bool", "int", "uint", "float", "duration", or "text"
+	bits    int    // bit size for int, uint, and float; 0 for int and uint
+	typ     string // the field's type, without the pointer
+	pointer bool   // whether the field is a pointer to typ
+}
+
+// loader returns the loader of the struct type name.
+func (p *pkg) loader(name string) (*loader, error) {
+	decl, ok := p.types[name]
+	if !ok {
+		return nil, fmt.Errorf("type %s not found in package %s", name, p.name)
+	}
+	st, ok := decl.spec.Type.(*ast.StructType)
+	if !ok || decl.spec.TypeParams != nil {
+		return nil, fmt.Errorf("type %s is not a non-generic struct", name)
+	}
+	funcName := "Load" + name
+	if !ast.IsExported(name) {
+		r, size := utf8.DecodeRuneInString(name)
+		funcName = "load" + string(unicode.ToUpper(r)) + name[size:]
+	}
+	l := &loader{typeName: name, funcName: funcName, imports: make(map[string]string)}
+	if err := p.walk(l, st, decl.file, "", "c", map[string]bool{name: true}); err != nil {
+		return nil, err
+	}
+	if len(l.fields) == 0 {
+		return nil, fmt.Errorf("type %s has no secret-tagged fields", name)
+	}
+	return l, nil
+}
+
+// walk adds the secret-tagged fields of st, declared in file, to l, with
+// name and expr as the path to st. It follows nested structs as
+// secrets.Resolver does. seen holds the types on the path, to stop at cycles.
+func (p *pkg) walk(l *loader, st *ast.StructType, file *ast.File, name, expr string, seen map[string]bool) error {
+	var errs []error
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			s, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(s)
+		}
+		uri, tagged := tag.Lookup("secret")
+
+		names := f.Names
+		if len(names) == 0 {
+			// An embedded field is named after its type. The resolver
+			// follows embedded structs, but not pointers to them.
+			ident, ok := f.Type.(*ast.Ident)
+			if !ok || tagged {
+				continue
+			}
+			names = []*ast.Ident{ident}
+		}
+		for _, n := range names {
+			fieldName := join(name, n.Name)
+			fieldExpr := expr + "." + n.Name
+			if len(f.Names) > 0 && !n.IsExported() {
+				continue
+			}
+			if tagged {
+				fd, err := p.field(l, file, fieldName, fieldExpr, uri, f.Type)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %s.%s: %w", p.fset.Position(f.Pos()), l.typeName, fieldName, err))
+					continue
+				}
+				l.fields = append(l.fields, fd)
+				continue
+			}
+
+			// Follow nested structs declared in this package.
+			typ := f.Type
+			star, pointer := typ.(*ast.StarExpr)
+			if pointer {
+				if len(f.Names) == 0 {
+					continue
+				}
+				typ = star.X
+			}
+			ident, ok := typ.(*ast.Ident)
+			if !ok || seen[ident.Name] {
+				continue
+			}
+			decl, ok := p.types[ident.Name]
+			if !ok || decl.spec.TypeParams != nil {
+				continue
+			}
+			nested, ok := decl.spec.Type.(*ast.StructType)
+			if !ok || !p.hasSecretTags(nested, map[string]bool{}) {
+				continue
+			}
+			if pointer {
+				l.allocs = append(l.allocs, alloc{expr: fieldExpr, typ: ident.Name})
+			}
+			seen[ident.Name] = true
+			if err := p.walk(l, nested, decl.file, fieldName, fieldExpr, seen); err != nil {
+				errs = append(errs, err)
+			}
+			delete(seen, ident.Name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hasSecretTags reports whether st, or a struct nested in it that is
+// declared in this package, has secret-tagged fields.
+func (p *pkg) hasSecretTags(st *ast.StructType, seen map[string]bool) bool {
+	for _, f := range st.Fields.List {
+		if f.Tag != nil {
+			if s, err := strconv.Unquote(f.Tag.Value); err == nil {
+				if _, ok := reflect.StructTag(s).Lookup("secret"); ok {
+					return true
+				}
+			}
+		}
+		typ := f.Type
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ = star.X
+		}
+		ident, ok := typ.(*ast.Ident)
+		if !ok || seen[ident.Name] {
+			continue
+		}
+		if decl, ok := p.types[ident.Name]; ok {
+			if nested, ok := decl.spec.Type.(*ast.StructType); ok {
+				seen[ident.Name] = true
+				if p.hasSecretTags(nested, seen) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// field parses the tag of a secret-tagged field and works out its
+// conversion.
+func (p *pkg) field(l *loader, file *ast.File, name, expr, uri string, typ ast.Expr) (*field, error) {
+	ref, err := secrets.ParseRef(uri)
+	if err != nil {
+		return nil, err
+	}
+	for opt := range strings.SplitSeq(uri, ",") {
+		if o, _, _ := strings.Cut(opt, "="); o == "ttl" || o == "bootstrap" {
+			return nil, fmt.Errorf("tag option %s= needs secrets.Resolver.Resolve", o)
+		}
+	}
+	conv, err := p.conversion(l, file, typ)
+	if err != nil {
+		return nil, err
+	}
+	return &field{name: name, expr: expr, uri: uri, ref: ref, conv: conv}, nil
+}
+
+// basicKinds maps the predeclared types the resolver converts to their
+// conversion kind and bit size.
+var basicKinds = map[string]struct {
+	kind string
+	bits int
+}{
+	"string": {"string", 0}, "bool": {"bool", 0},
+	"int": {"int", 0}, "int8": {"int", 8}, "int16": {"int", 16}, "int32": {"int", 32}, "int64": {"int", 64},
+	"uint": {"uint", 0}, "uint8": {"uint", 8}, "uint16": {"uint", 16}, "uint32": {"uint", 32}, "uint64": {"uint", 64},
+	"byte": {"uint", 8}, "rune": {"int", 32},
+	"float32": {"float", 32}, "float64": {"float", 64},
+}
+
+// conversion returns the conversion of a field of type typ, declared in file.
+func (p *pkg) conversion(l *loader, file *ast.File, typ ast.Expr) (conversion, error) {
+	if star, ok := typ.(*ast.StarExpr); ok {
+		c, err := p.conversion(l, file, star.X)
+		if err != nil {
+			return conversion{}, err
+		}
+		if c.pointer {
+			return conversion{}, fmt.Errorf("unsupported type %s", types.ExprString(typ))
+		}
+		c.pointer = true
+		return c, nil
+	}
+
+	c := conversion{typ: types.ExprString(typ)}
+	switch t := typ.(type) {
+	case *ast.Ident:
+		if b, ok := basicKinds[t.Name]; ok {
+			c.kind, c.bits = b.kind, b.bits
+			return c, nil
+		}
+		decl, ok := p.types[t.Name]
+		if !ok {
+			return conversion{}, fmt.Errorf("unsupported type %s", t.Name)
+		}
+		if p.methods[t.Name]["UnmarshalText"] {
+			c.kind = "text"
+			return c, nil
+		}
+		// A named type whose underlying type is predeclared converts as it.
+		if under, ok := decl.spec.Type.(*ast.Ident); ok && decl.spec.Assign == token.NoPos {
+			if b, ok := basicKinds[under.Name]; ok {
+				c.kind, c.bits = b.kind, b.bits
+				return c, nil
+			}
+		}
+		return conversion{}, fmt.Errorf("type %s has no UnmarshalText method", t.Name)
+	case *ast.ArrayType:
+		if elt, ok := t.Elt.(*ast.Ident); ok && t.Len == nil && (elt.Name == "byte" || elt.Name == "uint8") {
+			c.kind = "bytes"
+			return c, nil
+		}
+	case *ast.SelectorExpr:
+		path, err := l.importFor(file, t)
+		if err != nil {
+			return conversion{}, err
+		}
+		if path == "time" && t.Sel.Name == "Duration" {
+			c.kind = "duration"
+		} else {
+			c.kind = "text"
+		}
+		return c, nil
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		var x ast.Expr
+		if ix, ok := t.(*ast.IndexExpr); ok {
+			x = ix.X
+		} else {
+			x = t.(*ast.IndexListExpr).X
+		}
+		if sel, ok := x.(*ast.SelectorExpr); ok {
+			if path, err := l.importFor(file, sel); err == nil && path == secretsPath {
+				return conversion{}, fmt.Errorf("%s fields need secrets.Resolver.Resolve", types.ExprString(x))
+			}
+		}
+	}
+	return conversion{}, fmt.Errorf("unsupported type %s", types.ExprString(typ))
+}
+
+// importFor records the import of the package sel refers to in file, which
+// the generated code needs to name its type, and returns its path.
+func (l *loader) importFor(file *ast.File, sel *ast.SelectorExpr) (string, error) {
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return "", fmt.Errorf("unsupported type %s", types.ExprString(sel))
+	}
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if path == secretsPath {
+			name = "secrets"
+		}
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if name == x.Name {
+			l.imports[path] = name
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no import for package %s", x.Name)
+}
+
+// source returns the formatted source of the loaders.
+func (p *pkg) source(loaders []*loader) ([]byte, error) {
+	imports := map[string]string{"context": "context", "errors": "errors", secretsPath: "secrets"}
+	var body bytes.Buffer
+	for _, l := range loaders {
+		maps.Copy(imports, l.imports)
+		l.write(&body, imports)
+	}
+	body.WriteString(`
+// _secretsgenParseRefs parses the references of fields, by field. secretsgen
+// checked that they parse.
+func _secretsgenParseRefs(uris map[string]string) map[string]secrets.Ref {
+	refs := make(map[string]secrets.Ref, len(uris))
+	for name, uri := range uris {
+		ref, err := secrets.ParseRef(uri)
+		if err != nil {
+			panic("secretsgen: " + name + ": " + err.Error())
+		}
+		refs[name] = ref
+	}
+	return refs
+}
+`)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by secretsgen; DO NOT EDIT.\n\npackage %s\n\nimport (\n", p.name)
+	for _, path := range slices.Sorted(maps.Keys(imports)) {
+		name := imports[path]
+		if name == path[strings.LastIndex(path, "/")+1:] {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		} else {
+			fmt.Fprintf(&b, "\t%s %q\n", name, path)
+		}
+	}
+	b.WriteString(")\n")
+	b.Write(body.Bytes())
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// write writes the loader's code to b, adding the imports it needs.
+func (l *loader) write(b *bytes.Buffer, imports map[string]string) {
+	refsVar := "_" + l.typeName + "SecretRefs"
+	fmt.Fprintf(b, "\n// %s holds the references of the secret-tagged fields of %s, by field.\n", refsVar, l.typeName)
+	fmt.Fprintf(b, "var %s = _secretsgenParseRefs(map[string]string{\n", refsVar)
+	for _, f := range l.fields {
+		fmt.Fprintf(b, "\t%q: %q,\n", f.name, f.uri)
+	}
+	b.WriteString("})\n")
+
+	fmt.Fprintf(b, `
+// %[1]s returns a %[2]s with its secret-tagged fields resolved with r.
+// Secrets are fetched as r.ResolveRefs fetches them. As with r.Resolve, the
+// errors of every field that failed are joined, and the other fields are set.
+func %[1]s(ctx context.Context, r *secrets.Resolver) (*%[2]s, error) {
+	values, err := r.ResolveRefs(ctx, %[3]s)
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	var c %[2]s
+`, l.funcName, l.typeName, refsVar)
+	for _, a := range l.allocs {
+		fmt.Fprintf(b, "\t%s = new(%s)\n", a.expr, a.typ)
+	}
+	for _, f := range l.fields {
+		f.write(b, imports)
+	}
+	b.WriteString("\treturn &c, errors.Join(errs...)\n}\n")
+}
+
+// write writes the code that sets the field from values to b.
+func (f *field) write(b *bytes.Buffer, imports map[string]string) {
+	c := f.conv
+	fmt.Fprintf(b, "\tif v, ok := values[%q]; ok {\n", f.name)
+	set := func(value string) {
+		if c.pointer {
+			fmt.Fprintf(b, "x := %s\n%s = &x\n", value, f.expr)
+		} else {
+			fmt.Fprintf(b, "%s = %s\n", f.expr, value)
+		}
+	}
+	parse := func(call, result string) {
+		fmt.Fprintf(b, "if n, err := %s; err != nil {\n", call)
+		fmt.Fprintf(b, "errs = append(errs, &secrets.ErrConversion{Field: %q, TypeName: %q, Raw: string(v), Err: err})\n", f.name, c.typ)
+		b.WriteString("} else {\n")
+		set(result)
+		b.WriteString("}\n")
+	}
+	trimmed := "strings.TrimSpace(string(v))"
+	switch c.kind {
+	case "string":
+		set(c.typ + "(v)")
+	case "bytes":
+		set(c.typ + "(append([]byte(nil), v...))")
+	case "bool":
+		parse("strconv.ParseBool("+trimmed+")", c.typ+"(n)")
+	case "int":
+		parse(fmt.Sprintf("strconv.ParseInt(%s, 10, %d)", trimmed, c.bits), c.typ+"(n)")
+	case "uint":
+		parse(fmt.Sprintf("strconv.ParseUint(%s, 10, %d)", trimmed, c.bits), c.typ+"(n)")
+	case "float":
+		parse(fmt.Sprintf("strconv.ParseFloat(%s, %d)", trimmed, c.bits), c.typ+"(n)")
+	case "duration":
+		parse("time.ParseDuration("+trimmed+")", c.typ+"(n)")
+	case "text":
+		target := f.expr
+		if c.pointer {
+			fmt.Fprintf(b, "x := new(%s)\n", c.typ)
+			target = "x"
+		}
+		fmt.Fprintf(b, "if err := %s.UnmarshalText(v); err != nil {\n", target)
+		fmt.Fprintf(b, "errs = append(errs, &secrets.ErrConversion{Field: %q, TypeName: %q, Raw: string(v), Err: err})\n", f.name, c.typ)
+		if c.pointer {
+			fmt.Fprintf(b, "} else {\n%s = x\n", f.expr)
+		}
+		b.WriteString("}\n")
+	}
+	switch c.kind {
+	case "bool", "int", "uint", "float", "duration":
+		imports["strings"] = "strings"
+		if c.kind == "duration" {
+			imports["time"] = "time"
+		} else {
+			imports["strconv"] = "strconv"
+		}
+	}
+	b.WriteString("\t}\n")
+}
+
+// manifestEntry lists the keys a type reads from a provider.
+type manifestEntry struct {
+	Required []string `json:"required,omitempty"`
+	Optional []string `json:"optional,omitempty"`
+}
+
+// manifestJSON returns the manifest of the loaders: for each type, the keys
+// it reads from each provider, by scheme, with "default" for bare keys. A key
+// is optional if every field that reads it is.
+func manifestJSON(loaders []*loader) ([]byte, error) {
+	manifest := make(map[string]map[string]*manifestEntry)
+	for _, l := range loaders {
+		required := make(map[string]map[string]bool) // provider -> key -> required
+		for _, f := range l.fields {
+			provider := f.ref.Scheme()
+			if provider == "" {
+				provider = "default"
+			}
+			if required[provider] == nil {
+				required[provider] = make(map[string]bool)
+			}
+			required[provider][f.ref.Key()] = required[provider][f.ref.Key()] || !f.ref.Optional()
+		}
+		byProvider := make(map[string]*manifestEntry)
+		for provider, keys := range required {
+			e := &manifestEntry{}
+			for _, key := range slices.Sorted(maps.Keys(keys)) {
+				if keys[key] {
+					e.Required = append(e.Required, key)
+				} else {
+					e.Optional = append(e.Optional, key)
+				}
+			}
+			byProvider[provider] = e
+		}
+		manifest[l.typeName] = byProvider
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// join appends name to the dotted path.
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
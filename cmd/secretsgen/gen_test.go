@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const configSrc = `package app
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+type Level string
+
+type Config struct {
+	Password string        ` + "`secret:\"test://db#password\"`" + `
+	Port     int           ` + "`secret:\"test://db#port\"`" + `
+	Timeout  time.Duration ` + "`secret:\"test://timeout,optional\"`" + `
+	Debug    *bool         ` + "`secret:\"debug\"`" + `
+	Level    Level         ` + "`secret:\"level\"`" + `
+	Key      []byte        ` + "`secret:\"test://key\"`" + `
+	Endpoint *netip.Addr   ` + "`secret:\"endpoint\"`" + `
+	Name     string
+	DB       *DB
+	Base
+
+	ref secrets.Ref
+}
+
+type DB struct {
+	Replica string ` + "`secret:\"test://replica,optional\"`" + `
+}
+
+type Base struct {
+	Region string ` + "`secret:\"region\"`" + `
+}
+`
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerate_Manifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "config.go"), configSrc)
+	if code := run([]string{"-type", "Config", dir}, os.Stderr); code != 0 {
+		t.Fatalf("exit status = %d", code)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "secrets_manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]map[string]manifestEntry
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]manifestEntry{
+		"Config": {
+			"test":    {Required: []string{"db", "key"}, Optional: []string{"replica", "timeout"}},
+			"default": {Required: []string{"debug", "endpoint", "level", "region"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("manifest = %s, want %v", data, want)
+	}
+
+	src, err := os.ReadFile(filepath.Join(dir, "secrets_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"// Code generated by secretsgen; DO NOT EDIT.",
+		"func LoadConfig(ctx context.Context, r *secrets.Resolver) (*Config, error) {",
+		`"net/netip"`,
+		"c.DB = new(DB)",
+		"c.DB.Replica = string(v)",
+		"c.Base.Region = string(v)",
+	} {
+		if !bytes.Contains(src, []byte(s)) {
+			t.Errorf("generated code does not contain %q:\n%s", s, src)
+		}
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	tests := []struct {
+		name, field, want string
+	}{
+		{"bad tag", "X string `secret:\"key,sometimes\"`", "unknown tag option"},
+		{"versioned", "X secrets.Versioned[string] `secret:\"key\"`", "secrets.Versioned fields need"},
+		{"ttl", "X string `secret:\"key,ttl=1h\"`", "ttl= needs"},
+		{"unsupported", "X map[string]string `secret:\"key\"`", "unsupported type map[string]string"},
+		{"no UnmarshalText", "X Other `secret:\"key\"`", "type Other has no UnmarshalText method"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "config.go"), "package app\n\nimport \"github.com/brwse/go-secrets\"\n\nvar _ secrets.Ref\n\ntype Other struct{}\n\ntype Config struct {\n\t"+tt.field+"\n}\n")
+			var stderr bytes.Buffer
+			if code := run([]string{"-type", "Config", dir}, &stderr); code != 1 {
+				t.Fatalf("exit status = %d, want 1", code)
+			}
+			if !strings.Contains(stderr.String(), "Config.X: ") || !strings.Contains(stderr.String(), tt.want) {
+				t.Errorf("stderr = %q, want the field and %q", stderr.String(), tt.want)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "secrets_gen.go")); err == nil {
+				t.Error("code was generated despite the error")
+			}
+		})
+	}
+
+	if code := run([]string{"-type", "Missing", t.TempDir()}, &bytes.Buffer{}); code != 1 {
+		t.Errorf("exit status for a missing package = %d, want 1", code)
+	}
+	if code := run(nil, &bytes.Buffer{}); code != 2 {
+		t.Errorf("exit status without -type = %d, want 2", code)
+	}
+}
+
+// TestGenerate_Compiles builds and runs a program using generated code.
+func TestGenerate_Compiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the go command")
+	}
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), "module app\n\ngo 1.25.0\n\nrequire github.com/brwse/go-secrets v0.0.0\n\nreplace github.com/brwse/go-secrets => "+root+"\n")
+	sum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "go.sum"), string(sum))
+	writeFile(t, filepath.Join(dir, "config.go"), strings.Replace(configSrc, "package app", "package main", 1))
+	writeFile(t, filepath.Join(dir, "main.go"), `package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brwse/go-secrets"
+	"github.com/brwse/go-secrets/secrettest"
+)
+
+func main() {
+	p := secrettest.NewProvider(map[string]string{
+		"db":       `+"`"+`{"password":"hunter2","port":"5432"}`+"`"+`,
+		"debug":    "true",
+		"level":    "warn",
+		"key":      "k",
+		"endpoint": "10.0.0.1",
+		"region":   "eu",
+	})
+	r := secrets.NewResolver(secrets.WithProvider("test", p), secrets.WithDefault(p))
+	c, err := LoadConfig(context.Background(), r)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(c.Password, c.Port, c.Timeout, *c.Debug, c.Level, string(c.Key), c.Endpoint, c.DB.Replica == "", c.Region)
+
+	p.Put("debug", "maybe")
+	if _, err := LoadConfig(context.Background(), r); err == nil {
+		panic("want a conversion error")
+	}
+}
+`)
+	if code := run([]string{"-type", "Config", "-manifest", "", dir}, os.Stderr); code != 0 {
+		t.Fatalf("exit status = %d", code)
+	}
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off", "GOPROXY=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		src, _ := os.ReadFile(filepath.Join(dir, "secrets_gen.go"))
+		t.Fatalf("go run: %v\n%s\ngenerated code:\n%s", err, out, src)
+	}
+	if got, want := strings.TrimSpace(string(out)), "hunter2 5432 0s true warn k 10.0.0.1 true eu"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+// Command secretsgen generates, for configuration structs with `secret`
+// struct tags, a typed function that resolves them without reflection, and a
+// manifest of the secrets each needs from each provider. Run it with go
+// generate:
+//
+//	//go:generate go run github.com/brwse/go-secrets/cmd/secretsgen -type Config
+//
+//	type Config struct {
+//		DBPassword string        `secret:"awssm://prod/db#password"`
+//		Timeout    time.Duration `secret:"vault://app/config#timeout,optional"`
+//	}
+//
+// For each type, it writes a function
+//
+//	func LoadConfig(ctx context.Context, r *secrets.Resolver) (*Config, error)
+//
+// to secrets_gen.go, and the manifest to secrets_manifest.json. Tags are
+// parsed when the code is generated, so a malformed tag fails the build
+// rather than the deployment, and the manifest lets CI check that every
+// secret exists and that the service's role may read it.
+//
+// Fields are converted as secrets.Resolver.Resolve converts them, by code
+// specific to their type; fields of other types are set with their
+// UnmarshalText method, which the compiler checks they have. Nested structs
+// are followed if they are declared in the same package. Versioned[T],
+// Secret[T], and Redacted[T] fields, and field factories, need Resolve.
+//
+// Usage:
+//
+//	secretsgen -type T[,T...] [-output file] [-manifest file] [dir]
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run runs secretsgen with args and returns the exit status: 0 on success, 2
+// for invalid arguments, and 1 for any other error.
+func run(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("secretsgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	typeNames := fs.String("type", "", "comma-separated `list` of struct types to generate loaders for (required)")
+	output := fs.String("output", "secrets_gen.go", "`file` to write the generated code to, relative to dir")
+	manifest := fs.String("manifest", "secrets_manifest.json", "`file` to write the manifest to, relative to dir; empty for none")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: secretsgen -type T[,T...] [-output file] [-manifest file] [dir]")
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *typeNames == "" || fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	dir := "."
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+
+	var types []string
+	for name := range strings.SplitSeq(*typeNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			types = append(types, name)
+		}
+	}
+	if err := generate(dir, types, *output, *manifest); err != nil {
+		fmt.Fprintf(stderr, "secretsgen: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// generate writes the loaders of types, declared in the package in dir, to
+// output, and their manifest to manifest unless it is empty.
+func generate(dir string, types []string, output, manifest string) error {
+	pkg, err := parsePackage(dir, output)
+	if err != nil {
+		return err
+	}
+	var loaders []*loader
+	var errs []error
+	for _, name := range types {
+		l, err := pkg.loader(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		loaders = append(loaders, l)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	src, err := pkg.source(loaders)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, output), src, 0o644); err != nil {
+		return err
+	}
+	if manifest == "" {
+		return nil
+	}
+	data, err := manifestJSON(loaders)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, manifest), data, 0o644)
+}
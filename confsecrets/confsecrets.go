@@ -0,0 +1,229 @@
+// Package confsecrets resolves secret references held in the values of a
+// configuration map, so that applications configured with koanf or viper can
+// reference secrets from their config files:
+//
+//	db:
+//	  host: db.internal
+//	  password: awssm://prod/db#password
+//
+// With koanf, wrap the parser of the files, or the provider of an already
+// parsed map:
+//
+//	k := koanf.New(".")
+//	err := k.Load(file.Provider("config.yaml"), confsecrets.NewParser(ctx, r, yaml.Parser()))
+//
+// With viper, resolve its settings and merge them back:
+//
+//	settings, err := confsecrets.Resolve(ctx, r, v.AllSettings())
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	err = v.MergeConfigMap(settings)
+//
+// A string value is a reference if it is a URI whose scheme has a provider
+// registered with the resolver, such as "awssm://prod/db#password", or if it
+// begins with "!secret ", as placeholders in secrets.ResolveDocument do, which
+// also serves bare keys from the default provider. Other strings, such as
+// "https://example.com", are left as they are. References use `secret` tag
+// syntax, so fragments and options such as optional and version= apply.
+package confsecrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+)
+
+// placeholderPrefix marks a string as a reference whatever its scheme.
+const placeholderPrefix = "!secret "
+
+// Source reads a configuration map. koanf providers that parse their own
+// data, such as env.Provider and confmap.Provider, implement it.
+type Source interface {
+	Read() (map[string]any, error)
+}
+
+// Parser parses configuration files. It has the methods of koanf.Parser,
+// which koanf's yaml, json, toml, and dotenv parsers implement.
+type Parser interface {
+	Unmarshal([]byte) (map[string]any, error)
+	Marshal(map[string]any) ([]byte, error)
+}
+
+// Resolve returns a copy of m in which every reference in a string value,
+// however deeply nested in maps and slices, is replaced by the value of the
+// secret it references. A missing optional secret is replaced by nil.
+//
+// Secrets are fetched concurrently, once per distinct secret, as
+// secrets.Resolver.ResolveRefs does. Errors name the path of the value, such
+// as "db.password" or "replicas.0.password", and are joined; if any reference
+// cannot be parsed or resolved, Resolve returns no map.
+func Resolve(ctx context.Context, r *secrets.Resolver, m map[string]any) (map[string]any, error) {
+	refs := make(map[string]secrets.Ref)
+	var errs []error
+	collect(r, "", m, refs, &errs)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("confsecrets: %w", errors.Join(errs...))
+	}
+	if len(refs) == 0 {
+		return copyMap(m, "", nil), nil
+	}
+	values, err := r.ResolveRefs(ctx, refs)
+	if err != nil {
+		return nil, fmt.Errorf("confsecrets: %w", err)
+	}
+	return copyMap(m, "", func(path string) (any, bool) {
+		if _, ok := refs[path]; !ok {
+			return nil, false
+		}
+		value, ok := values[path]
+		if !ok {
+			return nil, true // a missing optional secret
+		}
+		return string(value), true
+	}), nil
+}
+
+// Provider is a koanf.Provider that reads a Source and resolves the
+// references in it.
+type Provider struct {
+	ctx context.Context
+	r   *secrets.Resolver
+	src Source
+}
+
+// NewProvider returns a Provider that reads src and resolves the references
+// in it with r. As koanf does not pass a context to providers, ctx is used
+// for every fetch.
+func NewProvider(ctx context.Context, r *secrets.Resolver, src Source) *Provider {
+	return &Provider{ctx: ctx, r: r, src: src}
+}
+
+// Read reads the Source and returns it with its references resolved.
+func (p *Provider) Read() (map[string]any, error) {
+	m, err := p.src.Read()
+	if err != nil {
+		return nil, err
+	}
+	return Resolve(p.ctx, p.r, m)
+}
+
+// ReadBytes is not supported, as the Source has already parsed its data.
+func (p *Provider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("confsecrets: provider does not support ReadBytes")
+}
+
+// resolvingParser is the Parser NewParser returns.
+type resolvingParser struct {
+	ctx context.Context
+	r   *secrets.Resolver
+	p   Parser
+}
+
+// NewParser returns a Parser that parses with p and resolves the references
+// in the result with r. Marshal is p's, so a configuration marshaled with it
+// holds the secrets, not the references. As koanf does not pass a context to
+// parsers, ctx is used for every fetch.
+func NewParser(ctx context.Context, r *secrets.Resolver, p Parser) Parser {
+	return &resolvingParser{ctx: ctx, r: r, p: p}
+}
+
+func (p *resolvingParser) Unmarshal(data []byte) (map[string]any, error) {
+	m, err := p.p.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return Resolve(p.ctx, p.r, m)
+}
+
+func (p *resolvingParser) Marshal(m map[string]any) ([]byte, error) {
+	return p.p.Marshal(m)
+}
+
+// collect adds the references among the values under v, at path, to refs by
+// path.
+func collect(r *secrets.Resolver, path string, v any, refs map[string]secrets.Ref, errs *[]error) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			collect(r, join(path, k), e, refs, errs)
+		}
+	case map[any]any:
+		for k, e := range v {
+			collect(r, join(path, fmt.Sprint(k)), e, refs, errs)
+		}
+	case []any:
+		for i, e := range v {
+			collect(r, join(path, strconv.Itoa(i)), e, refs, errs)
+		}
+	case string:
+		ref, ok, err := parse(r, v)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", path, err))
+		} else if ok {
+			refs[path] = ref
+		}
+	}
+}
+
+// parse parses s if it is a reference.
+func parse(r *secrets.Resolver, s string) (secrets.Ref, bool, error) {
+	if uri, ok := strings.CutPrefix(s, placeholderPrefix); ok {
+		ref, err := secrets.ParseRef(strings.TrimSpace(uri))
+		return ref, err == nil, err
+	}
+	scheme, _, ok := strings.Cut(s, "://")
+	if !ok || scheme == "" || !r.HasProvider(scheme) {
+		return secrets.Ref{}, false, nil
+	}
+	ref, err := secrets.ParseRef(s)
+	return ref, err == nil, err
+}
+
+// copyMap copies m, replacing the values at the paths replace reports.
+// A nil replace copies m as it is.
+func copyMap(m map[string]any, path string, replace func(path string) (any, bool)) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = copyValue(v, join(path, k), replace)
+	}
+	return out
+}
+
+func copyValue(v any, path string, replace func(path string) (any, bool)) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return copyMap(v, path, replace)
+	case map[any]any:
+		out := make(map[any]any, len(v))
+		for k, e := range v {
+			out[k] = copyValue(e, join(path, fmt.Sprint(k)), replace)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = copyValue(e, join(path, strconv.Itoa(i)), replace)
+		}
+		return out
+	case string:
+		if replace != nil {
+			if value, ok := replace(path); ok {
+				return value
+			}
+		}
+	}
+	return v
+}
+
+// join appends key to path with koanf's and viper's default delimiter.
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
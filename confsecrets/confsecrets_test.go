@@ -0,0 +1,140 @@
+package confsecrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+	"github.com/brwse/go-secrets/secrettest"
+)
+
+func newResolver() (*secrets.Resolver, *secrettest.Provider) {
+	p := secrettest.NewProvider(map[string]string{
+		"db":      `{"password":"hunter2"}`,
+		"api-key": "sk_live",
+	})
+	return secrets.NewResolver(secrets.WithProvider("test", p), secrets.WithDefault(p)), p
+}
+
+func TestResolve(t *testing.T) {
+	r, p := newResolver()
+	in := map[string]any{
+		"db": map[string]any{
+			"host":     "db.internal",
+			"password": "test://db#password",
+		},
+		"api_key":  "!secret api-key",
+		"homepage": "https://example.com",
+		"missing":  "test://nope,optional",
+		"replicas": []any{
+			map[any]any{"password": "test://db#password"},
+		},
+		"port": 5432,
+	}
+	got, err := Resolve(context.Background(), r, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"db": map[string]any{
+			"host":     "db.internal",
+			"password": "hunter2",
+		},
+		"api_key":  "sk_live",
+		"homepage": "https://example.com",
+		"missing":  nil,
+		"replicas": []any{
+			map[any]any{"password": "hunter2"},
+		},
+		"port": 5432,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve = %v, want %v", got, want)
+	}
+	if in["db"].(map[string]any)["password"] != "test://db#password" {
+		t.Error("Resolve modified its input")
+	}
+	// "db" is fetched once for both references.
+	if n := len(p.Calls()); n != 3 {
+		t.Errorf("%d fetches, want 3", n)
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	r, _ := newResolver()
+	tests := []struct {
+		name string
+		in   map[string]any
+		want string
+	}{
+		{"missing", map[string]any{"db": map[string]any{"password": "test://nope"}}, "db.password"},
+		{"bad reference", map[string]any{"key": "test://db,bogus"}, "key"},
+		{"unknown scheme in placeholder", map[string]any{"key": "!secret other://db"}, "key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(context.Background(), r, tt.in)
+			if err == nil {
+				t.Fatalf("Resolve = %v, want error", got)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not name %q", err, tt.want)
+			}
+		})
+	}
+}
+
+type mapSource map[string]any
+
+func (s mapSource) Read() (map[string]any, error) { return s, nil }
+
+func TestProvider(t *testing.T) {
+	r, _ := newResolver()
+	p := NewProvider(context.Background(), r, mapSource{"password": "test://db#password"})
+	got, err := p.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["password"] != "hunter2" {
+		t.Errorf("password = %v, want hunter2", got["password"])
+	}
+	if _, err := p.ReadBytes(); err == nil {
+		t.Error("ReadBytes succeeded, want error")
+	}
+}
+
+type jsonParser struct{}
+
+func (jsonParser) Unmarshal(b []byte) (map[string]any, error) {
+	var m map[string]any
+	err := json.Unmarshal(b, &m)
+	return m, err
+}
+
+func (jsonParser) Marshal(m map[string]any) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func TestParser(t *testing.T) {
+	r, _ := newResolver()
+	p := NewParser(context.Background(), r, jsonParser{})
+	got, err := p.Unmarshal([]byte(`{"db": {"password": "test://db#password"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pw := got["db"].(map[string]any)["password"]; pw != "hunter2" {
+		t.Errorf("password = %v, want hunter2", pw)
+	}
+	if _, err := p.Unmarshal([]byte(`{`)); err == nil {
+		t.Error("Unmarshal of invalid JSON succeeded")
+	}
+
+	var unknown *secrets.ErrUnknownProvider
+	if _, err := p.Unmarshal([]byte(`{"key": "!secret nope://key"}`)); !errors.As(err, &unknown) {
+		t.Errorf("err = %v, want ErrUnknownProvider", err)
+	}
+}
@@ -0,0 +1,119 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// httpClient reads keys through the Consul HTTP API.
+type httpClient struct {
+	address    string // such as "http://127.0.0.1:8500"
+	token      string
+	datacenter string
+	waitTime   time.Duration
+	http       *http.Client
+}
+
+func (c *httpClient) GetKey(ctx context.Context, key string, waitIndex uint64) ([]byte, uint64, error) {
+	query := url.Values{"raw": {""}}
+	if waitIndex > 0 {
+		query.Set("index", strconv.FormatUint(waitIndex, 10))
+		query.Set("wait", strconv.Itoa(int(c.waitTime.Seconds()))+"s")
+	}
+	resp, err := c.do(ctx, key, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	index, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, index, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	val, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return val, index, nil
+}
+
+func (c *httpClient) ListKeys(ctx context.Context, prefix string) ([]string, error) {
+	resp, err := c.do(ctx, prefix, url.Values{"keys": {""}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil // no keys have the prefix
+	}
+	var keys []string
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return nil, err
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// do sends a GET request for key to the KV endpoint, returning the response
+// if it succeeded or the key was not found.
+func (c *httpClient) do(ctx context.Context, key string, query url.Values) (*http.Response, error) {
+	if c.datacenter != "" {
+		query.Set("dc", c.datacenter)
+	}
+	// Flags such as "raw" take no value, which Values.Encode renders as
+	// "raw=" and Consul accepts.
+	u := c.address + "/v1/kv/" + escapePath(key) + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	if ua := secrets.UserAgentFromContext(ctx); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		defer resp.Body.Close()
+		return nil, apiError(resp)
+	}
+	return resp, nil
+}
+
+// escapePath escapes each segment of a slash-separated key.
+func escapePath(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// apiError returns a *secrets.ErrProvider for a failed response, whose
+// body is a plain-text message.
+func apiError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	msg := strings.TrimSpace(string(body))
+	if msg == "" {
+		msg = resp.Status
+	}
+	pe := &secrets.ErrProvider{StatusCode: resp.StatusCode, Err: errors.New(msg)}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		pe.RetryAfter = time.Duration(s) * time.Second
+	}
+	return pe
+}
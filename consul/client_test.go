@@ -0,0 +1,78 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+func TestHTTPClient(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		if r.Header.Get("X-Consul-Token") != "tok" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "ACL not found")
+			return
+		}
+		w.Header().Set("X-Consul-Index", "42")
+		q := r.URL.Query()
+		switch {
+		case q.Has("keys"):
+			fmt.Fprint(w, `["app/db", "app/api", "app/sub/"]`)
+		case r.URL.Path == "/v1/kv/app/db" && q.Has("raw"):
+			fmt.Fprint(w, "s3cret")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(WithAddress(srv.URL), WithToken("tok"), WithDatacenter("dc2")).client.(*httpClient)
+	ctx := context.Background()
+	val, index, err := c.GetKey(ctx, "app/db", 0)
+	if err != nil || string(val) != "s3cret" || index != 42 {
+		t.Errorf("GetKey = %q, %d, %v; want s3cret, 42, nil", val, index, err)
+	}
+	if queries[0] != "dc=dc2&raw=" {
+		t.Errorf("query = %q, want dc=dc2&raw=", queries[0])
+	}
+	if _, _, err := c.GetKey(ctx, "app/db", 41); err != nil {
+		t.Errorf("blocking GetKey: %v", err)
+	}
+	if queries[1] != "dc=dc2&index=41&raw=&wait=300s" {
+		t.Errorf("blocking query = %q", queries[1])
+	}
+
+	_, index, err = c.GetKey(ctx, "app/missing", 0)
+	if !errors.Is(err, secrets.ErrNotFound) || index != 42 {
+		t.Errorf("missing key: got index %d, %v; want 42, ErrNotFound", index, err)
+	}
+
+	keys, err := c.ListKeys(ctx, "app/")
+	if want := []string{"app/api", "app/db", "app/sub/"}; err != nil || !slices.Equal(keys, want) {
+		t.Errorf("ListKeys = %q, %v; want %q", keys, err, want)
+	}
+
+	c.token = "wrong"
+	_, _, err = c.GetKey(ctx, "app/db", 0)
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.StatusCode != http.StatusForbidden || pe.Err.Error() != "ACL not found" {
+		t.Errorf("got %v, want a 403 ErrProvider", err)
+	}
+}
+
+func TestNew_Env(t *testing.T) {
+	t.Setenv("CONSUL_HTTP_ADDR", "consul.internal:8500")
+	t.Setenv("CONSUL_HTTP_TOKEN", "from-env")
+	c := New().client.(*httpClient)
+	if c.address != "http://consul.internal:8500" || c.token != "from-env" {
+		t.Errorf("address, token = %q, %q", c.address, c.token)
+	}
+}
@@ -0,0 +1,229 @@
+// Package consul provides a secret provider that reads from the Consul KV
+// store.
+package consul
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// DefaultAddress is the address of the local Consul agent.
+const DefaultAddress = "http://127.0.0.1:8500"
+
+// Client abstracts the Consul KV API.
+type Client interface {
+	// GetKey returns the value of key and the Consul index it was read at.
+	// If waitIndex is not zero, it performs a blocking query that returns
+	// once the index exceeds waitIndex or the wait time passes. The index is
+	// returned with secrets.ErrNotFound too, so that a missing key can be
+	// waited on.
+	GetKey(ctx context.Context, key string, waitIndex uint64) (value []byte, index uint64, err error)
+}
+
+// ListClient is implemented by Clients that can list keys. The default
+// client uses the KV keys endpoint.
+type ListClient interface {
+	// ListKeys returns the keys that start with prefix.
+	ListKeys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// ProviderOption configures the consul Provider.
+type ProviderOption func(*Provider)
+
+// WithAddress sets the URL of the Consul agent or server. Defaults to the
+// CONSUL_HTTP_ADDR environment variable, or DefaultAddress.
+func WithAddress(addr string) ProviderOption {
+	return func(p *Provider) {
+		p.address = addr
+	}
+}
+
+// WithToken sets the ACL token requests are made with. Defaults to the
+// CONSUL_HTTP_TOKEN environment variable.
+func WithToken(token string) ProviderOption {
+	return func(p *Provider) {
+		p.token = token
+	}
+}
+
+// WithDatacenter reads keys from datacenter rather than that of the agent.
+func WithDatacenter(dc string) ProviderOption {
+	return func(p *Provider) {
+		p.datacenter = dc
+	}
+}
+
+// WithWaitTime sets how long each blocking query of Watch waits for a change
+// before Consul returns, up to Consul's maximum of 10 minutes. Defaults to
+// 5 minutes.
+func WithWaitTime(d time.Duration) ProviderOption {
+	return func(p *Provider) {
+		p.waitTime = d
+	}
+}
+
+// WithHTTPClient sets the HTTP client of the default client.
+func WithHTTPClient(c *http.Client) ProviderOption {
+	return func(p *Provider) {
+		p.httpClient = c
+	}
+}
+
+// WithClient injects a custom Client implementation.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider reads secrets from the Consul KV store. Keys are KV paths such as
+// "app/prod/db-password", and values are returned as stored.
+// It implements secrets.Provider, secrets.WatchableProvider, and
+// secrets.ListerProvider.
+type Provider struct {
+	address    string
+	token      string
+	datacenter string
+	waitTime   time.Duration
+	httpClient *http.Client
+	client     Client
+}
+
+// New creates a new Consul Provider.
+func New(opts ...ProviderOption) *Provider {
+	p := &Provider{waitTime: 5 * time.Minute}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		if p.address == "" {
+			p.address = os.Getenv("CONSUL_HTTP_ADDR")
+		}
+		if p.address == "" {
+			p.address = DefaultAddress
+		}
+		if !strings.Contains(p.address, "://") {
+			p.address = "http://" + p.address
+		}
+		if p.token == "" {
+			p.token = os.Getenv("CONSUL_HTTP_TOKEN")
+		}
+		if p.httpClient == nil {
+			p.httpClient = http.DefaultClient
+		}
+		p.client = &httpClient{
+			address:    strings.TrimSuffix(p.address, "/"),
+			token:      p.token,
+			datacenter: p.datacenter,
+			waitTime:   p.waitTime,
+			http:       p.httpClient,
+		}
+	}
+	return p
+}
+
+// Get retrieves the value of key.
+// Returns secrets.ErrNotFound (wrapped) if the key does not exist.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	val, _, err := p.client.GetKey(ctx, strings.TrimPrefix(key, "/"), 0)
+	if err != nil {
+		return nil, fmt.Errorf("consul: secret %q: %w", key, err)
+	}
+	return val, nil
+}
+
+// List returns the keys that start with prefix, sorted.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	lc, ok := p.client.(ListClient)
+	if !ok {
+		return nil, fmt.Errorf("consul: list %q: %w", prefix, errors.ErrUnsupported)
+	}
+	keys, err := lc.ListKeys(ctx, strings.TrimPrefix(prefix, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("consul: list %q: %w", prefix, err)
+	}
+	// Keys ending in "/" are folders, which hold no value of their own.
+	out := keys[:0]
+	for _, k := range keys {
+		if !strings.HasSuffix(k, "/") {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+// Watch returns a channel that receives the value of key each time it
+// changes, or nil when it is deleted, using Consul blocking queries rather
+// than polling. Failed queries are retried with backoff until ctx is done.
+func (p *Provider) Watch(ctx context.Context, key string) (<-chan []byte, error) {
+	name := strings.TrimPrefix(key, "/")
+	val, index, err := p.client.GetKey(ctx, name, 0)
+	if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		return nil, fmt.Errorf("consul: watch secret %q: %w", key, err)
+	}
+	values := make(chan []byte)
+	go func() {
+		defer close(values)
+		failures := 0
+		for {
+			next, nextIndex, err := p.client.GetKey(ctx, name, max(index, 1))
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+				failures++
+				if !sleep(ctx, retryDelay(failures)) {
+					return
+				}
+				continue
+			}
+			failures = 0
+			// Consul resets the index when it goes backwards, such as after
+			// a snapshot restore; start over from the current state.
+			if nextIndex < index {
+				nextIndex = 0
+			}
+			index = nextIndex
+			if bytes.Equal(next, val) && (next == nil) == (val == nil) {
+				continue
+			}
+			val = next
+			select {
+			case values <- val:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return values, nil
+}
+
+// retryDelay returns how long to wait after the given number of consecutive
+// failed queries: 1s doubling up to 1m.
+func retryDelay(failures int) time.Duration {
+	d := time.Second
+	for i := 1; i < failures && d < time.Minute; i++ {
+		d *= 2
+	}
+	return min(d, time.Minute)
+}
+
+// sleep waits for d, reporting false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
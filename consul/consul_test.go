@@ -0,0 +1,213 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// mockClient implements Client and ListClient for testing. Blocking queries
+// wait until the index changes.
+type mockClient struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	kv      map[string]string
+	index   uint64
+	err     error // returned by the next GetKey, if set
+}
+
+func newMockClient(kv map[string]string) *mockClient {
+	m := &mockClient{kv: kv, index: 1}
+	m.changed = sync.NewCond(&m.mu)
+	return m
+}
+
+// set sets key, or deletes it if value is nil, and advances the index.
+func (m *mockClient) set(key string, value *string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if value == nil {
+		delete(m.kv, key)
+	} else {
+		m.kv[key] = *value
+	}
+	m.index++
+	m.changed.Broadcast()
+}
+
+func (m *mockClient) GetKey(ctx context.Context, key string, waitIndex uint64) ([]byte, uint64, error) {
+	stop := context.AfterFunc(ctx, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.changed.Broadcast()
+	})
+	defer stop()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.err; err != nil {
+		m.err = nil
+		return nil, 0, err
+	}
+	for waitIndex >= m.index && ctx.Err() == nil {
+		m.changed.Wait()
+	}
+	val, ok := m.kv[key]
+	if !ok {
+		return nil, m.index, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return []byte(val), m.index, nil
+}
+
+func (m *mockClient) ListKeys(_ context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for k := range m.kv {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+var (
+	_ secrets.WatchableProvider = (*Provider)(nil)
+	_ secrets.ListerProvider    = (*Provider)(nil)
+)
+
+func TestGet(t *testing.T) {
+	p := New(WithClient(newMockClient(map[string]string{"app/db": "s3cret"})))
+	for _, key := range []string{"app/db", "/app/db"} {
+		got, err := p.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		if string(got) != "s3cret" {
+			t.Errorf("Get(%q) = %q, want s3cret", key, got)
+		}
+	}
+}
+
+func TestGet_Missing(t *testing.T) {
+	p := New(WithClient(newMockClient(map[string]string{})))
+	_, err := p.Get(context.Background(), "app/db")
+	if !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	p := New(WithClient(newMockClient(map[string]string{
+		"app/db":   "a",
+		"app/api":  "b",
+		"app/sub/": "",
+		"other":    "c",
+	})))
+	got, err := p.List(context.Background(), "app/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := []string{"app/api", "app/db"}; !slices.Equal(got, want) {
+		t.Errorf("List = %q, want %q", got, want)
+	}
+}
+
+// getOnly implements only Client.
+type getOnly struct{ Client }
+
+func TestList_Unsupported(t *testing.T) {
+	p := New(WithClient(getOnly{newMockClient(nil)}))
+	if _, err := p.List(context.Background(), ""); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("got %v, want ErrUnsupported", err)
+	}
+}
+
+// receive returns the next value from ch, failing after 5 seconds.
+func receive(t *testing.T, ch <-chan []byte) []byte {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a value")
+		return nil
+	}
+}
+
+func TestWatch(t *testing.T) {
+	m := newMockClient(map[string]string{"app/db": "v1"})
+	p := New(WithClient(m))
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := p.Watch(ctx, "app/db")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	// A change to another key advances the index without changing the
+	// value, and is not delivered.
+	other := "x"
+	m.set("app/other", &other)
+	v2 := "v2"
+	m.set("app/db", &v2)
+	if got := receive(t, ch); string(got) != "v2" {
+		t.Errorf("got %q, want v2", got)
+	}
+
+	m.set("app/db", nil)
+	if got := receive(t, ch); got != nil {
+		t.Errorf("got %q after delete, want nil", got)
+	}
+	v3 := "v3"
+	m.set("app/db", &v3)
+	if got := receive(t, ch); string(got) != "v3" {
+		t.Errorf("got %q, want v3", got)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("received a value after cancel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestWatch_Missing(t *testing.T) {
+	m := newMockClient(map[string]string{})
+	p := New(WithClient(m))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := p.Watch(ctx, "app/db")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	v1 := "v1"
+	m.set("app/db", &v1)
+	if got := receive(t, ch); string(got) != "v1" {
+		t.Errorf("got %q, want v1", got)
+	}
+}
+
+func TestWatch_Error(t *testing.T) {
+	m := newMockClient(map[string]string{})
+	m.err = &secrets.ErrProvider{StatusCode: 403, Err: errors.New("Permission denied")}
+	p := New(WithClient(m))
+	_, err := p.Watch(context.Background(), "app/db")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.StatusCode != 403 {
+		t.Errorf("got %v, want a 403 ErrProvider", err)
+	}
+}
@@ -0,0 +1,218 @@
+package secrets
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Coordinator is a store shared by the processes of a deployment, used by
+// CoordinatedProvider to elect a single process to fetch each secret and to
+// share the fetched value with the others. Implementations are typically
+// backed by Redis, etcd, or a database.
+//
+// Implementations must be safe for concurrent use.
+type Coordinator interface {
+	// Acquire tries to take a lease on key that expires after ttl, reporting
+	// whether this process now holds it. It must not block waiting for a
+	// lease held by another process.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Load returns the value stored under key, or an error wrapping
+	// ErrNotFound if there is none or it has expired.
+	Load(ctx context.Context, key string) ([]byte, error)
+	// Store stores value under key until ttl elapses.
+	Store(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// CoordinatedOption configures a CoordinatedProvider.
+type CoordinatedOption func(*CoordinatedProvider)
+
+// WithSharedTTL configures how long a fetched value is shared before the next
+// Get fetches it again. Defaults to 30 seconds.
+func WithSharedTTL(d time.Duration) CoordinatedOption {
+	return func(c *CoordinatedProvider) {
+		c.ttl = d
+	}
+}
+
+// WithLeaseTimeout configures how long a process that does not hold the lease
+// waits for the holder to share a value before fetching it itself. It is also
+// the lifetime of the lease. Defaults to 5 seconds.
+func WithLeaseTimeout(d time.Duration) CoordinatedOption {
+	return func(c *CoordinatedProvider) {
+		c.leaseTimeout = d
+	}
+}
+
+// WithSharedEncryption encrypts shared values with AES-GCM under key (16, 24,
+// or 32 bytes), so that the coordination store never holds plaintext secrets.
+// Every process in the deployment must use the same key. It panics if key has
+// an invalid length.
+func WithSharedEncryption(key []byte) CoordinatedOption {
+	return func(c *CoordinatedProvider) {
+		aead, err := newGCM(key)
+		if err != nil {
+			panic("secrets: create shared encryption cipher: " + err.Error())
+		}
+		c.aead = aead
+	}
+}
+
+// CoordinatedProvider wraps a Provider so that, across all processes sharing
+// a Coordinator, only one fetches a given secret from the upstream provider
+// per shared TTL. The others read the value it shares through the
+// Coordinator. This bounds provider traffic for large fleets that resolve or
+// watch the same secrets, independent of the number of replicas.
+//
+// Coordination is an optimization only: if the Coordinator fails, or the
+// lease holder does not share a value within the lease timeout, the secret is
+// fetched directly. Secrets the upstream provider reports as missing are
+// shared too, so other processes return ErrNotFound without fetching.
+//
+// CoordinatedProvider implements Provider and VersionedProvider (the latter
+// requires the wrapped provider to implement it too). It is safe for
+// concurrent use.
+type CoordinatedProvider struct {
+	provider     Provider
+	coord        Coordinator
+	ttl          time.Duration
+	leaseTimeout time.Duration
+	aead         cipher.AEAD // non-nil if WithSharedEncryption is set
+}
+
+// Shared records are a one-byte kind followed by the (optionally encrypted)
+// value.
+const (
+	sharedNotFound byte = iota
+	sharedValue
+)
+
+// NewCoordinatedProvider wraps p so that fetches are coordinated through coord.
+func NewCoordinatedProvider(p Provider, coord Coordinator, opts ...CoordinatedOption) *CoordinatedProvider {
+	c := &CoordinatedProvider{
+		provider:     p,
+		coord:        coord,
+		ttl:          30 * time.Second,
+		leaseTimeout: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Get retrieves the secret for key, from the shared store if another process
+// has fetched it recently.
+func (c *CoordinatedProvider) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.getOrFetch(ctx, key, func(ctx context.Context) ([]byte, error) {
+		return c.provider.Get(ctx, key)
+	})
+}
+
+// GetVersion retrieves a versioned secret, from the shared store if another
+// process has fetched it recently. The wrapped provider must implement
+// VersionedProvider.
+func (c *CoordinatedProvider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	vp, ok := c.provider.(VersionedProvider)
+	if !ok {
+		return nil, &ErrVersioningNotSupported{Provider: "coordinated"}
+	}
+	return c.getOrFetch(ctx, key+"\x00"+version, func(ctx context.Context) ([]byte, error) {
+		return vp.GetVersion(ctx, key, version)
+	})
+}
+
+// Close closes the wrapped provider if it implements io.Closer.
+func (c *CoordinatedProvider) Close() error {
+	if cl, ok := c.provider.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+func (c *CoordinatedProvider) getOrFetch(ctx context.Context, sharedKey string, fetch func(context.Context) ([]byte, error)) ([]byte, error) {
+	if data, found, err := c.load(ctx, sharedKey); found {
+		return data, err
+	}
+
+	leader, err := c.coord.Acquire(ctx, "lock:"+sharedKey, c.leaseTimeout)
+	if err != nil {
+		return fetch(ctx)
+	}
+	if leader {
+		data, err := fetch(ctx)
+		switch {
+		case err == nil:
+			c.store(ctx, sharedKey, sharedValue, data)
+		case errors.Is(err, ErrNotFound):
+			c.store(ctx, sharedKey, sharedNotFound, nil)
+		}
+		return data, err
+	}
+
+	// Another process holds the lease; wait for it to share the value.
+	deadline := time.Now().Add(c.leaseTimeout)
+	ticker := time.NewTicker(max(c.leaseTimeout/20, 10*time.Millisecond))
+	defer ticker.Stop()
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+		if data, found, err := c.load(ctx, sharedKey); found {
+			return data, err
+		}
+	}
+	return fetch(ctx)
+}
+
+// load returns the shared result for sharedKey. found is false if there is no
+// usable shared result.
+func (c *CoordinatedProvider) load(ctx context.Context, sharedKey string) (data []byte, found bool, err error) {
+	rec, err := c.coord.Load(ctx, "value:"+sharedKey)
+	if err != nil || len(rec) == 0 {
+		return nil, false, nil
+	}
+	kind, data := rec[0], rec[1:]
+	switch kind {
+	case sharedNotFound:
+		return nil, true, fmt.Errorf("secrets: secret %q: %w", sharedKey, ErrNotFound)
+	case sharedValue:
+		if c.aead != nil {
+			n := c.aead.NonceSize()
+			if len(data) < n {
+				return nil, false, nil
+			}
+			data, err = c.aead.Open(nil, data[:n], data[n:], []byte(sharedKey))
+			if err != nil {
+				return nil, false, nil
+			}
+		}
+		return data, true, nil
+	}
+	return nil, false, nil
+}
+
+// store shares a result. Failures are ignored: other processes then fetch
+// the secret themselves.
+func (c *CoordinatedProvider) store(ctx context.Context, sharedKey string, kind byte, data []byte) {
+	rec := []byte{kind}
+	if kind == sharedValue {
+		if c.aead != nil {
+			nonce := make([]byte, c.aead.NonceSize())
+			if _, err := rand.Read(nonce); err != nil {
+				return
+			}
+			rec = append(rec, nonce...)
+			rec = c.aead.Seal(rec, nonce, data, []byte(sharedKey))
+		} else {
+			rec = append(rec, data...)
+		}
+	}
+	_ = c.coord.Store(ctx, "value:"+sharedKey, rec, c.ttl)
+}
@@ -0,0 +1,292 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoordinatedProvider_SharesAcrossInstances(t *testing.T) {
+	coord := newMemCoordinator()
+	upstream := &countingSyncMapProvider{}
+	upstream.Store("db", []byte("s3cret"))
+
+	for i := range 3 {
+		p := NewCoordinatedProvider(upstream, coord)
+		val, err := p.Get(context.Background(), "db")
+		if err != nil {
+			t.Fatalf("instance %d: Get: %v", i, err)
+		}
+		if string(val) != "s3cret" {
+			t.Errorf("instance %d: Get = %q, want %q", i, val, "s3cret")
+		}
+	}
+	if n := upstream.gets("db"); n != 1 {
+		t.Errorf("upstream calls = %d, want 1", n)
+	}
+}
+
+func TestCoordinatedProvider_FollowerWaitsForLeader(t *testing.T) {
+	coord := newMemCoordinator()
+	upstream := &blockingProvider{release: make(chan struct{}), val: []byte("s3cret")}
+
+	var wg sync.WaitGroup
+	results := make([][]byte, 4)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := NewCoordinatedProvider(upstream, coord, WithLeaseTimeout(5*time.Second))
+			val, err := p.Get(context.Background(), "db")
+			if err != nil {
+				t.Errorf("Get: %v", err)
+			}
+			results[i] = val
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(upstream.release)
+	wg.Wait()
+
+	if n := upstream.calls.Load(); n != 1 {
+		t.Errorf("upstream calls = %d, want 1", n)
+	}
+	for i, val := range results {
+		if string(val) != "s3cret" {
+			t.Errorf("result %d = %q, want %q", i, val, "s3cret")
+		}
+	}
+}
+
+func TestCoordinatedProvider_LeaseTimeoutFetchesDirectly(t *testing.T) {
+	coord := newMemCoordinator()
+	// Another process holds the lease but never shares a value.
+	if _, err := coord.Acquire(context.Background(), "lock:db", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	upstream := &countingSyncMapProvider{}
+	upstream.Store("db", []byte("s3cret"))
+
+	p := NewCoordinatedProvider(upstream, coord, WithLeaseTimeout(50*time.Millisecond))
+	val, err := p.Get(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(val) != "s3cret" {
+		t.Errorf("Get = %q, want %q", val, "s3cret")
+	}
+}
+
+func TestCoordinatedProvider_CoordinatorFailureFetchesDirectly(t *testing.T) {
+	upstream := &countingSyncMapProvider{}
+	upstream.Store("db", []byte("s3cret"))
+
+	p := NewCoordinatedProvider(upstream, failingCoordinator{})
+	val, err := p.Get(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(val) != "s3cret" {
+		t.Errorf("Get = %q, want %q", val, "s3cret")
+	}
+}
+
+func TestCoordinatedProvider_SharesNotFound(t *testing.T) {
+	coord := newMemCoordinator()
+	upstream := &countingSyncMapProvider{}
+
+	for range 2 {
+		p := NewCoordinatedProvider(upstream, coord)
+		if _, err := p.Get(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+	if n := upstream.gets("missing"); n != 1 {
+		t.Errorf("upstream calls = %d, want 1", n)
+	}
+}
+
+func TestCoordinatedProvider_DoesNotShareErrors(t *testing.T) {
+	coord := newMemCoordinator()
+	upstream := &flakyProvider{failures: 1}
+	upstream.Store("db", []byte("s3cret"))
+
+	p := NewCoordinatedProvider(upstream, coord, WithLeaseTimeout(10*time.Millisecond))
+	if _, err := p.Get(context.Background(), "db"); !errors.Is(err, errFlaky) {
+		t.Fatalf("expected errFlaky, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond) // let the lease expire
+	val, err := p.Get(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(val) != "s3cret" {
+		t.Errorf("Get = %q, want %q", val, "s3cret")
+	}
+}
+
+func TestCoordinatedProvider_SharedEncryption(t *testing.T) {
+	coord := newMemCoordinator()
+	upstream := &countingSyncMapProvider{}
+	upstream.Store("db", []byte("s3cret"))
+	key := bytes.Repeat([]byte{7}, 32)
+
+	p1 := NewCoordinatedProvider(upstream, coord, WithSharedEncryption(key))
+	if _, err := p1.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	stored, err := coord.Load(context.Background(), "value:db")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if bytes.Contains(stored, []byte("s3cret")) {
+		t.Error("shared value contains plaintext")
+	}
+
+	p2 := NewCoordinatedProvider(upstream, coord, WithSharedEncryption(key))
+	val, err := p2.Get(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(val) != "s3cret" {
+		t.Errorf("Get = %q, want %q", val, "s3cret")
+	}
+	if n := upstream.gets("db"); n != 1 {
+		t.Errorf("upstream calls = %d, want 1", n)
+	}
+
+	// A process with a different key cannot read the shared value and
+	// fetches the secret itself.
+	p3 := NewCoordinatedProvider(upstream, coord,
+		WithSharedEncryption(bytes.Repeat([]byte{8}, 32)), WithLeaseTimeout(20*time.Millisecond))
+	if val, err := p3.Get(context.Background(), "db"); err != nil || string(val) != "s3cret" {
+		t.Errorf("Get = %q, %v, want %q", val, err, "s3cret")
+	}
+}
+
+func TestCoordinatedProvider_GetVersion(t *testing.T) {
+	coord := newMemCoordinator()
+	upstream := &versionedMapProvider{data: map[string][]byte{"db@1": []byte("old")}}
+
+	for range 2 {
+		p := NewCoordinatedProvider(upstream, coord)
+		val, err := p.GetVersion(context.Background(), "db", "1")
+		if err != nil {
+			t.Fatalf("GetVersion: %v", err)
+		}
+		if string(val) != "old" {
+			t.Errorf("GetVersion = %q, want %q", val, "old")
+		}
+	}
+	if n := upstream.calls.Load(); n != 1 {
+		t.Errorf("upstream calls = %d, want 1", n)
+	}
+
+	p := NewCoordinatedProvider(&syncMapProvider{}, coord)
+	var verr *ErrVersioningNotSupported
+	if _, err := p.GetVersion(context.Background(), "db", "1"); !errors.As(err, &verr) {
+		t.Errorf("expected ErrVersioningNotSupported, got %v", err)
+	}
+}
+
+// --- helpers ---
+
+// memCoordinator is an in-memory Coordinator.
+type memCoordinator struct {
+	mu      sync.Mutex
+	entries map[string]memCoordinatorEntry
+}
+
+type memCoordinatorEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func newMemCoordinator() *memCoordinator {
+	return &memCoordinator{entries: make(map[string]memCoordinatorEntry)}
+}
+
+func (c *memCoordinator) Acquire(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok && time.Now().Before(e.expires) {
+		return false, nil
+	}
+	c.entries[key] = memCoordinatorEntry{expires: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (c *memCoordinator) Load(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !time.Now().Before(e.expires) {
+		return nil, fmt.Errorf("mem: %q: %w", key, ErrNotFound)
+	}
+	return e.value, nil
+}
+
+func (c *memCoordinator) Store(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = memCoordinatorEntry{value: value, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+var errCoordinator = errors.New("coordinator unavailable")
+
+// failingCoordinator is a Coordinator whose operations always fail.
+type failingCoordinator struct{}
+
+func (failingCoordinator) Acquire(context.Context, string, time.Duration) (bool, error) {
+	return false, errCoordinator
+}
+
+func (failingCoordinator) Load(context.Context, string) ([]byte, error) {
+	return nil, errCoordinator
+}
+
+func (failingCoordinator) Store(context.Context, string, []byte, time.Duration) error {
+	return errCoordinator
+}
+
+// blockingProvider returns val from Get once release is closed.
+type blockingProvider struct {
+	release chan struct{}
+	val     []byte
+	calls   atomic.Int64
+}
+
+func (p *blockingProvider) Get(ctx context.Context, _ string) ([]byte, error) {
+	p.calls.Add(1)
+	select {
+	case <-p.release:
+		return p.val, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// versionedMapProvider serves versioned values keyed "key@version".
+type versionedMapProvider struct {
+	data  map[string][]byte
+	calls atomic.Int64
+}
+
+func (p *versionedMapProvider) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.GetVersion(ctx, key, "latest")
+}
+
+func (p *versionedMapProvider) GetVersion(_ context.Context, key, version string) ([]byte, error) {
+	p.calls.Add(1)
+	v, ok := p.data[key+"@"+version]
+	if !ok {
+		return nil, fmt.Errorf("versioned: %q: %w", key, ErrNotFound)
+	}
+	return v, nil
+}
@@ -0,0 +1,87 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// WithDecryptionKey registers an AES key (16, 24, or 32 bytes) under keyID
+// for fields tagged with `decrypt=aesgcm`. Register several keys to decrypt
+// envelopes written before a key rotation.
+func WithDecryptionKey(keyID string, key []byte) Option {
+	return func(c *resolverConfig) {
+		if c.decryptionKeys == nil {
+			c.decryptionKeys = make(map[string][]byte)
+		}
+		c.decryptionKeys[keyID] = key
+	}
+}
+
+// EncryptAESGCM encrypts plaintext with key into an envelope that fields
+// tagged with `decrypt=aesgcm` decrypt at resolve time, given the same key
+// registered via WithDecryptionKey(keyID, key). Use it to store
+// application-encrypted values in low-trust backends.
+//
+// The envelope is "<keyID>:<base64(nonce || ciphertext)>", and keyID is
+// authenticated along with the ciphertext. keyID must not contain ':'.
+func EncryptAESGCM(keyID string, key, plaintext []byte) ([]byte, error) {
+	if keyID == "" || strings.Contains(keyID, ":") {
+		return nil, fmt.Errorf("secrets: invalid key ID %q", keyID)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: key %q: %w", keyID, err)
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("secrets: generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(keyID))
+	return fmt.Appendf(nil, "%s:%s", keyID, base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// decrypt opens an envelope produced by EncryptAESGCM using the registered
+// decryption keys. Surrounding whitespace, such as a trailing newline in a
+// file, is ignored.
+func (r *Resolver) decrypt(alg string, envelope []byte) ([]byte, error) {
+	if alg != "aesgcm" {
+		return nil, fmt.Errorf("decrypt: unsupported algorithm %q", alg)
+	}
+	keyID, encoded, ok := bytes.Cut(bytes.TrimSpace(envelope), []byte(":"))
+	if !ok {
+		return nil, errors.New("decrypt: value is not an encrypted envelope")
+	}
+	key, ok := r.cfg.decryptionKeys[string(keyID)]
+	if !ok {
+		return nil, fmt.Errorf("decrypt: unknown key ID %q", keyID)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: key %q: %w", keyID, err)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, errors.New("decrypt: malformed envelope")
+	}
+	n := aead.NonceSize()
+	plain, err := aead.Open(nil, sealed[:n], sealed[n:], keyID)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: key %q: authentication failed", keyID)
+	}
+	return plain, nil
+}
+
+// newGCM returns an AES-GCM AEAD for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,130 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+var (
+	testKeyV1 = bytes.Repeat([]byte{1}, 32)
+	testKeyV2 = bytes.Repeat([]byte{2}, 16)
+)
+
+func mustEncrypt(t *testing.T, keyID string, key []byte, plaintext string) []byte {
+	t.Helper()
+	env, err := EncryptAESGCM(keyID, key, []byte(plaintext))
+	if err != nil {
+		t.Fatalf("EncryptAESGCM: %v", err)
+	}
+	return env
+}
+
+func TestResolve_Decrypt(t *testing.T) {
+	p := &mockProvider{data: map[string][]byte{
+		"plain": mustEncrypt(t, "v1", testKeyV1, "s3cret"),
+		"json":  []byte(`{"pass":"` + string(mustEncrypt(t, "v2", testKeyV2, "hunter2")) + `"}`),
+		"file":  append(mustEncrypt(t, "v1", testKeyV1, "with-newline"), '\n'),
+	}}
+	r := NewResolver(WithDefault(p), WithDecryptionKey("v1", testKeyV1), WithDecryptionKey("v2", testKeyV2))
+
+	type Config struct {
+		Plain string `secret:"plain,decrypt=aesgcm"`
+		JSON  string `secret:"json#pass,decrypt=aesgcm"`
+		File  []byte `secret:"file,decrypt=aesgcm"`
+	}
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.Plain != "s3cret" {
+		t.Errorf("Plain = %q, want %q", cfg.Plain, "s3cret")
+	}
+	if cfg.JSON != "hunter2" {
+		t.Errorf("JSON = %q, want %q", cfg.JSON, "hunter2")
+	}
+	if string(cfg.File) != "with-newline" {
+		t.Errorf("File = %q, want %q", cfg.File, "with-newline")
+	}
+}
+
+func TestResolve_DecryptErrors(t *testing.T) {
+	tampered := mustEncrypt(t, "v1", testKeyV1, "s3cret")
+	if i := len(tampered) / 2; tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+
+	tests := []struct {
+		name  string
+		value []byte
+		want  string
+	}{
+		{"not an envelope", []byte("s3cret"), "not an encrypted envelope"},
+		{"unknown key", mustEncrypt(t, "v9", testKeyV1, "s3cret"), `unknown key ID "v9"`},
+		{"wrong key", mustEncrypt(t, "v1", testKeyV2, "s3cret"), "authentication failed"},
+		{"tampered", tampered, "authentication failed"},
+		{"malformed", []byte("v1:!!!"), "malformed envelope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &mockProvider{data: map[string][]byte{"key": tt.value}}
+			r := NewResolver(WithDefault(p), WithDecryptionKey("v1", testKeyV1))
+
+			type Config struct {
+				Val string `secret:"key,decrypt=aesgcm"`
+			}
+			err := r.Resolve(context.Background(), &Config{})
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err, tt.want)
+			}
+			if strings.Contains(err.Error(), "s3cret") {
+				t.Errorf("error leaks plaintext: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_DecryptWithoutKey(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{}))
+
+	type Config struct {
+		Val string `secret:"key,decrypt=aesgcm"`
+	}
+	if err := r.Validate(&Config{}); err == nil || !strings.Contains(err.Error(), "WithDecryptionKey") {
+		t.Errorf("expected missing key error, got %v", err)
+	}
+}
+
+func TestResolveRef_Decrypt(t *testing.T) {
+	p := &mockProvider{data: map[string][]byte{
+		"key": mustEncrypt(t, "v1", testKeyV1, "s3cret"),
+	}}
+	r := NewResolver(WithDefault(p), WithDecryptionKey("v1", testKeyV1))
+
+	ref, err := ParseRef("key,decrypt=aesgcm")
+	if err != nil {
+		t.Fatalf("ParseRef: %v", err)
+	}
+	val, err := r.ResolveRef(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("ResolveRef: %v", err)
+	}
+	if string(val) != "s3cret" {
+		t.Errorf("ResolveRef = %q, want %q", val, "s3cret")
+	}
+}
+
+func TestEncryptAESGCM_InvalidInput(t *testing.T) {
+	if _, err := EncryptAESGCM("a:b", testKeyV1, nil); err == nil {
+		t.Error("expected error for key ID containing ':'")
+	}
+	if _, err := EncryptAESGCM("v1", []byte("short"), nil); err == nil {
+		t.Error("expected error for invalid key size")
+	}
+}
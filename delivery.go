@@ -0,0 +1,153 @@
+package secrets
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// send delivers event on ch, or queues it on q under DeliveryUnbounded,
+// according to the delivery policy. If the policy drops an event (event
+// itself, or under DeliveryDropOldest the oldest buffered event), the event
+// is counted by Dropped and returned with ok set. Under DeliveryBlock an
+// event that is not delivered because the Watcher is stopping is not counted.
+func send[E any](ctx context.Context, w *Watcher, ch chan E, q *eventQueue[E], event E) (lost E, ok bool) {
+	switch w.delivery {
+	case DeliveryUnbounded:
+		q.push(event)
+		return lost, false
+	case DeliveryBlock:
+		var timeout <-chan time.Time
+		if w.blockTimeout > 0 {
+			t := w.clock.NewTimer(w.blockTimeout)
+			defer t.Stop()
+			timeout = t.C()
+		}
+		select {
+		case ch <- event:
+			return lost, false
+		case <-timeout:
+			w.dropped.Add(1)
+			return event, true
+		case <-w.stop:
+			return lost, false
+		case <-ctx.Done():
+			return lost, false
+		}
+	case DeliveryDropOldest:
+		if cap(ch) == 0 {
+			break
+		}
+		select {
+		case ch <- event:
+			return lost, false
+		default:
+		}
+		select {
+		case lost = <-ch:
+			w.dropped.Add(1)
+			ok = true
+		default: // the consumer made room meanwhile
+		}
+		// Only the Watcher sends on ch, so there is room now.
+		ch <- event
+		return lost, ok
+	}
+	select {
+	case ch <- event:
+		return lost, false
+	default:
+		w.dropped.Add(1)
+		return event, true
+	}
+}
+
+// eventQueue holds the events of a DeliveryUnbounded Watcher until its
+// consumer receives them.
+type eventQueue[E any] struct {
+	mu    sync.Mutex
+	items []E
+	ready chan struct{} // signalled by push
+}
+
+func newEventQueue[E any]() *eventQueue[E] {
+	return &eventQueue[E]{ready: make(chan struct{}, 1)}
+}
+
+// push appends event to the queue.
+func (q *eventQueue[E]) push(event E) {
+	q.mu.Lock()
+	q.items = append(q.items, event)
+	q.mu.Unlock()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// head returns the oldest queued event, if any.
+func (q *eventQueue[E]) head() (E, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		var zero E
+		return zero, false
+	}
+	return q.items[0], true
+}
+
+// pop removes the oldest queued event.
+func (q *eventQueue[E]) pop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var zero E
+	q.items[0] = zero
+	q.items = q.items[1:]
+}
+
+// run sends queued events on out in order, and closes out when the Watcher
+// has stopped. When the Watcher is drained, the queued events are delivered
+// first; when it is stopped or its context is cancelled, they are discarded.
+func (q *eventQueue[E]) run(w *Watcher, out chan E) {
+	defer w.channelClosed()
+	defer close(out)
+	done := w.done
+	for {
+		event, ok := q.head()
+		if !ok {
+			if done == nil {
+				return // drained
+			}
+			select {
+			case <-q.ready:
+			case <-done:
+				if !w.isDraining() {
+					return
+				}
+				done = nil
+			}
+			continue
+		}
+		select {
+		case out <- event:
+			q.pop()
+		case <-w.stop:
+			return
+		case <-done:
+			if !w.isDraining() {
+				return
+			}
+			done = nil
+		}
+	}
+}
+
+// isDraining reports whether Drain has been called.
+func (w *Watcher) isDraining() bool {
+	select {
+	case <-w.draining:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,185 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// watchRefreshed watches a single field and returns a function that stores a
+// new value and refreshes the Watcher.
+func watchRefreshed(t *testing.T, ctx context.Context, opts ...WatchOption) (*Watcher, func(string)) {
+	t.Helper()
+	store := &syncMapProvider{}
+	store.Store("key", []byte("v0"))
+	r := NewResolver(WithDefault(store))
+
+	var cfg struct {
+		Val string `secret:"key"`
+	}
+	w, err := r.Watch(ctx, &cfg, append([]WatchOption{WatchInterval(time.Hour)}, opts...)...)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	t.Cleanup(w.Stop)
+	return w, func(val string) {
+		t.Helper()
+		store.Store("key", []byte(val))
+		if err := w.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+	}
+}
+
+// receiveValues receives n events from ch and returns their new values.
+func receiveValues(t *testing.T, ctx context.Context, ch <-chan ChangeEvent, n int) []string {
+	t.Helper()
+	var got []string
+	for range n {
+		select {
+		case ev := <-ch:
+			got = append(got, string(ev.NewValue))
+		case <-ctx.Done():
+			t.Fatalf("timed out after receiving %q", got)
+		}
+	}
+	return got
+}
+
+func TestDelivery_DropCounts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var overflowed []string
+	w, set := watchRefreshed(t, ctx,
+		WatchBufferSize(1),
+		WatchOnOverflow(func(ev ChangeEvent) { overflowed = append(overflowed, string(ev.NewValue)) }),
+	)
+
+	set("v1")
+	set("v2")
+	if got := w.Dropped(); got != 1 {
+		t.Errorf("Dropped = %d, want 1", got)
+	}
+	if fmt.Sprint(overflowed) != "[v2]" {
+		t.Errorf("overflowed = %q, want [v2]", overflowed)
+	}
+	if got := receiveValues(t, ctx, w.Changes(), 1); got[0] != "v1" {
+		t.Errorf("received %q, want [v1]", got)
+	}
+}
+
+func TestDelivery_DropOldest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var overflowed []string
+	w, set := watchRefreshed(t, ctx,
+		WatchBufferSize(2),
+		WatchDelivery(DeliveryDropOldest),
+		WatchOnOverflow(func(ev ChangeEvent) { overflowed = append(overflowed, string(ev.NewValue)) }),
+	)
+
+	for _, v := range []string{"v1", "v2", "v3", "v4"} {
+		set(v)
+	}
+	if got := w.Dropped(); got != 2 {
+		t.Errorf("Dropped = %d, want 2", got)
+	}
+	if fmt.Sprint(overflowed) != "[v1 v2]" {
+		t.Errorf("overflowed = %q, want [v1 v2]", overflowed)
+	}
+	if got := receiveValues(t, ctx, w.Changes(), 2); fmt.Sprint(got) != "[v3 v4]" {
+		t.Errorf("received %q, want [v3 v4]", got)
+	}
+}
+
+func TestDelivery_BlockTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, set := watchRefreshed(t, ctx,
+		WatchBufferSize(0),
+		WatchDelivery(DeliveryBlock),
+		WatchBlockTimeout(20*time.Millisecond),
+	)
+
+	start := time.Now()
+	set("v1") // nobody is receiving
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("Refresh returned after %v, want it to wait for the timeout", d)
+	}
+	if got := w.Dropped(); got != 1 {
+		t.Errorf("Dropped = %d, want 1", got)
+	}
+}
+
+func TestDelivery_Unbounded(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, set := watchRefreshed(t, ctx,
+		WatchBufferSize(1),
+		WatchDelivery(DeliveryUnbounded),
+	)
+
+	want := make([]string, 100)
+	for i := range want {
+		want[i] = fmt.Sprintf("v%d", i+1)
+		set(want[i])
+	}
+	if got := receiveValues(t, ctx, w.Changes(), len(want)); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("received %q, want %q", got, want)
+	}
+	if got := w.Dropped(); got != 0 {
+		t.Errorf("Dropped = %d, want 0", got)
+	}
+}
+
+func TestDelivery_UnboundedDrain(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, set := watchRefreshed(t, ctx,
+		WatchBufferSize(0),
+		WatchDelivery(DeliveryUnbounded),
+	)
+	rotations := w.Rotations()
+
+	for _, v := range []string{"v1", "v2", "v3"} {
+		set(v)
+	}
+	drained := make(chan error, 1)
+	go func() { drained <- w.Drain(ctx) }()
+
+	var got []string
+	for ev := range w.Changes() {
+		got = append(got, string(ev.NewValue))
+	}
+	if fmt.Sprint(got) != "[v1 v2 v3]" {
+		t.Errorf("received %q, want [v1 v2 v3]", got)
+	}
+	if err := <-drained; err != nil {
+		t.Errorf("Drain: %v", err)
+	}
+	select {
+	case _, ok := <-rotations:
+		if ok {
+			t.Error("unexpected rotation event")
+		}
+	case <-ctx.Done():
+		t.Error("Rotations channel not closed")
+	}
+}
+
+func TestDelivery_UnboundedStopDiscards(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, set := watchRefreshed(t, ctx,
+		WatchBufferSize(0),
+		WatchDelivery(DeliveryUnbounded),
+	)
+
+	set("v1")
+	set("v2")
+	w.Stop()
+	for range w.Changes() {
+		// The channel is closed once the queue is discarded.
+	}
+}
@@ -0,0 +1,316 @@
+// Package diskcache provides a provider wrapper that persists secret values
+// to an encrypted local file.
+//
+// It is intended for short-lived CLIs and cron jobs: values fetched by one
+// run are reused by later runs until they expire, avoiding provider rate
+// limits, and expired values can still be served while the backend is
+// unavailable.
+package diskcache
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// ProviderOption configures the diskcache Provider.
+type ProviderOption func(*Provider)
+
+// WithTTL configures how long a stored value is served without contacting
+// the underlying provider. Defaults to 5 minutes.
+func WithTTL(d time.Duration) ProviderOption {
+	return func(p *Provider) {
+		p.ttl = d
+	}
+}
+
+// WithMaxStale configures how long after expiry a stored value may still be
+// served when the underlying provider fails with an error other than
+// secrets.ErrNotFound. Defaults to 24 hours. Zero disables serving stale values.
+func WithMaxStale(d time.Duration) ProviderOption {
+	return func(p *Provider) {
+		p.maxStale = d
+	}
+}
+
+// WithKeyFile configures the path of the file holding the cache encryption key.
+// The key is generated on first use. Defaults to the cache path with a ".key"
+// suffix.
+func WithKeyFile(path string) ProviderOption {
+	return func(p *Provider) {
+		p.keyFile = path
+	}
+}
+
+// WithKey configures an explicit 32-byte AES-256 encryption key instead of a
+// key file.
+func WithKey(key []byte) ProviderOption {
+	return func(p *Provider) {
+		p.key = key
+	}
+}
+
+// Provider wraps a secrets.Provider and persists successful results to an
+// encrypted file. It implements secrets.Provider and secrets.VersionedProvider
+// (the latter requires the wrapped provider to implement it too).
+//
+// Provider is safe for concurrent use within a process. Concurrent processes
+// sharing a cache file do not corrupt it, but the last writer wins.
+type Provider struct {
+	provider secrets.Provider
+	path     string
+	keyFile  string
+	key      []byte
+	sealer   Sealer
+	ttl      time.Duration
+	maxStale time.Duration
+
+	aead    cipher.AEAD
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// entry is the persisted form of a cached value.
+type entry struct {
+	Value   []byte    `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// fileAAD binds the cache file ciphertext to its format version.
+var fileAAD = []byte("go-secrets diskcache v1")
+
+// New wraps p with a cache persisted at path. The cache file and its
+// directory are created if needed. An existing file that cannot be decrypted
+// (for example after the key was rotated) is ignored and overwritten.
+func New(p secrets.Provider, path string, opts ...ProviderOption) (*Provider, error) {
+	c := &Provider{
+		provider: p,
+		path:     path,
+		keyFile:  path + ".key",
+		ttl:      5 * time.Minute,
+		maxStale: 24 * time.Hour,
+		entries:  make(map[string]entry),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("diskcache: create cache directory: %w", err)
+	}
+	key := c.key
+	if key == nil {
+		var err error
+		key, err = loadOrCreateKey(c.keyFile, c.sealer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("diskcache: invalid key: %w", err)
+	}
+	c.aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("diskcache: invalid key: %w", err)
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get retrieves the secret for key, returning the stored value if fresh.
+func (c *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.getOrFetch(ctx, key, func(ctx context.Context) ([]byte, error) {
+		return c.provider.Get(ctx, key)
+	})
+}
+
+// GetVersion retrieves a versioned secret, returning the stored value if fresh.
+// The wrapped provider must implement secrets.VersionedProvider.
+func (c *Provider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	vp, ok := c.provider.(secrets.VersionedProvider)
+	if !ok {
+		return nil, &secrets.ErrVersioningNotSupported{Provider: "diskcache"}
+	}
+	return c.getOrFetch(ctx, key+"\x00"+version, func(ctx context.Context) ([]byte, error) {
+		return vp.GetVersion(ctx, key, version)
+	})
+}
+
+// Clear removes all entries from the cache and its file.
+func (c *Provider) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]entry)
+	return c.save()
+}
+
+// Close closes the wrapped provider if it implements io.Closer.
+func (c *Provider) Close() error {
+	if cl, ok := c.provider.(io.Closer); ok {
+		return cl.Close()
+	}
+	return nil
+}
+
+func (c *Provider) getOrFetch(ctx context.Context, cacheKey string, fetch func(context.Context) ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	stored, found := c.entries[cacheKey]
+	c.mu.Unlock()
+
+	now := time.Now()
+	if found && now.Before(stored.Expires) {
+		return stored.Value, nil
+	}
+
+	data, err := fetch(ctx)
+	if err != nil {
+		if errors.Is(err, secrets.ErrNotFound) {
+			c.update(cacheKey, nil)
+			return nil, err
+		}
+		if found && c.maxStale > 0 && now.Before(stored.Expires.Add(c.maxStale)) {
+			return stored.Value, nil
+		}
+		return nil, err
+	}
+	c.update(cacheKey, &entry{Value: data, Expires: now.Add(c.ttl)})
+	return data, nil
+}
+
+// update stores or (if e is nil) deletes an entry and persists the cache.
+// Persisting is best effort: a value that cannot be written to disk is still
+// returned to the caller.
+func (c *Provider) update(cacheKey string, e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e == nil {
+		if _, ok := c.entries[cacheKey]; !ok {
+			return
+		}
+		delete(c.entries, cacheKey)
+	} else {
+		c.entries[cacheKey] = *e
+	}
+	_ = c.save()
+}
+
+// load reads and decrypts the cache file, if it exists.
+func (c *Provider) load() error {
+	raw, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("diskcache: read %q: %w", c.path, err)
+	}
+	n := c.aead.NonceSize()
+	if len(raw) < n {
+		return nil
+	}
+	plain, err := c.aead.Open(nil, raw[:n], raw[n:], fileAAD)
+	if err != nil {
+		return nil
+	}
+	defer clear(plain)
+	var entries map[string]entry
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		return nil
+	}
+	c.entries = entries
+	return nil
+}
+
+// save encrypts the entries and atomically replaces the cache file.
+// Callers must hold c.mu.
+func (c *Provider) save() error {
+	plain, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("diskcache: encode: %w", err)
+	}
+	defer clear(plain)
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plain)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("diskcache: generate nonce: %w", err)
+	}
+	return writeFileAtomic(c.path, c.aead.Seal(nonce, nonce, plain, fileAAD))
+}
+
+// loadOrCreateKey reads a 32-byte key from path, generating it if the file
+// does not exist. With a sealer, the file holds the sealed key, and a key that
+// can no longer be unsealed is replaced.
+func loadOrCreateKey(path string, sealer Sealer) ([]byte, error) {
+	stored, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		key := stored
+		if sealer != nil {
+			key, err = sealer.Unseal(stored)
+		}
+		if err == nil {
+			if len(key) != 32 {
+				return nil, fmt.Errorf("diskcache: key file %q: expected 32 bytes, got %d", path, len(key))
+			}
+			return key, nil
+		}
+		// The sealing key is gone; start over with a new key.
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("diskcache: read key file %q: %w", path, err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("diskcache: generate key: %w", err)
+	}
+	data := key
+	if sealer != nil {
+		if data, err = sealer.Seal(key); err != nil {
+			return nil, fmt.Errorf("diskcache: seal key: %w", err)
+		}
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// writeFileAtomic writes data to a temporary file readable only by the
+// current user and renames it over path.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("diskcache: write %q: %w", path, err)
+	}
+	tmp := f.Name()
+	if err := f.Chmod(0o600); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("diskcache: write %q: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("diskcache: write %q: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("diskcache: write %q: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("diskcache: write %q: %w", path, err)
+	}
+	return nil
+}
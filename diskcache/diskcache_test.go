@@ -0,0 +1,320 @@
+package diskcache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+var errUnavailable = errors.New("backend unavailable")
+
+// mockProvider is a map-based provider that can be switched into a failing state.
+type mockProvider struct {
+	mu       sync.Mutex
+	data     map[string][]byte
+	versions map[string][]byte // key is "key\x00version"
+	fail     bool
+	calls    int
+}
+
+func (m *mockProvider) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.fail {
+		return nil, errUnavailable
+	}
+	v, ok := m.data[key]
+	if !ok {
+		return nil, fmt.Errorf("mock: %q: %w", key, secrets.ErrNotFound)
+	}
+	return v, nil
+}
+
+func (m *mockProvider) GetVersion(_ context.Context, key, version string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	v, ok := m.versions[key+"\x00"+version]
+	if !ok {
+		return nil, fmt.Errorf("mock: %q: %w", key, secrets.ErrNotFound)
+	}
+	return v, nil
+}
+
+func TestGet_PersistsAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	mock := &mockProvider{data: map[string][]byte{"db": []byte("s3cret")}}
+
+	c1, err := New(mock, path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c1.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	c2, err := New(mock, path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	val, err := c2.Get(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(val) != "s3cret" {
+		t.Errorf("Get = %q, want %q", val, "s3cret")
+	}
+	if mock.calls != 1 {
+		t.Errorf("provider calls = %d, want 1", mock.calls)
+	}
+}
+
+func TestGet_FileIsEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	mock := &mockProvider{data: map[string][]byte{"db": []byte("s3cret")}}
+
+	c, err := New(mock, path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if bytes.Contains(raw, []byte("s3cret")) || bytes.Contains(raw, []byte("db")) {
+		t.Error("cache file contains plaintext")
+	}
+	for _, p := range []string{path, path + ".key"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("%s permissions = %o, want 600", filepath.Base(p), perm)
+		}
+	}
+}
+
+func TestGet_ServesStaleOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	mock := &mockProvider{data: map[string][]byte{"db": []byte("s3cret")}}
+
+	c, err := New(mock, path, WithTTL(time.Nanosecond))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	mock.fail = true
+	val, err := c.Get(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("expected stale value, got error: %v", err)
+	}
+	if string(val) != "s3cret" {
+		t.Errorf("Get = %q, want %q", val, "s3cret")
+	}
+	if mock.calls != 2 {
+		t.Errorf("provider calls = %d, want 2", mock.calls)
+	}
+}
+
+func TestGet_NoStaleBeyondMaxStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	mock := &mockProvider{data: map[string][]byte{"db": []byte("s3cret")}}
+
+	c, err := New(mock, path, WithTTL(time.Nanosecond), WithMaxStale(0))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	mock.fail = true
+	if _, err := c.Get(context.Background(), "db"); !errors.Is(err, errUnavailable) {
+		t.Errorf("expected errUnavailable, got %v", err)
+	}
+}
+
+func TestGet_NotFoundEvicts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	mock := &mockProvider{data: map[string][]byte{"db": []byte("s3cret")}}
+
+	c, err := New(mock, path, WithTTL(time.Nanosecond))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	delete(mock.data, "db")
+	if _, err := c.Get(context.Background(), "db"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	mock.fail = true
+	if _, err := c.Get(context.Background(), "db"); !errors.Is(err, errUnavailable) {
+		t.Errorf("expected deleted secret not to be served stale, got %v", err)
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	mock := &mockProvider{versions: map[string][]byte{"db\x00previous": []byte("old")}}
+
+	c, err := New(mock, path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for range 2 {
+		val, err := c.GetVersion(context.Background(), "db", "previous")
+		if err != nil {
+			t.Fatalf("GetVersion: %v", err)
+		}
+		if string(val) != "old" {
+			t.Errorf("GetVersion = %q, want %q", val, "old")
+		}
+	}
+	if mock.calls != 1 {
+		t.Errorf("provider calls = %d, want 1", mock.calls)
+	}
+}
+
+func TestNew_WrongKeyIgnoresFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	mock := &mockProvider{data: map[string][]byte{"db": []byte("s3cret")}}
+
+	c1, err := New(mock, path, WithKey(bytes.Repeat([]byte{1}, 32)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c1.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	c2, err := New(mock, path, WithKey(bytes.Repeat([]byte{2}, 32)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c2.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if mock.calls != 2 {
+		t.Errorf("provider calls = %d, want 2", mock.calls)
+	}
+}
+
+func TestNew_InvalidKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	if _, err := New(&mockProvider{}, path, WithKey([]byte("short"))); err == nil {
+		t.Fatal("expected error for invalid key, got nil")
+	}
+}
+
+// xorSealer is a reversible Sealer for testing that records its use.
+type xorSealer struct {
+	mask   byte
+	sealed int
+}
+
+func (s *xorSealer) Seal(plaintext []byte) ([]byte, error) {
+	s.sealed++
+	out := make([]byte, len(plaintext))
+	for i, b := range plaintext {
+		out[i] = b ^ s.mask
+	}
+	return out, nil
+}
+
+func (s *xorSealer) Unseal(sealed []byte) ([]byte, error) {
+	if len(sealed) == 0 || sealed[0]^s.mask == 0 {
+		return nil, errors.New("xor: cannot unseal")
+	}
+	out := make([]byte, len(sealed))
+	for i, b := range sealed {
+		out[i] = b ^ s.mask
+	}
+	return out, nil
+}
+
+func TestWithSealer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	mock := &mockProvider{data: map[string][]byte{"db": []byte("s3cret")}}
+	sealer := &xorSealer{mask: 0x5a}
+
+	c1, err := New(mock, path, WithSealer(sealer))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c1.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if sealer.sealed != 1 {
+		t.Errorf("Seal calls = %d, want 1", sealer.sealed)
+	}
+
+	c2, err := New(mock, path, WithSealer(sealer))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c2.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if mock.calls != 1 {
+		t.Errorf("provider calls = %d, want 1 (second instance should unseal the key)", mock.calls)
+	}
+	if sealer.sealed != 1 {
+		t.Errorf("Seal calls = %d, want 1", sealer.sealed)
+	}
+}
+
+func TestWithSealer_UnsealFailureResetsCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	mock := &mockProvider{data: map[string][]byte{"db": []byte("s3cret")}}
+
+	c1, err := New(mock, path, WithSealer(&xorSealer{mask: 0x5a}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c1.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	// A different sealer cannot unseal the key, as after a reboot.
+	raw, err := os.ReadFile(path + ".key")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	failing := &xorSealer{mask: raw[0]}
+	c2, err := New(mock, path, WithSealer(failing))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := c2.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if mock.calls != 2 {
+		t.Errorf("provider calls = %d, want 2", mock.calls)
+	}
+	if failing.sealed != 1 {
+		t.Errorf("Seal calls = %d, want 1 (new key)", failing.sealed)
+	}
+}
@@ -0,0 +1,32 @@
+package diskcache
+
+// Sealer protects the cache encryption key at rest. With a Sealer, the key
+// file holds only the sealed key, so the cache and key files are useless when
+// copied to another machine or user account.
+type Sealer interface {
+	// Seal encrypts plaintext.
+	Seal(plaintext []byte) ([]byte, error)
+	// Unseal decrypts data produced by Seal.
+	Unseal(sealed []byte) ([]byte, error)
+}
+
+// WithSealer stores the cache encryption key sealed by s instead of in plain
+// form. If the key file cannot be unsealed (for example because the sealing
+// key did not survive a reboot), a new key is generated and the existing
+// cache is discarded. WithSealer has no effect when WithKey is used.
+func WithSealer(s Sealer) ProviderOption {
+	return func(p *Provider) {
+		p.sealer = s
+	}
+}
+
+// PlatformSealer returns a Sealer that binds the cache key to this machine:
+//
+//   - Windows: DPAPI, scoped to the current user account.
+//   - Linux: a wrapping key held in the kernel user keyring (keyctl). The
+//     keyring does not survive reboots, so the cache starts empty after one.
+//
+// On other platforms it returns an error wrapping errors.ErrUnsupported.
+func PlatformSealer() (Sealer, error) {
+	return platformSealer()
+}
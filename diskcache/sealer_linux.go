@@ -0,0 +1,96 @@
+//go:build linux
+
+package diskcache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// keyringDescription names the wrapping key in the user keyring.
+const keyringDescription = "go-secrets:diskcache"
+
+// keyringPerm grants the possessor full access and the owning user view,
+// read, and search, so later processes of the same user can find the key.
+const keyringPerm = 0x3f000000 | 0x00010000 | 0x00020000 | 0x00080000
+
+// keyringSealer seals with AES-256-GCM under a wrapping key kept in the
+// kernel user keyring, so the key never touches the filesystem.
+type keyringSealer struct {
+	aead cipher.AEAD
+}
+
+func platformSealer() (Sealer, error) {
+	key, err := keyringKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("diskcache: keyring key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("diskcache: keyring key: %w", err)
+	}
+	return &keyringSealer{aead: aead}, nil
+}
+
+// keyringKey returns the wrapping key from the user keyring, creating it if
+// needed. If two processes create it concurrently, the later one replaces
+// the key and the earlier one's cache is discarded on its next start.
+func keyringKey() ([]byte, error) {
+	id, err := unix.KeyctlSearch(unix.KEY_SPEC_USER_KEYRING, "user", keyringDescription, 0)
+	if err == nil {
+		key := make([]byte, 32)
+		n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, key, 0)
+		if err != nil {
+			return nil, fmt.Errorf("diskcache: read keyring key: %w", err)
+		}
+		if n != len(key) {
+			return nil, fmt.Errorf("diskcache: keyring key: expected 32 bytes, got %d", n)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, unix.ENOKEY) {
+		return nil, fmt.Errorf("diskcache: search keyring: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("diskcache: generate keyring key: %w", err)
+	}
+	id, err = unix.AddKey("user", keyringDescription, key, unix.KEY_SPEC_USER_KEYRING)
+	if err != nil {
+		return nil, fmt.Errorf("diskcache: add keyring key: %w", err)
+	}
+	if err := unix.KeyctlSetperm(id, keyringPerm); err != nil {
+		return nil, fmt.Errorf("diskcache: set keyring key permissions: %w", err)
+	}
+	return key, nil
+}
+
+func (s *keyringSealer) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(plaintext)+s.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("diskcache: generate nonce: %w", err)
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (s *keyringSealer) Unseal(sealed []byte) ([]byte, error) {
+	n := s.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, errors.New("diskcache: sealed data too short")
+	}
+	plain, err := s.aead.Open(nil, sealed[:n], sealed[n:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("diskcache: unseal: %w", err)
+	}
+	return plain, nil
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+package diskcache
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPlatformSealer(t *testing.T) {
+	s, err := PlatformSealer()
+	if err != nil {
+		t.Skipf("kernel keyring unavailable: %v", err)
+	}
+	key := bytes.Repeat([]byte{7}, 32)
+	sealed, err := s.Seal(key)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if bytes.Contains(sealed, key) {
+		t.Error("sealed data contains the plaintext key")
+	}
+
+	// A second sealer finds the same wrapping key in the keyring.
+	s2, err := PlatformSealer()
+	if err != nil {
+		t.Fatalf("PlatformSealer: %v", err)
+	}
+	got, err := s2.Unseal(sealed)
+	if err != nil {
+		t.Fatalf("Unseal: %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("Unseal = %x, want %x", got, key)
+	}
+}
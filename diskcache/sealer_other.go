@@ -0,0 +1,13 @@
+//go:build !linux && !windows
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+func platformSealer() (Sealer, error) {
+	return nil, fmt.Errorf("diskcache: no platform sealer on %s: %w", runtime.GOOS, errors.ErrUnsupported)
+}
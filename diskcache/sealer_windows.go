@@ -0,0 +1,56 @@
+//go:build windows
+
+package diskcache
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// dpapiEntropy is mixed into DPAPI so other applications using DPAPI for the
+// same user cannot unseal the key by accident.
+var dpapiEntropy = []byte("go-secrets diskcache")
+
+// dpapiSealer seals with the Windows Data Protection API under the current
+// user's credentials.
+type dpapiSealer struct{}
+
+func platformSealer() (Sealer, error) {
+	return dpapiSealer{}, nil
+}
+
+func (dpapiSealer) Seal(plaintext []byte) ([]byte, error) {
+	out, err := dpapi(plaintext, windows.CryptProtectData)
+	if err != nil {
+		return nil, fmt.Errorf("diskcache: DPAPI seal: %w", err)
+	}
+	return out, nil
+}
+
+func (dpapiSealer) Unseal(sealed []byte) ([]byte, error) {
+	out, err := dpapi(sealed, func(in *windows.DataBlob, name *uint16, entropy *windows.DataBlob, reserved uintptr, prompt *windows.CryptProtectPromptStruct, flags uint32, out *windows.DataBlob) error {
+		return windows.CryptUnprotectData(in, nil, entropy, reserved, prompt, flags, out)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("diskcache: DPAPI unseal: %w", err)
+	}
+	return out, nil
+}
+
+// dpapi runs a CryptProtectData-style call on data and returns a Go-owned
+// copy of the output.
+func dpapi(data []byte, call func(*windows.DataBlob, *uint16, *windows.DataBlob, uintptr, *windows.CryptProtectPromptStruct, uint32, *windows.DataBlob) error) ([]byte, error) {
+	in := windows.DataBlob{Size: uint32(len(data))}
+	if len(data) > 0 {
+		in.Data = &data[0]
+	}
+	entropy := windows.DataBlob{Size: uint32(len(dpapiEntropy)), Data: &dpapiEntropy[0]}
+	var out windows.DataBlob
+	if err := call(&in, nil, &entropy, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return nil, err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	return append([]byte(nil), unsafe.Slice(out.Data, out.Size)...), nil
+}
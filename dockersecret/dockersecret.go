@@ -0,0 +1,157 @@
+// Package dockersecret provides a secret provider that reads the secrets
+// Docker Swarm, Docker Compose, and Podman mount into a container.
+package dockersecret
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+
+	"github.com/brwse/go-secrets"
+	"github.com/brwse/go-secrets/file"
+)
+
+// DefaultDir is the directory Docker and Podman mount secrets into on Linux.
+const DefaultDir = "/run/secrets"
+
+// candidateDirs are the directories New looks for, in order: DefaultDir,
+// the path Podman and images without a /var/run link to /run also use, and
+// the directory of Windows containers.
+var candidateDirs = []string{DefaultDir, "/var/run/secrets"}
+
+func init() {
+	if runtime.GOOS == "windows" {
+		candidateDirs = []string{`C:\ProgramData\Docker\secrets`}
+	}
+}
+
+// name matches Docker secret names: letters, digits, "-", "_", and ".",
+// starting and ending with a letter or digit, at most 64 characters.
+var name = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]{0,62}[A-Za-z0-9])?$`)
+
+// ProviderOption configures the dockersecret Provider.
+type ProviderOption func(*Provider)
+
+// WithDir sets the directory secrets are mounted into, such as that of a
+// Compose secret's custom target. Defaults to the first of /run/secrets and
+// /var/run/secrets that exists, or C:\ProgramData\Docker\secrets on Windows.
+func WithDir(dir string) ProviderOption {
+	return func(p *Provider) {
+		p.dir = dir
+	}
+}
+
+// WithTrimNewline configures whether trailing newlines are trimmed. Secrets
+// created with echo or from a file saved by an editor usually end with one,
+// so it defaults to true.
+func WithTrimNewline(trim bool) ProviderOption {
+	return func(p *Provider) {
+		p.trimNewline = trim
+	}
+}
+
+// Provider reads mounted container secrets.
+// It implements secrets.Provider, secrets.CheckerProvider,
+// secrets.MetadataProvider, and secrets.ListerProvider.
+//
+// Keys are secret names, such as "db_password" for /run/secrets/db_password.
+// Names that Docker would reject, including any path, are rejected.
+type Provider struct {
+	dir         string
+	trimNewline bool
+	files       *file.Provider
+}
+
+// New creates a new Provider.
+func New(opts ...ProviderOption) *Provider {
+	p := &Provider{trimNewline: true}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.dir == "" {
+		p.dir = detectDir()
+	}
+	p.files = file.New(file.WithBaseDir(p.dir), file.WithTrimNewline(p.trimNewline))
+	return p
+}
+
+// Dir returns the directory secrets are read from.
+func (p *Provider) Dir() string {
+	return p.dir
+}
+
+// detectDir returns the first candidate directory that exists, or the first
+// candidate if none does.
+func detectDir() string {
+	for _, dir := range candidateDirs {
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			return dir
+		}
+	}
+	return candidateDirs[0]
+}
+
+// Get returns the secret name key.
+// Returns secrets.ErrNotFound (wrapped) if it is not mounted.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	val, err := p.files.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("dockersecret: %w", err)
+	}
+	return val, nil
+}
+
+// Check reports whether the secret is mounted, without reading it.
+// Returns secrets.ErrNotFound (wrapped) if it is not.
+func (p *Provider) Check(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if err := p.files.Check(ctx, key); err != nil {
+		return fmt.Errorf("dockersecret: %w", err)
+	}
+	return nil
+}
+
+// GetMetadata describes the mounted secret. RotatedAt is the modification
+// time of its file.
+// Returns secrets.ErrNotFound (wrapped) if it is not mounted.
+func (p *Provider) GetMetadata(ctx context.Context, key string) (secrets.Metadata, error) {
+	if err := checkKey(key); err != nil {
+		return secrets.Metadata{}, err
+	}
+	md, err := p.files.GetMetadata(ctx, key)
+	if err != nil {
+		return secrets.Metadata{}, fmt.Errorf("dockersecret: %w", err)
+	}
+	return md, nil
+}
+
+// List returns the names of the mounted secrets that start with prefix.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := p.files.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("dockersecret: %w", err)
+	}
+	// Skip files in subdirectories, which no secret name can reach.
+	out := keys[:0]
+	for _, k := range keys {
+		if name.MatchString(k) {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+// checkKey rejects keys that are not valid secret names.
+func checkKey(key string) error {
+	if !name.MatchString(key) {
+		return fmt.Errorf("dockersecret: invalid key %q: want a secret name of letters, digits, '-', '_', and '.'", key)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package dockersecret
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// writeFiles writes files, relative paths to contents, under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"db_password": "hunter2\n",
+		"tls.key":     "-----BEGIN KEY-----\n",
+	})
+	p := New(WithDir(dir))
+	ctx := context.Background()
+
+	got, err := p.Get(ctx, "db_password")
+	if err != nil || string(got) != "hunter2" {
+		t.Errorf("Get(db_password) = %q, %v; want hunter2", got, err)
+	}
+	got, err = p.Get(ctx, "tls.key")
+	if err != nil || string(got) != "-----BEGIN KEY-----" {
+		t.Errorf("Get(tls.key) = %q, %v", got, err)
+	}
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	raw := New(WithDir(dir), WithTrimNewline(false))
+	if got, _ := raw.Get(ctx, "db_password"); string(got) != "hunter2\n" {
+		t.Errorf("Get without trimming = %q", got)
+	}
+}
+
+func TestGet_InvalidName(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"app/db": "x"})
+	p := New(WithDir(dir))
+	for _, key := range []string{"", "app/db", "../etc/passwd", "/etc/passwd", "..", ".hidden", "db ", strings.Repeat("a", 65)} {
+		if _, err := p.Get(context.Background(), key); err == nil || errors.Is(err, secrets.ErrNotFound) {
+			t.Errorf("Get(%q) error = %v, want an invalid key error", key, err)
+		}
+		if err := p.Check(context.Background(), key); err == nil {
+			t.Errorf("Check(%q) succeeded", key)
+		}
+	}
+}
+
+func TestCheckMetadataList(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"api_key":     "k",
+		"api_secret":  "s",
+		"db_password": "p",
+		"nested/file": "n",
+	})
+	p := New(WithDir(dir))
+	ctx := context.Background()
+
+	if err := p.Check(ctx, "api_key"); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	if err := p.Check(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check(missing) error = %v, want ErrNotFound", err)
+	}
+	md, err := p.GetMetadata(ctx, "api_key")
+	if err != nil || md.RotatedAt.IsZero() {
+		t.Errorf("GetMetadata = %+v, %v; want RotatedAt set", md, err)
+	}
+
+	keys, err := p.List(ctx, "api_")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	slices.Sort(keys)
+	if want := []string{"api_key", "api_secret"}; !slices.Equal(keys, want) {
+		t.Errorf("List(api_) = %q, want %q", keys, want)
+	}
+	keys, _ = p.List(ctx, "")
+	if slices.Contains(keys, "nested/file") {
+		t.Errorf("List() = %q, includes a file in a subdirectory", keys)
+	}
+}
+
+func TestDetectDir(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	podman := t.TempDir()
+	old := candidateDirs
+	t.Cleanup(func() { candidateDirs = old })
+
+	candidateDirs = []string{missing, podman}
+	if p := New(); p.Dir() != podman {
+		t.Errorf("Dir() = %q, want the existing %q", p.Dir(), podman)
+	}
+	candidateDirs = []string{missing, missing + "2"}
+	if p := New(); p.Dir() != missing {
+		t.Errorf("Dir() = %q, want the first candidate %q", p.Dir(), missing)
+	}
+	if p := New(WithDir(podman)); p.Dir() != podman {
+		t.Errorf("Dir() = %q, want %q from WithDir", p.Dir(), podman)
+	}
+}
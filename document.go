@@ -0,0 +1,286 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// DocumentFormat is the syntax of a document passed to ResolveDocument.
+type DocumentFormat int
+
+const (
+	// FormatYAML is YAML. Placeholders are scalars tagged !secret:
+	//
+	//	password: !secret awssm://prod/db#password
+	FormatYAML DocumentFormat = iota + 1
+	// FormatJSON is JSON, which has no tags. Placeholders are strings that
+	// begin with "!secret ":
+	//
+	//	{"password": "!secret awssm://prod/db#password"}
+	FormatJSON
+)
+
+func (f DocumentFormat) String() string {
+	switch f {
+	case FormatYAML:
+		return "yaml"
+	case FormatJSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// secretTag marks a placeholder in a document.
+const secretTag = "!secret"
+
+// ResolveDocument returns doc, a YAML or JSON configuration document, with
+// each `!secret` placeholder replaced by the value of the secret it
+// references. References use `secret` tag syntax, so fragments and options
+// such as optional and version= apply:
+//
+//	db:
+//	  host: db.internal
+//	  password: !secret awssm://prod/db#password
+//	  replica_password: !secret awssm://prod/db-replica#password,optional
+//
+// Secrets are fetched concurrently, once per distinct secret, as ResolveRefs
+// does. Values are substituted as strings; an optional reference whose secret
+// does not exist is replaced by null. In YAML, a value that is not valid UTF-8
+// is substituted as !!binary; in JSON it is an error.
+//
+// JSON is edited in place, so its layout is preserved. YAML is re-encoded with
+// two-space indentation: comments and key order are kept, but other
+// formatting may change. If any placeholder cannot be parsed or resolved,
+// ResolveDocument returns an error and no document.
+func ResolveDocument(ctx context.Context, r *Resolver, doc []byte, format DocumentFormat) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		return resolveYAML(ctx, r, doc)
+	case FormatJSON:
+		return resolveJSON(ctx, r, doc)
+	default:
+		return nil, fmt.Errorf("secrets: unknown document format %d", format)
+	}
+}
+
+// parsePlaceholders parses the references of placeholders found at the given
+// lines, keyed by their text.
+func parsePlaceholders(uris []string, lines []int) (map[string]Ref, error) {
+	refs := make(map[string]Ref, len(uris))
+	var errs []error
+	for i, uri := range uris {
+		if _, ok := refs[uri]; ok {
+			continue
+		}
+		ref, err := ParseRef(uri)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("secrets: line %d: %s %s: %w", lines[i], secretTag, uri, err))
+			continue
+		}
+		refs[uri] = ref
+	}
+	return refs, errors.Join(errs...)
+}
+
+func resolveYAML(ctx context.Context, r *Resolver, doc []byte) ([]byte, error) {
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(doc))
+	for {
+		var n yaml.Node
+		err := dec.Decode(&n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("secrets: parse yaml: %w", err)
+		}
+		docs = append(docs, &n)
+	}
+
+	var placeholders []*yaml.Node
+	var errs []error
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Tag == secretTag {
+			if n.Kind != yaml.ScalarNode {
+				errs = append(errs, fmt.Errorf("secrets: line %d: %s must tag a scalar", n.Line, secretTag))
+				return
+			}
+			placeholders = append(placeholders, n)
+			return
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	for _, n := range docs {
+		walk(n)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if len(placeholders) == 0 {
+		return doc, nil
+	}
+
+	uris := make([]string, len(placeholders))
+	lines := make([]int, len(placeholders))
+	for i, n := range placeholders {
+		uris[i], lines[i] = strings.TrimSpace(n.Value), n.Line
+	}
+	refs, err := parsePlaceholders(uris, lines)
+	if err != nil {
+		return nil, err
+	}
+	values, err := r.ResolveRefs(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	for i, n := range placeholders {
+		value, ok := values[uris[i]]
+		n.Style = 0
+		switch {
+		case !ok:
+			n.Tag, n.Value = "!!null", "null"
+		case !utf8.Valid(value):
+			n.Tag, n.Value = "!!binary", base64.StdEncoding.EncodeToString(value)
+		default:
+			n.Tag, n.Value = "!!str", string(value)
+			if strings.Contains(n.Value, "\n") {
+				n.Style = yaml.LiteralStyle
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, n := range docs {
+		if err := enc.Encode(n); err != nil {
+			return nil, fmt.Errorf("secrets: encode yaml: %w", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("secrets: encode yaml: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonPlaceholder is the position in a JSON document of a string literal
+// holding a placeholder.
+type jsonPlaceholder struct {
+	start, end int // byte offsets of the literal, including its quotes
+	uri        string
+}
+
+func resolveJSON(ctx context.Context, r *Resolver, doc []byte) ([]byte, error) {
+	placeholders, err := scanJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(placeholders) == 0 {
+		return doc, nil
+	}
+
+	uris := make([]string, len(placeholders))
+	lines := make([]int, len(placeholders))
+	for i, ph := range placeholders {
+		uris[i], lines[i] = ph.uri, 1+bytes.Count(doc[:ph.start], []byte("\n"))
+	}
+	refs, err := parsePlaceholders(uris, lines)
+	if err != nil {
+		return nil, err
+	}
+	values, err := r.ResolveRefs(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for i, ph := range placeholders {
+		out.Write(doc[last:ph.start])
+		last = ph.end
+		value, ok := values[ph.uri]
+		if !ok {
+			out.WriteString("null")
+			continue
+		}
+		if !utf8.Valid(value) {
+			return nil, fmt.Errorf("secrets: line %d: %s %s: value is not valid UTF-8", lines[i], secretTag, ph.uri)
+		}
+		var lit bytes.Buffer
+		enc := json.NewEncoder(&lit)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(string(value)); err != nil {
+			return nil, err
+		}
+		out.Write(bytes.TrimSuffix(lit.Bytes(), []byte("\n")))
+	}
+	out.Write(doc[last:])
+	return out.Bytes(), nil
+}
+
+// scanJSON returns the placeholders among the string values, not object keys,
+// of doc.
+func scanJSON(doc []byte) ([]jsonPlaceholder, error) {
+	// For each open container, whether it is an object whose next string
+	// token is a key.
+	type container struct{ object, keyNext bool }
+	var stack []container
+	valueDone := func() {
+		if n := len(stack); n > 0 && stack[n-1].object {
+			stack[n-1].keyNext = true
+		}
+	}
+
+	var placeholders []jsonPlaceholder
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	for {
+		before := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("secrets: parse json: %w", err)
+		}
+		switch tok := tok.(type) {
+		case json.Delim:
+			switch tok {
+			case '{':
+				stack = append(stack, container{object: true, keyNext: true})
+			case '[':
+				stack = append(stack, container{})
+			default:
+				stack = stack[:len(stack)-1]
+				valueDone()
+			}
+		case string:
+			if n := len(stack); n > 0 && stack[n-1].keyNext {
+				stack[n-1].keyNext = false
+				continue
+			}
+			if uri, ok := strings.CutPrefix(tok, secretTag+" "); ok {
+				end := int(dec.InputOffset())
+				start := int(before) + bytes.IndexByte(doc[before:end], '"')
+				placeholders = append(placeholders, jsonPlaceholder{start: start, end: end, uri: strings.TrimSpace(uri)})
+			}
+			valueDone()
+		default:
+			valueDone()
+		}
+	}
+	return placeholders, nil
+}
@@ -0,0 +1,131 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newDocumentResolver() *Resolver {
+	return NewResolver(WithDefault(&mockProvider{data: map[string][]byte{
+		"db":     []byte(`{"user":"admin","password":"p<a>ss\"word"}`),
+		"cert":   []byte("line1\nline2\n"),
+		"port":   []byte("5432"),
+		"binary": {0xff, 0xfe},
+	}}))
+}
+
+func TestResolveDocument_YAML(t *testing.T) {
+	doc := `# database settings
+db:
+  host: db.internal
+  user: !secret db#user
+  password: !secret db#password # rotated monthly
+  port: !secret port
+  replica: !secret replica,optional
+  cert: !secret cert
+  raw: !secret binary
+---
+other: !secret db#user
+`
+	got, err := ResolveDocument(context.Background(), newDocumentResolver(), []byte(doc), FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# database settings
+db:
+  host: db.internal
+  user: admin
+  password: p<a>ss"word # rotated monthly
+  port: "5432"
+  replica: null
+  cert: |
+    line1
+    line2
+  raw: !!binary //4=
+---
+other: admin
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestResolveDocument_JSON(t *testing.T) {
+	doc := `{
+  "!secret db#user": "keys are left alone",
+  "db": {
+    "user": "!secret db#user",
+    "password":"!secret db#password",
+    "replica": "!secret replica,optional",
+    "ports": [1, "!secret port", {"cert": "!secret cert"}]
+  },
+  "note": "secret db#user"
+}`
+	got, err := ResolveDocument(context.Background(), newDocumentResolver(), []byte(doc), FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "!secret db#user": "keys are left alone",
+  "db": {
+    "user": "admin",
+    "password":"p<a>ss\"word",
+    "replica": null,
+    "ports": [1, "5432", {"cert": "line1\nline2\n"}]
+  },
+  "note": "secret db#user"
+}`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestResolveDocument_NoPlaceholders(t *testing.T) {
+	r := newDocumentResolver()
+	for _, tc := range []struct {
+		format DocumentFormat
+		doc    string
+	}{
+		{FormatYAML, "a:    1   # unusual spacing\n"},
+		{FormatJSON, `{"a":  1}`},
+	} {
+		got, err := ResolveDocument(context.Background(), r, []byte(tc.doc), tc.format)
+		if err != nil || string(got) != tc.doc {
+			t.Errorf("%v: got %q, %v; want document unchanged", tc.format, got, err)
+		}
+	}
+}
+
+func TestResolveDocument_Errors(t *testing.T) {
+	r := newDocumentResolver()
+	tests := []struct {
+		name   string
+		format DocumentFormat
+		doc    string
+		want   string
+	}{
+		{"yaml syntax", FormatYAML, "a: [", "secrets: parse yaml"},
+		{"json syntax", FormatJSON, `{"a": }`, "secrets: parse json"},
+		{"yaml bad ref", FormatYAML, "a: 1\nb: !secret db,bogus\n", "secrets: line 2: !secret db,bogus"},
+		{"json bad ref", FormatJSON, "{\n\"b\": \"!secret db,bogus\"}", "secrets: line 2: !secret db,bogus"},
+		{"yaml non-scalar", FormatYAML, "a: !secret {b: c}\n", "secrets: line 1: !secret must tag a scalar"},
+		{"json binary", FormatJSON, `["!secret binary"]`, "not valid UTF-8"},
+		{"format", DocumentFormat(0), "", "unknown document format"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ResolveDocument(context.Background(), r, []byte(tc.doc), tc.format)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("err = %v, want containing %q", err, tc.want)
+			}
+		})
+	}
+
+	_, err := ResolveDocument(context.Background(), r, []byte("a: !secret missing\n"), FormatYAML)
+	var re *ErrResolve
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &re) || re.URI != "missing" {
+		t.Errorf("err = %v, want ErrResolve for missing wrapping ErrNotFound", err)
+	}
+}
@@ -0,0 +1,220 @@
+// Package dotenv provides a secret provider that reads from .env files.
+package dotenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+)
+
+// ProviderOption configures the dotenv Provider.
+type ProviderOption func(*Provider)
+
+// WithFiles sets the files to read, in order, so that a later file overrides
+// the keys of an earlier one, as ".env.local" does those of ".env". Files
+// that do not exist are skipped. Defaults to ".env" in the working directory.
+func WithFiles(paths ...string) ProviderOption {
+	return func(p *Provider) {
+		p.paths = paths
+	}
+}
+
+// WithRequired makes a missing file an error rather than one without keys.
+func WithRequired() ProviderOption {
+	return func(p *Provider) {
+		p.required = true
+	}
+}
+
+// Provider reads secrets from .env files.
+// It implements secrets.Provider, secrets.CheckerProvider, and
+// secrets.ListerProvider.
+//
+// Keys are variable names. The files are read on every call, so edits are
+// seen by the next Resolve or watcher poll. See Parse for the syntax.
+type Provider struct {
+	paths    []string
+	required bool
+}
+
+// New creates a new dotenv Provider with the given options.
+func New(opts ...ProviderOption) *Provider {
+	p := &Provider{paths: []string{".env"}}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Get retrieves the value of the variable key.
+// Returns secrets.ErrNotFound (wrapped) if no file sets it.
+func (p *Provider) Get(_ context.Context, key string) ([]byte, error) {
+	vars, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	val, ok := vars[key]
+	if !ok {
+		return nil, fmt.Errorf("dotenv: %q: %w", key, secrets.ErrNotFound)
+	}
+	return []byte(val), nil
+}
+
+// Check reports whether a file sets the variable key.
+// Returns secrets.ErrNotFound (wrapped) if none does.
+func (p *Provider) Check(ctx context.Context, key string) error {
+	_, err := p.Get(ctx, key)
+	return err
+}
+
+// List returns the sorted names of the variables that start with prefix.
+func (p *Provider) List(_ context.Context, prefix string) ([]string, error) {
+	vars, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, k := range slices.Sorted(maps.Keys(vars)) {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// load reads and merges the files.
+func (p *Provider) load() (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, path := range p.paths {
+		f, err := os.Open(path)
+		if errors.Is(err, fs.ErrNotExist) && !p.required {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dotenv: %w", err)
+		}
+		fileVars, err := Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("dotenv: %s: %w", path, err)
+		}
+		maps.Copy(vars, fileVars)
+	}
+	return vars, nil
+}
+
+// name matches variable names.
+var name = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// Parse parses a .env file into its variables. Each line is blank, a
+// comment starting with "#", or NAME=value, optionally preceded by "export ".
+// A later assignment to a name overrides an earlier one. Values are:
+//   - unquoted, with surrounding spaces and a comment starting with " #"
+//     removed;
+//   - in single quotes, taken literally;
+//   - in double quotes, with the escapes \n, \r, \t, \", \\, and \$.
+//
+// Quoted values may span lines. "$" has no special meaning: values are not
+// expanded from other variables.
+func Parse(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	src := strings.ReplaceAll(string(data), "\r\n", "\n")
+	src = strings.TrimPrefix(src, "\ufeff")
+	vars := make(map[string]string)
+	line := 0
+	for src != "" {
+		line++
+		var l string
+		l, src, _ = strings.Cut(src, "\n")
+		// Only leading space is trimmed, as a quoted value keeps its own.
+		l = strings.TrimLeft(l, " \t")
+		if strings.TrimSpace(l) == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(l, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			l = strings.TrimSpace(rest)
+		}
+		k, v, ok := strings.Cut(l, "=")
+		k = strings.TrimSpace(k)
+		if !ok || !name.MatchString(k) {
+			return nil, fmt.Errorf("line %d: want NAME=value", line)
+		}
+		v = strings.TrimLeft(v, " \t")
+		start := line
+		if v != "" && (v[0] == '\'' || v[0] == '"') {
+			// The value may continue on the following lines.
+			var n int
+			v, src, n, err = parseQuoted(v, src)
+			line += n
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", start, err)
+			}
+		} else {
+			if i := strings.Index(v, " #"); i >= 0 {
+				v = v[:i]
+			} else if i := strings.Index(v, "\t#"); i >= 0 {
+				v = v[:i]
+			}
+			v = strings.TrimSpace(v)
+		}
+		vars[k] = v
+	}
+	return vars, nil
+}
+
+// parseQuoted parses the quoted value that starts v, the rest of its line,
+// reading on into src, the lines after it, until the closing quote. It
+// returns the value, what remains of src, and the number of lines of src it
+// consumed.
+func parseQuoted(v, src string) (val, rest string, lines int, err error) {
+	quote := v[0]
+	text := v[1:]
+	if src != "" {
+		text += "\n" + src
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == quote:
+			trailing, rest, _ := strings.Cut(text[i+1:], "\n")
+			if trailing = strings.TrimSpace(trailing); trailing != "" && !strings.HasPrefix(trailing, "#") {
+				return "", "", 0, fmt.Errorf("unexpected %q after closing quote", trailing)
+			}
+			return b.String(), rest, lines, nil
+		case c == '\\' && quote == '"' && i+1 < len(text):
+			i++
+			switch text[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(text[i])
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(text[i])
+			}
+		default:
+			if c == '\n' {
+				lines++
+			}
+			b.WriteByte(c)
+		}
+	}
+	return "", "", 0, fmt.Errorf("missing closing %c", quote)
+}
@@ -0,0 +1,117 @@
+package dotenv
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+func TestParse(t *testing.T) {
+	src := "\ufeff# comment\n" +
+		"PLAIN=value\n" +
+		"  SPACED = padded value   \n" +
+		"export EXPORTED=yes\n" +
+		"exported_NAME=kept\n" +
+		"EMPTY=\n" +
+		"INLINE=abc # comment\n" +
+		"HASH=abc#def\n" +
+		"SINGLE='literal $HOME \\n' # comment\n" +
+		"DOUBLE=\"tab\\there \\\"q\\\" \\$HOME \\\\ \\x\"\n" +
+		"MULTI=\"-----BEGIN KEY-----\r\n" +
+		"abc  \n" +
+		"-----END KEY-----\"\n" +
+		"MULTI_SINGLE='a\n" +
+		"\n" +
+		"b'\n" +
+		"URL=postgres://u:p@host/db?x=1\n" +
+		"PLAIN=overridden\n" +
+		"LAST=no newline"
+	got, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := map[string]string{
+		"PLAIN":         "overridden",
+		"SPACED":        "padded value",
+		"EXPORTED":      "yes",
+		"exported_NAME": "kept",
+		"EMPTY":         "",
+		"INLINE":        "abc",
+		"HASH":          "abc#def",
+		"SINGLE":        "literal $HOME \\n",
+		"DOUBLE":        "tab\there \"q\" $HOME \\ \\x",
+		"MULTI":         "-----BEGIN KEY-----\nabc  \n-----END KEY-----",
+		"MULTI_SINGLE":  "a\n\nb",
+		"URL":           "postgres://u:p@host/db?x=1",
+		"LAST":          "no newline",
+	}
+	if !maps.Equal(got, want) {
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("%s = %q, want %q", k, got[k], v)
+			}
+		}
+		t.Errorf("Parse = %q", got)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"no equals":        "A=1\nNOVALUE\n",
+		"bad name":         "1A=x\n",
+		"unclosed":         "A=1\nB=\"abc\nC=2\n",
+		"trailing garbage": "A='x' y\n",
+	}
+	for name, src := range tests {
+		if _, err := Parse(strings.NewReader(src)); err == nil {
+			t.Errorf("%s: Parse succeeded", name)
+		}
+	}
+	_, err := Parse(strings.NewReader("A=1\nB=\"x\n\ny\"\nBAD\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 5") {
+		t.Errorf("error = %v, want it on line 5", err)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	os.WriteFile(base, []byte("DB_PASSWORD=dev\nAPI_KEY=base\nAPI_URL=http://localhost\n"), 0o600)
+	os.WriteFile(local, []byte("API_KEY=local\n"), 0o600)
+
+	p := New(WithFiles(base, local, filepath.Join(dir, "missing")))
+	ctx := context.Background()
+	if got, err := p.Get(ctx, "DB_PASSWORD"); err != nil || string(got) != "dev" {
+		t.Errorf("Get(DB_PASSWORD) = %q, %v; want dev", got, err)
+	}
+	if got, err := p.Get(ctx, "API_KEY"); err != nil || string(got) != "local" {
+		t.Errorf("Get(API_KEY) = %q, %v; want the override", got, err)
+	}
+	if _, err := p.Get(ctx, "MISSING"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(MISSING) error = %v, want ErrNotFound", err)
+	}
+	if err := p.Check(ctx, "API_URL"); err != nil {
+		t.Errorf("Check(API_URL): %v", err)
+	}
+	keys, err := p.List(ctx, "API_")
+	if want := []string{"API_KEY", "API_URL"}; err != nil || !slices.Equal(keys, want) {
+		t.Errorf("List(API_) = %q, %v; want %q", keys, err, want)
+	}
+
+	os.WriteFile(local, []byte("API_KEY=edited\n"), 0o600)
+	if got, _ := p.Get(ctx, "API_KEY"); string(got) != "edited" {
+		t.Errorf("Get(API_KEY) after an edit = %q, want edited", got)
+	}
+
+	if _, err := New(WithFiles(filepath.Join(dir, "missing")), WithRequired()).Get(ctx, "A"); err == nil || errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get with a required missing file: error = %v", err)
+	}
+}
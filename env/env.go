@@ -22,7 +22,7 @@ func WithPrefix(prefix string) ProviderOption {
 }
 
 // Provider reads secrets from environment variables.
-// It implements secrets.Provider.
+// It implements secrets.Provider and secrets.CheckerProvider.
 type Provider struct {
 	prefix string
 }
@@ -47,3 +47,14 @@ func (p *Provider) Get(_ context.Context, key string) ([]byte, error) {
 	}
 	return []byte(val), nil
 }
+
+// Check reports whether the environment variable named by key (with any
+// configured prefix prepended) is set.
+// Returns secrets.ErrNotFound (wrapped) if the environment variable is not set.
+func (p *Provider) Check(_ context.Context, key string) error {
+	name := p.prefix + key
+	if _, ok := os.LookupEnv(name); !ok {
+		return fmt.Errorf("env: %q: %w", name, secrets.ErrNotFound)
+	}
+	return nil
+}
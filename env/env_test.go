@@ -45,3 +45,15 @@ func TestGet_WithPrefix(t *testing.T) {
 		t.Errorf("Get = %q, want %q", val, "password123")
 	}
 }
+
+func TestCheck(t *testing.T) {
+	t.Setenv("MYAPP_DB_PASS", "password123")
+
+	p := env.New(env.WithPrefix("MYAPP_"))
+	if err := p.Check(context.Background(), "DB_PASS"); err != nil {
+		t.Errorf("Check existing: %v", err)
+	}
+	if err := p.Check(context.Background(), "DEFINITELY_NOT_SET_12345"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check missing: expected ErrNotFound, got: %v", err)
+	}
+}
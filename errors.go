@@ -1,6 +1,11 @@
 package secrets
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
 
 // ErrNoDefaultProvider indicates a bare key was encountered but no default provider is configured.
 type ErrNoDefaultProvider struct {
@@ -9,6 +14,9 @@ type ErrNoDefaultProvider struct {
 }
 
 func (e *ErrNoDefaultProvider) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("secrets: no default provider for bare key %q", e.Key)
+	}
 	return fmt.Sprintf("secrets: field %s: no default provider for bare key %q", e.Field, e.Key)
 }
 
@@ -20,6 +28,9 @@ type ErrUnknownProvider struct {
 }
 
 func (e *ErrUnknownProvider) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("secrets: unknown provider %q for URI %q", e.Scheme, e.URI)
+	}
 	return fmt.Sprintf("secrets: field %s: unknown provider %q for URI %q", e.Field, e.Scheme, e.URI)
 }
 
@@ -57,5 +68,81 @@ type ErrVersioningNotSupported struct {
 }
 
 func (e *ErrVersioningNotSupported) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("secrets: provider %q does not support versioning", e.Provider)
+	}
 	return fmt.Sprintf("secrets: field %s: provider %q does not support versioning", e.Field, e.Provider)
 }
+
+// ErrFetchTimeout indicates that a provider fetch failed because its context
+// was canceled or its deadline passed, so that a slow or unreachable provider
+// can be told apart from a missing secret. errors.Is reports whether it
+// matches context.DeadlineExceeded or context.Canceled.
+type ErrFetchTimeout struct {
+	Field    string        // struct field name, empty outside Resolve
+	Provider string        // the provider scheme or "default"
+	Key      string        // the secret key
+	Elapsed  time.Duration // how long the fetch ran
+	Cause    error         // context.DeadlineExceeded or context.Canceled
+	Err      error         // the provider's error
+}
+
+func (e *ErrFetchTimeout) Error() string {
+	what := "canceled"
+	if errors.Is(e.Cause, context.DeadlineExceeded) {
+		what = "timed out"
+	}
+	elapsed := e.Elapsed.Round(time.Millisecond)
+	if e.Field == "" {
+		return fmt.Sprintf("secrets: provider %q: fetch of %q %s after %v: %v", e.Provider, e.Key, what, elapsed, e.Err)
+	}
+	return fmt.Sprintf("secrets: field %s: provider %q: fetch of %q %s after %v: %v", e.Field, e.Provider, e.Key, what, elapsed, e.Err)
+}
+
+func (e *ErrFetchTimeout) Unwrap() []error {
+	return []error{e.Cause, e.Err}
+}
+
+// ErrResolve reports that a secret could not be fetched from its provider.
+// Resolve, ResolveRef, and Secret[T].Get return it, possibly joined with
+// other errors; use errors.As to retrieve it. When the provider recognized the
+// failure as one returned by its service, its error tree holds an
+// *ErrProvider with the service's status, error code, and request ID.
+type ErrResolve struct {
+	Field    string // struct field name, empty for ResolveRef
+	URI      string // the reference without fragment or options
+	Provider string // the provider scheme or "default"
+	Version  string // the version requested, if any
+	Err      error  // the provider's error
+}
+
+func (e *ErrResolve) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("secrets: %s: %v", e.URI, e.Err)
+	}
+	return fmt.Sprintf("secrets: field %s: %v", e.Field, e.Err)
+}
+
+func (e *ErrResolve) Unwrap() error {
+	return e.Err
+}
+
+// ErrProvider wraps an error returned by a provider's SDK with the details a
+// cloud vendor asks for in a support ticket. The providers in this module
+// wrap the service errors they recognize; fields the service did not report
+// are left zero. Its message is that of Err, which usually includes them.
+type ErrProvider struct {
+	StatusCode int           // HTTP status code
+	Code       string        // service or gRPC error code, such as "AccessDeniedException" or "PermissionDenied"
+	RequestID  string        // the service's identifier for the failed request
+	RetryAfter time.Duration // how long the service asked clients to wait before retrying
+	Err        error         // the SDK's error
+}
+
+func (e *ErrProvider) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ErrProvider) Unwrap() error {
+	return e.Err
+}
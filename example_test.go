@@ -52,3 +52,18 @@ func Example() {
 	fmt.Printf("host=%s port=%d key=%s debug=%v\n", cfg.DBHost, cfg.DBPort, cfg.APIKey, cfg.Debug)
 	// Output: host=db.example.com port=5432 key=sk-test-123 debug=false
 }
+
+func ExampleResolver_Redactor() {
+	r := secrets.NewResolver(secrets.WithDefault(literal.New(map[string][]byte{
+		"prod/db-password": []byte("hunter22"),
+	})))
+
+	var cfg struct {
+		DBPass string `secret:"prod/db-password"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(r.Redactor().Redact("connect: password " + cfg.DBPass + " rejected"))
+	// Output: connect: password [REDACTED] rejected
+}
@@ -0,0 +1,87 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// Exec runs cmd with the secrets in mapping added to its environment, as
+// InjectEnv does, and waits for it to exit. It lets applications that cannot
+// be changed to use struct tags receive their secrets from a small launcher.
+//
+// While cmd runs, SIGTERM sent to the calling process is forwarded to it;
+// interrupts from a terminal already reach the whole process group. ctx
+// governs only the resolution of secrets: to stop the child when ctx is done,
+// create cmd with exec.CommandContext. If the child exits unsuccessfully,
+// the error is an *exec.ExitError carrying its exit status. If a secret
+// cannot be resolved, cmd is not started.
+func Exec(ctx context.Context, r *Resolver, mapping map[string]string, cmd *exec.Cmd) error {
+	if err := InjectEnv(ctx, cmd, mapping, r); err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				_ = cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return cmd.Wait()
+}
+
+// InjectEnv resolves the secret references in mapping (environment variable
+// name to reference, e.g. "DB_PASSWORD": "awssm://prod/db#password") and
+// appends them to cmd's environment. Call it just before cmd.Start or cmd.Run,
+// so a spawned worker receives only the secrets it needs rather than the
+// parent's full configuration.
+//
+// If cmd.Env is nil, the child would inherit the parent's environment; InjectEnv
+// preserves that by starting from cmd.Environ(). Secrets are fetched
+// concurrently and deduplicated. Optional references whose secret does not
+// exist are skipped. On error, cmd is left unchanged.
+func InjectEnv(ctx context.Context, cmd *exec.Cmd, mapping map[string]string, r *Resolver) error {
+	var errs []error
+	for name := range mapping {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			errs = append(errs, fmt.Errorf("secrets: invalid environment variable name %q", name))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	values, err := r.ResolveMap(ctx, mapping)
+	if err != nil {
+		return err
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = cmd.Environ()
+	}
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		env = append(env, name+"="+string(values[name]))
+	}
+	cmd.Env = env
+	return nil
+}
@@ -0,0 +1,114 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestInjectEnv(t *testing.T) {
+	var calls atomic.Int64
+	p := &countingProvider{data: map[string][]byte{
+		"db":  []byte(`{"user":"admin","password":"s3cret"}`),
+		"api": []byte("key-123"),
+	}, count: &calls}
+	r := NewResolver(WithDefault(p))
+
+	cmd := exec.Command("true")
+	cmd.Env = []string{"EXISTING=1"}
+	err := InjectEnv(context.Background(), cmd, map[string]string{
+		"DB_USER":  "db#user",
+		"DB_PASS":  "db#password",
+		"API_KEY":  "api",
+		"OPTIONAL": "missing,optional",
+	}, r)
+	if err != nil {
+		t.Fatalf("InjectEnv: %v", err)
+	}
+
+	want := []string{"EXISTING=1", "API_KEY=key-123", "DB_PASS=s3cret", "DB_USER=admin"}
+	if !slices.Equal(cmd.Env, want) {
+		t.Errorf("Env = %q, want %q", cmd.Env, want)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("provider calls = %d, want 3 (db fetched once)", n)
+	}
+}
+
+func TestInjectEnv_InheritsParentEnv(t *testing.T) {
+	t.Setenv("INJECT_ENV_PARENT", "yes")
+	r := NewResolver(WithDefault(&mockProvider{data: map[string][]byte{"k": []byte("v")}}))
+
+	cmd := exec.Command("true")
+	if err := InjectEnv(context.Background(), cmd, map[string]string{"CHILD": "k"}, r); err != nil {
+		t.Fatalf("InjectEnv: %v", err)
+	}
+	if !slices.Contains(cmd.Env, "INJECT_ENV_PARENT=yes") {
+		t.Error("expected parent environment to be preserved")
+	}
+	if !slices.Contains(cmd.Env, "CHILD=v") {
+		t.Error("expected CHILD=v in environment")
+	}
+}
+
+func TestInjectEnv_Errors(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{data: map[string][]byte{}}))
+
+	cmd := exec.Command("true")
+	err := InjectEnv(context.Background(), cmd, map[string]string{"MISSING": "nope"}, r)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if cmd.Env != nil {
+		t.Errorf("expected Env to be left unchanged on error, got %q", cmd.Env)
+	}
+
+	err = InjectEnv(context.Background(), cmd, map[string]string{"BAD=NAME": "k"}, r)
+	if err == nil {
+		t.Error("expected error for invalid variable name")
+	}
+	err = InjectEnv(context.Background(), cmd, map[string]string{"BAD_REF": "k,bogus"}, r)
+	if err == nil {
+		t.Error("expected error for invalid reference")
+	}
+}
+
+func TestExec(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{data: map[string][]byte{"db": []byte(`{"password":"s3cret"}`)}}))
+
+	var out strings.Builder
+	cmd := exec.Command("sh", "-c", `printf %s "$DB_PASSWORD"`)
+	cmd.Stdout = &out
+	if err := Exec(context.Background(), r, map[string]string{"DB_PASSWORD": "db#password"}, cmd); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if out.String() != "s3cret" {
+		t.Errorf("child saw DB_PASSWORD=%q, want %q", out.String(), "s3cret")
+	}
+}
+
+func TestExec_ExitStatus(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{data: map[string][]byte{}}))
+
+	err := Exec(context.Background(), r, nil, exec.Command("sh", "-c", "exit 3"))
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("expected exit status 3, got %v", err)
+	}
+}
+
+func TestExec_NotStartedOnError(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{data: map[string][]byte{}}))
+
+	cmd := exec.Command("true")
+	if err := Exec(context.Background(), r, map[string]string{"KEY": "missing"}, cmd); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if cmd.Process != nil {
+		t.Error("child started although a secret could not be resolved")
+	}
+}
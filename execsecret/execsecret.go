@@ -0,0 +1,137 @@
+// Package execsecret provides a secret provider that runs a command, such as
+// a password manager's CLI, and returns its output.
+package execsecret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+)
+
+// Placeholder is replaced by the key in the arguments of the command.
+const Placeholder = "{key}"
+
+// defaultNotFound holds the messages of common CLIs for missing secrets.
+var defaultNotFound = []string{
+	"not found",
+	"not in the password store", // pass
+	"no such file",              // sops, cat
+	"does not exist",
+}
+
+// ProviderOption configures the execsecret Provider.
+type ProviderOption func(*Provider)
+
+// WithTrimNewline sets whether a single trailing newline is trimmed from the
+// output, as most CLIs print one after the value. Defaults to true; disable
+// it for commands that print binary data.
+func WithTrimNewline(trim bool) ProviderOption {
+	return func(p *Provider) {
+		p.trimNewline = trim
+	}
+}
+
+// WithEnv adds environment variables, of the form "NAME=value", to those the
+// command inherits.
+func WithEnv(env ...string) ProviderOption {
+	return func(p *Provider) {
+		p.env = append(p.env, env...)
+	}
+}
+
+// WithDir sets the working directory of the command.
+func WithDir(dir string) ProviderOption {
+	return func(p *Provider) {
+		p.dir = dir
+	}
+}
+
+// WithNotFound sets the messages that, found in the command's standard
+// error, report a missing secret, compared without regard to case. They
+// replace the defaults, which cover pass, gopass, and sops.
+func WithNotFound(messages ...string) ProviderOption {
+	return func(p *Provider) {
+		p.notFound = messages
+	}
+}
+
+// Provider reads secrets by running a command. It implements
+// secrets.Provider.
+//
+// The command is run directly, not by a shell, with Placeholder in its
+// arguments replaced by the key:
+//
+//	execsecret.New([]string{"pass", "show", "{key}"})
+//	execsecret.New([]string{"sops", "-d", "secrets/{key}.yaml"})
+//
+// Keys starting with "-" are rejected, so that they cannot be taken for
+// options.
+type Provider struct {
+	command     []string
+	trimNewline bool
+	env         []string
+	dir         string
+	notFound    []string
+}
+
+// New creates a new Provider that runs command, whose first element is the
+// program to run and whose arguments must include Placeholder.
+func New(command []string, opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{command: slices.Clone(command), trimNewline: true, notFound: defaultNotFound}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if len(p.command) == 0 || p.command[0] == "" {
+		return nil, errors.New("execsecret: command is empty")
+	}
+	if !slices.ContainsFunc(p.command[1:], func(arg string) bool { return strings.Contains(arg, Placeholder) }) {
+		return nil, fmt.Errorf("execsecret: command %q has no %s argument", p.command, Placeholder)
+	}
+	return p, nil
+}
+
+// Get runs the command for key and returns its standard output.
+// Returns secrets.ErrNotFound (wrapped) if the command fails with a message
+// configured with WithNotFound.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	if key == "" || strings.HasPrefix(key, "-") {
+		return nil, fmt.Errorf("execsecret: invalid key %q", key)
+	}
+	args := make([]string, len(p.command)-1)
+	for i, arg := range p.command[1:] {
+		args[i] = strings.ReplaceAll(arg, Placeholder, key)
+	}
+	cmd := exec.CommandContext(ctx, p.command[0], args...)
+	cmd.Dir = p.dir
+	if len(p.env) > 0 {
+		cmd.Env = append(cmd.Environ(), p.env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		errMsg := strings.TrimSpace(stderr.String())
+		lower := strings.ToLower(errMsg)
+		for _, m := range p.notFound {
+			if strings.Contains(lower, strings.ToLower(m)) {
+				return nil, fmt.Errorf("execsecret: secret %q: %s: %w", key, errMsg, secrets.ErrNotFound)
+			}
+		}
+		if errMsg != "" {
+			return nil, fmt.Errorf("execsecret: secret %q: %w: %s", key, err, errMsg)
+		}
+		return nil, fmt.Errorf("execsecret: secret %q: %w", key, err)
+	}
+	out := stdout.Bytes()
+	if p.trimNewline {
+		out = bytes.TrimSuffix(out, []byte("\n"))
+		out = bytes.TrimSuffix(out, []byte("\r"))
+	}
+	return out, nil
+}
@@ -0,0 +1,131 @@
+package execsecret
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// fakePass installs a fake pass on PATH that prints the entries of a store.
+func fakePass(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$2" in
+prod/db) echo s3cret ;;
+prod/env) echo "$STORE_ENV" ;;
+prod/pwd) pwd ;;
+prod/raw) printf 'a\nb\n\n' ;;
+*) echo "Error: $2 is not in the password store." >&2; exit 1 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "pass"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestGet(t *testing.T) {
+	fakePass(t)
+	p, err := New([]string{"pass", "show", "{key}"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got, err := p.Get(context.Background(), "prod/db")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != "s3cret" {
+		t.Errorf("Get = %q, want s3cret", got)
+	}
+	got, err = p.Get(context.Background(), "prod/raw")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != "a\nb\n" {
+		t.Errorf("Get = %q, want one newline trimmed", got)
+	}
+}
+
+func TestGet_Missing(t *testing.T) {
+	fakePass(t)
+	p, err := New([]string{"pass", "show", "{key}"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := p.Get(context.Background(), "prod/missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+
+	// With other messages, the failure is reported as is.
+	p, err = New([]string{"pass", "show", "{key}"}, WithNotFound("no entry"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = p.Get(context.Background(), "prod/missing")
+	if err == nil || errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("got %v, want an error other than ErrNotFound", err)
+	}
+}
+
+func TestGet_InvalidKey(t *testing.T) {
+	fakePass(t)
+	p, err := New([]string{"pass", "show", "{key}"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, key := range []string{"", "--help", "-c"} {
+		if _, err := p.Get(context.Background(), key); err == nil {
+			t.Errorf("Get(%q) succeeded, want an error", key)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	fakePass(t)
+	dir := t.TempDir()
+	p, err := New([]string{"pass", "show", "{key}"}, WithEnv("STORE_ENV=staging"), WithDir(dir), WithTrimNewline(false))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	if got, err := p.Get(ctx, "prod/env"); err != nil || string(got) != "staging\n" {
+		t.Errorf("Get(prod/env) = %q, %v; want the environment variable, untrimmed", got, err)
+	}
+	got, err := p.Get(ctx, "prod/pwd")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if want, _ := filepath.EvalSymlinks(dir); string(got) != dir+"\n" && string(got) != want+"\n" {
+		t.Errorf("Get(prod/pwd) = %q, want %q", got, dir)
+	}
+}
+
+func TestPlaceholderInArgument(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db.txt"), []byte("from file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := New([]string{"cat", filepath.Join(dir, "{key}.txt")})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got, err := p.Get(context.Background(), "db"); err != nil || string(got) != "from file" {
+		t.Errorf("Get = %q, %v; want from file", got, err)
+	}
+	if _, err := p.Get(context.Background(), "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("missing file: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for _, cmd := range [][]string{nil, {""}, {"pass", "show"}, {"{key}"}} {
+		if _, err := New(cmd); err == nil {
+			t.Errorf("New(%q) succeeded, want an error", cmd)
+		}
+	}
+}
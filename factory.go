@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// fieldFactory builds a field value from secret bytes.
+type fieldFactory func(raw []byte) (reflect.Value, error)
+
+// WithFieldFactory registers fn to build values of type T from secret bytes.
+// Fields of type T, including Versioned[T] and Secret[T] fields, are set to
+// the value fn returns for their extracted secret. T is usually an interface,
+// which the resolver cannot otherwise populate, so that dependencies are
+// constructed directly from their secrets:
+//
+//	type Signer interface{ Sign([]byte) ([]byte, error) }
+//
+//	r := secrets.NewResolver(
+//		secrets.WithFieldFactory(func(pem []byte) (Signer, error) {
+//			return newECDSASigner(pem)
+//		}),
+//	)
+//
+//	type Config struct {
+//		Signer Signer `secret:"awssm://prod/signing-key"`
+//	}
+//
+// A factory for T takes precedence over the resolver's built-in conversion
+// for T. An error from fn is returned with the field name; unlike an
+// ErrConversion, it does not quote the secret. Watchers detect
+// changes to such fields by comparing the secret bytes, and rebuild the value
+// when they change.
+func WithFieldFactory[T any](fn func(raw []byte) (T, error)) Option {
+	return func(c *resolverConfig) {
+		if c.factories == nil {
+			c.factories = make(map[reflect.Type]fieldFactory)
+		}
+		c.factories[reflect.TypeFor[T]()] = func(raw []byte) (reflect.Value, error) {
+			v, err := fn(raw)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(&v).Elem(), nil
+		}
+	}
+}
+
+// setField sets fv from raw using the field factory registered for its type,
+// or else converts raw as the package-level setField does.
+func (r *Resolver) setField(fv reflect.Value, fieldName string, raw []byte) error {
+	build, ok := r.cfg.factories[fv.Type()]
+	if !ok {
+		return setField(fv, fieldName, raw)
+	}
+	v, err := build(raw)
+	if err != nil {
+		return fmt.Errorf("secrets: field %s: build %s: %w", fieldName, fv.Type(), err)
+	}
+	fv.Set(v)
+	return nil
+}
+
+// builds reports whether values of a field of type t, which is a Versioned[T]
+// type if versioned, are built by a field factory.
+func (r *Resolver) builds(t reflect.Type, versioned bool) bool {
+	if versioned {
+		t = t.Field(0).Type
+	}
+	_, ok := r.cfg.factories[t]
+	return ok
+}
+
+// supportsType reports whether the resolver can set values of type t.
+func (r *Resolver) supportsType(t reflect.Type) bool {
+	if _, ok := r.cfg.factories[t]; ok {
+		return true
+	}
+	return isSupportedType(t)
+}
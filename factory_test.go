@@ -0,0 +1,125 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signer is an interface-typed dependency built from a secret key.
+type signer interface {
+	Sign(msg string) string
+}
+
+type keySigner struct{ key string }
+
+func (s *keySigner) Sign(msg string) string { return s.key + ":" + msg }
+
+func newSigner(raw []byte) (signer, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("empty key")
+	}
+	return &keySigner{key: string(raw)}, nil
+}
+
+func TestWithFieldFactory(t *testing.T) {
+	p := &mockVersionedProvider{
+		data:     map[string][]byte{"signing": []byte(`{"key":"k2"}`), "lazy": []byte("k3")},
+		versions: map[string]map[string][]byte{"signing": {"previous": []byte(`{"key":"k1"}`)}},
+	}
+	r := NewResolver(WithDefault(p), WithFieldFactory(newSigner))
+
+	type Config struct {
+		Signer    signer            `secret:"signing#key"`
+		Versioned Versioned[signer] `secret:"signing#key"`
+		Lazy      Secret[signer]    `secret:"lazy"`
+	}
+	var cfg Config
+	if err := r.Validate(&cfg); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if got := cfg.Signer.Sign("m"); got != "k2:m" {
+		t.Errorf("Signer.Sign = %q, want %q", got, "k2:m")
+	}
+	if got := cfg.Versioned.Previous.Sign("m"); got != "k1:m" {
+		t.Errorf("Versioned.Previous.Sign = %q, want %q", got, "k1:m")
+	}
+	lazy, err := cfg.Lazy.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Lazy.Get: %v", err)
+	}
+	if got := lazy.Sign("m"); got != "k3:m" {
+		t.Errorf("Lazy.Sign = %q, want %q", got, "k3:m")
+	}
+}
+
+func TestWithFieldFactory_Unregistered(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{}))
+	var cfg struct {
+		Signer signer `secret:"signing"`
+	}
+	var ut *ErrUnsupportedType
+	if err := r.Validate(&cfg); !errors.As(err, &ut) {
+		t.Errorf("Validate: expected ErrUnsupportedType, got %v", err)
+	}
+}
+
+func TestWithFieldFactory_Error(t *testing.T) {
+	p := &mockProvider{data: map[string][]byte{"signing": []byte("private-key-material")}}
+	r := NewResolver(WithDefault(p), WithFieldFactory(func(raw []byte) (signer, error) {
+		return nil, errors.New("malformed key")
+	}))
+	var cfg struct {
+		Signer signer `secret:"signing"`
+	}
+	err := r.Resolve(context.Background(), &cfg)
+	if err == nil || !strings.Contains(err.Error(), "field Signer: build secrets.signer: malformed key") {
+		t.Fatalf("Resolve: unexpected error %v", err)
+	}
+	if strings.Contains(err.Error(), "private-key-material") {
+		t.Errorf("error reveals the secret: %v", err)
+	}
+}
+
+func TestWithFieldFactory_Watch(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("signing", []byte("k1"))
+	r := NewResolver(WithDefault(store), WithFieldFactory(newSigner))
+
+	var cfg struct {
+		Signer signer `secret:"signing"`
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, err := r.Watch(ctx, &cfg, WatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	// Unchanged polls rebuild the value but report no change.
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case ev := <-w.Changes():
+		t.Fatalf("unexpected change %+v", ev)
+	default:
+	}
+
+	store.Store("signing", []byte("k2"))
+	select {
+	case <-w.Changes():
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change")
+	}
+	w.RLock()
+	got := cfg.Signer.Sign("m")
+	w.RUnlock()
+	if got != "k2:m" {
+		t.Errorf("Signer.Sign = %q, want %q", got, "k2:m")
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/brwse/go-secrets"
 )
@@ -34,7 +37,9 @@ func WithTrimNewline(trim bool) ProviderOption {
 }
 
 // Provider reads secrets from filesystem files.
-// It implements secrets.Provider.
+// It implements secrets.Provider, secrets.CheckerProvider,
+// secrets.MetadataProvider, secrets.ListerProvider, and
+// secrets.WriterProvider.
 type Provider struct {
 	baseDir     string
 	trimNewline bool
@@ -53,11 +58,7 @@ func New(opts ...ProviderOption) *Provider {
 // by the key (with any configured base directory prepended).
 // Returns secrets.ErrNotFound (wrapped) if the file does not exist.
 func (p *Provider) Get(_ context.Context, key string) ([]byte, error) {
-	path := key
-	if p.baseDir != "" {
-		path = filepath.Join(p.baseDir, key)
-	}
-
+	path := p.path(key)
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -72,3 +73,140 @@ func (p *Provider) Get(_ context.Context, key string) ([]byte, error) {
 
 	return data, nil
 }
+
+// Check reports whether the file for key exists and can be opened for
+// reading, without reading its contents.
+// Returns secrets.ErrNotFound (wrapped) if the file does not exist.
+func (p *Provider) Check(_ context.Context, key string) error {
+	path := p.path(key)
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("file: %q: %w", path, secrets.ErrNotFound)
+		}
+		return fmt.Errorf("file: %q: %w", path, err)
+	}
+	return f.Close()
+}
+
+// GetMetadata describes the file for key without reading its contents. Only
+// RotatedAt, the file's modification time, is set; symlinks are followed, so
+// for a Kubernetes Secret volume it is the time of the last update of the
+// volume.
+// Returns secrets.ErrNotFound (wrapped) if the file does not exist.
+func (p *Provider) GetMetadata(_ context.Context, key string) (secrets.Metadata, error) {
+	path := p.path(key)
+	fi, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return secrets.Metadata{}, fmt.Errorf("file: %q: %w", path, secrets.ErrNotFound)
+		}
+		return secrets.Metadata{}, fmt.Errorf("file: %q: %w", path, err)
+	}
+	return secrets.Metadata{RotatedAt: fi.ModTime()}, nil
+}
+
+// List returns the keys of the files that start with prefix, walking the
+// directory named by prefix up to its last "/". With a base directory, keys
+// are paths relative to it; without one, they are paths as Get accepts them.
+// Symlinks to regular files are listed. Entries whose names begin with "..",
+// which Kubernetes uses for the internals of mounted Secret volumes, are
+// skipped. A directory that does not exist lists nothing.
+func (p *Provider) List(_ context.Context, prefix string) ([]string, error) {
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+	root := p.path(dir)
+	if root == "" {
+		root = "."
+	}
+	var keys []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if path != root && strings.HasPrefix(d.Name(), "..") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case d.IsDir():
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+		case !d.Type().IsRegular():
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if key := dir + filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("file: list %q: %w", prefix, err)
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Set writes value to the file for key, replacing its contents atomically:
+// value is written to a temporary file in the same directory, which is then
+// renamed over the target, so that readers never see a partial write. The
+// file is created with mode 0600; its directory must already exist.
+func (p *Provider) Set(_ context.Context, key string, value []byte) error {
+	path := p.path(key)
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("file: %q: %w", path, err)
+	}
+	tmp := f.Name()
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("file: %q: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("file: %q: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("file: %q: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("file: %q: %w", path, err)
+	}
+	return nil
+}
+
+// Delete removes the file for key.
+// Returns secrets.ErrNotFound (wrapped) if the file does not exist.
+func (p *Provider) Delete(_ context.Context, key string) error {
+	path := p.path(key)
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("file: %q: %w", path, secrets.ErrNotFound)
+		}
+		return fmt.Errorf("file: %q: %w", path, err)
+	}
+	return nil
+}
+
+// path returns the file path for key.
+func (p *Provider) path(key string) string {
+	if p.baseDir != "" {
+		return filepath.Join(p.baseDir, key)
+	}
+	return key
+}
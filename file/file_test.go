@@ -5,7 +5,9 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/brwse/go-secrets"
 	"github.com/brwse/go-secrets/file"
@@ -88,3 +90,116 @@ func TestGet_WithBaseDir(t *testing.T) {
 		t.Errorf("Get = %q, want %q", val, "tok123")
 	}
 }
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db-pass"), []byte("s3cret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := file.New(file.WithBaseDir(dir))
+	if err := p.Check(context.Background(), "db-pass"); err != nil {
+		t.Errorf("Check existing: %v", err)
+	}
+	if err := p.Check(context.Background(), "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check missing: expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestGetMetadata(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(path, []byte("s3cret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2024, 3, 9, 16, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	p := file.New(file.WithBaseDir(dir))
+
+	md, err := p.GetMetadata(context.Background(), "secret.txt")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if !md.RotatedAt.Equal(mtime) {
+		t.Errorf("RotatedAt = %v, want %v", md.RotatedAt, mtime)
+	}
+	if _, err := p.GetMetadata(context.Background(), "missing.txt"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	dir := t.TempDir()
+	p := file.New(file.WithBaseDir(dir))
+	ctx := context.Background()
+
+	for _, v := range []string{"first", "second"} {
+		if err := p.Set(ctx, "db-pass", []byte(v)); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if val, err := p.Get(ctx, "db-pass"); err != nil || string(val) != "second" {
+		t.Errorf("Get = %q, %v, want %q", val, err, "second")
+	}
+	info, err := os.Stat(filepath.Join(dir, "db-pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("mode = %v, want 0600", mode)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory has %d entries, want 1 (temporary file left behind?)", len(entries))
+	}
+
+	if err := p.Delete(ctx, "db-pass"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := p.Delete(ctx, "db-pass"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Delete missing: expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"db-pass", "db-user", "api/key", "..data/db-pass"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("v"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Kubernetes mounts each key as a symlink into ..data.
+	if err := os.Symlink(filepath.Join("..data", "db-pass"), filepath.Join(dir, "mounted")); err != nil {
+		t.Fatal(err)
+	}
+
+	p := file.New(file.WithBaseDir(dir))
+	for prefix, want := range map[string]string{
+		"":        "api/key,db-pass,db-user,mounted",
+		"db-":     "db-pass,db-user",
+		"api/":    "api/key",
+		"absent/": "",
+	} {
+		got, err := p.List(context.Background(), prefix)
+		if err != nil {
+			t.Fatalf("List(%q): %v", prefix, err)
+		}
+		if strings.Join(got, ",") != want {
+			t.Errorf("List(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+
+	// Without a base directory, keys are the paths Get accepts.
+	got, err := file.New().List(context.Background(), dir+"/api/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := dir + "/api/key"; len(got) != 1 || got[0] != want {
+		t.Errorf("List = %q, want [%q]", got, want)
+	}
+}
@@ -1,8 +1,13 @@
 package secrets
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -17,37 +22,337 @@ import (
 // String values are returned as-is (without JSON quotes).
 // Numbers, booleans, and null are returned as their JSON string representation.
 func extractFragment(data []byte, path string) ([]byte, error) {
-	var root any
-	if err := json.Unmarshal(data, &root); err != nil {
-		return nil, fmt.Errorf("secrets: invalid JSON: %w", err)
-	}
+	return newJSONDoc(data).fragment(path)
+}
 
-	parts := strings.Split(path, ".")
-	current := root
+// jsonDoc is a fetched secret value from which fields extract fragments.
+//
+// Fragments are located by scanning the raw JSON rather than decoding it:
+// values that are not on a requested path are validated and skipped without
+// being materialized, and only the value at the end of the path is decoded.
+// The document is validated once, on first use. The first lookup in an
+// object scans it for the one member; a second lookup indexes the spans of
+// all its members, so that N fields reading fragments of one large secret
+// cost a bounded number of passes over it rather than N. It is not safe for
+// concurrent use.
+type jsonDoc struct {
+	data      []byte
+	validated bool
+	err       error
+	root      []byte
+	spans     map[string][]byte            // path -> raw value, as ".db.host"
+	scanned   map[string]bool              // paths of objects looked up in at least once
+	members   map[string]map[string][]byte // path of an indexed object -> member -> raw value
+}
 
-	for _, part := range parts {
-		switch v := current.(type) {
-		case map[string]any:
-			val, ok := v[part]
+func newJSONDoc(data []byte) *jsonDoc {
+	return &jsonDoc{data: data}
+}
+
+// fragment extracts the value at path.
+func (d *jsonDoc) fragment(path string) ([]byte, error) {
+	if !d.validated {
+		d.validated = true
+		start := skipSpace(d.data, 0)
+		end, err := skipValue(d.data, start)
+		if err == nil && skipSpace(d.data, end) != len(d.data) {
+			err = fmt.Errorf("invalid character %q after top-level value", d.data[skipSpace(d.data, end)])
+		}
+		if err != nil {
+			d.err = fmt.Errorf("secrets: invalid JSON: %w", err)
+		} else {
+			d.root = d.data[start:end]
+			d.spans = make(map[string][]byte)
+			d.scanned = make(map[string]bool)
+			d.members = make(map[string]map[string][]byte)
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	current := d.root
+	prefix := ""
+	for part := range strings.SplitSeq(path, ".") {
+		next := prefix + "." + part
+		switch current[0] {
+		case '{':
+			span, ok := d.spans[next]
+			switch {
+			case ok:
+			case !d.scanned[prefix]:
+				d.scanned[prefix] = true
+				if span, ok = objectMember(current, part); ok {
+					d.spans[next] = span
+				}
+			default:
+				m, indexed := d.members[prefix]
+				if !indexed {
+					m = indexObject(current)
+					d.members[prefix] = m
+				}
+				if span, ok = m[part]; ok {
+					d.spans[next] = span
+				}
+			}
 			if !ok {
 				return nil, fmt.Errorf("secrets: fragment %q not found", path)
 			}
-			current = val
-		case []any:
+			current = span
+		case '[':
+			if span, ok := d.spans[next]; ok {
+				current = span
+				break
+			}
 			idx, err := strconv.Atoi(part)
 			if err != nil {
 				return nil, fmt.Errorf("secrets: fragment %q: %q is not a valid array index", path, part)
 			}
-			if idx < 0 || idx >= len(v) {
-				return nil, fmt.Errorf("secrets: fragment %q: index %d out of range (len %d)", path, idx, len(v))
+			var n int
+			if current, n = arrayElem(current, idx); current == nil {
+				return nil, fmt.Errorf("secrets: fragment %q: index %d out of range (len %d)", path, idx, n)
 			}
-			current = v[idx]
+			d.spans[next] = current
 		default:
-			return nil, fmt.Errorf("secrets: fragment %q: cannot index into %T", path, current)
+			var v any
+			if err := json.Unmarshal(current, &v); err != nil {
+				return nil, fmt.Errorf("secrets: fragment %q: %w", path, err)
+			}
+			return nil, fmt.Errorf("secrets: fragment %q: cannot index into %T", path, v)
+		}
+		prefix = next
+	}
+
+	var v any
+	if err := json.Unmarshal(current, &v); err != nil {
+		return nil, fmt.Errorf("secrets: fragment %q: %w", path, err)
+	}
+	b, err := fragmentBytes(v)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: fragment %q: %w", path, err)
+	}
+	return b, nil
+}
+
+// indexObject returns the members of the valid JSON object obj. As with
+// encoding/json, the last of duplicate keys wins.
+func indexObject(obj []byte) map[string][]byte {
+	m := make(map[string][]byte)
+	i := skipSpace(obj, 1)
+	for obj[i] != '}' {
+		keyEnd, _ := skipValue(obj, i)
+		name := memberName(obj[i+1 : keyEnd-1])
+		i = skipSpace(obj, skipSpace(obj, keyEnd)+1) // skip ':'
+		valEnd, _ := skipValue(obj, i)
+		m[name] = obj[i:valEnd]
+		i = skipSpace(obj, valEnd)
+		if obj[i] == ',' {
+			i = skipSpace(obj, i+1)
+		}
+	}
+	return m
+}
+
+// objectMember returns the raw value of member key in the valid JSON object
+// obj. As with encoding/json, the last of duplicate keys wins.
+func objectMember(obj []byte, key string) ([]byte, bool) {
+	var found []byte
+	i := skipSpace(obj, 1)
+	for obj[i] != '}' {
+		keyEnd, _ := skipValue(obj, i)
+		raw := obj[i+1 : keyEnd-1]
+		i = skipSpace(obj, skipSpace(obj, keyEnd)+1) // skip ':'
+		valEnd, _ := skipValue(obj, i)
+		if string(raw) == key || slices.Contains(raw, '\\') && memberName(raw) == key {
+			found = obj[i:valEnd]
+		}
+		i = skipSpace(obj, valEnd)
+		if obj[i] == ',' {
+			i = skipSpace(obj, i+1)
+		}
+	}
+	return found, found != nil
+}
+
+// memberName decodes the raw contents of a valid JSON string.
+func memberName(raw []byte) string {
+	if !slices.Contains(raw, '\\') {
+		return string(raw)
+	}
+	var s string
+	_ = json.Unmarshal(append(append([]byte{'"'}, raw...), '"'), &s)
+	return s
+}
+
+// arrayElem returns the raw element idx of the valid JSON array arr, or nil
+// and the array length if idx is out of range.
+func arrayElem(arr []byte, idx int) ([]byte, int) {
+	n := 0
+	i := skipSpace(arr, 1)
+	for arr[i] != ']' {
+		end, _ := skipValue(arr, i)
+		if n == idx {
+			return arr[i:end], 0
+		}
+		n++
+		i = skipSpace(arr, end)
+		if arr[i] == ',' {
+			i = skipSpace(arr, i+1)
+		}
+	}
+	return nil, n
+}
+
+// skipSpace returns the index of the first non-whitespace byte at or after i.
+func skipSpace(b []byte, i int) int {
+	for i < len(b) && (b[i] == ' ' || b[i] == '\t' || b[i] == '\n' || b[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// skipValue validates the JSON value starting at b[i] and returns the index
+// just past it.
+func skipValue(b []byte, i int) (int, error) {
+	if i >= len(b) {
+		return 0, errors.New("unexpected end of JSON input")
+	}
+	switch c := b[i]; {
+	case c == '{' || c == '[':
+		closing := byte('}')
+		if c == '[' {
+			closing = ']'
+		}
+		i = skipSpace(b, i+1)
+		if i < len(b) && b[i] == closing {
+			return i + 1, nil
+		}
+		for {
+			var err error
+			if c == '{' {
+				if i >= len(b) || b[i] != '"' {
+					return 0, syntaxError(b, i, "looking for beginning of object key string")
+				}
+				if i, err = skipValue(b, i); err != nil {
+					return 0, err
+				}
+				i = skipSpace(b, i)
+				if i >= len(b) || b[i] != ':' {
+					return 0, syntaxError(b, i, "after object key")
+				}
+				i = skipSpace(b, i+1)
+			}
+			if i, err = skipValue(b, i); err != nil {
+				return 0, err
+			}
+			i = skipSpace(b, i)
+			if i < len(b) && b[i] == closing {
+				return i + 1, nil
+			}
+			if i >= len(b) || b[i] != ',' {
+				return 0, syntaxError(b, i, "after value")
+			}
+			i = skipSpace(b, i+1)
+		}
+	case c == '"':
+		for i++; i < len(b); i++ {
+			switch {
+			case b[i] == '"':
+				return i + 1, nil
+			case b[i] < 0x20:
+				return 0, syntaxError(b, i, "in string literal")
+			case b[i] == '\\':
+				i++
+				if i >= len(b) {
+					break
+				}
+				switch b[i] {
+				case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				case 'u':
+					if i+4 >= len(b) {
+						return 0, errors.New("unexpected end of JSON input")
+					}
+					if _, err := strconv.ParseUint(string(b[i+1:i+5]), 16, 16); err != nil {
+						return 0, syntaxError(b, i, "in \\u hexadecimal character escape")
+					}
+					i += 4
+				default:
+					return 0, syntaxError(b, i, "in string escape code")
+				}
+			}
+		}
+		return 0, errors.New("unexpected end of JSON input")
+	case c == 't' || c == 'f' || c == 'n':
+		lit := "null"
+		switch c {
+		case 't':
+			lit = "true"
+		case 'f':
+			lit = "false"
+		}
+		if !bytes.HasPrefix(b[i:], []byte(lit)) {
+			return 0, syntaxError(b, i, "in literal")
+		}
+		return i + len(lit), nil
+	case c == '-' || ('0' <= c && c <= '9'):
+		return skipNumber(b, i)
+	default:
+		return 0, syntaxError(b, i, "looking for beginning of value")
+	}
+}
+
+// skipNumber validates the JSON number starting at b[i] and returns the index
+// just past it.
+func skipNumber(b []byte, i int) (int, error) {
+	digits := func(i int) int {
+		for i < len(b) && '0' <= b[i] && b[i] <= '9' {
+			i++
+		}
+		return i
+	}
+	if b[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(b) && b[i] == '0':
+		i++
+	case i < len(b) && '1' <= b[i] && b[i] <= '9':
+		i = digits(i)
+	default:
+		return 0, syntaxError(b, i, "in numeric literal")
+	}
+	if i < len(b) && b[i] == '.' {
+		if j := digits(i + 1); j > i+1 {
+			i = j
+		} else {
+			return 0, syntaxError(b, i+1, "after decimal point in numeric literal")
 		}
 	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		if i < len(b) && (b[i] == '+' || b[i] == '-') {
+			i++
+		}
+		if j := digits(i); j > i {
+			i = j
+		} else {
+			return 0, syntaxError(b, i, "in exponent of numeric literal")
+		}
+	}
+	return i, nil
+}
 
-	// Convert the final value to bytes.
+// syntaxError describes invalid JSON at b[i].
+func syntaxError(b []byte, i int, context string) error {
+	if i >= len(b) {
+		return errors.New("unexpected end of JSON input")
+	}
+	return fmt.Errorf("invalid character %q %s at offset %d", b[i], context, i)
+}
+
+// fragmentBytes converts a decoded JSON value to bytes as extractFragment
+// returns it.
+func fragmentBytes(current any) ([]byte, error) {
 	switch v := current.(type) {
 	case string:
 		return []byte(v), nil
@@ -63,10 +368,93 @@ func extractFragment(data []byte, path string) ([]byte, error) {
 		return []byte("null"), nil
 	default:
 		// For nested objects/arrays, re-marshal as JSON.
-		b, err := json.Marshal(v)
-		if err != nil {
-			return nil, fmt.Errorf("secrets: fragment %q: %w", path, err)
+		return json.Marshal(v)
+	}
+}
+
+// fragmentChange is a difference between two JSON documents at a
+// dot-delimited path. OldValue or NewValue is nil if the path is absent.
+type fragmentChange struct {
+	path     string
+	oldValue []byte
+	newValue []byte
+}
+
+// diffJSON returns the paths at which the JSON documents old and new differ,
+// descending into objects and arrays present in both. ok is false if either
+// document is not a JSON object or array.
+func diffJSON(old, new []byte) (changes []fragmentChange, ok bool) {
+	var oldRoot, newRoot any
+	if json.Unmarshal(old, &oldRoot) != nil || json.Unmarshal(new, &newRoot) != nil {
+		return nil, false
+	}
+	if !isJSONContainer(oldRoot) || !isJSONContainer(newRoot) {
+		return nil, false
+	}
+	diffValues("", oldRoot, newRoot, true, true, &changes)
+	return changes, true
+}
+
+func isJSONContainer(v any) bool {
+	switch v.(type) {
+	case map[string]any, []any:
+		return true
+	}
+	return false
+}
+
+// diffValues appends the differences between old and new at path. hasOld and
+// hasNew report whether the path exists on each side.
+func diffValues(path string, old, new any, hasOld, hasNew bool, out *[]fragmentChange) {
+	join := func(part string) string {
+		if path == "" {
+			return part
 		}
-		return b, nil
+		return path + "." + part
+	}
+	if hasOld && hasNew {
+		switch o := old.(type) {
+		case map[string]any:
+			if n, ok := new.(map[string]any); ok {
+				keys := slices.Collect(maps.Keys(o))
+				for k := range n {
+					if _, ok := o[k]; !ok {
+						keys = append(keys, k)
+					}
+				}
+				slices.Sort(keys)
+				for _, k := range keys {
+					ov, inOld := o[k]
+					nv, inNew := n[k]
+					diffValues(join(k), ov, nv, inOld, inNew, out)
+				}
+				return
+			}
+		case []any:
+			if n, ok := new.([]any); ok {
+				for i := range max(len(o), len(n)) {
+					var ov, nv any
+					if i < len(o) {
+						ov = o[i]
+					}
+					if i < len(n) {
+						nv = n[i]
+					}
+					diffValues(join(strconv.Itoa(i)), ov, nv, i < len(o), i < len(n), out)
+				}
+				return
+			}
+		}
+		if reflect.DeepEqual(old, new) {
+			return
+		}
+	}
+	c := fragmentChange{path: path}
+	if hasOld {
+		c.oldValue, _ = fragmentBytes(old)
+	}
+	if hasNew {
+		c.newValue, _ = fragmentBytes(new)
 	}
+	*out = append(*out, c)
 }
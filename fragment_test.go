@@ -1,6 +1,14 @@
 package secrets
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
 
 func TestExtractFragment_StringField(t *testing.T) {
 	data := []byte(`{"host":"localhost","port":5432,"password":"s3cret"}`)
@@ -107,3 +115,192 @@ func TestExtractFragment_FloatField(t *testing.T) {
 		t.Errorf("got %q, want %q", val, "3.14")
 	}
 }
+
+func TestDiffJSON(t *testing.T) {
+	old := []byte(`{"db":{"host":"a","port":5432},"keys":["k1","k2"],"ttl":60,"gone":true}`)
+	new := []byte(`{"db":{"host":"b","port":5432},"keys":["k1"],"ttl":60,"added":{"x":1}}`)
+
+	changes, ok := diffJSON(old, new)
+	if !ok {
+		t.Fatal("diffJSON: ok = false, want true")
+	}
+	want := []fragmentChange{
+		{path: "added", newValue: []byte(`{"x":1}`)},
+		{path: "db.host", oldValue: []byte("a"), newValue: []byte("b")},
+		{path: "gone", oldValue: []byte("true")},
+		{path: "keys.1", oldValue: []byte("k2")},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %+v, want %+v", changes, want)
+	}
+	for i, w := range want {
+		c := changes[i]
+		if c.path != w.path || string(c.oldValue) != string(w.oldValue) || string(c.newValue) != string(w.newValue) ||
+			(c.oldValue == nil) != (w.oldValue == nil) || (c.newValue == nil) != (w.newValue == nil) {
+			t.Errorf("changes[%d] = {%s %q %q}, want {%s %q %q}", i, c.path, c.oldValue, c.newValue, w.path, w.oldValue, w.newValue)
+		}
+	}
+}
+
+func TestDiffJSON_NotContainers(t *testing.T) {
+	for _, tc := range [][2]string{
+		{`plain`, `{"a":1}`},
+		{`{"a":1}`, `"str"`},
+		{`42`, `43`},
+	} {
+		if _, ok := diffJSON([]byte(tc[0]), []byte(tc[1])); ok {
+			t.Errorf("diffJSON(%s, %s): ok = true, want false", tc[0], tc[1])
+		}
+	}
+	changes, ok := diffJSON([]byte(`{"a": 1, "b": 2}`), []byte(`{"b":2,"a":1}`))
+	if !ok || len(changes) != 0 {
+		t.Errorf("reformatted document: changes = %+v, ok = %v, want none", changes, ok)
+	}
+}
+
+// largeJSONSecret returns a JSON object of about 60KB with n top-level keys.
+func largeJSONSecret(n int) []byte {
+	doc := make(map[string]string, n)
+	for i := range n {
+		doc[fmt.Sprintf("key%d", i)] = strings.Repeat("x", 50)
+	}
+	b, _ := json.Marshal(doc)
+	return b
+}
+
+func TestJSONDoc_DecodesOnce(t *testing.T) {
+	doc := newJSONDoc([]byte(`{"a":"1","b":"2"}`))
+	if v, err := doc.fragment("a"); err != nil || string(v) != "1" {
+		t.Fatalf("fragment(a) = %q, %v", v, err)
+	}
+	doc.data = nil // later lookups must not scan the raw data again
+	if v, err := doc.fragment("b"); err != nil || string(v) != "2" {
+		t.Errorf("fragment(b) = %q, %v", v, err)
+	}
+
+	bad := newJSONDoc([]byte(`not json`))
+	for range 2 {
+		if _, err := bad.fragment("a"); err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+			t.Errorf("fragment on invalid JSON: got %v", err)
+		}
+	}
+}
+
+func BenchmarkExtractFragment_Large(b *testing.B) {
+	data := largeJSONSecret(1000)
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		if _, err := extractFragment(data, "key500"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkResolve_LargeJSONFragments resolves ten fields from different
+// fragments of one large secret, which is fetched and scanned once.
+func BenchmarkResolve_LargeJSONFragments(b *testing.B) {
+	store := &syncMapProvider{}
+	store.Store("big", largeJSONSecret(1000))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		F0 string `secret:"big#key0"`
+		F1 string `secret:"big#key100"`
+		F2 string `secret:"big#key200"`
+		F3 string `secret:"big#key300"`
+		F4 string `secret:"big#key400"`
+		F5 string `secret:"big#key500"`
+		F6 string `secret:"big#key600"`
+		F7 string `secret:"big#key700"`
+		F8 string `secret:"big#key800"`
+		F9 string `secret:"big#key900"`
+	}
+	for b.Loop() {
+		var cfg Config
+		if err := r.Resolve(context.Background(), &cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// decodeFragment is the reference implementation of extractFragment: it
+// decodes the whole document and walks the path.
+func decodeFragment(data []byte, path string) ([]byte, error) {
+	var current any
+	if err := json.Unmarshal(data, &current); err != nil {
+		return nil, err
+	}
+	for part := range strings.SplitSeq(path, ".") {
+		switch v := current.(type) {
+		case map[string]any:
+			val, ok := v[part]
+			if !ok {
+				return nil, errors.New("not found")
+			}
+			current = val
+		case []any:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, errors.New("bad index")
+			}
+			current = v[idx]
+		default:
+			return nil, errors.New("not a container")
+		}
+	}
+	return fragmentBytes(current)
+}
+
+func TestExtractFragment_MatchesFullDecode(t *testing.T) {
+	docs := []string{
+		`{"a":"x","b":{"c":[1,2.5,-3e2,{"d":null}],"e":true},"f":false}`,
+		` { "a" : "x" , "b" : { "c" : [ 1 , 2 ] } } `,
+		`{"a":"first","a":"last"}`,
+		`{"ab":"escaped key","q":"say \"hi\"\n","u":"é😀"}`,
+		`{"a.b":"dotted","a":{"b":"nested"}}`,
+		`{"":{"":"empty keys"}}`,
+		`{"empty":{},"list":[],"nested":[[0,[1]]]}`,
+		`[{"a":1},{"a":2}]`,
+		`{"num":0.000001,"big":12345678901234567890,"neg":-0}`,
+		`"just a string"`,
+		`42`,
+		// Invalid documents.
+		`not json`,
+		`{"a":1,}`,
+		`{"a":tru,"b":"x"}`,
+		`{"a":01}`,
+		`{"a":"x"} trailing`,
+		`{"a":"unterminated}`,
+		`{"a":"bad \q escape"}`,
+		"{\"a\":\"control \x01\"}",
+		`{"a" "x"}`,
+		`[1 2]`,
+		`{"a":1.}`,
+		`{"a":1e}`,
+		``,
+	}
+	paths := []string{"a", "ab", "b", "b.c", "b.c.0", "b.c.1", "b.c.2", "b.c.3.d", "b.c.9", "b.e", "b.c.x", "a.x",
+		"q", "u", "empty", "list", "list.0", "nested.0.1.0", "0", "0.a", "1.a", "num", "big", "neg", "f", "a.b", "", "."}
+	for _, doc := range docs {
+		for _, path := range paths {
+			want, wantErr := decodeFragment([]byte(doc), path)
+			got, gotErr := extractFragment([]byte(doc), path)
+			if (gotErr != nil) != (wantErr != nil) || string(got) != string(want) {
+				t.Errorf("extractFragment(%s, %q) = %q, %v; full decode = %q, %v", doc, path, got, gotErr, want, wantErr)
+			}
+		}
+	}
+}
+
+func TestJSONDoc_MemoizesSharedPrefix(t *testing.T) {
+	doc := newJSONDoc([]byte(`{"db":{"host":"h","port":5432},"other":1}`))
+	for _, tc := range []struct{ path, want string }{{"db.host", "h"}, {"db.port", "5432"}, {"other", "1"}} {
+		got, err := doc.fragment(tc.path)
+		if err != nil || string(got) != tc.want {
+			t.Errorf("fragment(%q) = %q, %v, want %q", tc.path, got, err, tc.want)
+		}
+	}
+	if _, ok := doc.members[".db"]; !ok {
+		t.Error("object at shared prefix \"db\" not indexed")
+	}
+}
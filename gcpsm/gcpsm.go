@@ -3,12 +3,20 @@ package gcpsm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path"
+	"slices"
+	"strings"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 	"github.com/brwse/go-secrets"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -19,6 +27,34 @@ type Client interface {
 	Close() error
 }
 
+// CheckClient is implemented by Clients that can verify a secret exists
+// without accessing its payload. The default SDK client uses GetSecret.
+type CheckClient interface {
+	GetSecret(ctx context.Context, name string) error
+}
+
+// MetadataClient is implemented by Clients that can describe a secret without
+// accessing its payload. The default SDK client uses GetSecret and
+// GetSecretVersion on the latest version.
+type MetadataClient interface {
+	Metadata(ctx context.Context, name string) (secrets.Metadata, error)
+}
+
+// ListerClient is implemented by Clients that can enumerate secrets. The
+// default SDK client uses ListSecrets.
+type ListerClient interface {
+	// ListSecrets returns the IDs of the secrets in the project.
+	ListSecrets(ctx context.Context, project string) ([]string, error)
+}
+
+// WriterClient is implemented by Clients that can create, update, and delete
+// secrets. The default SDK client uses AddSecretVersion, first creating the
+// secret with automatic replication if it does not exist, and DeleteSecret.
+type WriterClient interface {
+	AddSecretVersion(ctx context.Context, name string, data []byte) error
+	DeleteSecret(ctx context.Context, name string) error
+}
+
 // ProviderOption configures the gcpsm Provider.
 type ProviderOption func(*Provider)
 
@@ -29,6 +65,70 @@ func WithProject(project string) ProviderOption {
 	}
 }
 
+// WithLocation reads and writes regional secrets stored in location, such as
+// "europe-west1", rather than global secrets: keys name secrets under
+// "projects/<project>/locations/<location>", and the default SDK client
+// connects to the location's regional endpoint unless WithEndpoint is given.
+func WithLocation(location string) ProviderOption {
+	return func(p *Provider) {
+		p.location = location
+	}
+}
+
+// WithEndpoint makes the default SDK client connect to endpoint, a
+// "host:port" address such as a Private Service Connect endpoint, instead of
+// the global or regional Secret Manager endpoint. It has no effect on a
+// Client injected with WithClient.
+func WithEndpoint(endpoint string) ProviderOption {
+	return func(p *Provider) {
+		p.endpoint = endpoint
+	}
+}
+
+// WithCredentialsFile makes the default SDK client authenticate with the
+// service account key, or other credential configuration such as a workload
+// identity federation config, in the JSON file at path, instead of
+// Application Default Credentials. Validate credential configurations from
+// external sources before use. It has no effect on a Client injected with
+// WithClient.
+func WithCredentialsFile(path string) ProviderOption {
+	return func(p *Provider) {
+		p.credentials = option.WithCredentialsFile(path)
+	}
+}
+
+// WithCredentialsJSON is like WithCredentialsFile but takes the credential
+// configuration itself.
+func WithCredentialsJSON(data []byte) ProviderOption {
+	return func(p *Provider) {
+		p.credentials = option.WithCredentialsJSON(data)
+	}
+}
+
+// WithImpersonateServiceAccount makes the default SDK client act as the
+// service account email, using short-lived tokens issued by the IAM
+// Credentials API to the provider's credentials (Application Default
+// Credentials, or those given with WithCredentialsFile or
+// WithCredentialsJSON), which need roles/iam.serviceAccountTokenCreator on
+// it. Tokens are refreshed before they expire. It has no effect on a Client
+// injected with WithClient.
+func WithImpersonateServiceAccount(email string) ProviderOption {
+	return func(p *Provider) {
+		p.impersonate = email
+	}
+}
+
+// WithUserAgent adds ua, such as "billing-api/1.4.2", to the User-Agent of
+// requests made by the default SDK client, for attribution in Cloud Audit
+// Logs. gRPC fixes the User-Agent when the connection is made, so the user
+// agent set on the Resolver with secrets.WithUserAgent does not apply to
+// this provider. It has no effect on a Client injected with WithClient.
+func WithUserAgent(ua string) ProviderOption {
+	return func(p *Provider) {
+		p.userAgent = ua
+	}
+}
+
 // WithClient injects a custom Client implementation.
 func WithClient(c Client) ProviderOption {
 	return func(p *Provider) {
@@ -37,13 +137,56 @@ func WithClient(c Client) ProviderOption {
 }
 
 // Provider reads secrets from GCP Secret Manager.
+// It implements secrets.Provider, secrets.VersionedProvider,
+// secrets.CheckerProvider, secrets.MetadataProvider, secrets.ListerProvider,
+// and secrets.WriterProvider.
+//
+// Keys are secret names in the configured project and location, or full
+// resource names of the form "projects/<project>/secrets/<name>" or
+// "projects/<project>/locations/<location>/secrets/<name>" to read secrets
+// from other projects.
 type Provider struct {
-	project string
-	client  Client
+	project   string
+	location  string
+	endpoint  string
+	userAgent string
+	// credentials replaces Application Default Credentials, and impersonate
+	// is the service account they act as, if set.
+	credentials option.ClientOption
+	impersonate string
+	client      Client
 }
 
-func (p *Provider) resourceName(key, version string) string {
-	return fmt.Sprintf("projects/%s/secrets/%s/versions/%s", p.project, key, version)
+// secretName returns the resource name of the secret for key.
+func (p *Provider) secretName(key string) (string, error) {
+	if !strings.HasPrefix(key, "projects/") {
+		return p.parent() + "/secrets/" + key, nil
+	}
+	parts := strings.Split(key, "/")
+	if len(parts) == 6 && parts[2] == "locations" && parts[3] != "" {
+		parts = append(parts[:2], parts[4:]...)
+	}
+	if len(parts) != 4 || parts[1] == "" || parts[2] != "secrets" || parts[3] == "" {
+		return "", fmt.Errorf("gcpsm: secret %q: invalid resource name: want projects/<project>/[locations/<location>/]secrets/<name>", key)
+	}
+	return key, nil
+}
+
+// parent returns the resource name of the project, or of the location in the
+// project, whose secrets keys name.
+func (p *Provider) parent() string {
+	if p.location != "" {
+		return fmt.Sprintf("projects/%s/locations/%s", p.project, p.location)
+	}
+	return "projects/" + p.project
+}
+
+func (p *Provider) resourceName(key, version string) (string, error) {
+	name, err := p.secretName(key)
+	if err != nil {
+		return "", err
+	}
+	return name + "/versions/" + version, nil
 }
 
 // New creates a new GCP Secret Manager Provider.
@@ -66,15 +209,55 @@ func New(opts ...ProviderOption) (*Provider, error) {
 		return nil, fmt.Errorf("gcpsm: project is required (use WithProject or set GOOGLE_CLOUD_PROJECT)")
 	}
 	if p.client == nil {
-		c, err := secretmanager.NewClient(context.Background())
+		credOpts, err := p.credentialOptions(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		c, err := secretmanager.NewClient(context.Background(), append(p.clientOptions(), credOpts...)...)
 		if err != nil {
 			return nil, fmt.Errorf("gcpsm: create Secret Manager client: %w", err)
 		}
-		p.client = &sdkClient{sm: c}
+		p.client = &sdkClient{sm: c, location: p.location}
 	}
 	return p, nil
 }
 
+// credentialOptions returns the SDK client options that select the
+// credentials for the provider options.
+func (p *Provider) credentialOptions(ctx context.Context) ([]option.ClientOption, error) {
+	var opts []option.ClientOption
+	if p.credentials != nil {
+		opts = append(opts, p.credentials)
+	}
+	if p.impersonate == "" {
+		return opts, nil
+	}
+	ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: p.impersonate,
+		Scopes:          secretmanager.DefaultAuthScopes(),
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("gcpsm: impersonate %s: %w", p.impersonate, err)
+	}
+	return []option.ClientOption{option.WithTokenSource(ts)}, nil
+}
+
+// clientOptions returns the SDK client options for the provider options.
+func (p *Provider) clientOptions() []option.ClientOption {
+	var opts []option.ClientOption
+	endpoint := p.endpoint
+	if endpoint == "" && p.location != "" {
+		endpoint = "secretmanager." + p.location + ".rep.googleapis.com:443"
+	}
+	if endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint))
+	}
+	if p.userAgent != "" {
+		opts = append(opts, option.WithUserAgent(p.userAgent))
+	}
+	return opts
+}
+
 // Get retrieves the latest version of the secret.
 func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
 	return p.GetVersion(ctx, key, "current")
@@ -86,22 +269,153 @@ func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte,
 	if v == "current" {
 		v = "latest"
 	}
-	name := p.resourceName(key, v)
+	name, err := p.resourceName(key, v)
+	if err != nil {
+		return nil, err
+	}
 	data, err := p.client.AccessSecretVersion(ctx, name)
 	if err != nil {
-		return nil, fmt.Errorf("gcpsm: secret %q version %q: %w", key, version, err)
+		return nil, fmt.Errorf("gcpsm: secret %q version %q: %w", key, version, providerError(err))
 	}
 	return data, nil
 }
 
+// GetMetadata describes the secret without accessing any version payload.
+// RotatedAt is the creation time of the latest version and Version its
+// number; ExpiresAt is the secret's expiration time, if any, and Tags its
+// labels. This requires secretmanager.secrets.get and
+// secretmanager.versions.get.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement
+// MetadataClient.
+func (p *Provider) GetMetadata(ctx context.Context, key string) (secrets.Metadata, error) {
+	mc, ok := p.client.(MetadataClient)
+	if !ok {
+		return secrets.Metadata{}, fmt.Errorf("gcpsm: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	name, err := p.secretName(key)
+	if err != nil {
+		return secrets.Metadata{}, err
+	}
+	md, err := mc.Metadata(ctx, name)
+	if err != nil {
+		return secrets.Metadata{}, fmt.Errorf("gcpsm: secret %q: %w", key, providerError(err))
+	}
+	return md, nil
+}
+
+// Check verifies the secret exists and is accessible by reading its metadata,
+// without accessing any version payload. This requires
+// secretmanager.secrets.get rather than secretmanager.versions.access.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement CheckClient.
+func (p *Provider) Check(ctx context.Context, key string) error {
+	cc, ok := p.client.(CheckClient)
+	if !ok {
+		return fmt.Errorf("gcpsm: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	name, err := p.secretName(key)
+	if err != nil {
+		return err
+	}
+	if err := cc.GetSecret(ctx, name); err != nil {
+		return fmt.Errorf("gcpsm: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// List returns the names of the secrets in the configured project, or
+// location, that start with prefix. This requires secretmanager.secrets.list.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// ListerClient.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	lc, ok := p.client.(ListerClient)
+	if !ok {
+		return nil, fmt.Errorf("gcpsm: list %q: %w", prefix, errors.ErrUnsupported)
+	}
+	ids, err := lc.ListSecrets(ctx, p.project)
+	if err != nil {
+		return nil, fmt.Errorf("gcpsm: list %q: %w", prefix, providerError(err))
+	}
+	var keys []string
+	for _, id := range ids {
+		if strings.HasPrefix(id, prefix) {
+			keys = append(keys, id)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Set adds value as a new version of the secret, which becomes its latest
+// version. A secret that does not exist is created first, with automatic
+// replication, or in the configured location for regional secrets. This requires secretmanager.versions.add, and
+// secretmanager.secrets.create for new secrets.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient.
+func (p *Provider) Set(ctx context.Context, key string, value []byte) error {
+	wc, ok := p.client.(WriterClient)
+	if !ok {
+		return fmt.Errorf("gcpsm: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	name, err := p.secretName(key)
+	if err != nil {
+		return err
+	}
+	if err := wc.AddSecretVersion(ctx, name, value); err != nil {
+		return fmt.Errorf("gcpsm: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// Delete deletes the secret and all of its versions.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient.
+func (p *Provider) Delete(ctx context.Context, key string) error {
+	wc, ok := p.client.(WriterClient)
+	if !ok {
+		return fmt.Errorf("gcpsm: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	name, err := p.secretName(key)
+	if err != nil {
+		return err
+	}
+	if err := wc.DeleteSecret(ctx, name); err != nil {
+		return fmt.Errorf("gcpsm: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
 // Close releases resources held by the provider.
 func (p *Provider) Close() error {
 	return p.client.Close()
 }
 
+// providerError wraps a gRPC error returned by Secret Manager in a
+// *secrets.ErrProvider carrying its status code and the request ID and
+// retry delay from its details. Other errors are returned unchanged.
+func providerError(err error) error {
+	s, ok := status.FromError(err)
+	if !ok || s.Code() == codes.OK {
+		return err
+	}
+	pe := &secrets.ErrProvider{Code: s.Code().String(), Err: err}
+	for _, d := range s.Details() {
+		switch d := d.(type) {
+		case *errdetails.RequestInfo:
+			pe.RequestID = d.GetRequestId()
+		case *errdetails.RetryInfo:
+			pe.RetryAfter = d.GetRetryDelay().AsDuration()
+		}
+	}
+	return pe
+}
+
 // sdkClient wraps the real GCP Secret Manager SDK.
 type sdkClient struct {
-	sm *secretmanager.Client
+	sm       *secretmanager.Client
+	location string // of the secrets ListSecrets lists; empty for global
 }
 
 func (c *sdkClient) AccessSecretVersion(ctx context.Context, name string) ([]byte, error) {
@@ -117,6 +431,117 @@ func (c *sdkClient) AccessSecretVersion(ctx context.Context, name string) ([]byt
 	return resp.Payload.Data, nil
 }
 
+func (c *sdkClient) GetSecret(ctx context.Context, name string) error {
+	_, err := c.sm.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{
+		Name: name,
+	})
+	if err != nil {
+		if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+			return fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *sdkClient) Metadata(ctx context.Context, name string) (secrets.Metadata, error) {
+	s, err := c.sm.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: name})
+	if err != nil {
+		if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+			return secrets.Metadata{}, fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return secrets.Metadata{}, err
+	}
+	v, err := c.sm.GetSecretVersion(ctx, &secretmanagerpb.GetSecretVersionRequest{
+		Name: name + "/versions/latest",
+	})
+	if err != nil {
+		if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+			return secrets.Metadata{}, fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return secrets.Metadata{}, err
+	}
+	md := secrets.Metadata{
+		Version: path.Base(v.GetName()),
+		Tags:    s.GetLabels(),
+	}
+	if s.GetCreateTime() != nil {
+		md.CreatedAt = s.GetCreateTime().AsTime()
+	}
+	if v.GetCreateTime() != nil {
+		md.RotatedAt = v.GetCreateTime().AsTime()
+	}
+	if s.GetExpireTime() != nil {
+		md.ExpiresAt = s.GetExpireTime().AsTime()
+	}
+	return md, nil
+}
+
+func (c *sdkClient) AddSecretVersion(ctx context.Context, name string, data []byte) error {
+	req := &secretmanagerpb.AddSecretVersionRequest{
+		Parent:  name,
+		Payload: &secretmanagerpb.SecretPayload{Data: data},
+	}
+	_, err := c.sm.AddSecretVersion(ctx, req)
+	if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+		parent, id, _ := strings.Cut(name, "/secrets/")
+		secret := &secretmanagerpb.Secret{}
+		// Regional secrets are stored in their location and take no
+		// replication policy.
+		if !strings.Contains(parent, "/locations/") {
+			secret.Replication = &secretmanagerpb.Replication{
+				Replication: &secretmanagerpb.Replication_Automatic_{
+					Automatic: &secretmanagerpb.Replication_Automatic{},
+				},
+			}
+		}
+		_, err = c.sm.CreateSecret(ctx, &secretmanagerpb.CreateSecretRequest{
+			Parent:   parent,
+			SecretId: id,
+			Secret:   secret,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = c.sm.AddSecretVersion(ctx, req)
+	}
+	return err
+}
+
+func (c *sdkClient) DeleteSecret(ctx context.Context, name string) error {
+	err := c.sm.DeleteSecret(ctx, &secretmanagerpb.DeleteSecretRequest{
+		Name: name,
+	})
+	if err != nil {
+		if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+			return fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *sdkClient) ListSecrets(ctx context.Context, project string) ([]string, error) {
+	var ids []string
+	parent := "projects/" + project
+	if c.location != "" {
+		parent += "/locations/" + c.location
+	}
+	it := c.sm.ListSecrets(ctx, &secretmanagerpb.ListSecretsRequest{
+		Parent: parent,
+	})
+	for {
+		s, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return ids, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, s.Name[strings.LastIndex(s.Name, "/")+1:])
+	}
+}
+
 func (c *sdkClient) Close() error {
 	return c.sm.Close()
 }
@@ -4,9 +4,24 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 	"github.com/brwse/go-secrets"
+	"google.golang.org/api/option"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // mockSMClient implements Client for testing.
@@ -27,6 +42,50 @@ func (m *mockSMClient) Close() error {
 	return nil
 }
 
+func (m *mockSMClient) GetSecret(_ context.Context, name string) error {
+	for resource := range m.secrets {
+		if strings.HasPrefix(resource, name+"/versions/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w", secrets.ErrNotFound)
+}
+
+func (m *mockSMClient) AddSecretVersion(_ context.Context, name string, data []byte) error {
+	if m.secrets == nil {
+		m.secrets = make(map[string][]byte)
+	}
+	m.secrets[name+"/versions/latest"] = data
+	return nil
+}
+
+func (m *mockSMClient) DeleteSecret(_ context.Context, name string) error {
+	found := false
+	for resource := range m.secrets {
+		if strings.HasPrefix(resource, name+"/versions/") {
+			delete(m.secrets, resource)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return nil
+}
+
+func (m *mockSMClient) ListSecrets(_ context.Context, project string) ([]string, error) {
+	seen := make(map[string]bool)
+	var ids []string
+	for resource := range m.secrets {
+		parts := strings.Split(resource, "/")
+		if parts[1] == project && !seen[parts[3]] {
+			seen[parts[3]] = true
+			ids = append(ids, parts[3])
+		}
+	}
+	return ids, nil
+}
+
 func TestGet_Existing(t *testing.T) {
 	mock := &mockSMClient{
 		secrets: map[string][]byte{
@@ -104,3 +163,464 @@ func TestNew_MissingProject(t *testing.T) {
 		t.Fatal("expected error for missing project, got nil")
 	}
 }
+
+func TestCheck(t *testing.T) {
+	mock := &mockSMClient{
+		secrets: map[string][]byte{
+			"projects/my-project/secrets/db-password/versions/latest": []byte("s3cret"),
+		},
+	}
+	p, err := New(WithProject("my-project"), WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "db-password"); err != nil {
+		t.Errorf("Check existing: %v", err)
+	}
+	if err := p.Check(context.Background(), "nonexistent"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check missing: expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestCheck_Unsupported(t *testing.T) {
+	// Embedding hides the mock's CheckClient method.
+	p, err := New(WithProject("my-project"), WithClient(struct{ Client }{&mockSMClient{}}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "db-password"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestGet_FullResourceName(t *testing.T) {
+	mock := &mockSMClient{
+		secrets: map[string][]byte{
+			"projects/other-project/secrets/shared-key/versions/latest": []byte("shared"),
+			"projects/other-project/secrets/shared-key/versions/2":      []byte("v2"),
+		},
+	}
+	p, err := New(WithProject("my-project"), WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	val, err := p.Get(context.Background(), "projects/other-project/secrets/shared-key")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(val) != "shared" {
+		t.Errorf("Get = %q, want %q", val, "shared")
+	}
+	val, err = p.GetVersion(context.Background(), "projects/other-project/secrets/shared-key", "2")
+	if err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+	if string(val) != "v2" {
+		t.Errorf("GetVersion = %q, want %q", val, "v2")
+	}
+	if err := p.Check(context.Background(), "projects/other-project/secrets/shared-key"); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+}
+
+func TestGet_InvalidResourceName(t *testing.T) {
+	p, err := New(WithProject("my-project"), WithClient(&mockSMClient{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for _, key := range []string{
+		"projects/other-project",
+		"projects//secrets/name",
+		"projects/other-project/configs/name",
+		"projects/other-project/secrets/name/versions/1",
+		"projects/other-project/locations//secrets/name",
+	} {
+		if _, err := p.Get(context.Background(), key); err == nil || !strings.Contains(err.Error(), "invalid resource name") {
+			t.Errorf("Get(%q): expected invalid resource name error, got: %v", key, err)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	mock := &mockSMClient{secrets: map[string][]byte{
+		"projects/my-project/secrets/db-password/versions/latest": []byte("a"),
+		"projects/my-project/secrets/db-password/versions/1":      []byte("a"),
+		"projects/my-project/secrets/api-key/versions/latest":     []byte("b"),
+		"projects/other-project/secrets/db-shared/versions/1":     []byte("c"),
+	}}
+	p, err := New(WithProject("my-project"), WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	got, err := p.List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := "api-key,db-password"; strings.Join(got, ",") != want {
+		t.Errorf("List = %q, want %q", got, want)
+	}
+	got, err = p.List(context.Background(), "db-")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := "db-password"; strings.Join(got, ",") != want {
+		t.Errorf("List(db-) = %q, want %q", got, want)
+	}
+
+	ro, _ := New(WithProject("my-project"), WithClient(struct{ Client }{mock}))
+	if _, err := ro.List(context.Background(), ""); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("List: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	mock := &mockSMClient{}
+	p, err := New(WithProject("my-project"), WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "api-key", []byte("s3cret")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := p.Get(ctx, "api-key"); err != nil || string(got) != "s3cret" {
+		t.Errorf("Get = %q, %v, want %q", got, err, "s3cret")
+	}
+	if err := p.Delete(ctx, "api-key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := p.Delete(ctx, "api-key"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Delete missing: expected ErrNotFound, got: %v", err)
+	}
+
+	ro, _ := New(WithProject("my-project"), WithClient(struct{ Client }{mock}))
+	if err := ro.Set(ctx, "api-key", nil); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Set: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+// writeServer is a Secret Manager server holding the payloads of existing
+// secrets by resource name.
+type writeServer struct {
+	secretmanagerpb.UnimplementedSecretManagerServiceServer
+	payloads map[string][]byte
+	created  []string
+}
+
+func (s *writeServer) CreateSecret(_ context.Context, req *secretmanagerpb.CreateSecretRequest) (*secretmanagerpb.Secret, error) {
+	regional := strings.Contains(req.GetParent(), "/locations/")
+	if regional && req.GetSecret().GetReplication() != nil {
+		return nil, status.Error(codes.InvalidArgument, "regional secrets take no replication")
+	}
+	if !regional && req.GetSecret().GetReplication().GetAutomatic() == nil {
+		return nil, status.Error(codes.InvalidArgument, "replication is required")
+	}
+	name := req.GetParent() + "/secrets/" + req.GetSecretId()
+	s.created = append(s.created, name)
+	s.payloads[name] = nil
+	return &secretmanagerpb.Secret{Name: name}, nil
+}
+
+func (s *writeServer) AddSecretVersion(_ context.Context, req *secretmanagerpb.AddSecretVersionRequest) (*secretmanagerpb.SecretVersion, error) {
+	if _, ok := s.payloads[req.GetParent()]; !ok {
+		return nil, status.Error(codes.NotFound, "secret not found")
+	}
+	s.payloads[req.GetParent()] = req.GetPayload().GetData()
+	return &secretmanagerpb.SecretVersion{Name: req.GetParent() + "/versions/1"}, nil
+}
+
+// newSDKProvider returns a Provider for project "my-project" whose SDK client
+// sends requests to server.
+func newSDKProvider(t *testing.T, server secretmanagerpb.SecretManagerServiceServer) *Provider {
+	t.Helper()
+	p, err := New(WithProject("my-project"), WithClient(&sdkClient{sm: dialServer(t, server)}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+// dialServer returns an SDK client that sends requests to server.
+func dialServer(t *testing.T, server secretmanagerpb.SecretManagerServiceServer) *secretmanager.Client {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	secretmanagerpb.RegisterSecretManagerServiceServer(srv, server)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	c, err := secretmanager.NewClient(context.Background(),
+		option.WithEndpoint(lis.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+func TestSDKClient_SetCreatesSecret(t *testing.T) {
+	ws := &writeServer{payloads: map[string][]byte{}}
+	p := newSDKProvider(t, ws)
+
+	if err := p.Set(context.Background(), "api-key", []byte("s3cret")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	const name = "projects/my-project/secrets/api-key"
+	if len(ws.created) != 1 || ws.created[0] != name {
+		t.Errorf("created = %q, want [%q]", ws.created, name)
+	}
+	if got := string(ws.payloads[name]); got != "s3cret" {
+		t.Errorf("payload = %q, want %q", got, "s3cret")
+	}
+}
+
+// metadataServer is a Secret Manager server holding one secret with two
+// versions.
+type metadataServer struct {
+	secretmanagerpb.UnimplementedSecretManagerServiceServer
+}
+
+func (s *metadataServer) GetSecret(_ context.Context, req *secretmanagerpb.GetSecretRequest) (*secretmanagerpb.Secret, error) {
+	if req.GetName() != "projects/my-project/secrets/db" {
+		return nil, status.Error(codes.NotFound, "secret not found")
+	}
+	return &secretmanagerpb.Secret{
+		Name:       req.GetName(),
+		CreateTime: timestamppb.New(time.Unix(1700000000, 0)),
+		Expiration: &secretmanagerpb.Secret_ExpireTime{ExpireTime: timestamppb.New(time.Unix(1720000000, 0))},
+		Labels:     map[string]string{"team": "billing"},
+	}, nil
+}
+
+func (s *metadataServer) GetSecretVersion(_ context.Context, req *secretmanagerpb.GetSecretVersionRequest) (*secretmanagerpb.SecretVersion, error) {
+	if req.GetName() != "projects/my-project/secrets/db/versions/latest" {
+		return nil, status.Errorf(codes.NotFound, "version %s not found", req.GetName())
+	}
+	return &secretmanagerpb.SecretVersion{
+		Name:       "projects/my-project/secrets/db/versions/2",
+		CreateTime: timestamppb.New(time.Unix(1710000000, 0)),
+	}, nil
+}
+
+func TestGetMetadata(t *testing.T) {
+	p := newSDKProvider(t, &metadataServer{})
+
+	md, err := p.GetMetadata(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if !md.CreatedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("CreatedAt = %v", md.CreatedAt)
+	}
+	if !md.RotatedAt.Equal(time.Unix(1710000000, 0)) {
+		t.Errorf("RotatedAt = %v, want the latest version's creation time", md.RotatedAt)
+	}
+	if !md.ExpiresAt.Equal(time.Unix(1720000000, 0)) {
+		t.Errorf("ExpiresAt = %v", md.ExpiresAt)
+	}
+	if md.Version != "2" {
+		t.Errorf("Version = %q, want 2", md.Version)
+	}
+	if md.Tags["team"] != "billing" {
+		t.Errorf("Tags = %v", md.Tags)
+	}
+
+	if _, err := p.GetMetadata(context.Background(), "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+// uaServer is a Secret Manager server that records the User-Agent of each
+// request.
+type uaServer struct {
+	secretmanagerpb.UnimplementedSecretManagerServiceServer
+	got []string
+}
+
+func (s *uaServer) AccessSecretVersion(ctx context.Context, _ *secretmanagerpb.AccessSecretVersionRequest) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.got = append(s.got, strings.Join(md.Get("user-agent"), " "))
+	return &secretmanagerpb.AccessSecretVersionResponse{
+		Payload: &secretmanagerpb.SecretPayload{Data: []byte("s3cret")},
+	}, nil
+}
+
+func TestWithUserAgent(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	us := &uaServer{}
+	secretmanagerpb.RegisterSecretManagerServiceServer(srv, us)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	p := &Provider{project: "my-project", userAgent: "billing-api/1.4.2"}
+	opts := append(p.clientOptions(),
+		option.WithEndpoint(lis.Addr().String()),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	c, err := secretmanager.NewClient(context.Background(), opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	p.client = &sdkClient{sm: c}
+	defer p.Close()
+
+	if _, err := p.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(us.got) != 1 || !strings.HasPrefix(us.got[0], "billing-api/1.4.2") {
+		t.Errorf("User-Agent = %q, want it to start with billing-api/1.4.2", us.got)
+	}
+}
+
+// deniedServer is a Secret Manager server that refuses every access.
+type deniedServer struct {
+	secretmanagerpb.UnimplementedSecretManagerServiceServer
+}
+
+func (s *deniedServer) AccessSecretVersion(context.Context, *secretmanagerpb.AccessSecretVersionRequest) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	st, err := status.New(codes.PermissionDenied, "permission denied on secret").WithDetails(
+		&errdetails.RequestInfo{RequestId: "req-123"},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(2 * time.Second)},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return nil, st.Err()
+}
+
+func TestSDKClient_ErrProvider(t *testing.T) {
+	p := newSDKProvider(t, &deniedServer{})
+
+	_, err := p.Get(context.Background(), "db")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected ErrProvider, got %v", err)
+	}
+	if pe.Code != "PermissionDenied" || pe.RequestID != "req-123" || pe.RetryAfter != 2*time.Second {
+		t.Errorf("ErrProvider = {%q %q %v}, want {\"PermissionDenied\" \"req-123\" 2s}", pe.Code, pe.RequestID, pe.RetryAfter)
+	}
+}
+
+func TestWithLocation(t *testing.T) {
+	mock := &mockSMClient{
+		secrets: map[string][]byte{
+			"projects/my-project/locations/europe-west1/secrets/db/versions/latest": []byte("regional"),
+			"projects/other/locations/us-east1/secrets/shared/versions/latest":      []byte("shared"),
+		},
+	}
+	p, err := New(WithProject("my-project"), WithLocation("europe-west1"), WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"db": "regional",
+		"projects/other/locations/us-east1/secrets/shared": "shared",
+	} {
+		if val, err := p.Get(context.Background(), key); err != nil || string(val) != want {
+			t.Errorf("Get(%q) = %q, %v; want %q", key, val, err, want)
+		}
+	}
+}
+
+func TestClientOptions_Endpoint(t *testing.T) {
+	for _, tc := range []struct {
+		p    Provider
+		want []option.ClientOption
+	}{
+		{Provider{}, nil},
+		{Provider{location: "europe-west1"}, []option.ClientOption{option.WithEndpoint("secretmanager.europe-west1.rep.googleapis.com:443")}},
+		{Provider{location: "europe-west1", endpoint: "sm.p.googleapis.com:443"}, []option.ClientOption{option.WithEndpoint("sm.p.googleapis.com:443")}},
+	} {
+		if got := tc.p.clientOptions(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("clientOptions(location %q, endpoint %q) = %v, want %v", tc.p.location, tc.p.endpoint, got, tc.want)
+		}
+	}
+}
+
+// listServer is a Secret Manager server that records the parent of
+// ListSecrets requests and lists one secret.
+type listServer struct {
+	secretmanagerpb.UnimplementedSecretManagerServiceServer
+	parents []string
+}
+
+func (s *listServer) ListSecrets(_ context.Context, req *secretmanagerpb.ListSecretsRequest) (*secretmanagerpb.ListSecretsResponse, error) {
+	s.parents = append(s.parents, req.GetParent())
+	return &secretmanagerpb.ListSecretsResponse{
+		Secrets: []*secretmanagerpb.Secret{{Name: req.GetParent() + "/secrets/db"}},
+	}, nil
+}
+
+func TestSDKClient_Regional(t *testing.T) {
+	ws := &writeServer{payloads: map[string][]byte{}}
+	p, err := New(WithProject("my-project"), WithLocation("europe-west1"),
+		WithClient(&sdkClient{sm: dialServer(t, ws), location: "europe-west1"}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	if err := p.Set(context.Background(), "api-key", []byte("s3cret")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	const name = "projects/my-project/locations/europe-west1/secrets/api-key"
+	if len(ws.created) != 1 || ws.created[0] != name {
+		t.Errorf("created = %q, want [%q]", ws.created, name)
+	}
+
+	ls := &listServer{}
+	p, err = New(WithProject("my-project"), WithLocation("europe-west1"),
+		WithClient(&sdkClient{sm: dialServer(t, ls), location: "europe-west1"}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	if keys, err := p.List(context.Background(), ""); err != nil || len(keys) != 1 || keys[0] != "db" {
+		t.Errorf("List = %q, %v; want [db]", keys, err)
+	}
+	if want := "projects/my-project/locations/europe-west1"; len(ls.parents) != 1 || ls.parents[0] != want {
+		t.Errorf("ListSecrets parents = %q, want [%q]", ls.parents, want)
+	}
+}
+
+func TestCredentialOptions(t *testing.T) {
+	ctx := context.Background()
+	var p Provider
+	if opts, err := p.credentialOptions(ctx); err != nil || opts != nil {
+		t.Errorf("default: credentialOptions = %v, %v; want none", opts, err)
+	}
+
+	WithCredentialsFile("/etc/gcp/key.json")(&p)
+	opts, err := p.credentialOptions(ctx)
+	if want := []option.ClientOption{option.WithCredentialsFile("/etc/gcp/key.json")}; err != nil || !reflect.DeepEqual(opts, want) {
+		t.Errorf("file: credentialOptions = %v, %v; want %v", opts, err, want)
+	}
+
+	// Impersonation wraps the given credentials in a token source.
+	WithCredentialsJSON([]byte(`{"type": "authorized_user", "client_id": "id", "client_secret": "s", "refresh_token": "r"}`))(&p)
+	WithImpersonateServiceAccount("reader@my-project.iam.gserviceaccount.com")(&p)
+	opts, err = p.credentialOptions(ctx)
+	if err != nil || len(opts) != 1 {
+		t.Fatalf("impersonate: credentialOptions = %v, %v; want one token source", opts, err)
+	}
+
+	WithCredentialsJSON([]byte(`{`))(&p)
+	if _, err := p.credentialOptions(ctx); err == nil || !strings.Contains(err.Error(), "gcpsm: impersonate reader@my-project.iam.gserviceaccount.com") {
+		t.Errorf("invalid credentials: expected an impersonation error, got %v", err)
+	}
+}
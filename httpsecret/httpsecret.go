@@ -0,0 +1,199 @@
+// Package httpsecret provides a secret provider that reads secrets from an
+// HTTP endpoint, for in-house secret services that have no SDK.
+package httpsecret
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// ProviderOption configures the httpsecret Provider.
+type ProviderOption func(*Provider)
+
+// WithHeader sets a header sent with every request, such as an API key
+// header of the service. It may be given more than once.
+func WithHeader(name, value string) ProviderOption {
+	return func(p *Provider) {
+		p.header.Set(name, value)
+	}
+}
+
+// WithBearerToken authenticates requests with an "Authorization: Bearer"
+// header.
+func WithBearerToken(token string) ProviderOption {
+	return func(p *Provider) {
+		p.header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// WithBasicAuth authenticates requests with HTTP Basic authentication.
+func WithBasicAuth(username, password string) ProviderOption {
+	return func(p *Provider) {
+		cred := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		p.header.Set("Authorization", "Basic "+cred)
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the default HTTP client, such
+// as the root CAs of an internal service or a client certificate. It cannot
+// be combined with WithHTTPClient.
+func WithTLSConfig(cfg *tls.Config) ProviderOption {
+	return func(p *Provider) {
+		p.tlsConfig = cfg
+	}
+}
+
+// WithHTTPClient sets the HTTP client requests are sent with. Defaults to
+// http.DefaultClient.
+func WithHTTPClient(c *http.Client) ProviderOption {
+	return func(p *Provider) {
+		p.httpClient = c
+	}
+}
+
+// WithJSONPath extracts the value at path from each JSON response, rather
+// than returning the whole body. Paths take the form "$.data.value",
+// "$.items[0].secret", or "$['key.with.dots']". String values are returned
+// without quotes; other values as JSON.
+func WithJSONPath(path string) ProviderOption {
+	return func(p *Provider) {
+		p.jsonPath = path
+	}
+}
+
+// Provider reads secrets with HTTP GET requests.
+// It implements secrets.Provider.
+//
+// Keys are paths relative to the base URL given to New: with the base URL
+// "https://secrets.internal/v1/secrets", the key "prod/db" is read from
+// "https://secrets.internal/v1/secrets/prod/db". A 404 response means the
+// secret does not exist; any other response outside 2xx is an error.
+type Provider struct {
+	base       *url.URL
+	header     http.Header
+	tlsConfig  *tls.Config
+	httpClient *http.Client
+	jsonPath   string
+	path       []pathStep
+}
+
+// New creates a new Provider that reads secrets under baseURL, an http or
+// https URL.
+func New(baseURL string, opts ...ProviderOption) (*Provider, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("httpsecret: base URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("httpsecret: base URL %q: want an http or https URL", baseURL)
+	}
+	p := &Provider{base: u, header: make(http.Header)}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.tlsConfig != nil {
+		if p.httpClient != nil {
+			return nil, errors.New("httpsecret: WithTLSConfig cannot be combined with WithHTTPClient")
+		}
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = p.tlsConfig
+		p.httpClient = &http.Client{Transport: t}
+	}
+	if p.httpClient == nil {
+		p.httpClient = http.DefaultClient
+	}
+	if p.jsonPath != "" {
+		if p.path, err = parseJSONPath(p.jsonPath); err != nil {
+			return nil, fmt.Errorf("httpsecret: JSONPath %q: %w", p.jsonPath, err)
+		}
+	}
+	return p, nil
+}
+
+// Get retrieves the secret at key.
+// Returns secrets.ErrNotFound (wrapped) if the endpoint responds 404, or if
+// the JSONPath selects nothing.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	u, err := p.url(key)
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.get(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("httpsecret: secret %q: %w", key, err)
+	}
+	if p.path == nil {
+		return body, nil
+	}
+	val, err := extract(body, p.path)
+	if err != nil {
+		return nil, fmt.Errorf("httpsecret: secret %q: %s: %w", key, p.jsonPath, err)
+	}
+	return val, nil
+}
+
+// url returns the URL of key, which must not climb out of the base URL.
+func (p *Provider) url(key string) (string, error) {
+	segments := strings.Split(strings.TrimPrefix(key, "/"), "/")
+	if key == "" || slices.ContainsFunc(segments, func(s string) bool { return s == "" || s == "." || s == ".." }) {
+		return "", fmt.Errorf("httpsecret: invalid key %q: want a relative path such as prod/db", key)
+	}
+	return p.base.JoinPath(segments...).String(), nil
+}
+
+// get sends a GET request for u and returns the response body.
+func (p *Provider) get(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range p.header {
+		req.Header[name] = values
+	}
+	if ua := secrets.UserAgentFromContext(ctx); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, responseError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// responseError returns the error described by a failed response:
+// secrets.ErrNotFound for 404, and a *secrets.ErrProvider otherwise.
+func responseError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", resp.Status, secrets.ErrNotFound)
+	}
+	msg := resp.Status
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if s := strings.TrimSpace(string(body)); s != "" {
+		msg += ": " + s
+	}
+	pe := &secrets.ErrProvider{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+		Err:        errors.New(msg),
+	}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		pe.RetryAfter = time.Duration(s) * time.Second
+	}
+	return pe
+}
@@ -0,0 +1,165 @@
+package httpsecret
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// newServer serves secrets, paths to JSON bodies, requiring the header
+// want if it is not empty.
+func newServer(t *testing.T, tls bool, want string, secrets map[string]string) *httptest.Server {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want != "" && r.Header.Get("Authorization") != want {
+			w.Header().Set("X-Request-Id", "req-1")
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		body, ok := secrets[r.URL.EscapedPath()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	})
+	var srv *httptest.Server
+	if tls {
+		srv = httptest.NewTLSServer(h)
+	} else {
+		srv = httptest.NewServer(h)
+	}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGet(t *testing.T) {
+	srv := newServer(t, false, "Bearer t0k", map[string]string{
+		"/v1/secrets/prod/db":     `{"data": {"value": "hunter2", "port": 5432}}`,
+		"/v1/secrets/a%20b":       `raw`,
+		"/v1/secrets/prod/list":   `{"items": [{"secret": "first"}, {"secret": "second"}]}`,
+		"/v1/secrets/prod/dotted": `{"key.with.dots": "d"}`,
+	})
+	ctx := context.Background()
+
+	p, err := New(srv.URL+"/v1/secrets/", WithBearerToken("t0k"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got, err := p.Get(ctx, "a b")
+	if err != nil || string(got) != "raw" {
+		t.Errorf("Get(a b) = %q, %v; want raw", got, err)
+	}
+	if _, err := p.Get(ctx, "prod/missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	tests := []struct {
+		path, key, want string
+	}{
+		{"$.data.value", "prod/db", "hunter2"},
+		{"$.data.port", "prod/db", "5432"},
+		{"$['data']", "prod/db", `{"port":5432,"value":"hunter2"}`},
+		{"$.items[1].secret", "prod/list", "second"},
+		{`$["key.with.dots"]`, "prod/dotted", "d"},
+	}
+	for _, tt := range tests {
+		p, err := New(srv.URL+"/v1/secrets", WithBearerToken("t0k"), WithJSONPath(tt.path))
+		if err != nil {
+			t.Fatalf("New(%s): %v", tt.path, err)
+		}
+		got, err := p.Get(ctx, tt.key)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("Get(%s) with %s = %q, %v; want %q", tt.key, tt.path, got, err, tt.want)
+		}
+	}
+
+	p, _ = New(srv.URL+"/v1/secrets", WithBearerToken("t0k"), WithJSONPath("$.data.missing"))
+	if _, err := p.Get(ctx, "prod/db"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get with a JSONPath that selects nothing: error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGet_Auth(t *testing.T) {
+	srv := newServer(t, false, "Basic dXNlcjpwYXNz", map[string]string{"/db": "p"})
+	ctx := context.Background()
+
+	p, _ := New(srv.URL, WithBasicAuth("user", "pass"))
+	if got, err := p.Get(ctx, "db"); err != nil || string(got) != "p" {
+		t.Errorf("Get with basic auth = %q, %v", got, err)
+	}
+	p, _ = New(srv.URL, WithHeader("Authorization", "Basic dXNlcjpwYXNz"))
+	if got, err := p.Get(ctx, "db"); err != nil || string(got) != "p" {
+		t.Errorf("Get with header = %q, %v", got, err)
+	}
+
+	p, _ = New(srv.URL, WithBearerToken("wrong"))
+	_, err := p.Get(ctx, "db")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.StatusCode != http.StatusForbidden || pe.RequestID != "req-1" {
+		t.Errorf("Get with wrong credentials: error = %v, want ErrProvider with status 403 and request ID", err)
+	}
+}
+
+func TestGet_RetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	p, _ := New(srv.URL)
+	_, err := p.Get(context.Background(), "db")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.RetryAfter != 7*time.Second {
+		t.Errorf("error = %v, want ErrProvider with RetryAfter 7s", err)
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	srv := newServer(t, true, "", map[string]string{"/db": "p"})
+	ctx := context.Background()
+
+	p, _ := New(srv.URL)
+	if _, err := p.Get(ctx, "db"); err == nil {
+		t.Error("Get succeeded without trusting the test certificate")
+	}
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	p, err := New(srv.URL, WithTLSConfig(&tls.Config{RootCAs: roots}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got, err := p.Get(ctx, "db"); err != nil || string(got) != "p" {
+		t.Errorf("Get = %q, %v", got, err)
+	}
+	if _, err := New(srv.URL, WithTLSConfig(&tls.Config{}), WithHTTPClient(srv.Client())); err == nil {
+		t.Error("New accepted WithTLSConfig with WithHTTPClient")
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for _, u := range []string{"", "ftp://host/x", "/relative", "http://"} {
+		if _, err := New(u); err == nil {
+			t.Errorf("New(%q) succeeded", u)
+		}
+	}
+	for _, path := range []string{"data", "$.", "$[0", "$[x]", "$.a b["} {
+		if _, err := New("https://host", WithJSONPath(path)); err == nil {
+			t.Errorf("New with JSONPath %q succeeded", path)
+		}
+	}
+}
+
+func TestGet_InvalidKey(t *testing.T) {
+	p, _ := New("https://secrets.internal/v1")
+	for _, key := range []string{"", "../admin", "prod/../../admin", "prod//db", "prod/"} {
+		if _, err := p.Get(context.Background(), key); err == nil || errors.Is(err, secrets.ErrNotFound) {
+			t.Errorf("Get(%q) error = %v, want an invalid key error", key, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package httpsecret
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+)
+
+// pathStep is one step of a JSONPath: an object member, or an array index
+// if name is empty.
+type pathStep struct {
+	name  string
+	index int
+}
+
+// parseJSONPath parses the subset of JSONPath WithJSONPath accepts: "$"
+// followed by ".name", "['name']", and "[index]" steps.
+func parseJSONPath(path string) ([]pathStep, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, errors.New(`must start with "$"`)
+	}
+	steps := []pathStep{}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, errors.New("empty member name")
+			}
+			steps = append(steps, pathStep{name: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, errors.New(`missing "]"`)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, pathStep{name: inner[1 : len(inner)-1]})
+				continue
+			}
+			i, err := strconv.Atoi(inner)
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid index %q", inner)
+			}
+			steps = append(steps, pathStep{index: i})
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest[0])
+		}
+	}
+	return steps, nil
+}
+
+// extract returns the value at path in the JSON document data: strings
+// unquoted, and other values as JSON.
+func extract(data []byte, path []pathStep) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	for _, step := range path {
+		switch x := v.(type) {
+		case map[string]any:
+			m, ok := x[step.name]
+			if step.name == "" || !ok {
+				return nil, fmt.Errorf("no value: %w", secrets.ErrNotFound)
+			}
+			v = m
+		case []any:
+			if step.name != "" || step.index >= len(x) {
+				return nil, fmt.Errorf("no value: %w", secrets.ErrNotFound)
+			}
+			v = x[step.index]
+		default:
+			return nil, fmt.Errorf("no value: %w", secrets.ErrNotFound)
+		}
+	}
+	if s, ok := v.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,62 @@
+package httpsecret
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []pathStep
+	}{
+		{"$", []pathStep{}},
+		{"$.a.b", []pathStep{{name: "a"}, {name: "b"}}},
+		{"$.items[2].v", []pathStep{{name: "items"}, {index: 2}, {name: "v"}}},
+		{`$['a.b']["c"]`, []pathStep{{name: "a.b"}, {name: "c"}}},
+	}
+	for _, tt := range tests {
+		got, err := parseJSONPath(tt.path)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseJSONPath(%q) = %v, %v; want %v", tt.path, got, err, tt.want)
+		}
+	}
+	for _, path := range []string{"", "a.b", "$..a", "$[-1]", "$['a'", "$x"} {
+		if _, err := parseJSONPath(path); err == nil {
+			t.Errorf("parseJSONPath(%q) succeeded", path)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	doc := []byte(`{"s": "v", "n": 12345678901234567890, "b": true, "o": {"k": null}, "a": [1, "two"]}`)
+	tests := []struct {
+		path, want string
+	}{
+		{"$.s", "v"},
+		{"$.n", "12345678901234567890"},
+		{"$.b", "true"},
+		{"$.o", `{"k":null}`},
+		{"$.o.k", "null"},
+		{"$.a[1]", "two"},
+	}
+	for _, tt := range tests {
+		steps, _ := parseJSONPath(tt.path)
+		got, err := extract(doc, steps)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("extract(%s) = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+	for _, path := range []string{"$.missing", "$.a[2]", "$.s.x", "$.a.x", "$.o[0]"} {
+		steps, _ := parseJSONPath(path)
+		if _, err := extract(doc, steps); !errors.Is(err, secrets.ErrNotFound) {
+			t.Errorf("extract(%s) error = %v, want ErrNotFound", path, err)
+		}
+	}
+	if _, err := extract([]byte("not json"), nil); err == nil || errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("extract of invalid JSON: error = %v", err)
+	}
+}
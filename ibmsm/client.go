@@ -0,0 +1,191 @@
+package ibmsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// apiClient reads secrets through the Secrets Manager v2 REST API.
+type apiClient struct {
+	host   string // instance URL
+	iamURL string
+	apiKey string
+	http   *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// secretResponse is the part of a secret or secret version that GetSecret
+// reads.
+type secretResponse struct {
+	ID         string         `json:"id"`
+	SecretID   string         `json:"secret_id"` // of versions
+	SecretType string         `json:"secret_type"`
+	Payload    string         `json:"payload"`
+	Data       map[string]any `json:"data"`
+	APIKey     string         `json:"api_key"`
+	APIKeyID   string         `json:"api_key_id"`
+	ServiceID  string         `json:"service_id"`
+	Username   string         `json:"username"`
+	Password   string         `json:"password"`
+}
+
+// GetSecret reads the secret by ID or by name. Versions other than the
+// current one are read by ID, so a secret named by name is looked up first.
+func (c *apiClient) GetSecret(ctx context.Context, id, group, secretType, name, version string) (Secret, error) {
+	var out secretResponse
+	if id == "" {
+		path := "/api/v2/secret_groups/" + url.PathEscape(group) + "/secret_types/" + url.PathEscape(secretType) + "/secrets/" + url.PathEscape(name)
+		if err := c.do(ctx, http.MethodGet, path, &out); err != nil {
+			return Secret{}, err
+		}
+		if version == "current" {
+			return out.secret(), nil
+		}
+		id = out.ID
+	}
+	path := "/api/v2/secrets/" + url.PathEscape(id)
+	if version != "current" {
+		path += "/versions/" + url.PathEscape(version)
+	}
+	out = secretResponse{}
+	if err := c.do(ctx, http.MethodGet, path, &out); err != nil {
+		return Secret{}, err
+	}
+	return out.secret(), nil
+}
+
+func (r secretResponse) secret() Secret {
+	id := r.ID
+	if r.SecretID != "" {
+		id = r.SecretID
+	}
+	return Secret{
+		ID:        id,
+		Type:      r.SecretType,
+		Payload:   r.Payload,
+		Data:      r.Data,
+		APIKey:    r.APIKey,
+		APIKeyID:  r.APIKeyID,
+		ServiceID: r.ServiceID,
+		Username:  r.Username,
+		Password:  r.Password,
+	}
+}
+
+// accessToken returns the IAM access token to authenticate requests with,
+// exchanging the API key for a new one if there is none or it is about to
+// expire.
+func (c *apiClient) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Until(c.expires) > time.Minute {
+		return c.token, nil
+	}
+	form := url.Values{
+		"grant_type": {"urn:ibm:params:oauth:grant-type:apikey"},
+		"apikey":     {c.apiKey},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.iamURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("get IAM token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get IAM token: %w", apiError(resp))
+	}
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"` // seconds
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("get IAM token: %w", err)
+	}
+	if out.AccessToken == "" {
+		return "", errors.New("get IAM token: response has no access token")
+	}
+	c.token = out.AccessToken
+	c.expires = time.Now().Add(time.Duration(out.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+// do sends an authenticated request and decodes the JSON response into out.
+func (c *apiClient) do(ctx context.Context, method, path string, out any) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.host+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if ua := secrets.UserAgentFromContext(ctx); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// apiError returns the error described by a failed response:
+// secrets.ErrNotFound for 404, and a *secrets.ErrProvider otherwise.
+// Secrets Manager reports errors as {"errors": [{"code", "message"}],
+// "trace"}, and IAM as {"errorCode", "errorMessage"}.
+func apiError(resp *http.Response) error {
+	var body struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+		Trace        string `json:"trace"`
+		ErrorCode    string `json:"errorCode"`
+		ErrorMessage string `json:"errorMessage"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	code, msg := body.ErrorCode, body.ErrorMessage
+	if len(body.Errors) > 0 {
+		code, msg = body.Errors[0].Code, body.Errors[0].Message
+	}
+	if msg == "" {
+		msg = resp.Status
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", msg, secrets.ErrNotFound)
+	}
+	pe := &secrets.ErrProvider{
+		StatusCode: resp.StatusCode,
+		Code:       code,
+		RequestID:  body.Trace,
+		Err:        errors.New(msg),
+	}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		pe.RetryAfter = time.Duration(s) * time.Second
+	}
+	return pe
+}
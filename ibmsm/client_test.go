@@ -0,0 +1,93 @@
+package ibmsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// newTestServer fakes IAM and a Secrets Manager instance holding an
+// arbitrary secret "api-token" with ID "s1" and two versions.
+func newTestServer(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	var logins atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/identity/token" {
+			if r.FormValue("apikey") != "key" || r.FormValue("grant_type") != "urn:ibm:params:oauth:grant-type:apikey" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"errorCode": "BXNIM0415E", "errorMessage": "Provided API key could not be found."}`)
+				return
+			}
+			logins.Add(1)
+			fmt.Fprint(w, `{"access_token": "iam-token", "expires_in": 3600}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer iam-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"errors": [{"code": "unauthorized", "message": "Unauthorized"}], "trace": "trace-1"}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v2/secret_groups/default/secret_types/arbitrary/secrets/api-token", "/api/v2/secrets/s1":
+			fmt.Fprint(w, `{"id": "s1", "secret_type": "arbitrary", "payload": "tok-2"}`)
+		case "/api/v2/secrets/s1/versions/previous":
+			fmt.Fprint(w, `{"id": "v1", "secret_id": "s1", "secret_type": "arbitrary", "payload": "tok-1"}`)
+		case "/api/v2/secrets/throttled":
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"errors": [{"code": "too_many_requests", "message": "slow down"}], "trace": "trace-2"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors": [{"code": "not_found", "message": "Secret not found"}], "trace": "trace-3"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &logins
+}
+
+func TestAPIClient(t *testing.T) {
+	srv, logins := newTestServer(t)
+	p, err := New(WithInstanceURL(srv.URL+"/"), WithAPIKey("key"), WithIAMURL(srv.URL+"/identity/token"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{"s1", "arbitrary/api-token"} {
+		if got, err := p.Get(ctx, key); err != nil || string(got) != "tok-2" {
+			t.Errorf("Get(%s) = %q, %v; want tok-2", key, got, err)
+		}
+	}
+	if got, err := p.GetVersion(ctx, "arbitrary/api-token", "previous"); err != nil || string(got) != "tok-1" {
+		t.Errorf("GetVersion(previous) = %q, %v; want tok-1", got, err)
+	}
+	if n := logins.Load(); n != 1 {
+		t.Errorf("IAM token requests = %d, want 1 (cached)", n)
+	}
+
+	if _, err := p.Get(ctx, "arbitrary/missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+	_, err = p.Get(ctx, "throttled")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.StatusCode != http.StatusTooManyRequests || pe.Code != "too_many_requests" ||
+		pe.RequestID != "trace-2" || pe.RetryAfter != 3*time.Second {
+		t.Errorf("Get(throttled) error = %v, want ErrProvider with code, trace, and Retry-After", err)
+	}
+}
+
+func TestAPIClient_BadAPIKey(t *testing.T) {
+	srv, _ := newTestServer(t)
+	p, _ := New(WithInstanceURL(srv.URL), WithAPIKey("wrong"), WithIAMURL(srv.URL+"/identity/token"))
+	_, err := p.Get(context.Background(), "s1")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.Code != "BXNIM0415E" {
+		t.Errorf("error = %v, want ErrProvider with the IAM error code", err)
+	}
+}
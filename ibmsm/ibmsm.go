@@ -0,0 +1,213 @@
+// Package ibmsm provides a secret provider that reads from IBM Cloud Secrets
+// Manager.
+package ibmsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// DefaultIAMURL is the IBM Cloud IAM token endpoint.
+const DefaultIAMURL = "https://iam.cloud.ibm.com/identity/token"
+
+// Secret is a version of a Secrets Manager secret. Only the fields of its
+// type are set.
+type Secret struct {
+	ID   string
+	Type string // "arbitrary", "kv", "iam_credentials", or "username_password"
+
+	Payload string         // arbitrary
+	Data    map[string]any // kv
+
+	APIKey    string // iam_credentials
+	APIKeyID  string
+	ServiceID string
+
+	Username string // username_password
+	Password string
+}
+
+// Client abstracts the Secrets Manager API.
+// Implement this interface to provide a custom client or for testing.
+type Client interface {
+	// GetSecret returns a version of a secret: "current", "previous", or a
+	// version ID. The secret is the one with ID id or, if id is empty, the
+	// one of secretType named name in the secret group group.
+	GetSecret(ctx context.Context, id, group, secretType, name, version string) (Secret, error)
+}
+
+// ProviderOption configures the ibmsm Provider.
+type ProviderOption func(*Provider)
+
+// WithInstanceURL sets the URL of the Secrets Manager instance, such as
+// "https://<instance-id>.us-south.secrets-manager.appdomain.cloud".
+// Defaults to the SECRETS_MANAGER_URL environment variable.
+func WithInstanceURL(url string) ProviderOption {
+	return func(p *Provider) {
+		p.instanceURL = url
+	}
+}
+
+// WithAPIKey authenticates with an IBM Cloud API key, exchanged for IAM
+// access tokens as needed. Defaults to the SECRETS_MANAGER_APIKEY
+// environment variable.
+func WithAPIKey(apiKey string) ProviderOption {
+	return func(p *Provider) {
+		p.apiKey = apiKey
+	}
+}
+
+// WithIAMURL sets the IAM token endpoint API keys are exchanged at.
+// Defaults to the SECRETS_MANAGER_AUTH_URL environment variable, or
+// DefaultIAMURL.
+func WithIAMURL(url string) ProviderOption {
+	return func(p *Provider) {
+		p.iamURL = url
+	}
+}
+
+// WithHTTPClient sets the HTTP client of the default client.
+func WithHTTPClient(c *http.Client) ProviderOption {
+	return func(p *Provider) {
+		p.httpClient = c
+	}
+}
+
+// WithClient injects a custom Client implementation.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider reads secrets from IBM Cloud Secrets Manager.
+// It implements secrets.Provider and secrets.VersionedProvider.
+//
+// Keys are secret IDs, or a secret type and name, optionally preceded by a
+// secret group name, which defaults to "default":
+//
+//	0b5571f7-21e6-42b7-91c5-3f5ac9793a46
+//	arbitrary/api-token
+//	billing/kv/db-config
+//
+// Arbitrary secrets are returned as stored. Key-value secrets are returned
+// as a JSON object of their data, IAM credentials as {"api_key",
+// "api_key_id", "service_id"}, and user credentials as {"username",
+// "password"}, so that fields can be read with fragments.
+type Provider struct {
+	instanceURL string
+	apiKey      string
+	iamURL      string
+	httpClient  *http.Client
+	client      Client
+}
+
+// New creates a new IBM Cloud Secrets Manager Provider.
+// An instance URL and API key are required when not providing a custom
+// Client via WithClient.
+func New(opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		if p.instanceURL == "" {
+			p.instanceURL = os.Getenv("SECRETS_MANAGER_URL")
+		}
+		if p.instanceURL == "" {
+			return nil, errors.New("ibmsm: instance URL is required (use WithInstanceURL or SECRETS_MANAGER_URL)")
+		}
+		if p.apiKey == "" {
+			p.apiKey = os.Getenv("SECRETS_MANAGER_APIKEY")
+		}
+		if p.apiKey == "" {
+			return nil, errors.New("ibmsm: API key is required (use WithAPIKey or SECRETS_MANAGER_APIKEY)")
+		}
+		if p.iamURL == "" {
+			p.iamURL = os.Getenv("SECRETS_MANAGER_AUTH_URL")
+		}
+		if p.iamURL == "" {
+			p.iamURL = DefaultIAMURL
+		}
+		if p.httpClient == nil {
+			p.httpClient = http.DefaultClient
+		}
+		p.client = &apiClient{
+			host:   strings.TrimSuffix(p.instanceURL, "/"),
+			iamURL: p.iamURL,
+			apiKey: p.apiKey,
+			http:   p.httpClient,
+		}
+	}
+	return p, nil
+}
+
+// Get retrieves the current version of the secret.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.GetVersion(ctx, key, "current")
+}
+
+// GetVersion retrieves a version of the secret: "current", "previous", or a
+// version ID.
+// Returns secrets.ErrNotFound (wrapped) if the secret or version does not
+// exist.
+func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	if version == "" {
+		return nil, fmt.Errorf("ibmsm: secret %q: unsupported version %q", key, version)
+	}
+	id, group, secretType, name, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	s, err := p.client.GetSecret(ctx, id, group, secretType, name, version)
+	if err != nil {
+		if version == "current" {
+			return nil, fmt.Errorf("ibmsm: secret %q: %w", key, err)
+		}
+		return nil, fmt.Errorf("ibmsm: secret %q version %q: %w", key, version, err)
+	}
+	val, err := s.value()
+	if err != nil {
+		return nil, fmt.Errorf("ibmsm: secret %q: %w", key, err)
+	}
+	return val, nil
+}
+
+// parseKey splits key into a secret ID, or the group, type, and name of a
+// secret.
+func parseKey(key string) (id, group, secretType, name string, err error) {
+	parts := strings.Split(key, "/")
+	switch {
+	case len(parts) == 1 && key != "":
+		return key, "", "", "", nil
+	case len(parts) == 2:
+		group, secretType, name = "default", parts[0], parts[1]
+	case len(parts) == 3:
+		group, secretType, name = parts[0], parts[1], parts[2]
+	}
+	if group == "" || secretType == "" || name == "" {
+		return "", "", "", "", fmt.Errorf("ibmsm: invalid key %q: want <id> or [<group>/]<type>/<name>", key)
+	}
+	return "", group, secretType, name, nil
+}
+
+// value returns the value of s as Provider documents it.
+func (s Secret) value() ([]byte, error) {
+	switch s.Type {
+	case "arbitrary":
+		return []byte(s.Payload), nil
+	case "kv":
+		return json.Marshal(s.Data)
+	case "iam_credentials":
+		return json.Marshal(map[string]string{"api_key": s.APIKey, "api_key_id": s.APIKeyID, "service_id": s.ServiceID})
+	case "username_password":
+		return json.Marshal(map[string]string{"username": s.Username, "password": s.Password})
+	}
+	return nil, fmt.Errorf("unsupported secret type %q", s.Type)
+}
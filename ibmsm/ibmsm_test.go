@@ -0,0 +1,103 @@
+package ibmsm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// fakeClient serves secrets by ID and by group/type/name.
+type fakeClient struct {
+	byID   map[string]Secret
+	byName map[string]string // group/type/name -> ID
+	calls  []string
+}
+
+func (c *fakeClient) GetSecret(_ context.Context, id, group, secretType, name, version string) (Secret, error) {
+	c.calls = append(c.calls, id+"|"+group+"/"+secretType+"/"+name+"|"+version)
+	if id == "" {
+		id = c.byName[group+"/"+secretType+"/"+name]
+	}
+	s, ok := c.byID[id+"@"+version]
+	if !ok {
+		return Secret{}, secrets.ErrNotFound
+	}
+	return s, nil
+}
+
+func TestGetVersion(t *testing.T) {
+	fc := &fakeClient{
+		byID: map[string]Secret{
+			"a1@current":  {Type: "arbitrary", Payload: "tok-2"},
+			"a1@previous": {Type: "arbitrary", Payload: "tok-1"},
+			"k1@current":  {Type: "kv", Data: map[string]any{"host": "db", "port": float64(5432)}},
+			"i1@current":  {Type: "iam_credentials", APIKey: "key", APIKeyID: "ApiKey-1", ServiceID: "ServiceId-1"},
+			"u1@current":  {Type: "username_password", Username: "app", Password: "pw"},
+			"c1@current":  {Type: "public_cert"},
+		},
+		byName: map[string]string{
+			"default/arbitrary/api-token":  "a1",
+			"billing/kv/db-config":         "k1",
+			"default/iam_credentials/ci":   "i1",
+			"default/username_password/db": "u1",
+		},
+	}
+	p, err := New(WithClient(fc))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		key, version, want string
+	}{
+		{"a1", "current", "tok-2"},
+		{"arbitrary/api-token", "current", "tok-2"},
+		{"arbitrary/api-token", "previous", "tok-1"},
+		{"billing/kv/db-config", "current", `{"host":"db","port":5432}`},
+		{"iam_credentials/ci", "current", `{"api_key":"key","api_key_id":"ApiKey-1","service_id":"ServiceId-1"}`},
+		{"username_password/db", "current", `{"password":"pw","username":"app"}`},
+	}
+	for _, tt := range tests {
+		got, err := p.GetVersion(ctx, tt.key, tt.version)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("GetVersion(%s, %s) = %q, %v; want %q", tt.key, tt.version, got, err, tt.want)
+		}
+	}
+	if fc.calls[1] != "|default/arbitrary/api-token|current" {
+		t.Errorf("call = %q, want a lookup by name in the default group", fc.calls[1])
+	}
+
+	if _, err := p.Get(ctx, "arbitrary/missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, err := p.Get(ctx, "c1"); err == nil {
+		t.Error("Get of an unsupported secret type succeeded")
+	}
+	for _, key := range []string{"", "a/b/c/d", "/arbitrary/x", "arbitrary/"} {
+		if _, err := p.Get(ctx, key); err == nil || errors.Is(err, secrets.ErrNotFound) {
+			t.Errorf("Get(%q) error = %v, want an invalid key error", key, err)
+		}
+	}
+	if _, err := p.GetVersion(ctx, "a1", ""); err == nil {
+		t.Error("GetVersion with an empty version succeeded")
+	}
+}
+
+func TestNew_RequiresConfig(t *testing.T) {
+	t.Setenv("SECRETS_MANAGER_URL", "")
+	t.Setenv("SECRETS_MANAGER_APIKEY", "")
+	if _, err := New(); err == nil {
+		t.Error("New succeeded without an instance URL")
+	}
+	if _, err := New(WithInstanceURL("https://sm.example")); err == nil {
+		t.Error("New succeeded without an API key")
+	}
+	t.Setenv("SECRETS_MANAGER_URL", "https://sm.example")
+	t.Setenv("SECRETS_MANAGER_APIKEY", "k")
+	if _, err := New(); err != nil {
+		t.Errorf("New from the environment: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package infisical
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// apiClient reads secrets through the Infisical REST API.
+type apiClient struct {
+	host         string // base URL, such as "https://app.infisical.com"
+	projectID    string
+	clientID     string // Universal Auth credentials; empty with a static token
+	clientSecret string
+	http         *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time // zero for a static token
+}
+
+// GetSecret reads a secret with the raw secrets endpoint.
+func (c *apiClient) GetSecret(ctx context.Context, environment, path, name string, version int) (string, int, error) {
+	query := url.Values{
+		"workspaceId": {c.projectID},
+		"environment": {environment},
+		"secretPath":  {path},
+	}
+	if version > 0 {
+		query.Set("version", strconv.Itoa(version))
+	}
+	var body struct {
+		Secret struct {
+			SecretValue string `json:"secretValue"`
+			Version     int    `json:"version"`
+		} `json:"secret"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v3/secrets/raw/"+url.PathEscape(name)+"?"+query.Encode(), nil, &body); err != nil {
+		return "", 0, err
+	}
+	return body.Secret.SecretValue, body.Secret.Version, nil
+}
+
+// accessToken returns the token to authenticate requests with, logging in
+// with Universal Auth if there is none or it is about to expire.
+func (c *apiClient) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.clientID == "" || (c.token != "" && time.Until(c.expires) > time.Minute) {
+		return c.token, nil
+	}
+	in := map[string]string{"clientId": c.clientID, "clientSecret": c.clientSecret}
+	var out struct {
+		AccessToken string `json:"accessToken"`
+		ExpiresIn   int    `json:"expiresIn"` // seconds
+	}
+	if err := c.send(ctx, http.MethodPost, "/api/v1/auth/universal-auth/login", "", in, &out); err != nil {
+		return "", fmt.Errorf("log in: %w", err)
+	}
+	if out.AccessToken == "" {
+		return "", errors.New("log in: response has no access token")
+	}
+	c.token = out.AccessToken
+	c.expires = time.Now().Add(time.Duration(out.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+// do sends an authenticated request and decodes the JSON response into out.
+func (c *apiClient) do(ctx context.Context, method, path string, in, out any) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, method, path, token, in, out)
+}
+
+// send sends a request with the JSON body in, if not nil, and decodes the
+// JSON response into out.
+func (c *apiClient) send(ctx context.Context, method, path, token string, in, out any) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.host+path, &body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if ua := secrets.UserAgentFromContext(ctx); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return apiError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// apiError returns the error described by a failed response:
+// secrets.ErrNotFound for 404, and a *secrets.ErrProvider otherwise.
+func apiError(resp *http.Response) error {
+	var body struct {
+		ReqID   string `json:"reqId"`
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	msg := body.Message
+	if msg == "" {
+		msg = resp.Status
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", msg, secrets.ErrNotFound)
+	}
+	pe := &secrets.ErrProvider{
+		StatusCode: resp.StatusCode,
+		Code:       body.Error,
+		RequestID:  body.ReqID,
+		Err:        errors.New(msg),
+	}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		pe.RetryAfter = time.Duration(s) * time.Second
+	}
+	return pe
+}
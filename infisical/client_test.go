@@ -0,0 +1,137 @@
+package infisical
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// fakeServer fakes the Universal Auth login and raw secret endpoints of
+// Infisical, issuing tokens that expire after expiresIn seconds.
+type fakeServer struct {
+	*httptest.Server
+	logins atomic.Int64
+}
+
+func newFakeServer(t *testing.T, expiresIn int) *fakeServer {
+	s := &fakeServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/auth/universal-auth/login" {
+			var in struct{ ClientID, ClientSecret string }
+			json.NewDecoder(r.Body).Decode(&in)
+			if in.ClientID != "id" || in.ClientSecret != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"reqId": "req-1", "error": "UnauthorizedError", "message": "invalid credentials"}`)
+				return
+			}
+			n := s.logins.Add(1)
+			fmt.Fprintf(w, `{"accessToken": "t%d", "expiresIn": %d}`, n, expiresIn)
+			return
+		}
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer t%d", s.logins.Load()) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		if r.URL.Path != "/api/v3/secrets/raw/DB_PASSWORD" || q.Get("workspaceId") != "proj" ||
+			q.Get("environment") != "prod" || q.Get("secretPath") != "/billing" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"reqId": "req-2", "error": "NotFound", "message": "Secret not found"}`)
+			return
+		}
+		version := 3
+		if v := q.Get("version"); v != "" {
+			fmt.Sscan(v, &version)
+		}
+		fmt.Fprintf(w, `{"secret": {"secretKey": "DB_PASSWORD", "secretValue": "v%d", "version": %d}}`, version, version)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestAPIClient(t *testing.T) {
+	srv := newFakeServer(t, 3600)
+	p, err := New(WithHost(srv.URL+"/"), WithProjectID("proj"), WithUniversalAuth("id", "secret"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	got, err := p.Get(ctx, "prod:billing/DB_PASSWORD")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != "v3" {
+		t.Errorf("Get = %q, want v3", got)
+	}
+	got, err = p.GetVersion(ctx, "prod:billing/DB_PASSWORD", "previous")
+	if err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+	if string(got) != "v2" {
+		t.Errorf("GetVersion(previous) = %q, want v2", got)
+	}
+	if n := srv.logins.Load(); n != 1 {
+		t.Errorf("logins = %d, want 1", n)
+	}
+
+	if _, err := p.Get(ctx, "prod:billing/OTHER"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("missing secret: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestAPIClient_Relogin(t *testing.T) {
+	srv := newFakeServer(t, 30) // within a minute of expiry from the start
+	p, err := New(WithHost(srv.URL), WithProjectID("proj"), WithUniversalAuth("id", "secret"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for range 2 {
+		if _, err := p.Get(context.Background(), "prod:billing/DB_PASSWORD"); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+	if n := srv.logins.Load(); n != 2 {
+		t.Errorf("logins = %d, want 2", n)
+	}
+}
+
+func TestAPIClient_LoginError(t *testing.T) {
+	srv := newFakeServer(t, 3600)
+	p, err := New(WithHost(srv.URL), WithProjectID("proj"), WithUniversalAuth("id", "wrong"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = p.Get(context.Background(), "prod:billing/DB_PASSWORD")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) {
+		t.Fatalf("got %v, want an ErrProvider", err)
+	}
+	if pe.StatusCode != http.StatusUnauthorized || pe.Code != "UnauthorizedError" || pe.RequestID != "req-1" {
+		t.Errorf("ErrProvider = %+v", pe)
+	}
+}
+
+func TestAPIError_RetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	p, err := New(WithHost(srv.URL), WithProjectID("proj"), WithAccessToken("t"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = p.Get(context.Background(), "API_KEY")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.StatusCode != http.StatusTooManyRequests || pe.RetryAfter != 5*time.Second {
+		t.Errorf("got %v, want a 429 ErrProvider retrying after 5s", err)
+	}
+}
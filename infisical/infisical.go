@@ -0,0 +1,206 @@
+// Package infisical provides a secret provider that reads from Infisical.
+package infisical
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+)
+
+// DefaultHost is the Infisical Cloud API.
+const DefaultHost = "https://app.infisical.com"
+
+// Client abstracts the Infisical secrets API.
+type Client interface {
+	// GetSecret returns the value and version of the secret name in the
+	// folder path of environment: the latest version if version is zero.
+	GetSecret(ctx context.Context, environment, path, name string, version int) (value string, ver int, err error)
+}
+
+// ProviderOption configures the infisical Provider.
+type ProviderOption func(*Provider)
+
+// WithHost sets the URL of the Infisical instance. Defaults to the
+// INFISICAL_API_URL environment variable, or DefaultHost.
+func WithHost(url string) ProviderOption {
+	return func(p *Provider) {
+		p.host = url
+	}
+}
+
+// WithProjectID sets the ID of the project secrets are read from. Required.
+func WithProjectID(id string) ProviderOption {
+	return func(p *Provider) {
+		p.projectID = id
+	}
+}
+
+// WithEnvironment sets the slug of the environment, such as "prod", of keys
+// that do not name one. Defaults to "dev".
+func WithEnvironment(slug string) ProviderOption {
+	return func(p *Provider) {
+		p.environment = slug
+	}
+}
+
+// WithUniversalAuth authenticates as a machine identity with Universal Auth
+// credentials. The provider logs in on first use and again before the
+// access token expires. Defaults to the INFISICAL_UNIVERSAL_AUTH_CLIENT_ID
+// and INFISICAL_UNIVERSAL_AUTH_CLIENT_SECRET environment variables.
+func WithUniversalAuth(clientID, clientSecret string) ProviderOption {
+	return func(p *Provider) {
+		p.clientID = clientID
+		p.clientSecret = clientSecret
+	}
+}
+
+// WithAccessToken authenticates with an access token obtained elsewhere,
+// such as from another auth method. Defaults to the INFISICAL_TOKEN
+// environment variable when no Universal Auth credentials are given.
+func WithAccessToken(token string) ProviderOption {
+	return func(p *Provider) {
+		p.accessToken = token
+	}
+}
+
+// WithHTTPClient sets the HTTP client of the default client.
+func WithHTTPClient(c *http.Client) ProviderOption {
+	return func(p *Provider) {
+		p.httpClient = c
+	}
+}
+
+// WithClient injects a custom Client implementation.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider reads secrets from Infisical.
+// It implements secrets.Provider and secrets.VersionedProvider.
+//
+// Keys are secret names, optionally under a folder path, and optionally
+// prefixed by an environment slug and a colon:
+//
+//	API_KEY             // /API_KEY in the default environment
+//	billing/DB_PASSWORD // /billing/DB_PASSWORD in the default environment
+//	prod:billing/DB_PASSWORD
+type Provider struct {
+	host         string
+	projectID    string
+	environment  string
+	clientID     string
+	clientSecret string
+	accessToken  string
+	httpClient   *http.Client
+	client       Client
+}
+
+// New creates a new Infisical Provider.
+// WithProjectID and credentials are required when not providing a custom
+// Client via WithClient.
+func New(opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{environment: "dev"}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		if p.projectID == "" {
+			return nil, errors.New("infisical: project ID is required (use WithProjectID)")
+		}
+		if p.host == "" {
+			p.host = os.Getenv("INFISICAL_API_URL")
+		}
+		if p.host == "" {
+			p.host = DefaultHost
+		}
+		if p.clientID == "" && p.accessToken == "" {
+			p.clientID = os.Getenv("INFISICAL_UNIVERSAL_AUTH_CLIENT_ID")
+			p.clientSecret = os.Getenv("INFISICAL_UNIVERSAL_AUTH_CLIENT_SECRET")
+			if p.clientID == "" {
+				p.accessToken = os.Getenv("INFISICAL_TOKEN")
+			}
+		}
+		if p.clientID == "" && p.accessToken == "" {
+			return nil, errors.New("infisical: credentials are required (use WithUniversalAuth or WithAccessToken)")
+		}
+		if p.httpClient == nil {
+			p.httpClient = http.DefaultClient
+		}
+		p.client = &apiClient{
+			host:         strings.TrimSuffix(p.host, "/"),
+			projectID:    p.projectID,
+			clientID:     p.clientID,
+			clientSecret: p.clientSecret,
+			token:        p.accessToken,
+			http:         p.httpClient,
+		}
+	}
+	return p, nil
+}
+
+// Get retrieves the latest version of the secret.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.GetVersion(ctx, key, "current")
+}
+
+// GetVersion retrieves a version of the secret: "current", "previous", or a
+// version number.
+func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	env, path, name, err := p.parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	var v int
+	switch version {
+	case "current":
+	case "previous":
+		_, current, err := p.client.GetSecret(ctx, env, path, name, 0)
+		if err != nil {
+			return nil, fmt.Errorf("infisical: secret %q version %q: %w", key, version, err)
+		}
+		if current <= 1 {
+			return nil, fmt.Errorf("infisical: secret %q version %q: %w", key, version, secrets.ErrNotFound)
+		}
+		v = current - 1
+	default:
+		v, err = strconv.Atoi(version)
+		if err != nil || v < 1 {
+			return nil, fmt.Errorf("infisical: secret %q: unsupported version %q", key, version)
+		}
+	}
+	val, _, err := p.client.GetSecret(ctx, env, path, name, v)
+	if err != nil {
+		if version == "current" {
+			return nil, fmt.Errorf("infisical: secret %q: %w", key, err)
+		}
+		return nil, fmt.Errorf("infisical: secret %q version %q: %w", key, version, err)
+	}
+	return []byte(val), nil
+}
+
+// parseKey splits "[env:][path/]name" into its parts, path being the
+// absolute folder path.
+func (p *Provider) parseKey(key string) (env, path, name string, err error) {
+	env, rest := p.environment, key
+	if e, r, ok := strings.Cut(key, ":"); ok {
+		env, rest = e, r
+	}
+	rest = strings.TrimPrefix(rest, "/")
+	dir, name := "", rest
+	if i := strings.LastIndex(rest, "/"); i >= 0 {
+		dir, name = rest[:i], rest[i+1:]
+	}
+	if env == "" || name == "" || strings.Contains(env, "/") {
+		return "", "", "", fmt.Errorf("infisical: invalid key %q: want [environment:][path/]name", key)
+	}
+	return env, "/" + dir, name, nil
+}
@@ -0,0 +1,151 @@
+package infisical
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// mockClient implements Client for testing.
+type mockClient struct {
+	// versions maps "env:path/name" to the values of its versions, oldest
+	// first.
+	versions map[string][]string
+}
+
+func (m *mockClient) GetSecret(_ context.Context, env, path, name string, version int) (string, int, error) {
+	vs, ok := m.versions[env+":"+strings.TrimSuffix(path, "/")+"/"+name]
+	if !ok || version > len(vs) {
+		return "", 0, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	if version == 0 {
+		version = len(vs)
+	}
+	return vs[version-1], version, nil
+}
+
+var _ secrets.VersionedProvider = (*Provider)(nil)
+
+func newTestProvider(t *testing.T, opts ...ProviderOption) *Provider {
+	t.Helper()
+	m := &mockClient{versions: map[string][]string{
+		"dev:/API_KEY":              {"k1"},
+		"dev:/billing/DB_PASSWORD":  {"d1", "d2"},
+		"prod:/billing/DB_PASSWORD": {"p1", "p2", "p3"},
+	}}
+	p, err := New(append([]ProviderOption{WithClient(m)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+func TestGet(t *testing.T) {
+	p := newTestProvider(t)
+	ctx := context.Background()
+	for key, want := range map[string]string{
+		"API_KEY":                   "k1",
+		"/API_KEY":                  "k1",
+		"billing/DB_PASSWORD":       "d2",
+		"prod:billing/DB_PASSWORD":  "p3",
+		"prod:/billing/DB_PASSWORD": "p3",
+	} {
+		got, err := p.Get(ctx, key)
+		if err != nil {
+			t.Errorf("Get(%q): %v", key, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("Get(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestGet_Missing(t *testing.T) {
+	p := newTestProvider(t)
+	_, err := p.Get(context.Background(), "prod:API_KEY")
+	if !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestGet_InvalidKey(t *testing.T) {
+	p := newTestProvider(t)
+	for _, key := range []string{"", "billing/", ":API_KEY", "a/b:API_KEY"} {
+		if _, err := p.Get(context.Background(), key); err == nil {
+			t.Errorf("Get(%q) succeeded, want an error", key)
+		}
+	}
+}
+
+func TestWithEnvironment(t *testing.T) {
+	p := newTestProvider(t, WithEnvironment("prod"))
+	got, err := p.Get(context.Background(), "billing/DB_PASSWORD")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != "p3" {
+		t.Errorf("Get = %q, want p3", got)
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	p := newTestProvider(t)
+	ctx := context.Background()
+	tests := []struct {
+		key, version, want string
+	}{
+		{"prod:billing/DB_PASSWORD", "current", "p3"},
+		{"prod:billing/DB_PASSWORD", "previous", "p2"},
+		{"prod:billing/DB_PASSWORD", "1", "p1"},
+		{"billing/DB_PASSWORD", "previous", "d1"},
+	}
+	for _, tt := range tests {
+		got, err := p.GetVersion(ctx, tt.key, tt.version)
+		if err != nil {
+			t.Errorf("GetVersion(%q, %q): %v", tt.key, tt.version, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("GetVersion(%q, %q) = %q, want %q", tt.key, tt.version, got, tt.want)
+		}
+	}
+
+	if _, err := p.GetVersion(ctx, "API_KEY", "previous"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("previous of a first version: got %v, want ErrNotFound", err)
+	}
+	if _, err := p.GetVersion(ctx, "API_KEY", "7"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("missing version: got %v, want ErrNotFound", err)
+	}
+	for _, v := range []string{"", "0", "latest"} {
+		if _, err := p.GetVersion(ctx, "API_KEY", v); err == nil {
+			t.Errorf("GetVersion(%q) succeeded, want an error", v)
+		}
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	t.Setenv("INFISICAL_UNIVERSAL_AUTH_CLIENT_ID", "")
+	t.Setenv("INFISICAL_UNIVERSAL_AUTH_CLIENT_SECRET", "")
+	t.Setenv("INFISICAL_TOKEN", "")
+	if _, err := New(WithAccessToken("t")); err == nil {
+		t.Error("New without a project ID succeeded")
+	}
+	if _, err := New(WithProjectID("p")); err == nil {
+		t.Error("New without credentials succeeded")
+	}
+
+	t.Setenv("INFISICAL_TOKEN", "from-env")
+	p, err := New(WithProjectID("p"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c := p.client.(*apiClient)
+	if c.token != "from-env" || c.host != DefaultHost {
+		t.Errorf("token, host = %q, %q; want from-env, %q", c.token, c.host, DefaultHost)
+	}
+}
@@ -0,0 +1,239 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/brwse/go-secrets"
+	corev1 "k8s.io/api/core/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// syncTimeout bounds how long a subscription waits for a new informer's
+// cache to sync.
+const syncTimeout = time.Minute
+
+// informerSet runs one shared informer per namespace and kind of object
+// watched, notifying subscribers of changes and serving reads from the
+// informers' caches once they have synced.
+type informerSet struct {
+	clientset kubernetes.Interface
+
+	mu        sync.Mutex
+	informers map[informerKey]*namespaceInformer
+	closed    bool
+}
+
+// informerKey identifies an informer by the resource, "secrets" or
+// "configmaps", and namespace it watches.
+type informerKey struct {
+	resource, namespace string
+}
+
+// namespaceInformer is the informer of one namespace and its subscribers.
+type namespaceInformer struct {
+	informer cache.SharedIndexInformer
+	stop     chan struct{}
+	synced   chan struct{} // closed once the cache has synced
+	failed   chan error    // receives the first list or watch error
+
+	mu   sync.Mutex
+	subs map[string]map[chan struct{}]bool // object name -> subscribers
+}
+
+func newInformerSet(clientset kubernetes.Interface) *informerSet {
+	return &informerSet{clientset: clientset, informers: make(map[informerKey]*namespaceInformer)}
+}
+
+// subscribe returns a channel that receives a value whenever the object
+// namespace/name of resource is added, updated, or deleted. It starts the
+// namespace's informer if needed and waits for its cache to sync. The channel
+// is closed when ctx is done or the set is closed.
+func (s *informerSet) subscribe(ctx context.Context, resource, namespace, name string) (<-chan struct{}, error) {
+	startCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+	ni, err := s.start(startCtx, informerKey{resource, namespace})
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan struct{}, 1)
+	ni.mu.Lock()
+	if ni.subs[name] == nil {
+		ni.subs[name] = make(map[chan struct{}]bool)
+	}
+	ni.subs[name][ch] = true
+	ni.mu.Unlock()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-ni.stop:
+		}
+		ni.mu.Lock()
+		defer ni.mu.Unlock()
+		delete(ni.subs[name], ch)
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// start returns the running informer for key, starting one if there is none,
+// once its cache has synced. An informer that fails to list or watch before
+// syncing, for lack of permission for example, is stopped, so that a later
+// call tries again.
+func (s *informerSet) start(ctx context.Context, key informerKey) (*namespaceInformer, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, errors.New("provider is closed")
+	}
+	ni, ok := s.informers[key]
+	if !ok {
+		ni = s.newInformer(key)
+		s.informers[key] = ni
+		go ni.informer.Run(ni.stop)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-ni.synced:
+		return ni, nil
+	case err := <-ni.failed:
+		select {
+		case <-ni.synced:
+			return ni, nil
+		default:
+		}
+		s.remove(key, ni)
+		return nil, providerError(err)
+	case <-ni.stop:
+		return nil, errors.New("informer stopped before its cache synced")
+	case <-ctx.Done():
+		if !ok {
+			s.remove(key, ni)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (s *informerSet) newInformer(key informerKey) *namespaceInformer {
+	ni := &namespaceInformer{
+		stop:   make(chan struct{}),
+		synced: make(chan struct{}),
+		failed: make(chan error, 1),
+		subs:   make(map[string]map[chan struct{}]bool),
+	}
+	if key.resource == configMapsResource.Resource {
+		ni.informer = coreinformers.NewConfigMapInformer(s.clientset, key.namespace, 0, cache.Indexers{})
+	} else {
+		ni.informer = coreinformers.NewSecretInformer(s.clientset, key.namespace, 0, cache.Indexers{})
+	}
+	ni.informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		select {
+		case <-ni.synced:
+			return // the informer retries on its own
+		default:
+		}
+		select {
+		case ni.failed <- err:
+		default:
+		}
+	})
+	ni.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    ni.notify,
+		UpdateFunc: func(_, obj any) { ni.notify(obj) },
+		DeleteFunc: ni.notify,
+	})
+	go func() {
+		if cache.WaitForCacheSync(ni.stop, ni.informer.HasSynced) {
+			close(ni.synced)
+		}
+	}()
+	return ni
+}
+
+// remove stops ni and forgets it if it is still the informer for key.
+func (s *informerSet) remove(key informerKey, ni *namespaceInformer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.informers[key] == ni {
+		delete(s.informers, key)
+		close(ni.stop)
+	}
+}
+
+// notify signals the subscribers of obj, dropping the signal for subscribers
+// that have yet to receive the previous one.
+func (ni *namespaceInformer) notify(obj any) {
+	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	o, ok := obj.(interface{ GetName() string })
+	if !ok {
+		return
+	}
+	ni.mu.Lock()
+	defer ni.mu.Unlock()
+	for ch := range ni.subs[o.GetName()] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// get returns the data of the object namespace/name of resource from the
+// informer cache. ok is false if no synced informer watches the namespace.
+func (s *informerSet) get(resource, namespace, name string) (data map[string][]byte, ok bool, err error) {
+	s.mu.Lock()
+	ni := s.informers[informerKey{resource, namespace}]
+	s.mu.Unlock()
+	if ni == nil {
+		return nil, false, nil
+	}
+	select {
+	case <-ni.synced:
+	default:
+		return nil, false, nil
+	}
+	obj, exists, err := ni.informer.GetStore().GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, true, err
+	}
+	if !exists {
+		return nil, true, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		return o.Data, true, nil
+	case *corev1.ConfigMap:
+		return configMapData(o), true, nil
+	}
+	return nil, false, nil
+}
+
+// close stops every informer, which closes their subscriptions.
+func (s *informerSet) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for key, ni := range s.informers {
+		close(ni.stop)
+		delete(s.informers, key)
+	}
+}
+
+// configMapData returns the data and binary data of cm as one map.
+func configMapData(cm *corev1.ConfigMap) map[string][]byte {
+	data := make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+	for k, v := range cm.Data {
+		data[k] = []byte(v)
+	}
+	maps.Copy(data, cm.BinaryData)
+	return data
+}
@@ -0,0 +1,141 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newInformerProvider returns a Provider whose default client, with
+// informers, talks to a fake clientset holding objects.
+func newInformerProvider(objects ...runtime.Object) (*Provider, *fake.Clientset) {
+	cs := fake.NewClientset(objects...)
+	return &Provider{client: &k8sClient{clientset: cs, informers: newInformerSet(cs)}}, cs
+}
+
+// receive returns the next value on ch, failing after 5 seconds.
+func receive(t *testing.T, ch <-chan []byte) ([]byte, bool) {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		return v, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a change")
+		return nil, false
+	}
+}
+
+func TestWatch_Informer(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod"},
+		Data:       map[string][]byte{"password": []byte("v1")},
+	}
+	p, cs := newInformerProvider(secret)
+	defer p.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := p.Watch(ctx, "prod/db/password")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	// Reads are served from the informer's cache.
+	cs.ClearActions()
+	if v, err := p.Get(ctx, "prod/db/password"); err != nil || string(v) != "v1" {
+		t.Errorf("Get = %q, %v; want v1", v, err)
+	}
+	for _, a := range cs.Actions() {
+		if a.GetVerb() == "get" {
+			t.Errorf("Get made a %s request, want it served from the cache", a.GetVerb())
+		}
+	}
+
+	updated := secret.DeepCopy()
+	updated.Data["password"] = []byte("v2")
+	if _, err := cs.CoreV1().Secrets("prod").Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := receive(t, ch); string(v) != "v2" {
+		t.Errorf("pushed %q, want v2", v)
+	}
+
+	if err := cs.CoreV1().Secrets("prod").Delete(ctx, "db", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := receive(t, ch); !ok || v != nil {
+		t.Errorf("pushed %q, %v after deletion; want nil", v, ok)
+	}
+	if _, err := p.Get(ctx, "prod/db"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get after deletion: expected ErrNotFound, got: %v", err)
+	}
+
+	p.Close()
+	if _, ok := receive(t, ch); ok {
+		t.Error("channel still open after Close")
+	}
+}
+
+func TestWatch_InformerConfigMap(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "prod"},
+		Data:       map[string]string{"log_level": "info"},
+	}
+	p, cs := newInformerProvider(cm)
+	p.configMaps = true
+	defer p.Close()
+	ctx := context.Background()
+
+	ch, err := p.Watch(ctx, "prod/app")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	updated := cm.DeepCopy()
+	updated.Data["log_level"] = "debug"
+	if _, err := cs.CoreV1().ConfigMaps("prod").Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := receive(t, ch); string(v) != `{"log_level":"debug"}` {
+		t.Errorf("pushed %s", v)
+	}
+}
+
+func TestWatch_InformerForbidden(t *testing.T) {
+	p, cs := newInformerProvider()
+	defer p.Close()
+	cs.PrependReactor("list", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", errors.New("no list"))
+	})
+
+	_, err := p.Watch(context.Background(), "prod/db")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.StatusCode != http.StatusForbidden {
+		t.Errorf("Watch: err = %v, want a 403 ErrProvider", err)
+	}
+	if n := len(p.client.(*k8sClient).informers.informers); n != 0 {
+		t.Errorf("%d informers left running, want the failed one stopped", n)
+	}
+}
+
+func TestWatch_Unsupported(t *testing.T) {
+	cs := fake.NewClientset()
+	for _, p := range []*Provider{
+		{client: &k8sClient{clientset: cs}},
+		{client: struct{ Client }{&k8sClient{clientset: cs}}},
+	} {
+		if _, err := p.Watch(context.Background(), "prod/db"); !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("Watch: expected ErrUnsupported, got: %v", err)
+		}
+	}
+}
@@ -1,16 +1,26 @@
-// Package k8s provides a secret provider that reads from Kubernetes Secrets.
+// Package k8s provides a secret provider that reads from Kubernetes Secrets
+// or ConfigMaps.
 package k8s
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/brwse/go-secrets"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -21,6 +31,64 @@ type Client interface {
 	GetSecret(ctx context.Context, namespace, name string) (map[string][]byte, error)
 }
 
+// CheckClient is implemented by Clients that can verify a Secret exists
+// without retrieving its data. The default client fetches only the Secret's
+// object metadata.
+type CheckClient interface {
+	GetSecretMetadata(ctx context.Context, namespace, name string) error
+}
+
+// MetadataClient is implemented by Clients that can describe a Secret without
+// retrieving its data. The default client fetches only the Secret's object
+// metadata.
+type MetadataClient interface {
+	SecretMetadata(ctx context.Context, namespace, name string) (secrets.Metadata, error)
+}
+
+// ListerClient is implemented by Clients that can enumerate Secrets. The
+// default client lists only the Secrets' object metadata.
+type ListerClient interface {
+	// ListSecrets returns the keys, of the form "namespace/name", of the
+	// Secrets in namespace, or in all namespaces if namespace is empty.
+	ListSecrets(ctx context.Context, namespace string) ([]string, error)
+}
+
+// WriterClient is implemented by Clients that can write and delete Secrets.
+type WriterClient interface {
+	// PutSecret replaces the data of the Secret, creating an Opaque Secret
+	// if it does not exist.
+	PutSecret(ctx context.Context, namespace, name string, data map[string][]byte) error
+	DeleteSecret(ctx context.Context, namespace, name string) error
+}
+
+// ConfigMapClient is implemented by Clients that can read ConfigMaps, as a
+// Provider created with WithConfigMaps requires. The default client
+// implements it.
+type ConfigMapClient interface {
+	// GetConfigMap returns the data and binary data of the ConfigMap.
+	GetConfigMap(ctx context.Context, namespace, name string) (map[string][]byte, error)
+	// ConfigMapMetadata describes the ConfigMap from its object metadata.
+	ConfigMapMetadata(ctx context.Context, namespace, name string) (secrets.Metadata, error)
+	// ListConfigMaps returns the keys, of the form "namespace/name", of the
+	// ConfigMaps in namespace, or in all namespaces if namespace is empty.
+	ListConfigMaps(ctx context.Context, namespace string) ([]string, error)
+}
+
+// WatchClient is implemented by Clients that can notify of changes to
+// Secrets. The default client implements it with shared informers if created
+// with WithInformers.
+type WatchClient interface {
+	// WatchSecret returns a channel that receives a value each time the
+	// Secret is created, updated, or deleted. The channel is closed when ctx
+	// is done or the client stops watching.
+	WatchSecret(ctx context.Context, namespace, name string) (<-chan struct{}, error)
+}
+
+// ConfigMapWatchClient is the WatchClient counterpart for ConfigMaps.
+type ConfigMapWatchClient interface {
+	WatchConfigMap(ctx context.Context, namespace, name string) (<-chan struct{}, error)
+}
+
 // ProviderOption configures the k8s Provider.
 type ProviderOption func(*Provider)
 
@@ -45,12 +113,116 @@ func WithContext(name string) ProviderOption {
 	}
 }
 
-// Provider reads secrets from Kubernetes Secrets.
-// It implements secrets.Provider.
+// WithImpersonation makes requests on behalf of user, as a member of groups,
+// using the Kubernetes impersonation headers. The credentials from the
+// kubeconfig must be allowed to impersonate them.
+func WithImpersonation(user string, groups ...string) ProviderOption {
+	return func(p *Provider) {
+		p.impersonate = rest.ImpersonationConfig{UserName: user, Groups: groups}
+	}
+}
+
+// WithQPS sets the client-side rate limit for API requests: a sustained qps
+// and bursts of up to burst requests. client-go defaults to 5 QPS with a
+// burst of 10, which throttles processes that resolve many secrets.
+func WithQPS(qps float32, burst int) ProviderOption {
+	return func(p *Provider) {
+		p.qps = qps
+		p.burst = burst
+	}
+}
+
+// WithTimeout sets the timeout for each API request. Zero (the default)
+// means no timeout beyond the request context.
+func WithTimeout(d time.Duration) ProviderOption {
+	return func(p *Provider) {
+		p.timeout = d
+	}
+}
+
+// WithUserAgent sets the User-Agent of API requests to ua, such as
+// "billing-api/1.4.2", in place of the client-go default, for attribution in
+// the API server's audit log. A user agent set on the Resolver with
+// secrets.WithUserAgent takes precedence.
+func WithUserAgent(ua string) ProviderOption {
+	return func(p *Provider) {
+		p.userAgent = ua
+	}
+}
+
+// WithBase64Values makes Get encode each data value with standard base64
+// instead of as a JSON string. JSON strings must be valid UTF-8, so binary
+// values, such as keystores and DER certificates, are corrupted without it.
+// Decode values with the `encoding=base64` tag option:
+//
+//	Keystore []byte `secret:"k8s://prod/tls#keystore,encoding=base64"`
+//
+// Keys naming a single entry, such as "prod/tls/keystore", return its bytes
+// as is with or without this option.
+func WithBase64Values() ProviderOption {
+	return func(p *Provider) {
+		p.base64Values = true
+	}
+}
+
+// WithNamespace sets the namespace of keys that name only a Secret, so that
+// "db-creds" reads prod/db-creds. Keys of the form "namespace/name" are
+// unaffected.
+func WithNamespace(namespace string) ProviderOption {
+	return func(p *Provider) {
+		p.namespace = namespace
+	}
+}
+
+// WithInformers makes Watch, and so Resolver.Watch, follow Secrets through
+// the Kubernetes watch API instead of polling them. The first Watch of a key
+// in a namespace starts a shared informer that lists and watches every
+// Secret, or ConfigMap, in that namespace; from then on changes are pushed
+// within seconds and Get is served from the informer's local cache.
+//
+// Informers need the list and watch permissions in the namespace, and hold
+// all of its Secrets in memory. If an informer cannot start, Watch fails and
+// the Watcher falls back to polling. Close stops the informers.
+func WithInformers() ProviderOption {
+	return func(p *Provider) {
+		p.informers = true
+	}
+}
+
+// WithConfigMaps makes the provider read ConfigMaps instead of Secrets, with
+// the same "namespace/name" keys and JSON output, so that apps configured
+// from both can resolve them alike. Register it under its own scheme:
+//
+//	cm, err := k8s.New(k8s.WithConfigMaps())
+//	r := secrets.NewResolver(secrets.WithProvider("k8s", sec), secrets.WithProvider("k8scm", cm))
+//
+// ConfigMaps are read-only: Set and Delete return errors.ErrUnsupported
+// (wrapped). Entries of the ConfigMap's binaryData are returned alongside
+// its data; use WithBase64Values to read them intact.
+func WithConfigMaps() ProviderOption {
+	return func(p *Provider) {
+		p.configMaps = true
+	}
+}
+
+// Provider reads secrets from Kubernetes Secrets, or from ConfigMaps if
+// created with WithConfigMaps.
+// It implements secrets.Provider, secrets.CheckerProvider,
+// secrets.MetadataProvider, secrets.ListerProvider, secrets.WriterProvider,
+// and secrets.WatchableProvider.
 type Provider struct {
-	client     Client
-	kubeconfig string
-	context    string
+	client       Client
+	namespace    string
+	kubeconfig   string
+	context      string
+	impersonate  rest.ImpersonationConfig
+	qps          float32
+	burst        int
+	timeout      time.Duration
+	userAgent    string
+	base64Values bool
+	configMaps   bool
+	informers    bool
 }
 
 // New creates a new Kubernetes Secrets Provider.
@@ -66,46 +238,404 @@ func New(opts ...ProviderOption) (*Provider, error) {
 		if err != nil {
 			return nil, fmt.Errorf("k8s: load kubeconfig: %w", err)
 		}
+		p.configure(config)
 		clientset, err := kubernetes.NewForConfig(config)
 		if err != nil {
 			return nil, fmt.Errorf("k8s: create client: %w", err)
 		}
-		p.client = &k8sClient{clientset: clientset}
+		meta, err := metadata.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("k8s: create metadata client: %w", err)
+		}
+		c := &k8sClient{clientset: clientset, meta: meta}
+		if p.informers {
+			c.informers = newInformerSet(clientset)
+		}
+		p.client = c
 	}
 	return p, nil
 }
 
-// Get retrieves a Kubernetes Secret and returns its data as JSON-encoded bytes.
-// The key format is "namespace/secret-name".
-// Returns secrets.ErrNotFound (wrapped) if the Secret does not exist.
+// object names the kind of object the provider reads, for errors.
+func (p *Provider) object() string {
+	if p.configMaps {
+		return "configmap"
+	}
+	return "secret"
+}
+
+// Get retrieves a Kubernetes Secret, or ConfigMap, and returns its data as a
+// JSON object of strings, base64-encoded if WithBase64Values is set.
+// The key format is "namespace/secret-name", or "secret-name" with
+// WithNamespace. A key of the form "namespace/secret-name/entry" returns the
+// value of a single data entry as is, neither JSON- nor base64-encoded.
+// Returns secrets.ErrNotFound (wrapped) if the Secret or entry does not
+// exist, and errors.ErrUnsupported (wrapped) for a ConfigMap if the Client
+// does not implement ConfigMapClient.
 func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
-	namespace, name, err := parseKey(key)
+	namespace, name, entry, err := p.parseKey(key)
 	if err != nil {
 		return nil, fmt.Errorf("k8s: %w", err)
 	}
-	data, err := p.client.GetSecret(ctx, namespace, name)
+	data, err := p.getData(ctx, key, namespace, name)
 	if err != nil {
-		return nil, fmt.Errorf("k8s: secret %q: %w", key, err)
+		return nil, err
+	}
+	if entry != "" {
+		v, ok := data[entry]
+		if !ok {
+			return nil, fmt.Errorf("k8s: %s %q: %w", p.object(), key, secrets.ErrNotFound)
+		}
+		return v, nil
 	}
 	// Convert map[string][]byte to map[string]string for JSON encoding.
 	strData := make(map[string]string, len(data))
 	for k, v := range data {
-		strData[k] = string(v)
+		if p.base64Values {
+			strData[k] = base64.StdEncoding.EncodeToString(v)
+		} else {
+			strData[k] = string(v)
+		}
 	}
 	b, err := json.Marshal(strData)
 	if err != nil {
-		return nil, fmt.Errorf("k8s: secret %q: marshal: %w", key, err)
+		return nil, fmt.Errorf("k8s: %s %q: marshal: %w", p.object(), key, err)
 	}
 	return b, nil
 }
 
-// parseKey splits "namespace/name" into its components.
-func parseKey(key string) (namespace, name string, err error) {
-	parts := strings.SplitN(key, "/", 2)
-	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-		return "", "", fmt.Errorf("invalid key %q: expected \"namespace/name\"", key)
+// getData returns the data of the Secret or ConfigMap that key names.
+func (p *Provider) getData(ctx context.Context, key, namespace, name string) (map[string][]byte, error) {
+	var data map[string][]byte
+	var err error
+	if p.configMaps {
+		cc, ok := p.client.(ConfigMapClient)
+		if !ok {
+			return nil, fmt.Errorf("k8s: configmap %q: %w", key, errors.ErrUnsupported)
+		}
+		data, err = cc.GetConfigMap(ctx, namespace, name)
+	} else {
+		data, err = p.client.GetSecret(ctx, namespace, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("k8s: %s %q: %w", p.object(), key, providerError(err))
+	}
+	return data, nil
+}
+
+// Check verifies the Secret exists and is accessible by fetching only its
+// object metadata, without retrieving its data. For a key naming a single
+// entry, only the Secret is checked.
+// Returns secrets.ErrNotFound (wrapped) if the Secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement CheckClient,
+// or ConfigMapClient for a ConfigMap.
+func (p *Provider) Check(ctx context.Context, key string) error {
+	namespace, name, _, err := p.parseKey(key)
+	if err != nil {
+		return fmt.Errorf("k8s: %w", err)
+	}
+	if p.configMaps {
+		_, err := p.GetMetadata(ctx, key)
+		return err
+	}
+	cc, ok := p.client.(CheckClient)
+	if !ok {
+		return fmt.Errorf("k8s: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	if err := cc.GetSecretMetadata(ctx, namespace, name); err != nil {
+		return fmt.Errorf("k8s: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// GetMetadata describes the Secret by fetching only its object metadata,
+// without retrieving its data. RotatedAt is the latest time recorded in the
+// Secret's managed fields, that is, its last write through the API server;
+// Version is its resource version and Tags its labels. Kubernetes Secrets do
+// not expire, so ExpiresAt is never set.
+// Returns secrets.ErrNotFound (wrapped) if the Secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement
+// MetadataClient, or ConfigMapClient for a ConfigMap.
+func (p *Provider) GetMetadata(ctx context.Context, key string) (secrets.Metadata, error) {
+	namespace, name, _, err := p.parseKey(key)
+	if err != nil {
+		return secrets.Metadata{}, fmt.Errorf("k8s: %w", err)
+	}
+	if p.configMaps {
+		cc, ok := p.client.(ConfigMapClient)
+		if !ok {
+			return secrets.Metadata{}, fmt.Errorf("k8s: configmap %q: %w", key, errors.ErrUnsupported)
+		}
+		md, err := cc.ConfigMapMetadata(ctx, namespace, name)
+		if err != nil {
+			return secrets.Metadata{}, fmt.Errorf("k8s: configmap %q: %w", key, providerError(err))
+		}
+		return md, nil
+	}
+	mc, ok := p.client.(MetadataClient)
+	if !ok {
+		return secrets.Metadata{}, fmt.Errorf("k8s: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	md, err := mc.SecretMetadata(ctx, namespace, name)
+	if err != nil {
+		return secrets.Metadata{}, fmt.Errorf("k8s: secret %q: %w", key, providerError(err))
+	}
+	return md, nil
+}
+
+// List returns the keys, of the form "namespace/name", of the Secrets, or
+// ConfigMaps, that start with prefix. A prefix containing "/" lists a single
+// namespace, which requires the list permission in that namespace; any other
+// prefix lists all namespaces.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// ListerClient, or ConfigMapClient for ConfigMaps.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	var namespace string
+	if ns, _, ok := strings.Cut(prefix, "/"); ok {
+		namespace = ns
+	}
+	var all []string
+	var err error
+	if p.configMaps {
+		cc, ok := p.client.(ConfigMapClient)
+		if !ok {
+			return nil, fmt.Errorf("k8s: list %q: %w", prefix, errors.ErrUnsupported)
+		}
+		all, err = cc.ListConfigMaps(ctx, namespace)
+	} else {
+		lc, ok := p.client.(ListerClient)
+		if !ok {
+			return nil, fmt.Errorf("k8s: list %q: %w", prefix, errors.ErrUnsupported)
+		}
+		all, err = lc.ListSecrets(ctx, namespace)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("k8s: list %q: %w", prefix, providerError(err))
+	}
+	var keys []string
+	for _, key := range all {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Set replaces the data of a Kubernetes Secret, creating an Opaque Secret if
+// it does not exist. value is a JSON object of strings in the form Get
+// returns, base64-encoded if WithBase64Values is set:
+//
+//	p.Set(ctx, "prod/db-creds", []byte(`{"username":"admin","password":"s3cret"}`))
+//
+// Keys of the existing Secret that are absent from value are removed. A key
+// naming a single entry, such as "prod/db-creds/password", sets that entry
+// to value as is and leaves the others unchanged.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient, or if the provider reads ConfigMaps.
+func (p *Provider) Set(ctx context.Context, key string, value []byte) error {
+	namespace, name, entry, err := p.parseKey(key)
+	if err != nil {
+		return fmt.Errorf("k8s: %w", err)
+	}
+	wc, ok := p.client.(WriterClient)
+	if !ok || p.configMaps {
+		return fmt.Errorf("k8s: %s %q: %w", p.object(), key, errors.ErrUnsupported)
+	}
+	if entry != "" {
+		return p.updateEntry(ctx, wc, key, namespace, name, func(data map[string][]byte) error {
+			data[entry] = value
+			return nil
+		})
+	}
+	var strData map[string]string
+	if err := json.Unmarshal(value, &strData); err != nil {
+		return fmt.Errorf("k8s: secret %q: value must be a JSON object of strings: %w", key, err)
+	}
+	data := make(map[string][]byte, len(strData))
+	for k, v := range strData {
+		if !p.base64Values {
+			data[k] = []byte(v)
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return fmt.Errorf("k8s: secret %q: key %q: %w", key, k, err)
+		}
+		data[k] = b
+	}
+	if err := wc.PutSecret(ctx, namespace, name, data); err != nil {
+		return fmt.Errorf("k8s: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// Delete deletes a Kubernetes Secret, or a single entry of it if key has the
+// form "namespace/secret-name/entry".
+// Returns secrets.ErrNotFound (wrapped) if the Secret or entry does not
+// exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient, or if the provider reads ConfigMaps.
+func (p *Provider) Delete(ctx context.Context, key string) error {
+	namespace, name, entry, err := p.parseKey(key)
+	if err != nil {
+		return fmt.Errorf("k8s: %w", err)
+	}
+	wc, ok := p.client.(WriterClient)
+	if !ok || p.configMaps {
+		return fmt.Errorf("k8s: %s %q: %w", p.object(), key, errors.ErrUnsupported)
+	}
+	if entry != "" {
+		return p.updateEntry(ctx, wc, key, namespace, name, func(data map[string][]byte) error {
+			if _, ok := data[entry]; !ok {
+				return fmt.Errorf("k8s: secret %q: %w", key, secrets.ErrNotFound)
+			}
+			delete(data, entry)
+			return nil
+		})
+	}
+	if err := wc.DeleteSecret(ctx, namespace, name); err != nil {
+		return fmt.Errorf("k8s: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// updateEntry applies update to the data of a Secret, which is created if
+// it does not exist, and writes it back.
+func (p *Provider) updateEntry(ctx context.Context, wc WriterClient, key, namespace, name string, update func(map[string][]byte) error) error {
+	data, err := p.client.GetSecret(ctx, namespace, name)
+	if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		return fmt.Errorf("k8s: secret %q: %w", key, providerError(err))
+	}
+	data = maps.Clone(data)
+	if data == nil {
+		data = make(map[string][]byte)
+	}
+	if err := update(data); err != nil {
+		return err
+	}
+	if err := wc.PutSecret(ctx, namespace, name, data); err != nil {
+		return fmt.Errorf("k8s: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// Watch returns a channel that receives the value of key, as Get returns it,
+// each time the Secret or ConfigMap it names changes, and nil when it is
+// deleted or cannot be read. The channel is closed when ctx is done or the
+// Provider is closed.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// WatchClient, or ConfigMapWatchClient for a ConfigMap, or if the default
+// client was created without WithInformers.
+func (p *Provider) Watch(ctx context.Context, key string) (<-chan []byte, error) {
+	namespace, name, _, err := p.parseKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("k8s: %w", err)
+	}
+	var changes <-chan struct{}
+	if p.configMaps {
+		wc, ok := p.client.(ConfigMapWatchClient)
+		if !ok {
+			return nil, fmt.Errorf("k8s: watch configmap %q: %w", key, errors.ErrUnsupported)
+		}
+		changes, err = wc.WatchConfigMap(ctx, namespace, name)
+	} else {
+		wc, ok := p.client.(WatchClient)
+		if !ok {
+			return nil, fmt.Errorf("k8s: watch secret %q: %w", key, errors.ErrUnsupported)
+		}
+		changes, err = wc.WatchSecret(ctx, namespace, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("k8s: watch %s %q: %w", p.object(), key, err)
+	}
+	values := make(chan []byte)
+	go func() {
+		defer close(values)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-changes:
+				if !ok {
+					return
+				}
+			}
+			val, err := p.Get(ctx, key)
+			if err != nil {
+				val = nil
+			}
+			select {
+			case values <- val:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return values, nil
+}
+
+// Close stops the informers started by Watch. The Provider must not be used
+// afterwards.
+func (p *Provider) Close() error {
+	if c, ok := p.client.(*k8sClient); ok && c.informers != nil {
+		c.informers.close()
+	}
+	return nil
+}
+
+// configure applies the impersonation, rate limit, timeout, and user agent
+// options to config.
+func (p *Provider) configure(config *rest.Config) {
+	if p.impersonate.UserName != "" {
+		config.Impersonate = p.impersonate
+	}
+	if p.qps > 0 {
+		config.QPS = p.qps
+	}
+	if p.burst > 0 {
+		config.Burst = p.burst
+	}
+	if p.timeout > 0 {
+		config.Timeout = p.timeout
+	}
+	if p.userAgent != "" {
+		config.UserAgent = p.userAgent
+	}
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return userAgentRoundTripper{rt: rt}
+	})
+}
+
+// userAgentRoundTripper sets the User-Agent of requests whose context carries
+// a user agent.
+type userAgentRoundTripper struct {
+	rt http.RoundTripper
+}
+
+func (u userAgentRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if ua := secrets.UserAgentFromContext(req.Context()); ua != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", ua)
+	}
+	return u.rt.RoundTrip(req)
+}
+
+// parseKey splits "namespace/name", "namespace/name/entry", or, with
+// WithNamespace, "name" into its components. entry is empty for keys that
+// name a whole Secret.
+func (p *Provider) parseKey(key string) (namespace, name, entry string, err error) {
+	parts := strings.Split(key, "/")
+	if len(parts) == 1 && p.namespace != "" {
+		parts = []string{p.namespace, key}
+	}
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return "", "", "", fmt.Errorf("invalid key %q: expected \"namespace/name\" or \"namespace/name/entry\"", key)
 	}
-	return parts[0], parts[1], nil
+	if len(parts) == 3 {
+		entry = parts[2]
+	}
+	return parts[0], parts[1], entry, nil
 }
 
 // buildConfig creates a *rest.Config using the standard loading rules.
@@ -128,12 +658,41 @@ func buildConfig(kubeconfig, context string) (*rest.Config, error) {
 	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
 }
 
+// providerError wraps an API status error in a *secrets.ErrProvider
+// carrying its HTTP status, reason, and suggested retry delay. Other errors are
+// returned unchanged.
+func providerError(err error) error {
+	var se apierrors.APIStatus
+	if !errors.As(err, &se) {
+		return err
+	}
+	st := se.Status()
+	pe := &secrets.ErrProvider{StatusCode: int(st.Code), Code: string(st.Reason), Err: err}
+	if seconds, ok := apierrors.SuggestsClientDelay(err); ok {
+		pe.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return pe
+}
+
 // k8sClient wraps a real Kubernetes clientset.
 type k8sClient struct {
 	clientset kubernetes.Interface
+	meta      metadata.Interface
+	informers *informerSet // nil unless WithInformers
 }
 
+// Resources of core/v1 objects for the metadata client.
+var (
+	secretsResource    = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	configMapsResource = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+)
+
 func (c *k8sClient) GetSecret(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+	if c.informers != nil {
+		if data, ok, err := c.informers.get(secretsResource.Resource, namespace, name); ok {
+			return data, err
+		}
+	}
 	secret, err := c.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
@@ -143,3 +702,128 @@ func (c *k8sClient) GetSecret(ctx context.Context, namespace, name string) (map[
 	}
 	return secret.Data, nil
 }
+
+func (c *k8sClient) GetSecretMetadata(ctx context.Context, namespace, name string) error {
+	_, err := c.SecretMetadata(ctx, namespace, name)
+	return err
+}
+
+func (c *k8sClient) SecretMetadata(ctx context.Context, namespace, name string) (secrets.Metadata, error) {
+	return c.objectMetadata(ctx, secretsResource, namespace, name)
+}
+
+// objectMetadata describes the object of resource from its object metadata.
+func (c *k8sClient) objectMetadata(ctx context.Context, resource schema.GroupVersionResource, namespace, name string) (secrets.Metadata, error) {
+	obj, err := c.meta.Resource(resource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return secrets.Metadata{}, fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return secrets.Metadata{}, err
+	}
+	md := secrets.Metadata{
+		CreatedAt: obj.CreationTimestamp.Time,
+		Version:   obj.ResourceVersion,
+	}
+	for _, f := range obj.ManagedFields {
+		if f.Time != nil && f.Time.After(md.RotatedAt) {
+			md.RotatedAt = f.Time.Time
+		}
+	}
+	if len(obj.Labels) > 0 {
+		md.Tags = maps.Clone(obj.Labels)
+	}
+	return md, nil
+}
+
+func (c *k8sClient) PutSecret(ctx context.Context, namespace, name string, data map[string][]byte) error {
+	api := c.clientset.CoreV1().Secrets(namespace)
+	secret, err := api.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = api.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       data,
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	secret.Data = data
+	secret.StringData = nil
+	_, err = api.Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *k8sClient) DeleteSecret(ctx context.Context, namespace, name string) error {
+	err := c.clientset.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *k8sClient) ListSecrets(ctx context.Context, namespace string) ([]string, error) {
+	return c.listObjects(ctx, secretsResource, namespace)
+}
+
+// listObjects returns the keys of the objects of resource in namespace.
+func (c *k8sClient) listObjects(ctx context.Context, resource schema.GroupVersionResource, namespace string) ([]string, error) {
+	var keys []string
+	opts := metav1.ListOptions{}
+	for {
+		list, err := c.meta.Resource(resource).Namespace(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			keys = append(keys, item.Namespace+"/"+item.Name)
+		}
+		if list.Continue == "" {
+			return keys, nil
+		}
+		opts.Continue = list.Continue
+	}
+}
+
+func (c *k8sClient) GetConfigMap(ctx context.Context, namespace, name string) (map[string][]byte, error) {
+	if c.informers != nil {
+		if data, ok, err := c.informers.get(configMapsResource.Resource, namespace, name); ok {
+			return data, err
+		}
+	}
+	cm, err := c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+		}
+		return nil, err
+	}
+	return configMapData(cm), nil
+}
+
+func (c *k8sClient) ConfigMapMetadata(ctx context.Context, namespace, name string) (secrets.Metadata, error) {
+	return c.objectMetadata(ctx, configMapsResource, namespace, name)
+}
+
+func (c *k8sClient) ListConfigMaps(ctx context.Context, namespace string) ([]string, error) {
+	return c.listObjects(ctx, configMapsResource, namespace)
+}
+
+func (c *k8sClient) WatchSecret(ctx context.Context, namespace, name string) (<-chan struct{}, error) {
+	if c.informers == nil {
+		return nil, fmt.Errorf("informers are disabled (use WithInformers): %w", errors.ErrUnsupported)
+	}
+	return c.informers.subscribe(ctx, secretsResource.Resource, namespace, name)
+}
+
+func (c *k8sClient) WatchConfigMap(ctx context.Context, namespace, name string) (<-chan struct{}, error) {
+	if c.informers == nil {
+		return nil, fmt.Errorf("informers are disabled (use WithInformers): %w", errors.ErrUnsupported)
+	}
+	return c.informers.subscribe(ctx, configMapsResource.Resource, namespace, name)
+}
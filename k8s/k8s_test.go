@@ -1,14 +1,24 @@
 package k8s_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/brwse/go-secrets"
 	"github.com/brwse/go-secrets/k8s"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
 // mockClient implements k8s.Client for testing.
@@ -28,6 +38,45 @@ func (m *mockClient) GetSecret(_ context.Context, namespace, name string) (map[s
 	return data, nil
 }
 
+func (m *mockClient) GetSecretMetadata(_ context.Context, namespace, name string) error {
+	if _, ok := m.secrets[namespace][name]; !ok {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return nil
+}
+
+func (m *mockClient) PutSecret(_ context.Context, namespace, name string, data map[string][]byte) error {
+	if m.secrets == nil {
+		m.secrets = make(map[string]map[string]map[string][]byte)
+	}
+	if m.secrets[namespace] == nil {
+		m.secrets[namespace] = make(map[string]map[string][]byte)
+	}
+	m.secrets[namespace][name] = data
+	return nil
+}
+
+func (m *mockClient) DeleteSecret(_ context.Context, namespace, name string) error {
+	if _, ok := m.secrets[namespace][name]; !ok {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	delete(m.secrets[namespace], name)
+	return nil
+}
+
+func (m *mockClient) ListSecrets(_ context.Context, namespace string) ([]string, error) {
+	var keys []string
+	for ns, names := range m.secrets {
+		if namespace != "" && ns != namespace {
+			continue
+		}
+		for name := range names {
+			keys = append(keys, ns+"/"+name)
+		}
+	}
+	return keys, nil
+}
+
 func TestGet_Existing(t *testing.T) {
 	mock := &mockClient{
 		secrets: map[string]map[string]map[string][]byte{
@@ -126,3 +175,585 @@ func TestGet_JSONEncoding(t *testing.T) {
 		}
 	}
 }
+
+func TestGet_Base64Values(t *testing.T) {
+	keystore := []byte{0x30, 0x82, 0xff, 0xfe, 0x00, 0xc3}
+	mock := &mockClient{
+		secrets: map[string]map[string]map[string][]byte{
+			"prod": {
+				"tls": {
+					"keystore": keystore,
+					"password": []byte("changeit"),
+				},
+			},
+		},
+	}
+	p, err := k8s.New(k8s.WithClient(mock), k8s.WithBase64Values())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	r := secrets.NewResolver(secrets.WithProvider("k8s", p))
+
+	type Config struct {
+		Keystore []byte `secret:"k8s://prod/tls#keystore,encoding=base64"`
+		Password string `secret:"k8s://prod/tls#password,encoding=base64"`
+	}
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if !bytes.Equal(cfg.Keystore, keystore) {
+		t.Errorf("Keystore = %x, want %x", cfg.Keystore, keystore)
+	}
+	if cfg.Password != "changeit" {
+		t.Errorf("Password = %q, want %q", cfg.Password, "changeit")
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	keystore := []byte{0x30, 0x82, 0xff, 0xfe, 0x00, 0xc3}
+	for _, tc := range []struct {
+		name string
+		opts []k8s.ProviderOption
+		ref  string
+	}{
+		{"base64 values", []k8s.ProviderOption{k8s.WithBase64Values()}, "k8s://prod/tls#keystore,encoding=base64"},
+		{"entry key", nil, "k8s://prod/tls/keystore"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := k8s.New(append(tc.opts, k8s.WithClient(&mockClient{}))...)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if err := p.Set(context.Background(), "prod/tls/keystore", keystore); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			ref, err := secrets.ParseRef(tc.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := secrets.NewResolver(secrets.WithProvider("k8s", p))
+			values, err := r.ResolveRefs(context.Background(), map[string]secrets.Ref{"keystore": ref})
+			if err != nil {
+				t.Fatalf("ResolveRefs: %v", err)
+			}
+			if !bytes.Equal(values["keystore"], keystore) {
+				t.Errorf("keystore = %x, want %x", values["keystore"], keystore)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	mock := &mockClient{
+		secrets: map[string]map[string]map[string][]byte{
+			"prod": {"db-creds": {"password": []byte("s3cret")}},
+		},
+	}
+	p, err := k8s.New(k8s.WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "prod/db-creds"); err != nil {
+		t.Errorf("Check existing: %v", err)
+	}
+	if err := p.Check(context.Background(), "prod/nonexistent"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check missing: expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestCheck_Unsupported(t *testing.T) {
+	// Embedding hides the mock's CheckClient method.
+	p, err := k8s.New(k8s.WithClient(struct{ k8s.Client }{&mockClient{}}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "prod/db-creds"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	mock := &mockClient{secrets: map[string]map[string]map[string][]byte{
+		"prod":    {"db-creds": {}, "db-replica": {}, "api": {}},
+		"staging": {"db-creds": {}},
+	}}
+	p, err := k8s.New(k8s.WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for prefix, want := range map[string]string{
+		"":         "prod/api,prod/db-creds,prod/db-replica,staging/db-creds",
+		"prod/":    "prod/api,prod/db-creds,prod/db-replica",
+		"prod/db-": "prod/db-creds,prod/db-replica",
+		"stag":     "staging/db-creds",
+	} {
+		got, err := p.List(context.Background(), prefix)
+		if err != nil {
+			t.Fatalf("List(%q): %v", prefix, err)
+		}
+		if strings.Join(got, ",") != want {
+			t.Errorf("List(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+
+	ro, _ := k8s.New(k8s.WithClient(struct{ k8s.Client }{mock}))
+	if _, err := ro.List(context.Background(), ""); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("List: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestList_Metadata(t *testing.T) {
+	var gotPath, gotAccept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAccept = r.URL.Path, r.Header.Get("Accept")
+		w.Header().Set("Content-Type", "application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1")
+		_, _ = io.WriteString(w, `{"apiVersion":"meta.k8s.io/v1","kind":"PartialObjectMetadataList","metadata":{},"items":[
+			{"apiVersion":"meta.k8s.io/v1","kind":"PartialObjectMetadata","metadata":{"name":"db","namespace":"prod"}},
+			{"apiVersion":"meta.k8s.io/v1","kind":"PartialObjectMetadata","metadata":{"name":"api","namespace":"prod"}}]}`)
+	}))
+	defer srv.Close()
+
+	p, err := k8s.New(k8s.WithKubeconfig(writeKubeconfig(t, srv.URL)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got, err := p.List(context.Background(), "prod/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := "prod/api,prod/db"; strings.Join(got, ",") != want {
+		t.Errorf("List = %q, want %q", got, want)
+	}
+	if gotPath != "/api/v1/namespaces/prod/secrets" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if !strings.Contains(gotAccept, "PartialObjectMetadataList") {
+		t.Errorf("Accept = %q, want a metadata-only list", gotAccept)
+	}
+}
+
+func TestGetMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/prod/secrets/db" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json;as=PartialObjectMetadata;g=meta.k8s.io;v=v1")
+		_, _ = io.WriteString(w, `{"apiVersion":"meta.k8s.io/v1","kind":"PartialObjectMetadata","metadata":{
+			"name":"db","namespace":"prod","resourceVersion":"4242",
+			"creationTimestamp":"2023-11-14T22:13:20Z",
+			"labels":{"team":"billing"},
+			"managedFields":[
+				{"manager":"kubectl","operation":"Update","time":"2024-03-09T16:00:00Z"},
+				{"manager":"labeler","operation":"Update","time":"2023-12-01T00:00:00Z"}
+			]}}`)
+	}))
+	defer srv.Close()
+
+	p, err := k8s.New(k8s.WithKubeconfig(writeKubeconfig(t, srv.URL)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	md, err := p.GetMetadata(context.Background(), "prod/db")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if want := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC); !md.CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v", md.CreatedAt, want)
+	}
+	if want := time.Date(2024, 3, 9, 16, 0, 0, 0, time.UTC); !md.RotatedAt.Equal(want) {
+		t.Errorf("RotatedAt = %v, want %v", md.RotatedAt, want)
+	}
+	if md.Version != "4242" {
+		t.Errorf("Version = %q, want 4242", md.Version)
+	}
+	if md.Tags["team"] != "billing" {
+		t.Errorf("Tags = %v", md.Tags)
+	}
+
+	if _, err := p.GetMetadata(context.Background(), "prod/missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	mock := &mockClient{}
+	p, err := k8s.New(k8s.WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	value := `{"password":"s3cret","username":"admin"}`
+	if err := p.Set(ctx, "prod/db-creds", []byte(value)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := p.Get(ctx, "prod/db-creds"); err != nil || string(got) != value {
+		t.Errorf("Get = %s, %v, want %s", got, err, value)
+	}
+	if err := p.Set(ctx, "prod/db-creds", []byte("s3cret")); err == nil {
+		t.Error("Set with a non-object value: expected error")
+	}
+	if err := p.Delete(ctx, "prod/db-creds"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := p.Delete(ctx, "prod/db-creds"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Delete missing: expected ErrNotFound, got: %v", err)
+	}
+
+	ro, _ := k8s.New(k8s.WithClient(struct{ k8s.Client }{mock}))
+	if err := ro.Set(ctx, "prod/db-creds", []byte(value)); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Set: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestSet_Base64Values(t *testing.T) {
+	mock := &mockClient{}
+	p, err := k8s.New(k8s.WithClient(mock), k8s.WithBase64Values())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Set(context.Background(), "prod/tls", []byte(`{"keystore":"MIL//gDD"}`)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, want := mock.secrets["prod"]["tls"]["keystore"], []byte{0x30, 0x82, 0xff, 0xfe, 0x00, 0xc3}; !bytes.Equal(got, want) {
+		t.Errorf("keystore = %x, want %x", got, want)
+	}
+}
+
+func TestSet_CreatesSecret(t *testing.T) {
+	var created *corev1.Secret
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"apiVersion":"v1","kind":"Status","status":"Failure","reason":"NotFound","code":404}`)
+			return
+		}
+		// The clientset may encode the request as protobuf.
+		body, _ := io.ReadAll(r.Body)
+		obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(body, nil, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		created = obj.(*corev1.Secret)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(created)
+	}))
+	defer srv.Close()
+
+	p, err := k8s.New(k8s.WithKubeconfig(writeKubeconfig(t, srv.URL)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := p.Set(context.Background(), "prod/db", []byte(`{"password":"s3cret"}`)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if created.Type != corev1.SecretTypeOpaque {
+		t.Errorf("Type = %q, want %q", created.Type, corev1.SecretTypeOpaque)
+	}
+	if got := string(created.Data["password"]); got != "s3cret" {
+		t.Errorf("password = %q, want %q", got, "s3cret")
+	}
+}
+
+// writeKubeconfig writes a kubeconfig for server and returns its path.
+func writeKubeconfig(t *testing.T, server string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	config := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: test-token
+`, server)
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNew_Impersonation(t *testing.T) {
+	var gotUser string
+	var gotGroups []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser = r.Header.Get("Impersonate-User")
+		gotGroups = r.Header.Values("Impersonate-Group")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"db","namespace":"prod"},"data":{"password":"czNjcmV0"}}`)
+	}))
+	defer srv.Close()
+
+	p, err := k8s.New(k8s.WithKubeconfig(writeKubeconfig(t, srv.URL)),
+		k8s.WithImpersonation("system:serviceaccount:prod:reader", "readers"),
+		k8s.WithQPS(100, 200))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	val, err := p.Get(context.Background(), "prod/db")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(val) != `{"password":"s3cret"}` {
+		t.Errorf("Get = %s", val)
+	}
+	if gotUser != "system:serviceaccount:prod:reader" {
+		t.Errorf("Impersonate-User = %q", gotUser)
+	}
+	if len(gotGroups) != 1 || gotGroups[0] != "readers" {
+		t.Errorf("Impersonate-Group = %q, want [readers]", gotGroups)
+	}
+}
+
+func TestNew_UserAgent(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"db","namespace":"prod"},"data":{"password":"czNjcmV0"}}`)
+	}))
+	defer srv.Close()
+
+	p, err := k8s.New(k8s.WithKubeconfig(writeKubeconfig(t, srv.URL)), k8s.WithUserAgent("billing-api/1.4.2"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := p.Get(context.Background(), "prod/db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	ctx := secrets.ContextWithUserAgent(context.Background(), "billing-worker/2.0")
+	if _, err := p.Get(ctx, "prod/db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	want := []string{"billing-api/1.4.2", "billing-worker/2.0"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("User-Agent = %q, want %q", got, want)
+	}
+}
+
+func TestNew_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	p, err := k8s.New(k8s.WithKubeconfig(writeKubeconfig(t, srv.URL)), k8s.WithTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	start := time.Now()
+	if _, err := p.Get(context.Background(), "prod/db"); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Get took %v, want it bounded by the timeout", d)
+	}
+}
+
+func TestGet_ErrProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"TooManyRequests","code":429,"details":{"retryAfterSeconds":5}}`)
+	}))
+	defer srv.Close()
+
+	p, err := k8s.New(k8s.WithKubeconfig(writeKubeconfig(t, srv.URL)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = p.Get(context.Background(), "prod/db")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected ErrProvider, got %v", err)
+	}
+	if pe.StatusCode != http.StatusTooManyRequests || pe.Code != "TooManyRequests" || pe.RetryAfter != 5*time.Second {
+		t.Errorf("ErrProvider = {%d %q %v}, want {429 \"TooManyRequests\" 5s}", pe.StatusCode, pe.Code, pe.RetryAfter)
+	}
+}
+
+// mockConfigMapClient adds ConfigMaps to mockClient.
+type mockConfigMapClient struct {
+	mockClient
+	configMaps map[string]map[string][]byte // "namespace/name" -> data
+}
+
+func (m *mockConfigMapClient) GetConfigMap(_ context.Context, namespace, name string) (map[string][]byte, error) {
+	data, ok := m.configMaps[namespace+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return data, nil
+}
+
+func (m *mockConfigMapClient) ConfigMapMetadata(_ context.Context, namespace, name string) (secrets.Metadata, error) {
+	if _, ok := m.configMaps[namespace+"/"+name]; !ok {
+		return secrets.Metadata{}, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return secrets.Metadata{Version: "1"}, nil
+}
+
+func (m *mockConfigMapClient) ListConfigMaps(_ context.Context, namespace string) ([]string, error) {
+	var keys []string
+	for key := range m.configMaps {
+		if namespace == "" || strings.HasPrefix(key, namespace+"/") {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func TestConfigMaps(t *testing.T) {
+	mock := &mockConfigMapClient{
+		mockClient: mockClient{secrets: map[string]map[string]map[string][]byte{
+			"prod": {"app": {"password": []byte("s3cret")}},
+		}},
+		configMaps: map[string]map[string][]byte{
+			"prod/app": {"log_level": []byte("debug")},
+		},
+	}
+	p, err := k8s.New(k8s.WithClient(mock), k8s.WithConfigMaps())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	if val, err := p.Get(ctx, "prod/app"); err != nil || string(val) != `{"log_level":"debug"}` {
+		t.Errorf("Get = %s, %v; want the ConfigMap's data", val, err)
+	}
+	if _, err := p.Get(ctx, "prod/missing"); !errors.Is(err, secrets.ErrNotFound) || !strings.Contains(err.Error(), "configmap") {
+		t.Errorf("expected configmap ErrNotFound, got: %v", err)
+	}
+	if err := p.Check(ctx, "prod/app"); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	if md, err := p.GetMetadata(ctx, "prod/app"); err != nil || md.Version != "1" {
+		t.Errorf("GetMetadata = %+v, %v", md, err)
+	}
+	if keys, err := p.List(ctx, "prod/"); err != nil || strings.Join(keys, ",") != "prod/app" {
+		t.Errorf("List = %q, %v", keys, err)
+	}
+	if err := p.Set(ctx, "prod/app", []byte(`{}`)); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Set: expected ErrUnsupported, got: %v", err)
+	}
+	if err := p.Delete(ctx, "prod/app"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Delete: expected ErrUnsupported, got: %v", err)
+	}
+
+	secretsOnly, _ := k8s.New(k8s.WithClient(&mock.mockClient), k8s.WithConfigMaps())
+	if _, err := secretsOnly.Get(ctx, "prod/app"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Get: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestConfigMaps_SDK(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"app","namespace":"prod"},
+			"data":{"log_level":"debug"},"binaryData":{"logo":"iVBO"}}`)
+	}))
+	defer srv.Close()
+
+	p, err := k8s.New(k8s.WithKubeconfig(writeKubeconfig(t, srv.URL)), k8s.WithConfigMaps(), k8s.WithBase64Values())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	val, err := p.Get(context.Background(), "prod/app")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if want := `{"log_level":"ZGVidWc=","logo":"iVBO"}`; string(val) != want {
+		t.Errorf("Get = %s, want %s", val, want)
+	}
+	if gotPath != "/api/v1/namespaces/prod/configmaps/app" {
+		t.Errorf("path = %q", gotPath)
+	}
+}
+
+func TestNamespaceAndEntryKeys(t *testing.T) {
+	mock := &mockClient{secrets: map[string]map[string]map[string][]byte{
+		"prod": {
+			"db":  {"password": []byte("s3cret")},
+			"tls": {"keystore": {0xff, 0xfe}},
+		},
+		"staging": {"db": {"password": []byte("staging")}},
+	}}
+	p, err := k8s.New(k8s.WithClient(mock), k8s.WithNamespace("prod"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		key, want string
+	}{
+		{"db", `{"password":"s3cret"}`},
+		{"staging/db", `{"password":"staging"}`},
+		{"prod/db/password", "s3cret"},
+		{"prod/tls/keystore", "\xff\xfe"},
+	}
+	for _, tc := range tests {
+		if got, err := p.Get(ctx, tc.key); err != nil || string(got) != tc.want {
+			t.Errorf("Get(%q) = %q, %v; want %q", tc.key, got, err, tc.want)
+		}
+	}
+	if _, err := p.Get(ctx, "prod/db/missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("missing entry: expected ErrNotFound, got: %v", err)
+	}
+	for _, key := range []string{"prod/db/a/b", "prod//password", "/db"} {
+		if _, err := p.Get(ctx, key); err == nil {
+			t.Errorf("Get(%q): expected invalid key error", key)
+		}
+	}
+
+	if err := p.Set(ctx, "prod/db/username", []byte("admin")); err != nil {
+		t.Fatalf("Set entry: %v", err)
+	}
+	if err := p.Set(ctx, "prod/new/token", []byte("t0k")); err != nil {
+		t.Fatalf("Set entry of new Secret: %v", err)
+	}
+	if err := p.Delete(ctx, "prod/db/password"); err != nil {
+		t.Fatalf("Delete entry: %v", err)
+	}
+	if err := p.Delete(ctx, "prod/db/password"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Delete missing entry: expected ErrNotFound, got: %v", err)
+	}
+	if got, _ := p.Get(ctx, "db"); string(got) != `{"username":"admin"}` {
+		t.Errorf("Get after entry writes = %s", got)
+	}
+	if got, _ := p.Get(ctx, "new"); string(got) != `{"token":"t0k"}` {
+		t.Errorf("Get new = %s", got)
+	}
+
+	bare, _ := k8s.New(k8s.WithClient(mock))
+	if _, err := bare.Get(ctx, "db"); err == nil {
+		t.Error("Get without WithNamespace: expected invalid key error")
+	}
+}
@@ -0,0 +1,262 @@
+// Package keeper provides a secret provider that reads from Keeper Secrets
+// Manager using the KSM CLI (ksm).
+package keeper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/brwse/go-secrets"
+)
+
+// Record is a Keeper record as returned by a Client.
+type Record struct {
+	UID    string  `json:"uid"`
+	Title  string  `json:"title"`
+	Fields []Field `json:"fields"`
+	Custom []Field `json:"custom"`
+}
+
+// Field is a standard or custom field of a Record.
+type Field struct {
+	Type  string `json:"type"`
+	Label string `json:"label,omitempty"`
+	Value []any  `json:"value"`
+}
+
+// Client abstracts Keeper Secrets Manager.
+type Client interface {
+	// GetRecord returns the record with the given UID or title.
+	GetRecord(ctx context.Context, record string) (*Record, error)
+}
+
+// NotationClient is implemented by Clients that can resolve Keeper notation,
+// such as "<uid>/field/password" or "<uid>/file/id_rsa", without the
+// "keeper://" prefix. The default CLI client uses ksm secret notation.
+type NotationClient interface {
+	// GetNotation returns the value notation refers to, file contents as
+	// they are.
+	GetNotation(ctx context.Context, notation string) ([]byte, error)
+}
+
+// ProviderOption configures the keeper Provider.
+type ProviderOption func(*Provider)
+
+// WithConfig sets the base64-encoded KSM configuration the CLI reads, as
+// printed by ksm profile export. Defaults to the KSM_CONFIG environment
+// variable.
+func WithConfig(config string) ProviderOption {
+	return func(p *Provider) {
+		p.config = config
+	}
+}
+
+// WithConfigFile sets the KSM configuration file the CLI reads and, with
+// WithOneTimeToken, writes.
+func WithConfigFile(path string) ProviderOption {
+	return func(p *Provider) {
+		p.configFile = path
+	}
+}
+
+// WithOneTimeToken binds this device to a Secrets Manager application with a
+// one-time access token, such as "US:ONE_TIME_TOKEN", on first use. The
+// resulting configuration is written to the WithConfigFile file, which is
+// used as is if it already exists, as the token is only valid once.
+func WithOneTimeToken(token string) ProviderOption {
+	return func(p *Provider) {
+		p.token = token
+	}
+}
+
+// WithClient injects a custom Client implementation.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider reads secrets from Keeper Secrets Manager using the KSM CLI.
+// It implements secrets.Provider.
+//
+// A key naming a record by UID or title returns its fields as a JSON object
+// keyed by label, or by type for fields without one, so that fragments pick
+// fields: "<uid>#password", "<uid>#login". Fields with one value map to that
+// value; others to an array of their values.
+//
+// Keys with a slash are Keeper notation and return the value they refer to
+// as is: "<uid>/field/password", "<uid>/custom_field/API Key", or
+// "<uid>/file/id_rsa" for a file attachment.
+type Provider struct {
+	config     string
+	configFile string
+	token      string
+	client     Client
+}
+
+// New creates a new Keeper Provider with the given options.
+func New(opts ...ProviderOption) *Provider {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		p.client = &cliClient{config: p.config, configFile: p.configFile, token: p.token}
+	}
+	return p
+}
+
+// Get retrieves a record's fields as a JSON object, or the value a Keeper
+// notation key refers to.
+// Returns secrets.ErrNotFound (wrapped) if the record or field does not
+// exist, and errors.ErrUnsupported (wrapped) for notation keys if the Client
+// does not implement NotationClient.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	if strings.Contains(key, "/") {
+		nc, ok := p.client.(NotationClient)
+		if !ok {
+			return nil, fmt.Errorf("keeper: %q: %w", key, errors.ErrUnsupported)
+		}
+		val, err := nc.GetNotation(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("keeper: %q: %w", key, err)
+		}
+		return val, nil
+	}
+	rec, err := p.client.GetRecord(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("keeper: %q: %w", key, err)
+	}
+	fields := make(map[string]any)
+	for _, f := range slices.Concat(rec.Fields, rec.Custom) {
+		name := f.Label
+		if name == "" {
+			name = f.Type
+		}
+		if _, dup := fields[name]; dup || name == "" || len(f.Value) == 0 {
+			continue // the first of fields with the same name wins
+		}
+		if len(f.Value) == 1 {
+			fields[name] = f.Value[0]
+		} else {
+			fields[name] = f.Value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// uid matches Keeper record UIDs: 16 random bytes in unpadded base64url.
+var uid = regexp.MustCompile(`^[A-Za-z0-9_-]{22}$`)
+
+// cliClient shells out to the KSM CLI.
+type cliClient struct {
+	config     string
+	configFile string
+	token      string
+
+	mu    sync.Mutex
+	bound bool // whether the one-time token has been redeemed
+}
+
+// GetRecord runs ksm secret get, selecting the record by UID or title.
+func (c *cliClient) GetRecord(ctx context.Context, record string) (*Record, error) {
+	by := "--title"
+	if uid.MatchString(record) {
+		by = "--uid"
+	}
+	out, err := c.run(ctx, "secret", "get", by, record, "--json")
+	if err != nil {
+		return nil, err
+	}
+	var rec struct {
+		Record
+		CustomFields []Field `json:"custom_fields"`
+	}
+	if err := json.Unmarshal(out, &rec); err != nil {
+		return nil, fmt.Errorf("decode ksm output: %w", err)
+	}
+	rec.Custom = append(rec.Custom, rec.CustomFields...)
+	return &rec.Record, nil
+}
+
+// GetNotation runs ksm secret notation, which prints files as they are and
+// other values followed by a newline.
+func (c *cliClient) GetNotation(ctx context.Context, notation string) ([]byte, error) {
+	out, err := c.run(ctx, "secret", "notation", "keeper://"+notation)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(notation, "/file/") {
+		out = bytes.TrimSuffix(out, []byte("\n"))
+	}
+	return out, nil
+}
+
+// run runs ksm with args and returns its output, first binding the device
+// with the one-time token if one was given.
+func (c *cliClient) run(ctx context.Context, args ...string) ([]byte, error) {
+	if c.token != "" {
+		c.mu.Lock()
+		if !c.bound {
+			if err := c.init(ctx); err != nil {
+				c.mu.Unlock()
+				return nil, err
+			}
+			c.bound = true
+		}
+		c.mu.Unlock()
+	}
+	if c.configFile != "" {
+		args = append([]string{"--ini-file", c.configFile}, args...)
+	}
+	return c.exec(ctx, args...)
+}
+
+// init writes the configuration for the one-time token with ksm profile
+// init, unless the configuration file already exists.
+func (c *cliClient) init(ctx context.Context) error {
+	if c.configFile != "" {
+		if _, err := os.Stat(c.configFile); err == nil {
+			return nil
+		}
+	}
+	args := []string{"profile", "init", "--token", c.token}
+	if c.configFile != "" {
+		args = append([]string{"--ini-file", c.configFile}, args...)
+	}
+	if _, err := c.exec(ctx, args...); err != nil {
+		return fmt.Errorf("redeem one-time token: %w", err)
+	}
+	return nil
+}
+
+func (c *cliClient) exec(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "ksm", args...)
+	if c.config != "" {
+		cmd.Env = append(cmd.Environ(), "KSM_CONFIG="+c.config)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		errMsg := strings.TrimSpace(stderr.String())
+		lower := strings.ToLower(errMsg)
+		if strings.Contains(lower, "not found") || strings.Contains(lower, "cannot find") {
+			return nil, fmt.Errorf("%s: %w", errMsg, secrets.ErrNotFound)
+		}
+		if errMsg != "" {
+			return nil, errors.New(errMsg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
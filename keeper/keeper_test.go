@@ -0,0 +1,199 @@
+package keeper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// mockClient implements Client and NotationClient for testing.
+type mockClient struct {
+	records  map[string]*Record // by UID and by title
+	notation map[string]string
+}
+
+func (m *mockClient) GetRecord(_ context.Context, record string) (*Record, error) {
+	rec, ok := m.records[record]
+	if !ok {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return rec, nil
+}
+
+func (m *mockClient) GetNotation(_ context.Context, notation string) ([]byte, error) {
+	val, ok := m.notation[notation]
+	if !ok {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return []byte(val), nil
+}
+
+func newMock() *mockClient {
+	rec := &Record{
+		UID:   "Jd8Ya_uHNj5rNOqqGb2k3A",
+		Title: "prod db",
+		Fields: []Field{
+			{Type: "login", Value: []any{"app"}},
+			{Type: "password", Value: []any{"s3cret"}},
+			{Type: "url", Value: []any{}},
+		},
+		Custom: []Field{
+			{Type: "text", Label: "API Key", Value: []any{"k1"}},
+			{Type: "text", Label: "hosts", Value: []any{"a", "b"}},
+		},
+	}
+	return &mockClient{
+		records:  map[string]*Record{rec.UID: rec, rec.Title: rec},
+		notation: map[string]string{"Jd8Ya_uHNj5rNOqqGb2k3A/file/id_rsa": "-----BEGIN KEY-----\n"},
+	}
+}
+
+func TestGet_Record(t *testing.T) {
+	p := New(WithClient(newMock()))
+	for _, key := range []string{"Jd8Ya_uHNj5rNOqqGb2k3A", "prod db"} {
+		got, err := p.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", key, err)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(got, &fields); err != nil {
+			t.Fatalf("Get(%q) = %s, not a JSON object: %v", key, got, err)
+		}
+		want := map[string]any{"login": "app", "password": "s3cret", "API Key": "k1", "hosts": []any{"a", "b"}}
+		if !reflect.DeepEqual(fields, want) {
+			t.Errorf("Get(%q) = %v, want %v", key, fields, want)
+		}
+	}
+}
+
+func TestGet_Fragment(t *testing.T) {
+	r := secrets.NewResolver(secrets.WithProvider("keeper", New(WithClient(newMock()))))
+	var cfg struct {
+		User     string `secret:"keeper://Jd8Ya_uHNj5rNOqqGb2k3A#login"`
+		Password string `secret:"keeper://Jd8Ya_uHNj5rNOqqGb2k3A#password"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.User != "app" || cfg.Password != "s3cret" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+}
+
+func TestGet_Notation(t *testing.T) {
+	p := New(WithClient(newMock()))
+	got, err := p.Get(context.Background(), "Jd8Ya_uHNj5rNOqqGb2k3A/file/id_rsa")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != "-----BEGIN KEY-----\n" {
+		t.Errorf("Get = %q", got)
+	}
+}
+
+func TestGet_Missing(t *testing.T) {
+	p := New(WithClient(newMock()))
+	for _, key := range []string{"missing", "Jd8Ya_uHNj5rNOqqGb2k3A/field/oneTimeCode"} {
+		if _, err := p.Get(context.Background(), key); !errors.Is(err, secrets.ErrNotFound) {
+			t.Errorf("Get(%q): got %v, want ErrNotFound", key, err)
+		}
+	}
+}
+
+// recordOnly implements only Client.
+type recordOnly struct{ Client }
+
+func TestGet_NotationUnsupported(t *testing.T) {
+	p := New(WithClient(recordOnly{newMock()}))
+	if _, err := p.Get(context.Background(), "Jd8Ya_uHNj5rNOqqGb2k3A/field/password"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("got %v, want ErrUnsupported", err)
+	}
+}
+
+// fakeKSM installs a fake ksm on PATH that appends its arguments to a log
+// and runs script, returning the log's path.
+func fakeKSM(t *testing.T, script string) string {
+	t.Helper()
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	sh := "#!/bin/sh\necho \"$@\" >> " + log + "\n" + script
+	if err := os.WriteFile(filepath.Join(dir, "ksm"), []byte(sh), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return log
+}
+
+func TestCLIClient(t *testing.T) {
+	fakeKSM(t, `case "$*" in
+*"secret get --uid Jd8Ya_uHNj5rNOqqGb2k3A --json")
+	echo '{"uid": "Jd8Ya_uHNj5rNOqqGb2k3A", "fields": [{"type": "password", "value": ["s3cret"]}], "custom_fields": [{"type": "text", "label": "env", "value": ["prod"]}]}' ;;
+*"secret get --title prod db --json")
+	echo '{"fields": [{"type": "login", "value": ["app"]}]}' ;;
+*"secret notation keeper://Jd8Ya_uHNj5rNOqqGb2k3A/field/password")
+	echo s3cret ;;
+*"secret notation keeper://Jd8Ya_uHNj5rNOqqGb2k3A/file/key")
+	printf 'line\n' ;;
+*)
+	echo "Error: record not found" >&2; exit 1 ;;
+esac
+`)
+	p := New()
+	ctx := context.Background()
+	tests := []struct {
+		key, want string
+	}{
+		{"Jd8Ya_uHNj5rNOqqGb2k3A", `{"env":"prod","password":"s3cret"}`},
+		{"prod db", `{"login":"app"}`},
+		{"Jd8Ya_uHNj5rNOqqGb2k3A/field/password", "s3cret"},
+		{"Jd8Ya_uHNj5rNOqqGb2k3A/file/key", "line\n"},
+	}
+	for _, tc := range tests {
+		if val, err := p.Get(ctx, tc.key); err != nil || string(val) != tc.want {
+			t.Errorf("Get(%q) = %q, %v; want %q", tc.key, val, err, tc.want)
+		}
+	}
+	if _, err := p.Get(ctx, "other"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("missing record: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestCLIClient_OneTimeToken(t *testing.T) {
+	log := fakeKSM(t, `echo '{"fields": []}'`)
+	config := filepath.Join(t.TempDir(), "keeper.ini")
+	p := New(WithOneTimeToken("US:tok"), WithConfigFile(config))
+	for range 2 {
+		if _, err := p.Get(context.Background(), "Jd8Ya_uHNj5rNOqqGb2k3A"); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+	got, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--ini-file " + config + " profile init --token US:tok\n" +
+		"--ini-file " + config + " secret get --uid Jd8Ya_uHNj5rNOqqGb2k3A --json\n" +
+		"--ini-file " + config + " secret get --uid Jd8Ya_uHNj5rNOqqGb2k3A --json\n"
+	if string(got) != want {
+		t.Errorf("ksm invocations:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCLIClient_Config(t *testing.T) {
+	fakeKSM(t, `printf '{"fields": [{"type": "text", "label": "config", "value": ["%s"]}]}' "$KSM_CONFIG"`)
+	p := New(WithConfig("eyJjbGllbnRJZCI6"))
+	got, err := p.Get(context.Background(), "Jd8Ya_uHNj5rNOqqGb2k3A")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != `{"config":"eyJjbGllbnRJZCI6"}` {
+		t.Errorf("Get = %s, want KSM_CONFIG passed to ksm", got)
+	}
+}
@@ -0,0 +1,166 @@
+// Package keyring provides a secret provider that reads and stores secrets
+// in the operating system's keyring: the macOS Keychain, the Windows
+// Credential Manager, or a Secret Service such as GNOME Keyring on Linux.
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+	gokeyring "github.com/zalando/go-keyring"
+)
+
+// Client abstracts the OS keyring, which stores a password for each account
+// of a service. Implement this interface to provide a custom client or for
+// testing.
+type Client interface {
+	// Get returns the password of account in service.
+	Get(ctx context.Context, service, account string) (string, error)
+	// Set stores the password of account in service.
+	Set(ctx context.Context, service, account, password string) error
+	// Delete removes account from service.
+	Delete(ctx context.Context, service, account string) error
+}
+
+// ProviderOption configures the keyring Provider.
+type ProviderOption func(*Provider)
+
+// WithService sets the service, such as the name of a CLI, whose accounts
+// keys name. Without it, keys name the service too.
+func WithService(service string) ProviderOption {
+	return func(p *Provider) {
+		p.service = service
+	}
+}
+
+// WithClient injects a custom Client implementation.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider reads and stores secrets in the OS keyring.
+// It implements secrets.Provider and secrets.WriterProvider.
+//
+// With WithService, keys are account names in that service. Without it, keys
+// are "service/account", split at the first slash:
+//
+//	api-token          // with WithService("mycli")
+//	mycli/api-token    // without
+//
+// Keyrings store text: store binary values base64-encoded, and read them
+// with the base64 transform. Platforms without a supported keyring return
+// errors.ErrUnsupported (wrapped).
+type Provider struct {
+	service string
+	client  Client
+}
+
+// New creates a new keyring Provider with the given options.
+func New(opts ...ProviderOption) *Provider {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		p.client = osClient{}
+	}
+	return p
+}
+
+// Get retrieves the password of the account key names.
+// Returns secrets.ErrNotFound (wrapped) if there is none.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	service, account, err := p.parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	val, err := p.client.Get(ctx, service, account)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: secret %q: %w", key, keyringError(err))
+	}
+	return []byte(val), nil
+}
+
+// Set stores value as the password of the account key names, replacing any
+// stored before.
+func (p *Provider) Set(ctx context.Context, key string, value []byte) error {
+	service, account, err := p.parseKey(key)
+	if err != nil {
+		return err
+	}
+	if err := p.client.Set(ctx, service, account, string(value)); err != nil {
+		return fmt.Errorf("keyring: secret %q: %w", key, keyringError(err))
+	}
+	return nil
+}
+
+// Delete removes the account key names.
+// Returns secrets.ErrNotFound (wrapped) if there is none.
+func (p *Provider) Delete(ctx context.Context, key string) error {
+	service, account, err := p.parseKey(key)
+	if err != nil {
+		return err
+	}
+	if err := p.client.Delete(ctx, service, account); err != nil {
+		return fmt.Errorf("keyring: secret %q: %w", key, keyringError(err))
+	}
+	return nil
+}
+
+// parseKey returns the service and account key names.
+func (p *Provider) parseKey(key string) (service, account string, err error) {
+	if p.service != "" {
+		service, account = p.service, key
+	} else {
+		service, account, _ = strings.Cut(key, "/")
+	}
+	if service == "" || account == "" {
+		if p.service != "" {
+			return "", "", fmt.Errorf("keyring: invalid key %q: want an account name", key)
+		}
+		return "", "", fmt.Errorf("keyring: invalid key %q: want service/account", key)
+	}
+	return service, account, nil
+}
+
+// keyringError maps the errors of go-keyring to those of this module.
+func keyringError(err error) error {
+	switch {
+	case errors.Is(err, gokeyring.ErrNotFound):
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	case errors.Is(err, gokeyring.ErrUnsupportedPlatform):
+		return fmt.Errorf("%w: %w", err, errors.ErrUnsupported)
+	}
+	return err
+}
+
+// osClient implements Client with go-keyring, which uses the Keychain
+// through /usr/bin/security on macOS, the Credential Manager API on
+// Windows, and the Secret Service D-Bus API elsewhere.
+type osClient struct{}
+
+func (osClient) Get(ctx context.Context, service, account string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return gokeyring.Get(service, account)
+}
+
+func (osClient) Set(ctx context.Context, service, account, password string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return gokeyring.Set(service, account, password)
+}
+
+func (osClient) Delete(ctx context.Context, service, account string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return gokeyring.Delete(service, account)
+}
@@ -0,0 +1,95 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+	gokeyring "github.com/zalando/go-keyring"
+)
+
+func TestGetSetDelete(t *testing.T) {
+	gokeyring.MockInit()
+	p := New(WithService("mycli"))
+	ctx := context.Background()
+
+	if _, err := p.Get(ctx, "api-token"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Fatalf("Get before Set: error = %v, want ErrNotFound", err)
+	}
+	if err := p.Set(ctx, "api-token", []byte("t0k")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	got, err := p.Get(ctx, "api-token")
+	if err != nil || string(got) != "t0k" {
+		t.Errorf("Get = %q, %v; want t0k", got, err)
+	}
+
+	// The same account is reachable by service/account without WithService.
+	got, err = New().Get(ctx, "mycli/api-token")
+	if err != nil || string(got) != "t0k" {
+		t.Errorf("Get(mycli/api-token) = %q, %v; want t0k", got, err)
+	}
+
+	if err := p.Delete(ctx, "api-token"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := p.Get(ctx, "api-token"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get after Delete: error = %v, want ErrNotFound", err)
+	}
+	if err := p.Delete(ctx, "api-token"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("second Delete: error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	gokeyring.MockInit()
+	p := New()
+	ctx := context.Background()
+	if err := secrets.Store(ctx, p, "gh/token", []byte("ghp")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if got, err := p.Get(ctx, "gh/token"); err != nil || string(got) != "ghp" {
+		t.Errorf("Get = %q, %v", got, err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	p := New(WithClient(failClient{}))
+	for _, key := range []string{"", "mycli", "/token", "mycli/"} {
+		if _, err := p.Get(context.Background(), key); err == nil || errors.Is(err, errFail) {
+			t.Errorf("Get(%q) error = %v, want an invalid key error", key, err)
+		}
+	}
+	// Accounts may contain slashes.
+	service, account, err := p.parseKey("mycli/https://api.example.com")
+	if err != nil || service != "mycli" || account != "https://api.example.com" {
+		t.Errorf("parseKey = %q, %q, %v", service, account, err)
+	}
+	if _, err := New(WithService("mycli")).Get(context.Background(), ""); err == nil {
+		t.Error("Get of an empty account succeeded")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	gokeyring.MockInitWithError(gokeyring.ErrUnsupportedPlatform)
+	t.Cleanup(gokeyring.MockInit)
+	p := New(WithService("mycli"))
+	if _, err := p.Get(context.Background(), "token"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Get on an unsupported platform: error = %v, want ErrUnsupported", err)
+	}
+
+	p = New(WithService("mycli"), WithClient(failClient{}))
+	if err := p.Set(context.Background(), "token", nil); !errors.Is(err, errFail) {
+		t.Errorf("Set error = %v, want the client's error", err)
+	}
+}
+
+var errFail = errors.New("dbus: no Secret Service")
+
+// failClient fails every call.
+type failClient struct{}
+
+func (failClient) Get(context.Context, string, string) (string, error) { return "", errFail }
+func (failClient) Set(context.Context, string, string, string) error   { return errFail }
+func (failClient) Delete(context.Context, string, string) error        { return errFail }
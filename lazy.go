@@ -0,0 +1,131 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Secret is a struct field type for a secret that is fetched lazily. Resolve
+// does not fetch it; instead it binds the field to its provider, and the value
+// is fetched on the first call to Get. The `ttl=` tag option makes Get fetch
+// the value again once it is older than the TTL; without it, the first value
+// is kept.
+//
+// Use Secret for rarely used credentials, and in large configurations where
+// fetching everything at startup is too slow:
+//
+//	type Config struct {
+//	    ReportKey secrets.Secret[string] `secret:"awssm://reports/api-key,ttl=1h"`
+//	}
+//
+// T may be any type supported for eager fields. Copies of a Secret share its
+// cached value. Secret is safe for concurrent use; concurrent calls to Get
+// share a single fetch.
+type Secret[T any] struct {
+	h *lazyHandle[T]
+}
+
+// lazyHandle holds the binding and cached value of a Secret.
+type lazyHandle[T any] struct {
+	r     *Resolver
+	field string
+	tag   parsedTag
+
+	mu      sync.Mutex
+	value   T
+	fetched time.Time // zero until the first successful fetch
+}
+
+// lazyField is implemented by *Secret[T] so that the resolver can bind
+// Secret fields without knowing T.
+type lazyField interface {
+	bind(r *Resolver, field string, tag parsedTag)
+	valueType() reflect.Type
+}
+
+var lazyFieldType = reflect.TypeFor[lazyField]()
+
+// isLazyType reports whether t is a Secret[T] type.
+func isLazyType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(lazyFieldType)
+}
+
+func (s *Secret[T]) bind(r *Resolver, field string, tag parsedTag) {
+	s.h = &lazyHandle[T]{r: r, field: field, tag: tag}
+}
+
+func (s *Secret[T]) valueType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// Get returns the secret value, fetching it if it has not been fetched yet or
+// if the cached value is older than the `ttl=` tag option. If the field is
+// `optional` and the secret does not exist, Get returns the zero value and a
+// nil error. Get fails if the struct holding the Secret has not been resolved.
+func (s *Secret[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	h := s.h
+	if h == nil {
+		return zero, errors.New("secrets: Secret field has not been resolved")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.fetched.IsZero() && (h.tag.TTL == 0 || time.Since(h.fetched) < h.tag.TTL) {
+		return h.value, nil
+	}
+
+	data, err := h.r.fetchValue(ctx, h.field, h.tag)
+	if err != nil {
+		if h.tag.Optional && errors.Is(err, ErrNotFound) {
+			return zero, nil
+		}
+		return zero, err
+	}
+	var v T
+	if err := h.r.setField(reflect.ValueOf(&v).Elem(), h.field, data); err != nil {
+		return zero, err
+	}
+	h.value = v
+	h.fetched = time.Now()
+	return v, nil
+}
+
+// Invalidate discards the cached value, so that the next Get fetches the
+// secret again.
+func (s *Secret[T]) Invalidate() {
+	h := s.h
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var zero T
+	h.value = zero
+	h.fetched = time.Time{}
+}
+
+// fetchValue fetches the value for tag from its provider and extracts it as
+// Resolve would. field is used for error reporting.
+func (r *Resolver) fetchValue(ctx context.Context, field string, tag parsedTag) ([]byte, error) {
+	p, providerName, err := r.providerFor(field, tag)
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.fetch(ctx, p, providerName, field, tag.Key, tag.Version)
+	if r.cfg.bootstrap && tag.Bootstrap != "" && errors.Is(err, ErrNotFound) {
+		data, err = r.bootstrapValue(ctx, p, providerName, tag)
+	}
+	if err != nil {
+		return nil, &ErrResolve{Field: field, URI: tag.URI(), Provider: providerName, Version: tag.Version, Err: err}
+	}
+	data, err = r.extractValue(tag, data)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: field %s: %w", field, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,197 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSecret_FetchedOnFirstGet(t *testing.T) {
+	store := &countingSyncMapProvider{}
+	store.Store("api-key", []byte("k1"))
+	store.Store("port", []byte("8080"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		APIKey Secret[string] `secret:"api-key"`
+		Port   Secret[int]    `secret:"port"`
+	}
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if n := store.gets("api-key"); n != 0 {
+		t.Fatalf("Resolve fetched the lazy secret %d times, want 0", n)
+	}
+
+	for range 3 {
+		v, err := cfg.APIKey.Get(context.Background())
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if v != "k1" {
+			t.Errorf("Get = %q, want %q", v, "k1")
+		}
+	}
+	if n := store.gets("api-key"); n != 1 {
+		t.Errorf("provider calls = %d, want 1", n)
+	}
+
+	port, err := cfg.Port.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if port != 8080 {
+		t.Errorf("Port = %d, want 8080", port)
+	}
+}
+
+func TestSecret_TTL(t *testing.T) {
+	store := &countingSyncMapProvider{}
+	store.Store("api-key", []byte("k1"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		APIKey Secret[string] `secret:"api-key,ttl=20ms"`
+	}
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if v, _ := cfg.APIKey.Get(context.Background()); v != "k1" {
+		t.Fatalf("Get = %q, want %q", v, "k1")
+	}
+
+	store.Store("api-key", []byte("k2"))
+	if v, _ := cfg.APIKey.Get(context.Background()); v != "k1" {
+		t.Errorf("Get before expiry = %q, want cached %q", v, "k1")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if v, _ := cfg.APIKey.Get(context.Background()); v != "k2" {
+		t.Errorf("Get after expiry = %q, want %q", v, "k2")
+	}
+}
+
+func TestSecret_Invalidate(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("api-key", []byte("k1"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		APIKey Secret[[]byte] `secret:"api-key"`
+	}
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if v, _ := cfg.APIKey.Get(context.Background()); string(v) != "k1" {
+		t.Fatalf("Get = %q, want %q", v, "k1")
+	}
+	store.Store("api-key", []byte("k2"))
+	cfg.APIKey.Invalidate()
+	if v, _ := cfg.APIKey.Get(context.Background()); string(v) != "k2" {
+		t.Errorf("Get after Invalidate = %q, want %q", v, "k2")
+	}
+}
+
+func TestSecret_ConcurrentGetSharesFetch(t *testing.T) {
+	store := &countingSyncMapProvider{}
+	store.Store("api-key", []byte("k1"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		APIKey Secret[string] `secret:"api-key"`
+	}
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if _, err := cfg.APIKey.Get(context.Background()); err != nil {
+				t.Errorf("Get: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	if n := store.gets("api-key"); n != 1 {
+		t.Errorf("provider calls = %d, want 1", n)
+	}
+}
+
+func TestSecret_Errors(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("bad-int", []byte("abc"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Missing  Secret[string] `secret:"missing"`
+		Optional Secret[string] `secret:"missing,optional"`
+		BadInt   Secret[int]    `secret:"bad-int"`
+	}
+	var cfg Config
+
+	if _, err := cfg.Missing.Get(context.Background()); err == nil {
+		t.Error("Get on unresolved Secret: expected error, got nil")
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if _, err := cfg.Missing.Get(context.Background()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get missing: expected ErrNotFound, got %v", err)
+	}
+	if v, err := cfg.Optional.Get(context.Background()); err != nil || v != "" {
+		t.Errorf("Get optional missing = %q, %v, want zero value and nil", v, err)
+	}
+	var convErr *ErrConversion
+	if _, err := cfg.BadInt.Get(context.Background()); !errors.As(err, &convErr) {
+		t.Errorf("Get bad int: expected ErrConversion, got %v", err)
+	}
+}
+
+func TestSecret_Validate(t *testing.T) {
+	r := NewResolver(WithDefault(&syncMapProvider{}))
+
+	type Good struct {
+		Key Secret[string] `secret:"key,ttl=1h"`
+	}
+	if err := r.Validate(&Good{}); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	type BadType struct {
+		Key Secret[map[string]string] `secret:"key"`
+	}
+	var unsupported *ErrUnsupportedType
+	if err := r.Validate(&BadType{}); !errors.As(err, &unsupported) {
+		t.Errorf("Validate unsupported type: expected ErrUnsupportedType, got %v", err)
+	}
+
+	type EagerTTL struct {
+		Key string `secret:"key,ttl=1h"`
+	}
+	if err := r.Validate(&EagerTTL{}); err == nil || !strings.Contains(err.Error(), "ttl= requires a Secret[T] field") {
+		t.Errorf("Validate ttl on eager field: got %v", err)
+	}
+}
+
+func TestSecret_ErrResolve(t *testing.T) {
+	r := NewResolver(WithDefault(deniedProvider{}))
+	var cfg struct {
+		Key Secret[string] `secret:"key"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	_, err := cfg.Key.Get(context.Background())
+	var re *ErrResolve
+	var pe *ErrProvider
+	if !errors.As(err, &re) || re.Field != "Key" || re.Provider != "default" || !errors.As(re, &pe) {
+		t.Errorf("expected ErrResolve for field Key, got %v", err)
+	}
+}
@@ -21,7 +21,8 @@ func WithVersions(versions map[string]map[string][]byte) ProviderOption {
 }
 
 // Provider is a map-based secrets.Provider intended for testing.
-// It supports both Get and GetVersion (implements secrets.VersionedProvider).
+// It supports both Get and GetVersion (implements secrets.VersionedProvider)
+// and implements secrets.CheckerProvider.
 type Provider struct {
 	data     map[string][]byte
 	versions map[string]map[string][]byte // key -> version -> value
@@ -64,3 +65,12 @@ func (p *Provider) GetVersion(_ context.Context, key, version string) ([]byte, e
 	}
 	return v, nil
 }
+
+// Check reports whether the key exists.
+// Returns secrets.ErrNotFound (wrapped) if the key does not exist.
+func (p *Provider) Check(_ context.Context, key string) error {
+	if _, ok := p.data[key]; !ok {
+		return fmt.Errorf("literal: %q: %w", key, secrets.ErrNotFound)
+	}
+	return nil
+}
@@ -96,3 +96,15 @@ func TestGetVersion_NoVersionsConfigured(t *testing.T) {
 		t.Errorf("expected ErrNotFound, got: %v", err)
 	}
 }
+
+func TestCheck(t *testing.T) {
+	p := literal.New(map[string][]byte{
+		"db-pass": []byte("s3cret"),
+	})
+	if err := p.Check(context.Background(), "db-pass"); err != nil {
+		t.Errorf("Check existing: %v", err)
+	}
+	if err := p.Check(context.Background(), "no-such-key"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check missing: expected ErrNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// envTag holds the components of an `env` struct tag.
+type envTag struct {
+	Name     string // environment variable name
+	Required bool   // true if ,required is set
+	Default  string // value if the variable is unset (from ,default=X)
+	Defaults bool   // true if ,default= is set, possibly to ""
+}
+
+// parseEnvTag parses an `env` tag with the format:
+//
+//	NAME[,required][,default=value]
+//
+// A default runs to the end of the tag, so it may contain commas.
+func parseEnvTag(raw string) (envTag, error) {
+	var t envTag
+	name, opts, _ := strings.Cut(raw, ",")
+	t.Name = name
+	if t.Name == "" {
+		return envTag{}, errors.New("secrets: empty env tag")
+	}
+	for opts != "" {
+		if def, ok := strings.CutPrefix(opts, "default="); ok {
+			t.Default, t.Defaults = def, true
+			break
+		}
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		switch opt {
+		case "required":
+			t.Required = true
+		default:
+			return envTag{}, fmt.Errorf("secrets: unknown env tag option %q", opt)
+		}
+	}
+	if t.Required && t.Defaults {
+		return envTag{}, errors.New("secrets: env tag options required and default= conflict")
+	}
+	return t, nil
+}
+
+// Load populates dst, which must be a non-nil pointer to a struct, from
+// environment variables and secrets in one pass, so that a configuration
+// struct can mix both:
+//
+//	type Config struct {
+//		Port       int           `env:"PORT,default=8080"`
+//		LogLevel   string        `env:"LOG_LEVEL"`
+//		Timeout    time.Duration `env:"TIMEOUT,required"`
+//		DBPassword string        `env:"DB_PASSWORD" secret:"awssm://prod/db#password"`
+//	}
+//
+// A field with an `env` tag is set from the named variable, converted as
+// secrets are. If the variable is unset or empty, the field is set to the
+// tag's default= value, if any; is an error if the tag is required; and is
+// left as it is otherwise. The options required and default= conflict.
+//
+// A field with both tags is set from the variable if it is set and not
+// empty, and its secret is then not fetched, so that a deployment or a
+// developer can override a secret locally. Otherwise the field is resolved
+// from its secret, which decides whether it may be missing; the variable's
+// options do not apply. Fields with only a `secret` tag are resolved as
+// Resolve does.
+//
+// Errors from variables and secrets are collected and joined. A required
+// variable that is unset is reported as an error wrapping ErrNotFound.
+func (r *Resolver) Load(ctx context.Context, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("secrets: dst must be a non-nil pointer, got %T", dst)
+	}
+	elem := rv.Elem()
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("secrets: dst must point to a struct, got pointer to %s", elem.Kind())
+	}
+
+	overridden := make(map[fieldAddr]bool)
+	var errs []error
+	r.loadEnv(elem, overridden, &errs)
+	_, err := r.resolveExcept(ctx, dst, func(fv reflect.Value) bool {
+		return overridden[addrOf(fv)]
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// fieldAddr identifies a struct field by its address and type, as a nested
+// struct shares its address with its first field.
+type fieldAddr struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+func addrOf(fv reflect.Value) fieldAddr {
+	return fieldAddr{fv.UnsafeAddr(), fv.Type()}
+}
+
+// loadEnv sets the `env`-tagged fields of sv, recursing as collectFields
+// does, and records those with a `secret` tag that a variable overrode.
+func (r *Resolver) loadEnv(sv reflect.Value, overridden map[fieldAddr]bool, errs *[]error) {
+	st := sv.Type()
+	for i := range st.NumField() {
+		field := st.Field(i)
+		fv := sv.Field(i)
+
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			r.loadEnv(fv, overridden, errs)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		raw, ok := field.Tag.Lookup("env")
+		if !ok {
+			if _, ok := field.Tag.Lookup("secret"); ok {
+				continue
+			}
+			ft, v := field.Type, fv
+			if ft.Kind() == reflect.Pointer {
+				if ft.Elem().Kind() != reflect.Struct || !hasTags(ft.Elem(), "env") {
+					continue
+				}
+				if v.IsNil() {
+					v.Set(reflect.New(ft.Elem()))
+				}
+				ft, v = ft.Elem(), v.Elem()
+			}
+			if ft.Kind() == reflect.Struct && hasTags(ft, "env") {
+				r.loadEnv(v, overridden, errs)
+			}
+			continue
+		}
+
+		tag, err := parseEnvTag(raw)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("secrets: field %s: %w", field.Name, err))
+			continue
+		}
+		_, hasSecret := field.Tag.Lookup("secret")
+		if hasSecret && (tag.Required || tag.Defaults) {
+			*errs = append(*errs, fmt.Errorf("secrets: field %s: env tag options do not apply to a field with a secret tag", field.Name))
+			continue
+		}
+
+		value := os.Getenv(tag.Name)
+		switch {
+		case value != "":
+			if hasSecret {
+				overridden[addrOf(fv)] = true
+			}
+		case hasSecret:
+			continue
+		case tag.Defaults:
+			value = tag.Default
+		case tag.Required:
+			*errs = append(*errs, fmt.Errorf("secrets: field %s: environment variable %s: %w", field.Name, tag.Name, ErrNotFound))
+			continue
+		default:
+			continue
+		}
+		if err := r.setField(fv, field.Name, []byte(value)); err != nil {
+			*errs = append(*errs, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEnvTag(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    envTag
+		wantErr bool
+	}{
+		{raw: "PORT", want: envTag{Name: "PORT"}},
+		{raw: "PORT,required", want: envTag{Name: "PORT", Required: true}},
+		{raw: "PORT,default=8080", want: envTag{Name: "PORT", Default: "8080", Defaults: true}},
+		{raw: "HOSTS,default=a,b", want: envTag{Name: "HOSTS", Default: "a,b", Defaults: true}},
+		{raw: "EMPTY,default=", want: envTag{Name: "EMPTY", Defaults: true}},
+		{raw: "", wantErr: true},
+		{raw: "PORT,bogus", wantErr: true},
+		{raw: "PORT,required,default=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseEnvTag(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEnvTag(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseEnvTag(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	p := &checkerProvider{data: map[string][]byte{
+		"db-pass": []byte("from-secret"),
+		"api-key": []byte("key"),
+	}}
+	r := NewResolver(WithDefault(p))
+
+	type DB struct {
+		Host     string `env:"DB_HOST,default=localhost"`
+		Password string `env:"DB_PASSWORD" secret:"db-pass"`
+	}
+	type Config struct {
+		Port    int           `env:"PORT,default=8080"`
+		Debug   bool          `env:"DEBUG"`
+		Timeout time.Duration `env:"TIMEOUT,required"`
+		Name    string        `env:"NAME"`
+		APIKey  string        `env:"API_KEY" secret:"api-key"`
+		DB      *DB
+	}
+
+	t.Setenv("PORT", "")
+	t.Setenv("DEBUG", "true")
+	t.Setenv("TIMEOUT", "5s")
+	t.Setenv("API_KEY", "")
+	t.Setenv("DB_PASSWORD", "from-env")
+
+	cfg := Config{Name: "unchanged"}
+	if err := r.Load(context.Background(), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 8080 || !cfg.Debug || cfg.Timeout != 5*time.Second || cfg.Name != "unchanged" {
+		t.Errorf("env fields = %+v", cfg)
+	}
+	if cfg.APIKey != "key" {
+		t.Errorf("APIKey = %q, want the secret", cfg.APIKey)
+	}
+	if cfg.DB == nil || cfg.DB.Host != "localhost" || cfg.DB.Password != "from-env" {
+		t.Errorf("DB = %+v, want the default host and the password from the environment", cfg.DB)
+	}
+	if n := p.gets.Load(); n != 1 {
+		t.Errorf("%d fetches, want 1: an overridden secret should not be fetched", n)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{data: map[string][]byte{}}))
+
+	t.Run("required", func(t *testing.T) {
+		var cfg struct {
+			Port int    `env:"LOAD_TEST_PORT,required"`
+			Pass string `secret:"missing"`
+		}
+		err := r.Load(context.Background(), &cfg)
+		if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "LOAD_TEST_PORT") {
+			t.Errorf("err = %v, want the unset variable reported", err)
+		}
+		if !strings.Contains(err.Error(), "missing") {
+			t.Errorf("err = %v, want the missing secret reported too", err)
+		}
+	})
+
+	t.Run("conversion", func(t *testing.T) {
+		t.Setenv("LOAD_TEST_PORT", "http")
+		var cfg struct {
+			Port int `env:"LOAD_TEST_PORT"`
+		}
+		var convErr *ErrConversion
+		if err := r.Load(context.Background(), &cfg); !errors.As(err, &convErr) {
+			t.Errorf("err = %v, want ErrConversion", err)
+		}
+	})
+
+	t.Run("options with secret", func(t *testing.T) {
+		var cfg struct {
+			Pass string `env:"LOAD_TEST_PASS,default=x" secret:"pass,optional"`
+		}
+		if err := r.Load(context.Background(), &cfg); err == nil {
+			t.Error("expected an error for default= on a field with a secret tag")
+		}
+	})
+
+	t.Run("not a struct pointer", func(t *testing.T) {
+		var s string
+		if err := r.Load(context.Background(), &s); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithLogger makes the Resolver log its activity to l:
+//
+//   - each provider fetch, at Debug, or at Warn if it fails with an error
+//     other than ErrNotFound;
+//   - each Resolve, at Debug, with the number of fields and its duration;
+//   - Watcher polls that fail (Warn) or detect a change (Info);
+//   - retries of the initial resolve configured with WithInitialRetry (Warn).
+//
+// Records carry the field, provider, key, version, and duration as
+// attributes, but never secret values. By default nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *resolverConfig) {
+		c.logger = l
+	}
+}
+
+// log logs a record to the configured logger, if any.
+func (r *Resolver) log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if r.cfg.logger == nil {
+		return
+	}
+	r.cfg.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// logFetch logs the outcome of a provider fetch.
+func (r *Resolver) logFetch(ctx context.Context, providerName, key, version string, d time.Duration, err error) {
+	if r.cfg.logger == nil {
+		return
+	}
+	attrs := make([]slog.Attr, 0, 5)
+	attrs = append(attrs, slog.String("provider", providerName), slog.String("key", key))
+	if version != "" {
+		attrs = append(attrs, slog.String("version", version))
+	}
+	attrs = append(attrs, slog.Duration("duration", d))
+	switch {
+	case err == nil:
+		r.log(ctx, slog.LevelDebug, "secret fetched", attrs...)
+	case errors.Is(err, ErrNotFound):
+		r.log(ctx, slog.LevelDebug, "secret not found", attrs...)
+	default:
+		attrs = append(attrs, r.errorAttr(err))
+		var pe *ErrProvider
+		if errors.As(err, &pe) {
+			attrs = append(attrs, providerErrorAttrs(pe)...)
+		}
+		r.log(ctx, slog.LevelWarn, "secret fetch failed", attrs...)
+	}
+}
+
+// providerErrorAttrs returns the details of pe that the service reported.
+func providerErrorAttrs(pe *ErrProvider) []slog.Attr {
+	var attrs []slog.Attr
+	if pe.StatusCode != 0 {
+		attrs = append(attrs, slog.Int("status", pe.StatusCode))
+	}
+	if pe.Code != "" {
+		attrs = append(attrs, slog.String("code", pe.Code))
+	}
+	if pe.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", pe.RequestID))
+	}
+	if pe.RetryAfter > 0 {
+		attrs = append(attrs, slog.Duration("retry_after", pe.RetryAfter))
+	}
+	return attrs
+}
+
+// errorAttr returns err as an "error" attribute. The raw values quoted by
+// conversion errors, and any value known to the Resolver's Redactor, are
+// replaced with "[REDACTED]" in the message.
+func (r *Resolver) errorAttr(err error) slog.Attr {
+	msg := err.Error()
+	for _, raw := range conversionRaws(err) {
+		msg = strings.ReplaceAll(msg, strconv.Quote(raw), strconv.Quote(redactedText))
+	}
+	return slog.String("error", r.redactor.Redact(msg))
+}
+
+// conversionRaws returns the raw values of the ErrConversion errors in err's
+// tree.
+func conversionRaws(err error) []string {
+	var raws []string
+	var walk func(error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case *ErrConversion:
+			if e.Raw != "" {
+				raws = append(raws, e.Raw)
+			}
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				walk(err)
+			}
+		case interface{ Unwrap() error }:
+			if err := e.Unwrap(); err != nil {
+				walk(err)
+			}
+		}
+	}
+	walk(err)
+	return raws
+}
@@ -0,0 +1,178 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// logBuffer is a concurrency-safe buffer for a slog.TextHandler.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newTestLogger() (*slog.Logger, *logBuffer) {
+	buf := &logBuffer{}
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})), buf
+}
+
+func TestWithLogger_Fetches(t *testing.T) {
+	logger, buf := newTestLogger()
+	p := &mockVersionedProvider{
+		data:     map[string][]byte{"db": []byte("hunter22")},
+		versions: map[string]map[string][]byte{"db": {"previous": []byte("hunter11")}},
+	}
+	r := NewResolver(WithDefault(p), WithLogger(logger))
+
+	type Config struct {
+		DB      Versioned[string] `secret:"db"`
+		Missing string            `secret:"missing,optional"`
+	}
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		`level=DEBUG msg="secret fetched" provider=default key=db duration=`,
+		`level=DEBUG msg="secret fetched" provider=default key=db version=previous duration=`,
+		`level=DEBUG msg="secret not found" provider=default key=missing`,
+		`level=DEBUG msg="secrets resolved" fields=2 errors=0 duration=`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hunter") {
+		t.Errorf("log reveals a secret value:\n%s", out)
+	}
+}
+
+func TestWithLogger_FetchFailure(t *testing.T) {
+	logger, buf := newTestLogger()
+	p := &toggleProvider{}
+	p.fail.Store(true)
+	r := NewResolver(WithDefault(p), WithLogger(logger))
+
+	var cfg struct {
+		Key string `secret:"key"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err == nil {
+		t.Fatal("expected error")
+	}
+	if want := `level=WARN msg="secret fetch failed" provider=default key=key`; !strings.Contains(buf.String(), want) {
+		t.Errorf("log missing %q:\n%s", want, buf.String())
+	}
+	if !strings.Contains(buf.String(), `error="`+errFlaky.Error()+`"`) {
+		t.Errorf("log missing error:\n%s", buf.String())
+	}
+}
+
+func TestWithLogger_ErrProvider(t *testing.T) {
+	logger, buf := newTestLogger()
+	r := NewResolver(WithDefault(deniedProvider{}), WithLogger(logger))
+
+	var cfg struct {
+		Key string `secret:"key"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err == nil {
+		t.Fatal("expected error")
+	}
+	if want := `status=403 code=AccessDenied request_id=req-123`; !strings.Contains(buf.String(), want) {
+		t.Errorf("log missing %q:\n%s", want, buf.String())
+	}
+}
+
+func TestWithLogger_Watch(t *testing.T) {
+	logger, buf := newTestLogger()
+	store := &syncMapProvider{}
+	store.Store("port", []byte("5432"))
+	r := NewResolver(WithDefault(store), WithLogger(logger))
+
+	type Config struct {
+		Port int `secret:"port"`
+	}
+	var cfg Config
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, err := r.Watch(ctx, &cfg, WatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.Store("port", []byte("5433"))
+	select {
+	case <-w.Changes():
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change")
+	}
+	if want := `level=INFO msg="secret changed" field=Port provider=default key=port`; !strings.Contains(buf.String(), want) {
+		t.Errorf("log missing %q:\n%s", want, buf.String())
+	}
+
+	// A value that fails conversion is not quoted in the poll failure.
+	store.Store("port", []byte("pw-hunter22"))
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(buf.String(), "secret poll failed") {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for poll failure:\n%s", buf.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if strings.Contains(buf.String(), "hunter22") {
+		t.Errorf("log reveals a secret value:\n%s", buf.String())
+	}
+}
+
+func TestWithLogger_InitialRetry(t *testing.T) {
+	logger, buf := newTestLogger()
+	p := &flakyProvider{failures: 1}
+	p.Store("key", []byte("value"))
+	r := NewResolver(WithDefault(p), WithLogger(logger))
+
+	var cfg struct {
+		Key string `secret:"key"`
+	}
+	w, err := r.Watch(context.Background(), &cfg,
+		WithInitialRetry(RetryPolicy{InitialInterval: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	w.Stop()
+	if want := `level=WARN msg="initial resolve failed; retrying" attempt=1 delay=1ms`; !strings.Contains(buf.String(), want) {
+		t.Errorf("log missing %q:\n%s", want, buf.String())
+	}
+}
+
+func TestErrorAttr_ScrubsValues(t *testing.T) {
+	r := NewResolver()
+	r.Redactor().Add("known-secret")
+	err := errors.Join(
+		&ErrConversion{Field: "Port", TypeName: "int", Raw: "abc", Err: errors.New(`parsing "abc": invalid syntax`)},
+		errors.New("upstream echoed known-secret"),
+	)
+	got := r.errorAttr(err).Value.String()
+	if strings.Contains(got, "abc") || strings.Contains(got, "known-secret") {
+		t.Errorf("errorAttr = %q, want values scrubbed", got)
+	}
+}
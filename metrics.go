@@ -0,0 +1,43 @@
+package secrets
+
+import "time"
+
+// Recorder receives metrics from a Resolver and from CachedProviders. The
+// promsecrets package provides a Prometheus implementation.
+//
+// Methods are called synchronously on the hot path, possibly concurrently,
+// and must be safe for concurrent use. They never receive secret values.
+type Recorder interface {
+	// Fetch records a provider fetch of key (at version, if not empty) that
+	// took d. err is nil on success, wraps ErrNotFound when the secret
+	// does not exist, and wraps an *ErrFetchTimeout when the fetch's context
+	// was canceled or expired.
+	Fetch(provider, key, version string, d time.Duration, err error)
+	// Resolve records a call to Resolver.Resolve that resolved fields fields
+	// in d. err is the joined error returned by Resolve.
+	Resolve(fields int, d time.Duration, err error)
+	// WatchChange records a change to key detected by a Watcher poll.
+	WatchChange(provider, key string)
+	// Cache records a hit, miss, or eviction of the CachedProvider
+	// registered under name with WithCacheMetrics.
+	Cache(name string, event CacheEvent)
+}
+
+// WithMetrics reports the Resolver's provider fetches, Resolve calls, and
+// Watcher changes to rec. Cache events are reported by CachedProviders
+// configured with WithCacheMetrics.
+func WithMetrics(rec Recorder) Option {
+	return func(c *resolverConfig) {
+		c.metrics = rec
+	}
+}
+
+// WithCacheMetrics reports every cache hit, miss, and eviction to rec, with
+// name identifying the cache. Usually name is the scheme the CachedProvider is
+// registered under.
+func WithCacheMetrics(rec Recorder, name string) CacheOption {
+	return func(c *CachedProvider) {
+		c.metrics = rec
+		c.metricsName = name
+	}
+}
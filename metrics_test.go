@@ -0,0 +1,122 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingRecorder is a Recorder that records calls as strings.
+type recordingRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recordingRecorder) add(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingRecorder) Fetch(provider, key, version string, _ time.Duration, err error) {
+	r.add("fetch %s %s %s notfound=%t err=%t", provider, key, version, errors.Is(err, ErrNotFound), err != nil)
+}
+
+func (r *recordingRecorder) Resolve(fields int, _ time.Duration, err error) {
+	r.add("resolve %d err=%t", fields, err != nil)
+}
+
+func (r *recordingRecorder) WatchChange(provider, key string) {
+	r.add("change %s %s", provider, key)
+}
+
+func (r *recordingRecorder) Cache(name string, ev CacheEvent) {
+	r.add("cache %s %s %s", name, ev.Kind, ev.Key)
+}
+
+func (r *recordingRecorder) has(call string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.calls {
+		if c == call {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWithMetrics_Resolve(t *testing.T) {
+	rec := &recordingRecorder{}
+	p := &mockVersionedProvider{
+		data:     map[string][]byte{"db": []byte("hunter22")},
+		versions: map[string]map[string][]byte{"db": {"previous": []byte("hunter11")}},
+	}
+	r := NewResolver(WithDefault(p), WithMetrics(rec))
+
+	var cfg struct {
+		DB      Versioned[string] `secret:"db"`
+		Missing string            `secret:"missing,optional"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	for _, want := range []string{
+		"fetch default db  notfound=false err=false",
+		"fetch default db previous notfound=false err=false",
+		"fetch default missing  notfound=true err=true",
+		"resolve 2 err=false",
+	} {
+		if !rec.has(want) {
+			t.Errorf("missing call %q in %q", want, rec.calls)
+		}
+	}
+}
+
+func TestWithMetrics_WatchChange(t *testing.T) {
+	rec := &recordingRecorder{}
+	store := &syncMapProvider{}
+	store.Store("port", []byte("5432"))
+	r := NewResolver(WithDefault(store), WithMetrics(rec))
+
+	var cfg struct {
+		Port int `secret:"port"`
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, err := r.Watch(ctx, &cfg, WatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.Store("port", []byte("5433"))
+	select {
+	case <-w.Changes():
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change")
+	}
+	if !rec.has("change default port") {
+		t.Errorf("missing change in %q", rec.calls)
+	}
+}
+
+func TestWithCacheMetrics(t *testing.T) {
+	rec := &recordingRecorder{}
+	p := &mockProvider{data: map[string][]byte{"key": []byte("value")}}
+	c := NewCachedProvider(p, time.Minute, WithCacheMetrics(rec, "awssm"))
+	defer c.Close()
+
+	for range 2 {
+		if _, err := c.Get(context.Background(), "key"); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+	for _, want := range []string{"cache awssm miss key", "cache awssm hit key"} {
+		if !rec.has(want) {
+			t.Errorf("missing call %q in %q", want, rec.calls)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Package ocivault provides a secret provider that reads from Oracle Cloud
+// Infrastructure (OCI) Vault.
+package ocivault
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+	ocisecrets "github.com/oracle/oci-go-sdk/v65/secrets"
+)
+
+// Client abstracts the OCI Vault secret retrieval API.
+// Implement this interface to provide a custom or pre-configured client.
+type Client interface {
+	// GetSecret returns the content of a version of a secret: the version
+	// number versionNumber if it is not zero, or else the version in stage,
+	// such as "CURRENT" or "PREVIOUS". secret is a secret OCID, or the name
+	// of a secret in the vault vaultID.
+	GetSecret(ctx context.Context, vaultID, secret, stage string, versionNumber int64) ([]byte, error)
+}
+
+// ProviderOption configures the ocivault Provider.
+type ProviderOption func(*Provider)
+
+// WithVaultID sets the OCID of the vault whose secrets keys name. It is
+// required for keys that are secret names rather than secret OCIDs.
+func WithVaultID(id string) ProviderOption {
+	return func(p *Provider) {
+		p.vaultID = id
+	}
+}
+
+// WithRegion sets the region of the default SDK client, such as
+// "us-ashburn-1". Defaults to the region of the configuration.
+func WithRegion(region string) ProviderOption {
+	return func(p *Provider) {
+		p.region = region
+	}
+}
+
+// WithEndpoint sends requests made by the default SDK client to url instead
+// of the regional endpoint. It has no effect on a Client injected with
+// WithClient.
+func WithEndpoint(url string) ProviderOption {
+	return func(p *Provider) {
+		p.endpoint = url
+	}
+}
+
+// WithConfigurationProvider makes the default SDK client authenticate with
+// cp instead of the default configuration, which is read from
+// ~/.oci/config and the OCI_ environment variables.
+func WithConfigurationProvider(cp common.ConfigurationProvider) ProviderOption {
+	return func(p *Provider) {
+		p.config = cp
+	}
+}
+
+// WithInstancePrincipal makes the default SDK client authenticate as the
+// compute instance it runs on, with instance principal certificates.
+func WithInstancePrincipal() ProviderOption {
+	return func(p *Provider) {
+		p.instancePrincipal = true
+	}
+}
+
+// WithClient injects a custom Client implementation.
+// Use this to provide a pre-configured OCI client or for testing.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider reads secrets from OCI Vault.
+// It implements secrets.Provider and secrets.VersionedProvider.
+//
+// Keys are secret names in the vault set with WithVaultID, or secret OCIDs
+// ("ocid1.vaultsecret..."), which need no vault.
+type Provider struct {
+	vaultID           string
+	region            string
+	endpoint          string
+	config            common.ConfigurationProvider
+	instancePrincipal bool
+	client            Client
+}
+
+// New creates a new OCI Vault Provider with the given options.
+// If no Client is provided via WithClient, a real OCI SDK client is created
+// from the default configuration.
+func New(opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		cp := p.config
+		if p.instancePrincipal {
+			var err error
+			if cp, err = auth.InstancePrincipalConfigurationProvider(); err != nil {
+				return nil, fmt.Errorf("ocivault: instance principal: %w", err)
+			}
+		}
+		if cp == nil {
+			cp = common.DefaultConfigProvider()
+		}
+		sc, err := ocisecrets.NewSecretsClientWithConfigurationProvider(cp)
+		if err != nil {
+			return nil, fmt.Errorf("ocivault: create client: %w", err)
+		}
+		if p.region != "" {
+			sc.SetRegion(p.region)
+		}
+		if p.endpoint != "" {
+			sc.Host = p.endpoint
+		}
+		p.client = &sdkClient{sc: sc}
+	}
+	return p, nil
+}
+
+// versionStage maps user-facing version strings to OCI rotation stages.
+var versionStage = map[string]string{
+	"current":  "CURRENT",
+	"previous": "PREVIOUS",
+	"pending":  "PENDING",
+	"latest":   "LATEST",
+}
+
+// Get retrieves the current version of the secret.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.GetVersion(ctx, key, "current")
+}
+
+// GetVersion retrieves a version of the secret: "current", "previous",
+// "pending", "latest" (the newest, whatever its stage), or a version
+// number.
+// Returns secrets.ErrNotFound (wrapped) if the secret or version does not
+// exist.
+func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	if !isOCID(key) && p.vaultID == "" {
+		return nil, fmt.Errorf("ocivault: secret %q: a vault is required for secret names (use WithVaultID)", key)
+	}
+	stage, ok := versionStage[version]
+	var number int64
+	if !ok {
+		n, err := strconv.ParseInt(version, 10, 64)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("ocivault: secret %q: unsupported version %q", key, version)
+		}
+		number = n
+	}
+	val, err := p.client.GetSecret(ctx, p.vaultID, key, stage, number)
+	if err != nil {
+		if version == "current" {
+			return nil, fmt.Errorf("ocivault: secret %q: %w", key, providerError(err))
+		}
+		return nil, fmt.Errorf("ocivault: secret %q version %q: %w", key, version, providerError(err))
+	}
+	return val, nil
+}
+
+// isOCID reports whether key is a secret OCID rather than a secret name.
+func isOCID(key string) bool {
+	return strings.HasPrefix(key, "ocid1.vaultsecret.")
+}
+
+// providerError converts OCI service errors to secrets.ErrNotFound for 404s
+// and to *secrets.ErrProvider otherwise.
+func providerError(err error) error {
+	var se common.ServiceError
+	if !errors.As(err, &se) {
+		return err
+	}
+	if se.GetHTTPStatusCode() == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", se.GetMessage(), secrets.ErrNotFound)
+	}
+	return &secrets.ErrProvider{
+		StatusCode: se.GetHTTPStatusCode(),
+		Code:       se.GetCode(),
+		RequestID:  se.GetOpcRequestID(),
+		Err:        err,
+	}
+}
+
+// sdkClient implements Client using the OCI Go SDK.
+type sdkClient struct {
+	sc ocisecrets.SecretsClient
+}
+
+func (c *sdkClient) GetSecret(ctx context.Context, vaultID, secret, stage string, versionNumber int64) ([]byte, error) {
+	var version *int64
+	if versionNumber != 0 {
+		version = common.Int64(versionNumber)
+	}
+	var bundle ocisecrets.SecretBundle
+	if isOCID(secret) {
+		resp, err := c.sc.GetSecretBundle(ctx, ocisecrets.GetSecretBundleRequest{
+			SecretId:      common.String(secret),
+			VersionNumber: version,
+			Stage:         ocisecrets.GetSecretBundleStageEnum(stage),
+		})
+		if err != nil {
+			return nil, err
+		}
+		bundle = resp.SecretBundle
+	} else {
+		resp, err := c.sc.GetSecretBundleByName(ctx, ocisecrets.GetSecretBundleByNameRequest{
+			SecretName:    common.String(secret),
+			VaultId:       common.String(vaultID),
+			VersionNumber: version,
+			Stage:         ocisecrets.GetSecretBundleByNameStageEnum(stage),
+		})
+		if err != nil {
+			return nil, err
+		}
+		bundle = resp.SecretBundle
+	}
+	content, ok := bundle.SecretBundleContent.(ocisecrets.Base64SecretBundleContentDetails)
+	if !ok || content.Content == nil {
+		return nil, errors.New("secret bundle has no base64 content")
+	}
+	return base64.StdEncoding.DecodeString(*content.Content)
+}
@@ -0,0 +1,160 @@
+package ocivault
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+const secretOCID = "ocid1.vaultsecret.oc1.iad.abc"
+
+// fakeClient serves versions of secrets, keyed by vault/secret.
+type fakeClient struct {
+	versions map[string][]string // versions[0] is version 1; the last is CURRENT
+}
+
+func (c *fakeClient) GetSecret(_ context.Context, vaultID, secret, stage string, versionNumber int64) ([]byte, error) {
+	vs, ok := c.versions[vaultID+"/"+secret]
+	if !ok {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	n := int64(len(vs))
+	switch {
+	case versionNumber != 0:
+		n = versionNumber
+	case stage == "PREVIOUS":
+		n--
+	case stage != "CURRENT" && stage != "LATEST":
+		n = 0
+	}
+	if n < 1 || n > int64(len(vs)) {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return []byte(vs[n-1]), nil
+}
+
+func TestGetVersion(t *testing.T) {
+	fc := &fakeClient{versions: map[string][]string{
+		"vault1/db-password": {"v1", "v2", "v3"},
+		"/" + secretOCID:     {"by-ocid"},
+	}}
+	p, err := New(WithClient(fc), WithVaultID("vault1"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		version, want string
+	}{
+		{"current", "v3"},
+		{"previous", "v2"},
+		{"latest", "v3"},
+		{"1", "v1"},
+	}
+	for _, tt := range tests {
+		got, err := p.GetVersion(ctx, "db-password", tt.version)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("GetVersion(%s) = %q, %v; want %q", tt.version, got, err, tt.want)
+		}
+	}
+	if _, err := p.GetVersion(ctx, "db-password", "pending"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("GetVersion(pending) error = %v, want ErrNotFound", err)
+	}
+	for _, v := range []string{"", "0", "v2"} {
+		if _, err := p.GetVersion(ctx, "db-password", v); err == nil || errors.Is(err, secrets.ErrNotFound) {
+			t.Errorf("GetVersion(%q) error = %v, want an unsupported version error", v, err)
+		}
+	}
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	// OCIDs need no vault.
+	noVault, _ := New(WithClient(&fakeClient{versions: map[string][]string{"/" + secretOCID: {"by-ocid"}}}))
+	if got, err := noVault.Get(ctx, secretOCID); err != nil || string(got) != "by-ocid" {
+		t.Errorf("Get(OCID) = %q, %v", got, err)
+	}
+	if _, err := noVault.Get(ctx, "db-password"); err == nil || !strings.Contains(err.Error(), "WithVaultID") {
+		t.Errorf("Get of a name without a vault: error = %v", err)
+	}
+}
+
+// newSDKProvider returns a Provider whose SDK client sends requests to
+// handler, signed with a throwaway key.
+func newSDKProvider(t *testing.T, handler http.HandlerFunc, opts ...ProviderOption) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	cp := common.NewRawConfigurationProvider("ocid1.tenancy.oc1..t", "ocid1.user.oc1..u", "us-ashburn-1", "aa:bb", string(keyPEM), nil)
+	p, err := New(append([]ProviderOption{WithConfigurationProvider(cp), WithEndpoint(srv.URL)}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+func TestSDKClient_GetSecret(t *testing.T) {
+	content := base64.StdEncoding.EncodeToString([]byte("hunter2"))
+	var queries []string
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			t.Error("request is not signed")
+		}
+		queries = append(queries, r.URL.Path+"?"+r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"secretId": %q, "versionNumber": 2, "secretBundleContent": {"contentType": "BASE64", "content": %q}}`, secretOCID, content)
+	}, WithVaultID("ocid1.vault.oc1..v"))
+	ctx := context.Background()
+
+	if got, err := p.Get(ctx, "db-password"); err != nil || string(got) != "hunter2" {
+		t.Fatalf("Get = %q, %v; want hunter2", got, err)
+	}
+	if _, err := p.GetVersion(ctx, secretOCID, "2"); err != nil {
+		t.Fatalf("GetVersion(OCID, 2): %v", err)
+	}
+	if !strings.HasSuffix(queries[0], "/secretbundles/actions/getByName?secretName=db-password&stage=CURRENT&vaultId=ocid1.vault.oc1..v") {
+		t.Errorf("by-name request = %s", queries[0])
+	}
+	if !strings.HasSuffix(queries[1], "/secretbundles/"+secretOCID+"?versionNumber=2") {
+		t.Errorf("by-OCID request = %s", queries[1])
+	}
+}
+
+func TestSDKClient_Errors(t *testing.T) {
+	status := http.StatusNotFound
+	p := newSDKProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("opc-request-id", "req-1")
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"code": "NotAuthorizedOrNotFound", "message": "secret not found"}`)
+	})
+	ctx := context.Background()
+	if _, err := p.Get(ctx, secretOCID); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get on 404: error = %v, want ErrNotFound", err)
+	}
+
+	status = http.StatusUnauthorized
+	_, err := p.Get(ctx, secretOCID)
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.StatusCode != http.StatusUnauthorized || pe.Code != "NotAuthorizedOrNotFound" || pe.RequestID != "req-1" {
+		t.Errorf("Get on 401: error = %v, want ErrProvider with status, code, and request ID", err)
+	}
+}
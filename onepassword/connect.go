@@ -0,0 +1,209 @@
+package onepassword
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+)
+
+// connectClient reads items from a 1Password Connect server through its
+// REST API.
+type connectClient struct {
+	host  string // base URL, such as "http://localhost:8080"
+	token string
+	http  *http.Client
+}
+
+// connectItem is the part of a Connect item that GetItem reads.
+type connectItem struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Fields []struct {
+		ID      string `json:"id"`
+		Label   string `json:"label"`
+		Value   string `json:"value"`
+		Section *struct {
+			ID string `json:"id"`
+		} `json:"section"`
+	} `json:"fields"`
+	Sections []struct {
+		ID    string `json:"id"`
+		Label string `json:"label"`
+	} `json:"sections"`
+}
+
+// GetItem reads reference, of the form "vault/item/field" or
+// "vault/item/section/field", where each part is a name or an ID, as the op
+// CLI does.
+func (c *connectClient) GetItem(ctx context.Context, reference string) (string, error) {
+	parts := strings.Split(reference, "/")
+	if len(parts) < 3 || len(parts) > 4 || slices.Contains(parts, "") {
+		return "", fmt.Errorf("invalid reference: want vault/item/[section/]field")
+	}
+	vault, itemName, field := parts[0], parts[1], parts[len(parts)-1]
+	var section string
+	if len(parts) == 4 {
+		section = parts[2]
+	}
+
+	itemPath, err := c.itemPath(ctx, vault, itemName)
+	if err != nil {
+		return "", err
+	}
+	var item connectItem
+	if err := c.get(ctx, itemPath, nil, &item); err != nil {
+		return "", err
+	}
+
+	sectionIDs := make(map[string]bool)
+	for _, s := range item.Sections {
+		if s.ID == section || s.Label == section {
+			sectionIDs[s.ID] = true
+		}
+	}
+	for _, f := range item.Fields {
+		if f.ID != field && f.Label != field {
+			continue
+		}
+		if section != "" && (f.Section == nil || !sectionIDs[f.Section.ID]) {
+			continue
+		}
+		return f.Value, nil
+	}
+	return "", fmt.Errorf("item %q has no field %q: %w", itemName, field, secrets.ErrNotFound)
+}
+
+// GetFile returns the content of the file attachment name of item, or of
+// the file of a Document item if name is empty.
+func (c *connectClient) GetFile(ctx context.Context, vault, item, name string) ([]byte, error) {
+	itemPath, err := c.itemPath(ctx, vault, item)
+	if err != nil {
+		return nil, err
+	}
+	var files []struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		ContentPath string `json:"content_path"`
+	}
+	if err := c.get(ctx, itemPath+"/files", nil, &files); err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if name != "" && f.Name != name && f.ID != name {
+			continue
+		}
+		path := f.ContentPath
+		if path == "" {
+			path = itemPath + "/files/" + url.PathEscape(f.ID) + "/content"
+		}
+		return c.getRaw(ctx, path)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("item %q has no document: %w", item, secrets.ErrNotFound)
+	}
+	return nil, fmt.Errorf("item %q has no file %q: %w", item, name, secrets.ErrNotFound)
+}
+
+// itemPath returns the API path of item in vault.
+func (c *connectClient) itemPath(ctx context.Context, vault, item string) (string, error) {
+	vaultID, err := c.lookup(ctx, "/v1/vaults", "name", vault)
+	if err != nil {
+		return "", err
+	}
+	itemsPath := "/v1/vaults/" + url.PathEscape(vaultID) + "/items"
+	itemID, err := c.lookup(ctx, itemsPath, "title", item)
+	if err != nil {
+		return "", err
+	}
+	return itemsPath + "/" + url.PathEscape(itemID), nil
+}
+
+// lookup returns the ID of the vault or item at path whose attr, "name" or
+// "title", is nameOrID, or nameOrID itself if none has that name and so it
+// is taken to be an ID.
+func (c *connectClient) lookup(ctx context.Context, path, attr, nameOrID string) (string, error) {
+	var found []struct {
+		ID string `json:"id"`
+	}
+	query := url.Values{"filter": {fmt.Sprintf("%s eq %q", attr, nameOrID)}}
+	if err := c.get(ctx, path, query, &found); err != nil {
+		return "", err
+	}
+	if len(found) == 0 {
+		return nameOrID, nil
+	}
+	return found[0].ID, nil
+}
+
+// get sends a GET request for path and decodes the JSON response into out.
+func (c *connectClient) get(ctx context.Context, path string, query url.Values, out any) error {
+	resp, err := c.do(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// getRaw sends a GET request for path and returns the response body.
+func (c *connectClient) getRaw(ctx context.Context, path string) ([]byte, error) {
+	resp, err := c.do(ctx, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// do sends a GET request for path, returning the response if it succeeded.
+func (c *connectClient) do(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := strings.TrimSuffix(c.host, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if ua := secrets.UserAgentFromContext(ctx); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, connectError(resp)
+	}
+	return resp, nil
+}
+
+// connectError returns the error described by a failed Connect response:
+// secrets.ErrNotFound for 404, and a *secrets.ErrProvider otherwise.
+func connectError(resp *http.Response) error {
+	var body struct {
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	msg := body.Message
+	if msg == "" {
+		msg = resp.Status
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", msg, secrets.ErrNotFound)
+	}
+	return &secrets.ErrProvider{
+		StatusCode: resp.StatusCode,
+		Err:        errors.New("connect: " + msg),
+	}
+}
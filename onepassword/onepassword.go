@@ -1,11 +1,14 @@
 // Package onepassword provides a secret provider that reads from 1Password
-// using the 1Password CLI (op).
+// using the 1Password CLI (op), a 1Password Connect server, or the 1Password
+// Go SDK with a service account token.
 package onepassword
 
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os/exec"
 	"strings"
 
@@ -17,6 +20,14 @@ type Client interface {
 	GetItem(ctx context.Context, reference string) (string, error)
 }
 
+// FileClient is implemented by Clients that can read file attachments and
+// documents as raw bytes. The default CLI and Connect clients implement it.
+type FileClient interface {
+	// GetFile returns the content of the file attachment name of item in
+	// vault, or of the file of a Document item if name is empty.
+	GetFile(ctx context.Context, vault, item, name string) ([]byte, error)
+}
+
 // ProviderOption configures the onepassword Provider.
 type ProviderOption func(*Provider)
 
@@ -28,6 +39,26 @@ func WithServiceAccountToken(token string) ProviderOption {
 	}
 }
 
+// WithConnect reads secrets from the 1Password Connect server at host, such
+// as "http://op-connect:8080", authenticating with a Connect token, instead
+// of through the op CLI, which then need not be installed.
+func WithConnect(host, token string) ProviderOption {
+	return func(p *Provider) {
+		p.connectHost = host
+		p.connectToken = token
+	}
+}
+
+// WithSDK reads secrets with the 1Password Go SDK, authenticating with a
+// service account token, instead of through the op CLI, which then need not
+// be installed. The SDK client is created on first use.
+func WithSDK(token string) ProviderOption {
+	return func(p *Provider) {
+		p.sdkToken = token
+		p.sdk = true
+	}
+}
+
 // WithClient injects a custom Client implementation.
 func WithClient(c Client) ProviderOption {
 	return func(p *Provider) {
@@ -35,13 +66,28 @@ func WithClient(c Client) ProviderOption {
 	}
 }
 
-// Provider reads secrets from 1Password using the CLI.
+// Provider reads secrets from 1Password using the CLI, a Connect server if
+// created with WithConnect, or the SDK if created with WithSDK.
 // It implements secrets.Provider.
 //
 // Keys are in the format "vault/item/field", which maps to the
-// 1Password reference "op://vault/item/field".
+// 1Password reference "op://vault/item/field". With Connect or the SDK, keys
+// may also be "vault/item/section/field"; each part is a name or an ID.
+//
+// Two keys return raw bytes, for SSH keys, certificates, and other files
+// stored in 1Password:
+//   - "vault/item/files/<name>": the file attachment name of item;
+//   - "vault/item/document": the file of a Document item.
+//
+// These are also field paths, of a field in a section named "files" and of
+// a field named "document"; such a field, if the item has one, is read
+// instead.
 type Provider struct {
 	serviceAccountToken string
+	connectHost         string
+	connectToken        string
+	sdk                 bool
+	sdkToken            string
 	client              Client
 }
 
@@ -51,6 +97,12 @@ func New(opts ...ProviderOption) *Provider {
 	for _, opt := range opts {
 		opt(p)
 	}
+	if p.client == nil && p.sdk {
+		p.client = &sdkClient{token: p.sdkToken, newAPI: newSDKAPI}
+	}
+	if p.client == nil && p.connectHost != "" {
+		p.client = &connectClient{host: p.connectHost, token: p.connectToken, http: http.DefaultClient}
+	}
 	if p.client == nil {
 		p.client = &cliClient{serviceAccountToken: p.serviceAccountToken}
 	}
@@ -59,13 +111,44 @@ func New(opts ...ProviderOption) *Provider {
 
 // Get retrieves the secret value for the given key.
 // The key should be in the format "vault/item/field".
-// Returns secrets.ErrNotFound (wrapped) if the secret does not exist.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) for files and documents if the Client does
+// not implement FileClient.
 func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
 	val, err := p.client.GetItem(ctx, key)
-	if err != nil {
-		return nil, fmt.Errorf("onepassword: %q: %w", key, err)
+	if err == nil {
+		return []byte(val), nil
 	}
-	return []byte(val), nil
+	// A file key is read as a file only if it names no field.
+	if vault, item, name, ok := parseFileKey(key); ok && errors.Is(err, secrets.ErrNotFound) {
+		fc, ok := p.client.(FileClient)
+		if !ok {
+			return nil, fmt.Errorf("onepassword: %q: %w", key, errors.ErrUnsupported)
+		}
+		b, err := fc.GetFile(ctx, vault, item, name)
+		if err != nil {
+			return nil, fmt.Errorf("onepassword: %q: %w", key, err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("onepassword: %q: %w", key, err)
+}
+
+// parseFileKey splits a key of the form "vault/item/files/<name>" or
+// "vault/item/document" into its parts, name being empty for a document.
+func parseFileKey(key string) (vault, item, name string, ok bool) {
+	parts := strings.Split(key, "/")
+	switch {
+	case len(parts) == 4 && parts[2] == "files" && parts[3] != "":
+		name = parts[3]
+	case len(parts) == 3 && parts[2] == "document":
+	default:
+		return "", "", "", false
+	}
+	if parts[0] == "" || parts[1] == "" {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], name, true
 }
 
 // cliClient shells out to the 1Password CLI.
@@ -74,8 +157,25 @@ type cliClient struct {
 }
 
 func (c *cliClient) GetItem(ctx context.Context, reference string) (string, error) {
-	ref := "op://" + reference
-	cmd := exec.CommandContext(ctx, "op", "read", ref)
+	out, err := c.run(ctx, "read", "op://"+reference)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// GetFile reads a file attachment with op read, which prints files as they
+// are, or a document with op document get.
+func (c *cliClient) GetFile(ctx context.Context, vault, item, name string) ([]byte, error) {
+	if name == "" {
+		return c.run(ctx, "document", "get", item, "--vault", vault)
+	}
+	return c.run(ctx, "read", "op://"+vault+"/"+item+"/"+name)
+}
+
+// run runs op with args and returns its output.
+func (c *cliClient) run(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "op", args...)
 	if c.serviceAccountToken != "" {
 		cmd.Env = append(cmd.Environ(), "OP_SERVICE_ACCOUNT_TOKEN="+c.serviceAccountToken)
 	}
@@ -86,12 +186,12 @@ func (c *cliClient) GetItem(ctx context.Context, reference string) (string, erro
 		errMsg := strings.TrimSpace(stderr.String())
 		if strings.Contains(strings.ToLower(errMsg), "not found") ||
 			strings.Contains(strings.ToLower(errMsg), "isn't an item") {
-			return "", fmt.Errorf("%s: %w", errMsg, secrets.ErrNotFound)
+			return nil, fmt.Errorf("%s: %w", errMsg, secrets.ErrNotFound)
 		}
 		if errMsg != "" {
-			return "", fmt.Errorf("%s", errMsg)
+			return nil, fmt.Errorf("%s", errMsg)
 		}
-		return "", err
+		return nil, err
 	}
-	return strings.TrimRight(stdout.String(), "\n"), nil
+	return stdout.Bytes(), nil
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/brwse/go-secrets"
@@ -71,3 +73,108 @@ func TestGet_WithServiceAccountToken(t *testing.T) {
 		t.Errorf("Get = %q, want %q", val, "value")
 	}
 }
+
+// mockFileClient adds file attachments to mockOPClient.
+type mockFileClient struct {
+	mockOPClient
+	// files maps "vault/item/name" to content; name is empty for documents.
+	files map[string][]byte
+}
+
+func (m *mockFileClient) GetFile(_ context.Context, vault, item, name string) ([]byte, error) {
+	b, ok := m.files[vault+"/"+item+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return b, nil
+}
+
+func TestGet_Files(t *testing.T) {
+	mock := &mockFileClient{
+		mockOPClient: mockOPClient{items: map[string]string{"prod/db/files": "a field"}},
+		files: map[string][]byte{
+			"prod/ssh/id_ed25519": []byte("key\n"),
+			"prod/ca/":            {0x30, 0x82},
+		},
+	}
+	p := New(WithClient(mock))
+	ctx := context.Background()
+
+	tests := []struct {
+		key, want string
+	}{
+		{"prod/ssh/files/id_ed25519", "key\n"},
+		{"prod/ca/document", "\x30\x82"},
+		{"prod/db/files", "a field"},
+	}
+	for _, tc := range tests {
+		if val, err := p.Get(ctx, tc.key); err != nil || string(val) != tc.want {
+			t.Errorf("Get(%q) = %q, %v; want %q", tc.key, val, err, tc.want)
+		}
+	}
+	if _, err := p.Get(ctx, "prod/ssh/files/missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+
+	p = New(WithClient(&mock.mockOPClient))
+	if _, err := p.Get(ctx, "prod/ssh/files/id_ed25519"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestGet_FieldsNamedLikeFiles(t *testing.T) {
+	mock := &mockFileClient{
+		mockOPClient: mockOPClient{items: map[string]string{
+			"prod/ca/document":       "a field named document",
+			"prod/ssh/files/comment": "a field in the files section",
+		}},
+		files: map[string][]byte{
+			"prod/ca/":            {0x30, 0x82},
+			"prod/ssh/comment":    []byte("an attachment"),
+			"prod/ssh/id_ed25519": []byte("key\n"),
+		},
+	}
+	p := New(WithClient(mock))
+	ctx := context.Background()
+
+	tests := []struct {
+		key, want string
+	}{
+		{"prod/ca/document", "a field named document"},
+		{"prod/ssh/files/comment", "a field in the files section"},
+		{"prod/ssh/files/id_ed25519", "key\n"},
+	}
+	for _, tc := range tests {
+		if val, err := p.Get(ctx, tc.key); err != nil || string(val) != tc.want {
+			t.Errorf("Get(%q) = %q, %v; want %q", tc.key, val, err, tc.want)
+		}
+	}
+}
+
+func TestCLIClient(t *testing.T) {
+	// A fake op that prints its arguments, with a trailing newline, and
+	// whose items have no fields named like files.
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" +
+		"case \"$1 $2\" in \"read \"*/files/*|\"read \"*/document) echo \"[ERROR] field not found\" >&2; exit 1;; esac\n" +
+		"echo \"$@\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "op"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	p := New()
+	ctx := context.Background()
+
+	tests := []struct {
+		key, want string
+	}{
+		{"prod/db/password", "read op://prod/db/password"},
+		{"prod/ssh/files/id_ed25519", "read op://prod/ssh/id_ed25519\n"},
+		{"prod/ca/document", "document get ca --vault prod\n"},
+	}
+	for _, tc := range tests {
+		if val, err := p.Get(ctx, tc.key); err != nil || string(val) != tc.want {
+			t.Errorf("Get(%q) = %q, %v; want %q", tc.key, val, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package onepassword
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	op "github.com/1password/onepassword-sdk-go"
+	"github.com/brwse/go-secrets"
+)
+
+// sdkAPI is the part of the 1Password SDK client that sdkClient uses.
+type sdkAPI struct {
+	secrets op.SecretsAPI
+	items   op.ItemsAPI
+	vaults  op.VaultsAPI
+}
+
+// sdkClient reads items with the 1Password Go SDK, authenticating with a
+// service account token. The SDK client is created on first use, and again
+// on the next use if that fails.
+type sdkClient struct {
+	token  string
+	newAPI func(ctx context.Context, token string) (sdkAPI, error)
+
+	mu  sync.Mutex
+	api *sdkAPI
+}
+
+// newSDKAPI creates an SDK client authenticated with token.
+func newSDKAPI(ctx context.Context, token string) (sdkAPI, error) {
+	c, err := op.NewClient(ctx,
+		op.WithServiceAccountToken(token),
+		op.WithIntegrationInfo("go-secrets", op.DefaultIntegrationVersion),
+	)
+	if err != nil {
+		return sdkAPI{}, err
+	}
+	return sdkAPI{secrets: c.Secrets(), items: c.Items(), vaults: c.Vaults()}, nil
+}
+
+// client returns the SDK client, creating it if needed.
+func (c *sdkClient) client(ctx context.Context) (*sdkAPI, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.api == nil {
+		api, err := c.newAPI(ctx, c.token)
+		if err != nil {
+			return nil, fmt.Errorf("sdk: %w", err)
+		}
+		c.api = &api
+	}
+	return c.api, nil
+}
+
+// GetItem resolves reference, of the form "vault/item/[section/]field", as
+// the secret reference "op://" + reference.
+func (c *sdkClient) GetItem(ctx context.Context, reference string) (string, error) {
+	api, err := c.client(ctx)
+	if err != nil {
+		return "", err
+	}
+	val, err := api.secrets.Resolve(ctx, "op://"+reference)
+	if err != nil {
+		return "", sdkError(err)
+	}
+	return val, nil
+}
+
+// GetFile returns the content of the file attachment name of item, or of
+// the file of a Document item if name is empty.
+func (c *sdkClient) GetFile(ctx context.Context, vault, item, name string) ([]byte, error) {
+	api, err := c.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vaultID, err := sdkLookupVault(ctx, api.vaults, vault)
+	if err != nil {
+		return nil, err
+	}
+	itemID, err := sdkLookupItem(ctx, api.items, vaultID, item)
+	if err != nil {
+		return nil, err
+	}
+	it, err := api.items.Get(ctx, vaultID, itemID)
+	if err != nil {
+		return nil, sdkError(err)
+	}
+
+	var attr *op.FileAttributes
+	if name == "" {
+		attr = it.Document
+	}
+	for i := range it.Files {
+		if f := &it.Files[i].Attributes; name != "" && (f.Name == name || f.ID == name) {
+			attr = f
+			break
+		}
+	}
+	if attr == nil {
+		if name == "" {
+			return nil, fmt.Errorf("item %q has no document: %w", item, secrets.ErrNotFound)
+		}
+		return nil, fmt.Errorf("item %q has no file %q: %w", item, name, secrets.ErrNotFound)
+	}
+	b, err := api.items.Files().Read(ctx, vaultID, itemID, *attr)
+	if err != nil {
+		return nil, sdkError(err)
+	}
+	return b, nil
+}
+
+// sdkLookupVault returns the ID of the vault named nameOrID, or nameOrID
+// itself if none has that name and so it is taken to be an ID.
+func sdkLookupVault(ctx context.Context, vaults op.VaultsAPI, nameOrID string) (string, error) {
+	list, err := vaults.List(ctx)
+	if err != nil {
+		return "", sdkError(err)
+	}
+	for _, v := range list {
+		if v.Title == nameOrID {
+			return v.ID, nil
+		}
+	}
+	return nameOrID, nil
+}
+
+// sdkLookupItem returns the ID of the item titled nameOrID in vaultID, or
+// nameOrID itself if none has that title and so it is taken to be an ID.
+func sdkLookupItem(ctx context.Context, items op.ItemsAPI, vaultID, nameOrID string) (string, error) {
+	list, err := items.List(ctx, vaultID)
+	if err != nil {
+		return "", sdkError(err)
+	}
+	for _, it := range list {
+		if it.Title == nameOrID {
+			return it.ID, nil
+		}
+	}
+	return nameOrID, nil
+}
+
+// sdkError converts an SDK error to secrets.ErrNotFound (wrapped) if its
+// message says that a vault, item, field, or file does not exist. The SDK
+// reports errors only as messages, as the op CLI does.
+func sdkError(err error) error {
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"not found", "cannot be found", "no vault matched", "no item matched", "no matching"} {
+		if strings.Contains(msg, s) {
+			return fmt.Errorf("%w: %w", err, secrets.ErrNotFound)
+		}
+	}
+	var rl *op.RateLimitExceededError
+	if errors.As(err, &rl) {
+		return &secrets.ErrProvider{StatusCode: http.StatusTooManyRequests, Code: "RateLimitExceeded", Err: err}
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package onepassword
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	op "github.com/1password/onepassword-sdk-go"
+	"github.com/brwse/go-secrets"
+)
+
+// fakeSDK fakes the Secrets and Vaults APIs of the SDK for vault "prod"
+// (id v1) with item "db" (id i1).
+type fakeSDK struct{}
+
+func (fakeSDK) Resolve(_ context.Context, ref string) (string, error) {
+	switch ref {
+	case "op://prod/db/password":
+		return "s3cret", nil
+	case "op://prod/db/replica/password":
+		return "replica-s3cret", nil
+	}
+	return "", errors.New("error resolving secret reference: the specified field cannot be found within the item")
+}
+
+func (fakeSDK) ResolveAll(context.Context, []string) (op.ResolveAllResponse, error) {
+	return op.ResolveAllResponse{}, errors.ErrUnsupported
+}
+
+func (fakeSDK) List(context.Context) ([]op.VaultOverview, error) {
+	return []op.VaultOverview{{ID: "v1", Title: "prod"}}, nil
+}
+
+// fakeSDKItems fakes the Items API for vault v1 with item "db" (id i1),
+// which has a file attachment, and Document item "ca" (id i2).
+type fakeSDKItems struct{ op.ItemsAPI }
+
+func (fakeSDKItems) Files() op.ItemsFilesAPI { return fakeSDKFiles{} }
+
+func (fakeSDKItems) Get(_ context.Context, vaultID, itemID string) (op.Item, error) {
+	switch vaultID + "/" + itemID {
+	case "v1/i1":
+		return op.Item{ID: "i1", Files: []op.ItemFile{{Attributes: op.FileAttributes{ID: "f1", Name: "id_ed25519"}}}}, nil
+	case "v1/i2":
+		return op.Item{ID: "i2", Document: &op.FileAttributes{ID: "d1", Name: "ca.der"}}, nil
+	}
+	return op.Item{}, errors.New("item not found")
+}
+
+func (fakeSDKItems) List(_ context.Context, vaultID string, _ ...op.ItemListFilter) ([]op.ItemOverview, error) {
+	if vaultID != "v1" {
+		return nil, nil
+	}
+	return []op.ItemOverview{{ID: "i1", Title: "db"}, {ID: "i2", Title: "ca"}}, nil
+}
+
+// fakeSDKFiles reads files as their vault, item, and file IDs.
+type fakeSDKFiles struct{ op.ItemsFilesAPI }
+
+func (fakeSDKFiles) Read(_ context.Context, vaultID, itemID string, attr op.FileAttributes) ([]byte, error) {
+	return []byte(vaultID + "/" + itemID + "/" + attr.ID), nil
+}
+
+func newFakeSDK(context.Context, string) (sdkAPI, error) {
+	return sdkAPI{secrets: fakeSDK{}, items: fakeSDKItems{}, vaults: fakeSDK{}}, nil
+}
+
+func TestSDK(t *testing.T) {
+	p := New(WithClient(&sdkClient{token: "ops_token", newAPI: newFakeSDK}))
+	ctx := context.Background()
+
+	tests := []struct {
+		key, want string
+	}{
+		{"prod/db/password", "s3cret"},
+		{"prod/db/replica/password", "replica-s3cret"},
+		{"prod/db/files/id_ed25519", "v1/i1/f1"},
+		{"prod/ca/document", "v1/i2/d1"},
+		{"v1/i1/files/f1", "v1/i1/f1"},
+	}
+	for _, tc := range tests {
+		if val, err := p.Get(ctx, tc.key); err != nil || string(val) != tc.want {
+			t.Errorf("Get(%q) = %q, %v; want %q", tc.key, val, err, tc.want)
+		}
+	}
+	for _, key := range []string{"prod/db/missing", "prod/db/files/missing", "prod/db/document", "prod/missing/files/a"} {
+		if _, err := p.Get(ctx, key); !errors.Is(err, secrets.ErrNotFound) {
+			t.Errorf("Get(%q): expected ErrNotFound, got: %v", key, err)
+		}
+	}
+}
+
+func TestSDK_RetriesClientCreation(t *testing.T) {
+	calls := 0
+	c := &sdkClient{token: "ops_token", newAPI: func(ctx context.Context, token string) (sdkAPI, error) {
+		calls++
+		if token != "ops_token" {
+			t.Errorf("token = %q", token)
+		}
+		if calls == 1 {
+			return sdkAPI{}, errors.New("error initializing client: invalid service account token")
+		}
+		return newFakeSDK(ctx, token)
+	}}
+	p := New(WithClient(c))
+	ctx := context.Background()
+
+	if _, err := p.Get(ctx, "prod/db/password"); err == nil || !strings.Contains(err.Error(), "invalid service account token") {
+		t.Errorf("first Get: err = %v, want the client creation error", err)
+	}
+	for range 2 {
+		if val, err := p.Get(ctx, "prod/db/password"); err != nil || string(val) != "s3cret" {
+			t.Errorf("Get = %q, %v; want s3cret", val, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("newAPI called %d times, want 2", calls)
+	}
+}
+
+func TestSDKError(t *testing.T) {
+	var pe *secrets.ErrProvider
+	if err := sdkError(&op.RateLimitExceededError{}); !errors.As(err, &pe) || pe.StatusCode != 429 {
+		t.Errorf("rate limit: error = %v, want ErrProvider with status 429", err)
+	}
+	if err := sdkError(errors.New("no vault matched the secret reference query")); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("missing vault: error = %v, want ErrNotFound", err)
+	}
+	if err := sdkError(errors.New("invalid token")); errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("other error: error = %v, want it unchanged", err)
+	}
+}
+
+func TestWithSDK(t *testing.T) {
+	p := New(WithSDK("ops_token"), WithConnect("http://op-connect:8080", "tok"))
+	c, ok := p.client.(*sdkClient)
+	if !ok || c.token != "ops_token" {
+		t.Fatalf("client = %#v, want an SDK client with the token", p.client)
+	}
+	var _ FileClient = c
+}
@@ -0,0 +1,66 @@
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Key types (CKA_KEY_TYPE) of public keys.
+const (
+	ckkRSA = 0x0
+	ckkEC  = 0x3
+)
+
+// oidECPublicKey identifies EC keys in a SubjectPublicKeyInfo.
+var oidECPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+// publicKeyAttributes holds the attributes of a public key object.
+type publicKeyAttributes struct {
+	keyType  uint
+	modulus  []byte // CKA_MODULUS of RSA keys
+	exponent []byte // CKA_PUBLIC_EXPONENT of RSA keys
+	ecParams []byte // CKA_EC_PARAMS of EC keys: the DER-encoded curve OID
+	ecPoint  []byte // CKA_EC_POINT of EC keys: a DER OCTET STRING
+}
+
+// publicKey returns the public key described by a.
+func publicKey(a publicKeyAttributes) (crypto.PublicKey, error) {
+	switch a.keyType {
+	case ckkRSA:
+		if len(a.modulus) == 0 || len(a.exponent) == 0 {
+			return nil, errors.New("RSA key has no modulus or exponent")
+		}
+		e := new(big.Int).SetBytes(a.exponent)
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA key exponent is too large")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(a.modulus), E: int(e.Int64())}, nil
+	case ckkEC:
+		// Tokens return the point wrapped in an OCTET STRING, as PKCS#11
+		// requires, though some return it bare.
+		point := a.ecPoint
+		var raw []byte
+		if rest, err := asn1.Unmarshal(a.ecPoint, &raw); err == nil && len(rest) == 0 {
+			point = raw
+		}
+		spki, err := asn1.Marshal(struct {
+			Algorithm pkix.AlgorithmIdentifier
+			PublicKey asn1.BitString
+		}{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidECPublicKey, Parameters: asn1.RawValue{FullBytes: a.ecParams}},
+			PublicKey: asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return x509.ParsePKIXPublicKey(spki)
+	default:
+		return nil, fmt.Errorf("unsupported key type %#x", a.keyType)
+	}
+}
@@ -0,0 +1,70 @@
+package pkcs11
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+)
+
+func TestPublicKey_RSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := publicKey(publicKeyAttributes{
+		keyType:  ckkRSA,
+		modulus:  key.N.Bytes(),
+		exponent: big.NewInt(int64(key.E)).Bytes(),
+	})
+	if err != nil {
+		t.Fatalf("publicKey: %v", err)
+	}
+	if !key.PublicKey.Equal(pub) {
+		t.Error("RSA public key does not match")
+	}
+}
+
+func TestPublicKey_EC(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := asn1.Marshal(asn1.ObjectIdentifier{1, 3, 132, 0, 34}) // secp384r1
+	if err != nil {
+		t.Fatal(err)
+	}
+	point, err := key.PublicKey.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := asn1.Marshal(point)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tokens should wrap the point in an OCTET STRING; some do not.
+	for _, p := range [][]byte{wrapped, point} {
+		pub, err := publicKey(publicKeyAttributes{keyType: ckkEC, ecParams: params, ecPoint: p})
+		if err != nil {
+			t.Fatalf("publicKey: %v", err)
+		}
+		if !key.PublicKey.Equal(pub) {
+			t.Error("EC public key does not match")
+		}
+	}
+}
+
+func TestPublicKey_Invalid(t *testing.T) {
+	for _, a := range []publicKeyAttributes{
+		{keyType: ckkRSA},
+		{keyType: ckkEC, ecParams: []byte{0x05, 0x00}, ecPoint: []byte{0x04}},
+		{keyType: 0x1f}, // AES
+	} {
+		if _, err := publicKey(a); err == nil {
+			t.Errorf("publicKey(%+v) succeeded, want an error", a)
+		}
+	}
+}
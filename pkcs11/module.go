@@ -0,0 +1,224 @@
+//go:build cgo
+
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/brwse/go-secrets"
+	p11 "github.com/miekg/pkcs11"
+)
+
+// moduleClient reads objects through a PKCS#11 library. Sessions are not
+// safe for concurrent use, so calls are serialized.
+type moduleClient struct {
+	mu      sync.Mutex
+	ctx     *p11.Ctx
+	session p11.SessionHandle
+}
+
+// openModule loads the library at path and opens a session with the token
+// labelled tokenLabel, or the first token if it is empty, logged in with pin
+// unless it is empty.
+func openModule(path, tokenLabel, pin string) (Client, error) {
+	ctx := p11.New(path)
+	if ctx == nil {
+		return nil, fmt.Errorf("load module %q", path)
+	}
+	if err := ctx.Initialize(); err != nil && !errors.Is(err, p11.Error(p11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+		ctx.Destroy()
+		return nil, fmt.Errorf("initialize module: %w", providerError(err))
+	}
+	c := &moduleClient{ctx: ctx}
+	if err := c.open(tokenLabel, pin); err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *moduleClient) open(tokenLabel, pin string) error {
+	slots, err := c.ctx.GetSlotList(true)
+	if err != nil {
+		return fmt.Errorf("list slots: %w", providerError(err))
+	}
+	slot, found := uint(0), false
+	for _, s := range slots {
+		info, err := c.ctx.GetTokenInfo(s)
+		if err != nil {
+			return fmt.Errorf("token info: %w", providerError(err))
+		}
+		if tokenLabel == "" || strings.TrimSpace(info.Label) == tokenLabel {
+			slot, found = s, true
+			break
+		}
+	}
+	if !found {
+		if tokenLabel == "" {
+			return errors.New("no token present")
+		}
+		return fmt.Errorf("no token labelled %q", tokenLabel)
+	}
+	c.session, err = c.ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("open session: %w", providerError(err))
+	}
+	if pin != "" {
+		err := c.ctx.Login(c.session, p11.CKU_USER, pin)
+		if err != nil && !errors.Is(err, p11.Error(p11.CKR_USER_ALREADY_LOGGED_IN)) {
+			c.ctx.CloseSession(c.session)
+			return fmt.Errorf("log in: %w", providerError(err))
+		}
+	}
+	return nil
+}
+
+func (c *moduleClient) GetValue(_ context.Context, class Class, label string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	obj, err := c.find(class, label)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := c.ctx.GetAttributeValue(c.session, obj, []*p11.Attribute{p11.NewAttribute(p11.CKA_VALUE, nil)})
+	if err != nil {
+		return nil, providerError(err)
+	}
+	return attrs[0].Value, nil
+}
+
+func (c *moduleClient) GetPublicKey(_ context.Context, label string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	obj, err := c.find(PublicKey, label)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := c.ctx.GetAttributeValue(c.session, obj, []*p11.Attribute{p11.NewAttribute(p11.CKA_KEY_TYPE, nil)})
+	if err != nil {
+		return nil, providerError(err)
+	}
+	a := publicKeyAttributes{keyType: uint(bytesToUint(attrs[0].Value))}
+	switch a.keyType {
+	case ckkRSA:
+		attrs, err = c.ctx.GetAttributeValue(c.session, obj, []*p11.Attribute{
+			p11.NewAttribute(p11.CKA_MODULUS, nil),
+			p11.NewAttribute(p11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err == nil {
+			a.modulus, a.exponent = attrs[0].Value, attrs[1].Value
+		}
+	case ckkEC:
+		attrs, err = c.ctx.GetAttributeValue(c.session, obj, []*p11.Attribute{
+			p11.NewAttribute(p11.CKA_EC_PARAMS, nil),
+			p11.NewAttribute(p11.CKA_EC_POINT, nil),
+		})
+		if err == nil {
+			a.ecParams, a.ecPoint = attrs[0].Value, attrs[1].Value
+		}
+	}
+	if err != nil {
+		return nil, providerError(err)
+	}
+	return publicKey(a)
+}
+
+func (c *moduleClient) WrapKey(_ context.Context, wrappingLabel, label string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	wrapping, err := c.find(SecretKey, wrappingLabel)
+	if err != nil {
+		return nil, fmt.Errorf("wrapping key: %w", err)
+	}
+	key, err := c.find(SecretKey, label)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := c.ctx.WrapKey(c.session, []*p11.Mechanism{p11.NewMechanism(p11.CKM_AES_KEY_WRAP_PAD, nil)}, wrapping, key)
+	if err != nil {
+		return nil, providerError(err)
+	}
+	return wrapped, nil
+}
+
+// find returns the object of class with label, which must be unique.
+func (c *moduleClient) find(class Class, label string) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, objectClass(class)),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+	}
+	if err := c.ctx.FindObjectsInit(c.session, template); err != nil {
+		return 0, providerError(err)
+	}
+	objs, _, err := c.ctx.FindObjects(c.session, 2)
+	if ferr := c.ctx.FindObjectsFinal(c.session); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		return 0, providerError(err)
+	}
+	switch len(objs) {
+	case 0:
+		return 0, fmt.Errorf("%w", secrets.ErrNotFound)
+	case 1:
+		return objs[0], nil
+	}
+	return 0, fmt.Errorf("several %s objects are labelled %q", class, label)
+}
+
+// Close logs out, closes the session, and unloads the module.
+func (c *moduleClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ctx == nil {
+		return nil
+	}
+	c.ctx.Logout(c.session)
+	err := c.ctx.CloseSession(c.session)
+	c.ctx.Finalize()
+	c.ctx.Destroy()
+	c.ctx = nil
+	return err
+}
+
+func objectClass(class Class) uint {
+	switch class {
+	case Certificate:
+		return p11.CKO_CERTIFICATE
+	case PublicKey:
+		return p11.CKO_PUBLIC_KEY
+	case SecretKey:
+		return p11.CKO_SECRET_KEY
+	}
+	return p11.CKO_DATA
+}
+
+// bytesToUint decodes a CK_ULONG attribute, which is in native byte order.
+func bytesToUint(b []byte) uint64 {
+	switch len(b) {
+	case 8:
+		return binary.NativeEndian.Uint64(b)
+	case 4:
+		return uint64(binary.NativeEndian.Uint32(b))
+	}
+	return ^uint64(0)
+}
+
+// providerError converts a PKCS#11 return value to a *secrets.ErrProvider
+// whose Code is its name, such as "CKR_PIN_INCORRECT", and returns other
+// errors unchanged.
+func providerError(err error) error {
+	var rv p11.Error
+	if !errors.As(err, &rv) {
+		return err
+	}
+	msg := rv.Error()
+	return &secrets.ErrProvider{Code: msg[strings.LastIndex(msg, " ")+1:], Err: err}
+}
@@ -0,0 +1,14 @@
+//go:build !cgo
+
+package pkcs11
+
+import (
+	"errors"
+	"fmt"
+)
+
+// openModule fails: loading a PKCS#11 library requires cgo. A Client
+// backed by another implementation can be given with WithClient.
+func openModule(path, _, _ string) (Client, error) {
+	return nil, fmt.Errorf("load module %q: built without cgo: %w", path, errors.ErrUnsupported)
+}
@@ -0,0 +1,247 @@
+// Package pkcs11 provides a secret provider that reads certificates, public
+// keys, data objects, and secret keys from a PKCS#11 token, such as a
+// hardware security module.
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Class is the PKCS#11 object class of the objects a key names.
+type Class int
+
+const (
+	// Data objects (CKO_DATA) hold arbitrary secrets, returned as stored.
+	Data Class = iota
+	// Certificate objects (CKO_CERTIFICATE) hold X.509 certificates.
+	Certificate
+	// PublicKey objects (CKO_PUBLIC_KEY) hold RSA and EC public keys.
+	PublicKey
+	// SecretKey objects (CKO_SECRET_KEY) hold symmetric keys.
+	SecretKey
+)
+
+func (c Class) String() string {
+	switch c {
+	case Data:
+		return "data"
+	case Certificate:
+		return "certificate"
+	case PublicKey:
+		return "public-key"
+	case SecretKey:
+		return "secret-key"
+	}
+	return fmt.Sprintf("Class(%d)", int(c))
+}
+
+// Client abstracts a session with a PKCS#11 token.
+type Client interface {
+	// GetValue returns the value (CKA_VALUE) of the object of class with
+	// label: the DER encoding of a certificate, the content of a data
+	// object, or the key bytes of a secret key.
+	GetValue(ctx context.Context, class Class, label string) ([]byte, error)
+	// GetPublicKey returns the public key object with label.
+	GetPublicKey(ctx context.Context, label string) (crypto.PublicKey, error)
+}
+
+// WrapClient is implemented by Clients that can export secret keys wrapped
+// by another key on the token. The default client uses C_WrapKey with
+// CKM_AES_KEY_WRAP_PAD (RFC 5649).
+type WrapClient interface {
+	// WrapKey returns the secret key with label wrapped by the secret key
+	// with wrappingLabel.
+	WrapKey(ctx context.Context, wrappingLabel, label string) ([]byte, error)
+}
+
+// ProviderOption configures the pkcs11 Provider.
+type ProviderOption func(*Provider)
+
+// WithModule sets the path of the PKCS#11 library of the token, such as
+// "/usr/lib/softhsm/libsofthsm2.so". Defaults to the PKCS11_MODULE
+// environment variable.
+func WithModule(path string) ProviderOption {
+	return func(p *Provider) {
+		p.module = path
+	}
+}
+
+// WithTokenLabel selects the token by label. Defaults to the
+// PKCS11_TOKEN_LABEL environment variable, or the first token present.
+func WithTokenLabel(label string) ProviderOption {
+	return func(p *Provider) {
+		p.tokenLabel = label
+	}
+}
+
+// WithPIN sets the user PIN to log in with. Defaults to the PKCS11_PIN
+// environment variable.
+func WithPIN(pin string) ProviderOption {
+	return func(p *Provider) {
+		p.pin = pin
+	}
+}
+
+// WithWrappingKey exports secret keys wrapped by the secret key with label,
+// for keys that are sensitive and so cannot be read in the clear. The
+// wrapped key can be unwrapped on another token, or with a key held
+// elsewhere.
+func WithWrappingKey(label string) ProviderOption {
+	return func(p *Provider) {
+		p.wrappingKey = label
+	}
+}
+
+// WithDER returns certificates and public keys DER-encoded rather than as
+// PEM.
+func WithDER() ProviderOption {
+	return func(p *Provider) {
+		p.der = true
+	}
+}
+
+// WithClient injects a custom Client implementation.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider reads objects from a PKCS#11 token.
+// It implements secrets.Provider and io.Closer.
+//
+// Keys name an object by class and label:
+//
+//	db-password                // the data object labelled db-password
+//	data/db-password           // the same
+//	certificate/api-tls        // a PEM CERTIFICATE block
+//	public-key/signing         // a PEM PUBLIC KEY block
+//	secret-key/payload-aes     // the key bytes, or wrapped with WithWrappingKey
+type Provider struct {
+	module      string
+	tokenLabel  string
+	pin         string
+	wrappingKey string
+	der         bool
+	client      Client
+}
+
+// New creates a new PKCS#11 Provider. Without WithClient it loads the
+// module, opens a session with the token, and logs in, which requires cgo.
+func New(opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		if p.module == "" {
+			p.module = os.Getenv("PKCS11_MODULE")
+		}
+		if p.tokenLabel == "" {
+			p.tokenLabel = os.Getenv("PKCS11_TOKEN_LABEL")
+		}
+		if p.pin == "" {
+			p.pin = os.Getenv("PKCS11_PIN")
+		}
+		if p.module == "" {
+			return nil, errors.New("pkcs11: module is required (use WithModule or PKCS11_MODULE)")
+		}
+		c, err := openModule(p.module, p.tokenLabel, p.pin)
+		if err != nil {
+			return nil, fmt.Errorf("pkcs11: %w", err)
+		}
+		p.client = c
+	}
+	return p, nil
+}
+
+// Get retrieves the object key names.
+// Returns secrets.ErrNotFound (wrapped) if there is no such object, and
+// errors.ErrUnsupported (wrapped) for wrapped secret keys if the Client does
+// not implement WrapClient.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	class, label, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	switch class {
+	case PublicKey:
+		pub, err := p.client.GetPublicKey(ctx, label)
+		if err != nil {
+			return nil, fmt.Errorf("pkcs11: %s %q: %w", class, label, err)
+		}
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, fmt.Errorf("pkcs11: %s %q: %w", class, label, err)
+		}
+		return p.encode("PUBLIC KEY", der), nil
+	case SecretKey:
+		if p.wrappingKey != "" {
+			wc, ok := p.client.(WrapClient)
+			if !ok {
+				return nil, fmt.Errorf("pkcs11: %s %q: wrap: %w", class, label, errors.ErrUnsupported)
+			}
+			val, err := wc.WrapKey(ctx, p.wrappingKey, label)
+			if err != nil {
+				return nil, fmt.Errorf("pkcs11: %s %q: %w", class, label, err)
+			}
+			return val, nil
+		}
+	}
+	val, err := p.client.GetValue(ctx, class, label)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11: %s %q: %w", class, label, err)
+	}
+	if class == Certificate {
+		return p.encode("CERTIFICATE", val), nil
+	}
+	return val, nil
+}
+
+// Close logs out and closes the session of the default client.
+func (p *Provider) Close() error {
+	if c, ok := p.client.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// encode returns der as a PEM block of type, or as is with WithDER.
+func (p *Provider) encode(typ string, der []byte) []byte {
+	if p.der {
+		return der
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+// parseKey returns the class and label of the object key names.
+func parseKey(key string) (Class, string, error) {
+	class, label := Data, key
+	if prefix, rest, ok := strings.Cut(key, "/"); ok {
+		switch prefix {
+		case "data":
+			class = Data
+		case "certificate":
+			class = Certificate
+		case "public-key":
+			class = PublicKey
+		case "secret-key":
+			class = SecretKey
+		default:
+			return 0, "", fmt.Errorf("pkcs11: %q: invalid key: want [data|certificate|public-key|secret-key/]<label>", key)
+		}
+		label = rest
+	}
+	if label == "" {
+		return 0, "", fmt.Errorf("pkcs11: %q: invalid key: empty label", key)
+	}
+	return class, label, nil
+}
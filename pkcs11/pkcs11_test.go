@@ -0,0 +1,202 @@
+package pkcs11
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// mockClient implements Client and WrapClient for testing.
+type mockClient struct {
+	values  map[Class]map[string][]byte
+	pubs    map[string]crypto.PublicKey
+	wrapped map[[2]string][]byte // by wrapping key and key label
+}
+
+func (m *mockClient) GetValue(_ context.Context, class Class, label string) ([]byte, error) {
+	v, ok := m.values[class][label]
+	if !ok {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return v, nil
+}
+
+func (m *mockClient) GetPublicKey(_ context.Context, label string) (crypto.PublicKey, error) {
+	pub, ok := m.pubs[label]
+	if !ok {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return pub, nil
+}
+
+func (m *mockClient) WrapKey(_ context.Context, wrappingLabel, label string) ([]byte, error) {
+	w, ok := m.wrapped[[2]string{wrappingLabel, label}]
+	if !ok {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return w, nil
+}
+
+func newMock(t *testing.T) (*mockClient, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &mockClient{
+		values: map[Class]map[string][]byte{
+			Data:        {"db-password": []byte("s3cret")},
+			Certificate: {"api-tls": []byte{0x30, 0x03, 0x02, 0x01, 0x01}},
+			SecretKey:   {"payload-aes": bytes.Repeat([]byte{0xaa}, 32)},
+		},
+		pubs:    map[string]crypto.PublicKey{"signing": &key.PublicKey},
+		wrapped: map[[2]string][]byte{{"kek", "payload-aes"}: []byte("wrapped")},
+	}, key
+}
+
+func TestGet(t *testing.T) {
+	m, key := newMock(t)
+	p, err := New(WithClient(m))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, k := range []string{"db-password", "data/db-password"} {
+		if got, err := p.Get(ctx, k); err != nil || string(got) != "s3cret" {
+			t.Errorf("Get(%q) = %q, %v; want s3cret", k, got, err)
+		}
+	}
+	if got, err := p.Get(ctx, "secret-key/payload-aes"); err != nil || !bytes.Equal(got, m.values[SecretKey]["payload-aes"]) {
+		t.Errorf("Get(secret-key) = %x, %v", got, err)
+	}
+
+	got, err := p.Get(ctx, "certificate/api-tls")
+	if err != nil {
+		t.Fatalf("Get(certificate): %v", err)
+	}
+	block, _ := pem.Decode(got)
+	if block == nil || block.Type != "CERTIFICATE" || !bytes.Equal(block.Bytes, m.values[Certificate]["api-tls"]) {
+		t.Errorf("Get(certificate) = %q, want the DER in a CERTIFICATE block", got)
+	}
+
+	got, err = p.Get(ctx, "public-key/signing")
+	if err != nil {
+		t.Fatalf("Get(public-key): %v", err)
+	}
+	block, _ = pem.Decode(got)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		t.Fatalf("Get(public-key) = %q, want a PUBLIC KEY block", got)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil || !key.PublicKey.Equal(pub) {
+		t.Errorf("public key = %v, %v; want the token's key", pub, err)
+	}
+}
+
+func TestGet_DER(t *testing.T) {
+	m, _ := newMock(t)
+	p, err := New(WithClient(m), WithDER())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got, err := p.Get(context.Background(), "certificate/api-tls")
+	if err != nil || !bytes.Equal(got, m.values[Certificate]["api-tls"]) {
+		t.Errorf("Get = %x, %v; want the DER as is", got, err)
+	}
+	got, err = p.Get(context.Background(), "public-key/signing")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if _, err := x509.ParsePKIXPublicKey(got); err != nil {
+		t.Errorf("public key is not DER: %v", err)
+	}
+}
+
+func TestGet_Wrapped(t *testing.T) {
+	m, _ := newMock(t)
+	p, err := New(WithClient(m), WithWrappingKey("kek"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got, err := p.Get(context.Background(), "secret-key/payload-aes"); err != nil || string(got) != "wrapped" {
+		t.Errorf("Get = %q, %v; want the wrapped key", got, err)
+	}
+	// Other classes are not wrapped.
+	if got, err := p.Get(context.Background(), "db-password"); err != nil || string(got) != "s3cret" {
+		t.Errorf("Get(data) = %q, %v; want s3cret", got, err)
+	}
+}
+
+// valueOnly implements only Client.
+type valueOnly struct{ Client }
+
+func TestGet_WrapUnsupported(t *testing.T) {
+	m, _ := newMock(t)
+	p, err := New(WithClient(valueOnly{m}), WithWrappingKey("kek"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := p.Get(context.Background(), "secret-key/payload-aes"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("got %v, want ErrUnsupported", err)
+	}
+}
+
+func TestGet_Missing(t *testing.T) {
+	m, _ := newMock(t)
+	p, err := New(WithClient(m))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, k := range []string{"missing", "certificate/db-password", "public-key/missing"} {
+		if _, err := p.Get(context.Background(), k); !errors.Is(err, secrets.ErrNotFound) {
+			t.Errorf("Get(%q): got %v, want ErrNotFound", k, err)
+		}
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		class Class
+		label string
+	}{
+		{"db", Data, "db"},
+		{"data/db", Data, "db"},
+		{"certificate/tls", Certificate, "tls"},
+		{"public-key/sig", PublicKey, "sig"},
+		{"secret-key/aes", SecretKey, "aes"},
+		{"secret-key/a/b", SecretKey, "a/b"},
+	}
+	for _, tt := range tests {
+		class, label, err := parseKey(tt.key)
+		if err != nil || class != tt.class || label != tt.label {
+			t.Errorf("parseKey(%q) = %v, %q, %v; want %v, %q", tt.key, class, label, err, tt.class, tt.label)
+		}
+	}
+	for _, key := range []string{"", "data/", "private-key/x", "a/b"} {
+		if _, _, err := parseKey(key); err == nil {
+			t.Errorf("parseKey(%q) succeeded, want an error", key)
+		}
+	}
+}
+
+func TestNew_Module(t *testing.T) {
+	t.Setenv("PKCS11_MODULE", "")
+	if _, err := New(); err == nil {
+		t.Error("New without a module succeeded")
+	}
+	if _, err := New(WithModule(t.TempDir() + "/missing.so")); err == nil {
+		t.Error("New with a missing module succeeded")
+	}
+}
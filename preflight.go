@@ -0,0 +1,199 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Preflight checks that dst is valid and that every secret it references
+// exists and is accessible, without retrieving any secret values. This lets
+// deploy pipelines catch missing secrets and permission gaps without pulling
+// plaintext into CI.
+//
+// Preflight first runs Validate. It then calls Check once per distinct
+// secret on providers that implement CheckerProvider, with the resolver's
+// parallelism. Secrets served by other providers, or for which Check reports
+// errors.ErrUnsupported, are skipped. A missing secret is not an error if
+// every field referencing it is optional. Fragments and versions are not
+// checked, since that would require reading the value.
+// All errors are collected and returned via errors.Join.
+func (r *Resolver) Preflight(ctx context.Context, dst any) error {
+	if err := r.Validate(dst); err != nil {
+		return err
+	}
+
+	// Walk a zero value of dst's type, so that nested pointers are not set
+	// in dst.
+	scratch := reflect.New(reflect.TypeOf(dst).Elem()).Elem()
+	var fields []fieldInfo
+	var collectErrs []error
+	r.collectFields(scratch, &fields, &collectErrs)
+	if len(collectErrs) > 0 {
+		return errors.Join(collectErrs...)
+	}
+
+	type checkSpec struct {
+		checker  CheckerProvider
+		key      string
+		field    string // first field referencing the secret, for error reporting
+		optional bool   // true if every referencing field is optional
+	}
+	specs := make(map[string]*checkSpec) // URI -> spec
+	var order []string
+	for _, fi := range fields {
+		cp, ok := fi.provider.(CheckerProvider)
+		if !ok {
+			continue
+		}
+		uri := fi.tag.URI()
+		if spec, ok := specs[uri]; ok {
+			spec.optional = spec.optional && fi.tag.Optional
+			continue
+		}
+		specs[uri] = &checkSpec{checker: cp, key: fi.tag.Key, field: fi.fieldName, optional: fi.tag.Optional}
+		order = append(order, uri)
+	}
+
+	errs := make([]error, len(order))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, r.cfg.parallelism)
+	for i, uri := range order {
+		spec := specs[uri]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}        // acquire
+			defer func() { <-sem }() // release
+
+			err := spec.checker.Check(ctx, spec.key)
+			switch {
+			case err == nil, errors.Is(err, errors.ErrUnsupported):
+			case spec.optional && errors.Is(err, ErrNotFound):
+			default:
+				errs[i] = fmt.Errorf("secrets: field %s: %w", spec.field, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// ValidateRemote is a dry run of Resolve: it checks that dst is valid and
+// that every secret it references exists, can be read, and converts to its
+// field, without setting any field of dst. Deploys can run it before rolling
+// out a configuration.
+//
+// ValidateRemote first runs Validate. Each distinct secret is then checked
+// once, with the resolver's parallelism: with Check, as Preflight does, if
+// its provider implements CheckerProvider and no field needs its value, and
+// otherwise by fetching it. A field needs the value if it names a version, a
+// fragment, an encoding, decryption, or transforms. Fetched values are
+// extracted and converted for each field into scratch values, which are then
+// discarded, so a missing fragment or a value that does not parse is
+// reported; for checked secrets, conversion cannot be verified. Unlike
+// Preflight, no secret is skipped, and unlike Resolve, missing secrets are
+// not bootstrapped. A missing secret is not an error for optional fields.
+// All errors are collected and returned via errors.Join.
+func (r *Resolver) ValidateRemote(ctx context.Context, dst any) error {
+	if err := r.Validate(dst); err != nil {
+		return err
+	}
+
+	// Walk a zero value of dst's type, so that neither nested pointers nor
+	// converted values are set in dst.
+	scratch := reflect.New(reflect.TypeOf(dst).Elem()).Elem()
+	var fields []fieldInfo
+	var collectErrs []error
+	r.collectFields(scratch, &fields, &collectErrs)
+	if len(collectErrs) > 0 {
+		return errors.Join(collectErrs...)
+	}
+
+	type remoteCheck struct {
+		fk     fetchKey
+		fields []*fieldInfo
+		fetch  bool // true if a field needs the value
+	}
+	checks := make(map[fetchKey]*remoteCheck)
+	var order []*remoteCheck
+	for i := range fields {
+		fi := &fields[i]
+		fk := fetchKey{uri: fi.tag.URI(), version: fi.tag.Version}
+		c, ok := checks[fk]
+		if !ok {
+			c = &remoteCheck{fk: fk}
+			checks[fk] = c
+			order = append(order, c)
+		}
+		c.fields = append(c.fields, fi)
+		t := fi.tag
+		c.fetch = c.fetch || t.Version != "" || t.Fragment != "" || t.Encoding != "" || t.Decrypt != "" || t.Transform != ""
+	}
+
+	errs := make([][]error, len(order))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, r.cfg.parallelism)
+	for i, c := range order {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}        // acquire
+			defer func() { <-sem }() // release
+
+			first := c.fields[0]
+			// report records err for every field that is not optional.
+			report := func(err error) {
+				for _, fi := range c.fields {
+					if fi.tag.Optional && errors.Is(err, ErrNotFound) {
+						continue
+					}
+					errs[i] = append(errs[i], fi.resolveError(c.fk.version, err))
+				}
+			}
+			if cp, ok := first.provider.(CheckerProvider); ok && !c.fetch {
+				err := cp.Check(ctx, first.tag.Key)
+				if err == nil {
+					return
+				}
+				if !errors.Is(err, errors.ErrUnsupported) {
+					report(err)
+					return
+				}
+			}
+
+			data, err := r.fetch(ctx, first.provider, first.providerName, first.fieldName, first.tag.Key, c.fk.version)
+			if err != nil {
+				report(err)
+				return
+			}
+			doc := newJSONDoc(data)
+			for _, fi := range c.fields {
+				value, err := r.extractFrom(fi.tag, doc)
+				if err != nil {
+					errs[i] = append(errs[i], fmt.Errorf("secrets: field %s: %w", fi.fieldName, err))
+					continue
+				}
+				target := fi.fieldValue
+				switch {
+				case fi.isLazy:
+					target = reflect.New(target.Addr().Interface().(lazyField).valueType()).Elem()
+				case fi.isVersioned:
+					target = target.Field(0) // Current
+				}
+				if err := r.setField(target, fi.fieldName, value); err != nil {
+					errs[i] = append(errs[i], err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var all []error
+	for _, e := range errs {
+		all = append(all, e...)
+	}
+	return errors.Join(all...)
+}
@@ -0,0 +1,237 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// checkerProvider is a map-based CheckerProvider that counts calls.
+type checkerProvider struct {
+	data        map[string][]byte
+	unsupported map[string]bool // keys for which Check reports errors.ErrUnsupported
+	denied      map[string]bool // keys for which Check reports a permission error
+	gets        atomic.Int64
+	checks      atomic.Int64
+}
+
+func (p *checkerProvider) Get(_ context.Context, key string) ([]byte, error) {
+	p.gets.Add(1)
+	v, ok := p.data[key]
+	if !ok {
+		return nil, fmt.Errorf("checker: %q: %w", key, ErrNotFound)
+	}
+	return v, nil
+}
+
+func (p *checkerProvider) Check(_ context.Context, key string) error {
+	p.checks.Add(1)
+	switch {
+	case p.unsupported[key]:
+		return fmt.Errorf("checker: %q: %w", key, errors.ErrUnsupported)
+	case p.denied[key]:
+		return fmt.Errorf("checker: %q: access denied", key)
+	}
+	if _, ok := p.data[key]; !ok {
+		return fmt.Errorf("checker: %q: %w", key, ErrNotFound)
+	}
+	return nil
+}
+
+func TestPreflight_AllPresent(t *testing.T) {
+	p := &checkerProvider{data: map[string][]byte{
+		"db": []byte(`{"user":"admin","pass":"s3cret"}`),
+	}}
+	r := NewResolver(WithDefault(p))
+
+	type Config struct {
+		User string `secret:"db#user"`
+		Pass string `secret:"db#pass"`
+	}
+	var cfg Config
+	if err := r.Preflight(context.Background(), &cfg); err != nil {
+		t.Fatalf("Preflight: %v", err)
+	}
+	if got := p.checks.Load(); got != 1 {
+		t.Errorf("Check calls = %d, want 1", got)
+	}
+	if got := p.gets.Load(); got != 0 {
+		t.Errorf("Get calls = %d, want 0", got)
+	}
+	if cfg.User != "" || cfg.Pass != "" {
+		t.Error("Preflight populated dst")
+	}
+}
+
+func TestPreflight_LeavesNestedPointersNil(t *testing.T) {
+	p := &checkerProvider{data: map[string][]byte{"db-pass": []byte("s3cret")}}
+	r := NewResolver(WithDefault(p))
+
+	type DB struct {
+		Pass string `secret:"db-pass"`
+	}
+	type Config struct {
+		DB *DB
+	}
+	var cfg Config
+	if err := r.Preflight(context.Background(), &cfg); err != nil {
+		t.Fatalf("Preflight: %v", err)
+	}
+	if got := p.checks.Load(); got != 1 {
+		t.Errorf("Check calls = %d, want 1", got)
+	}
+	if cfg.DB != nil {
+		t.Error("Preflight allocated a nested pointer in dst")
+	}
+}
+
+func TestPreflight_ReportsMissingAndDenied(t *testing.T) {
+	p := &checkerProvider{
+		data:   map[string][]byte{"ok": []byte("v")},
+		denied: map[string]bool{"locked": true},
+	}
+	r := NewResolver(WithDefault(p))
+
+	type Config struct {
+		OK      string `secret:"ok"`
+		Missing string `secret:"missing"`
+		Locked  string `secret:"locked"`
+	}
+	err := r.Preflight(context.Background(), &Config{})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound in %v", err)
+	}
+	for _, want := range []string{"field Missing", "field Locked", "access denied"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestPreflight_OptionalMissing(t *testing.T) {
+	p := &checkerProvider{data: map[string][]byte{}}
+	r := NewResolver(WithDefault(p))
+
+	type Config struct {
+		A string `secret:"missing,optional"`
+	}
+	if err := r.Preflight(context.Background(), &Config{}); err != nil {
+		t.Errorf("Preflight: %v", err)
+	}
+
+	// A secret that is also required elsewhere must exist.
+	type Mixed struct {
+		A string `secret:"missing,optional"`
+		B string `secret:"missing"`
+	}
+	if err := r.Preflight(context.Background(), &Mixed{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPreflight_SkipsUncheckable(t *testing.T) {
+	p := &checkerProvider{
+		data:        map[string][]byte{},
+		unsupported: map[string]bool{"opaque": true},
+	}
+	plain := &mockProvider{data: map[string][]byte{}}
+	r := NewResolver(WithDefault(p), WithProvider("plain", plain))
+
+	type Config struct {
+		A string `secret:"opaque"`
+		B string `secret:"plain://missing"`
+	}
+	if err := r.Preflight(context.Background(), &Config{}); err != nil {
+		t.Errorf("Preflight: %v", err)
+	}
+}
+
+func TestPreflight_ValidatesFirst(t *testing.T) {
+	p := &checkerProvider{data: map[string][]byte{}}
+	r := NewResolver(WithDefault(p))
+
+	type Config struct {
+		A string `secret:"unknown://key"`
+	}
+	var unknown *ErrUnknownProvider
+	if err := r.Preflight(context.Background(), &Config{}); !errors.As(err, &unknown) {
+		t.Errorf("expected ErrUnknownProvider, got %v", err)
+	}
+	if got := p.checks.Load(); got != 0 {
+		t.Errorf("Check calls = %d, want 0", got)
+	}
+}
+
+func TestValidateRemote(t *testing.T) {
+	p := &checkerProvider{
+		data:        map[string][]byte{"db": []byte(`{"user":"admin","port":"5432"}`), "token": []byte("t"), "opaque": []byte("o")},
+		unsupported: map[string]bool{"opaque": true},
+	}
+	plain := &mockProvider{data: map[string][]byte{"key": []byte("k")}}
+	r := NewResolver(WithDefault(p), WithProvider("plain", plain))
+
+	type DB struct {
+		User string `secret:"db#user"`
+		Port int    `secret:"db#port"`
+	}
+	type Config struct {
+		DB     *DB
+		Token  string         `secret:"token"`
+		Opaque string         `secret:"opaque"`
+		Key    Secret[string] `secret:"plain://key"`
+		Extra  string         `secret:"missing,optional"`
+	}
+	var cfg Config
+	if err := r.ValidateRemote(context.Background(), &cfg); err != nil {
+		t.Fatalf("ValidateRemote: %v", err)
+	}
+	// "token" and "missing" are checked; "db" needs its fragments and
+	// "opaque" cannot be checked, so they are fetched.
+	if got := p.checks.Load(); got != 3 {
+		t.Errorf("Check calls = %d, want 3", got)
+	}
+	if got := p.gets.Load(); got != 2 {
+		t.Errorf("Get calls = %d, want 2", got)
+	}
+	if cfg.DB != nil || cfg.Token != "" || cfg.Opaque != "" {
+		t.Errorf("ValidateRemote populated dst: %+v", cfg)
+	}
+}
+
+func TestValidateRemote_ReportsErrors(t *testing.T) {
+	p := &checkerProvider{data: map[string][]byte{"db": []byte(`{"port":"http"}`)}}
+	plain := &mockProvider{data: map[string][]byte{}}
+	r := NewResolver(WithDefault(p), WithProvider("plain", plain))
+
+	type Config struct {
+		Port    int    `secret:"db#port"`
+		User    string `secret:"db#user"`
+		Missing string `secret:"plain://missing"`
+	}
+	err := r.ValidateRemote(context.Background(), &Config{})
+	var conv *ErrConversion
+	if !errors.As(err, &conv) || conv.Field != "Port" {
+		t.Errorf("expected ErrConversion for Port in %v", err)
+	}
+	var resolveErr *ErrResolve
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &resolveErr) || resolveErr.Field != "Missing" {
+		t.Errorf("expected an ErrResolve for Missing in %v", err)
+	}
+	if !strings.Contains(err.Error(), "field User") {
+		t.Errorf("expected the missing fragment reported in %v", err)
+	}
+
+	type Bad struct {
+		A string `secret:"unknown://key"`
+	}
+	var unknown *ErrUnknownProvider
+	if err := r.ValidateRemote(context.Background(), &Bad{}); !errors.As(err, &unknown) {
+		t.Errorf("expected ErrUnknownProvider, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package promsecrets provides a secrets.Recorder that exports resolver,
+// provider, cache, and watcher metrics to Prometheus.
+//
+//	rec := promsecrets.New()
+//	prometheus.MustRegister(rec)
+//	r := secrets.NewResolver(
+//		secrets.WithMetrics(rec),
+//		secrets.WithProvider("awssm", secrets.NewCachedProvider(awsProvider, 5*time.Minute,
+//			secrets.WithCacheMetrics(rec, "awssm"))),
+//	)
+//
+// The exported metrics are:
+//
+//	secrets_fetches_total{provider, result}       provider fetches; result is "ok", "not_found", "timeout", or "error"
+//	secrets_fetch_duration_seconds{provider}      provider fetch latency
+//	secrets_resolves_total{result}                Resolve calls; result is "ok" or "error"
+//	secrets_resolve_duration_seconds              Resolve latency
+//	secrets_cache_events_total{cache, event}      cache lookups and evictions; event is "hit", "miss", or "eviction"
+//	secrets_watch_changes_total{provider}         changes detected by Watcher polls
+//
+// Keys are not used as labels, to bound the number of series. The cache hit
+// ratio is
+//
+//	sum by (cache) (rate(secrets_cache_events_total{event="hit"}[5m]))
+//	  / sum by (cache) (rate(secrets_cache_events_total{event=~"hit|miss"}[5m]))
+package promsecrets
+
+import (
+	"errors"
+	"time"
+
+	"github.com/brwse/go-secrets"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Option configures a Recorder.
+type Option func(*options)
+
+type options struct {
+	namespace   string
+	constLabels prometheus.Labels
+	buckets     []float64
+}
+
+// WithNamespace prefixes every metric name with namespace and an underscore,
+// for example "billing_secrets_fetches_total".
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels with fixed values to every metric.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(o *options) {
+		o.constLabels = labels
+	}
+}
+
+// WithBuckets sets the histogram buckets, in seconds, of the fetch and
+// resolve latency metrics. Defaults to prometheus.DefBuckets.
+func WithBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// Recorder is a secrets.Recorder backed by Prometheus metrics. It implements
+// prometheus.Collector; register it with a prometheus.Registerer to export
+// the metrics.
+type Recorder struct {
+	fetches         *prometheus.CounterVec
+	fetchDuration   *prometheus.HistogramVec
+	resolves        *prometheus.CounterVec
+	resolveDuration prometheus.Histogram
+	cacheEvents     *prometheus.CounterVec
+	watchChanges    *prometheus.CounterVec
+}
+
+var _ secrets.Recorder = (*Recorder)(nil)
+
+// New creates a Recorder with the given options.
+func New(opts ...Option) *Recorder {
+	o := options{buckets: prometheus.DefBuckets}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	counterOpts := func(name, help string) prometheus.CounterOpts {
+		return prometheus.CounterOpts{
+			Namespace: o.namespace, Subsystem: "secrets", Name: name, Help: help,
+			ConstLabels: o.constLabels,
+		}
+	}
+	histogramOpts := func(name, help string) prometheus.HistogramOpts {
+		return prometheus.HistogramOpts{
+			Namespace: o.namespace, Subsystem: "secrets", Name: name, Help: help,
+			ConstLabels: o.constLabels, Buckets: o.buckets,
+		}
+	}
+	return &Recorder{
+		fetches: prometheus.NewCounterVec(
+			counterOpts("fetches_total", "Secret fetches from providers, by provider and result."),
+			[]string{"provider", "result"}),
+		fetchDuration: prometheus.NewHistogramVec(
+			histogramOpts("fetch_duration_seconds", "Latency of secret fetches from providers."),
+			[]string{"provider"}),
+		resolves: prometheus.NewCounterVec(
+			counterOpts("resolves_total", "Resolve calls, by result."),
+			[]string{"result"}),
+		resolveDuration: prometheus.NewHistogram(
+			histogramOpts("resolve_duration_seconds", "Latency of Resolve calls.")),
+		cacheEvents: prometheus.NewCounterVec(
+			counterOpts("cache_events_total", "Secret cache hits, misses, and evictions, by cache."),
+			[]string{"cache", "event"}),
+		watchChanges: prometheus.NewCounterVec(
+			counterOpts("watch_changes_total", "Secret changes detected by watchers, by provider."),
+			[]string{"provider"}),
+	}
+}
+
+// Fetch implements secrets.Recorder.
+func (r *Recorder) Fetch(provider, _, _ string, d time.Duration, err error) {
+	r.fetches.WithLabelValues(provider, result(err)).Inc()
+	r.fetchDuration.WithLabelValues(provider).Observe(d.Seconds())
+}
+
+// Resolve implements secrets.Recorder.
+func (r *Recorder) Resolve(_ int, d time.Duration, err error) {
+	r.resolves.WithLabelValues(result(err)).Inc()
+	r.resolveDuration.Observe(d.Seconds())
+}
+
+// WatchChange implements secrets.Recorder.
+func (r *Recorder) WatchChange(provider, _ string) {
+	r.watchChanges.WithLabelValues(provider).Inc()
+}
+
+// Cache implements secrets.Recorder.
+func (r *Recorder) Cache(name string, ev secrets.CacheEvent) {
+	r.cacheEvents.WithLabelValues(name, ev.Kind.String()).Inc()
+}
+
+// Describe implements prometheus.Collector.
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	r.fetches.Describe(ch)
+	r.fetchDuration.Describe(ch)
+	r.resolves.Describe(ch)
+	r.resolveDuration.Describe(ch)
+	r.cacheEvents.Describe(ch)
+	r.watchChanges.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	r.fetches.Collect(ch)
+	r.fetchDuration.Collect(ch)
+	r.resolves.Collect(ch)
+	r.resolveDuration.Collect(ch)
+	r.cacheEvents.Collect(ch)
+	r.watchChanges.Collect(ch)
+}
+
+// result returns the result label for err.
+func result(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, secrets.ErrNotFound):
+		return "not_found"
+	case errors.As(err, new(*secrets.ErrFetchTimeout)):
+		return "timeout"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,86 @@
+package promsecrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+	"github.com/brwse/go-secrets/literal"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := New()
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(rec)
+
+	lit := literal.New(map[string][]byte{"db": []byte("s3cret")})
+	r := secrets.NewResolver(
+		secrets.WithMetrics(rec),
+		secrets.WithDefault(secrets.NewCachedProvider(lit, time.Minute, secrets.WithCacheMetrics(rec, "literal"))),
+	)
+	var cfg struct {
+		DB      string `secret:"db"`
+		Missing string `secret:"missing,optional"`
+	}
+	for range 2 {
+		if err := r.Resolve(context.Background(), &cfg); err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+	}
+
+	want := `
+# HELP secrets_cache_events_total Secret cache hits, misses, and evictions, by cache.
+# TYPE secrets_cache_events_total counter
+secrets_cache_events_total{cache="literal",event="hit"} 1
+secrets_cache_events_total{cache="literal",event="miss"} 3
+# HELP secrets_fetches_total Secret fetches from providers, by provider and result.
+# TYPE secrets_fetches_total counter
+secrets_fetches_total{provider="default",result="not_found"} 2
+secrets_fetches_total{provider="default",result="ok"} 2
+# HELP secrets_resolves_total Resolve calls, by result.
+# TYPE secrets_resolves_total counter
+secrets_resolves_total{result="ok"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"secrets_cache_events_total", "secrets_fetches_total", "secrets_resolves_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(rec, "secrets_fetch_duration_seconds"); n != 1 {
+		t.Errorf("fetch duration series = %d, want 1", n)
+	}
+}
+
+func TestRecorder_WatchChange(t *testing.T) {
+	rec := New(WithNamespace("billing"), WithConstLabels(prometheus.Labels{"env": "prod"}))
+	rec.WatchChange("awssm", "prod/db")
+	rec.WatchChange("awssm", "prod/api-key")
+
+	want := `
+# HELP billing_secrets_watch_changes_total Secret changes detected by watchers, by provider.
+# TYPE billing_secrets_watch_changes_total counter
+billing_secrets_watch_changes_total{env="prod",provider="awssm"} 2
+`
+	if err := testutil.CollectAndCompare(rec, strings.NewReader(want), "billing_secrets_watch_changes_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRecorder_FetchTimeout(t *testing.T) {
+	rec := New()
+	timeout := &secrets.ErrFetchTimeout{Provider: "awssm", Key: "prod/db", Cause: context.DeadlineExceeded, Err: context.DeadlineExceeded}
+	rec.Fetch("awssm", "prod/db", "", time.Second, fmt.Errorf("wrapped: %w", timeout))
+
+	want := `
+# HELP secrets_fetches_total Secret fetches from providers, by provider and result.
+# TYPE secrets_fetches_total counter
+secrets_fetches_total{provider="awssm",result="timeout"} 1
+`
+	if err := testutil.CollectAndCompare(rec, strings.NewReader(want), "secrets_fetches_total"); err != nil {
+		t.Error(err)
+	}
+}
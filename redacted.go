@@ -0,0 +1,105 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+)
+
+// redactedText replaces the value of a Redacted wherever it is printed or
+// encoded.
+const redactedText = "[REDACTED]"
+
+// Redacted is a struct field type for a secret that must not end up in logs.
+// The resolver populates it like a field of type T, but its String, GoString,
+// Format, MarshalJSON, and LogValue methods all produce "[REDACTED]", so
+// printing a resolved config with fmt or slog, or encoding it as JSON, does
+// not reveal the value. Call Expose to use it:
+//
+//	type Config struct {
+//	    DBPass secrets.Redacted[string] `secret:"awssm://prod/db#password"`
+//	}
+//
+//	db, err := sql.Open("postgres", dsn(cfg.DBPass.Expose()))
+//
+// T may be any type supported for plain fields. Redacted may also be used
+// inside Versioned[T] and Secret[T].
+type Redacted[T any] struct {
+	v T
+}
+
+// Redact returns a Redacted holding v.
+func Redact[T any](v T) Redacted[T] {
+	return Redacted[T]{v: v}
+}
+
+// Expose returns the secret value.
+func (r Redacted[T]) Expose() T {
+	return r.v
+}
+
+// String returns "[REDACTED]".
+func (Redacted[T]) String() string {
+	return redactedText
+}
+
+// GoString returns "[REDACTED]".
+func (Redacted[T]) GoString() string {
+	return redactedText
+}
+
+// Format prints "[REDACTED]" for every verb and flag, including %#v and %x.
+func (Redacted[T]) Format(f fmt.State, _ rune) {
+	_, _ = f.Write([]byte(redactedText))
+}
+
+// MarshalJSON encodes the value as the JSON string "[REDACTED]".
+func (Redacted[T]) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(redactedText)), nil
+}
+
+// LogValue implements slog.LogValuer.
+func (Redacted[T]) LogValue() slog.Value {
+	return slog.StringValue(redactedText)
+}
+
+// redactedField is implemented by *Redacted[T] so that the resolver can set
+// Redacted fields without knowing T.
+type redactedField interface {
+	value() reflect.Value
+	valueType() reflect.Type
+}
+
+var redactedFieldType = reflect.TypeFor[redactedField]()
+
+// isRedactedType reports whether t is a Redacted[T] type.
+func isRedactedType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(redactedFieldType)
+}
+
+func (r *Redacted[T]) value() reflect.Value {
+	return reflect.ValueOf(&r.v).Elem()
+}
+
+func (r *Redacted[T]) valueType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// setRedacted sets the value of the Redacted[T] rf from raw. Conversion errors
+// report the field type but neither the raw value nor any error text that
+// may quote it.
+func setRedacted(rf redactedField, fieldName string, typeName string, raw []byte) error {
+	err := setField(rf.value(), fieldName, raw)
+	var cerr *ErrConversion
+	if !errors.As(err, &cerr) {
+		return err
+	}
+	redacted := &ErrConversion{Field: fieldName, TypeName: typeName, Raw: redactedText, Err: errors.New("invalid value")}
+	var nerr *strconv.NumError
+	if errors.As(cerr.Err, &nerr) {
+		redacted.Err = nerr.Err
+	}
+	return redacted
+}
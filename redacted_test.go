@@ -0,0 +1,166 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedacted_Resolve(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("db", []byte(`{"password":"hunter2"}`))
+	store.Store("port", []byte("5432"))
+	store.Store("timeout", []byte("5s"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		DBPass  Redacted[string]         `secret:"db#password"`
+		Port    Redacted[int]            `secret:"port"`
+		Timeout *Redacted[time.Duration] `secret:"timeout"`
+		Raw     Redacted[[]byte]         `secret:"db"`
+	}
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if got := cfg.DBPass.Expose(); got != "hunter2" {
+		t.Errorf("DBPass = %q, want %q", got, "hunter2")
+	}
+	if got := cfg.Port.Expose(); got != 5432 {
+		t.Errorf("Port = %d, want 5432", got)
+	}
+	if got := cfg.Timeout.Expose(); got != 5*time.Second {
+		t.Errorf("Timeout = %v, want 5s", got)
+	}
+	if got := string(cfg.Raw.Expose()); got != `{"password":"hunter2"}` {
+		t.Errorf("Raw = %q", got)
+	}
+}
+
+func TestRedacted_NeverPrinted(t *testing.T) {
+	type Config struct {
+		DBPass Redacted[string]
+		Port   Redacted[int]
+	}
+	cfg := Config{DBPass: Redact("hunter2"), Port: Redact(5432)}
+
+	outputs := map[string]string{
+		"%v":      fmt.Sprintf("%v", cfg),
+		"%+v":     fmt.Sprintf("%+v", cfg),
+		"%#v":     fmt.Sprintf("%#v", cfg),
+		"%s":      fmt.Sprintf("%s", cfg.DBPass),
+		"%q":      fmt.Sprintf("%q", cfg.DBPass),
+		"%x":      fmt.Sprintf("%x", cfg.DBPass),
+		"%d":      fmt.Sprintf("%d", cfg.Port),
+		"String":  cfg.DBPass.String(),
+		"GoStr":   cfg.DBPass.GoString(),
+		"Println": fmt.Sprintln(cfg.DBPass, &cfg.DBPass),
+	}
+	js, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	outputs["json"] = string(js)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("config", "pass", cfg.DBPass, "cfg", cfg)
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("config", "pass", cfg.DBPass)
+	outputs["slog"] = buf.String()
+
+	for name, out := range outputs {
+		if strings.Contains(out, "hunter2") || strings.Contains(out, "5432") || strings.Contains(out, "68756e746572") {
+			t.Errorf("%s output reveals the secret: %s", name, out)
+		}
+		if !strings.Contains(out, "[REDACTED]") {
+			t.Errorf("%s output = %s, want [REDACTED]", name, out)
+		}
+	}
+	if want := `{"DBPass":"[REDACTED]","Port":"[REDACTED]"}`; string(js) != want {
+		t.Errorf("json = %s, want %s", js, want)
+	}
+}
+
+func TestRedacted_ConversionErrorHidesValue(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("port", []byte("not-a-number-hunter2"))
+	store.Store("timeout", []byte("hunter2"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Port    Redacted[int]           `secret:"port"`
+		Timeout Redacted[time.Duration] `secret:"timeout"`
+	}
+	var cfg Config
+	err := r.Resolve(context.Background(), &cfg)
+	var cerr *ErrConversion
+	if !errors.As(err, &cerr) {
+		t.Fatalf("expected ErrConversion, got %v", err)
+	}
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("error reveals the secret: %v", err)
+	}
+	if !strings.Contains(err.Error(), "secrets.Redacted[int]") {
+		t.Errorf("error = %v, want the field type", err)
+	}
+}
+
+func TestRedacted_Validate(t *testing.T) {
+	r := NewResolver(WithDefault(&syncMapProvider{}))
+
+	type Good struct {
+		A Redacted[string]            `secret:"a"`
+		B Versioned[Redacted[string]] `secret:"b"`
+		C Secret[Redacted[int]]       `secret:"c"`
+	}
+	if err := r.Validate(&Good{}); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	type Bad struct {
+		A Redacted[map[string]string] `secret:"a"`
+	}
+	var uerr *ErrUnsupportedType
+	if err := r.Validate(&Bad{}); !errors.As(err, &uerr) {
+		t.Errorf("expected ErrUnsupportedType, got %v", err)
+	}
+}
+
+func TestRedacted_Watch(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("key", []byte("v1"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Key Redacted[string] `secret:"key"`
+	}
+	var cfg Config
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, err := r.Watch(ctx, &cfg, WatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.Store("key", []byte("v2"))
+	select {
+	case ev := <-w.Changes():
+		if string(ev.OldValue) != "v1" || string(ev.NewValue) != "v2" {
+			t.Errorf("event = %q -> %q, want v1 -> v2", ev.OldValue, ev.NewValue)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change")
+	}
+	w.RLock()
+	defer w.RUnlock()
+	if got := cfg.Key.Expose(); got != "v2" {
+		t.Errorf("Key = %q, want %q", got, "v2")
+	}
+}
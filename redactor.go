@@ -0,0 +1,211 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MinRedactLength is the length below which resolved values are not
+// registered with a Resolver's Redactor automatically. Shorter values, such
+// as ports, booleans, and small counts, are too likely to occur in ordinary
+// log text to be scrubbed from it. Redactor.Add accepts values of any length.
+const MinRedactLength = 6
+
+// Redactor scrubs known secret values from text, replacing each occurrence
+// with "[REDACTED]". Every Resolver has one, obtained with Resolver.Redactor,
+// which learns each value the Resolver extracts: by Resolve, by Watcher
+// polls (including values that have since rotated away), and by Secret.Get.
+//
+// The zero value is an empty Redactor ready to use. A Redactor is safe for
+// concurrent use.
+type Redactor struct {
+	mu       sync.RWMutex
+	values   map[string]struct{}
+	replacer *strings.Replacer // nil when stale; rebuilt on the next use
+}
+
+// Redactor returns the Redactor that knows every value r has resolved. Use it
+// to scrub logs:
+//
+//	logger := slog.New(r.Redactor().Handler(slog.NewJSONHandler(os.Stderr, nil)))
+func (r *Resolver) Redactor() *Redactor {
+	return &r.redactor
+}
+
+// Add registers values to be redacted. Empty values are ignored.
+func (rd *Redactor) Add(values ...string) {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		if _, ok := rd.values[v]; ok {
+			continue
+		}
+		if rd.values == nil {
+			rd.values = make(map[string]struct{})
+		}
+		rd.values[v] = struct{}{}
+		rd.replacer = nil
+	}
+}
+
+// addResolved registers a value extracted by the resolver if it is at least
+// MinRedactLength bytes long.
+func (rd *Redactor) addResolved(data []byte) {
+	if len(data) < MinRedactLength {
+		return
+	}
+	rd.mu.RLock()
+	_, ok := rd.values[string(data)]
+	rd.mu.RUnlock()
+	if !ok {
+		rd.Add(string(data))
+	}
+}
+
+// Len returns the number of registered values.
+func (rd *Redactor) Len() int {
+	rd.mu.RLock()
+	defer rd.mu.RUnlock()
+	return len(rd.values)
+}
+
+// Redact returns s with every registered value replaced by "[REDACTED]".
+// Where registered values overlap, the longest is replaced.
+func (rd *Redactor) Redact(s string) string {
+	repl := rd.replacerFor()
+	if repl == nil {
+		return s
+	}
+	return repl.Replace(s)
+}
+
+// replacerFor returns the replacer for the registered values, or nil if there
+// are none.
+func (rd *Redactor) replacerFor() *strings.Replacer {
+	rd.mu.RLock()
+	repl, n := rd.replacer, len(rd.values)
+	rd.mu.RUnlock()
+	if repl != nil || n == 0 {
+		return repl
+	}
+
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	if rd.replacer == nil && len(rd.values) > 0 {
+		// strings.Replacer tries old strings in argument order, so longer
+		// values come first to win over values they contain.
+		values := make([]string, 0, len(rd.values))
+		for v := range rd.values {
+			values = append(values, v)
+		}
+		slices.SortFunc(values, func(a, b string) int {
+			if c := len(b) - len(a); c != 0 {
+				return c
+			}
+			return strings.Compare(a, b)
+		})
+		oldnew := make([]string, 0, 2*len(values))
+		for _, v := range values {
+			oldnew = append(oldnew, v, redactedText)
+		}
+		rd.replacer = strings.NewReplacer(oldnew...)
+	}
+	return rd.replacer
+}
+
+// Writer returns an io.Writer that redacts each Write before passing it to w.
+// Values are matched within a single Write only, which suits loggers that
+// write one record per call, such as the log and log/slog packages and
+// zap's WriteSyncer.
+func (rd *Redactor) Writer(w io.Writer) io.Writer {
+	return &redactingWriter{rd: rd, w: w}
+}
+
+type redactingWriter struct {
+	rd *Redactor
+	w  io.Writer
+}
+
+func (rw *redactingWriter) Write(p []byte) (int, error) {
+	repl := rw.rd.replacerFor()
+	if repl == nil {
+		return rw.w.Write(p)
+	}
+	var buf bytes.Buffer
+	if _, err := repl.WriteString(&buf, string(p)); err != nil {
+		return 0, err
+	}
+	if _, err := rw.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Handler returns a slog.Handler that redacts the message and attribute
+// values of each record before passing it to h. String values, values of
+// other kinds formatted as text (such as errors and structs), and values
+// nested in groups are all redacted; numbers, booleans, times, and durations
+// are passed through unchanged.
+func (rd *Redactor) Handler(h slog.Handler) slog.Handler {
+	return &redactingHandler{rd: rd, h: h}
+}
+
+type redactingHandler struct {
+	rd *Redactor
+	h  slog.Handler
+}
+
+func (rh *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return rh.h.Enabled(ctx, level)
+}
+
+func (rh *redactingHandler) Handle(ctx context.Context, rec slog.Record) error {
+	out := slog.NewRecord(rec.Time, rec.Level, rh.rd.Redact(rec.Message), rec.PC)
+	rec.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(rh.redactAttr(a))
+		return true
+	})
+	return rh.h.Handle(ctx, out)
+}
+
+func (rh *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = rh.redactAttr(a)
+	}
+	return &redactingHandler{rd: rh.rd, h: rh.h.WithAttrs(redacted)}
+}
+
+func (rh *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{rd: rh.rd, h: rh.h.WithGroup(name)}
+}
+
+func (rh *redactingHandler) redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, rh.rd.Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = rh.redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindAny:
+		s := fmt.Sprint(v.Any())
+		if r := rh.rd.Redact(s); r != s {
+			return slog.String(a.Key, r)
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,132 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactor_LearnsResolvedValues(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("db", []byte(`{"user":"admin","password":"hunter22"}`))
+	store.Store("api-key", []byte("sk-live-abcdef"))
+	store.Store("port", []byte("5432"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		DBPass string `secret:"db#password"`
+		APIKey string `secret:"api-key"`
+		Port   int    `secret:"port"`
+	}
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	got := r.Redactor().Redact("connecting to 10.0.0.1:5432 with hunter22, key sk-live-abcdef")
+	want := "connecting to 10.0.0.1:5432 with [REDACTED], key [REDACTED]"
+	if got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+}
+
+func TestRedactor_KeepsRotatedValues(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("key", []byte("first-secret"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Key string `secret:"key"`
+	}
+	var cfg Config
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	w, err := r.Watch(ctx, &cfg, WatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.Store("key", []byte("second-secret"))
+	select {
+	case <-w.Changes():
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change")
+	}
+	got := r.Redactor().Redact("old=first-secret new=second-secret")
+	if want := "old=[REDACTED] new=[REDACTED]"; got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+}
+
+func TestRedactor_LongestMatchWins(t *testing.T) {
+	var rd Redactor
+	if got := rd.Redact("nothing registered"); got != "nothing registered" {
+		t.Errorf("Redact on empty Redactor = %q", got)
+	}
+	rd.Add("secret", "secret-suffix", "")
+	if rd.Len() != 2 {
+		t.Errorf("Len = %d, want 2", rd.Len())
+	}
+	if got, want := rd.Redact("a secret-suffix and a secret"), "a [REDACTED] and a [REDACTED]"; got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+	rd.Add("and")
+	if got, want := rd.Redact("a secret and"), "a [REDACTED] [REDACTED]"; got != want {
+		t.Errorf("Redact after Add = %q, want %q", got, want)
+	}
+}
+
+func TestRedactor_Writer(t *testing.T) {
+	var rd Redactor
+	rd.Add("hunter22")
+
+	var buf bytes.Buffer
+	logger := log.New(rd.Writer(&buf), "", 0)
+	logger.Printf("login failed for password %s", "hunter22")
+	if got, want := buf.String(), "login failed for password [REDACTED]\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	n, err := rd.Writer(&buf).Write([]byte("hunter22"))
+	if err != nil || n != len("hunter22") {
+		t.Errorf("Write = %d, %v, want %d, nil", n, err, len("hunter22"))
+	}
+}
+
+func TestRedactor_Handler(t *testing.T) {
+	var rd Redactor
+	rd.Add("hunter22")
+
+	var buf bytes.Buffer
+	logger := slog.New(rd.Handler(slog.NewTextHandler(&buf, nil)))
+	logger = logger.With("dsn", "postgres://admin:hunter22@db").WithGroup("req")
+	logger.Info("using hunter22",
+		"err", errors.New("auth failed: hunter22"),
+		"n", 42,
+		slog.Group("creds", "pass", "hunter22"),
+		"cfg", struct{ Pass string }{"hunter22"},
+	)
+
+	out := buf.String()
+	if strings.Contains(out, "hunter22") {
+		t.Errorf("output reveals the secret: %s", out)
+	}
+	for _, want := range []string{
+		`msg="using [REDACTED]"`,
+		`dsn=postgres://admin:[REDACTED]@db`,
+		`req.err="auth failed: [REDACTED]"`,
+		`req.n=42`,
+		`req.creds.pass=[REDACTED]`,
+		`req.cfg={[REDACTED]}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %s, want it to contain %s", out, want)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Package redis provides a secret provider that reads from Redis.
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brwse/go-secrets"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Client abstracts the Redis commands the provider uses.
+type Client interface {
+	// Get returns the string value of key.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// HGet returns the value of field in the hash at key.
+	HGet(ctx context.Context, key, field string) ([]byte, error)
+}
+
+// TTLClient is implemented by Clients that can report a key's remaining time
+// to live. The default client uses PTTL.
+type TTLClient interface {
+	// TTL returns the remaining time to live of key, or zero if it has no
+	// expiry.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
+
+// ProviderOption configures the redis Provider.
+type ProviderOption func(*Provider)
+
+// WithURL sets the server to connect to as a URL of the form
+// redis://[[user]:password@]host[:port][/db], or rediss:// for TLS. Defaults
+// to the REDIS_URL environment variable, or redis://localhost:6379.
+func WithURL(url string) ProviderOption {
+	return func(p *Provider) {
+		p.url = url
+	}
+}
+
+// WithAuth authenticates with an ACL username and password, overriding any
+// in the URL. An empty username authenticates as the default user, as the
+// AUTH command does with a password alone.
+func WithAuth(username, password string) ProviderOption {
+	return func(p *Provider) {
+		p.username = username
+		p.password = password
+	}
+}
+
+// WithTLSConfig connects over TLS with config, such as one trusting a
+// private CA or presenting a client certificate.
+func WithTLSConfig(config *tls.Config) ProviderOption {
+	return func(p *Provider) {
+		p.tlsConfig = config
+	}
+}
+
+// WithDB selects the logical database, overriding any in the URL.
+func WithDB(db int) ProviderOption {
+	return func(p *Provider) {
+		p.db = &db
+	}
+}
+
+// WithHashFields reads keys containing a slash as hash fields: the part after
+// the last slash names a field of the hash at the part before it, read with
+// HGET, so that "creds/app:42/password" reads the password field of the hash
+// "creds/app:42". Without it, every key is read with GET, slashes included.
+func WithHashFields() ProviderOption {
+	return func(p *Provider) {
+		p.hashFields = true
+	}
+}
+
+// WithClient injects a custom Client implementation.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider reads secrets from Redis. Keys are read with GET, or as hash
+// fields with WithHashFields.
+// It implements secrets.Provider, secrets.MetadataProvider, and io.Closer.
+type Provider struct {
+	url        string
+	username   string
+	password   string
+	tlsConfig  *tls.Config
+	db         *int
+	hashFields bool
+	client     Client
+	closer     func() error
+}
+
+// New creates a new Redis Provider. It does not connect until first used.
+func New(opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		if p.url == "" {
+			p.url = os.Getenv("REDIS_URL")
+		}
+		if p.url == "" {
+			p.url = "redis://localhost:6379"
+		}
+		o, err := goredis.ParseURL(p.url)
+		if err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		if p.username != "" || p.password != "" {
+			o.Username, o.Password = p.username, p.password
+		}
+		if p.tlsConfig != nil {
+			o.TLSConfig = p.tlsConfig
+			if o.TLSConfig.ServerName == "" {
+				o.TLSConfig = o.TLSConfig.Clone()
+				o.TLSConfig.ServerName, _, _ = net.SplitHostPort(o.Addr)
+			}
+		}
+		if p.db != nil {
+			o.DB = *p.db
+		}
+		rc := goredis.NewClient(o)
+		p.client = &sdkClient{rc: rc}
+		p.closer = rc.Close
+	}
+	return p, nil
+}
+
+// Get retrieves the value of key, or of a hash field for keys of the form
+// "key/field" with WithHashFields.
+// Returns secrets.ErrNotFound (wrapped) if the key or field does not exist.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	var val []byte
+	var err error
+	if name, field, ok := p.splitKey(key); ok {
+		val, err = p.client.HGet(ctx, name, field)
+	} else {
+		val, err = p.client.Get(ctx, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis: secret %q: %w", key, providerError(err))
+	}
+	return val, nil
+}
+
+// GetMetadata returns the time the key expires, for keys with a time to
+// live. A hash field reports the expiry of its hash.
+func (p *Provider) GetMetadata(ctx context.Context, key string) (secrets.Metadata, error) {
+	tc, ok := p.client.(TTLClient)
+	if !ok {
+		return secrets.Metadata{}, fmt.Errorf("redis: metadata %q: %w", key, errors.ErrUnsupported)
+	}
+	name := key
+	if n, _, ok := p.splitKey(key); ok {
+		name = n
+	}
+	ttl, err := tc.TTL(ctx, name)
+	if err != nil {
+		return secrets.Metadata{}, fmt.Errorf("redis: metadata %q: %w", key, providerError(err))
+	}
+	var md secrets.Metadata
+	if ttl > 0 {
+		md.ExpiresAt = time.Now().Add(ttl)
+	}
+	return md, nil
+}
+
+// Close closes the connections of the default client.
+func (p *Provider) Close() error {
+	if p.closer != nil {
+		return p.closer()
+	}
+	return nil
+}
+
+// splitKey splits "key/field" at its last slash if hash fields are enabled.
+func (p *Provider) splitKey(key string) (name, field string, ok bool) {
+	i := strings.LastIndex(key, "/")
+	if !p.hashFields || i < 0 {
+		return key, "", false
+	}
+	return key[:i], key[i+1:], true
+}
+
+// providerError converts a Redis server error, such as NOPERM or WRONGTYPE,
+// to a *secrets.ErrProvider whose Code is the error prefix, and returns
+// other errors unchanged.
+func providerError(err error) error {
+	var re goredis.Error
+	if !errors.As(err, &re) || errors.Is(err, secrets.ErrNotFound) {
+		return err
+	}
+	code, _, _ := strings.Cut(re.Error(), " ")
+	return &secrets.ErrProvider{Code: code, Err: err}
+}
+
+// sdkClient wraps a go-redis client.
+type sdkClient struct {
+	rc *goredis.Client
+}
+
+func (c *sdkClient) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.rc.Get(ctx, key).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return val, err
+}
+
+func (c *sdkClient) HGet(ctx context.Context, key, field string) ([]byte, error) {
+	val, err := c.rc.HGet(ctx, key, field).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return val, err
+}
+
+func (c *sdkClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.rc.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// PTTL reports -2 for a missing key and -1 for a key without expiry,
+	// which go-redis returns unscaled.
+	if ttl == -2 {
+		return 0, fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return max(ttl, 0), nil
+}
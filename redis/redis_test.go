@@ -0,0 +1,178 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/brwse/go-secrets"
+)
+
+var _ secrets.MetadataProvider = (*Provider)(nil)
+
+// newTestProvider returns a Provider connected to a fresh miniredis server.
+func newTestProvider(t *testing.T, opts ...ProviderOption) (*Provider, *miniredis.Miniredis) {
+	t.Helper()
+	s := miniredis.RunT(t)
+	p, err := New(append([]ProviderOption{WithURL("redis://" + s.Addr())}, opts...)...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p, s
+}
+
+func TestGet(t *testing.T) {
+	p, s := newTestProvider(t, WithHashFields())
+	s.Set("api-key", "k1")
+	s.HSet("creds/app:42", "username", "app", "password", "s3cret")
+	ctx := context.Background()
+	for key, want := range map[string]string{
+		"api-key":               "k1",
+		"creds/app:42/password": "s3cret",
+		"creds/app:42/username": "app",
+	} {
+		got, err := p.Get(ctx, key)
+		if err != nil {
+			t.Errorf("Get(%q): %v", key, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("Get(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestGet_PlainKeyWithSlash(t *testing.T) {
+	p, s := newTestProvider(t)
+	s.Set("app/prod/api-key", "k1")
+	got, err := p.Get(context.Background(), "app/prod/api-key")
+	if err != nil || string(got) != "k1" {
+		t.Errorf("Get = %q, %v; want k1", got, err)
+	}
+}
+
+func TestGet_Missing(t *testing.T) {
+	p, s := newTestProvider(t, WithHashFields())
+	s.HSet("creds", "password", "s3cret")
+	for _, key := range []string{"missing", "creds/username", "missing/password"} {
+		if _, err := p.Get(context.Background(), key); !errors.Is(err, secrets.ErrNotFound) {
+			t.Errorf("Get(%q): got %v, want ErrNotFound", key, err)
+		}
+	}
+}
+
+func TestGet_WrongType(t *testing.T) {
+	p, s := newTestProvider(t)
+	s.HSet("creds", "password", "s3cret")
+	_, err := p.Get(context.Background(), "creds")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) || pe.Code != "WRONGTYPE" {
+		t.Errorf("got %v, want a WRONGTYPE ErrProvider", err)
+	}
+}
+
+func TestWithAuth(t *testing.T) {
+	s := miniredis.RunT(t)
+	s.RequireUserAuth("app", "pw")
+	s.Set("api-key", "k1")
+
+	p, err := New(WithURL("redis://"+s.Addr()), WithAuth("app", "pw"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	if got, err := p.Get(context.Background(), "api-key"); err != nil || string(got) != "k1" {
+		t.Errorf("Get = %q, %v; want k1", got, err)
+	}
+
+	bad, err := New(WithURL("redis://"+s.Addr()), WithAuth("app", "wrong"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer bad.Close()
+	if _, err := bad.Get(context.Background(), "api-key"); err == nil {
+		t.Error("Get with a wrong password succeeded")
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	// Borrow the test certificate of httptest, valid for 127.0.0.1.
+	ts := httptest.NewTLSServer(nil)
+	defer ts.Close()
+	s, err := miniredis.RunTLS(&tls.Config{Certificates: ts.TLS.Certificates})
+	if err != nil {
+		t.Fatalf("RunTLS: %v", err)
+	}
+	defer s.Close()
+	s.Set("api-key", "k1")
+
+	roots := ts.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	p, err := New(WithURL("redis://"+s.Addr()), WithTLSConfig(&tls.Config{RootCAs: roots}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	if got, err := p.Get(context.Background(), "api-key"); err != nil || string(got) != "k1" {
+		t.Errorf("Get = %q, %v; want k1", got, err)
+	}
+}
+
+func TestWithDB(t *testing.T) {
+	p, s := newTestProvider(t, WithDB(2))
+	s.Select(2)
+	s.Set("api-key", "db2")
+	if got, err := p.Get(context.Background(), "api-key"); err != nil || string(got) != "db2" {
+		t.Errorf("Get = %q, %v; want db2", got, err)
+	}
+}
+
+func TestGetMetadata(t *testing.T) {
+	p, s := newTestProvider(t, WithHashFields())
+	s.Set("token", "t")
+	s.SetTTL("token", time.Hour)
+	s.HSet("creds", "password", "s3cret")
+	ctx := context.Background()
+
+	md, err := p.GetMetadata(ctx, "token")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if d := time.Until(md.ExpiresAt); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("ExpiresAt in %v, want about 1h", d)
+	}
+	md, err = p.GetMetadata(ctx, "creds/password")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if !md.ExpiresAt.IsZero() {
+		t.Errorf("ExpiresAt = %v for a key without expiry, want zero", md.ExpiresAt)
+	}
+	if _, err := p.GetMetadata(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("missing key: got %v, want ErrNotFound", err)
+	}
+}
+
+// getOnly implements only Client.
+type getOnly struct{ Client }
+
+func TestGetMetadata_Unsupported(t *testing.T) {
+	p, err := New(WithClient(getOnly{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := p.GetMetadata(context.Background(), "token"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("got %v, want ErrUnsupported", err)
+	}
+}
+
+func TestNew_InvalidURL(t *testing.T) {
+	if _, err := New(WithURL("http://localhost")); err == nil {
+		t.Error("New succeeded with a non-redis URL")
+	}
+}
@@ -0,0 +1,266 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Ref is an unresolved reference to a secret, written in the same syntax as a
+// `secret` struct tag:
+//
+//	[scheme://]key[#fragment][,option...]
+//
+// A Ref lets configuration carry a secret reference through layers of a
+// system and defer resolution to the component that actually needs the value,
+// via Resolver.ResolveRef.
+//
+// A Ref never holds the secret value, only its location, so it is safe to log:
+// String, MarshalText, and LogValue all render the reference itself.
+// The zero Ref is empty and cannot be resolved.
+type Ref struct {
+	tag parsedTag
+}
+
+// ParseRef parses s as a secret reference.
+func ParseRef(s string) (Ref, error) {
+	tag, err := parseTag(s)
+	if err != nil {
+		return Ref{}, err
+	}
+	return Ref{tag: tag}, nil
+}
+
+// Scheme returns the URI scheme, or "" for a bare key served by the default provider.
+func (r Ref) Scheme() string { return r.tag.Scheme }
+
+// Key returns the provider-specific secret key.
+func (r Ref) Key() string { return r.tag.Key }
+
+// Fragment returns the JSON fragment to extract, or "".
+func (r Ref) Fragment() string { return r.tag.Fragment }
+
+// Version returns the requested version, or "" for the current version.
+func (r Ref) Version() string { return r.tag.Version }
+
+// Optional reports whether a missing secret resolves to nil instead of an error.
+func (r Ref) Optional() bool { return r.tag.Optional }
+
+// IsZero reports whether r is the zero (empty) Ref.
+func (r Ref) IsZero() bool { return r.tag.Key == "" }
+
+// String returns the reference in canonical form. It never contains a secret value.
+func (r Ref) String() string {
+	if r.IsZero() {
+		return ""
+	}
+	return r.tag.String()
+}
+
+// LogValue implements slog.LogValuer.
+func (r Ref) LogValue() slog.Value {
+	return slog.StringValue(r.String())
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r Ref) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so a Ref can be loaded
+// from JSON, YAML, flags, or environment-based configuration.
+// Empty text yields the zero Ref.
+func (r *Ref) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*r = Ref{}
+		return nil
+	}
+	ref, err := ParseRef(string(text))
+	if err != nil {
+		return err
+	}
+	*r = ref
+	return nil
+}
+
+// ResolveRef fetches the secret referenced by ref and returns its raw bytes,
+// after fragment extraction. If ref is optional and the secret does not
+// exist, ResolveRef returns nil and no error.
+func (r *Resolver) ResolveRef(ctx context.Context, ref Ref) ([]byte, error) {
+	if ref.IsZero() {
+		return nil, errors.New("secrets: empty reference")
+	}
+	tag := ref.tag
+	p, providerName, err := r.providerFor("", tag)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := r.fetch(ctx, p, providerName, "", tag.Key, tag.Version)
+	if err != nil {
+		if tag.Optional && errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, &ErrResolve{URI: tag.URI(), Provider: providerName, Version: tag.Version, Err: err}
+	}
+
+	data, err = r.extractValue(tag, data)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: %s: %w", ref, err)
+	}
+	return data, nil
+}
+
+// ResolveMap resolves a set of named secret references given as strings in
+// `secret` tag syntax, such as names mapped to references in a YAML file, and
+// returns their values by name:
+//
+//	values, err := r.ResolveMap(ctx, map[string]string{
+//		"db_password": "awssm://prod/db#password",
+//		"api_key":     "vault://payments/stripe#key",
+//	})
+//
+// It behaves as ResolveRefs does. If any reference does not parse, nothing is
+// fetched.
+func (r *Resolver) ResolveMap(ctx context.Context, uris map[string]string) (map[string][]byte, error) {
+	refs := make(map[string]Ref, len(uris))
+	var errs []error
+	for name, uri := range uris {
+		ref, err := ParseRef(uri)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("secrets: %s: %w", name, err))
+			continue
+		}
+		refs[name] = ref
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return r.ResolveRefs(ctx, refs)
+}
+
+// ResolveRefs resolves a set of named references concurrently, honoring the
+// resolver's parallelism limit and fetching each distinct secret (URI and
+// version) only once, and returns their values by name. Optional references
+// whose secret does not exist are omitted from the result. Errors are
+// reported per name and joined. As in Resolve, current values served by a
+// BatchProvider are fetched together.
+func (r *Resolver) ResolveRefs(ctx context.Context, refs map[string]Ref) (map[string][]byte, error) {
+	type fetchResult struct {
+		data []byte
+		err  error
+	}
+
+	var errs []error
+	byKey := make(map[fetchKey][]string) // fetch key -> names
+	for name, ref := range refs {
+		if ref.IsZero() {
+			errs = append(errs, fmt.Errorf("secrets: %s: empty reference", name))
+			continue
+		}
+		fk := fetchKey{uri: ref.tag.URI(), version: ref.tag.Version}
+		byKey[fk] = append(byKey[fk], name)
+	}
+
+	// Strip the fragment and optional flag; they are applied per name below.
+	bare := func(fk fetchKey) Ref {
+		tag := refs[byKey[fk][0]].tag
+		return Ref{tag: parsedTag{Scheme: tag.Scheme, Key: tag.Key, Version: fk.version}}
+	}
+	type batch struct {
+		p    BatchProvider
+		fks  []fetchKey
+		keys []string
+	}
+	batches := make(map[string]*batch) // provider name -> batch
+	var single []fetchKey
+	for fk := range byKey {
+		if fk.version == "" {
+			ref := bare(fk)
+			if p, name, err := r.providerFor("", ref.tag); err == nil {
+				if bp, ok := p.(BatchProvider); ok {
+					b := batches[name]
+					if b == nil {
+						b = &batch{p: bp}
+						batches[name] = b
+					}
+					b.fks = append(b.fks, fk)
+					b.keys = append(b.keys, ref.tag.Key)
+					continue
+				}
+			}
+		}
+		single = append(single, fk)
+	}
+	for name, b := range batches {
+		if len(b.fks) < 2 {
+			single = append(single, b.fks...)
+			delete(batches, name)
+		}
+	}
+
+	results := make(map[fetchKey]fetchResult, len(byKey))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, r.cfg.parallelism)
+	fetchOne := func(fk fetchKey) {
+		defer wg.Done()
+		sem <- struct{}{}        // acquire
+		defer func() { <-sem }() // release
+
+		data, err := r.ResolveRef(ctx, bare(fk))
+		mu.Lock()
+		results[fk] = fetchResult{data: data, err: err}
+		mu.Unlock()
+	}
+	for _, fk := range single {
+		wg.Add(1)
+		go fetchOne(fk)
+	}
+	for name, b := range batches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			values := r.fetchBatch(ctx, b.p, name, b.keys)
+			<-sem
+			for i, fk := range b.fks {
+				if data, ok := values[b.keys[i]]; ok {
+					data, err := r.extractValue(bare(fk).tag, data) // as ResolveRef does
+					mu.Lock()
+					results[fk] = fetchResult{data: data, err: err}
+					mu.Unlock()
+					continue
+				}
+				wg.Add(1)
+				go fetchOne(fk)
+			}
+		}()
+	}
+	wg.Wait()
+
+	out := make(map[string][]byte, len(refs))
+	for fk, names := range byKey {
+		res := results[fk]
+		doc := newJSONDoc(res.data)
+		for _, name := range names {
+			tag := refs[name].tag
+			if res.err != nil {
+				if tag.Optional && errors.Is(res.err, ErrNotFound) {
+					continue
+				}
+				errs = append(errs, fmt.Errorf("secrets: %s: %w", name, res.err))
+				continue
+			}
+			value, err := r.extractFrom(tag, doc)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("secrets: %s: %w", name, err))
+				continue
+			}
+			out[name] = value
+		}
+	}
+	return out, errors.Join(errs...)
+}
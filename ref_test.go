@@ -0,0 +1,230 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	ref, err := ParseRef("awssm://prod/db#password,version=previous,optional")
+	if err != nil {
+		t.Fatalf("ParseRef: %v", err)
+	}
+	if ref.Scheme() != "awssm" || ref.Key() != "prod/db" || ref.Fragment() != "password" ||
+		ref.Version() != "previous" || !ref.Optional() {
+		t.Errorf("unexpected ref components: %+v", ref.tag)
+	}
+	want := "awssm://prod/db#password,optional,version=previous"
+	if got := ref.String(); got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+	again, err := ParseRef(ref.String())
+	if err != nil {
+		t.Fatalf("ParseRef(String()): %v", err)
+	}
+	if again != ref {
+		t.Errorf("round trip mismatch: %+v != %+v", again, ref)
+	}
+}
+
+func TestParseRef_Invalid(t *testing.T) {
+	for _, s := range []string{"", "awssm://", "key,bogus"} {
+		if _, err := ParseRef(s); err == nil {
+			t.Errorf("ParseRef(%q): expected error", s)
+		}
+	}
+}
+
+func TestRef_JSON(t *testing.T) {
+	var cfg struct {
+		DB    Ref `json:"db"`
+		Empty Ref `json:"empty"`
+	}
+	if err := json.Unmarshal([]byte(`{"db":"vault://app/db#password","empty":""}`), &cfg); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if cfg.DB.Scheme() != "vault" || cfg.DB.Fragment() != "password" {
+		t.Errorf("DB = %+v", cfg.DB.tag)
+	}
+	if !cfg.Empty.IsZero() {
+		t.Errorf("Empty = %+v, want zero", cfg.Empty.tag)
+	}
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(out) != `{"db":"vault://app/db#password","empty":""}` {
+		t.Errorf("Marshal = %s", out)
+	}
+	if got := cfg.DB.LogValue().String(); got != "vault://app/db#password" {
+		t.Errorf("LogValue = %q", got)
+	}
+}
+
+func TestResolveRef(t *testing.T) {
+	p := &mockVersionedProvider{
+		data: map[string][]byte{"db": []byte(`{"user":"admin","password":"s3cret"}`)},
+		versions: map[string]map[string][]byte{
+			"db": {"previous": []byte(`{"password":"old"}`)},
+		},
+	}
+	r := NewResolver(WithDefault(p), WithProvider("v", p))
+	ctx := context.Background()
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"db#password", "s3cret"},
+		{"v://db#user", "admin"},
+		{"db#password,version=previous", "old"},
+	}
+	for _, tt := range tests {
+		ref, err := ParseRef(tt.ref)
+		if err != nil {
+			t.Fatalf("ParseRef(%q): %v", tt.ref, err)
+		}
+		got, err := r.ResolveRef(ctx, ref)
+		if err != nil {
+			t.Fatalf("ResolveRef(%q): %v", tt.ref, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("ResolveRef(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestResolveRef_Errors(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{data: map[string][]byte{}}))
+	ctx := context.Background()
+
+	if _, err := r.ResolveRef(ctx, Ref{}); err == nil {
+		t.Error("expected error for zero Ref")
+	}
+
+	ref, _ := ParseRef("missing")
+	if _, err := r.ResolveRef(ctx, ref); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	ref, _ = ParseRef("missing,optional")
+	got, err := r.ResolveRef(ctx, ref)
+	if err != nil || got != nil {
+		t.Errorf("optional missing: got %q, %v; want nil, nil", got, err)
+	}
+
+	ref, _ = ParseRef("nope://key")
+	var unknown *ErrUnknownProvider
+	if _, err := r.ResolveRef(ctx, ref); !errors.As(err, &unknown) {
+		t.Errorf("expected ErrUnknownProvider, got %v", err)
+	}
+
+	ref, _ = ParseRef("key,version=previous")
+	var notSupported *ErrVersioningNotSupported
+	if _, err := r.ResolveRef(ctx, ref); !errors.As(err, &notSupported) {
+		t.Errorf("expected ErrVersioningNotSupported, got %v", err)
+	}
+
+	r = NewResolver(WithProvider("store", deniedProvider{}))
+	ref, _ = ParseRef("store://db#password")
+	_, err = r.ResolveRef(ctx, ref)
+	var re *ErrResolve
+	var pe *ErrProvider
+	if !errors.As(err, &re) || re.Field != "" || !errors.As(re, &pe) {
+		t.Fatalf("expected ErrResolve with provider details, got %v", err)
+	}
+	if want := `secrets: store://db: store: secret "db": access denied`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err, want)
+	}
+}
+
+func TestResolver_ResolveRefs(t *testing.T) {
+	var calls atomic.Int64
+	p := &countingProvider{data: map[string][]byte{"db": []byte(`{"user":"admin","password":"s3cret"}`)}, count: &calls}
+	r := NewResolver(WithDefault(p))
+
+	refs := make(map[string]Ref)
+	for name, s := range map[string]string{"user": "db#user", "password": "db#password", "opt": "missing,optional"} {
+		ref, err := ParseRef(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs[name] = ref
+	}
+	got, err := r.ResolveRefs(context.Background(), refs)
+	if err != nil {
+		t.Fatalf("ResolveRefs: %v", err)
+	}
+	if len(got) != 2 || string(got["user"]) != "admin" || string(got["password"]) != "s3cret" {
+		t.Errorf("ResolveRefs = %q", got)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("provider calls = %d, want 2 (db fetched once)", n)
+	}
+
+	refs["bad"], _ = ParseRef("missing")
+	if _, err := r.ResolveRefs(context.Background(), refs); !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "bad") {
+		t.Errorf("expected ErrNotFound naming bad, got %v", err)
+	}
+}
+
+func TestResolver_ResolveMap(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{data: map[string][]byte{
+		"db": []byte(`{"user":"admin","password":"s3cret"}`),
+	}}))
+
+	got, err := r.ResolveMap(context.Background(), map[string]string{
+		"user":     "db#user",
+		"password": "db#password",
+		"optional": "missing,optional",
+	})
+	if err != nil {
+		t.Fatalf("ResolveMap: %v", err)
+	}
+	if len(got) != 2 || string(got["user"]) != "admin" || string(got["password"]) != "s3cret" {
+		t.Errorf("ResolveMap = %q", got)
+	}
+
+	_, err = r.ResolveMap(context.Background(), map[string]string{"a": "", "b": "db,bogus", "c": "db#user"})
+	if err == nil || !strings.Contains(err.Error(), "secrets: a: ") || !strings.Contains(err.Error(), "secrets: b: ") {
+		t.Errorf("expected errors for a and b, got %v", err)
+	}
+	_, err = r.ResolveMap(context.Background(), map[string]string{"a": "missing", "b": "other"})
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "secrets: a: ") || !strings.Contains(err.Error(), "secrets: b: ") {
+		t.Errorf("expected joined ErrNotFound for a and b, got %v", err)
+	}
+}
+
+func TestResolver_ResolveRefsBatch(t *testing.T) {
+	p := newBatchProvider()
+	p.skip = map[string]bool{"token": true}
+	r := NewResolver(WithDefault(p))
+
+	refs := make(map[string]Ref)
+	for name, s := range map[string]string{"user": "db#user", "api": "api", "token": "token", "opt": "missing,optional"} {
+		ref, err := ParseRef(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs[name] = ref
+	}
+	got, err := r.ResolveRefs(context.Background(), refs)
+	if err != nil {
+		t.Fatalf("ResolveRefs: %v", err)
+	}
+	if len(got) != 3 || string(got["user"]) != "admin" || string(got["api"]) != "key" || string(got["token"]) != "tok" {
+		t.Errorf("ResolveRefs = %q", got)
+	}
+	if want := [][]string{{"api", "db", "missing", "token"}}; !reflect.DeepEqual(p.batches, want) {
+		t.Errorf("batches = %q, want %q", p.batches, want)
+	}
+	if got := slices.Sorted(slices.Values(p.gets)); !reflect.DeepEqual(got, []string{"missing", "token"}) {
+		t.Errorf("individual gets = %q, want missing and token", got)
+	}
+}
@@ -0,0 +1,177 @@
+// Package render renders text/template files whose secrets come from a
+// secrets.Resolver, for configuration files of programs that cannot read
+// secrets themselves, such as nginx.conf:
+//
+//	ssl_password_file {{ secret "file:///run/secrets/tls-pass" }};
+//	proxy_set_header Authorization "Bearer {{ secret "awssm://prod/upstream#token" }}";
+//
+// The secret function takes a reference in the syntax of the `secret` struct
+// tag. As with Resolver.Resolve, the secrets a template references are
+// fetched concurrently, each distinct secret once.
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/brwse/go-secrets"
+)
+
+// Option configures a Template.
+type Option func(*config)
+
+type config struct {
+	funcs       template.FuncMap
+	left, right string
+}
+
+// WithFuncs adds funcs to the template's function map, as
+// template.Template.Funcs does. A function named "secret" is replaced.
+func WithFuncs(funcs template.FuncMap) Option {
+	return func(c *config) {
+		for name, fn := range funcs {
+			c.funcs[name] = fn
+		}
+	}
+}
+
+// WithDelims sets the template's action delimiters, as
+// template.Template.Delims does, for files in which "{{" is common.
+func WithDelims(left, right string) Option {
+	return func(c *config) {
+		c.left, c.right = left, right
+	}
+}
+
+// Template is a parsed template whose secret function reads from a Resolver.
+// It is safe for concurrent use.
+type Template struct {
+	tmpl *template.Template
+}
+
+// Parse parses text as a template named name. Referring to a missing map key
+// is an error rather than "<no value>".
+func Parse(name, text string, opts ...Option) (*Template, error) {
+	c := config{funcs: template.FuncMap{}}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	c.funcs["secret"] = func(string) (string, error) {
+		return "", fmt.Errorf("render: secret called outside Execute")
+	}
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Delims(c.left, c.right).
+		Funcs(c.funcs).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("render: %w", err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// ParseFile parses the template in the file at path, named by its base name.
+func ParseFile(path string, opts ...Option) (*Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("render: %w", err)
+	}
+	return Parse(filepath.Base(path), string(text), opts...)
+}
+
+// Execute renders t with data as dot, resolving secrets with r, and writes
+// the result to w. Nothing is written if rendering fails.
+//
+// Execute renders t twice. The first pass records the references passed to
+// secret, with every secret empty, and they are then resolved together with
+// Resolver.ResolveRefs; the second pass writes the output. A reference that
+// the first pass did not reach, because it depends on the value of another
+// secret, is resolved when the second pass reaches it.
+func (t *Template) Execute(ctx context.Context, r *secrets.Resolver, w io.Writer, data any) error {
+	refs := make(map[string]secrets.Ref)
+	// Errors caused by empty secrets are not errors of the second pass, which
+	// reports any others again.
+	_ = t.execute(io.Discard, data, func(s string) (string, error) {
+		ref, err := secrets.ParseRef(s)
+		if err != nil {
+			return "", err
+		}
+		refs[s] = ref
+		return "", nil
+	})
+
+	values, err := r.ResolveRefs(ctx, refs)
+	if err != nil {
+		return fmt.Errorf("render: %s: %w", t.tmpl.Name(), err)
+	}
+	var buf bytes.Buffer
+	if err := t.execute(&buf, data, func(s string) (string, error) {
+		if v, ok := values[s]; ok {
+			return string(v), nil
+		}
+		if _, ok := refs[s]; ok {
+			return "", nil // optional and missing
+		}
+		ref, err := secrets.ParseRef(s)
+		if err != nil {
+			return "", err
+		}
+		v, err := r.ResolveRef(ctx, ref)
+		if err != nil {
+			return "", err
+		}
+		refs[s] = ref
+		if v != nil {
+			values[s] = v
+		}
+		return string(v), nil
+	}); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// ExecuteFile renders t as Execute does and writes the result to the file at
+// path with mode 0600. The file is replaced atomically, so a process reading
+// it never sees a partial file.
+func (t *Template) ExecuteFile(ctx context.Context, r *secrets.Resolver, path string, data any) error {
+	var buf bytes.Buffer
+	if err := t.Execute(ctx, r, &buf, data); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("render: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+	return nil
+}
+
+// execute executes a clone of t's template with secret as its secret
+// function.
+func (t *Template) execute(w io.Writer, data any, secret func(string) (string, error)) error {
+	tmpl, err := t.tmpl.Clone()
+	if err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+	if err := tmpl.Funcs(template.FuncMap{"secret": secret}).Execute(w, data); err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// mapProvider serves secrets from a map and counts fetches.
+type mapProvider struct {
+	mu    sync.Mutex
+	data  map[string]string
+	calls map[string]int
+}
+
+func (p *mapProvider) Get(_ context.Context, key string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.calls == nil {
+		p.calls = make(map[string]int)
+	}
+	p.calls[key]++
+	v, ok := p.data[key]
+	if !ok {
+		return nil, fmt.Errorf("map: %q: %w", key, secrets.ErrNotFound)
+	}
+	return []byte(v), nil
+}
+
+func TestExecute(t *testing.T) {
+	p := &mapProvider{data: map[string]string{
+		"db":  `{"user":"admin","password":"s3cret"}`,
+		"env": "prod",
+	}}
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	tmpl, err := Parse("app.conf", `user={{ secret "db#user" }}
+password={{ secret "db#password" }}
+again={{ secret "db#password" }}
+missing={{ secret "missing,optional" }}
+listen={{ .Port }}
+`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(context.Background(), r, &out, map[string]int{"Port": 8080}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	want := "user=admin\npassword=s3cret\nagain=s3cret\nmissing=\nlisten=8080\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if p.calls["db"] != 1 || p.calls["missing"] != 1 {
+		t.Errorf("calls = %v, want one fetch per secret", p.calls)
+	}
+}
+
+func TestExecute_DependentReference(t *testing.T) {
+	p := &mapProvider{data: map[string]string{"env": "prod", "db-prod": "s3cret"}}
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	tmpl, err := Parse("t", `{{ $env := secret "env" }}{{ if $env }}{{ secret (printf "db-%s" $env) }}{{ end }}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(context.Background(), r, &out, nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out.String() != "s3cret" {
+		t.Errorf("output = %q, want %q", out.String(), "s3cret")
+	}
+}
+
+// barrierProvider blocks each Get until n Gets are in flight.
+type barrierProvider struct {
+	n       int
+	mu      sync.Mutex
+	waiting int
+	ready   chan struct{}
+}
+
+func (p *barrierProvider) Get(_ context.Context, key string) ([]byte, error) {
+	p.mu.Lock()
+	p.waiting++
+	if p.waiting == p.n {
+		close(p.ready)
+	}
+	p.mu.Unlock()
+	select {
+	case <-p.ready:
+		return []byte(key), nil
+	case <-time.After(5 * time.Second):
+		return nil, errors.New("fetches were not concurrent")
+	}
+}
+
+func TestExecute_Concurrent(t *testing.T) {
+	r := secrets.NewResolver(secrets.WithDefault(&barrierProvider{n: 3, ready: make(chan struct{})}))
+	tmpl, err := Parse("t", `{{ secret "a" }}{{ secret "b" }}{{ secret "c" }}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(context.Background(), r, &out, nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out.String() != "abc" {
+		t.Errorf("output = %q, want %q", out.String(), "abc")
+	}
+}
+
+func TestExecute_Errors(t *testing.T) {
+	r := secrets.NewResolver(secrets.WithDefault(&mapProvider{data: map[string]string{"a": "1"}}))
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`{{ secret "a" }}{{ secret "missing" }}`, "missing"},
+		{`{{ secret "a,sometimes" }}`, "unknown tag option"},
+		{`{{ secret "a" }}{{ .Nope }}`, "Nope"},
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse("t", tt.text)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.text, err)
+		}
+		var out bytes.Buffer
+		err = tmpl.Execute(context.Background(), r, &out, map[string]string{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Execute(%q) = %v, want error containing %q", tt.text, err, tt.want)
+		}
+		if out.Len() > 0 {
+			t.Errorf("Execute(%q) wrote %q despite failing", tt.text, out.String())
+		}
+	}
+
+	if _, err := Parse("t", `{{ secret "a" }`); err == nil {
+		t.Error("Parse: expected error for malformed template")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	r := secrets.NewResolver(secrets.WithDefault(&mapProvider{data: map[string]string{"token": "abc"}}))
+	tmpl, err := Parse("t", `{{ "{{literal}}" }} <% secret "token" | upper %>`,
+		WithDelims("<%", "%>"),
+		WithFuncs(template.FuncMap{"upper": strings.ToUpper}))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(context.Background(), r, &out, nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if want := `{{ "{{literal}}" }} ABC`; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestExecuteFile(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "nginx.conf.tmpl"), filepath.Join(dir, "nginx.conf")
+	if err := os.WriteFile(src, []byte(`proxy_set_header Authorization "Bearer {{ secret "token" }}";`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := secrets.NewResolver(secrets.WithDefault(&mapProvider{data: map[string]string{"token": "abc"}}))
+
+	tmpl, err := ParseFile(src)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if err := tmpl.ExecuteFile(context.Background(), r, dst, nil); err != nil {
+		t.Fatalf("ExecuteFile: %v", err)
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `proxy_set_header Authorization "Bearer abc";`; string(got) != want {
+		t.Errorf("file = %q, want %q", got, want)
+	}
+	if fi, err := os.Stat(dst); err == nil && fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", fi.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("directory has %d entries, want no temporary files left", len(entries))
+	}
+}
@@ -1,11 +1,15 @@
 package secrets
 
 import (
+	"bytes"
 	"context"
 	"encoding"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"log/slog"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -13,8 +17,16 @@ import (
 )
 
 // Resolver populates struct fields annotated with `secret` tags from configured providers.
+// A Resolver is safe for concurrent use.
 type Resolver struct {
-	cfg resolverConfig
+	mu       sync.RWMutex // guards cfg.defaultProvider, cfg.providers, and cfg.order
+	cfg      resolverConfig
+	redactor Redactor
+
+	life     sync.Mutex // guards closed and watchers
+	closed   bool
+	watchers map[*Watcher]struct{} // running Watchers, stopped by CloseContext
+	inflight sync.WaitGroup        // in-flight fetches, waited for by CloseContext
 }
 
 // NewResolver creates a Resolver with the given options.
@@ -29,9 +41,82 @@ func NewResolver(opts ...Option) *Resolver {
 	return r
 }
 
-// Close closes all providers that implement io.Closer.
-func (r *Resolver) Close() error {
-	return closeProviders(&r.cfg)
+// ReplaceProvider atomically replaces the provider registered for scheme and
+// returns the previous one (nil if none was registered). An empty scheme
+// replaces the default provider used for bare keys.
+//
+// It is safe to call while Resolve calls and Watchers are running: in-flight
+// fetches complete against the previous provider, and every subsequent
+// Resolve (including each Watcher poll) uses p. This allows long-running
+// processes to rotate provider credentials or endpoints without rebuilding
+// resolvers and watchers. The previous provider is not closed; callers should
+// close it once in-flight work has drained. A nil p unregisters scheme.
+func (r *Resolver) ReplaceProvider(scheme string, p Provider) Provider {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p == nil {
+		r.cfg.unregistered(scheme)
+	} else {
+		r.cfg.registered(scheme)
+	}
+	if scheme == "" {
+		old := r.cfg.defaultProvider
+		r.cfg.defaultProvider = p
+		return old
+	}
+	old := r.cfg.providers[scheme]
+	if p == nil {
+		delete(r.cfg.providers, scheme)
+		return old
+	}
+	if r.cfg.providers == nil {
+		r.cfg.providers = make(map[string]Provider)
+	}
+	r.cfg.providers[scheme] = p
+	return old
+}
+
+// HasProvider reports whether a provider is registered for scheme. An empty
+// scheme reports whether there is a default provider for bare keys.
+func (r *Resolver) HasProvider(scheme string) bool {
+	_, ok := r.lookupProvider(scheme)
+	return ok
+}
+
+// lookupProvider returns the provider registered for scheme, or the default
+// provider if scheme is empty.
+func (r *Resolver) lookupProvider(scheme string) (Provider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if scheme == "" {
+		return r.cfg.defaultProvider, r.cfg.defaultProvider != nil
+	}
+	p, ok := r.cfg.providers[scheme]
+	return p, ok
+}
+
+// providerFor returns the provider and provider name ("default" for bare
+// keys) that serve tag. field is used for error reporting.
+func (r *Resolver) providerFor(field string, tag parsedTag) (Provider, string, error) {
+	if tag.Scheme != "" {
+		p, found := r.lookupProvider(tag.Scheme)
+		if !found {
+			return nil, "", &ErrUnknownProvider{
+				Field:  field,
+				Scheme: tag.Scheme,
+				URI:    tag.URI(),
+			}
+		}
+		return p, tag.Scheme, nil
+	}
+	p, found := r.lookupProvider("")
+	if !found {
+		return nil, "", &ErrNoDefaultProvider{
+			Field: field,
+			Key:   tag.Key,
+		}
+	}
+	return p, "default", nil
 }
 
 // Validate checks that dst is a valid target for Resolve without contacting any provider.
@@ -97,35 +182,45 @@ func (r *Resolver) validateStruct(st reflect.Type, errs *[]error) {
 		}
 
 		// Validate provider availability.
-		if tag.Scheme != "" {
-			if _, found := r.cfg.providers[tag.Scheme]; !found {
-				*errs = append(*errs, &ErrUnknownProvider{
-					Field:  field.Name,
-					Scheme: tag.Scheme,
-					URI:    tag.URI(),
-				})
-			}
-		} else {
-			if r.cfg.defaultProvider == nil {
-				*errs = append(*errs, &ErrNoDefaultProvider{
-					Field: field.Name,
-					Key:   tag.Key,
-				})
+		p, _, err := r.providerFor(field.Name, tag)
+		if err != nil {
+			*errs = append(*errs, err)
+		} else if _, ok := p.(WriterProvider); !ok && r.cfg.bootstrap && tag.Bootstrap != "" {
+			*errs = append(*errs, fmt.Errorf("secrets: field %s: bootstrap= requires a provider that implements WriterProvider", field.Name))
+		}
+
+		if tag.Decrypt != "" && len(r.cfg.decryptionKeys) == 0 {
+			*errs = append(*errs, fmt.Errorf("secrets: field %s: decrypt=%s requires WithDecryptionKey", field.Name, tag.Decrypt))
+		}
+		for _, name := range tag.transforms() {
+			if _, ok := r.transformFor(name); !ok {
+				*errs = append(*errs, fmt.Errorf("secrets: field %s: unknown transform %q", field.Name, name))
 			}
 		}
 
 		// Validate field type is supported.
 		ft := field.Type
-		if isVersionedType(ft) {
+		if tag.TTL > 0 && !isLazyType(ft) {
+			*errs = append(*errs, fmt.Errorf("secrets: field %s: ttl= requires a Secret[T] field", field.Name))
+		}
+		if isLazyType(ft) {
+			// For Secret[T], validate the value type T.
+			if !r.supportsType(reflect.New(ft).Interface().(lazyField).valueType()) {
+				*errs = append(*errs, &ErrUnsupportedType{
+					Field:    field.Name,
+					TypeName: ft.String(),
+				})
+			}
+		} else if isVersionedType(ft) {
 			// For Versioned[T], validate the inner type T (Current field type).
 			innerType := ft.Field(0).Type
-			if !isSupportedType(innerType) {
+			if !r.supportsType(innerType) {
 				*errs = append(*errs, &ErrUnsupportedType{
 					Field:    field.Name,
 					TypeName: ft.String(),
 				})
 			}
-		} else if !isSupportedType(ft) {
+		} else if !r.supportsType(ft) {
 			*errs = append(*errs, &ErrUnsupportedType{
 				Field:    field.Name,
 				TypeName: ft.String(),
@@ -141,6 +236,10 @@ func isSupportedType(t reflect.Type) bool {
 		t = t.Elem()
 	}
 
+	if isRedactedType(t) {
+		return isSupportedType(reflect.New(t).Interface().(redactedField).valueType())
+	}
+
 	// Check for encoding.TextUnmarshaler via pointer receiver.
 	if reflect.PointerTo(t).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
 		return true
@@ -185,7 +284,15 @@ type fieldInfo struct {
 	tag          parsedTag
 	provider     Provider
 	providerName string
-	isVersioned  bool // true if the field is a Versioned[T] type
+	isVersioned  bool   // true if the field is a Versioned[T] type
+	isLazy       bool   // true if the field is a Secret[T] type, bound instead of fetched
+	built        bool   // true if the field's value is built by a field factory
+	raw          []byte // extracted bytes of the value (Current for Versioned[T]), set by resolveFields
+}
+
+// resolveError reports that fetching fi's secret at version failed with err.
+func (fi *fieldInfo) resolveError(version string, err error) *ErrResolve {
+	return &ErrResolve{Field: fi.fieldName, URI: fi.tag.URI(), Provider: fi.providerName, Version: version, Err: err}
 }
 
 // fetchKey uniquely identifies a fetch operation including version.
@@ -208,27 +315,149 @@ func (fk fetchKey) String() string {
 // Secrets are deduplicated by URI so the same secret is only fetched once.
 // All errors are collected and returned via errors.Join.
 func (r *Resolver) Resolve(ctx context.Context, dst any) error {
+	_, err := r.resolve(ctx, dst)
+	return err
+}
+
+// resolve implements Resolve, also returning the resolved fields.
+func (r *Resolver) resolve(ctx context.Context, dst any) ([]fieldInfo, error) {
+	return r.resolveExcept(ctx, dst, nil)
+}
+
+// resolveExcept is resolve, leaving alone the fields for whose values skip
+// reports true. A nil skip resolves every field.
+func (r *Resolver) resolveExcept(ctx context.Context, dst any, skip func(reflect.Value) bool) ([]fieldInfo, error) {
 	rv := reflect.ValueOf(dst)
 	if rv.Kind() != reflect.Pointer || rv.IsNil() {
-		return fmt.Errorf("secrets: dst must be a non-nil pointer, got %T", dst)
+		return nil, fmt.Errorf("secrets: dst must be a non-nil pointer, got %T", dst)
 	}
 	elem := rv.Elem()
 	if elem.Kind() != reflect.Struct {
-		return fmt.Errorf("secrets: dst must point to a struct, got pointer to %s", elem.Kind())
+		return nil, fmt.Errorf("secrets: dst must point to a struct, got pointer to %s", elem.Kind())
 	}
 
 	// Phase 1: Collect all fields that need resolution.
 	var fields []fieldInfo
 	var collectErrs []error
 	r.collectFields(elem, &fields, &collectErrs)
+	if skip != nil {
+		fields = slices.DeleteFunc(fields, func(f fieldInfo) bool { return skip(f.fieldValue) })
+	}
 	if len(collectErrs) > 0 && len(fields) == 0 {
-		return errors.Join(collectErrs...)
+		return nil, errors.Join(collectErrs...)
+	}
+
+	start := time.Now()
+	assignErrs := r.resolveFields(ctx, fields)
+	allErrs := append(collectErrs, assignErrs...)
+	d := time.Since(start)
+	r.log(ctx, slog.LevelDebug, "secrets resolved",
+		slog.Int("fields", len(fields)),
+		slog.Int("errors", len(allErrs)),
+		slog.Duration("duration", d))
+	err := errors.Join(allErrs...)
+	if r.cfg.metrics != nil {
+		r.cfg.metrics.Resolve(len(fields), d, err)
+	}
+	return fields, err
+}
+
+// fetch retrieves key from p, at version if it is not empty, and logs the
+// call. field is used for error reporting.
+func (r *Resolver) fetch(ctx context.Context, p Provider, providerName, field, key, version string) ([]byte, error) {
+	if r.cfg.userAgent != "" && UserAgentFromContext(ctx) == "" {
+		ctx = ContextWithUserAgent(ctx, r.cfg.userAgent)
+	}
+	if !r.acquire() {
+		return nil, ErrClosed
+	}
+	defer r.inflight.Done()
+	start := time.Now()
+	var data []byte
+	var err error
+	if version != "" {
+		vp, ok := p.(VersionedProvider)
+		if !ok {
+			return nil, &ErrVersioningNotSupported{Field: field, Provider: providerName}
+		}
+		data, err = vp.GetVersion(ctx, key, version)
+	} else {
+		data, err = p.Get(ctx, key)
+	}
+	d := time.Since(start)
+	if err != nil {
+		err = fetchTimeout(ctx, err, field, providerName, key, d)
 	}
+	r.logFetch(ctx, providerName, key, version, d, err)
+	if r.cfg.metrics != nil {
+		r.cfg.metrics.Fetch(providerName, key, version, d, err)
+	}
+	return data, err
+}
+
+// fetchBatch fetches the current values of keys from bp with one GetBatch
+// call, and logs and records each value it returns as a fetch. It returns nil
+// if the call fails, so that the caller fetches every key individually.
+func (r *Resolver) fetchBatch(ctx context.Context, bp BatchProvider, providerName string, keys []string) map[string][]byte {
+	if r.cfg.userAgent != "" && UserAgentFromContext(ctx) == "" {
+		ctx = ContextWithUserAgent(ctx, r.cfg.userAgent)
+	}
+	if !r.acquire() {
+		return nil
+	}
+	defer r.inflight.Done()
+	start := time.Now()
+	values, err := bp.GetBatch(ctx, keys)
+	d := time.Since(start)
+	if err != nil {
+		r.log(ctx, slog.LevelWarn, "batch fetch failed; fetching secrets individually",
+			slog.String("provider", providerName),
+			slog.Int("keys", len(keys)),
+			slog.Duration("duration", d),
+			r.errorAttr(err))
+		return nil
+	}
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			continue
+		}
+		r.logFetch(ctx, providerName, key, "", d, nil)
+		if r.cfg.metrics != nil {
+			r.cfg.metrics.Fetch(providerName, key, "", d, nil)
+		}
+	}
+	return values
+}
+
+// fetchTimeout returns err wrapped in an *ErrFetchTimeout if the fetch failed
+// because ctx was done, and err otherwise.
+func fetchTimeout(ctx context.Context, err error, field, providerName, key string, elapsed time.Duration) error {
+	var cause error
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return err
+	case errors.Is(err, context.DeadlineExceeded):
+		cause = context.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		cause = context.Canceled
+	case ctx.Err() != nil:
+		// Some SDKs report an expired context in their own terms, such as a
+		// gRPC DeadlineExceeded status.
+		cause = ctx.Err()
+	default:
+		return err
+	}
+	return &ErrFetchTimeout{Field: field, Provider: providerName, Key: key, Elapsed: elapsed, Cause: cause, Err: err}
+}
 
+// resolveFields fetches and assigns the given fields. Secrets are fetched
+// concurrently and deduplicated by URI and version. It returns the errors
+// for fields that could not be resolved.
+func (r *Resolver) resolveFields(ctx context.Context, fields []fieldInfo) []error {
 	// Phase 2: Determine unique fetch keys and fetch them concurrently.
 	type fetchResult struct {
-		data []byte
-		err  error
+		doc *jsonDoc
+		err error
 	}
 
 	// Build the set of unique fetch keys.
@@ -240,11 +469,20 @@ func (r *Resolver) Resolve(ctx context.Context, dst any) error {
 
 	seen := make(map[string]bool) // fetchKey.String() -> true
 	var specs []fetchSpec
+	bootstrap := make(map[fetchKey]*fieldInfo) // current-version fetches to bootstrap if missing
 
 	for i := range fields {
 		fi := &fields[i]
 		uri := fi.tag.URI()
 
+		if fi.isLazy {
+			continue // fetched on first use
+		}
+		if r.cfg.bootstrap && fi.tag.Bootstrap != "" {
+			if fk := (fetchKey{uri: uri}); bootstrap[fk] == nil {
+				bootstrap[fk] = fi
+			}
+		}
 		if fi.isVersioned {
 			// Versioned fields need two fetches: current and previous.
 			currentKey := fetchKey{uri: uri, version: ""}
@@ -267,39 +505,70 @@ func (r *Resolver) Resolve(ctx context.Context, dst any) error {
 		}
 	}
 
+	// Current values from a BatchProvider that serves two or more of them are
+	// fetched together; the rest are fetched individually.
+	batches := make(map[string][]fetchSpec) // provider name -> specs
+	var single []fetchSpec
+	for _, spec := range specs {
+		if _, ok := spec.fi.provider.(BatchProvider); ok && spec.version == "" {
+			batches[spec.fi.providerName] = append(batches[spec.fi.providerName], spec)
+		} else {
+			single = append(single, spec)
+		}
+	}
+	for name, batch := range batches {
+		if len(batch) < 2 {
+			single = append(single, batch...)
+			delete(batches, name)
+		}
+	}
+
 	// Fetch all unique keys concurrently with semaphore.
 	results := make(map[string]*fetchResult) // fetchKey.String() -> result
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, r.cfg.parallelism)
 
-	for _, spec := range specs {
+	fetchOne := func(spec fetchSpec) {
+		defer wg.Done()
+		sem <- struct{}{}        // acquire
+		defer func() { <-sem }() // release
+
+		data, fetchErr := r.fetch(ctx, spec.fi.provider, spec.fi.providerName, spec.fi.fieldName, spec.fi.tag.Key, spec.version)
+		if bfi := bootstrap[spec.key]; bfi != nil && errors.Is(fetchErr, ErrNotFound) {
+			data, fetchErr = r.bootstrapValue(ctx, bfi.provider, bfi.providerName, bfi.tag)
+		}
+
+		mu.Lock()
+		results[spec.key.String()] = &fetchResult{doc: newJSONDoc(data), err: fetchErr}
+		mu.Unlock()
+	}
+	for _, spec := range single {
+		wg.Add(1)
+		go fetchOne(spec)
+	}
+	for _, batch := range batches {
 		wg.Add(1)
-		go func(spec fetchSpec) {
+		go func() {
 			defer wg.Done()
-			sem <- struct{}{}        // acquire
-			defer func() { <-sem }() // release
-
-			var data []byte
-			var fetchErr error
-			if spec.version != "" {
-				vp, ok := spec.fi.provider.(VersionedProvider)
-				if !ok {
-					fetchErr = &ErrVersioningNotSupported{
-						Field:    spec.fi.fieldName,
-						Provider: spec.fi.providerName,
-					}
-				} else {
-					data, fetchErr = vp.GetVersion(ctx, spec.fi.tag.Key, spec.version)
+			keys := make([]string, len(batch))
+			for i, spec := range batch {
+				keys[i] = spec.fi.tag.Key
+			}
+			sem <- struct{}{}
+			values := r.fetchBatch(ctx, batch[0].fi.provider.(BatchProvider), batch[0].fi.providerName, keys)
+			<-sem
+			for _, spec := range batch {
+				if data, ok := values[spec.fi.tag.Key]; ok {
+					mu.Lock()
+					results[spec.key.String()] = &fetchResult{doc: newJSONDoc(data)}
+					mu.Unlock()
+					continue
 				}
-			} else {
-				data, fetchErr = spec.fi.provider.Get(ctx, spec.fi.tag.Key)
+				wg.Add(1)
+				go fetchOne(spec)
 			}
-
-			mu.Lock()
-			results[spec.key.String()] = &fetchResult{data: data, err: fetchErr}
-			mu.Unlock()
-		}(spec)
+		}()
 	}
 	wg.Wait()
 
@@ -309,6 +578,10 @@ func (r *Resolver) Resolve(ctx context.Context, dst any) error {
 		fi := &fields[i]
 		uri := fi.tag.URI()
 
+		if fi.isLazy {
+			fi.fieldValue.Addr().Interface().(lazyField).bind(r, fi.fieldName, fi.tag)
+			continue
+		}
 		if fi.isVersioned {
 			// Handle Versioned[T] field: set both Current and Previous.
 			currentFK := fetchKey{uri: uri, version: ""}
@@ -322,24 +595,21 @@ func (r *Resolver) Resolve(ctx context.Context, dst any) error {
 				if fi.tag.Optional && errors.Is(currentResult.err, ErrNotFound) {
 					continue
 				}
-				assignErrs = append(assignErrs, fmt.Errorf("secrets: field %s: %w", fi.fieldName, currentResult.err))
+				assignErrs = append(assignErrs, fi.resolveError("", currentResult.err))
 				continue
 			}
 
-			// Extract fragment from current value.
-			currentVal := currentResult.data
-			if fi.tag.Fragment != "" {
-				extracted, fragErr := extractFragment(currentResult.data, fi.tag.Fragment)
-				if fragErr != nil {
-					assignErrs = append(assignErrs, fmt.Errorf("secrets: field %s: %w", fi.fieldName, fragErr))
-					continue
-				}
-				currentVal = extracted
+			// Extract the fragment and decrypt the current value.
+			currentVal, err := r.extractFrom(fi.tag, currentResult.doc)
+			if err != nil {
+				assignErrs = append(assignErrs, fmt.Errorf("secrets: field %s: %w", fi.fieldName, err))
+				continue
 			}
 
 			// Set Current field.
+			fi.raw = currentVal
 			currentField := fi.fieldValue.Field(0) // Current
-			if err := setField(currentField, fi.fieldName+".Current", currentVal); err != nil {
+			if err := r.setField(currentField, fi.fieldName+".Current", currentVal); err != nil {
 				assignErrs = append(assignErrs, err)
 				continue
 			}
@@ -347,25 +617,21 @@ func (r *Resolver) Resolve(ctx context.Context, dst any) error {
 			// Previous value: if not found, leave as zero value.
 			if previousResult.err != nil {
 				if !errors.Is(previousResult.err, ErrNotFound) {
-					assignErrs = append(assignErrs, fmt.Errorf("secrets: field %s: %w", fi.fieldName, previousResult.err))
+					assignErrs = append(assignErrs, fi.resolveError("previous", previousResult.err))
 				}
 				// Leave Previous as zero value.
 				continue
 			}
 
-			previousVal := previousResult.data
-			if fi.tag.Fragment != "" {
-				extracted, fragErr := extractFragment(previousResult.data, fi.tag.Fragment)
-				if fragErr != nil {
-					assignErrs = append(assignErrs, fmt.Errorf("secrets: field %s: %w", fi.fieldName, fragErr))
-					continue
-				}
-				previousVal = extracted
+			previousVal, err := r.extractFrom(fi.tag, previousResult.doc)
+			if err != nil {
+				assignErrs = append(assignErrs, fmt.Errorf("secrets: field %s: %w", fi.fieldName, err))
+				continue
 			}
 
 			// Set Previous field.
 			previousField := fi.fieldValue.Field(1) // Previous
-			if err := setField(previousField, fi.fieldName+".Previous", previousVal); err != nil {
+			if err := r.setField(previousField, fi.fieldName+".Previous", previousVal); err != nil {
 				assignErrs = append(assignErrs, err)
 			}
 		} else {
@@ -377,28 +643,85 @@ func (r *Resolver) Resolve(ctx context.Context, dst any) error {
 				if fi.tag.Optional && errors.Is(result.err, ErrNotFound) {
 					continue
 				}
-				assignErrs = append(assignErrs, fmt.Errorf("secrets: field %s: %w", fi.fieldName, result.err))
+				assignErrs = append(assignErrs, fi.resolveError(fi.tag.Version, result.err))
 				continue
 			}
 
-			value := result.data
-			if fi.tag.Fragment != "" {
-				extracted, fragErr := extractFragment(result.data, fi.tag.Fragment)
-				if fragErr != nil {
-					assignErrs = append(assignErrs, fmt.Errorf("secrets: field %s: %w", fi.fieldName, fragErr))
-					continue
-				}
-				value = extracted
+			value, err := r.extractFrom(fi.tag, result.doc)
+			if err != nil {
+				assignErrs = append(assignErrs, fmt.Errorf("secrets: field %s: %w", fi.fieldName, err))
+				continue
 			}
 
-			if err := setField(fi.fieldValue, fi.fieldName, value); err != nil {
+			fi.raw = value
+			if err := r.setField(fi.fieldValue, fi.fieldName, value); err != nil {
 				assignErrs = append(assignErrs, err)
 			}
 		}
 	}
 
-	allErrs := append(collectErrs, assignErrs...)
-	return errors.Join(allErrs...)
+	return assignErrs
+}
+
+// extractValue derives the value for tag from the raw bytes fetched from its
+// provider: it extracts the #fragment, if any, decodes the result if the tag
+// has an encoding= option, decrypts it if the tag has a decrypt= option, then
+// applies the tag's transform= pipeline.
+func (r *Resolver) extractValue(tag parsedTag, data []byte) ([]byte, error) {
+	return r.extractFrom(tag, newJSONDoc(data))
+}
+
+// decode decodes a value tagged with `encoding=`. Surrounding whitespace is
+// ignored.
+func decode(encoding string, data []byte) ([]byte, error) {
+	if encoding != "base64" {
+		return nil, fmt.Errorf("decode: unsupported encoding %q", encoding)
+	}
+	out, err := decodeBase64(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode base64: %w", err)
+	}
+	return out, nil
+}
+
+// decodeBase64 decodes standard base64, ignoring surrounding whitespace.
+func decodeBase64(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	out := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(out, data)
+	if err != nil {
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+// extractFrom is extractValue for a fetched value that may be shared by
+// several fields, reusing its decoded JSON across fragments.
+func (r *Resolver) extractFrom(tag parsedTag, doc *jsonDoc) ([]byte, error) {
+	data := doc.data
+	var err error
+	if tag.Fragment != "" {
+		if data, err = doc.fragment(tag.Fragment); err != nil {
+			return nil, err
+		}
+	}
+	if tag.Encoding != "" {
+		if data, err = decode(tag.Encoding, data); err != nil {
+			return nil, err
+		}
+	}
+	if tag.Decrypt != "" {
+		if data, err = r.decrypt(tag.Decrypt, data); err != nil {
+			return nil, err
+		}
+	}
+	if tag.Transform != "" {
+		if data, err = r.transform(tag.transforms(), data); err != nil {
+			return nil, err
+		}
+	}
+	r.redactor.addResolved(data)
+	return data, nil
 }
 
 // collectFields walks a struct value recursively and collects all tagged fields.
@@ -431,30 +754,10 @@ func (r *Resolver) collectFields(sv reflect.Value, fields *[]fieldInfo, errs *[]
 		}
 
 		// Determine the provider.
-		var provider Provider
-		var providerName string
-		if tag.Scheme != "" {
-			p, found := r.cfg.providers[tag.Scheme]
-			if !found {
-				*errs = append(*errs, &ErrUnknownProvider{
-					Field:  field.Name,
-					Scheme: tag.Scheme,
-					URI:    tag.URI(),
-				})
-				continue
-			}
-			provider = p
-			providerName = tag.Scheme
-		} else {
-			if r.cfg.defaultProvider == nil {
-				*errs = append(*errs, &ErrNoDefaultProvider{
-					Field: field.Name,
-					Key:   tag.Key,
-				})
-				continue
-			}
-			provider = r.cfg.defaultProvider
-			providerName = "default"
+		provider, providerName, err := r.providerFor(field.Name, tag)
+		if err != nil {
+			*errs = append(*errs, err)
+			continue
 		}
 
 		// Check if this is a Versioned[T] field.
@@ -477,6 +780,8 @@ func (r *Resolver) collectFields(sv reflect.Value, fields *[]fieldInfo, errs *[]
 			provider:     provider,
 			providerName: providerName,
 			isVersioned:  versioned,
+			isLazy:       isLazyType(field.Type),
+			built:        r.builds(field.Type, versioned),
 		})
 	}
 
@@ -521,16 +826,22 @@ func (r *Resolver) collectFields(sv reflect.Value, fields *[]fieldInfo, errs *[]
 
 // hasSecretTags returns true if the given struct type (or any nested struct) has secret-tagged fields.
 func hasSecretTags(t reflect.Type) bool {
+	return hasTags(t, "secret")
+}
+
+// hasTags returns true if the given struct type (or any nested struct) has
+// fields with a key tag.
+func hasTags(t reflect.Type, key string) bool {
 	for i := range t.NumField() {
 		f := t.Field(i)
-		if _, ok := f.Tag.Lookup("secret"); ok {
+		if _, ok := f.Tag.Lookup(key); ok {
 			return true
 		}
 		ft := f.Type
 		if ft.Kind() == reflect.Pointer {
 			ft = ft.Elem()
 		}
-		if ft.Kind() == reflect.Struct && hasSecretTags(ft) {
+		if ft.Kind() == reflect.Struct && hasTags(ft, key) {
 			return true
 		}
 	}
@@ -552,6 +863,12 @@ func setField(fv reflect.Value, fieldName string, raw []byte) error {
 		return nil
 	}
 
+	if fv.CanAddr() {
+		if rf, ok := fv.Addr().Interface().(redactedField); ok {
+			return setRedacted(rf, fieldName, ft.String(), raw)
+		}
+	}
+
 	// Check for encoding.TextUnmarshaler first.
 	if fv.CanAddr() {
 		if tu, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
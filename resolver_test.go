@@ -1,10 +1,15 @@
 package secrets
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -1198,6 +1203,40 @@ func TestResolve_VersionedWithFragment(t *testing.T) {
 	}
 }
 
+func TestResolve_Base64Encoding(t *testing.T) {
+	binary := []byte{0x30, 0x82, 0xff, 0xfe, 0x00, 0x01}
+	encoded := base64.StdEncoding.EncodeToString(binary)
+	p := &mockProvider{data: map[string][]byte{
+		"keystore": []byte(`{"keystore":"` + encoded + `"}`),
+		"raw":      []byte(encoded + "\n"),
+		"bad":      []byte("not base64!"),
+	}}
+	r := NewResolver(WithDefault(p))
+
+	type Config struct {
+		Keystore []byte  `secret:"keystore#keystore,encoding=base64"`
+		Raw      []byte  `secret:"raw,encoding=base64"`
+		Ptr      *[]byte `secret:"raw,encoding=base64"`
+	}
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(cfg.Keystore, binary) {
+		t.Errorf("Keystore = %x, want %x", cfg.Keystore, binary)
+	}
+	if !bytes.Equal(cfg.Raw, binary) || !bytes.Equal(*cfg.Ptr, binary) {
+		t.Errorf("Raw = %x, Ptr = %x, want %x", cfg.Raw, *cfg.Ptr, binary)
+	}
+
+	var bad struct {
+		Val []byte `secret:"bad,encoding=base64"`
+	}
+	if err := r.Resolve(context.Background(), &bad); err == nil || !strings.Contains(err.Error(), "decode base64") {
+		t.Errorf("expected base64 decode error, got %v", err)
+	}
+}
+
 func TestResolve_Close(t *testing.T) {
 	cp := &closableProvider{closed: false}
 	r := NewResolver(WithDefault(cp))
@@ -1211,6 +1250,98 @@ func TestResolve_Close(t *testing.T) {
 
 // --- helpers ---
 
+func TestReplaceProvider(t *testing.T) {
+	oldP := &mockProvider{data: map[string][]byte{"key": []byte("old")}}
+	newP := &mockProvider{data: map[string][]byte{"key": []byte("new")}}
+	r := NewResolver(WithDefault(oldP), WithProvider("s", oldP))
+
+	type Config struct {
+		Bare   string `secret:"key"`
+		Scheme string `secret:"s://key"`
+	}
+
+	if got := r.ReplaceProvider("", newP); got != oldP {
+		t.Errorf("ReplaceProvider(\"\") returned %v, want previous default", got)
+	}
+	if got := r.ReplaceProvider("s", newP); got != oldP {
+		t.Errorf("ReplaceProvider(\"s\") returned %v, want previous provider", got)
+	}
+	if got := r.ReplaceProvider("other", newP); got != nil {
+		t.Errorf("ReplaceProvider(\"other\") returned %v, want nil", got)
+	}
+
+	var cfg Config
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Bare != "new" || cfg.Scheme != "new" {
+		t.Errorf("cfg = %+v, want both fields from the new provider", cfg)
+	}
+
+	r.ReplaceProvider("s", nil)
+	var unknown *ErrUnknownProvider
+	if err := r.Validate(&cfg); !errors.As(err, &unknown) {
+		t.Errorf("expected ErrUnknownProvider after unregistering, got %v", err)
+	}
+}
+
+func TestHasProvider(t *testing.T) {
+	p := &mockProvider{data: map[string][]byte{}}
+	r := NewResolver(WithProvider("s", p))
+	if !r.HasProvider("s") || r.HasProvider("missing") {
+		t.Error("HasProvider does not match the registered providers")
+	}
+	if r.HasProvider("") {
+		t.Error("HasProvider(\"\") = true without a default provider")
+	}
+
+	r.ReplaceProvider("", p)
+	if !r.HasProvider("") {
+		t.Error("HasProvider(\"\") = false with a default provider")
+	}
+	r.ReplaceProvider("s", nil)
+	if r.HasProvider("s") {
+		t.Error("HasProvider(\"s\") = true after unregistering")
+	}
+}
+
+func TestReplaceProvider_ConcurrentWithResolve(t *testing.T) {
+	a := &mockProvider{data: map[string][]byte{"key": []byte("a")}}
+	b := &mockProvider{data: map[string][]byte{"key": []byte("b")}}
+	r := NewResolver(WithDefault(a))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				var cfg Config
+				if err := r.Resolve(context.Background(), &cfg); err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if cfg.Val != "a" && cfg.Val != "b" {
+					t.Errorf("unexpected value %q", cfg.Val)
+					return
+				}
+			}
+		}()
+	}
+	for i := range 100 {
+		if i%2 == 0 {
+			r.ReplaceProvider("", b)
+		} else {
+			r.ReplaceProvider("", a)
+		}
+	}
+	wg.Wait()
+}
+
 func containsSubstring(s, sub string) bool {
 	return len(s) >= len(sub) && (s == sub || len(s) > 0 && containsStr(s, sub))
 }
@@ -1305,3 +1436,247 @@ func (p *mockVersionedProvider) GetVersion(_ context.Context, key, version strin
 	}
 	return v, nil
 }
+
+// deniedProvider fails every fetch as a cloud service refusing access would.
+type deniedProvider struct{}
+
+func (deniedProvider) Get(_ context.Context, key string) ([]byte, error) {
+	return nil, fmt.Errorf("store: secret %q: %w", key, &ErrProvider{
+		StatusCode: 403,
+		Code:       "AccessDenied",
+		RequestID:  "req-123",
+		Err:        errors.New("access denied"),
+	})
+}
+
+func TestResolve_ErrResolve(t *testing.T) {
+	r := NewResolver(WithProvider("store", deniedProvider{}))
+	var cfg struct {
+		DB string `secret:"store://db#password"`
+	}
+	err := r.Resolve(context.Background(), &cfg)
+
+	var re *ErrResolve
+	if !errors.As(err, &re) {
+		t.Fatalf("expected ErrResolve, got %v", err)
+	}
+	if re.Field != "DB" || re.URI != "store://db" || re.Provider != "store" || re.Version != "" {
+		t.Errorf("ErrResolve = {%q %q %q %q}", re.Field, re.URI, re.Provider, re.Version)
+	}
+	if want := `secrets: field DB: store: secret "db": access denied`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err, want)
+	}
+	var pe *ErrProvider
+	if !errors.As(re, &pe) || pe.StatusCode != 403 || pe.Code != "AccessDenied" || pe.RequestID != "req-123" {
+		t.Errorf("ErrProvider = %+v", pe)
+	}
+
+	re = &ErrResolve{Field: "Key", Err: ErrNotFound}
+	if !errors.Is(re, ErrNotFound) || errors.As(re, &pe) {
+		t.Errorf("ErrResolve without provider details: Is(ErrNotFound) = %v, As(ErrProvider) = %v", errors.Is(re, ErrNotFound), errors.As(re, &pe))
+	}
+}
+
+// slowProvider blocks until its context is done. If err is set, it returns
+// err instead of the context's error, as SDKs with their own error types do.
+type slowProvider struct {
+	err error
+}
+
+func (p slowProvider) Get(ctx context.Context, key string) ([]byte, error) {
+	<-ctx.Done()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return nil, fmt.Errorf("slow: secret %q: %w", key, ctx.Err())
+}
+
+func TestResolve_FetchTimeout(t *testing.T) {
+	for _, p := range []slowProvider{{}, {err: errors.New("rpc error: code = DeadlineExceeded")}} {
+		r := NewResolver(WithProvider("slow", p))
+		var cfg struct {
+			DB string `secret:"slow://prod/db#password"`
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		err := r.Resolve(ctx, &cfg)
+		cancel()
+
+		var timeout *ErrFetchTimeout
+		if !errors.As(err, &timeout) {
+			t.Fatalf("expected ErrFetchTimeout, got %v", err)
+		}
+		if timeout.Field != "DB" || timeout.Provider != "slow" || timeout.Key != "prod/db" || timeout.Elapsed < 20*time.Millisecond {
+			t.Errorf("ErrFetchTimeout = %+v", timeout)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrNotFound) {
+			t.Errorf("errors.Is(DeadlineExceeded) = %v, errors.Is(ErrNotFound) = %v", errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrNotFound))
+		}
+		if !strings.Contains(timeout.Error(), `secrets: field DB: provider "slow": fetch of "prod/db" timed out after`) {
+			t.Errorf("Error() = %q", timeout)
+		}
+	}
+}
+
+func TestResolve_FetchCanceled(t *testing.T) {
+	r := NewResolver(WithDefault(slowProvider{}))
+	var cfg struct {
+		Key string `secret:"key"`
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := r.Resolve(ctx, &cfg)
+	var timeout *ErrFetchTimeout
+	if !errors.As(err, &timeout) || !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "canceled after") {
+		t.Errorf("expected a canceled ErrFetchTimeout, got %v", err)
+	}
+}
+
+func TestResolve_NotFoundIsNotTimeout(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{data: map[string][]byte{}}))
+	var cfg struct {
+		Key string `secret:"key"`
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var timeout *ErrFetchTimeout
+	if err := r.Resolve(ctx, &cfg); !errors.Is(err, ErrNotFound) || errors.As(err, &timeout) {
+		t.Errorf("expected ErrNotFound only, got %v", err)
+	}
+}
+
+// batchProvider is a BatchProvider that records its calls. GetBatch omits
+// keys in skip, and fails if err is set.
+type batchProvider struct {
+	mockProvider
+	skip    map[string]bool
+	err     error
+	mu      sync.Mutex
+	batches [][]string
+	gets    []string
+}
+
+func (p *batchProvider) Get(ctx context.Context, key string) ([]byte, error) {
+	p.mu.Lock()
+	p.gets = append(p.gets, key)
+	p.mu.Unlock()
+	return p.mockProvider.Get(ctx, key)
+}
+
+func (p *batchProvider) GetVersion(_ context.Context, key, version string) ([]byte, error) {
+	p.mu.Lock()
+	p.gets = append(p.gets, key+"@"+version)
+	p.mu.Unlock()
+	return []byte("old-" + key), nil
+}
+
+func (p *batchProvider) GetBatch(_ context.Context, keys []string) (map[string][]byte, error) {
+	p.mu.Lock()
+	p.batches = append(p.batches, slices.Sorted(slices.Values(keys)))
+	p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	out := make(map[string][]byte)
+	for _, key := range keys {
+		if v, ok := p.data[key]; ok && !p.skip[key] {
+			out[key] = v
+		}
+	}
+	return out, nil
+}
+
+func newBatchProvider() *batchProvider {
+	return &batchProvider{mockProvider: mockProvider{data: map[string][]byte{
+		"db":    []byte(`{"user":"admin","password":"s3cret"}`),
+		"api":   []byte("key"),
+		"token": []byte("tok"),
+	}}}
+}
+
+type batchConfig struct {
+	User     string `secret:"db#user"`
+	Password string `secret:"db#password"`
+	API      string `secret:"api"`
+	Token    string `secret:"token"`
+	Prev     string `secret:"api,version=previous"`
+}
+
+func TestResolve_Batch(t *testing.T) {
+	p := newBatchProvider()
+	r := NewResolver(WithDefault(p))
+	var cfg batchConfig
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.User != "admin" || cfg.Password != "s3cret" || cfg.API != "key" || cfg.Token != "tok" || cfg.Prev != "old-api" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if want := [][]string{{"api", "db", "token"}}; !reflect.DeepEqual(p.batches, want) {
+		t.Errorf("batches = %q, want %q", p.batches, want)
+	}
+	if want := []string{"api@previous"}; !reflect.DeepEqual(p.gets, want) {
+		t.Errorf("individual gets = %q, want only the previous version", p.gets)
+	}
+}
+
+func TestResolve_BatchFallback(t *testing.T) {
+	t.Run("missing keys", func(t *testing.T) {
+		p := newBatchProvider()
+		p.skip = map[string]bool{"token": true}
+		r := NewResolver(WithDefault(p))
+		var cfg batchConfig
+		if err := r.Resolve(context.Background(), &cfg); err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+		if cfg.Token != "tok" {
+			t.Errorf("Token = %q, want tok", cfg.Token)
+		}
+		if got, want := slices.Sorted(slices.Values(p.gets)), []string{"api@previous", "token"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("individual gets = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("batch error", func(t *testing.T) {
+		p := newBatchProvider()
+		p.err = errors.New("throttled")
+		r := NewResolver(WithDefault(p))
+		var cfg batchConfig
+		if err := r.Resolve(context.Background(), &cfg); err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+		if cfg.User != "admin" || cfg.API != "key" {
+			t.Errorf("cfg = %+v", cfg)
+		}
+		if got := slices.Sorted(slices.Values(p.gets)); !reflect.DeepEqual(got, []string{"api", "api@previous", "db", "token"}) {
+			t.Errorf("individual gets = %q, want every key", got)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		p := newBatchProvider()
+		r := NewResolver(WithDefault(p))
+		var cfg struct {
+			API     string `secret:"api"`
+			Missing string `secret:"missing"`
+		}
+		if err := r.Resolve(context.Background(), &cfg); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestResolve_BatchSingleKey(t *testing.T) {
+	p := newBatchProvider()
+	r := NewResolver(WithDefault(p))
+	var cfg struct {
+		User     string `secret:"db#user"`
+		Password string `secret:"db#password"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if len(p.batches) != 0 || len(p.gets) != 1 {
+		t.Errorf("batches = %q, gets = %q; want one Get", p.batches, p.gets)
+	}
+}
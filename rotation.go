@@ -0,0 +1,164 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// RotationPolicy configures Resolver.CheckRotation. The maximum age for a
+// field is taken from ClassMaxAge if the field has a `class=` tag option with
+// an entry there, otherwise from ProviderMaxAge, otherwise MaxAge. A zero
+// maximum age disables the check for that field.
+type RotationPolicy struct {
+	// MaxAge is the default maximum time since a secret was last rotated.
+	MaxAge time.Duration
+	// ProviderMaxAge overrides MaxAge per provider scheme ("default" for
+	// bare keys).
+	ProviderMaxAge map[string]time.Duration
+	// ClassMaxAge overrides MaxAge and ProviderMaxAge for fields tagged with
+	// `class=<name>`.
+	ClassMaxAge map[string]time.Duration
+	// Logger, if non-nil, receives a warning for each stale secret.
+	Logger *slog.Logger
+	// OnStale, if non-nil, is called for each stale secret, for example to
+	// update a metric or page the owning team.
+	OnStale func(StaleSecret)
+}
+
+// maxAge returns the maximum age that applies to fi.
+func (p *RotationPolicy) maxAge(fi *fieldInfo) time.Duration {
+	if d, ok := p.ClassMaxAge[fi.tag.Class]; ok && fi.tag.Class != "" {
+		return d
+	}
+	if d, ok := p.ProviderMaxAge[fi.providerName]; ok {
+		return d
+	}
+	return p.MaxAge
+}
+
+// StaleSecret describes a secret that has not been rotated within its
+// maximum age.
+type StaleSecret struct {
+	Field     string        // struct field name
+	Key       string        // secret key
+	Provider  string        // provider scheme, or "default"
+	Class     string        // rotation class from the `class=` tag option
+	RotatedAt time.Time     // last rotation reported by the provider
+	Age       time.Duration // time since RotatedAt
+	MaxAge    time.Duration // maximum age that was exceeded
+}
+
+// CheckRotation reports the secrets referenced by dst that have not been
+// rotated within the maximum age configured by policy, without retrieving any
+// secret values. Each stale secret is also logged to policy.Logger and passed
+// to policy.OnStale.
+//
+// Rotation times come from providers that implement MetadataProvider; when a
+// provider does not report a rotation time, the creation time is used.
+// Secrets served by other providers, by providers whose GetMetadata returns
+// errors.ErrUnsupported (wrapped), or whose provider reports neither time,
+// are skipped. A missing secret is skipped if its field is optional.
+// Metadata errors are collected and returned via errors.Join alongside the
+// stale secrets found.
+func (r *Resolver) CheckRotation(ctx context.Context, dst any, policy RotationPolicy) ([]StaleSecret, error) {
+	if err := r.Validate(dst); err != nil {
+		return nil, err
+	}
+
+	var fields []fieldInfo
+	var collectErrs []error
+	r.collectFields(reflect.ValueOf(dst).Elem(), &fields, &collectErrs)
+	if len(collectErrs) > 0 {
+		return nil, errors.Join(collectErrs...)
+	}
+
+	type metadataResult struct {
+		md  Metadata
+		err error
+	}
+	results := make(map[string]*metadataResult) // URI -> result
+	var checked, fetch []*fieldInfo
+	for i := range fields {
+		fi := &fields[i]
+		if _, ok := fi.provider.(MetadataProvider); !ok || policy.maxAge(fi) <= 0 {
+			continue
+		}
+		checked = append(checked, fi)
+		if _, ok := results[fi.tag.URI()]; !ok {
+			results[fi.tag.URI()] = &metadataResult{}
+			fetch = append(fetch, fi)
+		}
+	}
+
+	// Each goroutine writes only its own result, so no lock is needed.
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, r.cfg.parallelism)
+	for _, fi := range fetch {
+		res := results[fi.tag.URI()]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}        // acquire
+			defer func() { <-sem }() // release
+
+			res.md, res.err = fi.provider.(MetadataProvider).GetMetadata(ctx, fi.tag.Key)
+		}()
+	}
+	wg.Wait()
+
+	now := time.Now()
+	var stale []StaleSecret
+	var errs []error
+	for _, fi := range checked {
+		res := results[fi.tag.URI()]
+		if res.err != nil {
+			if errors.Is(res.err, errors.ErrUnsupported) || fi.tag.Optional && errors.Is(res.err, ErrNotFound) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("secrets: field %s: %w", fi.fieldName, res.err))
+			continue
+		}
+		rotated := res.md.RotatedAt
+		if rotated.IsZero() {
+			rotated = res.md.CreatedAt
+		}
+		if rotated.IsZero() {
+			continue
+		}
+		maxAge := policy.maxAge(fi)
+		if age := now.Sub(rotated); age > maxAge {
+			stale = append(stale, StaleSecret{
+				Field:     fi.fieldName,
+				Key:       fi.tag.Key,
+				Provider:  fi.providerName,
+				Class:     fi.tag.Class,
+				RotatedAt: rotated,
+				Age:       age,
+				MaxAge:    maxAge,
+			})
+		}
+	}
+
+	for _, s := range stale {
+		if policy.Logger != nil {
+			policy.Logger.LogAttrs(ctx, slog.LevelWarn, "secret overdue for rotation",
+				slog.String("field", s.Field),
+				slog.String("key", s.Key),
+				slog.String("provider", s.Provider),
+				slog.String("class", s.Class),
+				slog.Time("rotated_at", s.RotatedAt),
+				slog.Duration("age", s.Age),
+				slog.Duration("max_age", s.MaxAge),
+			)
+		}
+		if policy.OnStale != nil {
+			policy.OnStale(s)
+		}
+	}
+	return stale, errors.Join(errs...)
+}
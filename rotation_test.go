@@ -0,0 +1,159 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// metadataProvider is a map-based MetadataProvider that counts calls.
+type metadataProvider struct {
+	metadata map[string]Metadata
+	calls    atomic.Int64
+}
+
+func (p *metadataProvider) Get(_ context.Context, key string) ([]byte, error) {
+	return nil, fmt.Errorf("metadata: %q: values must not be fetched", key)
+}
+
+func (p *metadataProvider) GetMetadata(_ context.Context, key string) (Metadata, error) {
+	p.calls.Add(1)
+	md, ok := p.metadata[key]
+	if !ok {
+		return Metadata{}, fmt.Errorf("metadata: %q: %w", key, ErrNotFound)
+	}
+	return md, nil
+}
+
+func TestCheckRotation(t *testing.T) {
+	now := time.Now()
+	p := &metadataProvider{metadata: map[string]Metadata{
+		"fresh":   {RotatedAt: now.Add(-24 * time.Hour)},
+		"old":     {RotatedAt: now.Add(-100 * 24 * time.Hour)},
+		"created": {CreatedAt: now.Add(-100 * 24 * time.Hour)},
+		"unknown": {},
+	}}
+	r := NewResolver(WithDefault(p))
+
+	type Config struct {
+		Fresh   string `secret:"fresh"`
+		Old     string `secret:"old"`
+		OldUser string `secret:"old#user"`
+		Created string `secret:"created"`
+		Unknown string `secret:"unknown"`
+	}
+	var seen []string
+	stale, err := r.CheckRotation(context.Background(), &Config{}, RotationPolicy{
+		MaxAge:  90 * 24 * time.Hour,
+		OnStale: func(s StaleSecret) { seen = append(seen, s.Field) },
+	})
+	if err != nil {
+		t.Fatalf("CheckRotation: %v", err)
+	}
+
+	var fields []string
+	for _, s := range stale {
+		fields = append(fields, s.Field)
+		if s.MaxAge != 90*24*time.Hour {
+			t.Errorf("%s: MaxAge = %v, want 90 days", s.Field, s.MaxAge)
+		}
+		if s.Age < 99*24*time.Hour {
+			t.Errorf("%s: Age = %v, want about 100 days", s.Field, s.Age)
+		}
+	}
+	want := "Old,OldUser,Created"
+	if got := strings.Join(fields, ","); got != want {
+		t.Errorf("stale fields = %q, want %q", got, want)
+	}
+	if got := strings.Join(seen, ","); got != want {
+		t.Errorf("OnStale fields = %q, want %q", got, want)
+	}
+	if got := p.calls.Load(); got != 4 {
+		t.Errorf("GetMetadata calls = %d, want 4", got)
+	}
+}
+
+func TestCheckRotation_PolicyPrecedence(t *testing.T) {
+	rotated := time.Now().Add(-10 * 24 * time.Hour)
+	p := &metadataProvider{metadata: map[string]Metadata{
+		"a": {RotatedAt: rotated},
+	}}
+	other := &metadataProvider{metadata: p.metadata}
+	r := NewResolver(WithDefault(p), WithProvider("other", other))
+
+	type Config struct {
+		Default  string `secret:"a"`
+		Provider string `secret:"other://a"`
+		Class    string `secret:"a,class=tls"`
+		Disabled string `secret:"other://a,class=static"`
+	}
+	stale, err := r.CheckRotation(context.Background(), &Config{}, RotationPolicy{
+		MaxAge:         30 * 24 * time.Hour,
+		ProviderMaxAge: map[string]time.Duration{"other": 7 * 24 * time.Hour},
+		ClassMaxAge:    map[string]time.Duration{"tls": 5 * 24 * time.Hour, "static": 0},
+	})
+	if err != nil {
+		t.Fatalf("CheckRotation: %v", err)
+	}
+
+	var fields []string
+	for _, s := range stale {
+		fields = append(fields, s.Field)
+	}
+	if got, want := strings.Join(fields, ","), "Provider,Class"; got != want {
+		t.Errorf("stale fields = %q, want %q", got, want)
+	}
+}
+
+func TestCheckRotation_Logger(t *testing.T) {
+	p := &metadataProvider{metadata: map[string]Metadata{
+		"old": {RotatedAt: time.Now().Add(-48 * time.Hour)},
+	}}
+	r := NewResolver(WithDefault(p))
+
+	type Config struct {
+		Old string `secret:"old,class=db"`
+	}
+	var buf bytes.Buffer
+	_, err := r.CheckRotation(context.Background(), &Config{}, RotationPolicy{
+		MaxAge: time.Hour,
+		Logger: slog.New(slog.NewTextHandler(&buf, nil)),
+	})
+	if err != nil {
+		t.Fatalf("CheckRotation: %v", err)
+	}
+	for _, want := range []string{"level=WARN", "field=Old", "key=old", "class=db", "max_age=1h0m0s"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log %q does not contain %q", buf.String(), want)
+		}
+	}
+}
+
+func TestCheckRotation_Errors(t *testing.T) {
+	p := &metadataProvider{metadata: map[string]Metadata{}}
+	plain := &mockProvider{data: map[string][]byte{}}
+	cached := NewCachedProvider(plain, time.Minute)
+	defer cached.Close()
+	r := NewResolver(WithDefault(p), WithProvider("plain", plain), WithProvider("cached", cached))
+
+	type Config struct {
+		Optional string `secret:"gone,optional"`
+		Required string `secret:"missing"`
+		Plain    string `secret:"plain://skipped"`
+		Cached   string `secret:"cached://unsupported"`
+	}
+	_, err := r.CheckRotation(context.Background(), &Config{}, RotationPolicy{MaxAge: time.Hour})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "field Required") || strings.Contains(err.Error(), "field Optional") ||
+		strings.Contains(err.Error(), "field Cached") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
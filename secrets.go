@@ -1,9 +1,12 @@
 package secrets
 
 import (
+	"bytes"
 	"context"
 	"errors"
-	"io"
+	"log/slog"
+	"reflect"
+	"time"
 )
 
 // ErrNotFound indicates the requested secret does not exist.
@@ -27,6 +30,78 @@ type VersionedProvider interface {
 	GetVersion(ctx context.Context, key, version string) ([]byte, error)
 }
 
+// CheckerProvider is implemented by providers that can verify a secret exists
+// and is accessible without retrieving its value, typically via a metadata
+// call. Resolver.Preflight uses it.
+type CheckerProvider interface {
+	Provider
+	// Check returns nil if key exists and is accessible, ErrNotFound (wrapped)
+	// if it does not exist, and errors.ErrUnsupported (wrapped) if the
+	// provider cannot check it without fetching the value.
+	Check(ctx context.Context, key string) error
+}
+
+// MetadataProvider is implemented by providers that can describe a secret
+// without retrieving its value. Resolver.CheckRotation uses it.
+type MetadataProvider interface {
+	Provider
+	// GetMetadata returns the metadata for key. Returns ErrNotFound (wrapped)
+	// if the key does not exist.
+	GetMetadata(ctx context.Context, key string) (Metadata, error)
+}
+
+// Metadata describes a secret. Fields the backend does not report are left
+// at their zero value.
+type Metadata struct {
+	// CreatedAt is when the secret was first created.
+	CreatedAt time.Time
+	// RotatedAt is when the current value was set, i.e. the last rotation.
+	RotatedAt time.Time
+	// Version identifies the current version of the secret.
+	Version string
+	// ExpiresAt is when the secret expires or is scheduled for deletion.
+	ExpiresAt time.Time
+	// Tags holds backend labels or tags attached to the secret.
+	Tags map[string]string
+}
+
+// WatchableProvider is implemented by providers that can push change
+// notifications, such as Vault event streams or the Kubernetes watch API.
+// Resolver.Watch subscribes to these instead of polling the provider.
+type WatchableProvider interface {
+	Provider
+	// Watch returns a channel that receives the new raw value of key each time
+	// it changes. The channel should be closed when ctx is cancelled or the
+	// subscription ends.
+	Watch(ctx context.Context, key string) (<-chan []byte, error)
+}
+
+// BatchProvider is implemented by providers that can fetch several secrets in
+// one request, such as AWS Secrets Manager's BatchGetSecretValue. When a
+// Resolve needs the current values of two or more secrets from a
+// BatchProvider, the resolver fetches them with one GetBatch call instead of
+// one Get per secret.
+type BatchProvider interface {
+	Provider
+	// GetBatch returns the current values of keys by key, splitting them into
+	// as many requests as the backend requires. Keys that are missing from the
+	// result, because they do not exist or could not be fetched, are then
+	// fetched with Get, which reports their errors; so is every key if
+	// GetBatch returns an error.
+	GetBatch(ctx context.Context, keys []string) (map[string][]byte, error)
+}
+
+// ListerProvider is implemented by providers that can enumerate their keys,
+// for bulk export and audit tooling.
+type ListerProvider interface {
+	Provider
+	// List returns the keys that start with prefix, in the form Get accepts,
+	// sorted. An empty prefix lists every key the provider can see.
+	// Providers whose keys form a hierarchy may require prefix to name a
+	// level of it; see each provider's documentation.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
 // Versioned holds current and previous values for key rotation.
 // When used as a field type, the resolver fetches both versions.
 // Requires the provider to implement VersionedProvider.
@@ -35,6 +110,45 @@ type Versioned[T any] struct {
 	Previous T
 }
 
+// HasPrevious reports whether Previous holds a value, that is, whether it is
+// not the zero value of T. Previous is left zero when the provider has no
+// previous version.
+func (v Versioned[T]) HasPrevious() bool {
+	return !reflect.ValueOf(&v.Previous).Elem().IsZero()
+}
+
+// All returns the values in the order they should be tried: Current, then
+// Previous if HasPrevious reports true.
+func (v Versioned[T]) All() []T {
+	if v.HasPrevious() {
+		return []T{v.Current, v.Previous}
+	}
+	return []T{v.Current}
+}
+
+// ForEach calls fn for each value returned by All, in order, until fn returns
+// false. It reports whether fn returned false, so a token validator can stop
+// at the first key that verifies:
+//
+//	verified := cfg.SigningKey.ForEach(func(key []byte) bool {
+//	    return !verify(token, key)
+//	})
+func (v Versioned[T]) ForEach(fn func(T) bool) bool {
+	for _, val := range v.All() {
+		if !fn(val) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRotated reports whether Current differs from prev.Current, where prev is
+// an earlier copy of the same field. Values are compared with
+// reflect.DeepEqual.
+func (v Versioned[T]) IsRotated(prev Versioned[T]) bool {
+	return !reflect.DeepEqual(v.Current, prev.Current)
+}
+
 // ChangeEvent is emitted by a Watcher when a secret value changes.
 type ChangeEvent struct {
 	// Field is the struct field name (e.g. "EncKey").
@@ -43,12 +157,44 @@ type ChangeEvent struct {
 	Key string
 	// Provider is the provider scheme (e.g. "awssm").
 	Provider string
-	// OldValue is the previous raw value.
+	// Fragment is the dot-delimited path of the changed value within a JSON
+	// secret, in `#fragment` syntax, for events emitted with
+	// WatchStructuralDiff. It is empty for whole-value events.
+	Fragment string
+	// OldValue is the previous raw value. With a Fragment, it is the previous
+	// value at that path, or nil if the path was added.
 	OldValue []byte
-	// NewValue is the new raw value.
+	// NewValue is the new raw value. With a Fragment, it is the new value at
+	// that path, or nil if the path was removed.
 	NewValue []byte
 }
 
+// RotationEvent is emitted by a Watcher when the Current value of a
+// Versioned[T] field changes, carrying both generations so that handlers can
+// re-encrypt or dual-sign without decoding ChangeEvent bytes.
+type RotationEvent struct {
+	// Field is the struct field name (e.g. "EncKey").
+	Field string
+	// Key is the secret key (e.g. "prod/encryption-key").
+	Key string
+	// Provider is the provider scheme (e.g. "awssm").
+	Provider string
+	// Current is the raw value of the new current version.
+	Current []byte
+	// Previous is the raw value of the new previous version.
+	Previous []byte
+	// OldCurrent is the raw current value before the rotation.
+	OldCurrent []byte
+}
+
+// Sequential reports whether the old current version became the previous
+// version. It is false when the secret rotated more than once between polls,
+// in which case values encrypted under OldCurrent can no longer be read with
+// Previous.
+func (e RotationEvent) Sequential() bool {
+	return bytes.Equal(e.Previous, e.OldCurrent)
+}
+
 // Option configures a Resolver.
 type Option func(*resolverConfig)
 
@@ -56,12 +202,26 @@ type resolverConfig struct {
 	defaultProvider Provider
 	providers       map[string]Provider
 	parallelism     int
+	decryptionKeys  map[string][]byte // key ID -> AES key, for decrypt=aesgcm
+	logger          *slog.Logger      // nil to log nothing
+	userAgent       string            // added to fetch contexts, see WithUserAgent
+	metrics         Recorder          // nil to record nothing
+	order           []string          // provider schemes in registration order, "" for the default
+
+	// factories maps field types to the factories registered with
+	// WithFieldFactory.
+	factories map[reflect.Type]fieldFactory
+	// transforms holds the transforms registered with WithTransform.
+	transforms map[string]Transform
+	// bootstrap enables `bootstrap=` tag options, see WithBootstrap.
+	bootstrap bool
 }
 
 // WithDefault sets the provider used for bare keys (no URI scheme).
 func WithDefault(p Provider) Option {
 	return func(c *resolverConfig) {
 		c.defaultProvider = p
+		c.registered("")
 	}
 }
 
@@ -72,6 +232,7 @@ func WithProvider(scheme string, p Provider) Option {
 			c.providers = make(map[string]Provider)
 		}
 		c.providers[scheme] = p
+		c.registered(scheme)
 	}
 }
 
@@ -85,29 +246,3 @@ func WithParallelism(n int) Option {
 		c.parallelism = n
 	}
 }
-
-// closeProviders closes all providers that implement io.Closer.
-func closeProviders(cfg *resolverConfig) error {
-	var errs []error
-	seen := make(map[Provider]bool)
-	if cfg.defaultProvider != nil {
-		if c, ok := cfg.defaultProvider.(io.Closer); ok {
-			seen[cfg.defaultProvider] = true
-			if err := c.Close(); err != nil {
-				errs = append(errs, err)
-			}
-		}
-	}
-	for _, p := range cfg.providers {
-		if seen[p] {
-			continue
-		}
-		seen[p] = true
-		if c, ok := p.(io.Closer); ok {
-			if err := c.Close(); err != nil {
-				errs = append(errs, err)
-			}
-		}
-	}
-	return errors.Join(errs...)
-}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"testing"
 )
 
@@ -28,6 +29,61 @@ func TestVersioned(t *testing.T) {
 	}
 }
 
+func TestVersioned_All(t *testing.T) {
+	v := Versioned[string]{Current: "new-key", Previous: "old-key"}
+	if !v.HasPrevious() {
+		t.Error("HasPrevious = false, want true")
+	}
+	if got := v.All(); !slices.Equal(got, []string{"new-key", "old-key"}) {
+		t.Errorf("All = %q", got)
+	}
+
+	// Previous is left zero when the provider has no previous version.
+	first := Versioned[[]byte]{Current: []byte("only")}
+	if first.HasPrevious() {
+		t.Error("HasPrevious = true for zero Previous")
+	}
+	if got := first.All(); len(got) != 1 || string(got[0]) != "only" {
+		t.Errorf("All = %q, want [only]", got)
+	}
+}
+
+func TestVersioned_ForEach(t *testing.T) {
+	v := Versioned[string]{Current: "new-key", Previous: "old-key"}
+
+	var seen []string
+	stopped := v.ForEach(func(key string) bool {
+		seen = append(seen, key)
+		return key != "new-key"
+	})
+	if !stopped || !slices.Equal(seen, []string{"new-key"}) {
+		t.Errorf("ForEach stopped = %v, seen = %q; want true, [new-key]", stopped, seen)
+	}
+
+	seen = nil
+	if v.ForEach(func(key string) bool {
+		seen = append(seen, key)
+		return true
+	}) {
+		t.Error("ForEach reported a stop, but fn never returned false")
+	}
+	if !slices.Equal(seen, []string{"new-key", "old-key"}) {
+		t.Errorf("seen = %q", seen)
+	}
+}
+
+func TestVersioned_IsRotated(t *testing.T) {
+	before := Versioned[[]byte]{Current: []byte("k1")}
+	same := Versioned[[]byte]{Current: []byte("k1")}
+	rotated := Versioned[[]byte]{Current: []byte("k2"), Previous: []byte("k1")}
+	if same.IsRotated(before) {
+		t.Error("IsRotated = true for an unchanged Current")
+	}
+	if !rotated.IsRotated(before) {
+		t.Error("IsRotated = false after Current changed")
+	}
+}
+
 func TestOptions(t *testing.T) {
 	mp := &mockProvider{data: map[string][]byte{"a": []byte("1")}}
 	fp := &mockProvider{data: map[string][]byte{"b": []byte("2")}}
@@ -0,0 +1,141 @@
+package secrettest
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// Clock is a secrets.Clock whose time moves only when a test advances it. Pass
+// it to secrets.WithCacheClock or secrets.WatchClock to expire cache entries
+// and trigger Watcher polls without sleeping:
+//
+//	clk := secrettest.NewClock(time.Now())
+//	w, _ := r.Watch(ctx, &cfg, secrets.WatchInterval(time.Minute), secrets.WatchClock(clk))
+//	clk.BlockUntil(1)          // the Watcher has scheduled its first poll
+//	clk.Advance(time.Minute)   // the poll runs now
+//
+// It is safe for concurrent use.
+type Clock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond // signaled when a timer is armed
+	now    time.Time
+	timers []*fakeTimer // armed timers
+}
+
+// NewClock returns a Clock set to now.
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the Clock's current time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer returns a Timer that fires when the Clock has been advanced by d.
+// A Timer with d <= 0 fires at once.
+func (c *Clock) NewTimer(d time.Duration) secrets.Timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the Clock forward by d and fires, in deadline order, every
+// timer that is due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	slices.SortStableFunc(c.timers, func(a, b *fakeTimer) int { return a.when.Compare(b.when) })
+	for len(c.timers) > 0 && !c.timers[0].when.After(c.now) {
+		t := c.timers[0]
+		c.timers = c.timers[1:]
+		t.fire(c.now)
+	}
+}
+
+// Next returns the deadline of the earliest armed timer, if any.
+func (c *Clock) Next() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.timers) == 0 {
+		return time.Time{}, false
+	}
+	next := c.timers[0].when
+	for _, t := range c.timers[1:] {
+		if t.when.Before(next) {
+			next = t.when
+		}
+	}
+	return next, true
+}
+
+// BlockUntil waits until at least n timers are armed. Call it before Advance
+// so that the code under test has scheduled the timers Advance should fire.
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// fakeTimer is a Timer of a Clock. Like a time.Timer since Go 1.23, a value
+// that has fired but not been received is discarded by Stop and Reset.
+type fakeTimer struct {
+	clock *Clock
+	c     chan time.Time
+	when  time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.stop()
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	active := t.stop()
+	t.when = c.now.Add(d)
+	if d <= 0 {
+		t.fire(c.now)
+		return active
+	}
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	return active
+}
+
+// stop disarms t and discards an unreceived value. The caller holds
+// t.clock.mu.
+func (t *fakeTimer) stop() bool {
+	select {
+	case <-t.c:
+	default:
+	}
+	i := slices.Index(t.clock.timers, t)
+	if i < 0 {
+		return false
+	}
+	t.clock.timers = slices.Delete(t.clock.timers, i, i+1)
+	return true
+}
+
+func (t *fakeTimer) fire(now time.Time) {
+	select {
+	case t.c <- now:
+	default:
+	}
+}
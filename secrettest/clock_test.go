@@ -0,0 +1,93 @@
+package secrettest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets/secrettest"
+)
+
+var epoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fired(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case at := <-c:
+		return at, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestClock_Advance(t *testing.T) {
+	clk := secrettest.NewClock(epoch)
+	short := clk.NewTimer(time.Second)
+	long := clk.NewTimer(time.Minute)
+
+	clk.Advance(999 * time.Millisecond)
+	if _, ok := fired(short.C()); ok {
+		t.Fatal("timer fired before its deadline")
+	}
+	clk.Advance(time.Millisecond)
+	if at, ok := fired(short.C()); !ok || !at.Equal(epoch.Add(time.Second)) {
+		t.Errorf("short timer: fired %v at %v, want at %v", ok, at, epoch.Add(time.Second))
+	}
+	if _, ok := fired(long.C()); ok {
+		t.Error("long timer fired early")
+	}
+	if next, ok := clk.Next(); !ok || !next.Equal(epoch.Add(time.Minute)) {
+		t.Errorf("Next() = %v, %v; want %v", next, ok, epoch.Add(time.Minute))
+	}
+	clk.Advance(time.Hour)
+	if _, ok := fired(long.C()); !ok {
+		t.Error("long timer did not fire")
+	}
+	if _, ok := clk.Next(); ok {
+		t.Error("Next() reports a timer after all fired")
+	}
+	if got, want := clk.Now(), epoch.Add(time.Hour+time.Second); !got.Equal(want) {
+		t.Errorf("Now() = %v, want %v", got, want)
+	}
+}
+
+func TestClock_StopReset(t *testing.T) {
+	clk := secrettest.NewClock(epoch)
+	timer := clk.NewTimer(time.Second)
+	if !timer.Stop() {
+		t.Error("Stop of an armed timer returned false")
+	}
+	if timer.Stop() {
+		t.Error("second Stop returned true")
+	}
+	clk.Advance(time.Second)
+	if _, ok := fired(timer.C()); ok {
+		t.Error("stopped timer fired")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("Reset of a stopped timer returned true")
+	}
+	clk.Advance(time.Second)
+	// A fired but unreceived value is discarded by Reset.
+	timer.Reset(time.Second)
+	if _, ok := fired(timer.C()); ok {
+		t.Error("stale value received after Reset")
+	}
+
+	timer.Reset(0)
+	if _, ok := fired(timer.C()); !ok {
+		t.Error("Reset(0) did not fire at once")
+	}
+}
+
+func TestClock_BlockUntil(t *testing.T) {
+	clk := secrettest.NewClock(epoch)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		timer := clk.NewTimer(time.Minute)
+		<-timer.C()
+	}()
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+	<-done
+}
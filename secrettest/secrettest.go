@@ -0,0 +1,435 @@
+// Package secrettest provides a scripted provider and a harness for
+// regression-testing how a Watcher reacts to secrets that rotate, fail, and
+// disappear.
+//
+// The Provider is also useful on its own, in place of a hand-written mock:
+// it injects latency and errors, simulates outages and rotations, and records
+// the calls made of it.
+//
+// A Timeline lists the states a provider passes through. Run watches a
+// struct, applies each step in turn, refreshes the Watcher, and checks the
+// ChangeEvents it emits and the final state of the struct:
+//
+//	var cfg struct {
+//		APIKey string `secret:"api-key"`
+//	}
+//	secrettest.Run(t, &cfg, secrettest.Timeline{
+//		Initial: map[string]string{"api-key": "v1"},
+//		Steps: []secrettest.Step{
+//			{Name: "rotate", Put: map[string]string{"api-key": "v2"},
+//				Want: []secrettest.Change{{Field: "APIKey", Old: "v1", New: "v2"}}},
+//			{Name: "outage", Fail: map[string]error{"api-key": errors.New("503")}, WantErr: true},
+//			{Name: "recovered", Put: map[string]string{"api-key": "v2"}},
+//		},
+//	})
+//
+// Steps are driven with Watcher.Refresh rather than by poll timing, so a
+// timeline runs in microseconds and gives the same result on every run.
+package secrettest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// Provider is a secrets.Provider whose values are scripted by a test. Each
+// key holds a value, fails with an error, or is missing. Beyond that, a test
+// can slow fetches down, fail the next few of them, take the whole backend
+// down, and inspect every call made. It is safe for concurrent use.
+//
+// Provider also implements secrets.VersionedProvider. Every Put or Rotate
+// adds a version numbered from "1"; "current" and "previous" name the latest
+// two, and SetVersion adds versions under any other label, such as
+// "pending".
+type Provider struct {
+	mu       sync.Mutex
+	values   map[string][]byte
+	versions map[string]map[string][]byte // key -> version ID or label -> value
+	ids      map[string][]string          // key -> version IDs, oldest first
+	errs     map[string]error
+	failNext map[string]failure
+	outage   error
+	delays   map[string]time.Duration // "" for every key
+	clock    secrets.Clock
+	fetches  map[string]int
+	calls    []Call
+}
+
+// failure is a run of fetches FailNext makes fail.
+type failure struct {
+	n   int
+	err error
+}
+
+// Call records a fetch made of a Provider.
+type Call struct {
+	Key     string
+	Version string // empty for Get
+	Err     error  // the error returned, if any
+}
+
+// NewProvider returns a Provider holding values, each as version "1".
+func NewProvider(values map[string]string) *Provider {
+	p := &Provider{
+		values:   make(map[string][]byte, len(values)),
+		versions: make(map[string]map[string][]byte),
+		ids:      make(map[string][]string),
+		errs:     make(map[string]error),
+		failNext: make(map[string]failure),
+		delays:   make(map[string]time.Duration),
+		fetches:  make(map[string]int),
+	}
+	for k, v := range values {
+		p.rotate(k, []byte(v))
+	}
+	return p
+}
+
+// Put sets the value of key, clearing any error set with Fail.
+func (p *Provider) Put(key, value string) {
+	p.Rotate(key, value)
+}
+
+// Rotate sets the value of key as a new version, clearing any error set with
+// Fail, and returns the ID of the version. The version it replaces becomes
+// "previous".
+func (p *Provider) Rotate(key, value string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.errs, key)
+	return p.rotate(key, []byte(value))
+}
+
+// rotate adds value as the current version of key. The caller holds p.mu.
+func (p *Provider) rotate(key string, value []byte) string {
+	id := strconv.Itoa(len(p.ids[key]) + 1)
+	p.ids[key] = append(p.ids[key], id)
+	if p.versions[key] == nil {
+		p.versions[key] = make(map[string][]byte)
+	}
+	p.versions[key][id] = value
+	p.values[key] = value
+	return id
+}
+
+// SetVersion sets the value of version of key without changing its current
+// value. version is a label, such as "pending", or the ID of an existing
+// version to overwrite.
+func (p *Provider) SetVersion(key, version, value string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.versions[key] == nil {
+		p.versions[key] = make(map[string][]byte)
+	}
+	p.versions[key][version] = []byte(value)
+	if ids := p.ids[key]; len(ids) > 0 && ids[len(ids)-1] == version {
+		p.values[key] = []byte(value)
+	}
+}
+
+// Fail makes fetches of key fail with err until the next Put or Remove.
+func (p *Provider) Fail(key string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errs[key] = err
+}
+
+// FailNext makes the next n fetches of key fail with err, after which key
+// behaves as before, as a flaky backend does.
+func (p *Provider) FailNext(key string, n int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failNext[key] = failure{n: n, err: err}
+}
+
+// SetOutage makes every fetch fail with err, as if the backend were down,
+// until SetOutage(nil).
+func (p *Provider) SetOutage(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.outage = err
+}
+
+// Delay makes each fetch of key take d, or return the context's error if it
+// is done first. An empty key delays fetches of every key not given its own
+// delay.
+func (p *Provider) Delay(key string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.delays[key] = d
+}
+
+// SetClock makes delays wait on clk, such as a Clock, rather than on the
+// system clock.
+func (p *Provider) SetClock(clk secrets.Clock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clock = clk
+}
+
+// Remove makes fetches of key report secrets.ErrNotFound until the next Put,
+// and discards its versions.
+func (p *Provider) Remove(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.values, key)
+	delete(p.versions, key)
+	delete(p.ids, key)
+	delete(p.errs, key)
+}
+
+// Fetches returns the number of times key has been fetched.
+func (p *Provider) Fetches(key string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.fetches[key]
+}
+
+// Calls returns the fetches made so far, in order.
+func (p *Provider) Calls() []Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.calls)
+}
+
+// Get returns the scripted value of key.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.fetch(ctx, key, "")
+}
+
+// GetVersion returns the scripted value of version of key: "current",
+// "previous", a version ID, or a label set with SetVersion.
+func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	return p.fetch(ctx, key, version)
+}
+
+func (p *Provider) fetch(ctx context.Context, key, version string) ([]byte, error) {
+	p.mu.Lock()
+	d, ok := p.delays[key]
+	if !ok {
+		d = p.delays[""]
+	}
+	clk := p.clock
+	p.mu.Unlock()
+	var waitErr error
+	if d > 0 {
+		waitErr = wait(ctx, clk, d)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fetches[key]++
+	val, err := p.lookup(key, version)
+	if waitErr != nil {
+		val, err = nil, waitErr
+	}
+	if err != nil {
+		if version != "" {
+			err = fmt.Errorf("secrettest: %q version %q: %w", key, version, err)
+		} else {
+			err = fmt.Errorf("secrettest: %q: %w", key, err)
+		}
+	}
+	p.calls = append(p.calls, Call{Key: key, Version: version, Err: err})
+	return val, err
+}
+
+// lookup returns the scripted result of a fetch. The caller holds p.mu.
+func (p *Provider) lookup(key, version string) ([]byte, error) {
+	if p.outage != nil {
+		return nil, p.outage
+	}
+	if f, ok := p.failNext[key]; ok {
+		if f.n--; f.n <= 0 {
+			delete(p.failNext, key)
+		} else {
+			p.failNext[key] = f
+		}
+		return nil, f.err
+	}
+	if err, ok := p.errs[key]; ok {
+		return nil, err
+	}
+	var v []byte
+	switch ids := p.ids[key]; {
+	case version == "":
+		v, ok := p.values[key]
+		if !ok {
+			return nil, secrets.ErrNotFound
+		}
+		return slices.Clone(v), nil
+	case version == "current" && len(ids) > 0:
+		v = p.versions[key][ids[len(ids)-1]]
+	case version == "previous" && len(ids) > 1:
+		v = p.versions[key][ids[len(ids)-2]]
+	default:
+		var ok bool
+		if v, ok = p.versions[key][version]; !ok {
+			return nil, secrets.ErrNotFound
+		}
+	}
+	if v == nil {
+		return nil, secrets.ErrNotFound
+	}
+	return slices.Clone(v), nil
+}
+
+// wait waits for d on clk, or the system clock if clk is nil, or until ctx
+// is done.
+func wait(ctx context.Context, clk secrets.Clock, d time.Duration) error {
+	var c <-chan time.Time
+	if clk != nil {
+		t := clk.NewTimer(d)
+		defer t.Stop()
+		c = t.C()
+	} else {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		c = t.C
+	}
+	select {
+	case <-c:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Change is an expected ChangeEvent. Values are compared as strings, so a nil
+// and an empty value are equal.
+type Change struct {
+	Field    string
+	Fragment string
+	Old      string
+	New      string
+}
+
+func (c Change) String() string {
+	if c.Fragment != "" {
+		return fmt.Sprintf("%s#%s: %q -> %q", c.Field, c.Fragment, c.Old, c.New)
+	}
+	return fmt.Sprintf("%s: %q -> %q", c.Field, c.Old, c.New)
+}
+
+// Step is one point of a Timeline: a change to the provider's state,
+// followed by a refresh of the Watcher.
+type Step struct {
+	// Name identifies the step in failure messages.
+	Name string
+	// Put sets the values of keys.
+	Put map[string]string
+	// Fail makes fetches of keys fail with an error.
+	Fail map[string]error
+	// Remove makes keys missing.
+	Remove []string
+
+	// Want lists the ChangeEvents the refresh must emit, in order.
+	Want []Change
+	// WantErr reports that the refresh must fail, leaving the struct
+	// unchanged.
+	WantErr bool
+}
+
+// Timeline scripts the states of a Provider registered as the default
+// provider of the Resolver under test.
+type Timeline struct {
+	// Initial holds the values present when the Watcher starts.
+	Initial map[string]string
+	// Steps are applied in order.
+	Steps []Step
+	// WantFinal, if non-nil, is the struct value the watched struct must
+	// hold after the last step, compared with reflect.DeepEqual.
+	WantFinal any
+
+	// ResolverOptions configure the Resolver. The scripted Provider is
+	// registered with secrets.WithDefault before them.
+	ResolverOptions []secrets.Option
+	// WatchOptions configure the Watcher. Polling is set to an hour before
+	// them, so that only refreshes re-resolve the struct; a step that emits
+	// more events than the Changes buffer holds blocks under
+	// secrets.DeliveryBlock.
+	WatchOptions []secrets.WatchOption
+}
+
+// Run watches dst, a pointer to a struct, while applying the steps of tl, and
+// reports each mismatch between the events emitted and those wanted, and
+// between the final struct and tl.WantFinal, as an error on t. It returns the
+// Provider so that callers can make further assertions, such as on fetch
+// counts. The Watcher is stopped before Run returns, and dst keeps the last
+// values it was given.
+func Run(t testing.TB, dst any, tl Timeline) *Provider {
+	t.Helper()
+	p := NewProvider(tl.Initial)
+	r := secrets.NewResolver(append([]secrets.Option{secrets.WithDefault(p)}, tl.ResolverOptions...)...)
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	opts := append([]secrets.WatchOption{secrets.WatchInterval(time.Hour)}, tl.WatchOptions...)
+	w, err := r.Watch(ctx, dst, opts...)
+	if err != nil {
+		t.Fatalf("secrettest: Watch: %v", err)
+	}
+	defer w.Stop()
+
+	for i, step := range tl.Steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i)
+		}
+		for k, v := range step.Put {
+			p.Put(k, v)
+		}
+		for k, err := range step.Fail {
+			p.Fail(k, err)
+		}
+		for _, k := range step.Remove {
+			p.Remove(k)
+		}
+
+		err := w.Refresh(ctx)
+		switch {
+		case step.WantErr && err == nil:
+			t.Errorf("secrettest: %s: refresh succeeded, want error", name)
+		case !step.WantErr && err != nil:
+			t.Errorf("secrettest: %s: refresh: %v", name, err)
+		}
+		got := received(w.Changes())
+		if !slices.Equal(got, step.Want) {
+			t.Errorf("secrettest: %s: events = %v, want %v", name, got, step.Want)
+		}
+	}
+
+	if tl.WantFinal != nil {
+		w.RLock()
+		final := reflect.ValueOf(dst).Elem().Interface()
+		w.RUnlock()
+		if !reflect.DeepEqual(final, tl.WantFinal) {
+			t.Errorf("secrettest: final state = %+v, want %+v", final, tl.WantFinal)
+		}
+	}
+	return p
+}
+
+// received returns the events buffered on ch.
+func received(ch <-chan secrets.ChangeEvent) []Change {
+	var got []Change
+	for len(ch) > 0 {
+		ev := <-ch
+		got = append(got, Change{
+			Field:    ev.Field,
+			Fragment: ev.Fragment,
+			Old:      string(ev.OldValue),
+			New:      string(ev.NewValue),
+		})
+	}
+	return got
+}
@@ -0,0 +1,213 @@
+package secrettest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+	"github.com/brwse/go-secrets/secrettest"
+)
+
+func TestRun_Rotation(t *testing.T) {
+	type Config struct {
+		APIKey string `secret:"api-key"`
+		Region string `secret:"region,optional"`
+	}
+	var cfg Config
+	p := secrettest.Run(t, &cfg, secrettest.Timeline{
+		Initial: map[string]string{"api-key": "v1", "region": "eu"},
+		Steps: []secrettest.Step{
+			{Name: "unchanged"},
+			{
+				Name: "rotate",
+				Put:  map[string]string{"api-key": "v2"},
+				Want: []secrettest.Change{{Field: "APIKey", Old: "v1", New: "v2"}},
+			},
+			{Name: "outage", Fail: map[string]error{"api-key": errors.New("503")}, WantErr: true},
+			{Name: "recovered", Put: map[string]string{"api-key": "v2"}},
+			{Name: "deleted", Remove: []string{"api-key"}, WantErr: true},
+			{
+				Name:   "optional removed",
+				Put:    map[string]string{"api-key": "v3"},
+				Remove: []string{"region"},
+				Want: []secrettest.Change{
+					{Field: "APIKey", Old: "v2", New: "v3"},
+					{Field: "Region", Old: "eu", New: ""},
+				},
+			},
+		},
+		WantFinal: Config{APIKey: "v3"},
+	})
+
+	// One fetch for the initial resolve and one per step.
+	if got := p.Fetches("api-key"); got != 7 {
+		t.Errorf("Fetches = %d, want 7", got)
+	}
+}
+
+func TestRun_StructuralDiff(t *testing.T) {
+	var cfg struct {
+		Password string `secret:"db#password"`
+		DB       string `secret:"db"`
+	}
+	secrettest.Run(t, &cfg, secrettest.Timeline{
+		Initial: map[string]string{"db": `{"user":"admin","password":"a"}`},
+		Steps: []secrettest.Step{{
+			Put: map[string]string{"db": `{"user":"admin","password":"b"}`},
+			Want: []secrettest.Change{
+				{Field: "Password", Old: "a", New: "b"},
+				{Field: "DB", Fragment: "password", Old: "a", New: "b"},
+			},
+		}},
+		WatchOptions: []secrets.WatchOption{secrets.WatchStructuralDiff()},
+	})
+}
+
+// recordingTB records the errors reported by Run.
+type recordingTB struct {
+	testing.TB
+	errs []string
+}
+
+func (tb *recordingTB) Helper() {}
+
+func (tb *recordingTB) Errorf(format string, args ...any) {
+	tb.errs = append(tb.errs, fmt.Sprintf(format, args...))
+}
+
+func TestRun_ReportsMismatches(t *testing.T) {
+	type Config struct {
+		Key string `secret:"key"`
+	}
+	var cfg Config
+	tb := &recordingTB{TB: t}
+	secrettest.Run(tb, &cfg, secrettest.Timeline{
+		Initial: map[string]string{"key": "v1"},
+		Steps: []secrettest.Step{
+			{Name: "silent rotation", Put: map[string]string{"key": "v2"}},
+			{Name: "expected failure", WantErr: true},
+		},
+		WantFinal: Config{Key: "v1"},
+	})
+
+	want := []string{
+		`silent rotation: events = [Key: "v1" -> "v2"], want []`,
+		`expected failure: refresh succeeded`,
+		`final state = {Key:v2}, want {Key:v1}`,
+	}
+	if len(tb.errs) != len(want) {
+		t.Fatalf("errors = %q, want %d", tb.errs, len(want))
+	}
+	for i, w := range want {
+		if !strings.Contains(tb.errs[i], w) {
+			t.Errorf("error %d = %q, want it to contain %q", i, tb.errs[i], w)
+		}
+	}
+}
+
+func TestProvider_Versions(t *testing.T) {
+	p := secrettest.NewProvider(map[string]string{"db": "pw-1"})
+	ctx := context.Background()
+	if id := p.Rotate("db", "pw-2"); id != "2" {
+		t.Errorf("Rotate = %q, want 2", id)
+	}
+	p.SetVersion("db", "pending", "pw-3")
+
+	tests := map[string]string{"current": "pw-2", "previous": "pw-1", "1": "pw-1", "2": "pw-2", "pending": "pw-3"}
+	for version, want := range tests {
+		if got, err := p.GetVersion(ctx, "db", version); err != nil || string(got) != want {
+			t.Errorf("GetVersion(db, %s) = %q, %v; want %q", version, got, err, want)
+		}
+	}
+	if got, _ := p.Get(ctx, "db"); string(got) != "pw-2" {
+		t.Errorf("Get(db) = %q, want pw-2", got)
+	}
+	if _, err := p.GetVersion(ctx, "db", "3"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("GetVersion(db, 3) error = %v, want ErrNotFound", err)
+	}
+
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	var cfg struct {
+		DB secrets.Versioned[string] `secret:"db"`
+	}
+	if err := r.Resolve(ctx, &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.DB.Current != "pw-2" || cfg.DB.Previous != "pw-1" {
+		t.Errorf("Versioned = %+v, want pw-2 and pw-1", cfg.DB)
+	}
+}
+
+func TestProvider_Faults(t *testing.T) {
+	p := secrettest.NewProvider(map[string]string{"a": "1", "b": "2"})
+	ctx := context.Background()
+	flaky := errors.New("503")
+
+	p.FailNext("a", 2, flaky)
+	for i := range 2 {
+		if _, err := p.Get(ctx, "a"); !errors.Is(err, flaky) {
+			t.Errorf("fetch %d: error = %v, want the injected error", i, err)
+		}
+	}
+	if got, err := p.Get(ctx, "a"); err != nil || string(got) != "1" {
+		t.Errorf("Get after the failures = %q, %v; want 1", got, err)
+	}
+
+	down := errors.New("connection refused")
+	p.SetOutage(down)
+	if _, err := p.Get(ctx, "b"); !errors.Is(err, down) {
+		t.Errorf("Get during the outage: error = %v", err)
+	}
+	p.SetOutage(nil)
+	if _, err := p.Get(ctx, "b"); err != nil {
+		t.Errorf("Get after the outage: %v", err)
+	}
+
+	calls := p.Calls()
+	if len(calls) != 5 || calls[0].Key != "a" || !errors.Is(calls[0].Err, flaky) || calls[4].Key != "b" || calls[4].Err != nil {
+		t.Errorf("Calls() = %+v", calls)
+	}
+}
+
+func TestProvider_Delay(t *testing.T) {
+	p := secrettest.NewProvider(map[string]string{"slow": "s", "fast": "f"})
+	clk := secrettest.NewClock(epoch)
+	p.SetClock(clk)
+	p.Delay("", time.Second)
+	p.Delay("fast", 0)
+	ctx := context.Background()
+
+	if _, err := p.Get(ctx, "fast"); err != nil {
+		t.Fatalf("Get(fast): %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Get(ctx, "slow")
+		done <- err
+	}()
+	clk.BlockUntil(1)
+	select {
+	case <-done:
+		t.Fatal("Get(slow) returned before the delay")
+	default:
+	}
+	clk.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("Get(slow): %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		_, err := p.Get(ctx, "slow")
+		done <- err
+	}()
+	clk.BlockUntil(1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Get(slow) with a cancelled context: error = %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+// Package sqlconn provides a database/sql connector that reads the database
+// password from a secrets.Resolver each time it opens a connection, so that
+// connection pools pick up a rotated password without a restart:
+//
+//	c, err := sqlconn.New(r, "awssm://prod/db#password", &pq.Driver{}, func(password string) string {
+//		return "postgres://app:" + url.QueryEscape(password) + "@db.internal/app"
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	db := sql.OpenDB(c)
+//
+// Connections already open keep working when the password rotates, as
+// databases check passwords only when a connection is made; give the
+// resolver a secrets.CachedProvider so that opening a connection does not
+// always fetch the secret.
+package sqlconn
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/brwse/go-secrets"
+)
+
+// Option configures a Connector.
+type Option func(*Connector)
+
+// WithRetryPrevious sets which errors from connecting with the current
+// password are retried with the previous one. By default every error is,
+// other than the context being done, as drivers report authentication
+// failures in their own ways.
+func WithRetryPrevious(retry func(error) bool) Option {
+	return func(c *Connector) {
+		c.retry = retry
+	}
+}
+
+// defaultRetry reports whether err is not the context being done, which is
+// the default of WithRetryPrevious.
+func defaultRetry(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// Connector is a driver.Connector that connects with the password a secret
+// holds when each connection is opened.
+//
+// During a rotation, the secret and the database may briefly disagree on the
+// password. If connecting with the current version of the secret fails, the
+// Connector retries with its previous version, when the provider keeps
+// versions and the reference does not name one.
+type Connector struct {
+	r        *secrets.Resolver
+	current  secrets.Ref
+	previous secrets.Ref // zero if there is no previous version to try
+	drv      driver.Driver
+	dsn      func(password string) string
+	retry    func(error) bool
+
+	// For drivers that parse the DSN once, the connectors of the current
+	// and previous passwords; guarded by mu.
+	mu                        sync.Mutex
+	currentConn, previousConn cachedConnector
+}
+
+// cachedConnector is a driver.Connector opened for a DSN.
+type cachedConnector struct {
+	dsn       string
+	connector driver.Connector
+}
+
+// New returns a Connector for drv that resolves the secret ref with r, in
+// the syntax of the `secret` struct tag, and connects with the data source
+// name dsn returns for its value.
+func New(r *secrets.Resolver, ref string, drv driver.Driver, dsn func(password string) string, opts ...Option) (*Connector, error) {
+	current, err := secrets.ParseRef(ref)
+	if err != nil {
+		return nil, fmt.Errorf("sqlconn: %w", err)
+	}
+	c := &Connector{
+		r:       r,
+		current: current,
+		drv:     drv,
+		dsn:     dsn,
+		retry:   defaultRetry,
+	}
+	if current.Version() == "" {
+		if c.previous, err = secrets.ParseRef(ref + ",version=previous"); err != nil {
+			return nil, fmt.Errorf("sqlconn: %w", err)
+		}
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Connect opens a connection with the current password, or with the
+// previous one if that fails.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	password, err := c.r.ResolveRef(ctx, c.current)
+	if err != nil {
+		return nil, fmt.Errorf("sqlconn: %w", err)
+	}
+	conn, err := c.connect(ctx, string(password), true)
+	if err == nil || c.previous.IsZero() || !c.retry(err) {
+		return conn, err
+	}
+	previous, perr := c.r.ResolveRef(ctx, c.previous)
+	if perr != nil || len(previous) == 0 || string(previous) == string(password) {
+		// No previous version to fall back to; report the error of the
+		// current one.
+		return nil, err
+	}
+	if conn, perr := c.connect(ctx, string(previous), false); perr == nil {
+		return conn, nil
+	}
+	return nil, err
+}
+
+// connect opens a connection with password, which is the current one if
+// current is true and the previous one otherwise.
+func (c *Connector) connect(ctx context.Context, password string, current bool) (driver.Conn, error) {
+	dsn := c.dsn(password)
+	dc, ok := c.drv.(driver.DriverContext)
+	if !ok {
+		return c.drv.Open(dsn)
+	}
+	c.mu.Lock()
+	connector, err := c.connector(dc, dsn, current)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+// connector returns the connector for dsn, opening it if neither slot holds
+// it. After a rotation, the connector of the password that was current
+// moves to the previous slot, so that falling back to it does not open it
+// again. c.mu must be held.
+func (c *Connector) connector(dc driver.DriverContext, dsn string, current bool) (driver.Connector, error) {
+	switch {
+	case c.currentConn.connector != nil && c.currentConn.dsn == dsn:
+		return c.currentConn.connector, nil
+	case c.previousConn.connector != nil && c.previousConn.dsn == dsn:
+		connector := c.previousConn.connector
+		if current {
+			c.currentConn, c.previousConn = c.previousConn, c.currentConn
+		}
+		return connector, nil
+	}
+	connector, err := dc.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if current {
+		c.previousConn = c.currentConn
+		c.currentConn = cachedConnector{dsn, connector}
+	} else {
+		c.previousConn = cachedConnector{dsn, connector}
+	}
+	return connector, nil
+}
+
+// Driver returns the underlying driver.
+func (c *Connector) Driver() driver.Driver {
+	return c.drv
+}
@@ -0,0 +1,187 @@
+package sqlconn
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+	"github.com/brwse/go-secrets/secrettest"
+)
+
+var errAuth = errors.New("password authentication failed")
+
+// fakeDriver accepts connections whose DSN ends in its current password.
+type fakeDriver struct {
+	mu       sync.Mutex
+	password string
+	opened   []string // passwords connected with
+}
+
+func (d *fakeDriver) setPassword(pw string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.password = pw
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, pw, _ := strings.Cut(dsn, "password=")
+	if pw != d.password {
+		return nil, errAuth
+	}
+	d.opened = append(d.opened, pw)
+	return fakeConn{}, nil
+}
+
+// contextDriver is a fakeDriver that implements driver.DriverContext.
+type contextDriver struct {
+	*fakeDriver
+	connectors int
+}
+
+func (d *contextDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	d.connectors++
+	return dsnConnector{d, dsn}, nil
+}
+
+type dsnConnector struct {
+	d   *contextDriver
+	dsn string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.d }
+
+type fakeConn struct{ driver.Conn }
+
+func (fakeConn) Close() error { return nil }
+
+func dsn(password string) string { return "host=db password=" + password }
+
+func TestConnect_Rotation(t *testing.T) {
+	p := secrettest.NewProvider(map[string]string{"db": "pw-1"})
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	drv := &fakeDriver{password: "pw-1"}
+	c, err := New(r, "db", drv, dsn)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	// The secret rotates before the database does.
+	p.Rotate("db", "pw-2")
+	if _, err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect during the rotation window: %v", err)
+	}
+	// The database catches up.
+	drv.setPassword("pw-2")
+	if _, err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect after the rotation: %v", err)
+	}
+	if want := []string{"pw-1", "pw-1", "pw-2"}; strings.Join(drv.opened, ",") != strings.Join(want, ",") {
+		t.Errorf("connected with %q, want %q", drv.opened, want)
+	}
+
+	drv.setPassword("pw-9")
+	if _, err := c.Connect(ctx); !errors.Is(err, errAuth) {
+		t.Errorf("Connect with neither password: error = %v, want the authentication error", err)
+	}
+}
+
+func TestConnect_NoFallback(t *testing.T) {
+	p := secrettest.NewProvider(map[string]string{"db": "pw-1"})
+	p.Rotate("db", "pw-2")
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	drv := &fakeDriver{password: "pw-1"}
+	ctx := context.Background()
+
+	pinned, _ := New(r, "db,version=2", drv, dsn)
+	if _, err := pinned.Connect(ctx); !errors.Is(err, errAuth) {
+		t.Errorf("Connect with a pinned version: error = %v, want no fallback", err)
+	}
+	never, _ := New(r, "db", drv, dsn, WithRetryPrevious(func(error) bool { return false }))
+	if _, err := never.Connect(ctx); !errors.Is(err, errAuth) {
+		t.Errorf("Connect with retries off: error = %v, want no fallback", err)
+	}
+
+	// A provider without versions has no previous password to try.
+	unversioned := secrets.NewResolver(secrets.WithDefault(getOnly{p}))
+	c, _ := New(unversioned, "db", drv, dsn)
+	if _, err := c.Connect(ctx); !errors.Is(err, errAuth) {
+		t.Errorf("Connect without versions: error = %v, want the authentication error", err)
+	}
+	if _, err := New(r, "db,bogus", drv, dsn); err == nil {
+		t.Error("New with an invalid reference succeeded")
+	}
+}
+
+// getOnly hides every method of a provider but Get.
+type getOnly struct{ p secrets.Provider }
+
+func (g getOnly) Get(ctx context.Context, key string) ([]byte, error) { return g.p.Get(ctx, key) }
+
+func TestConnect_DriverContext(t *testing.T) {
+	p := secrettest.NewProvider(map[string]string{"db": "pw-1"})
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	drv := &contextDriver{fakeDriver: &fakeDriver{password: "pw-1"}}
+	c, err := New(r, "db", drv, dsn)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	db := sql.OpenDB(c)
+	defer db.Close()
+	db.SetMaxIdleConns(0)
+	ctx := context.Background()
+	for range 3 {
+		if err := db.PingContext(ctx); err != nil {
+			t.Fatalf("Ping: %v", err)
+		}
+	}
+	if drv.connectors != 1 {
+		t.Errorf("OpenConnector called %d times, want 1", drv.connectors)
+	}
+	if c.Driver() != drv {
+		t.Error("Driver() is not the underlying driver")
+	}
+}
+
+func TestConnect_DriverContextRotation(t *testing.T) {
+	p := secrettest.NewProvider(map[string]string{"db": "pw-1"})
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	drv := &contextDriver{fakeDriver: &fakeDriver{password: "pw-1"}}
+	c, err := New(r, "db", drv, dsn)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	// Each rotation opens a connector for the new password only; the one of
+	// the password that was current is kept for falling back to.
+	for i, pw := range []string{"pw-1", "pw-2", "pw-3"} {
+		if i > 0 {
+			p.Rotate("db", pw)
+		}
+		for range 2 {
+			if _, err := c.Connect(ctx); err != nil {
+				t.Fatalf("Connect with %s before the database rotates: %v", pw, err)
+			}
+		}
+		drv.setPassword(pw)
+		if _, err := c.Connect(ctx); err != nil {
+			t.Fatalf("Connect with %s: %v", pw, err)
+		}
+	}
+	if drv.connectors != 3 {
+		t.Errorf("OpenConnector called %d times, want 3", drv.connectors)
+	}
+}
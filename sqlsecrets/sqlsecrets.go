@@ -0,0 +1,211 @@
+// Package sqlsecrets provides a secret provider that reads key/value rows
+// from a table through database/sql.
+package sqlsecrets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+)
+
+// Placeholder is the bind parameter syntax of a database driver.
+type Placeholder int
+
+const (
+	// Dollar numbers parameters $1, $2, ..., as Postgres drivers expect.
+	Dollar Placeholder = iota
+	// Question marks parameters with ?, as MySQL and SQLite drivers expect.
+	Question
+)
+
+// ProviderOption configures the sqlsecrets Provider.
+type ProviderOption func(*Provider)
+
+// WithTable sets the table secrets are read from, optionally qualified by a
+// schema, such as "vault.secrets". Defaults to "secrets".
+func WithTable(table string) ProviderOption {
+	return func(p *Provider) {
+		p.table = table
+	}
+}
+
+// WithColumns sets the columns holding each secret's key and value. Defaults
+// to "name" and "value".
+func WithColumns(key, value string) ProviderOption {
+	return func(p *Provider) {
+		p.keyColumn = key
+		p.valueColumn = value
+	}
+}
+
+// WithVersionColumn enables versioning: the table holds one row per version
+// of a secret, numbered by the integer column, and the row with the highest
+// number is the current version. Without it, each key has a single row.
+func WithVersionColumn(column string) ProviderOption {
+	return func(p *Provider) {
+		p.versionColumn = column
+	}
+}
+
+// WithPlaceholder sets the bind parameter syntax of the driver. Defaults to
+// Dollar.
+func WithPlaceholder(ph Placeholder) ProviderOption {
+	return func(p *Provider) {
+		p.placeholder = ph
+	}
+}
+
+// Provider reads secrets from a database table.
+// It implements secrets.Provider, secrets.VersionedProvider,
+// secrets.CheckerProvider, and secrets.ListerProvider.
+//
+// Values are returned as stored. Tables that hold values encrypted by the
+// application can be read with the resolver's decrypt= tag option.
+type Provider struct {
+	db            *sql.DB
+	table         string
+	keyColumn     string
+	valueColumn   string
+	versionColumn string
+	placeholder   Placeholder
+}
+
+// identifier matches table and column names, optionally qualified by a
+// schema. Names are interpolated into queries, so nothing else is accepted.
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// New creates a new Provider reading from db. The caller keeps ownership of
+// db and closes it.
+func New(db *sql.DB, opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{db: db, table: "secrets", keyColumn: "name", valueColumn: "value"}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if db == nil {
+		return nil, errors.New("sqlsecrets: db is required")
+	}
+	for _, name := range []string{p.table, p.keyColumn, p.valueColumn} {
+		if !identifier.MatchString(name) {
+			return nil, fmt.Errorf("sqlsecrets: invalid identifier %q", name)
+		}
+	}
+	if p.versionColumn != "" && !identifier.MatchString(p.versionColumn) {
+		return nil, fmt.Errorf("sqlsecrets: invalid identifier %q", p.versionColumn)
+	}
+	return p, nil
+}
+
+// Get retrieves the value of key, the current version if the table is
+// versioned.
+// Returns secrets.ErrNotFound (wrapped) if no row has the key.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", p.valueColumn, p.table, p.keyColumn, p.param(1))
+	if p.versionColumn != "" {
+		query += fmt.Sprintf(" ORDER BY %s DESC LIMIT 1", p.versionColumn)
+	}
+	val, err := p.queryValue(ctx, query, key)
+	if err != nil {
+		return nil, fmt.Errorf("sqlsecrets: secret %q: %w", key, err)
+	}
+	return val, nil
+}
+
+// GetVersion retrieves a version of the secret: "current", "previous" (the
+// row with the next highest version), or a version number. Without
+// WithVersionColumn only "current" is supported.
+func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	if version == "current" {
+		return p.Get(ctx, key)
+	}
+	if p.versionColumn == "" {
+		return nil, &secrets.ErrVersioningNotSupported{Provider: "sqlsecrets"}
+	}
+	var val []byte
+	var err error
+	switch version {
+	case "previous":
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s ORDER BY %s DESC LIMIT 1 OFFSET 1",
+			p.valueColumn, p.table, p.keyColumn, p.param(1), p.versionColumn)
+		val, err = p.queryValue(ctx, query, key)
+	default:
+		n, perr := strconv.ParseInt(version, 10, 64)
+		if perr != nil {
+			return nil, fmt.Errorf("sqlsecrets: secret %q: unsupported version %q", key, version)
+		}
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s AND %s = %s",
+			p.valueColumn, p.table, p.keyColumn, p.param(1), p.versionColumn, p.param(2))
+		val, err = p.queryValue(ctx, query, key, n)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqlsecrets: secret %q version %q: %w", key, version, err)
+	}
+	return val, nil
+}
+
+// Check reports whether a row has the key, without reading its value.
+// Returns secrets.ErrNotFound (wrapped) if none does.
+func (p *Provider) Check(ctx context.Context, key string) error {
+	query := fmt.Sprintf("SELECT 1 FROM %s WHERE %s = %s LIMIT 1", p.table, p.keyColumn, p.param(1))
+	var one int
+	if err := p.db.QueryRowContext(ctx, query, key).Scan(&one); err != nil {
+		return fmt.Errorf("sqlsecrets: secret %q: %w", key, notFound(err))
+	}
+	return nil
+}
+
+// List returns the distinct keys that start with prefix, sorted.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	// Comparing prefixes in Go avoids the dialects' differing LIKE escapes.
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM %s ORDER BY %s", p.keyColumn, p.table, p.keyColumn)
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("sqlsecrets: list %q: %w", prefix, err)
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, fmt.Errorf("sqlsecrets: list %q: %w", prefix, err)
+		}
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlsecrets: list %q: %w", prefix, err)
+	}
+	return keys, nil
+}
+
+// queryValue runs query, which selects one value column, and returns the
+// value of its first row.
+func (p *Provider) queryValue(ctx context.Context, query string, args ...any) ([]byte, error) {
+	var val []byte
+	if err := p.db.QueryRowContext(ctx, query, args...).Scan(&val); err != nil {
+		return nil, notFound(err)
+	}
+	return val, nil
+}
+
+// param returns the nth bind parameter, counting from 1.
+func (p *Provider) param(n int) string {
+	if p.placeholder == Question {
+		return "?"
+	}
+	return "$" + strconv.Itoa(n)
+}
+
+// notFound converts sql.ErrNoRows to secrets.ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return err
+}
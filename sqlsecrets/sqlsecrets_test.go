@@ -0,0 +1,173 @@
+package sqlsecrets
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/brwse/go-secrets"
+)
+
+var (
+	_ secrets.VersionedProvider = (*Provider)(nil)
+	_ secrets.CheckerProvider   = (*Provider)(nil)
+	_ secrets.ListerProvider    = (*Provider)(nil)
+)
+
+// newTestProvider returns a Provider reading from a mock database, whose
+// expectations are checked when the test ends.
+func newTestProvider(t *testing.T, opts ...ProviderOption) (*Provider, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	p, err := New(db, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return p, mock
+}
+
+func TestGet(t *testing.T) {
+	p, mock := newTestProvider(t)
+	mock.ExpectQuery("SELECT value FROM secrets WHERE name = $1").
+		WithArgs("db-password").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow([]byte("s3cret")))
+	got, err := p.Get(context.Background(), "db-password")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != "s3cret" {
+		t.Errorf("Get = %q, want s3cret", got)
+	}
+}
+
+func TestGet_Missing(t *testing.T) {
+	p, mock := newTestProvider(t)
+	mock.ExpectQuery("SELECT value FROM secrets WHERE name = $1").
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}))
+	if _, err := p.Get(context.Background(), "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestGet_CustomTable(t *testing.T) {
+	p, mock := newTestProvider(t, WithTable("vault.app_secrets"), WithColumns("k", "v"), WithPlaceholder(Question))
+	mock.ExpectQuery("SELECT v FROM vault.app_secrets WHERE k = ?").
+		WithArgs("api-key").
+		WillReturnRows(sqlmock.NewRows([]string{"v"}).AddRow("k1"))
+	got, err := p.Get(context.Background(), "api-key")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != "k1" {
+		t.Errorf("Get = %q, want k1", got)
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	p, mock := newTestProvider(t, WithVersionColumn("version"))
+	ctx := context.Background()
+	mock.ExpectQuery("SELECT value FROM secrets WHERE name = $1 ORDER BY version DESC LIMIT 1").
+		WithArgs("api-key").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("v3"))
+	mock.ExpectQuery("SELECT value FROM secrets WHERE name = $1 ORDER BY version DESC LIMIT 1 OFFSET 1").
+		WithArgs("api-key").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("v2"))
+	mock.ExpectQuery("SELECT value FROM secrets WHERE name = $1 AND version = $2").
+		WithArgs("api-key", int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("v1"))
+	mock.ExpectQuery("SELECT value FROM secrets WHERE name = $1 ORDER BY version DESC LIMIT 1 OFFSET 1").
+		WithArgs("new-key").
+		WillReturnRows(sqlmock.NewRows([]string{"value"}))
+
+	for _, tt := range []struct{ version, want string }{{"current", "v3"}, {"previous", "v2"}, {"1", "v1"}} {
+		got, err := p.GetVersion(ctx, "api-key", tt.version)
+		if err != nil {
+			t.Fatalf("GetVersion(%q): %v", tt.version, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("GetVersion(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+	if _, err := p.GetVersion(ctx, "new-key", "previous"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("previous of a single version: got %v, want ErrNotFound", err)
+	}
+	if _, err := p.GetVersion(ctx, "api-key", "latest"); err == nil {
+		t.Error("GetVersion(latest) succeeded, want an error")
+	}
+}
+
+func TestGetVersion_Unversioned(t *testing.T) {
+	p, _ := newTestProvider(t)
+	_, err := p.GetVersion(context.Background(), "api-key", "previous")
+	var vns *secrets.ErrVersioningNotSupported
+	if !errors.As(err, &vns) {
+		t.Errorf("got %v, want ErrVersioningNotSupported", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p, mock := newTestProvider(t)
+	query := "SELECT 1 FROM secrets WHERE name = $1 LIMIT 1"
+	mock.ExpectQuery(query).WithArgs("api-key").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery(query).WithArgs("missing").WillReturnRows(sqlmock.NewRows([]string{"1"}))
+	if err := p.Check(context.Background(), "api-key"); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	if err := p.Check(context.Background(), "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("missing key: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	p, mock := newTestProvider(t)
+	mock.ExpectQuery("SELECT DISTINCT name FROM secrets ORDER BY name").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("api-key").AddRow("prod/db").AddRow("prod/mq"))
+	got, err := p.List(context.Background(), "prod/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := []string{"prod/db", "prod/mq"}; !slices.Equal(got, want) {
+		t.Errorf("List = %q, want %q", got, want)
+	}
+}
+
+func TestGet_DatabaseError(t *testing.T) {
+	p, mock := newTestProvider(t)
+	dbErr := errors.New("connection refused")
+	mock.ExpectQuery("SELECT value FROM secrets WHERE name = $1").WillReturnError(dbErr)
+	if _, err := p.Get(context.Background(), "api-key"); !errors.Is(err, dbErr) {
+		t.Errorf("got %v, want the database error", err)
+	}
+}
+
+func TestNew_InvalidIdentifier(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	for _, opt := range []ProviderOption{
+		WithTable("secrets; DROP TABLE users"),
+		WithColumns("name", "value--"),
+		WithVersionColumn("a.b.c"),
+	} {
+		if _, err := New(db, opt); err == nil {
+			t.Error("New accepted an invalid identifier")
+		}
+	}
+	if _, err := New(nil); err == nil {
+		t.Error("New accepted a nil db")
+	}
+}
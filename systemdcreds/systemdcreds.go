@@ -0,0 +1,196 @@
+// Package systemdcreds provides a secret provider that reads the credentials
+// systemd passes to a service with LoadCredential=, SetCredential=, and their
+// encrypted variants.
+package systemdcreds
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+	"github.com/brwse/go-secrets/file"
+)
+
+// Decrypter decrypts credentials encrypted with systemd-creds encrypt.
+// Implement this interface to provide a custom decrypter or for testing.
+type Decrypter interface {
+	// Decrypt returns the plaintext of the credential name.
+	Decrypt(ctx context.Context, name string, ciphertext []byte) ([]byte, error)
+}
+
+// ProviderOption configures the systemdcreds Provider.
+type ProviderOption func(*Provider)
+
+// WithDir sets the directory credentials are read from, such as
+// /etc/credstore.encrypted. Defaults to the CREDENTIALS_DIRECTORY
+// environment variable systemd sets for services with credentials.
+func WithDir(dir string) ProviderOption {
+	return func(p *Provider) {
+		p.dir = dir
+	}
+}
+
+// WithDecrypt decrypts credentials with systemd-creds decrypt before
+// returning them, for credentials encrypted with systemd-creds encrypt that
+// systemd has not decrypted, such as those in /etc/credstore.encrypted read
+// outside a unit. Credentials given with LoadCredentialEncrypted= or
+// SetCredentialEncrypted= are decrypted by systemd and need no decryption.
+func WithDecrypt() ProviderOption {
+	return func(p *Provider) {
+		p.decrypter = cliDecrypter{}
+	}
+}
+
+// WithDecrypter decrypts credentials with d instead of systemd-creds.
+func WithDecrypter(d Decrypter) ProviderOption {
+	return func(p *Provider) {
+		p.decrypter = d
+	}
+}
+
+// WithTrimNewline configures whether trailing newlines are trimmed.
+// systemd passes credentials byte for byte, so it defaults to false.
+func WithTrimNewline(trim bool) ProviderOption {
+	return func(p *Provider) {
+		p.trimNewline = trim
+	}
+}
+
+// Provider reads systemd service credentials.
+// It implements secrets.Provider, secrets.CheckerProvider,
+// secrets.MetadataProvider, and secrets.ListerProvider.
+//
+// Keys are credential names, such as "db-password" for
+// LoadCredential=db-password:/etc/app/db-password. Names with a "/" and the
+// names "." and ".." are rejected.
+type Provider struct {
+	dir         string
+	decrypter   Decrypter
+	trimNewline bool
+	files       *file.Provider
+}
+
+// New creates a new Provider.
+// A directory is required, from WithDir or CREDENTIALS_DIRECTORY, which
+// systemd only sets for services that are given credentials.
+func New(opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.dir == "" {
+		p.dir = os.Getenv("CREDENTIALS_DIRECTORY")
+	}
+	if p.dir == "" {
+		return nil, errors.New("systemdcreds: credentials directory is required (use WithDir or run with LoadCredential=)")
+	}
+	// Trimming happens after decryption, which needs the exact ciphertext.
+	p.files = file.New(file.WithBaseDir(p.dir), file.WithTrimNewline(p.trimNewline && p.decrypter == nil))
+	return p, nil
+}
+
+// Dir returns the directory credentials are read from.
+func (p *Provider) Dir() string {
+	return p.dir
+}
+
+// Get returns the credential name key, decrypted if configured with
+// WithDecrypt or WithDecrypter.
+// Returns secrets.ErrNotFound (wrapped) if the service has no such
+// credential.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	val, err := p.files.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("systemdcreds: %w", err)
+	}
+	if p.decrypter == nil {
+		return val, nil
+	}
+	val, err = p.decrypter.Decrypt(ctx, key, val)
+	if err != nil {
+		return nil, fmt.Errorf("systemdcreds: decrypt credential %q: %w", key, err)
+	}
+	if p.trimNewline {
+		val = bytes.TrimSuffix(val, []byte("\n"))
+		val = bytes.TrimSuffix(val, []byte("\r"))
+	}
+	return val, nil
+}
+
+// Check reports whether the service has the credential, without reading it.
+// Returns secrets.ErrNotFound (wrapped) if it does not.
+func (p *Provider) Check(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if err := p.files.Check(ctx, key); err != nil {
+		return fmt.Errorf("systemdcreds: %w", err)
+	}
+	return nil
+}
+
+// GetMetadata describes the credential. RotatedAt is the modification time
+// of its file, which systemd sets when it starts the service.
+// Returns secrets.ErrNotFound (wrapped) if the service has no such
+// credential.
+func (p *Provider) GetMetadata(ctx context.Context, key string) (secrets.Metadata, error) {
+	if err := checkKey(key); err != nil {
+		return secrets.Metadata{}, err
+	}
+	md, err := p.files.GetMetadata(ctx, key)
+	if err != nil {
+		return secrets.Metadata{}, fmt.Errorf("systemdcreds: %w", err)
+	}
+	return md, nil
+}
+
+// List returns the names of the credentials that start with prefix.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := p.files.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("systemdcreds: %w", err)
+	}
+	// The credentials directory is flat; skip anything in subdirectories.
+	out := keys[:0]
+	for _, k := range keys {
+		if checkKey(k) == nil {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+// checkKey rejects keys that are not valid credential names.
+func checkKey(key string) error {
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, "/\x00") {
+		return fmt.Errorf("systemdcreds: invalid key %q: want a credential name without '/'", key)
+	}
+	return nil
+}
+
+// cliDecrypter runs systemd-creds decrypt, which checks the credential was
+// encrypted under name.
+type cliDecrypter struct{}
+
+func (cliDecrypter) Decrypt(ctx context.Context, name string, ciphertext []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "systemd-creds", "decrypt", "--name="+name, "-", "-")
+	cmd.Stdin = bytes.NewReader(ciphertext)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,148 @@
+package systemdcreds
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// writeFiles writes files, relative paths to contents, under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o400); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"db-password": "hunter2\n",
+		"tls.key":     "\x00\x01",
+	})
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+	p, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if p.Dir() != dir {
+		t.Errorf("Dir() = %q, want %q from CREDENTIALS_DIRECTORY", p.Dir(), dir)
+	}
+	ctx := context.Background()
+
+	if got, err := p.Get(ctx, "db-password"); err != nil || string(got) != "hunter2\n" {
+		t.Errorf("Get(db-password) = %q, %v; want it byte for byte", got, err)
+	}
+	if got, err := p.Get(ctx, "tls.key"); err != nil || string(got) != "\x00\x01" {
+		t.Errorf("Get(tls.key) = %q, %v", got, err)
+	}
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	trim, _ := New(WithDir(dir), WithTrimNewline(true))
+	if got, _ := trim.Get(ctx, "db-password"); string(got) != "hunter2" {
+		t.Errorf("Get with trimming = %q", got)
+	}
+}
+
+func TestNew_NoDir(t *testing.T) {
+	t.Setenv("CREDENTIALS_DIRECTORY", "")
+	if _, err := New(); err == nil {
+		t.Error("New without a credentials directory succeeded")
+	}
+}
+
+func TestGet_InvalidName(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"app/db": "x"})
+	p, _ := New(WithDir(dir))
+	for _, key := range []string{"", ".", "..", "app/db", "../etc/passwd", "/etc/passwd"} {
+		if _, err := p.Get(context.Background(), key); err == nil || errors.Is(err, secrets.ErrNotFound) {
+			t.Errorf("Get(%q) error = %v, want an invalid key error", key, err)
+		}
+		if err := p.Check(context.Background(), key); err == nil {
+			t.Errorf("Check(%q) succeeded", key)
+		}
+	}
+}
+
+// fakeDecrypter "decrypts" ciphertexts of the form "enc:<name>:<plaintext>".
+type fakeDecrypter struct{}
+
+func (fakeDecrypter) Decrypt(_ context.Context, name string, ciphertext []byte) ([]byte, error) {
+	pt, ok := bytes.CutPrefix(ciphertext, []byte("enc:"+name+":"))
+	if !ok {
+		return nil, errors.New("credential name mismatch")
+	}
+	return pt, nil
+}
+
+func TestGet_Decrypt(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"db-password": "enc:db-password:hunter2\n",
+		"renamed":     "enc:other:x",
+	})
+	ctx := context.Background()
+
+	p, _ := New(WithDir(dir), WithDecrypter(fakeDecrypter{}))
+	if got, err := p.Get(ctx, "db-password"); err != nil || string(got) != "hunter2\n" {
+		t.Errorf("Get(db-password) = %q, %v; want the plaintext", got, err)
+	}
+	if _, err := p.Get(ctx, "renamed"); err == nil {
+		t.Error("Get of a credential encrypted under another name succeeded")
+	}
+	trim, _ := New(WithDir(dir), WithDecrypter(fakeDecrypter{}), WithTrimNewline(true))
+	if got, err := trim.Get(ctx, "db-password"); err != nil || string(got) != "hunter2" {
+		t.Errorf("Get with trimming = %q, %v; want hunter2", got, err)
+	}
+}
+
+func TestCheckMetadataList(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"api-key":     "k",
+		"api-secret":  "s",
+		"db-password": "p",
+		"nested/file": "n",
+	})
+	p, _ := New(WithDir(dir))
+	ctx := context.Background()
+
+	if err := p.Check(ctx, "api-key"); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	if err := p.Check(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check(missing) error = %v, want ErrNotFound", err)
+	}
+	md, err := p.GetMetadata(ctx, "api-key")
+	if err != nil || md.RotatedAt.IsZero() {
+		t.Errorf("GetMetadata = %+v, %v; want RotatedAt set", md, err)
+	}
+
+	keys, err := p.List(ctx, "api-")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	slices.Sort(keys)
+	if want := []string{"api-key", "api-secret"}; !slices.Equal(keys, want) {
+		t.Errorf("List(api-) = %q, want %q", keys, want)
+	}
+	keys, _ = p.List(ctx, "")
+	if slices.Contains(keys, "nested/file") {
+		t.Errorf("List() = %q, includes a file in a subdirectory", keys)
+	}
+}
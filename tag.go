@@ -2,23 +2,34 @@ package secrets
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 )
 
 // parsedTag holds the components extracted from a `secret` struct tag.
 type parsedTag struct {
-	Scheme   string // URI scheme (e.g. "awssm"), empty for bare keys
-	Key      string // secret key/path
-	Fragment string // JSON field to extract (from #fragment)
-	Optional bool   // true if ,optional is set
-	Version  string // version identifier (from ,version=X)
+	Scheme    string        // URI scheme (e.g. "awssm"), empty for bare keys
+	Key       string        // secret key/path
+	Fragment  string        // JSON field to extract (from #fragment)
+	Optional  bool          // true if ,optional is set
+	Version   string        // version identifier (from ,version=X)
+	Watch     time.Duration // per-field watch interval (from ,watch=X), zero for the default
+	Class     string        // rotation policy class (from ,class=X)
+	Decrypt   string        // envelope decryption algorithm (from ,decrypt=X)
+	Encoding  string        // value encoding to decode (from ,encoding=X)
+	TTL       time.Duration // Secret[T] cache lifetime (from ,ttl=X), zero to cache forever
+	Transform string        // "|"-separated transforms to apply in order (from ,transform=X)
+	Bootstrap string        // generator for a missing secret (from ,bootstrap=X)
 }
 
 // parseTag parses a struct tag value with the format:
 //
 //	[scheme://]key[#fragment][,option...]
 //
-// Options: optional, version=X
+// Options: optional, version=X, watch=<duration>, class=X, decrypt=aesgcm,
+// encoding=base64, ttl=<duration>, transform=<name>[|<name>...],
+// bootstrap=generate:N[:hex|:base64]
 func parseTag(raw string) (parsedTag, error) {
 	if raw == "" {
 		return parsedTag{}, fmt.Errorf("secrets: empty tag")
@@ -35,6 +46,40 @@ func parseTag(raw string) (parsedTag, error) {
 			t.Optional = true
 		case strings.HasPrefix(opt, "version="):
 			t.Version = strings.TrimPrefix(opt, "version=")
+		case strings.HasPrefix(opt, "watch="):
+			d, err := time.ParseDuration(strings.TrimPrefix(opt, "watch="))
+			if err != nil || d <= 0 {
+				return parsedTag{}, fmt.Errorf("secrets: invalid watch interval in tag option %q", opt)
+			}
+			t.Watch = d
+		case strings.HasPrefix(opt, "class="):
+			t.Class = strings.TrimPrefix(opt, "class=")
+		case strings.HasPrefix(opt, "decrypt="):
+			t.Decrypt = strings.TrimPrefix(opt, "decrypt=")
+			if t.Decrypt != "aesgcm" {
+				return parsedTag{}, fmt.Errorf("secrets: unsupported decryption algorithm in tag option %q", opt)
+			}
+		case strings.HasPrefix(opt, "encoding="):
+			t.Encoding = strings.TrimPrefix(opt, "encoding=")
+			if t.Encoding != "base64" {
+				return parsedTag{}, fmt.Errorf("secrets: unsupported encoding in tag option %q", opt)
+			}
+		case strings.HasPrefix(opt, "ttl="):
+			d, err := time.ParseDuration(strings.TrimPrefix(opt, "ttl="))
+			if err != nil || d <= 0 {
+				return parsedTag{}, fmt.Errorf("secrets: invalid TTL in tag option %q", opt)
+			}
+			t.TTL = d
+		case strings.HasPrefix(opt, "transform="):
+			t.Transform = strings.TrimPrefix(opt, "transform=")
+			if slices.Contains(strings.Split(t.Transform, "|"), "") {
+				return parsedTag{}, fmt.Errorf("secrets: empty transform name in tag option %q", opt)
+			}
+		case strings.HasPrefix(opt, "bootstrap="):
+			t.Bootstrap = strings.TrimPrefix(opt, "bootstrap=")
+			if _, err := parseBootstrap(t.Bootstrap); err != nil {
+				return parsedTag{}, fmt.Errorf("secrets: invalid tag option %q: %w", opt, err)
+			}
 		default:
 			return parsedTag{}, fmt.Errorf("secrets: unknown tag option %q", opt)
 		}
@@ -57,6 +102,15 @@ func parseTag(raw string) (parsedTag, error) {
 	if t.Key == "" {
 		return parsedTag{}, fmt.Errorf("secrets: empty key in tag %q", raw)
 	}
+	if t.Bootstrap != "" {
+		// A generated value replaces the whole secret, in the clear.
+		switch {
+		case t.Fragment != "":
+			return parsedTag{}, fmt.Errorf("secrets: bootstrap= cannot be combined with a #fragment in tag %q", raw)
+		case t.Optional, t.Version != "", t.Decrypt != "":
+			return parsedTag{}, fmt.Errorf("secrets: bootstrap= cannot be combined with optional, version=, or decrypt= in tag %q", raw)
+		}
+	}
 
 	return t, nil
 }
@@ -69,3 +123,48 @@ func (t parsedTag) URI() string {
 	}
 	return t.Key
 }
+
+// String returns the tag in canonical form, such that parseTag(t.String())
+// yields t.
+func (t parsedTag) String() string {
+	s := t.URI()
+	if t.Fragment != "" {
+		s += "#" + t.Fragment
+	}
+	if t.Optional {
+		s += ",optional"
+	}
+	if t.Version != "" {
+		s += ",version=" + t.Version
+	}
+	if t.Watch > 0 {
+		s += ",watch=" + t.Watch.String()
+	}
+	if t.Class != "" {
+		s += ",class=" + t.Class
+	}
+	if t.Decrypt != "" {
+		s += ",decrypt=" + t.Decrypt
+	}
+	if t.Encoding != "" {
+		s += ",encoding=" + t.Encoding
+	}
+	if t.TTL > 0 {
+		s += ",ttl=" + t.TTL.String()
+	}
+	if t.Transform != "" {
+		s += ",transform=" + t.Transform
+	}
+	if t.Bootstrap != "" {
+		s += ",bootstrap=" + t.Bootstrap
+	}
+	return s
+}
+
+// transforms returns the names of the transforms in the tag's pipeline.
+func (t parsedTag) transforms() []string {
+	if t.Transform == "" {
+		return nil
+	}
+	return strings.Split(t.Transform, "|")
+}
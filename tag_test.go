@@ -1,6 +1,10 @@
 package secrets
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestParseTag_BareKey(t *testing.T) {
 	tag, err := parseTag("db-password")
@@ -99,6 +103,130 @@ func TestParseTag_Version(t *testing.T) {
 	}
 }
 
+func TestParseTag_Watch(t *testing.T) {
+	tag, err := parseTag("key,watch=10s")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag.Watch != 10*time.Second {
+		t.Errorf("Watch = %v, want %v", tag.Watch, 10*time.Second)
+	}
+	if got := tag.String(); got != "key,watch=10s" {
+		t.Errorf("String() = %q, want %q", got, "key,watch=10s")
+	}
+}
+
+func TestParseTag_InvalidWatch(t *testing.T) {
+	for _, raw := range []string{"key,watch=", "key,watch=soon", "key,watch=0s", "key,watch=-1m"} {
+		if _, err := parseTag(raw); err == nil {
+			t.Errorf("parseTag(%q): expected error, got nil", raw)
+		}
+	}
+}
+
+func TestParseTag_Class(t *testing.T) {
+	tag, err := parseTag("key,class=db")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag.Class != "db" {
+		t.Errorf("Class = %q, want %q", tag.Class, "db")
+	}
+	if got := tag.String(); got != "key,class=db" {
+		t.Errorf("String() = %q, want %q", got, "key,class=db")
+	}
+}
+
+func TestParseTag_Decrypt(t *testing.T) {
+	tag, err := parseTag("key,decrypt=aesgcm")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag.Decrypt != "aesgcm" {
+		t.Errorf("Decrypt = %q, want %q", tag.Decrypt, "aesgcm")
+	}
+	if _, err := parseTag("key,decrypt=rot13"); err == nil {
+		t.Error("expected error for unsupported algorithm, got nil")
+	}
+}
+
+func TestParseTag_Encoding(t *testing.T) {
+	tag, err := parseTag("k8s://prod/keystore#keystore.p12,encoding=base64")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag.Encoding != "base64" {
+		t.Errorf("Encoding = %q, want %q", tag.Encoding, "base64")
+	}
+	if got, want := tag.String(), "k8s://prod/keystore#keystore.p12,encoding=base64"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if _, err := parseTag("key,encoding=rot13"); err == nil {
+		t.Error("expected error for unsupported encoding, got nil")
+	}
+}
+
+func TestParseTag_Transform(t *testing.T) {
+	tag, err := parseTag("key#cert,transform=trim|base64|gunzip,optional")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(tag.transforms(), ","); got != "trim,base64,gunzip" {
+		t.Errorf("transforms() = %q, want trim,base64,gunzip", got)
+	}
+	if got, want := tag.String(), "key#cert,optional,transform=trim|base64|gunzip"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	for _, raw := range []string{"key,transform=", "key,transform=trim||gunzip", "key,transform=trim|"} {
+		if _, err := parseTag(raw); err == nil {
+			t.Errorf("parseTag(%q): expected error, got nil", raw)
+		}
+	}
+}
+
+func TestParseTag_Bootstrap(t *testing.T) {
+	tag, err := parseTag("awssm://svc/key,bootstrap=generate:32:base64,transform=base64")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag.Bootstrap != "generate:32:base64" {
+		t.Errorf("Bootstrap = %q, want generate:32:base64", tag.Bootstrap)
+	}
+	if got, want := tag.String(), "awssm://svc/key,transform=base64,bootstrap=generate:32:base64"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	for _, raw := range []string{
+		"key,bootstrap=",
+		"key,bootstrap=generate:0",
+		"key#password,bootstrap=generate:16",
+		"key,optional,bootstrap=generate:16",
+		"key,version=previous,bootstrap=generate:16",
+		"key,decrypt=aesgcm,bootstrap=generate:16",
+	} {
+		if _, err := parseTag(raw); err == nil {
+			t.Errorf("parseTag(%q): expected error, got nil", raw)
+		}
+	}
+}
+
+func TestParseTag_TTL(t *testing.T) {
+	tag, err := parseTag("key,ttl=1h")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag.TTL != time.Hour {
+		t.Errorf("TTL = %v, want %v", tag.TTL, time.Hour)
+	}
+	if got := tag.String(); got != "key,ttl=1h0m0s" {
+		t.Errorf("String() = %q, want %q", got, "key,ttl=1h0m0s")
+	}
+	for _, raw := range []string{"key,ttl=", "key,ttl=later", "key,ttl=0s"} {
+		if _, err := parseTag(raw); err == nil {
+			t.Errorf("parseTag(%q): expected error, got nil", raw)
+		}
+	}
+}
+
 func TestParseTag_AllOptions(t *testing.T) {
 	tag, err := parseTag("awssm://prod/db#password,optional,version=2")
 	if err != nil {
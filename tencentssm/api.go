@@ -0,0 +1,236 @@
+package tencentssm
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// cvmMetadataURL is where CVM role credentials are obtained, a variable for
+// testing.
+var cvmMetadataURL = "http://metadata.tencentyun.com/latest/meta-data/cam/security-credentials/"
+
+// apiClient calls Tencent Cloud API 3.0 actions, signed with TC3-HMAC-SHA256.
+type apiClient struct {
+	endpoint string // such as "https://ssm.tencentcloudapi.com"
+	service  string // such as "ssm"
+	version  string // API version, such as "2019-09-23"
+	region   string
+	creds    credentialsProvider
+	http     *http.Client
+}
+
+// call sends action with params and decodes the Response object of the JSON
+// response into out.
+func (c *apiClient) call(ctx context.Context, action string, params map[string]any, out any) error {
+	creds, err := c.creds.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(c.endpoint)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-TC-Action", action)
+	req.Header.Set("X-TC-Version", c.version)
+	req.Header.Set("X-TC-Region", c.region)
+	req.Header.Set("X-TC-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	if creds.Token != "" {
+		req.Header.Set("X-TC-Token", creds.Token)
+	}
+	if ua := secrets.UserAgentFromContext(ctx); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	req.Header.Set("Authorization", authorization(creds, c.service, u.Host, payload, now))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var env struct {
+		Response json.RawMessage `json:"Response"`
+	}
+	if err := json.Unmarshal(body, &env); err != nil || env.Response == nil {
+		if resp.StatusCode != http.StatusOK {
+			return &secrets.ErrProvider{StatusCode: resp.StatusCode, Err: errors.New(resp.Status)}
+		}
+		return fmt.Errorf("decode %s response: %w", action, err)
+	}
+	if err := apiError(resp.StatusCode, env.Response); err != nil {
+		return err
+	}
+	return json.Unmarshal(env.Response, out)
+}
+
+// authorization returns the TC3-HMAC-SHA256 Authorization header of a POST
+// request to host with payload, signing the content-type and host headers.
+func authorization(creds credentials, service, host string, payload []byte, t time.Time) string {
+	date := t.UTC().Format("2006-01-02")
+	scope := date + "/" + service + "/tc3_request"
+	canonicalRequest := "POST\n/\n\n" +
+		"content-type:application/json; charset=utf-8\nhost:" + host + "\n\n" +
+		"content-type;host\n" + sha256Hex(payload)
+	stringToSign := "TC3-HMAC-SHA256\n" + strconv.FormatInt(t.Unix(), 10) + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("TC3"+creds.SecretKey), date)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "tc3_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	return "TC3-HMAC-SHA256 Credential=" + creds.SecretID + "/" + scope + ", SignedHeaders=content-type;host, Signature=" + signature
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return mac.Sum(nil)
+}
+
+// apiError returns the error described by the Error object of response, if
+// any: secrets.ErrNotFound for ResourceNotFound, and a
+// *secrets.ErrProvider otherwise.
+func apiError(status int, response json.RawMessage) error {
+	var body struct {
+		Error *struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		RequestID string `json:"RequestId"`
+	}
+	if err := json.Unmarshal(response, &body); err != nil {
+		return err
+	}
+	if body.Error == nil {
+		if status != http.StatusOK {
+			return &secrets.ErrProvider{StatusCode: status, RequestID: body.RequestID, Err: errors.New(http.StatusText(status))}
+		}
+		return nil
+	}
+	msg := body.Error.Message
+	if msg == "" {
+		msg = body.Error.Code
+	}
+	if body.Error.Code == "ResourceNotFound" || strings.HasPrefix(body.Error.Code, "ResourceNotFound.") {
+		return fmt.Errorf("%s: %w", msg, secrets.ErrNotFound)
+	}
+	return &secrets.ErrProvider{
+		StatusCode: status,
+		Code:       body.Error.Code,
+		RequestID:  body.RequestID,
+		Err:        errors.New(msg),
+	}
+}
+
+// credentials are a SecretId and SecretKey pair, with a token if they are
+// temporary.
+type credentials struct {
+	SecretID   string
+	SecretKey  string
+	Token      string
+	Expiration time.Time // zero for long-lived credentials
+}
+
+// credentialsProvider returns the credentials to sign requests with.
+type credentialsProvider interface {
+	credentials(ctx context.Context) (credentials, error)
+}
+
+// staticCredentials are credentials that never change.
+type staticCredentials credentials
+
+func (c staticCredentials) credentials(context.Context) (credentials, error) {
+	return credentials(c), nil
+}
+
+// cachedCredentials caches temporary credentials from fetch, fetching new
+// ones five minutes before they expire.
+type cachedCredentials struct {
+	fetch func(ctx context.Context) (credentials, error)
+
+	mu    sync.Mutex
+	creds credentials
+}
+
+func (c *cachedCredentials) credentials(ctx context.Context) (credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.creds.SecretID != "" && time.Until(c.creds.Expiration) > 5*time.Minute {
+		return c.creds, nil
+	}
+	creds, err := c.fetch(ctx)
+	if err != nil {
+		return credentials{}, err
+	}
+	c.creds = creds
+	return creds, nil
+}
+
+// cvmRole returns the credentials of the CAM role bound to the CVM instance,
+// from the instance metadata service.
+func cvmRole(hc *http.Client, role string) credentialsProvider {
+	return &cachedCredentials{fetch: func(ctx context.Context) (credentials, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, cvmMetadataURL+url.PathEscape(role), nil)
+		if err != nil {
+			return credentials{}, err
+		}
+		resp, err := hc.Do(req)
+		if err != nil {
+			return credentials{}, fmt.Errorf("CVM role %q: %w", role, err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return credentials{}, fmt.Errorf("CVM role %q: %w", role, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return credentials{}, fmt.Errorf("CVM role %q: %s", role, resp.Status)
+		}
+		var out struct {
+			Code         string `json:"Code"`
+			TmpSecretID  string `json:"TmpSecretId"`
+			TmpSecretKey string `json:"TmpSecretKey"`
+			Token        string `json:"Token"`
+			ExpiredTime  int64  `json:"ExpiredTime"`
+		}
+		if err := json.Unmarshal(body, &out); err != nil {
+			return credentials{}, fmt.Errorf("CVM role %q: %w", role, err)
+		}
+		if out.Code != "Success" || out.TmpSecretID == "" {
+			return credentials{}, fmt.Errorf("CVM role %q: metadata service returned %q", role, out.Code)
+		}
+		return credentials{out.TmpSecretID, out.TmpSecretKey, out.Token, time.Unix(out.ExpiredTime, 0)}, nil
+	}}
+}
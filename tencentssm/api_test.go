@@ -0,0 +1,103 @@
+package tencentssm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get("X-TC-Timestamp"), 10, 64)
+		creds := credentials{SecretID: "id", SecretKey: "key"}
+		if got, want := r.Header.Get("Authorization"), authorization(creds, "ssm", r.Host, payload, time.Unix(ts, 0)); got != want {
+			t.Errorf("Authorization = %q, want %q", got, want)
+		}
+		if r.Header.Get("X-TC-Action") != "Echo" || r.Header.Get("X-TC-Token") != "tok" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		fmt.Fprintf(w, `{"Response": %s}`, payload)
+	}))
+	defer srv.Close()
+
+	c := &apiClient{
+		endpoint: srv.URL,
+		service:  "ssm",
+		version:  "2019-09-23",
+		region:   "ap-guangzhou",
+		creds:    staticCredentials{SecretID: "id", SecretKey: "key", Token: "tok"},
+		http:     srv.Client(),
+	}
+	var out struct{ Name string }
+	if err := c.call(context.Background(), "Echo", map[string]any{"Name": "a b"}, &out); err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if out.Name != "a b" {
+		t.Errorf("Name = %q, want %q", out.Name, "a b")
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	// The example request from the Tencent Cloud signature v3 documentation.
+	creds := credentials{SecretID: "AKIDz8krbsJ5yKBZQpn74WFkmLPx3EXAMPLE", SecretKey: "Gu5t9xGARNpq86cd98joQYCN3EXAMPLE"}
+	payload := `{"Limit": 1, "Filters": [{"Values": ["\u672a\u547d\u540d"], "Name": "instance-name"}]}`
+	got := authorization(creds, "cvm", "cvm.tencentcloudapi.com", []byte(payload), time.Unix(1551113065, 0))
+	want := "TC3-HMAC-SHA256 Credential=AKIDz8krbsJ5yKBZQpn74WFkmLPx3EXAMPLE/2019-02-25/cvm/tc3_request, SignedHeaders=content-type;host, Signature=72e494ea809ad7a8c8f7a4507b9bddcbaa8e581f516e8da2f66e2c5a96525168"
+	if got != want {
+		t.Errorf("authorization = %q, want %q", got, want)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	if err := apiError(http.StatusOK, json.RawMessage(`{"Error": {"Code": "ResourceNotFound", "Message": "no such secret"}}`)); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("ResourceNotFound: error = %v, want ErrNotFound", err)
+	}
+	var pe *secrets.ErrProvider
+	err := apiError(http.StatusOK, json.RawMessage(`{"Error": {"Code": "AuthFailure.SignatureFailure", "Message": "bad signature"}, "RequestId": "req-1"}`))
+	if !errors.As(err, &pe) || pe.Code != "AuthFailure.SignatureFailure" || pe.RequestID != "req-1" || pe.Error() != "bad signature" {
+		t.Errorf("error = %v, want ErrProvider with code and request ID", err)
+	}
+	if err := apiError(http.StatusOK, json.RawMessage(`{"RequestId": "req-1"}`)); err != nil {
+		t.Errorf("success: error = %v", err)
+	}
+}
+
+func TestCVMRole(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/app-role" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"TmpSecretId": "tmp-id", "TmpSecretKey": "tmp-key", "Token": "tok", "ExpiredTime": %d, "Code": "Success"}`, time.Now().Add(time.Hour).Unix())
+	}))
+	defer srv.Close()
+	defer func(u string) { cvmMetadataURL = u }(cvmMetadataURL)
+	cvmMetadataURL = srv.URL + "/"
+
+	ctx := context.Background()
+	p := cvmRole(srv.Client(), "app-role")
+	for range 2 {
+		creds, err := p.credentials(ctx)
+		if err != nil || creds.SecretID != "tmp-id" || creds.SecretKey != "tmp-key" || creds.Token != "tok" {
+			t.Fatalf("credentials = %+v, %v", creds, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("metadata service called %d times, want 1", calls)
+	}
+	if _, err := cvmRole(srv.Client(), "other").credentials(ctx); err == nil {
+		t.Error("credentials of an unknown role succeeded")
+	}
+}
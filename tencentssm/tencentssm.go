@@ -0,0 +1,254 @@
+// Package tencentssm provides a secret provider that reads from Tencent Cloud
+// Secrets Manager (SSM).
+package tencentssm
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// Client abstracts the Tencent Cloud Secrets Manager API.
+// Implement this interface to provide a custom client or for testing.
+type Client interface {
+	// GetSecretValue returns the value of the version of secret name with ID
+	// versionID.
+	GetSecretValue(ctx context.Context, name, versionID string) ([]byte, error)
+
+	// ListSecretVersions returns the versions of secret name, in any order.
+	ListSecretVersions(ctx context.Context, name string) ([]Version, error)
+}
+
+// Version describes a version of a secret.
+type Version struct {
+	ID      string
+	Created time.Time
+}
+
+// ProviderOption configures the tencentssm Provider.
+type ProviderOption func(*Provider)
+
+// WithRegion sets the region, such as "ap-guangzhou". Defaults to the
+// TENCENTCLOUD_REGION environment variable.
+func WithRegion(region string) ProviderOption {
+	return func(p *Provider) {
+		p.region = region
+	}
+}
+
+// WithEndpoint sends requests to url instead of the public SSM endpoint,
+// such as a private network endpoint.
+func WithEndpoint(url string) ProviderOption {
+	return func(p *Provider) {
+		p.endpoint = url
+	}
+}
+
+// WithCredentials authenticates with a SecretId and SecretKey pair, and
+// token if the pair is temporary. Defaults to the TENCENTCLOUD_SECRET_ID,
+// TENCENTCLOUD_SECRET_KEY, and TENCENTCLOUD_SESSION_TOKEN environment
+// variables.
+func WithCredentials(secretID, secretKey, token string) ProviderOption {
+	return func(p *Provider) {
+		p.secretID = secretID
+		p.secretKey = secretKey
+		p.token = token
+	}
+}
+
+// WithCVMRole authenticates as the CAM role role bound to the CVM instance,
+// with credentials from the instance metadata service, refreshed before they
+// expire. Defaults to the TENCENTCLOUD_CVM_ROLE environment variable when no
+// SecretId is given.
+func WithCVMRole(role string) ProviderOption {
+	return func(p *Provider) {
+		p.cvmRole = role
+	}
+}
+
+// WithHTTPClient sets the HTTP client of the default client.
+func WithHTTPClient(c *http.Client) ProviderOption {
+	return func(p *Provider) {
+		p.httpClient = c
+	}
+}
+
+// WithClient injects a custom Client implementation.
+func WithClient(c Client) ProviderOption {
+	return func(p *Provider) {
+		p.client = c
+	}
+}
+
+// Provider reads secrets from Tencent Cloud Secrets Manager.
+// It implements secrets.Provider and secrets.VersionedProvider.
+//
+// Keys are secret names. Binary secrets are returned decoded.
+type Provider struct {
+	region     string
+	endpoint   string
+	secretID   string
+	secretKey  string
+	token      string
+	cvmRole    string
+	httpClient *http.Client
+	client     Client
+}
+
+// New creates a new Tencent Cloud SSM Provider.
+// A region and credentials are required when not providing a custom Client
+// via WithClient.
+func New(opts ...ProviderOption) (*Provider, error) {
+	p := &Provider{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.client == nil {
+		if p.region == "" {
+			p.region = os.Getenv("TENCENTCLOUD_REGION")
+		}
+		if p.region == "" {
+			return nil, errors.New("tencentssm: region is required (use WithRegion or TENCENTCLOUD_REGION)")
+		}
+		if p.endpoint == "" {
+			p.endpoint = "https://ssm.tencentcloudapi.com"
+		}
+		if p.httpClient == nil {
+			p.httpClient = http.DefaultClient
+		}
+		creds, err := p.credentials()
+		if err != nil {
+			return nil, err
+		}
+		p.client = &apiClientAdapter{&apiClient{
+			endpoint: p.endpoint,
+			service:  "ssm",
+			version:  "2019-09-23",
+			region:   p.region,
+			creds:    creds,
+			http:     p.httpClient,
+		}}
+	}
+	return p, nil
+}
+
+// credentials returns the credentials provider the options and environment
+// configure.
+func (p *Provider) credentials() (credentialsProvider, error) {
+	if p.secretID == "" && p.cvmRole == "" {
+		p.secretID = os.Getenv("TENCENTCLOUD_SECRET_ID")
+		p.secretKey = os.Getenv("TENCENTCLOUD_SECRET_KEY")
+		p.token = os.Getenv("TENCENTCLOUD_SESSION_TOKEN")
+		if p.secretID == "" {
+			p.cvmRole = os.Getenv("TENCENTCLOUD_CVM_ROLE")
+		}
+	}
+	switch {
+	case p.secretID != "":
+		return staticCredentials{SecretID: p.secretID, SecretKey: p.secretKey, Token: p.token}, nil
+	case p.cvmRole != "":
+		return cvmRole(p.httpClient, p.cvmRole), nil
+	default:
+		return nil, errors.New("tencentssm: credentials are required (use WithCredentials or WithCVMRole)")
+	}
+}
+
+// Get retrieves the current version of the secret.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.GetVersion(ctx, key, "current")
+}
+
+// GetVersion retrieves a version of the secret. version is one of:
+//   - "current", the most recently created version;
+//   - "previous", the version created before it;
+//   - "id:" and a version ID, for a version whose ID is "current" or
+//     "previous";
+//   - any other version ID, such as "v2" or "SSM_Current", which names the
+//     version in use of a rotated cloud product credential.
+//
+// Secrets Manager has no version stages of its own, so "current" and
+// "previous" list the versions of the secret before reading one.
+// Returns secrets.ErrNotFound (wrapped) if the secret or version does not
+// exist.
+func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	if version == "" {
+		return nil, fmt.Errorf("tencentssm: secret %q: unsupported version %q", key, version)
+	}
+	val, err := p.getVersion(ctx, key, version)
+	if err != nil {
+		if version == "current" {
+			return nil, fmt.Errorf("tencentssm: secret %q: %w", key, err)
+		}
+		return nil, fmt.Errorf("tencentssm: secret %q version %q: %w", key, version, err)
+	}
+	return val, nil
+}
+
+func (p *Provider) getVersion(ctx context.Context, key, version string) ([]byte, error) {
+	id, ok := strings.CutPrefix(version, "id:")
+	if !ok && (version == "current" || version == "previous") {
+		versions, err := p.client.ListSecretVersions(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		// Newest first; ties keep the order the service returned.
+		slices.SortStableFunc(versions, func(a, b Version) int {
+			return b.Created.Compare(a.Created)
+		})
+		i := 0
+		if version == "previous" {
+			i = 1
+		}
+		if i >= len(versions) {
+			return nil, secrets.ErrNotFound
+		}
+		id = versions[i].ID
+	}
+	return p.client.GetSecretValue(ctx, key, id)
+}
+
+// apiClientAdapter implements Client with the Secrets Manager API actions.
+type apiClientAdapter struct {
+	api *apiClient
+}
+
+func (c *apiClientAdapter) GetSecretValue(ctx context.Context, name, versionID string) ([]byte, error) {
+	params := map[string]any{"SecretName": name, "VersionId": versionID}
+	var out struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+	}
+	if err := c.api.call(ctx, "GetSecretValue", params, &out); err != nil {
+		return nil, err
+	}
+	if out.SecretBinary != "" {
+		return base64.StdEncoding.DecodeString(out.SecretBinary)
+	}
+	return []byte(out.SecretString), nil
+}
+
+func (c *apiClientAdapter) ListSecretVersions(ctx context.Context, name string) ([]Version, error) {
+	var out struct {
+		Versions []struct {
+			VersionID  string `json:"VersionId"`
+			CreateTime int64  `json:"CreateTime"`
+		} `json:"Versions"`
+	}
+	if err := c.api.call(ctx, "ListSecretVersionIds", map[string]any{"SecretName": name}, &out); err != nil {
+		return nil, err
+	}
+	versions := make([]Version, 0, len(out.Versions))
+	for _, v := range out.Versions {
+		versions = append(versions, Version{ID: v.VersionID, Created: time.Unix(v.CreateTime, 0)})
+	}
+	return versions, nil
+}
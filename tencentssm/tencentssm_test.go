@@ -0,0 +1,152 @@
+package tencentssm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+)
+
+// fakeClient serves versions of secrets by name and version ID.
+type fakeClient struct {
+	versions map[string][]Version // name -> versions
+	values   map[string]string    // name#id -> value
+}
+
+func (c *fakeClient) GetSecretValue(_ context.Context, name, versionID string) ([]byte, error) {
+	v, ok := c.values[name+"#"+versionID]
+	if !ok {
+		return nil, secrets.ErrNotFound
+	}
+	return []byte(v), nil
+}
+
+func (c *fakeClient) ListSecretVersions(_ context.Context, name string) ([]Version, error) {
+	versions, ok := c.versions[name]
+	if !ok {
+		return nil, secrets.ErrNotFound
+	}
+	return versions, nil
+}
+
+func TestGetVersion(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	p, err := New(WithClient(&fakeClient{
+		versions: map[string][]Version{
+			"db":   {{"v2", t0.Add(time.Hour)}, {"v3", t0.Add(2 * time.Hour)}, {"v1", t0}},
+			"new":  {{"v1", t0}},
+			"none": {},
+		},
+		values: map[string]string{
+			"db#v1":          "pw-1",
+			"db#v2":          "pw-2",
+			"db#v3":          "pw-3",
+			"db#current":     "pw-literal",
+			"db#SSM_Current": "pw-3",
+			"new#v1":         "only",
+		},
+	}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	tests := map[string]string{
+		"current":     "pw-3",
+		"previous":    "pw-2",
+		"v1":          "pw-1",
+		"SSM_Current": "pw-3",
+		"id:current":  "pw-literal",
+	}
+	for version, want := range tests {
+		got, err := p.GetVersion(ctx, "db", version)
+		if err != nil || string(got) != want {
+			t.Errorf("GetVersion(db, %s) = %q, %v; want %q", version, got, err, want)
+		}
+	}
+	if got, err := p.Get(ctx, "db"); err != nil || string(got) != "pw-3" {
+		t.Errorf("Get(db) = %q, %v; want pw-3", got, err)
+	}
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, err := p.Get(ctx, "none"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(none) error = %v, want ErrNotFound", err)
+	}
+	if _, err := p.GetVersion(ctx, "new", "previous"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("GetVersion(new, previous) error = %v, want ErrNotFound", err)
+	}
+	if _, err := p.GetVersion(ctx, "db", ""); err == nil {
+		t.Error("GetVersion with an empty version succeeded")
+	}
+}
+
+func TestNew_Config(t *testing.T) {
+	t.Setenv("TENCENTCLOUD_REGION", "")
+	t.Setenv("TENCENTCLOUD_SECRET_ID", "")
+	t.Setenv("TENCENTCLOUD_CVM_ROLE", "")
+	if _, err := New(WithCredentials("id", "key", "")); err == nil {
+		t.Error("New without a region succeeded")
+	}
+	if _, err := New(WithRegion("ap-guangzhou")); err == nil {
+		t.Error("New without credentials succeeded")
+	}
+
+	t.Setenv("TENCENTCLOUD_REGION", "ap-shanghai")
+	t.Setenv("TENCENTCLOUD_SECRET_ID", "id")
+	t.Setenv("TENCENTCLOUD_SECRET_KEY", "key")
+	p, err := New()
+	if err != nil {
+		t.Fatalf("New from the environment: %v", err)
+	}
+	if p.region != "ap-shanghai" || p.endpoint != "https://ssm.tencentcloudapi.com" {
+		t.Errorf("region, endpoint = %q, %q", p.region, p.endpoint)
+	}
+}
+
+func TestAPIClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-TC-Version") != "2019-09-23" || r.Header.Get("X-TC-Region") != "ap-guangzhou" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		var in struct{ SecretName, VersionId string }
+		json.NewDecoder(r.Body).Decode(&in)
+		switch action := r.Header.Get("X-TC-Action"); {
+		case action == "ListSecretVersionIds" && in.SecretName == "db":
+			fmt.Fprint(w, `{"Response": {"Versions": [{"VersionId": "v1", "CreateTime": 100}, {"VersionId": "v2", "CreateTime": 200}]}}`)
+		case action == "GetSecretValue" && in.SecretName == "db" && in.VersionId == "v2":
+			fmt.Fprint(w, `{"Response": {"SecretString": "pw-2"}}`)
+		case action == "GetSecretValue" && in.SecretName == "db" && in.VersionId == "v1":
+			fmt.Fprint(w, `{"Response": {"SecretString": "pw-1"}}`)
+		case action == "GetSecretValue" && in.SecretName == "cert":
+			fmt.Fprintf(w, `{"Response": {"SecretBinary": %q}}`, base64.StdEncoding.EncodeToString([]byte{0, 1, 2}))
+		default:
+			fmt.Fprint(w, `{"Response": {"Error": {"Code": "ResourceNotFound", "Message": "secret not found"}, "RequestId": "req-1"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := New(WithRegion("ap-guangzhou"), WithEndpoint(srv.URL), WithCredentials("id", "key", ""), WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	if got, err := p.Get(ctx, "db"); err != nil || string(got) != "pw-2" {
+		t.Errorf("Get(db) = %q, %v; want pw-2", got, err)
+	}
+	if got, err := p.GetVersion(ctx, "db", "previous"); err != nil || string(got) != "pw-1" {
+		t.Errorf("GetVersion(db, previous) = %q, %v; want pw-1", got, err)
+	}
+	if got, err := p.GetVersion(ctx, "cert", "v1"); err != nil || string(got) != "\x00\x01\x02" {
+		t.Errorf("GetVersion(cert, v1) = %q, %v; want the decoded binary", got, err)
+	}
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+}
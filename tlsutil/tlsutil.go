@@ -0,0 +1,287 @@
+// Package tlsutil builds *tls.Config values whose certificate, key, and CA
+// bundle are secrets, reloaded when they rotate:
+//
+//	certs, err := tlsutil.New(ctx, r, tlsutil.Source{
+//		Cert: "awssm://prod/tls#cert",
+//		Key:  "awssm://prod/tls#key",
+//		CA:   "awssm://prod/tls#ca",
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer certs.Stop()
+//	srv := &http.Server{Addr: ":8443", TLSConfig: certs.ServerConfig()}
+//	log.Fatal(srv.ListenAndServeTLS("", ""))
+//
+// The secrets are watched with a secrets.Watcher; connections accepted or
+// made after a rotation use the new certificate and trust the new CAs, while
+// established ones are left alone.
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/brwse/go-secrets"
+)
+
+// Source names the secrets that make up a TLS identity, in the syntax of the
+// `secret` struct tag. Each holds PEM data. Any may be empty: a client that
+// only verifies servers needs no Cert, and a server that does not verify
+// clients needs no CA.
+type Source struct {
+	// Cert is the certificate chain, leaf first.
+	Cert string
+	// Key is the private key of the leaf. If it is empty, the key is read
+	// from Cert, for secrets that hold both.
+	Key string
+	// CA is the bundle of CA certificates peers are verified against: the
+	// roots of servers for ClientConfig, and of client certificates for
+	// ServerConfig.
+	CA string
+}
+
+// Option configures a Certs.
+type Option func(*config)
+
+type config struct {
+	watchOpts []secrets.WatchOption
+	onError   func(error)
+}
+
+// WithWatchOptions configures the Watcher the secrets are watched with, such
+// as with secrets.WatchInterval.
+func WithWatchOptions(opts ...secrets.WatchOption) Option {
+	return func(c *config) {
+		c.watchOpts = append(c.watchOpts, opts...)
+	}
+}
+
+// WithOnError calls fn when rotated secrets cannot be loaded, such as when a
+// new certificate does not match the key, or the key has not been rotated
+// yet. The previous certificate and CAs stay in use.
+func WithOnError(fn func(error)) Option {
+	return func(c *config) {
+		c.onError = fn
+	}
+}
+
+// Certs holds the certificate and CA pool loaded from a Source, and reloads
+// them when the secrets rotate. It is safe for concurrent use.
+type Certs struct {
+	src     Source
+	w       *secrets.Watcher
+	val     reflect.Value // *struct{ Cert, Key, CA []byte }
+	onError func(error)
+	done    chan struct{}
+
+	mu   sync.Mutex // serializes loads
+	cert atomic.Pointer[tls.Certificate]
+	cas  atomic.Pointer[x509.CertPool]
+}
+
+// New resolves the secrets of src with r and watches them for rotation until
+// Stop is called or ctx is done.
+// It returns an error if a secret cannot be resolved, or does not hold a
+// valid certificate, key, or CA bundle.
+func New(ctx context.Context, r *secrets.Resolver, src Source, opts ...Option) (*Certs, error) {
+	if src.Cert == "" && src.CA == "" {
+		return nil, errors.New("tlsutil: a certificate or a CA is required")
+	}
+	if src.Cert == "" && src.Key != "" {
+		return nil, errors.New("tlsutil: a key requires a certificate")
+	}
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	// The Watcher watches a struct, so build one whose tags are the URIs.
+	bytesType := reflect.TypeFor[[]byte]()
+	var fields []reflect.StructField
+	for _, f := range []struct{ name, uri string }{{"Cert", src.Cert}, {"Key", src.Key}, {"CA", src.CA}} {
+		field := reflect.StructField{Name: f.name, Type: bytesType}
+		if f.uri != "" {
+			field.Tag = reflect.StructTag("secret:" + strconv.Quote(f.uri))
+		}
+		fields = append(fields, field)
+	}
+	val := reflect.New(reflect.StructOf(fields))
+
+	w, err := r.Watch(ctx, val.Interface(), c.watchOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("tlsutil: %w", err)
+	}
+	certs := &Certs{src: src, w: w, val: val, onError: c.onError, done: make(chan struct{})}
+	if err := certs.load(); err != nil {
+		w.Stop()
+		return nil, err
+	}
+	go certs.run()
+	return certs, nil
+}
+
+// run reloads the secrets on every change until the Watcher stops.
+func (c *Certs) run() {
+	defer close(c.done)
+	for range c.w.Changes() {
+		if err := c.load(); err != nil && c.onError != nil {
+			c.onError(err)
+		}
+	}
+}
+
+// load parses the watched secrets and, if they are valid, makes them the
+// ones in use.
+func (c *Certs) load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.w.RLock()
+	v := c.val.Elem()
+	certPEM := v.FieldByName("Cert").Bytes()
+	keyPEM := v.FieldByName("Key").Bytes()
+	caPEM := v.FieldByName("CA").Bytes()
+	c.w.RUnlock()
+
+	var cert *tls.Certificate
+	if c.src.Cert != "" {
+		if c.src.Key == "" {
+			keyPEM = certPEM
+		}
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("tlsutil: %s: %w", c.src.Cert, err)
+		}
+		cert = &pair
+	}
+	var cas *x509.CertPool
+	if c.src.CA != "" {
+		cas = x509.NewCertPool()
+		if !cas.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("tlsutil: %s: no PEM certificates", c.src.CA)
+		}
+	}
+	c.cert.Store(cert)
+	c.cas.Store(cas)
+	return nil
+}
+
+// Refresh re-resolves the secrets now, rather than at the next poll, and
+// loads them. It returns an error if they cannot be resolved or loaded, in
+// which case the previous ones stay in use.
+func (c *Certs) Refresh(ctx context.Context) error {
+	if err := c.w.Refresh(ctx); err != nil {
+		return fmt.Errorf("tlsutil: %w", err)
+	}
+	return c.load()
+}
+
+// Stop stops watching the secrets. The certificate and CAs last loaded stay
+// in use.
+func (c *Certs) Stop() {
+	c.w.Stop()
+	<-c.done
+}
+
+// Certificate returns the certificate in use, or nil if the Source has no
+// Cert.
+func (c *Certs) Certificate() *tls.Certificate {
+	return c.cert.Load()
+}
+
+// CAs returns the CA pool in use, or nil if the Source has no CA.
+func (c *Certs) CAs() *x509.CertPool {
+	return c.cas.Load()
+}
+
+// GetCertificate returns the certificate in use, for tls.Config's
+// GetCertificate.
+func (c *Certs) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if cert := c.cert.Load(); cert != nil {
+		return cert, nil
+	}
+	return nil, errors.New("tlsutil: no certificate")
+}
+
+// GetClientCertificate returns the certificate in use, for tls.Config's
+// GetClientCertificate. Without one, it returns an empty certificate, so
+// that the server decides whether to continue.
+func (c *Certs) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if cert := c.cert.Load(); cert != nil {
+		return cert, nil
+	}
+	return &tls.Certificate{}, nil
+}
+
+// ServerConfig returns a server configuration that presents the certificate
+// in use and, if the Source has a CA, requires client certificates signed by
+// the CAs in use. The caller may modify it, such as to set NextProtos, but
+// GetConfigForClient is used to apply the CAs.
+func (c *Certs) ServerConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.GetCertificate,
+	}
+	if c.src.CA != "" {
+		cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			clientCfg := cfg.Clone()
+			clientCfg.GetConfigForClient = nil
+			clientCfg.ClientAuth = tls.RequireAndVerifyClientCert
+			clientCfg.ClientCAs = c.cas.Load()
+			return clientCfg, nil
+		}
+	}
+	return cfg
+}
+
+// ClientConfig returns a client configuration that presents the certificate
+// in use, if the Source has a Cert, and verifies servers against the CAs in
+// use, if it has a CA, or the system roots otherwise.
+//
+// As tls.Config reads RootCAs when a connection is made but offers no
+// callback for it, verification against the CAs in use is done by
+// VerifyConnection, with InsecureSkipVerify set to turn off the built-in
+// verification. Do not clear VerifyConnection. As with the built-in
+// verification, ServerName must be set, by the caller or by a dialer such
+// as tls.Dial, or the handshake fails.
+func (c *Certs) ClientConfig() *tls.Config {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.src.Cert != "" {
+		cfg.GetClientCertificate = c.GetClientCertificate
+	}
+	if c.src.CA != "" {
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = c.verifyServer
+	}
+	return cfg
+}
+
+// verifyServer verifies the certificate chain of a server as the built-in
+// verification does, against the CAs in use.
+func (c *Certs) verifyServer(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("tlsutil: server presented no certificate")
+	}
+	if cs.ServerName == "" {
+		// x509 skips the host name check without a name, which would
+		// accept any certificate the CAs signed.
+		return errors.New("tlsutil: ServerName must be specified to verify the server")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         c.cas.Load(),
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
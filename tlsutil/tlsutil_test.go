@@ -0,0 +1,243 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+	"github.com/brwse/go-secrets/secrettest"
+)
+
+// issuer is a CA that issues test certificates.
+type issuer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  string
+}
+
+func newIssuer(t *testing.T, name string) *issuer {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	key, certPEM := sign(t, tmpl, nil)
+	block, _ := pem.Decode([]byte(certPEM))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &issuer{cert: cert, key: key, pem: certPEM}
+}
+
+// issue returns the PEM certificate and key of a leaf for name.
+func (ca *issuer) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM string) {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	key, certPEM := sign(t, tmpl, ca)
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certPEM, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+}
+
+// sign creates a key and a certificate for it from tmpl, signed by ca, or
+// self-signed if ca is nil.
+func sign(t *testing.T, tmpl *x509.Certificate, ca *issuer) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, signer := tmpl, key
+	if ca != nil {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// handshake connects a client with clientCfg to a server with serverCfg
+// over loopback TCP, naming the server serverName. Unlike net.Pipe, TCP
+// buffers writes, so a side that fails can send its alert while the other is
+// writing.
+func handshake(serverCfg, clientCfg *tls.Config, serverName string) error {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	serverErr := make(chan error, 1)
+	go func() {
+		sc, err := ln.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer sc.Close()
+		serverErr <- tls.Server(sc, serverCfg).Handshake()
+	}()
+	cc, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		return err
+	}
+	clientCfg = clientCfg.Clone()
+	clientCfg.ServerName = serverName
+	err = tls.Client(cc, clientCfg).Handshake()
+	cc.Close()
+	if sErr := <-serverErr; err == nil {
+		err = sErr
+	}
+	return err
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newIssuer(t, "ca-1")
+	serverCert, serverKey := ca.issue(t, "server.test", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, "client.test", x509.ExtKeyUsageClientAuth)
+	p := secrettest.NewProvider(map[string]string{
+		"server-cert": serverCert,
+		"server-key":  serverKey,
+		"client-pem":  clientCert + clientKey,
+		"ca":          ca.pem,
+	})
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	ctx := context.Background()
+
+	server, err := New(ctx, r, Source{Cert: "server-cert", Key: "server-key", CA: "ca"})
+	if err != nil {
+		t.Fatalf("New(server): %v", err)
+	}
+	defer server.Stop()
+	client, err := New(ctx, r, Source{Cert: "client-pem", CA: "ca"})
+	if err != nil {
+		t.Fatalf("New(client): %v", err)
+	}
+	defer client.Stop()
+
+	if err := handshake(server.ServerConfig(), client.ClientConfig(), "server.test"); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	// Without a server name, the server's identity cannot be verified.
+	if err := handshake(server.ServerConfig(), client.ClientConfig(), ""); err == nil {
+		t.Error("handshake without a server name succeeded")
+	}
+
+	// A client without a certificate is refused.
+	anon, err := New(ctx, r, Source{CA: "ca"})
+	if err != nil {
+		t.Fatalf("New(anonymous): %v", err)
+	}
+	defer anon.Stop()
+	if err := handshake(server.ServerConfig(), anon.ClientConfig(), "server.test"); err == nil {
+		t.Error("handshake without a client certificate succeeded")
+	}
+
+	// A server signed by another CA is refused.
+	other := newIssuer(t, "other")
+	otherCert, otherKey := other.issue(t, "server.test", x509.ExtKeyUsageServerAuth)
+	p.Put("server-cert", otherCert)
+	p.Put("server-key", otherKey)
+	if err := server.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if err := handshake(server.ServerConfig(), client.ClientConfig(), "server.test"); err == nil {
+		t.Error("handshake with an untrusted server succeeded")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	ca := newIssuer(t, "ca-1")
+	cert1, key1 := ca.issue(t, "server.test", x509.ExtKeyUsageServerAuth)
+	p := secrettest.NewProvider(map[string]string{"cert": cert1, "key": key1, "ca": ca.pem})
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	ctx := context.Background()
+
+	errs := make(chan error, 1)
+	server, err := New(ctx, r, Source{Cert: "cert", Key: "key"},
+		WithWatchOptions(secrets.WatchInterval(10*time.Millisecond)),
+		WithOnError(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer server.Stop()
+	cfg := server.ServerConfig()
+	first := server.Certificate()
+
+	// A certificate that does not match the key is not used.
+	cert2, key2 := ca.issue(t, "server.test", x509.ExtKeyUsageServerAuth)
+	p.Put("cert", cert2)
+	if err := <-errs; err == nil {
+		t.Error("OnError called with nil")
+	}
+	if server.Certificate() != first {
+		t.Error("certificate replaced by one that does not match the key")
+	}
+
+	// Once the key follows, the new pair is picked up by polling.
+	p.Put("key", key2)
+	deadline := time.Now().Add(5 * time.Second)
+	for server.Certificate() == first && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	got, err := cfg.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil || got == first {
+		t.Fatalf("GetCertificate after rotation = %p, %v; want the new certificate", got, err)
+	}
+	leaf, _ := x509.ParseCertificate(got.Certificate[0])
+	if want, _ := pem.Decode([]byte(cert2)); leaf == nil || string(leaf.Raw) != string(want.Bytes) {
+		t.Error("GetCertificate returned another certificate")
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	p := secrettest.NewProvider(map[string]string{"cert": "not PEM", "ca": "not PEM"})
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	ctx := context.Background()
+	for name, src := range map[string]Source{
+		"empty":       {},
+		"key only":    {Key: "cert"},
+		"bad cert":    {Cert: "cert"},
+		"bad CA":      {CA: "ca"},
+		"missing":     {Cert: "missing"},
+		"missing key": {Cert: "cert", Key: "missing"},
+	} {
+		if c, err := New(ctx, r, src); err == nil {
+			c.Stop()
+			t.Errorf("%s: New succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Transform converts a secret value, for example by decoding or
+// decompressing it. Fields tagged with `transform=name1|name2` pass their
+// value through the named transforms in order before it is converted to the
+// field's type.
+type Transform func(data []byte) ([]byte, error)
+
+// builtinTransforms are the transforms available to every Resolver:
+//
+//   - trim removes leading and trailing whitespace, such as the newline at
+//     the end of a file.
+//   - base64 decodes standard base64, ignoring surrounding whitespace.
+//   - base64url decodes unpadded URL-safe base64, ignoring surrounding
+//     whitespace.
+//   - hex decodes hexadecimal, ignoring surrounding whitespace.
+//   - gunzip decompresses gzip data.
+var builtinTransforms = map[string]Transform{
+	"trim": func(data []byte) ([]byte, error) {
+		return bytes.TrimSpace(data), nil
+	},
+	"base64": decodeBase64,
+	"base64url": func(data []byte) ([]byte, error) {
+		data = bytes.TrimSpace(data)
+		out := make([]byte, base64.RawURLEncoding.DecodedLen(len(data)))
+		n, err := base64.RawURLEncoding.Decode(out, data)
+		if err != nil {
+			return nil, err
+		}
+		return out[:n], nil
+	},
+	"hex": func(data []byte) ([]byte, error) {
+		data = bytes.TrimSpace(data)
+		out := make([]byte, hex.DecodedLen(len(data)))
+		n, err := hex.Decode(out, data)
+		if err != nil {
+			return nil, err
+		}
+		return out[:n], nil
+	},
+	"gunzip": func(data []byte) ([]byte, error) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	},
+}
+
+// WithTransform registers t under name for fields tagged with
+// `transform=name`, replacing any built-in or earlier transform of that name.
+// Built-in transforms are trim, base64, base64url, hex, and gunzip. name must
+// not contain ',' or '|'.
+//
+//	r := secrets.NewResolver(
+//		secrets.WithTransform("rot13", rot13),
+//	)
+//
+//	type Config struct {
+//		Token string `secret:"token,transform=trim|rot13"`
+//	}
+func WithTransform(name string, t Transform) Option {
+	return func(c *resolverConfig) {
+		if c.transforms == nil {
+			c.transforms = make(map[string]Transform)
+		}
+		c.transforms[name] = t
+	}
+}
+
+// transformFor returns the transform registered under name, or else the
+// built-in transform of that name.
+func (r *Resolver) transformFor(name string) (Transform, bool) {
+	if t, ok := r.cfg.transforms[name]; ok {
+		return t, true
+	}
+	t, ok := builtinTransforms[name]
+	return t, ok
+}
+
+// transform passes data through the named transforms in order.
+func (r *Resolver) transform(names []string, data []byte) ([]byte, error) {
+	for _, name := range names {
+		t, ok := r.transformFor(name)
+		if !ok {
+			return nil, fmt.Errorf("transform: unknown transform %q", name)
+		}
+		var err error
+		if data, err = t(data); err != nil {
+			return nil, fmt.Errorf("transform %s: %w", name, err)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,117 @@
+package secrets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestResolve_Transform(t *testing.T) {
+	compressed := base64.StdEncoding.EncodeToString(gzipped(t, `{"user":"admin"}`))
+	p := &mockProvider{data: map[string][]byte{
+		"bundle": []byte(compressed + "\n"),
+		"hex":    []byte("68656c6c6f\n"),
+		"url":    []byte(base64.RawURLEncoding.EncodeToString([]byte{0xfb, 0xff})),
+		"padded": []byte("  s3cret \n"),
+		"json":   []byte(`{"cert":"` + base64.StdEncoding.EncodeToString([]byte("PEM")) + `"}`),
+	}}
+	r := NewResolver(WithDefault(p))
+
+	var cfg struct {
+		Bundle string `secret:"bundle,transform=base64|gunzip"`
+		Hex    string `secret:"hex,transform=hex"`
+		URL    []byte `secret:"url,transform=base64url"`
+		Padded string `secret:"padded,transform=trim"`
+		Cert   string `secret:"json#cert,transform=base64"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.Bundle != `{"user":"admin"}` {
+		t.Errorf("Bundle = %q", cfg.Bundle)
+	}
+	if cfg.Hex != "hello" {
+		t.Errorf("Hex = %q, want hello", cfg.Hex)
+	}
+	if !bytes.Equal(cfg.URL, []byte{0xfb, 0xff}) {
+		t.Errorf("URL = %x, want fbff", cfg.URL)
+	}
+	if cfg.Padded != "s3cret" {
+		t.Errorf("Padded = %q, want s3cret", cfg.Padded)
+	}
+	if cfg.Cert != "PEM" {
+		t.Errorf("Cert = %q, want PEM", cfg.Cert)
+	}
+}
+
+func TestResolve_CustomTransform(t *testing.T) {
+	p := &mockProvider{data: map[string][]byte{"token": []byte(" abc\n")}}
+	upper := func(data []byte) ([]byte, error) { return bytes.ToUpper(data), nil }
+	r := NewResolver(WithDefault(p), WithTransform("upper", upper))
+
+	var cfg struct {
+		Token string `secret:"token,transform=trim|upper"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.Token != "ABC" {
+		t.Errorf("Token = %q, want ABC", cfg.Token)
+	}
+}
+
+func TestResolve_TransformOverridesBuiltin(t *testing.T) {
+	p := &mockProvider{data: map[string][]byte{"key": []byte(" v ")}}
+	keep := func(data []byte) ([]byte, error) { return data, nil }
+	r := NewResolver(WithDefault(p), WithTransform("trim", keep))
+
+	var cfg struct {
+		Val string `secret:"key,transform=trim"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.Val != " v " {
+		t.Errorf("Val = %q, want the registered transform to replace the built-in", cfg.Val)
+	}
+}
+
+func TestResolve_TransformErrors(t *testing.T) {
+	p := &mockProvider{data: map[string][]byte{"key": []byte("not gzip")}}
+	r := NewResolver(WithDefault(p))
+
+	var unknown struct {
+		Val string `secret:"key,transform=trim|rot13"`
+	}
+	err := r.Validate(&unknown)
+	if err == nil || !strings.Contains(err.Error(), `field Val: unknown transform "rot13"`) {
+		t.Errorf("Validate: expected unknown transform error, got %v", err)
+	}
+
+	var bad struct {
+		Val string `secret:"key,transform=gunzip"`
+	}
+	err = r.Resolve(context.Background(), &bad)
+	if err == nil || !strings.Contains(err.Error(), "transform gunzip") {
+		t.Errorf("Resolve: expected gunzip error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "not gzip") {
+		t.Errorf("error leaks the secret value: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package secrets
+
+import "context"
+
+// WithUserAgent sets an application identifier, such as "billing-api/1.4.2",
+// that providers add to the User-Agent of their API requests, so that cloud
+// audit logs attribute secret reads to the application rather than to a
+// generic SDK. The Resolver passes it to providers in the context of every
+// fetch; a user agent already set on the context with ContextWithUserAgent
+// takes precedence.
+//
+// Providers honor it where their SDK allows setting the User-Agent per
+// request (awssm, awsps, azkv, vault, and k8s). gcpsm fixes its User-Agent
+// when the client is created; use gcpsm.WithUserAgent instead.
+func WithUserAgent(ua string) Option {
+	return func(c *resolverConfig) {
+		c.userAgent = ua
+	}
+}
+
+type userAgentKey struct{}
+
+// ContextWithUserAgent returns a copy of ctx carrying ua as the application
+// user agent for provider API requests.
+func ContextWithUserAgent(ctx context.Context, ua string) context.Context {
+	return context.WithValue(ctx, userAgentKey{}, ua)
+}
+
+// UserAgentFromContext returns the application user agent carried by ctx, or
+// the empty string. Providers call it to tag their API requests.
+func UserAgentFromContext(ctx context.Context) string {
+	ua, _ := ctx.Value(userAgentKey{}).(string)
+	return ua
+}
@@ -0,0 +1,57 @@
+package secrets
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// uaProvider records the user agent carried by each fetch context.
+type uaProvider struct {
+	mu  sync.Mutex
+	got []string
+}
+
+func (p *uaProvider) Get(ctx context.Context, key string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.got = append(p.got, UserAgentFromContext(ctx))
+	return []byte("value"), nil
+}
+
+func TestWithUserAgent(t *testing.T) {
+	p := &uaProvider{}
+	r := NewResolver(WithDefault(p), WithUserAgent("billing-api/1.4.2"))
+
+	var cfg struct {
+		A string `secret:"a"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	// A user agent on the context takes precedence.
+	ctx := ContextWithUserAgent(context.Background(), "billing-worker/2.0")
+	if err := r.Resolve(ctx, &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	want := []string{"billing-api/1.4.2", "billing-worker/2.0"}
+	if len(p.got) != len(want) || p.got[0] != want[0] || p.got[1] != want[1] {
+		t.Errorf("user agents = %q, want %q", p.got, want)
+	}
+}
+
+func TestWithUserAgent_Unset(t *testing.T) {
+	p := &uaProvider{}
+	r := NewResolver(WithDefault(p))
+
+	var cfg struct {
+		A string `secret:"a"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if len(p.got) != 1 || p.got[0] != "" {
+		t.Errorf("user agents = %q, want one empty", p.got)
+	}
+}
@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+)
+
+// Value holds the latest resolved snapshot of a watched struct of type T.
+//
+// Unlike Watcher, which updates the struct in place and requires readers to
+// hold RLock, a Value publishes a new copy on every change. Readers call Load
+// and may keep using the returned snapshot for as long as they like, such as
+// for the duration of a request, without any locking.
+type Value[T any] struct {
+	ptr atomic.Pointer[T]
+	w   *Watcher
+}
+
+// WatchValue resolves a new T and watches it like Resolver.Watch, publishing
+// an immutable snapshot after the initial resolve and after every change.
+// T must be a struct type. The returned Value must be stopped via Stop() or
+// context cancellation.
+func WatchValue[T any](ctx context.Context, r *Resolver, opts ...WatchOption) (*Value[T], error) {
+	v := &Value[T]{}
+	working := new(T)
+	publish := func() {
+		v.ptr.Store(cloneStruct(working))
+	}
+	opts = append(opts, func(c *watcherConfig) {
+		c.onUpdate = publish
+	})
+	w, err := r.Watch(ctx, working, opts...)
+	if err != nil {
+		return nil, err
+	}
+	v.w = w
+	return v, nil
+}
+
+// Load returns the latest snapshot. The snapshot is never modified after it
+// is published; callers must not modify it either.
+func (v *Value[T]) Load() *T {
+	return v.ptr.Load()
+}
+
+// Changes returns a channel that receives a ChangeEvent for every changed
+// field. When an event is received, Load already returns the new snapshot.
+// The channel is closed when the Value is stopped or the context is cancelled.
+func (v *Value[T]) Changes() <-chan ChangeEvent {
+	return v.w.Changes()
+}
+
+// Stop stops watching and closes the Changes channel. Load keeps returning
+// the last snapshot.
+func (v *Value[T]) Stop() {
+	v.w.Stop()
+}
+
+// cloneStruct returns a copy of *src that shares no structs the Watcher may
+// later modify: nested structs reached through pointers are copied too.
+// Secret values themselves (strings, byte slices) are replaced rather than
+// modified on update, so they can be shared.
+func cloneStruct[T any](src *T) *T {
+	dst := new(T)
+	dv := reflect.ValueOf(dst).Elem()
+	dv.Set(reflect.ValueOf(src).Elem())
+	if dv.Kind() == reflect.Struct {
+		clonePointers(dv)
+	}
+	return dst
+}
+
+// clonePointers replaces each exported pointer-to-struct field of the struct
+// sv, including those in nested and embedded structs, with a pointer to a copy.
+func clonePointers(sv reflect.Value) {
+	st := sv.Type()
+	for i := range st.NumField() {
+		field := st.Field(i)
+		fv := sv.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		switch {
+		case fv.Kind() == reflect.Struct:
+			clonePointers(fv)
+		case fv.Kind() == reflect.Pointer && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct && fv.CanSet():
+			cp := reflect.New(fv.Elem().Type())
+			cp.Elem().Set(fv.Elem())
+			clonePointers(cp.Elem())
+			fv.Set(cp)
+		}
+	}
+}
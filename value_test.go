@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatchValue(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("key", []byte("initial"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	v, err := WatchValue[Config](ctx, r, WatchInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("WatchValue: %v", err)
+	}
+	defer v.Stop()
+
+	before := v.Load()
+	if before.Val != "initial" {
+		t.Fatalf("Load().Val = %q, want %q", before.Val, "initial")
+	}
+
+	store.Store("key", []byte("updated"))
+
+	select {
+	case <-v.Changes():
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change event")
+	}
+	if got := v.Load().Val; got != "updated" {
+		t.Errorf("Load().Val = %q, want %q", got, "updated")
+	}
+	if before.Val != "initial" {
+		t.Errorf("earlier snapshot was modified: Val = %q", before.Val)
+	}
+}
+
+func TestWatchValue_NestedPointerSnapshotsAreIndependent(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("host", []byte("db1"))
+	r := NewResolver(WithDefault(store))
+
+	type DB struct {
+		Host string `secret:"host"`
+	}
+	type Config struct {
+		DB *DB
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	v, err := WatchValue[Config](ctx, r, WatchInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("WatchValue: %v", err)
+	}
+	defer v.Stop()
+
+	before := v.Load()
+	store.Store("host", []byte("db2"))
+
+	select {
+	case <-v.Changes():
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change event")
+	}
+	if got := v.Load().DB.Host; got != "db2" {
+		t.Errorf("Load().DB.Host = %q, want %q", got, "db2")
+	}
+	if before.DB.Host != "db1" {
+		t.Errorf("earlier snapshot was modified: DB.Host = %q", before.DB.Host)
+	}
+}
+
+func TestWatchValue_InitialError(t *testing.T) {
+	r := NewResolver(WithDefault(&syncMapProvider{}))
+
+	type Config struct {
+		Val string `secret:"missing"`
+	}
+	if _, err := WatchValue[Config](context.Background(), r); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,184 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// ErrTokenExpired is reported to the WithOnTokenError callback when a token
+// given with WithToken or VAULT_TOKEN reaches the end of its lease and cannot
+// be renewed further. Without WithAuthMethod there is no way to obtain a new
+// one, so requests fail from then on.
+var ErrTokenExpired = errors.New("vault: token expired and no auth method is configured")
+
+// tokenRenewer keeps the SDK client's token alive in the background: it
+// renews the token's lease before it expires and, with an auth method, logs
+// in again once the token can no longer be renewed.
+type tokenRenewer struct {
+	client  *vaultapi.Client
+	auth    vaultapi.AuthMethod // nil for a static token
+	onError func(error)
+	timeout time.Duration // bounds each login or lookup; zero for none
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// startRenewer starts renewing the token of client. login is the result of
+// the initial login with auth, or nil for a static token.
+func startRenewer(client *vaultapi.Client, auth vaultapi.AuthMethod, login *vaultapi.Secret, onError func(error), timeout time.Duration) *tokenRenewer {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &tokenRenewer{
+		client:  client,
+		auth:    auth,
+		onError: onError,
+		timeout: timeout,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go t.run(ctx, login)
+	return t
+}
+
+// stop stops the renewer and waits for it to exit.
+func (t *tokenRenewer) stop() {
+	t.cancel()
+	<-t.done
+}
+
+func (t *tokenRenewer) run(ctx context.Context, secret *vaultapi.Secret) {
+	defer close(t.done)
+	failures := 0
+	for {
+		if secret == nil {
+			var err error
+			secret, err = t.authenticate(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				t.report(err)
+				failures++
+				if !sleep(ctx, retryDelay(failures)) {
+					return
+				}
+				continue
+			}
+			failures = 0
+			if secret.Auth.LeaseDuration <= 0 {
+				return // the token never expires
+			}
+		}
+
+		err := t.watch(ctx, secret)
+		secret = nil
+		if ctx.Err() != nil {
+			return
+		}
+		if t.auth == nil {
+			if err == nil {
+				err = ErrTokenExpired
+			}
+			t.report(err)
+			return
+		}
+		if err != nil {
+			t.report(err)
+		}
+	}
+}
+
+// authenticate logs in with the auth method, or looks up the static token,
+// and returns the token's lease.
+func (t *tokenRenewer) authenticate(ctx context.Context) (*vaultapi.Secret, error) {
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+	if t.auth != nil {
+		return login(ctx, t.client, t.auth)
+	}
+	s, err := t.client.Auth().Token().LookupSelfWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("vault: look up token: %w", providerError(err))
+	}
+	ttl, err := s.TokenTTL()
+	if err != nil {
+		return nil, fmt.Errorf("vault: look up token: %w", err)
+	}
+	renewable, err := s.TokenIsRenewable()
+	if err != nil {
+		return nil, fmt.Errorf("vault: look up token: %w", err)
+	}
+	return &vaultapi.Secret{Auth: &vaultapi.SecretAuth{
+		ClientToken:   t.client.Token(),
+		Renewable:     renewable,
+		LeaseDuration: int(ttl.Seconds()),
+	}}, nil
+}
+
+// watch renews the token of secret until it can no longer be renewed or ctx
+// is done. It returns the last renewal error if renewals failed until the
+// lease ran out.
+func (t *tokenRenewer) watch(ctx context.Context, secret *vaultapi.Secret) error {
+	w, err := t.client.NewLifetimeWatcher(&vaultapi.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		return fmt.Errorf("vault: renew token: %w", err)
+	}
+	go w.Start()
+	defer w.Stop()
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-w.DoneCh():
+		if err != nil {
+			return fmt.Errorf("vault: renew token: %w", providerError(err))
+		}
+		return nil
+	}
+}
+
+func (t *tokenRenewer) report(err error) {
+	if t.onError != nil {
+		t.onError(err)
+	}
+}
+
+// login authenticates client with auth, which sets the client's token, and
+// returns the login response.
+func login(ctx context.Context, client *vaultapi.Client, auth vaultapi.AuthMethod) (*vaultapi.Secret, error) {
+	s, err := client.Auth().Login(ctx, auth)
+	if err != nil {
+		return nil, fmt.Errorf("vault: log in: %w", providerError(err))
+	}
+	if s == nil || s.Auth == nil {
+		return nil, errors.New("vault: log in: response has no token")
+	}
+	return s, nil
+}
+
+// retryDelay returns how long to wait after the given number of consecutive
+// failed logins or lookups: 1s doubling up to 1m.
+func retryDelay(failures int) time.Duration {
+	d := time.Second
+	for i := 1; i < failures && d < time.Minute; i++ {
+		d *= 2
+	}
+	return min(d, time.Minute)
+}
+
+// sleep waits for d, reporting false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,188 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brwse/go-secrets"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// waitFor polls cond until it holds or 5 seconds have passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// tokenServer fakes the token endpoints of Vault, serving lookup-self with
+// the given ttl and renewability, and a KV v2 secret.
+type tokenServer struct {
+	*httptest.Server
+	renewals atomic.Int64
+	mu       sync.Mutex
+	tokens   []string // X-Vault-Token of KV requests
+}
+
+func newTokenServer(t *testing.T, ttl int, renewable bool) *tokenServer {
+	s := &tokenServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			fmt.Fprintf(w, `{"data": {"ttl": %d, "renewable": %t}}`, ttl, renewable)
+		case "/v1/auth/token/renew-self":
+			s.renewals.Add(1)
+			fmt.Fprintf(w, `{"auth": {"client_token": %q, "renewable": true, "lease_duration": 3600}}`, r.Header.Get("X-Vault-Token"))
+		default:
+			s.mu.Lock()
+			s.tokens = append(s.tokens, r.Header.Get("X-Vault-Token"))
+			s.mu.Unlock()
+			fmt.Fprint(w, `{"data": {"data": {"value": "s3cret"}, "metadata": {"version": 1}}}`)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestTokenRenewer_Renews(t *testing.T) {
+	srv := newTokenServer(t, 3600, true)
+	p, err := New(WithAddress(srv.URL), WithToken("t"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	waitFor(t, "renewal", func() bool { return srv.renewals.Load() > 0 })
+	if err := p.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestTokenRenewer_NeverExpires(t *testing.T) {
+	srv := newTokenServer(t, 0, false)
+	var reported atomic.Int64
+	p, err := New(WithAddress(srv.URL), WithToken("root"), WithOnTokenError(func(error) { reported.Add(1) }))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	waitFor(t, "renewer to exit", func() bool {
+		select {
+		case <-p.renewer.done:
+			return true
+		default:
+			return false
+		}
+	})
+	p.Close()
+	if n := srv.renewals.Load(); n != 0 {
+		t.Errorf("renewals = %d, want 0", n)
+	}
+	if n := reported.Load(); n != 0 {
+		t.Errorf("errors reported = %d, want 0", n)
+	}
+}
+
+func TestTokenRenewer_Expired(t *testing.T) {
+	srv := newTokenServer(t, 1, false)
+	errs := make(chan error, 1)
+	p, err := New(WithAddress(srv.URL), WithToken("t"), WithOnTokenError(func(err error) { errs <- err }))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrTokenExpired) {
+			t.Errorf("reported %v, want ErrTokenExpired", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expiry not reported")
+	}
+}
+
+func TestTokenRenewer_LookupError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors": ["permission denied"]}`)
+	}))
+	defer srv.Close()
+
+	errs := make(chan error, 1)
+	p, err := New(WithAddress(srv.URL), WithToken("t"), WithRetryPolicy(RetryPolicy{}),
+		WithOnTokenError(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	var pe *secrets.ErrProvider
+	if err := <-errs; !errors.As(err, &pe) || pe.StatusCode != http.StatusForbidden {
+		t.Errorf("reported %v, want ErrProvider with status 403", err)
+	}
+}
+
+// countingAuth is an AuthMethod that issues tokens t1, t2, ... with the given
+// lease, or fails with err.
+type countingAuth struct {
+	logins atomic.Int64
+	lease  int
+	err    error
+}
+
+func (a *countingAuth) Login(context.Context, *vaultapi.Client) (*vaultapi.Secret, error) {
+	n := a.logins.Add(1)
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &vaultapi.Secret{Auth: &vaultapi.SecretAuth{
+		ClientToken:   fmt.Sprintf("t%d", n),
+		LeaseDuration: a.lease,
+	}}, nil
+}
+
+func TestWithAuthMethod(t *testing.T) {
+	srv := newTokenServer(t, 0, false)
+	auth := &countingAuth{lease: 1}
+	p, err := New(WithAddress(srv.URL), WithAuthMethod(auth))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	if _, err := p.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	// The token is not renewable, so the renewer logs in again before it
+	// expires.
+	waitFor(t, "second login", func() bool { return auth.logins.Load() >= 2 })
+	if _, err := p.Get(context.Background(), "db"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.tokens[0] != "t1" || srv.tokens[1] == "t1" {
+		t.Errorf("tokens = %q, want t1 then a newer token", srv.tokens)
+	}
+}
+
+func TestWithAuthMethod_LoginError(t *testing.T) {
+	srv := newTokenServer(t, 0, false)
+	auth := &countingAuth{err: errors.New("bad role")}
+	if _, err := New(WithAddress(srv.URL), WithAuthMethod(auth)); err == nil {
+		t.Error("New succeeded despite a failed login")
+	}
+}
@@ -1,15 +1,22 @@
-// Package vault provides a secret provider that reads from HashiCorp Vault (KV v2).
+// Package vault provides a secret provider that reads from HashiCorp Vault:
+// KV v2 by default, or KV v1 or any logical path with WithEngine.
 package vault
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/brwse/go-secrets"
 	vaultapi "github.com/hashicorp/vault/api"
+	"golang.org/x/time/rate"
 )
 
 // Client abstracts the HashiCorp Vault KV v2 API.
@@ -21,6 +28,36 @@ type Client interface {
 	GetVersion(ctx context.Context, path string, version int) (map[string]any, error)
 }
 
+// CheckClient is implemented by Clients that can verify a secret exists
+// without retrieving its data. The default SDK client reads KV v2 metadata.
+type CheckClient interface {
+	GetMetadata(ctx context.Context, path string) error
+}
+
+// MetadataClient is implemented by Clients that can describe a secret without
+// retrieving its data. The default SDK client reads KV v2 metadata.
+type MetadataClient interface {
+	Metadata(ctx context.Context, path string) (secrets.Metadata, error)
+}
+
+// ListerClient is implemented by Clients that can enumerate secrets. The
+// default SDK client LISTs the KV v2 metadata path.
+type ListerClient interface {
+	// ListKeys returns the entries directly under the folder at path, which
+	// is empty or ends with "/". Entries that are folders end with "/".
+	// A folder that does not exist has no entries.
+	ListKeys(ctx context.Context, path string) ([]string, error)
+}
+
+// WriterClient is implemented by Clients that can write and delete secrets.
+// The default SDK client uses KV v2 Put and Delete.
+type WriterClient interface {
+	// Put writes data as a new version of the secret at the given path.
+	Put(ctx context.Context, path string, data map[string]any) error
+	// Delete soft-deletes the latest version of the secret at the given path.
+	Delete(ctx context.Context, path string) error
+}
+
 // ProviderOption configures the vault Provider.
 type ProviderOption func(*Provider)
 
@@ -38,7 +75,86 @@ func WithToken(token string) ProviderOption {
 	}
 }
 
-// WithMount configures the KV v2 mount path. Defaults to "secret".
+// WithAuthMethod authenticates the SDK client with m, such as AppRole or
+// Kubernetes auth from the github.com/hashicorp/vault/api/auth packages,
+// instead of a static token. New logs in, and the token renewer started by
+// New logs in again whenever the token can no longer be renewed. Ignored when
+// a Client is injected with WithClient.
+func WithAuthMethod(m vaultapi.AuthMethod) ProviderOption {
+	return func(p *Provider) {
+		p.auth = m
+	}
+}
+
+// WithOnTokenError registers fn to be called with each error of the
+// background token renewer: failed logins, lookups, and renewals, and
+// ErrTokenExpired when a static token cannot be kept alive. fn is called from
+// the renewer's goroutine. Without it, such errors surface only as failing
+// requests.
+func WithOnTokenError(fn func(error)) ProviderOption {
+	return func(p *Provider) {
+		p.onTokenError = fn
+	}
+}
+
+// Engine selects the secrets engine the SDK client reads from.
+type Engine int
+
+const (
+	// KVv2 reads keys as paths in a KV version 2 mount. It is the default.
+	KVv2 Engine = iota
+	// KVv1 reads keys as paths in a KV version 1 mount. KV v1 keeps no
+	// versions or metadata, so GetVersion (other than "current"), Check, and
+	// GetMetadata are not supported.
+	KVv1
+	// Logical reads keys as full logical paths, such as
+	// "database/creds/my-role" or "aws/creds/deploy", ignoring the mount, so
+	// that any secrets engine can be read. Its data key defaults to "", which
+	// returns the whole response data as JSON for fragments to select from:
+	// `secret:"vault://database/creds/my-role#password"`. Versions and
+	// metadata are not supported.
+	//
+	// Reading a dynamic secrets engine issues new credentials, with a new
+	// lease, on every read. Resolve such paths once, or through a
+	// secrets.CachedProvider, rather than watching them.
+	Logical
+)
+
+func (e Engine) String() string {
+	switch e {
+	case KVv2:
+		return "kv-v2"
+	case KVv1:
+		return "kv-v1"
+	case Logical:
+		return "logical"
+	default:
+		return "unknown"
+	}
+}
+
+// WithFullData makes Get return the whole data map of a secret as a JSON
+// object, as the k8s provider does, so that fields are selected with the
+// resolver's #fragment syntax instead of one data key per Provider:
+// `secret:"vault://prod/db#username"` and `secret:"vault://prod/db#password"`
+// read two fields of the same secret with a single request. Set then expects
+// a JSON object, which replaces the data map. It is equivalent to
+// WithDataKey("").
+func WithFullData() ProviderOption {
+	return WithDataKey("")
+}
+
+// WithEngine selects the secrets engine that keys are read from. Defaults to
+// KVv2. A Client injected with WithClient is responsible for reading from the
+// engine; WithEngine then only changes the default data key.
+func WithEngine(e Engine) ProviderOption {
+	return func(p *Provider) {
+		p.engine = e
+	}
+}
+
+// WithMount configures the KV mount path. Defaults to "secret". The Logical
+// engine ignores it.
 func WithMount(mount string) ProviderOption {
 	return func(p *Provider) {
 		p.mount = mount
@@ -46,11 +162,106 @@ func WithMount(mount string) ProviderOption {
 }
 
 // WithDataKey configures which key from the Vault data map to return.
-// Defaults to "value". If the secret data contains {"value": "s3cret"},
-// Get returns "s3cret".
+// Defaults to "value", or "" for the Logical engine. If the secret data
+// contains {"value": "s3cret"}, Get returns "s3cret". An empty key returns the
+// whole data map as a JSON object, and Set then expects a JSON object to
+// write.
 func WithDataKey(key string) ProviderOption {
 	return func(p *Provider) {
 		p.dataKey = key
+		p.dataKeySet = true
+	}
+}
+
+// RetryPolicy configures how the SDK client retries requests that fail with
+// a connection error or a 412, 429, or 5xx response.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	// Zero disables retries.
+	MaxRetries int
+	// MinWait and MaxWait bound the exponential backoff between retries.
+	// Zero keeps the SDK defaults (1s and 1.5s).
+	MinWait time.Duration
+	MaxWait time.Duration
+}
+
+// WithRetryPolicy configures request retries of the SDK client, replacing the
+// default of 2 retries (or VAULT_MAX_RETRIES). Fewer retries with shorter
+// waits suit aggressive watch intervals, where the next poll soon retries
+// anyway. Ignored when a Client is injected with WithClient.
+func WithRetryPolicy(policy RetryPolicy) ProviderOption {
+	return func(p *Provider) {
+		p.retry = &policy
+	}
+}
+
+// WithRateLimit limits the SDK client to rps requests per second with bursts
+// of up to burst requests, so that watchers cannot overload the Vault server
+// or trip its rate limit quotas. Requests wait for the limiter, respecting
+// context cancellation. Ignored when a Client is injected with WithClient.
+func WithRateLimit(rps float64, burst int) ProviderOption {
+	return func(p *Provider) {
+		p.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+}
+
+// WithTimeout bounds each Get, GetVersion, and Check call, including any
+// retries. Zero (the default) applies no timeout beyond the caller's context
+// and the SDK's 60 second HTTP timeout.
+func WithTimeout(d time.Duration) ProviderOption {
+	return func(p *Provider) {
+		p.timeout = d
+	}
+}
+
+// WithTLSConfig replaces the TLS configuration of the SDK client's HTTP
+// transport, including any set from VAULT_CACERT and related variables, with
+// a copy of cfg. WithCACert and WithClientCert are applied on top of it.
+// Ignored when a Client is injected with WithClient.
+func WithTLSConfig(cfg *tls.Config) ProviderOption {
+	return func(p *Provider) {
+		p.tlsConfig = cfg
+	}
+}
+
+// WithCACert makes the SDK client trust the PEM-encoded CA certificates in
+// the file at path, instead of the system roots, when verifying the Vault
+// server, as VAULT_CACERT does. Ignored when a Client is injected with
+// WithClient.
+func WithCACert(path string) ProviderOption {
+	return func(p *Provider) {
+		p.caCert = path
+	}
+}
+
+// WithClientCert makes the SDK client present the PEM-encoded certificate and
+// private key in certFile and keyFile to the Vault server, as
+// VAULT_CLIENT_CERT and VAULT_CLIENT_KEY do, for TLS certificate auth or
+// servers that require client certificates. Ignored when a Client is injected
+// with WithClient.
+func WithClientCert(certFile, keyFile string) ProviderOption {
+	return func(p *Provider) {
+		p.clientCert, p.clientKey = certFile, keyFile
+	}
+}
+
+// WithHTTPClient makes the SDK client send requests with a copy of hc, for
+// full control over its transport and proxying. It cannot be combined with
+// WithTLSConfig, WithCACert, or WithClientCert: configure hc's transport
+// instead. Ignored when a Client is injected with WithClient.
+func WithHTTPClient(hc *http.Client) ProviderOption {
+	return func(p *Provider) {
+		p.httpClient = hc
+	}
+}
+
+// WithUserAgent sets the User-Agent of requests made by the SDK client to ua,
+// such as "billing-api/1.4.2", for attribution in Vault's audit log. A user
+// agent set on the Resolver with secrets.WithUserAgent takes precedence.
+// Ignored when a Client is injected with WithClient.
+func WithUserAgent(ua string) ProviderOption {
+	return func(p *Provider) {
+		p.userAgent = ua
 	}
 }
 
@@ -61,19 +272,44 @@ func WithClient(c Client) ProviderOption {
 	}
 }
 
-// Provider reads secrets from HashiCorp Vault's KV v2 engine.
-// It implements secrets.Provider and secrets.VersionedProvider.
+// Provider reads secrets from HashiCorp Vault's KV v2 engine, or from the
+// engine selected with WithEngine.
+// It implements secrets.Provider, secrets.VersionedProvider,
+// secrets.CheckerProvider, secrets.MetadataProvider, secrets.ListerProvider,
+// and secrets.WriterProvider.
+//
+// A Provider that creates its own SDK client keeps the client's token alive
+// in a background goroutine, renewing its lease before it expires and, with
+// WithAuthMethod, logging in again once it can no longer be renewed. Close
+// stops the goroutine.
 type Provider struct {
-	address string
-	token   string
-	mount   string
-	dataKey string
-	client  Client
+	address   string
+	token     string
+	auth      vaultapi.AuthMethod
+	mount     string
+	engine    Engine
+	dataKey   string
+	userAgent string
+	client    Client
+	retry     *RetryPolicy
+	limiter   *rate.Limiter
+	timeout   time.Duration
+
+	tlsConfig  *tls.Config
+	caCert     string
+	clientCert string
+	clientKey  string
+	httpClient *http.Client
+
+	dataKeySet   bool // WithDataKey was given
+	onTokenError func(error)
+	renewer      *tokenRenewer // nil for an injected Client or without a token
 }
 
 // New creates a new HashiCorp Vault Provider with the given options.
 // If no Client is provided via WithClient, a real Vault SDK client is created
-// using DefaultConfig (reads VAULT_ADDR and VAULT_TOKEN from environment).
+// using DefaultConfig (reads VAULT_ADDR and VAULT_TOKEN from environment),
+// logging in first if WithAuthMethod is set, and a token renewer is started.
 func New(opts ...ProviderOption) (*Provider, error) {
 	p := &Provider{
 		mount:   "secret",
@@ -82,10 +318,13 @@ func New(opts ...ProviderOption) (*Provider, error) {
 	for _, opt := range opts {
 		opt(p)
 	}
+	if p.engine == Logical && !p.dataKeySet {
+		p.dataKey = ""
+	}
 	if p.client == nil {
-		cfg := vaultapi.DefaultConfig()
-		if p.address != "" {
-			cfg.Address = p.address
+		cfg, err := p.apiConfig()
+		if err != nil {
+			return nil, err
 		}
 		c, err := vaultapi.NewClient(cfg)
 		if err != nil {
@@ -94,16 +333,93 @@ func New(opts ...ProviderOption) (*Provider, error) {
 		if p.token != "" {
 			c.SetToken(p.token)
 		}
-		p.client = &sdkClient{kv: c.KVv2(p.mount)}
+		if p.userAgent != "" {
+			c.AddHeader("User-Agent", p.userAgent) // for login and renewal requests
+		}
+		var secret *vaultapi.Secret
+		if p.auth != nil {
+			ctx, cancel := p.withTimeout(context.Background())
+			secret, err = login(ctx, c, p.auth)
+			cancel()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if p.auth != nil || c.Token() != "" {
+			p.renewer = startRenewer(c, p.auth, secret, p.onTokenError, p.timeout)
+		}
+		p.client = &sdkClient{client: c, kv: c.KVv2(p.mount), mount: p.mount, engine: p.engine, userAgent: p.userAgent}
 	}
 	return p, nil
 }
 
-// extractValue extracts the configured data key from the Vault data map.
+// Close stops the token renewer. The Provider must not be used afterwards.
+func (p *Provider) Close() error {
+	if p.renewer != nil {
+		p.renewer.stop()
+	}
+	return nil
+}
+
+// apiConfig returns the SDK client configuration for the provider options.
+func (p *Provider) apiConfig() (*vaultapi.Config, error) {
+	cfg := vaultapi.DefaultConfig()
+	if p.address != "" {
+		cfg.Address = p.address
+	}
+	customTLS := p.tlsConfig != nil || p.caCert != "" || p.clientCert != "" || p.clientKey != ""
+	if p.httpClient != nil {
+		if customTLS {
+			return nil, errors.New("vault: WithHTTPClient cannot be combined with WithTLSConfig, WithCACert, or WithClientCert")
+		}
+		hc := *p.httpClient // the SDK sets CheckRedirect on its client
+		cfg.HttpClient = &hc
+	}
+	if p.tlsConfig != nil {
+		cfg.HttpClient.Transport.(*http.Transport).TLSClientConfig = p.tlsConfig.Clone()
+	}
+	if p.caCert != "" || p.clientCert != "" || p.clientKey != "" {
+		err := cfg.ConfigureTLS(&vaultapi.TLSConfig{CACert: p.caCert, ClientCert: p.clientCert, ClientKey: p.clientKey})
+		if err != nil {
+			return nil, fmt.Errorf("vault: configure TLS: %w", err)
+		}
+	}
+	if p.retry != nil {
+		cfg.MaxRetries = p.retry.MaxRetries
+		if p.retry.MinWait > 0 {
+			cfg.MinRetryWait = p.retry.MinWait
+		}
+		if p.retry.MaxWait > 0 {
+			cfg.MaxRetryWait = p.retry.MaxWait
+		}
+	}
+	if p.limiter != nil {
+		cfg.Limiter = p.limiter
+	}
+	return cfg, nil
+}
+
+// withTimeout derives a context bounded by the configured timeout.
+func (p *Provider) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, p.timeout)
+}
+
+// extractValue extracts the configured data key from the Vault data map, or
+// encodes the whole map as JSON if the data key is empty.
 func (p *Provider) extractValue(key string, data map[string]any) ([]byte, error) {
 	if data == nil {
 		return nil, fmt.Errorf("vault: secret %q: %w", key, secrets.ErrNotFound)
 	}
+	if p.dataKey == "" {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("vault: secret %q: %w", key, err)
+		}
+		return b, nil
+	}
 	val, ok := data[p.dataKey]
 	if !ok {
 		return nil, fmt.Errorf("vault: secret %q: data key %q not found", key, p.dataKey)
@@ -121,9 +437,11 @@ func (p *Provider) extractValue(key string, data map[string]any) ([]byte, error)
 // Get retrieves the latest version of the secret.
 // Returns secrets.ErrNotFound (wrapped) if the secret does not exist.
 func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
 	data, err := p.client.Get(ctx, key)
 	if err != nil {
-		return nil, fmt.Errorf("vault: secret %q: %w", key, err)
+		return nil, fmt.Errorf("vault: secret %q: %w", key, providerError(err))
 	}
 	return p.extractValue(key, data)
 }
@@ -140,38 +458,329 @@ func (p *Provider) GetVersion(ctx context.Context, key, version string) ([]byte,
 	if err != nil {
 		return nil, fmt.Errorf("vault: secret %q: invalid version %q: must be integer or \"current\"", key, version)
 	}
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
 	data, err := p.client.GetVersion(ctx, key, v)
 	if err != nil {
-		return nil, fmt.Errorf("vault: secret %q: %w", key, err)
+		return nil, fmt.Errorf("vault: secret %q: %w", key, providerError(err))
 	}
 	return p.extractValue(key, data)
 }
 
-// sdkClient wraps the real HashiCorp Vault KV v2 SDK.
+// Check verifies the secret exists and is accessible by reading its KV v2
+// metadata, without retrieving its data. This requires read access to the
+// metadata path rather than the data path.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement CheckClient
+// or the engine is not KVv2.
+func (p *Provider) Check(ctx context.Context, key string) error {
+	cc, ok := p.client.(CheckClient)
+	if !ok {
+		return fmt.Errorf("vault: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	if err := cc.GetMetadata(ctx, key); err != nil {
+		return fmt.Errorf("vault: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// GetMetadata describes the secret by reading its KV v2 metadata, without
+// retrieving its data. RotatedAt is the creation time of the current version
+// and Version its number; ExpiresAt is the current version's deletion time
+// when the mount or secret sets delete_version_after, and Tags holds the
+// secret's custom metadata. This requires read access to the metadata path.
+// Returns secrets.ErrNotFound (wrapped) if the secret does not exist, and
+// errors.ErrUnsupported (wrapped) if the Client does not implement
+// MetadataClient or the engine is not KVv2.
+func (p *Provider) GetMetadata(ctx context.Context, key string) (secrets.Metadata, error) {
+	mc, ok := p.client.(MetadataClient)
+	if !ok {
+		return secrets.Metadata{}, fmt.Errorf("vault: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	md, err := mc.Metadata(ctx, key)
+	if err != nil {
+		return secrets.Metadata{}, fmt.Errorf("vault: secret %q: %w", key, providerError(err))
+	}
+	return md, nil
+}
+
+// List returns the paths of the secrets that start with prefix, walking the
+// folders under the last "/" of prefix recursively. Each level is one LIST
+// request on the metadata path (the path itself for the KVv1 and Logical
+// engines), which requires the list capability.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// ListerClient.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	lc, ok := p.client.(ListerClient)
+	if !ok {
+		return nil, fmt.Errorf("vault: list %q: %w", prefix, errors.ErrUnsupported)
+	}
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	var keys []string
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		entries, err := lc.ListKeys(ctx, dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			full := dir + entry
+			if !strings.HasPrefix(full, prefix) && !strings.HasPrefix(prefix, full) {
+				continue
+			}
+			if strings.HasSuffix(entry, "/") {
+				if err := walk(full); err != nil {
+					return err
+				}
+			} else if strings.HasPrefix(full, prefix) {
+				keys = append(keys, full)
+			}
+		}
+		return nil
+	}
+	if err := walk(prefix[:strings.LastIndex(prefix, "/")+1]); err != nil {
+		return nil, fmt.Errorf("vault: list %q: %w", prefix, providerError(err))
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// Set writes a new version of the secret with the configured data key set to
+// value. Other keys of the latest version's data map are carried over, so
+// that setting "password" keeps a "username" stored alongside it; the read
+// and the write are separate requests, so concurrent writers to the same
+// secret can lose each other's keys. With an empty data key, value must be a
+// JSON object, which replaces the data map.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient.
+func (p *Provider) Set(ctx context.Context, key string, value []byte) error {
+	wc, ok := p.client.(WriterClient)
+	if !ok {
+		return fmt.Errorf("vault: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	if p.dataKey == "" {
+		var data map[string]any
+		if err := json.Unmarshal(value, &data); err != nil {
+			return fmt.Errorf("vault: secret %q: value must be a JSON object: %w", key, err)
+		}
+		if err := wc.Put(ctx, key, data); err != nil {
+			return fmt.Errorf("vault: secret %q: %w", key, providerError(err))
+		}
+		return nil
+	}
+	cur, err := p.client.Get(ctx, key)
+	if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		return fmt.Errorf("vault: secret %q: %w", key, providerError(err))
+	}
+	data := make(map[string]any, len(cur)+1)
+	for k, v := range cur {
+		data[k] = v
+	}
+	data[p.dataKey] = string(value)
+	if err := wc.Put(ctx, key, data); err != nil {
+		return fmt.Errorf("vault: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// Delete soft-deletes the latest version of the secret. Earlier versions, and
+// the deleted version itself, remain recoverable with Vault's undelete. The
+// KVv1 and Logical engines delete the path outright.
+// Deleting a secret that does not exist is not an error.
+// Returns errors.ErrUnsupported (wrapped) if the Client does not implement
+// WriterClient.
+func (p *Provider) Delete(ctx context.Context, key string) error {
+	wc, ok := p.client.(WriterClient)
+	if !ok {
+		return fmt.Errorf("vault: secret %q: %w", key, errors.ErrUnsupported)
+	}
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	if err := wc.Delete(ctx, key); err != nil {
+		return fmt.Errorf("vault: secret %q: %w", key, providerError(err))
+	}
+	return nil
+}
+
+// providerError wraps a *vaultapi.ResponseError in a *secrets.ErrProvider
+// carrying the HTTP status of the failed call. Other errors are returned
+// unchanged.
+func providerError(err error) error {
+	var re *vaultapi.ResponseError
+	if !errors.As(err, &re) {
+		return err
+	}
+	return &secrets.ErrProvider{StatusCode: re.StatusCode, Err: err}
+}
+
+// sdkClient wraps the real HashiCorp Vault SDK.
 type sdkClient struct {
-	kv *vaultapi.KVv2
+	client    *vaultapi.Client
+	kv        *vaultapi.KVv2
+	mount     string
+	engine    Engine
+	userAgent string
+}
+
+// clientFor returns the client for a request, setting the User-Agent to the
+// user agent carried by ctx, or else the configured one.
+func (c *sdkClient) clientFor(ctx context.Context) *vaultapi.Client {
+	ua := secrets.UserAgentFromContext(ctx)
+	if ua == "" {
+		ua = c.userAgent
+	}
+	if ua == "" {
+		return c.client
+	}
+	return c.client.WithRequestCallbacks(func(req *vaultapi.Request) {
+		if req.Headers == nil {
+			req.Headers = make(http.Header)
+		}
+		req.Headers.Set("User-Agent", ua)
+	})
+}
+
+// kvFor returns the KV v2 client for a request, as clientFor.
+func (c *sdkClient) kvFor(ctx context.Context) *vaultapi.KVv2 {
+	if client := c.clientFor(ctx); client != c.client {
+		return client.KVv2(c.mount)
+	}
+	return c.kv
+}
+
+// notFound maps the SDK's not-found errors to secrets.ErrNotFound.
+func notFound(err error) error {
+	var re *vaultapi.ResponseError
+	if errors.Is(err, vaultapi.ErrSecretNotFound) || errors.As(err, &re) && re.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return err
+}
+
+// unsupported reports an operation the engine does not support.
+func (c *sdkClient) unsupported(op string) error {
+	return fmt.Errorf("%s with the %v engine: %w", op, c.engine, errors.ErrUnsupported)
 }
 
 func (c *sdkClient) Get(ctx context.Context, path string) (map[string]any, error) {
-	s, err := c.kv.Get(ctx, path)
-	if err != nil {
-		var re *vaultapi.ResponseError
-		if errors.As(err, &re) && re.StatusCode == http.StatusNotFound {
+	switch c.engine {
+	case KVv1:
+		s, err := c.clientFor(ctx).KVv1(c.mount).Get(ctx, path)
+		if err != nil {
+			return nil, notFound(err)
+		}
+		return s.Data, nil
+	case Logical:
+		s, err := c.clientFor(ctx).Logical().ReadWithContext(ctx, path)
+		if err != nil {
+			return nil, notFound(err)
+		}
+		if s == nil {
 			return nil, fmt.Errorf("%w", secrets.ErrNotFound)
 		}
-		return nil, err
+		return s.Data, nil
+	}
+	s, err := c.kvFor(ctx).Get(ctx, path)
+	if err != nil {
+		return nil, notFound(err)
 	}
 	return s.Data, nil
 }
 
 func (c *sdkClient) GetVersion(ctx context.Context, path string, version int) (map[string]any, error) {
-	s, err := c.kv.GetVersion(ctx, path, version)
+	if c.engine != KVv2 {
+		return nil, &secrets.ErrVersioningNotSupported{Provider: "vault " + c.engine.String()}
+	}
+	s, err := c.kvFor(ctx).GetVersion(ctx, path, version)
 	if err != nil {
-		var re *vaultapi.ResponseError
-		if errors.As(err, &re) && re.StatusCode == http.StatusNotFound {
-			return nil, fmt.Errorf("%w", secrets.ErrNotFound)
+		return nil, notFound(err)
+	}
+	return s.Data, nil
+}
+
+func (c *sdkClient) GetMetadata(ctx context.Context, path string) error {
+	_, err := c.Metadata(ctx, path)
+	return err
+}
+
+func (c *sdkClient) Metadata(ctx context.Context, path string) (secrets.Metadata, error) {
+	if c.engine != KVv2 {
+		return secrets.Metadata{}, c.unsupported("metadata")
+	}
+	kvm, err := c.kvFor(ctx).GetMetadata(ctx, path)
+	if err != nil {
+		return secrets.Metadata{}, notFound(err)
+	}
+	md := secrets.Metadata{
+		CreatedAt: kvm.CreatedTime,
+		Version:   strconv.Itoa(kvm.CurrentVersion),
+	}
+	if v, ok := kvm.Versions[md.Version]; ok {
+		md.RotatedAt = v.CreatedTime
+		md.ExpiresAt = v.DeletionTime
+	}
+	if len(kvm.CustomMetadata) > 0 {
+		md.Tags = make(map[string]string, len(kvm.CustomMetadata))
+		for k, v := range kvm.CustomMetadata {
+			md.Tags[k] = fmt.Sprint(v)
 		}
+	}
+	return md, nil
+}
+
+func (c *sdkClient) Put(ctx context.Context, path string, data map[string]any) error {
+	switch c.engine {
+	case KVv1:
+		return c.clientFor(ctx).KVv1(c.mount).Put(ctx, path, data)
+	case Logical:
+		_, err := c.clientFor(ctx).Logical().WriteWithContext(ctx, path, data)
+		return err
+	}
+	_, err := c.kvFor(ctx).Put(ctx, path, data)
+	return err
+}
+
+func (c *sdkClient) Delete(ctx context.Context, path string) error {
+	switch c.engine {
+	case KVv1:
+		return c.clientFor(ctx).KVv1(c.mount).Delete(ctx, path)
+	case Logical:
+		_, err := c.clientFor(ctx).Logical().DeleteWithContext(ctx, path)
+		return err
+	}
+	return c.kvFor(ctx).Delete(ctx, path)
+}
+
+// ListKeys LISTs the KV v2 metadata path of path, or path itself in KV v1 and
+// logical engines.
+func (c *sdkClient) ListKeys(ctx context.Context, path string) ([]string, error) {
+	listPath := c.mount + "/metadata/" + path
+	switch c.engine {
+	case KVv1:
+		listPath = c.mount + "/" + path
+	case Logical:
+		listPath = path
+	}
+	s, err := c.clientFor(ctx).Logical().ListWithContext(ctx, listPath)
+	if err != nil {
 		return nil, err
 	}
-	return s.Data, nil
+	if s == nil {
+		return nil, nil
+	}
+	raw, _ := s.Data["keys"].([]any)
+	keys := make([]string, 0, len(raw))
+	for _, k := range raw {
+		if key, ok := k.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
 }
@@ -2,9 +2,26 @@ package vault
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/brwse/go-secrets"
 )
@@ -40,6 +57,48 @@ func (m *mockVaultClient) GetVersion(_ context.Context, path string, version int
 	return data, nil
 }
 
+func (m *mockVaultClient) GetMetadata(_ context.Context, path string) error {
+	if _, ok := m.secrets[path]; !ok {
+		return fmt.Errorf("%w", secrets.ErrNotFound)
+	}
+	return nil
+}
+
+func (m *mockVaultClient) Put(_ context.Context, path string, data map[string]any) error {
+	if m.secrets == nil {
+		m.secrets = make(map[string]map[int]map[string]any)
+	}
+	if m.secrets[path] == nil {
+		m.secrets[path] = make(map[int]map[string]any)
+	}
+	m.secrets[path][0] = data
+	return nil
+}
+
+func (m *mockVaultClient) Delete(_ context.Context, path string) error {
+	delete(m.secrets[path], 0)
+	return nil
+}
+
+func (m *mockVaultClient) ListKeys(_ context.Context, path string) ([]string, error) {
+	seen := make(map[string]bool)
+	var keys []string
+	for p := range m.secrets {
+		rest, ok := strings.CutPrefix(p, path)
+		if !ok {
+			continue
+		}
+		if i := strings.Index(rest, "/"); i >= 0 {
+			rest = rest[:i+1]
+		}
+		if !seen[rest] {
+			seen[rest] = true
+			keys = append(keys, rest)
+		}
+	}
+	return keys, nil
+}
+
 func TestGet_Existing(t *testing.T) {
 	mock := &mockVaultClient{
 		secrets: map[string]map[int]map[string]any{
@@ -141,3 +200,642 @@ func TestGetVersion_InvalidVersion(t *testing.T) {
 		t.Fatal("expected error, got nil")
 	}
 }
+
+func TestCheck(t *testing.T) {
+	mock := &mockVaultClient{
+		secrets: map[string]map[int]map[string]any{
+			"db-password": {0: {"value": "s3cret"}},
+		},
+	}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "db-password"); err != nil {
+		t.Errorf("Check existing: %v", err)
+	}
+	if err := p.Check(context.Background(), "nonexistent"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check missing: expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestCheck_Unsupported(t *testing.T) {
+	// Embedding hides the mock's CheckClient method.
+	p, err := New(WithClient(struct{ Client }{&mockVaultClient{}}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := p.Check(context.Background(), "db-password"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	mock := &mockVaultClient{secrets: map[string]map[int]map[string]any{
+		"prod/db/password":  {0: {"value": "a"}},
+		"prod/db-replica":   {0: {"value": "b"}},
+		"prod/api/key":      {0: {"value": "c"}},
+		"staging/db/secret": {0: {"value": "d"}},
+	}}
+	p, err := New(WithClient(mock))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for prefix, want := range map[string][]string{
+		"":         {"prod/api/key", "prod/db-replica", "prod/db/password", "staging/db/secret"},
+		"prod/":    {"prod/api/key", "prod/db-replica", "prod/db/password"},
+		"prod/db":  {"prod/db-replica", "prod/db/password"},
+		"prod/db/": {"prod/db/password"},
+		"nope/":    nil,
+	} {
+		got, err := p.List(context.Background(), prefix)
+		if err != nil {
+			t.Fatalf("List(%q): %v", prefix, err)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("List(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+
+	ro, _ := New(WithClient(struct{ Client }{mock}))
+	if _, err := ro.List(context.Background(), ""); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("List: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestSDKClient_List(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("list") != "true" {
+			http.Error(w, "not a LIST request", http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/metadata":
+			fmt.Fprint(w, `{"data": {"keys": ["api-key", "db/"]}}`)
+		case "/v1/secret/metadata/db":
+			fmt.Fprint(w, `{"data": {"keys": ["password"]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors": []}`)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := New(WithAddress(srv.URL), WithToken("t"), WithRetryPolicy(RetryPolicy{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	got, err := p.List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := "api-key,db/password"; strings.Join(got, ",") != want {
+		t.Errorf("List = %q, want %q", got, want)
+	}
+	if got, err := p.List(context.Background(), "missing/"); err != nil || len(got) != 0 {
+		t.Errorf("List(missing/) = %q, %v, want none", got, err)
+	}
+}
+
+func TestSDKClient_GetMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/metadata/db" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors": []}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": {
+			"created_time": "2023-11-14T22:13:20Z",
+			"updated_time": "2024-03-09T16:00:00Z",
+			"current_version": 2,
+			"delete_version_after": "0s",
+			"custom_metadata": {"team": "billing"},
+			"versions": {
+				"1": {"version": 1, "created_time": "2023-11-14T22:13:20Z", "deletion_time": ""},
+				"2": {"version": 2, "created_time": "2024-03-09T16:00:00Z", "deletion_time": "2024-07-03T15:20:00Z"}
+			}
+		}}`)
+	}))
+	defer srv.Close()
+
+	p, err := New(WithAddress(srv.URL), WithToken("t"), WithRetryPolicy(RetryPolicy{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	md, err := p.GetMetadata(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if want := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC); !md.CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v", md.CreatedAt, want)
+	}
+	if want := time.Date(2024, 3, 9, 16, 0, 0, 0, time.UTC); !md.RotatedAt.Equal(want) {
+		t.Errorf("RotatedAt = %v, want %v", md.RotatedAt, want)
+	}
+	if want := time.Date(2024, 7, 3, 15, 20, 0, 0, time.UTC); !md.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", md.ExpiresAt, want)
+	}
+	if md.Version != "2" {
+		t.Errorf("Version = %q, want 2", md.Version)
+	}
+	if md.Tags["team"] != "billing" {
+		t.Errorf("Tags = %v", md.Tags)
+	}
+
+	if _, err := p.GetMetadata(context.Background(), "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	mock := &mockVaultClient{
+		secrets: map[string]map[int]map[string]any{
+			"db": {0: {"username": "admin", "password": "old"}},
+		},
+	}
+	p, err := New(WithClient(mock), WithDataKey("password"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := p.Set(ctx, "db", []byte("s3cret")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	want := map[string]any{"username": "admin", "password": "s3cret"}
+	if got := mock.secrets["db"][0]; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("data = %v, want %v", got, want)
+	}
+	if err := p.Set(ctx, "new", []byte("v1")); err != nil {
+		t.Fatalf("Set new: %v", err)
+	}
+	if got, err := p.Get(ctx, "new"); err != nil || string(got) != "v1" {
+		t.Errorf("Get = %q, %v, want %q", got, err, "v1")
+	}
+
+	if err := p.Delete(ctx, "db"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := p.Get(ctx, "db"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get deleted: expected ErrNotFound, got: %v", err)
+	}
+
+	ro, _ := New(WithClient(struct{ Client }{mock}))
+	if err := ro.Set(ctx, "db", nil); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Set: expected ErrUnsupported, got: %v", err)
+	}
+}
+
+func TestSDKClient_Set(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors": []}`)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		body = r.Method + " " + r.URL.Path + " " + string(b)
+		fmt.Fprint(w, `{"data": {"version": 1}}`)
+	}))
+	defer srv.Close()
+
+	p, err := New(WithAddress(srv.URL), WithToken("t"), WithRetryPolicy(RetryPolicy{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	if err := p.Set(context.Background(), "api-key", []byte("s3cret")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if want := `PUT /v1/secret/data/api-key {"data":{"value":"s3cret"}}`; strings.TrimSpace(body) != want {
+		t.Errorf("request = %q, want %q", body, want)
+	}
+}
+
+func TestAPIConfig(t *testing.T) {
+	p := &Provider{address: "http://vault:8200"}
+	p2 := *p
+	WithRetryPolicy(RetryPolicy{MaxRetries: 0, MinWait: 10 * time.Millisecond})(&p2)
+	WithRateLimit(5, 10)(&p2)
+
+	cfg, err := p2.apiConfig()
+	if err != nil {
+		t.Fatalf("apiConfig: %v", err)
+	}
+	if cfg.Address != "http://vault:8200" {
+		t.Errorf("Address = %q", cfg.Address)
+	}
+	if cfg.MaxRetries != 0 {
+		t.Errorf("MaxRetries = %d, want 0", cfg.MaxRetries)
+	}
+	if cfg.MinRetryWait != 10*time.Millisecond {
+		t.Errorf("MinRetryWait = %v, want 10ms", cfg.MinRetryWait)
+	}
+	if def, _ := p.apiConfig(); cfg.MaxRetryWait != def.MaxRetryWait {
+		t.Errorf("MaxRetryWait = %v, want default %v", cfg.MaxRetryWait, def.MaxRetryWait)
+	}
+	if cfg.Limiter == nil || cfg.Limiter.Limit() != 5 || cfg.Limiter.Burst() != 10 {
+		t.Errorf("Limiter = %+v, want 5 rps, burst 10", cfg.Limiter)
+	}
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/auth/") {
+			fmt.Fprint(w, `{"data": {"ttl": 0}}`) // token renewer
+			return
+		}
+		if calls.Add(1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"data": {"value": "s3cret"}, "metadata": {"version": 1}}}`)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		retries int
+		wantErr bool
+	}{
+		{retries: 0, wantErr: true},
+		{retries: 1, wantErr: false},
+	} {
+		calls.Store(0)
+		p, err := New(WithAddress(srv.URL), WithToken("t"),
+			WithRetryPolicy(RetryPolicy{MaxRetries: tc.retries, MinWait: time.Millisecond, MaxWait: time.Millisecond}))
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		defer p.Close()
+		val, err := p.Get(context.Background(), "db-password")
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("retries=%d: Get error = %v, want error %v", tc.retries, err, tc.wantErr)
+		}
+		if !tc.wantErr && string(val) != "s3cret" {
+			t.Errorf("retries=%d: Get = %q, want %q", tc.retries, val, "s3cret")
+		}
+	}
+}
+
+// blockingClient is a Client whose calls block until the context is done.
+type blockingClient struct{}
+
+func (blockingClient) Get(ctx context.Context, _ string) (map[string]any, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingClient) GetVersion(ctx context.Context, _ string, _ int) (map[string]any, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestWithTimeout(t *testing.T) {
+	p, err := New(WithClient(blockingClient{}), WithTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if _, err := p.Get(context.Background(), "db-password"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get: expected DeadlineExceeded, got: %v", err)
+	}
+	if _, err := p.GetVersion(context.Background(), "db-password", "1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetVersion: expected DeadlineExceeded, got: %v", err)
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/auth/") {
+			fmt.Fprint(w, `{"data": {"ttl": 0}}`) // token renewer
+			return
+		}
+		got = append(got, r.Header.Get("User-Agent"))
+		fmt.Fprint(w, `{"data": {"data": {"value": "s3cret"}, "metadata": {"version": 1}}}`)
+	}))
+	defer srv.Close()
+
+	p, err := New(WithAddress(srv.URL), WithToken("t"), WithUserAgent("billing-api/1.4.2"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	if _, err := p.Get(context.Background(), "db-password"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	ctx := secrets.ContextWithUserAgent(context.Background(), "billing-worker/2.0")
+	if _, err := p.GetVersion(ctx, "db-password", "1"); err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+
+	want := []string{"billing-api/1.4.2", "billing-worker/2.0"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("User-Agent = %q, want %q", got, want)
+	}
+}
+
+func TestSDKClient_ErrProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors": ["permission denied"]}`)
+	}))
+	defer srv.Close()
+
+	p, err := New(WithAddress(srv.URL), WithToken("t"), WithRetryPolicy(RetryPolicy{}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	_, err = p.Get(context.Background(), "db")
+	var pe *secrets.ErrProvider
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected ErrProvider, got %v", err)
+	}
+	if pe.StatusCode != http.StatusForbidden {
+		t.Errorf("StatusCode = %d, want 403", pe.StatusCode)
+	}
+}
+
+func TestWithEngine_KVv1(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/auth/") {
+			fmt.Fprint(w, `{"data": {"ttl": 0}}`) // token renewer
+			return
+		}
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		mu.Unlock()
+		switch {
+		case r.URL.Query().Get("list") == "true":
+			fmt.Fprint(w, `{"data": {"keys": ["db"]}}`)
+		case r.URL.Path == "/v1/kv/db" && r.Method == http.MethodGet:
+			fmt.Fprint(w, `{"data": {"value": "s3cret", "user": "admin"}}`)
+		case r.URL.Path == "/v1/kv/db":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors": []}`)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := New(WithAddress(srv.URL), WithToken("t"), WithRetryPolicy(RetryPolicy{}), WithEngine(KVv1), WithMount("kv"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	ctx := context.Background()
+	if got, err := p.Get(ctx, "db"); err != nil || string(got) != "s3cret" {
+		t.Errorf("Get = %q, %v; want %q", got, err, "s3cret")
+	}
+	if _, err := p.Get(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing): expected ErrNotFound, got %v", err)
+	}
+	var verr *secrets.ErrVersioningNotSupported
+	if _, err := p.GetVersion(ctx, "db", "2"); !errors.As(err, &verr) {
+		t.Errorf("GetVersion: expected ErrVersioningNotSupported, got %v", err)
+	}
+	if err := p.Check(ctx, "db"); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Check: expected ErrUnsupported, got %v", err)
+	}
+	if err := p.Set(ctx, "db", []byte("n3w")); err != nil {
+		t.Errorf("Set: %v", err)
+	}
+	if got, err := p.List(ctx, ""); err != nil || strings.Join(got, ",") != "db" {
+		t.Errorf("List = %q, %v; want [db]", got, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"GET /v1/kv/db", "GET /v1/kv/missing",
+		"GET /v1/kv/db", "PUT /v1/kv/db",
+		"GET /v1/kv?list=true",
+	}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests:\n%s\nwant:\n%s", strings.Join(requests, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWithEngine_Logical(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			fmt.Fprint(w, `{"data": {"ttl": 0}}`)
+		case "/v1/database/creds/app":
+			fmt.Fprint(w, `{"lease_id": "database/creds/app/abc", "lease_duration": 3600,
+				"data": {"username": "v-app-x1", "password": "p4ss"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors": []}`)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := New(WithAddress(srv.URL), WithToken("t"), WithRetryPolicy(RetryPolicy{}), WithEngine(Logical))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	ctx := context.Background()
+	got, err := p.Get(ctx, "database/creds/app")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if want := `{"password":"p4ss","username":"v-app-x1"}`; string(got) != want {
+		t.Errorf("Get = %s, want %s", got, want)
+	}
+	if _, err := p.Get(ctx, "database/creds/missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing): expected ErrNotFound, got %v", err)
+	}
+
+	p2, err := New(WithAddress(srv.URL), WithToken("t"), WithEngine(Logical), WithDataKey("password"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p2.Close()
+	if got, err := p2.Get(ctx, "database/creds/app"); err != nil || string(got) != "p4ss" {
+		t.Errorf("Get with data key = %q, %v; want %q", got, err, "p4ss")
+	}
+}
+
+func TestSet_EmptyDataKey(t *testing.T) {
+	m := &mockVaultClient{}
+	p, _ := New(WithClient(m), WithDataKey(""))
+	if err := p.Set(context.Background(), "db", []byte(`{"user":"admin","password":"s3cret"}`)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	got, err := p.Get(context.Background(), "db")
+	if err != nil || string(got) != `{"password":"s3cret","user":"admin"}` {
+		t.Errorf("Get = %s, %v", got, err)
+	}
+	if err := p.Set(context.Background(), "db", []byte("not json")); err == nil {
+		t.Error("Set accepted a value that is not a JSON object")
+	}
+}
+
+func TestWithFullData(t *testing.T) {
+	m := &mockVaultClient{secrets: map[string]map[int]map[string]any{
+		"db": {0: {"username": "admin", "password": "s3cret", "port": 5432}},
+	}}
+	p, err := New(WithClient(m), WithFullData())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	got, err := p.Get(context.Background(), "db")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if want := `{"password":"s3cret","port":5432,"username":"admin"}`; string(got) != want {
+		t.Errorf("Get = %s, want %s", got, want)
+	}
+
+	r := secrets.NewResolver(secrets.WithDefault(p))
+	var cfg struct {
+		User     string `secret:"db#username"`
+		Password string `secret:"db#password"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.User != "admin" || cfg.Password != "s3cret" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+}
+
+// tlsServer starts a TLS server serving a KV v2 secret, and writes its CA
+// certificate to a file. If clientAuth, it requires a client certificate.
+func tlsServer(t *testing.T, clientAuth bool) (srv *httptest.Server, caFile string) {
+	srv = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/auth/") {
+			fmt.Fprint(w, `{"data": {"ttl": 0}}`) // token renewer
+			return
+		}
+		fmt.Fprint(w, `{"data": {"data": {"value": "s3cret"}, "metadata": {"version": 1}}}`)
+	}))
+	if clientAuth {
+		srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	caFile = filepath.Join(t.TempDir(), "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", srv.Certificate().Raw)
+	return srv, caFile
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// clientCert writes a self-signed client certificate and its key to files.
+func clientCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "app"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func TestWithCACert(t *testing.T) {
+	srv, caFile := tlsServer(t, true)
+	certFile, keyFile := clientCert(t)
+	for _, tc := range []struct {
+		name    string
+		opts    []ProviderOption
+		wantErr bool
+	}{
+		{"no CA", []ProviderOption{WithClientCert(certFile, keyFile)}, true},
+		{"no client cert", []ProviderOption{WithCACert(caFile)}, true},
+		{"CA and client cert", []ProviderOption{WithCACert(caFile), WithClientCert(certFile, keyFile)}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]ProviderOption{WithAddress(srv.URL), WithToken("t"), WithRetryPolicy(RetryPolicy{})}, tc.opts...)
+			p, err := New(opts...)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer p.Close()
+			val, err := p.Get(context.Background(), "db")
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Get error = %v, want error %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && string(val) != "s3cret" {
+				t.Errorf("Get = %q, want %q", val, "s3cret")
+			}
+		})
+	}
+
+	if _, err := New(WithAddress(srv.URL), WithCACert(filepath.Join(t.TempDir(), "missing.pem"))); err == nil {
+		t.Error("New succeeded with a missing CA file")
+	}
+	if _, err := New(WithAddress(srv.URL), WithClientCert(certFile, "")); err == nil {
+		t.Error("New succeeded with a client certificate but no key")
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	srv, _ := tlsServer(t, false)
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	p, err := New(WithAddress(srv.URL), WithToken("t"), WithTLSConfig(&tls.Config{RootCAs: roots}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	if val, err := p.Get(context.Background(), "db"); err != nil || string(val) != "s3cret" {
+		t.Errorf("Get = %q, %v; want %q", val, err, "s3cret")
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	srv, caFile := tlsServer(t, false)
+	hc := srv.Client()
+	p, err := New(WithAddress(srv.URL), WithToken("t"), WithHTTPClient(hc))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer p.Close()
+	if val, err := p.Get(context.Background(), "db"); err != nil || string(val) != "s3cret" {
+		t.Errorf("Get = %q, %v; want %q", val, err, "s3cret")
+	}
+	if hc.CheckRedirect != nil {
+		t.Error("WithHTTPClient modified the caller's client")
+	}
+
+	if _, err := New(WithAddress(srv.URL), WithHTTPClient(hc), WithCACert(caFile)); err == nil {
+		t.Error("New accepted WithHTTPClient combined with WithCACert")
+	}
+}
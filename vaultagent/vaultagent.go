@@ -0,0 +1,190 @@
+// Package vaultagent provides a secret provider that reads the files that a
+// HashiCorp Vault Agent, such as the sidecar added by the Vault Agent
+// Injector, renders next to an application: its auto-auth token sink and its
+// templates.
+package vaultagent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/brwse/go-secrets"
+	"github.com/brwse/go-secrets/file"
+)
+
+// DefaultDir is the directory the Vault Agent Injector renders templates
+// into.
+const DefaultDir = "/vault/secrets"
+
+// TokenKey is the key of the Agent's auto-auth token.
+const TokenKey = "token"
+
+// ProviderOption configures the vaultagent Provider.
+type ProviderOption func(*Provider)
+
+// WithDir sets the directory the Agent renders templates into. Defaults to
+// DefaultDir.
+func WithDir(dir string) ProviderOption {
+	return func(p *Provider) {
+		p.dir = dir
+	}
+}
+
+// WithTokenSink sets the path of the Agent's file sink, read for TokenKey.
+// Defaults to the file "token" in the template directory, where the injector
+// writes it when the agent-inject-token annotation is set.
+func WithTokenSink(path string) ProviderOption {
+	return func(p *Provider) {
+		p.tokenSink = path
+	}
+}
+
+// WithTrimNewline configures whether trailing newlines are trimmed from
+// rendered templates. Templates usually end with one, so it defaults to
+// true.
+func WithTrimNewline(trim bool) ProviderOption {
+	return func(p *Provider) {
+		p.trimNewline = trim
+	}
+}
+
+// Provider reads the output of a Vault Agent.
+// It implements secrets.Provider, secrets.CheckerProvider,
+// secrets.MetadataProvider, and secrets.ListerProvider.
+//
+// Keys are the paths of rendered templates relative to the template
+// directory, such as "db-creds" for /vault/secrets/db-creds. Templates that
+// render JSON can be read field by field with fragments:
+//
+//	Password string `secret:"vaultagent://db-creds#password"`
+//
+// TokenKey reads the Agent's auto-auth token from its file sink. A sink
+// configured with wrap_ttl holds the JSON of a response-wrapping token; the
+// wrapping token is returned, to be unwrapped by the caller.
+type Provider struct {
+	dir         string
+	tokenSink   string
+	trimNewline bool
+	files       *file.Provider
+}
+
+// New creates a new Vault Agent Provider.
+func New(opts ...ProviderOption) *Provider {
+	p := &Provider{dir: DefaultDir, trimNewline: true}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.tokenSink == "" {
+		p.tokenSink = filepath.Join(p.dir, TokenKey)
+	}
+	p.files = file.New(file.WithBaseDir(p.dir), file.WithTrimNewline(p.trimNewline))
+	return p
+}
+
+// Get returns the rendered template key, or the auto-auth token for
+// TokenKey.
+// Returns secrets.ErrNotFound (wrapped) if the file does not exist, as it
+// does until the Agent has rendered it.
+func (p *Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	if key == TokenKey {
+		return p.token()
+	}
+	val, err := p.files.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("vaultagent: %w", err)
+	}
+	return val, nil
+}
+
+// token reads the auto-auth token from the sink.
+func (p *Provider) token() ([]byte, error) {
+	data, err := os.ReadFile(p.tokenSink)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("vaultagent: token sink %q: %w", p.tokenSink, secrets.ErrNotFound)
+		}
+		return nil, fmt.Errorf("vaultagent: token sink %q: %w", p.tokenSink, err)
+	}
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		var wrapped struct {
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("vaultagent: token sink %q: %w", p.tokenSink, err)
+		}
+		data = []byte(wrapped.Token)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("vaultagent: token sink %q: no token", p.tokenSink)
+	}
+	return data, nil
+}
+
+// Check reports whether the rendered template, or token sink, for key
+// exists, without reading it.
+// Returns secrets.ErrNotFound (wrapped) if it does not exist.
+func (p *Provider) Check(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if key == TokenKey {
+		_, err := p.token()
+		return err
+	}
+	if err := p.files.Check(ctx, key); err != nil {
+		return fmt.Errorf("vaultagent: %w", err)
+	}
+	return nil
+}
+
+// GetMetadata describes the rendered template for key. RotatedAt is the time
+// the Agent last rendered it.
+// Returns secrets.ErrNotFound (wrapped) if it does not exist.
+func (p *Provider) GetMetadata(ctx context.Context, key string) (secrets.Metadata, error) {
+	if err := checkKey(key); err != nil {
+		return secrets.Metadata{}, err
+	}
+	if key == TokenKey {
+		fi, err := os.Stat(p.tokenSink)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				err = secrets.ErrNotFound
+			}
+			return secrets.Metadata{}, fmt.Errorf("vaultagent: token sink %q: %w", p.tokenSink, err)
+		}
+		return secrets.Metadata{RotatedAt: fi.ModTime()}, nil
+	}
+	md, err := p.files.GetMetadata(ctx, key)
+	if err != nil {
+		return secrets.Metadata{}, fmt.Errorf("vaultagent: %w", err)
+	}
+	return md, nil
+}
+
+// List returns the keys of the rendered templates that start with prefix.
+func (p *Provider) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := p.files.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("vaultagent: %w", err)
+	}
+	return keys, nil
+}
+
+// checkKey rejects keys that would read outside the template directory.
+func checkKey(key string) error {
+	if key == "" || filepath.IsAbs(key) || slices.Contains(strings.Split(filepath.ToSlash(key), "/"), "..") {
+		return fmt.Errorf("vaultagent: invalid key %q: want a path relative to the template directory", key)
+	}
+	return nil
+}
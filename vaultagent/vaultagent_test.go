@@ -0,0 +1,114 @@
+package vaultagent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brwse/go-secrets"
+)
+
+// writeFiles writes files, relative paths to contents, under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"db-creds":     `{"username":"app","password":"s3cret"}` + "\n",
+		"tls/cert.pem": "-----BEGIN CERTIFICATE-----\n",
+		"token":        "hvs.CAESIabc\n",
+	})
+	p := New(WithDir(dir))
+	ctx := context.Background()
+
+	tests := []struct {
+		key, want string
+	}{
+		{"db-creds", `{"username":"app","password":"s3cret"}`},
+		{"tls/cert.pem", "-----BEGIN CERTIFICATE-----"},
+		{TokenKey, "hvs.CAESIabc"},
+	}
+	for _, tc := range tests {
+		if val, err := p.Get(ctx, tc.key); err != nil || string(val) != tc.want {
+			t.Errorf("Get(%q) = %q, %v; want %q", tc.key, val, err, tc.want)
+		}
+	}
+
+	if _, err := p.Get(ctx, "not-rendered"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+	for _, key := range []string{"", "../etc/passwd", "/etc/passwd", "tls/../../x"} {
+		if _, err := p.Get(ctx, key); err == nil || !strings.Contains(err.Error(), "invalid key") {
+			t.Errorf("Get(%q): err = %v, want invalid key", key, err)
+		}
+	}
+
+	raw := New(WithDir(dir), WithTrimNewline(false))
+	if val, _ := raw.Get(ctx, "tls/cert.pem"); string(val) != "-----BEGIN CERTIFICATE-----\n" {
+		t.Errorf("Get without trimming = %q", val)
+	}
+}
+
+func TestGet_TokenSink(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"wrapped": `{"token":"hvs.wrapping","accessor":"acc","ttl":300,"creation_path":"sys/wrapping/wrap"}`,
+		"empty":   "\n",
+	})
+	ctx := context.Background()
+
+	p := New(WithDir(t.TempDir()), WithTokenSink(filepath.Join(dir, "wrapped")))
+	if val, err := p.Get(ctx, TokenKey); err != nil || string(val) != "hvs.wrapping" {
+		t.Errorf("Get = %q, %v; want the wrapping token", val, err)
+	}
+	if err := p.Check(ctx, TokenKey); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	if md, err := p.GetMetadata(ctx, TokenKey); err != nil || md.RotatedAt.IsZero() {
+		t.Errorf("GetMetadata = %+v, %v", md, err)
+	}
+
+	p = New(WithTokenSink(filepath.Join(dir, "empty")))
+	if _, err := p.Get(ctx, TokenKey); err == nil || !strings.Contains(err.Error(), "no token") {
+		t.Errorf("err = %v, want no token", err)
+	}
+	p = New(WithDir(dir))
+	if _, err := p.Get(ctx, TokenKey); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected ErrNotFound before the Agent authenticates, got: %v", err)
+	}
+}
+
+func TestCheckMetadataList(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"db-creds": "{}", "tls/cert.pem": "x", "tls/key.pem": "y"})
+	p := New(WithDir(dir))
+	ctx := context.Background()
+
+	if err := p.Check(ctx, "db-creds"); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	if err := p.Check(ctx, "missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Check missing: expected ErrNotFound, got: %v", err)
+	}
+	if md, err := p.GetMetadata(ctx, "db-creds"); err != nil || md.RotatedAt.IsZero() {
+		t.Errorf("GetMetadata = %+v, %v", md, err)
+	}
+	keys, err := p.List(ctx, "tls/")
+	if err != nil || strings.Join(keys, ",") != "tls/cert.pem,tls/key.pem" {
+		t.Errorf("List = %q, %v", keys, err)
+	}
+}
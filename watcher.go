@@ -3,9 +3,15 @@ package secrets
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
 	"reflect"
+	"slices"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,7 +19,22 @@ import (
 type WatchOption func(*watcherConfig)
 
 type watcherConfig struct {
-	interval time.Duration
+	interval     time.Duration
+	initialRetry *RetryPolicy
+	only         []string // if non-empty, only these fields are watched
+	exclude      []string // fields that are never watched
+	jitter       float64
+	maxBackoff   time.Duration
+	delivery     DeliveryPolicy
+	blockTimeout time.Duration
+	bufferSize   int
+	onOverflow   func(ChangeEvent)
+	structural   bool
+	clock        Clock
+
+	// onUpdate is called after the initial resolve and whenever changed
+	// fields have been written to dst, from the goroutine that wrote them.
+	onUpdate func()
 }
 
 // WatchInterval sets the polling interval for the Watcher.
@@ -24,13 +45,249 @@ func WatchInterval(d time.Duration) WatchOption {
 	}
 }
 
+// WatchJitter randomizes each poll delay by up to ±fraction of the interval
+// (for example 0.1 for ±10%), so that many processes watching the same
+// secrets do not poll the provider at the same instant. fraction is clamped
+// to [0, 1]. Defaults to 0.
+func WatchJitter(fraction float64) WatchOption {
+	return func(c *watcherConfig) {
+		c.jitter = min(max(fraction, 0), 1)
+	}
+}
+
+// WatchMaxBackoff caps the poll delay after consecutive failed polls. After
+// each failure the delay for the affected fields doubles, starting from their
+// interval, until a poll succeeds. Defaults to 10 times the interval.
+func WatchMaxBackoff(d time.Duration) WatchOption {
+	return func(c *watcherConfig) {
+		c.maxBackoff = d
+	}
+}
+
+// delay returns how long to wait before polling a field with the given
+// interval after the given number of consecutive failures.
+func (c *watcherConfig) delay(interval time.Duration, failures int) time.Duration {
+	d := interval
+	if failures > 0 {
+		maxBackoff := c.maxBackoff
+		if maxBackoff <= 0 {
+			maxBackoff = 10 * interval
+		}
+		maxBackoff = max(maxBackoff, interval)
+		for i := 0; i < failures && d < maxBackoff; i++ {
+			d *= 2
+		}
+		d = min(d, maxBackoff)
+	}
+	if c.jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * c.jitter * float64(d))
+	}
+	return d
+}
+
+// WatchOnly restricts watching to the named fields. Other fields are
+// resolved once by the initial Resolve and never refreshed. Names are Go
+// field names, as reported in ChangeEvent.Field.
+func WatchOnly(fields ...string) WatchOption {
+	return func(c *watcherConfig) {
+		c.only = append(c.only, fields...)
+	}
+}
+
+// WatchExclude excludes the named fields from watching. They are resolved
+// once by the initial Resolve and never refreshed. Names are Go field names,
+// as reported in ChangeEvent.Field.
+func WatchExclude(fields ...string) WatchOption {
+	return func(c *watcherConfig) {
+		c.exclude = append(c.exclude, fields...)
+	}
+}
+
+// DeliveryPolicy controls what the Watcher does when the Changes channel
+// buffer is full.
+type DeliveryPolicy int
+
+const (
+	// DeliveryDrop drops events that do not fit in the buffer, reporting
+	// them to the WatchOnOverflow callback if one is set. Polling is never
+	// delayed by a slow consumer. This is the default.
+	DeliveryDrop DeliveryPolicy = iota
+	// DeliveryBlock waits for the consumer to receive each event, so no event
+	// is ever lost. Polling pauses while the buffer is full. With
+	// WatchBlockTimeout, an event that cannot be delivered in time is dropped.
+	DeliveryBlock
+	// DeliveryDropOldest makes room for each new event by dropping the oldest
+	// buffered one, so that the consumer always sees the most recent
+	// changes. Polling is never delayed. With an unbuffered channel, it
+	// behaves like DeliveryDrop.
+	DeliveryDropOldest
+	// DeliveryUnbounded queues events that do not fit in the buffer in
+	// memory until the consumer receives them, so no event is lost and
+	// polling is never delayed. A consumer that stops receiving makes the
+	// queue grow without limit.
+	DeliveryUnbounded
+)
+
+// WatchDelivery sets the policy for delivering events when the Changes
+// channel buffer is full. Defaults to DeliveryDrop.
+//
+// Events lost under any policy are counted by Watcher.Dropped and, for the
+// Changes channel, passed to the WatchOnOverflow callback.
+func WatchDelivery(policy DeliveryPolicy) WatchOption {
+	return func(c *watcherConfig) {
+		c.delivery = policy
+	}
+}
+
+// WatchBlockTimeout bounds how long DeliveryBlock waits for the consumer to
+// receive an event. An event still undelivered after d is dropped and polling
+// resumes, so a stuck consumer cannot stall rotation indefinitely. Zero (the
+// default) waits until the Watcher stops.
+func WatchBlockTimeout(d time.Duration) WatchOption {
+	return func(c *watcherConfig) {
+		c.blockTimeout = d
+	}
+}
+
+// WatchBufferSize sets the capacity of the Changes channel buffer.
+// Defaults to 64.
+func WatchBufferSize(n int) WatchOption {
+	return func(c *watcherConfig) {
+		c.bufferSize = n
+	}
+}
+
+// WatchOnOverflow registers fn to be called with each event dropped from the
+// Changes channel under the delivery policy, so that lost events can be
+// logged or acted on. fn is called from the Watcher's goroutine and must not
+// block.
+func WatchOnOverflow(fn func(ChangeEvent)) WatchOption {
+	return func(c *watcherConfig) {
+		c.onOverflow = fn
+	}
+}
+
+// WatchStructuralDiff reports changes to JSON secrets per changed value. When
+// both the old and the new value of a field are JSON objects or arrays, the
+// Watcher emits one ChangeEvent for each added, removed, or modified leaf
+// value, with ChangeEvent.Fragment set to its path, instead of a single event
+// for the whole document. Consumers can then react only to the parts they
+// use. A change that leaves the decoded document equal (reformatting, key
+// order) is reported as a single whole-value event.
+func WatchStructuralDiff() WatchOption {
+	return func(c *watcherConfig) {
+		c.structural = true
+	}
+}
+
+// WatchClock makes the Watcher schedule polls, initial-resolve retries, and
+// WatchBlockTimeout against clk instead of the system clock. It is intended
+// for tests: with a fake clock such as secrettest.Clock, a test can trigger
+// jittered polls and failure backoff by advancing the clock rather than
+// sleeping for the interval.
+func WatchClock(clk Clock) WatchOption {
+	return func(c *watcherConfig) {
+		c.clock = clk
+	}
+}
+
+// watches reports whether the field named name should be watched.
+func (c *watcherConfig) watches(name string) bool {
+	if slices.Contains(c.exclude, name) {
+		return false
+	}
+	return len(c.only) == 0 || slices.Contains(c.only, name)
+}
+
+// checkFieldNames reports an error for WatchOnly or WatchExclude names that
+// do not match any secret-tagged field, which usually indicates a typo.
+func (c *watcherConfig) checkFieldNames(fields []fieldInfo) error {
+	var errs []error
+	for _, name := range slices.Concat(c.only, c.exclude) {
+		if !slices.ContainsFunc(fields, func(fi fieldInfo) bool { return fi.fieldName == name }) {
+			errs = append(errs, fmt.Errorf("secrets: watch filter: no secret field named %q", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithInitialRetry makes Watch retry the initial Resolve with exponential
+// backoff according to policy, instead of failing on the first error.
+// This lets watchers started at boot tolerate a briefly unavailable provider.
+// Configuration errors reported by Validate (bad tags, unknown schemes,
+// unsupported types) are never retried.
+func WithInitialRetry(policy RetryPolicy) WatchOption {
+	return func(c *watcherConfig) {
+		c.initialRetry = &policy
+	}
+}
+
+// RetryPolicy configures retries with exponential backoff.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Zero means retry until the context is cancelled.
+	MaxAttempts int
+	// InitialInterval is the delay before the first retry. Defaults to 1 second.
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between retries. Defaults to 30 seconds.
+	MaxInterval time.Duration
+	// Multiplier is the factor by which the delay grows after each retry.
+	// Defaults to 2.
+	Multiplier float64
+}
+
+// backoff returns the delay before retry number n (starting at 1).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.InitialInterval
+	if d <= 0 {
+		d = time.Second
+	}
+	maxInterval := p.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = 30 * time.Second
+	}
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = 2
+	}
+	for i := 1; i < n && d < maxInterval; i++ {
+		d = time.Duration(float64(d) * mult)
+	}
+	return min(d, maxInterval)
+}
+
 // Watcher periodically re-resolves secrets and detects changes.
 // It provides thread-safe read access via RLock/RUnlock.
 type Watcher struct {
 	mu      sync.RWMutex
 	changes chan ChangeEvent
 	stop    chan struct{}
+	stopped sync.Once // closes stop
 	done    chan struct{}
+	pushes  chan string // subscription ID whose key changed
+	ended   chan string // subscription ID that ended
+	refresh chan chan error
+
+	draining  chan struct{} // closed by Drain to stop polling gracefully
+	drainOnce sync.Once     // closes draining
+	flushed   chan struct{} // closed once the event channels are closed
+
+	rotMu     sync.Mutex
+	rotations chan RotationEvent // created by Rotations
+	closed    bool               // the poll loop has exited
+	open      int                // event channels not yet closed
+
+	bufferSize   int
+	delivery     DeliveryPolicy
+	blockTimeout time.Duration
+	onOverflow   func(ChangeEvent)
+	structural   bool
+	clock        Clock
+	onUpdate     func() // see watcherConfig.onUpdate
+
+	dropped     atomic.Uint64
+	changeQueue *eventQueue[ChangeEvent]   // DeliveryUnbounded only
+	rotQueue    *eventQueue[RotationEvent] // DeliveryUnbounded only; created by Rotations
 }
 
 // Changes returns a channel that receives ChangeEvents when secret values change.
@@ -39,6 +296,83 @@ func (w *Watcher) Changes() <-chan ChangeEvent {
 	return w.changes
 }
 
+// Rotations returns a channel that receives a RotationEvent whenever the
+// Current value of a Versioned[T] field changes, in addition to the
+// ChangeEvent sent on Changes. The channel is created on the first call, so
+// the Watcher never waits on it unless it is used; it has the same buffer
+// size and delivery policy as Changes, and dropped rotation events are
+// counted by Dropped, but they are not passed to the WatchOnOverflow
+// callback. The channel is closed when the Watcher stops.
+func (w *Watcher) Rotations() <-chan RotationEvent {
+	w.rotMu.Lock()
+	defer w.rotMu.Unlock()
+	if w.rotations == nil {
+		w.rotations = make(chan RotationEvent, w.bufferSize)
+		if w.closed {
+			close(w.rotations)
+			return w.rotations
+		}
+		w.open++
+		if w.delivery == DeliveryUnbounded {
+			// The Watcher is tracked and its poll loop has not exited
+			// (closed is set under rotMu before it returns), so done
+			// will be closed and the queue goroutine will end.
+			w.rotQueue = newEventQueue[RotationEvent]()
+			go w.rotQueue.run(w, w.rotations)
+		}
+	}
+	return w.rotations
+}
+
+// rotationsChan returns the rotations channel and its queue, or nil if
+// Rotations has not been called.
+func (w *Watcher) rotationsChan() (chan RotationEvent, *eventQueue[RotationEvent]) {
+	w.rotMu.Lock()
+	defer w.rotMu.Unlock()
+	return w.rotations, w.rotQueue
+}
+
+// closeChannels closes the event channels when the poll loop exits. Under
+// DeliveryUnbounded, the queues close them once they are done delivering.
+func (w *Watcher) closeChannels() {
+	w.rotMu.Lock()
+	defer w.rotMu.Unlock()
+	w.closed = true
+	if w.changeQueue == nil {
+		close(w.changes)
+		w.open--
+	}
+	if w.rotations != nil && w.rotQueue == nil {
+		close(w.rotations)
+		w.open--
+	}
+	w.flushIfClosed()
+}
+
+// channelClosed records that a queue has closed its event channel.
+func (w *Watcher) channelClosed() {
+	w.rotMu.Lock()
+	defer w.rotMu.Unlock()
+	w.open--
+	w.flushIfClosed()
+}
+
+// flushIfClosed closes flushed once the poll loop has exited and every event
+// channel is closed. The caller must hold rotMu.
+func (w *Watcher) flushIfClosed() {
+	if w.closed && w.open == 0 {
+		close(w.flushed)
+	}
+}
+
+// Dropped returns the number of events the Watcher has dropped from the
+// Changes and Rotations channels because the consumer did not keep up, as
+// decided by the delivery policy. A non-zero count means that some changes
+// were applied to the struct without notification.
+func (w *Watcher) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
 // RLock acquires a read lock on the watched struct.
 // Use this before reading the struct to ensure consistency.
 func (w *Watcher) RLock() {
@@ -50,15 +384,64 @@ func (w *Watcher) RUnlock() {
 	w.mu.RUnlock()
 }
 
+// ErrWatcherStopped is returned by Watcher.Refresh when the Watcher has
+// stopped.
+var ErrWatcherStopped = errors.New("watcher stopped")
+
+// Refresh re-resolves every watched field now, without waiting for polls to
+// fall due or for pushes, and returns once the changes it detects have been
+// applied to the struct and their events delivered to the channels' buffers.
+// It returns the error that made the re-resolve fail, in which case the
+// struct is left unchanged, ErrWatcherStopped if the Watcher has stopped, or
+// ctx.Err() if ctx is done first.
+//
+// Refresh suits signal handlers that check for rotated credentials on
+// demand, and tests that must not depend on poll timing.
+func (w *Watcher) Refresh(ctx context.Context) error {
+	done := make(chan error, 1)
+	select {
+	case w.refresh <- done:
+	case <-w.done:
+		return ErrWatcherStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Stop stops the Watcher and closes the Changes channel.
 func (w *Watcher) Stop() {
+	w.signalStop()
+	<-w.done // Wait for the poll loop to finish.
+}
+
+// signalStop tells the poll loop to stop, without waiting for it.
+func (w *Watcher) signalStop() {
+	w.stopped.Do(func() { close(w.stop) })
+}
+
+// Drain stops polling and waits until every event detected before shutdown
+// has been delivered to the Changes and Rotations channels and they are
+// closed, so that no change is lost. Unlike Stop, an event that is waiting
+// to be delivered under DeliveryBlock, or queued under DeliveryUnbounded, is
+// delivered first. Delivered events may still be buffered when Drain
+// returns; consumers should keep receiving until the channels are closed.
+//
+// If ctx is done first, Drain stops the Watcher and returns ctx.Err().
+func (w *Watcher) Drain(ctx context.Context) error {
+	w.drainOnce.Do(func() { close(w.draining) })
 	select {
-	case <-w.stop:
-		// Already stopped.
-	default:
-		close(w.stop)
+	case <-w.flushed:
+		return nil
+	case <-ctx.Done():
+		w.Stop()
+		return ctx.Err()
 	}
-	<-w.done // Wait for the poll loop to finish.
 }
 
 // fieldSnapshot records the raw bytes for a field after fragment extraction.
@@ -69,54 +452,127 @@ type fieldSnapshot struct {
 	raw          []byte // raw bytes after fragment extraction
 }
 
+// watchedField tracks when a watched field is next re-resolved.
+type watchedField struct {
+	index    int    // position in collectFields order
+	sub      string // push subscription ID; empty if the field is polled
+	interval time.Duration
+	next     time.Time // next poll; unused while sub is set
+	failures int       // consecutive failed polls
+}
+
 // Watch starts a Watcher that periodically re-resolves secrets into dst.
-// It performs an initial Resolve and then polls at the configured interval.
+// It performs an initial Resolve and then polls each field at its interval:
+// the `watch=` tag option if set, otherwise the WatchInterval. Each poll
+// re-resolves only the fields that are due. WatchOnly and WatchExclude
+// select which fields are watched at all.
+//
+// Fields served by a WatchableProvider are not polled: the Watcher subscribes
+// to their keys and re-resolves them as soon as a change is pushed. If
+// subscribing fails or a subscription ends, those fields fall back to
+// interval polling.
+//
 // The returned Watcher must be stopped via Stop() or context cancellation.
 func (r *Resolver) Watch(ctx context.Context, dst any, opts ...WatchOption) (*Watcher, error) {
 	cfg := watcherConfig{
-		interval: 1 * time.Minute,
+		interval:   1 * time.Minute,
+		bufferSize: 64,
+		clock:      systemClock{},
 	}
 	for _, opt := range opts {
 		opt(&cfg)
 	}
 
 	// Perform the initial resolve.
-	if err := r.Resolve(ctx, dst); err != nil {
+	fields, err := r.initialResolve(ctx, dst, cfg.initialRetry, cfg.clock)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.checkFieldNames(fields); err != nil {
 		return nil, err
 	}
 
 	// Take initial snapshot.
-	snapshot := r.takeSnapshot(dst)
+	snapshot := takeSnapshot(fields)
 
 	w := &Watcher{
-		changes: make(chan ChangeEvent, 64),
-		stop:    make(chan struct{}),
-		done:    make(chan struct{}),
+		changes:  make(chan ChangeEvent, max(cfg.bufferSize, 0)),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		pushes:   make(chan string),
+		ended:    make(chan string),
+		refresh:  make(chan chan error),
+		draining: make(chan struct{}),
+		flushed:  make(chan struct{}),
+
+		bufferSize:   max(cfg.bufferSize, 0),
+		delivery:     cfg.delivery,
+		blockTimeout: cfg.blockTimeout,
+		onOverflow:   cfg.onOverflow,
+		structural:   cfg.structural,
+		clock:        cfg.clock,
+		onUpdate:     cfg.onUpdate,
+		open:         1,
+	}
+	// Track the Watcher before starting anything that assumes its poll loop
+	// runs: the queues wait for it to close done.
+	if !r.trackWatcher(w) {
+		return nil, ErrClosed
+	}
+	if w.delivery == DeliveryUnbounded {
+		w.changeQueue = newEventQueue[ChangeEvent]()
+		go w.changeQueue.run(w, w.changes)
+	}
+
+	if w.onUpdate != nil {
+		w.onUpdate()
 	}
 
-	go w.pollLoop(ctx, r, dst, cfg.interval, snapshot)
+	ctx, cancel := context.WithCancel(ctx)
+	watched := w.schedule(ctx, fields, &cfg)
+	go w.pollLoop(ctx, cancel, r, dst, &cfg, watched, snapshot)
 
 	return w, nil
 }
 
-// takeSnapshot collects the current raw bytes for all secret-tagged fields.
-func (r *Resolver) takeSnapshot(dst any) []fieldSnapshot {
-	rv := reflect.ValueOf(dst)
-	if rv.Kind() != reflect.Pointer || rv.IsNil() {
-		return nil
+// initialResolve resolves dst, retrying according to policy if it is non-nil
+// and waiting between attempts on clk. When retries are exhausted or ctx is cancelled, the last Resolve error is returned.
+// On success it returns the resolved fields.
+func (r *Resolver) initialResolve(ctx context.Context, dst any, policy *RetryPolicy, clk Clock) ([]fieldInfo, error) {
+	if policy == nil {
+		return r.resolve(ctx, dst)
 	}
-	elem := rv.Elem()
-	if elem.Kind() != reflect.Struct {
-		return nil
+	if err := r.Validate(dst); err != nil {
+		return nil, err
 	}
+	for attempt := 1; ; attempt++ {
+		fields, err := r.resolve(ctx, dst)
+		if err == nil {
+			return fields, nil
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return nil, err
+		}
+		delay := policy.backoff(attempt)
+		r.log(ctx, slog.LevelWarn, "initial resolve failed; retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			r.errorAttr(err))
+		timer := clk.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C():
+		}
+	}
+}
 
-	var fields []fieldInfo
-	var errs []error
-	r.collectFields(elem, &fields, &errs)
-
+// takeSnapshot collects the current raw bytes for the resolved fields.
+func takeSnapshot(fields []fieldInfo) []fieldSnapshot {
 	var snapshots []fieldSnapshot
 	for _, fi := range fields {
-		raw := fieldToBytes(fi.fieldValue, fi.isVersioned)
+		raw := fi.snapshotBytes()
 		providerName := fi.providerName
 		if providerName == "" {
 			providerName = fi.tag.Scheme
@@ -131,6 +587,16 @@ func (r *Resolver) takeSnapshot(dst any) []fieldSnapshot {
 	return snapshots
 }
 
+// snapshotBytes returns the bytes compared between polls to detect a change to
+// the field. Values built by a field factory cannot be converted back to
+// bytes, so the extracted secret bytes they were built from are used instead.
+func (fi *fieldInfo) snapshotBytes() []byte {
+	if fi.built {
+		return fi.raw
+	}
+	return fieldToBytes(fi.fieldValue, fi.isVersioned)
+}
+
 // fieldToBytes converts a reflect.Value back to bytes for snapshot comparison.
 func fieldToBytes(fv reflect.Value, isVersioned bool) []byte {
 	if isVersioned {
@@ -154,6 +620,10 @@ func valueToBytes(v reflect.Value) []byte {
 		ft = v.Type()
 	}
 
+	if isRedactedType(ft) {
+		return valueToBytes(v.Field(0))
+	}
+
 	switch ft.Kind() {
 	case reflect.String:
 		return []byte(v.String())
@@ -179,90 +649,295 @@ func valueToBytes(v reflect.Value) []byte {
 	return []byte(v.String())
 }
 
-// pollLoop runs the polling loop.
-func (w *Watcher) pollLoop(ctx context.Context, r *Resolver, dst any, interval time.Duration, snapshot []fieldSnapshot) {
+// schedule returns the watched fields and their first poll times. For fields
+// whose provider implements WatchableProvider it starts one push
+// subscription per key; fields whose subscription fails are polled instead.
+func (w *Watcher) schedule(ctx context.Context, fields []fieldInfo, cfg *watcherConfig) []watchedField {
+	now := w.clock.Now()
+	subscribed := make(map[string]bool) // subscription ID -> started successfully
+	var watched []watchedField
+	for i, fi := range fields {
+		if !cfg.watches(fi.fieldName) || fi.isLazy {
+			continue // Secret[T] fields refresh themselves
+		}
+		wf := watchedField{index: i, interval: cfg.interval}
+		if fi.tag.Watch > 0 {
+			wf.interval = fi.tag.Watch
+		}
+		if wp, ok := fi.provider.(WatchableProvider); ok {
+			id := fi.providerName + "\x00" + fi.tag.Key
+			started, tried := subscribed[id]
+			if !tried {
+				ch, err := wp.Watch(ctx, fi.tag.Key)
+				started = err == nil
+				subscribed[id] = started
+				if started {
+					go w.forward(ctx, id, ch)
+				}
+			}
+			if started {
+				wf.sub = id
+			}
+		}
+		wf.next = now.Add(cfg.delay(wf.interval, 0))
+		watched = append(watched, wf)
+	}
+	return watched
+}
+
+// forward relays values pushed on ch to the poll loop as the subscription ID,
+// and reports the ID as ended when ch is closed before ctx is done.
+func (w *Watcher) forward(ctx context.Context, id string, ch <-chan []byte) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-ch:
+			signal := w.pushes
+			if !ok {
+				signal = w.ended
+			}
+			select {
+			case signal <- id:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// pollLoop re-resolves watched fields when they are due or when a push
+// subscription reports a change, until the Watcher is stopped. Polled fields
+// are rescheduled after each poll, backing off while polls fail.
+func (w *Watcher) pollLoop(ctx context.Context, cancel context.CancelFunc, r *Resolver, dst any, cfg *watcherConfig, watched []watchedField, snapshot []fieldSnapshot) {
 	defer close(w.done)
-	defer close(w.changes)
+	defer r.untrackWatcher(w)
+	defer w.closeChannels()
+	defer cancel()
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	timer := w.clock.NewTimer(0)
+	timer.Stop()
+	defer timer.Stop()
 
 	for {
+		var wait <-chan time.Time
+		if next, ok := nextPoll(watched); ok {
+			timer.Reset(next.Sub(w.clock.Now()))
+			wait = timer.C()
+		}
+
+		var due []*watchedField
+		var refreshed chan error
 		select {
 		case <-w.stop:
 			return
+		case <-w.draining:
+			return
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			newSnapshot := w.poll(ctx, r, dst, snapshot)
-			if newSnapshot != nil {
-				snapshot = newSnapshot
+		case <-wait:
+			now := w.clock.Now()
+			for i := range watched {
+				if wf := &watched[i]; wf.sub == "" && !wf.next.After(now) {
+					due = append(due, wf)
+				}
+			}
+		case id := <-w.pushes:
+			for i := range watched {
+				if wf := &watched[i]; wf.sub == id {
+					due = append(due, wf)
+				}
+			}
+		case refreshed = <-w.refresh:
+			for i := range watched {
+				due = append(due, &watched[i])
+			}
+		case id := <-w.ended:
+			// Re-resolve now in case a change was missed, then poll.
+			for i := range watched {
+				if wf := &watched[i]; wf.sub == id {
+					wf.sub = ""
+					due = append(due, wf)
+				}
+			}
+		}
+		timer.Stop()
+		if len(due) == 0 {
+			if refreshed != nil {
+				refreshed <- nil
+			}
+			continue
+		}
+
+		indices := make([]int, len(due))
+		for i, wf := range due {
+			indices[i] = wf.index
+		}
+		newSnapshot, err := w.poll(ctx, r, dst, snapshot, indices)
+		if err == nil {
+			snapshot = newSnapshot
+		}
+		now := w.clock.Now()
+		for _, wf := range due {
+			if wf.sub != "" {
+				continue
 			}
+			if err == nil {
+				wf.failures = 0
+			} else {
+				wf.failures++
+			}
+			wf.next = now.Add(cfg.delay(wf.interval, wf.failures))
+		}
+		if refreshed != nil {
+			refreshed <- err
 		}
 	}
 }
 
-// poll performs one polling cycle: re-resolve into temp copy, compare, update if changed.
-func (w *Watcher) poll(ctx context.Context, r *Resolver, dst any, oldSnapshot []fieldSnapshot) []fieldSnapshot {
-	// Create a temporary copy and resolve into it (not dst) to avoid
-	// partial updates on failure.
-	dstVal := reflect.ValueOf(dst).Elem()
-	tmp := reflect.New(dstVal.Type())
-	if err := r.Resolve(ctx, tmp.Interface()); err != nil {
-		// On error, keep the old snapshot and skip this cycle.
-		return nil
+// nextPoll returns the earliest poll time among polled fields, if any.
+func nextPoll(watched []watchedField) (time.Time, bool) {
+	var next time.Time
+	found := false
+	for _, wf := range watched {
+		if wf.sub != "" {
+			continue
+		}
+		if !found || wf.next.Before(next) {
+			next = wf.next
+			found = true
+		}
 	}
+	return next, found
+}
 
-	// Take new snapshot from the temp copy.
-	newSnapshot := r.takeSnapshot(tmp.Interface())
+// poll re-resolves the fields at the given indices into a temporary copy,
+// compares them with the snapshot, and copies changed fields into dst.
+// It returns the updated snapshot, or the error that made resolution fail.
+func (w *Watcher) poll(ctx context.Context, r *Resolver, dst any, oldSnapshot []fieldSnapshot, due []int) ([]fieldSnapshot, error) {
+	// Resolve into a temporary copy (not dst) to avoid partial updates on
+	// failure. A collection error means the set of fields no longer lines up
+	// with the snapshot (for example, a provider was unregistered).
+	dstVal := reflect.ValueOf(dst).Elem()
+	tmp := reflect.New(dstVal.Type()).Elem()
+	var tmpFields []fieldInfo
+	var errs []error
+	r.collectFields(tmp, &tmpFields, &errs)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if len(tmpFields) != len(oldSnapshot) {
+		return nil, fmt.Errorf("secrets: watched fields changed from %d to %d", len(oldSnapshot), len(tmpFields))
+	}
+	subset := make([]fieldInfo, 0, len(due))
+	for _, i := range due {
+		subset = append(subset, tmpFields[i])
+	}
+	if errs := r.resolveFields(ctx, subset); len(errs) > 0 {
+		// Keep the old snapshot and skip this cycle.
+		r.log(ctx, slog.LevelWarn, "secret poll failed",
+			slog.Int("fields", len(subset)),
+			r.errorAttr(errors.Join(errs...)))
+		return nil, errors.Join(errs...)
+	}
+	for k, i := range due {
+		tmpFields[i].raw = subset[k].raw
+	}
 
 	// Collect change events.
+	newSnapshot := slices.Clone(oldSnapshot)
+	var changed []int
 	var events []ChangeEvent
-	for i := range newSnapshot {
-		if i >= len(oldSnapshot) {
-			break
-		}
+	var rotations []RotationEvent
+	for _, i := range due {
 		old := &oldSnapshot[i]
-		new_ := &newSnapshot[i]
-
-		if !bytes.Equal(old.raw, new_.raw) {
-			events = append(events, ChangeEvent{
-				Field:    new_.fieldName,
-				Key:      new_.key,
-				Provider: new_.providerName,
-				OldValue: old.raw,
-				NewValue: new_.raw,
-			})
+		raw := tmpFields[i].snapshotBytes()
+		newSnapshot[i].raw = raw
+		if !bytes.Equal(old.raw, raw) {
+			r.log(ctx, slog.LevelInfo, "secret changed",
+				slog.String("field", old.fieldName),
+				slog.String("provider", old.providerName),
+				slog.String("key", old.key))
+			if r.cfg.metrics != nil {
+				r.cfg.metrics.WatchChange(old.providerName, old.key)
+			}
+			changed = append(changed, i)
+			events = append(events, w.changeEvents(old, raw)...)
+			if tmpFields[i].isVersioned {
+				rotations = append(rotations, RotationEvent{
+					Field:      old.fieldName,
+					Key:        old.key,
+					Provider:   old.providerName,
+					Current:    raw,
+					Previous:   valueToBytes(tmpFields[i].fieldValue.Field(1)),
+					OldCurrent: old.raw,
+				})
+			}
 		}
 	}
 
-	// If any changes were detected, copy only secret-tagged fields from tmp
-	// to dst under write lock. We must not copy the entire struct because
-	// non-secret fields would be zeroed out.
-	if len(events) > 0 {
-		var tmpFields []fieldInfo
+	// Copy only the changed fields from tmp to dst under write lock. Other
+	// fields in tmp were not resolved, and non-secret fields must be kept.
+	if len(changed) > 0 {
 		var dstFields []fieldInfo
-		var errs []error
-		r.collectFields(tmp.Elem(), &tmpFields, &errs)
 		r.collectFields(dstVal, &dstFields, &errs)
 
 		w.mu.Lock()
-		for i := range dstFields {
-			if i < len(tmpFields) {
+		for _, i := range changed {
+			if i < len(dstFields) {
 				dstFields[i].fieldValue.Set(tmpFields[i].fieldValue)
 			}
 		}
 		w.mu.Unlock()
+		if w.onUpdate != nil {
+			w.onUpdate()
+		}
 
-		// Emit change events.
 		for _, event := range events {
-			select {
-			case w.changes <- event:
-			default:
-				// Channel full, skip this event to avoid blocking.
+			if lost, ok := send(ctx, w, w.changes, w.changeQueue, event); ok && w.onOverflow != nil {
+				w.onOverflow(lost)
+			}
+		}
+		if ch, q := w.rotationsChan(); ch != nil {
+			for _, event := range rotations {
+				send(ctx, w, ch, q, event)
 			}
 		}
 	}
 
-	return newSnapshot
+	return newSnapshot, nil
+}
+
+// changeEvents returns the events for a field whose raw value changed from
+// old.raw to raw.
+func (w *Watcher) changeEvents(old *fieldSnapshot, raw []byte) []ChangeEvent {
+	whole := ChangeEvent{
+		Field:    old.fieldName,
+		Key:      old.key,
+		Provider: old.providerName,
+		OldValue: old.raw,
+		NewValue: raw,
+	}
+	if !w.structural {
+		return []ChangeEvent{whole}
+	}
+	diffs, ok := diffJSON(old.raw, raw)
+	if !ok || len(diffs) == 0 {
+		return []ChangeEvent{whole}
+	}
+	events := make([]ChangeEvent, len(diffs))
+	for i, d := range diffs {
+		events[i] = ChangeEvent{
+			Field:    old.fieldName,
+			Key:      old.key,
+			Provider: old.providerName,
+			Fragment: d.path,
+			OldValue: d.oldValue,
+			NewValue: d.newValue,
+		}
+	}
+	return events
 }
@@ -2,8 +2,10 @@ package secrets
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -165,8 +167,787 @@ func TestWatch_PreservesNonSecretFields(t *testing.T) {
 	w.RUnlock()
 }
 
+func TestWatch_ReplaceProvider(t *testing.T) {
+	oldStore := &syncMapProvider{}
+	oldStore.Store("key", []byte("old"))
+	newStore := &syncMapProvider{}
+	newStore.Store("key", []byte("new"))
+	r := NewResolver(WithDefault(oldStore))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	r.ReplaceProvider("", newStore)
+
+	select {
+	case event := <-w.Changes():
+		if string(event.NewValue) != "new" {
+			t.Errorf("event.NewValue = %q, want %q", event.NewValue, "new")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change from replaced provider")
+	}
+}
+
+func TestWatch_InitialRetry(t *testing.T) {
+	store := &flakyProvider{failures: 2}
+	store.Store("key", []byte("v"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WithInitialRetry(RetryPolicy{InitialInterval: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	if cfg.Val != "v" {
+		t.Errorf("Val = %q, want %q", cfg.Val, "v")
+	}
+	if n := store.attempts.Load(); n != 3 {
+		t.Errorf("attempts = %d, want 3", n)
+	}
+}
+
+func TestWatch_InitialRetryMaxAttempts(t *testing.T) {
+	store := &flakyProvider{failures: 5}
+	store.Store("key", []byte("v"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	_, err := r.Watch(context.Background(), &cfg, WithInitialRetry(RetryPolicy{
+		MaxAttempts:     2,
+		InitialInterval: time.Millisecond,
+	}))
+	if !errors.Is(err, errFlaky) {
+		t.Fatalf("expected errFlaky, got %v", err)
+	}
+	if n := store.attempts.Load(); n != 2 {
+		t.Errorf("attempts = %d, want 2", n)
+	}
+}
+
+func TestWatch_InitialRetrySkipsConfigErrors(t *testing.T) {
+	store := &flakyProvider{}
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"nope://key"`
+	}
+	var cfg Config
+
+	_, err := r.Watch(context.Background(), &cfg, WithInitialRetry(RetryPolicy{}))
+	var target *ErrUnknownProvider
+	if !errors.As(err, &target) {
+		t.Fatalf("expected ErrUnknownProvider, got %v", err)
+	}
+	if n := store.attempts.Load(); n != 0 {
+		t.Errorf("attempts = %d, want 0", n)
+	}
+}
+
+func TestWatch_PushTriggersResolve(t *testing.T) {
+	store := newPushProvider()
+	store.Store("key", []byte("initial"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The interval is far longer than the test; only a push can deliver the change.
+	w, err := r.Watch(ctx, &cfg, WatchInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.Push("key", []byte("pushed"))
+
+	select {
+	case event := <-w.Changes():
+		if string(event.NewValue) != "pushed" {
+			t.Errorf("event.NewValue = %q, want %q", event.NewValue, "pushed")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for pushed change")
+	}
+	if got := store.gets.Load(); got != 2 {
+		t.Errorf("Get calls = %d, want 2 (initial resolve and one push)", got)
+	}
+}
+
+func TestWatch_PushSubscribeErrorFallsBackToPolling(t *testing.T) {
+	store := newPushProvider()
+	store.Store("key", []byte("initial"))
+	store.watchErr = errors.New("push: unsupported")
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.Store("key", []byte("polled"))
+
+	select {
+	case event := <-w.Changes():
+		if string(event.NewValue) != "polled" {
+			t.Errorf("event.NewValue = %q, want %q", event.NewValue, "polled")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for polled change")
+	}
+}
+
+func TestWatch_PushClosedFallsBackToPolling(t *testing.T) {
+	store := newPushProvider()
+	store.Store("key", []byte("initial"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.CloseWatch("key")
+	store.Store("key", []byte("polled"))
+
+	select {
+	case event := <-w.Changes():
+		if string(event.NewValue) != "polled" {
+			t.Errorf("event.NewValue = %q, want %q", event.NewValue, "polled")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for polled change")
+	}
+}
+
+func TestWatch_PerFieldInterval(t *testing.T) {
+	store := &countingSyncMapProvider{}
+	store.Store("fast", []byte("f1"))
+	store.Store("slow", []byte("s1"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Fast string `secret:"fast,watch=20ms"`
+		Slow string `secret:"slow"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.Store("fast", []byte("f2"))
+	store.Store("slow", []byte("s2"))
+
+	select {
+	case event := <-w.Changes():
+		if event.Field != "Fast" {
+			t.Errorf("event.Field = %q, want %q", event.Field, "Fast")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change event")
+	}
+
+	w.RLock()
+	defer w.RUnlock()
+	if cfg.Fast != "f2" {
+		t.Errorf("Fast = %q, want %q", cfg.Fast, "f2")
+	}
+	if cfg.Slow != "s1" {
+		t.Errorf("Slow = %q, want %q (not yet due)", cfg.Slow, "s1")
+	}
+	if got := store.gets("slow"); got != 1 {
+		t.Errorf("slow fetched %d times, want 1", got)
+	}
+}
+
+func TestWatch_Filters(t *testing.T) {
+	tests := []struct {
+		name  string
+		opt   WatchOption
+		field string
+	}{
+		{"only", WatchOnly("B"), "B"},
+		{"exclude", WatchExclude("A"), "B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &countingSyncMapProvider{}
+			store.Store("a", []byte("a1"))
+			store.Store("b", []byte("b1"))
+			r := NewResolver(WithDefault(store))
+
+			type Config struct {
+				A string `secret:"a"`
+				B string `secret:"b"`
+			}
+			var cfg Config
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			w, err := r.Watch(ctx, &cfg, WatchInterval(20*time.Millisecond), tt.opt)
+			if err != nil {
+				t.Fatalf("Watch: %v", err)
+			}
+			defer w.Stop()
+
+			store.Store("a", []byte("a2"))
+			store.Store("b", []byte("b2"))
+
+			select {
+			case event := <-w.Changes():
+				if event.Field != tt.field {
+					t.Errorf("event.Field = %q, want %q", event.Field, tt.field)
+				}
+			case <-ctx.Done():
+				t.Fatal("timed out waiting for change event")
+			}
+			if got := store.gets("a"); got != 1 {
+				t.Errorf("unwatched field fetched %d times, want 1", got)
+			}
+		})
+	}
+}
+
+func TestWatch_FilterUnknownField(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("a", []byte("a1"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		A string `secret:"a"`
+	}
+	var cfg Config
+
+	for _, opt := range []WatchOption{WatchOnly("Typo"), WatchExclude("Typo")} {
+		if _, err := r.Watch(context.Background(), &cfg, opt); err == nil {
+			t.Error("expected error for unknown field name, got nil")
+		}
+	}
+}
+
+func TestWatch_PartialPollPreservesOtherFields(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("fast", []byte("f1"))
+	store.Store("slow", []byte("s1"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Fast string `secret:"fast,watch=20ms"`
+		Slow string `secret:"slow"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	for _, v := range []string{"f2", "f3"} {
+		store.Store("fast", []byte(v))
+		select {
+		case <-w.Changes():
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for change event")
+		}
+	}
+
+	w.RLock()
+	defer w.RUnlock()
+	if cfg.Slow != "s1" {
+		t.Errorf("Slow = %q, want %q", cfg.Slow, "s1")
+	}
+}
+
+func TestWatch_OnOverflow(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("key", []byte("v0"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dropped := make(chan ChangeEvent, 1)
+	w, err := r.Watch(ctx, &cfg,
+		WatchInterval(10*time.Millisecond),
+		WatchBufferSize(1),
+		WatchOnOverflow(func(ev ChangeEvent) { dropped <- ev }),
+	)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.Store("key", []byte("v1"))
+	waitForValue(t, ctx, w, &cfg.Val, "v1")
+	store.Store("key", []byte("v2")) // buffer is full
+
+	select {
+	case ev := <-dropped:
+		if string(ev.NewValue) != "v2" {
+			t.Errorf("dropped NewValue = %q, want %q", ev.NewValue, "v2")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for overflow callback")
+	}
+	if ev := <-w.Changes(); string(ev.NewValue) != "v1" {
+		t.Errorf("buffered NewValue = %q, want %q", ev.NewValue, "v1")
+	}
+}
+
+func TestWatch_DeliveryBlock(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("key", []byte("v0"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg,
+		WatchInterval(10*time.Millisecond),
+		WatchBufferSize(0),
+		WatchDelivery(DeliveryBlock),
+	)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.Store("key", []byte("v1"))
+	waitForValue(t, ctx, w, &cfg.Val, "v1")
+	store.Store("key", []byte("v2"))
+	time.Sleep(50 * time.Millisecond) // polling is paused until v1 is received
+
+	for _, want := range []string{"v1", "v2"} {
+		select {
+		case ev := <-w.Changes():
+			if string(ev.NewValue) != want {
+				t.Errorf("NewValue = %q, want %q", ev.NewValue, want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+}
+
+func TestWatch_Drain(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("key", []byte("v0"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.Store("key", []byte("v1"))
+	waitForValue(t, ctx, w, &cfg.Val, "v1")
+
+	if err := w.Drain(ctx); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+
+	// The buffered event is still received, and the channel is closed.
+	var got []string
+	for ev := range w.Changes() {
+		got = append(got, string(ev.NewValue))
+	}
+	if len(got) != 1 || got[0] != "v1" {
+		t.Errorf("events = %q, want [v1]", got)
+	}
+}
+
+func TestWatch_DrainConcurrent(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("key", []byte("v0"))
+	r := NewResolver(WithDefault(store))
+
+	var cfg struct {
+		Val string `secret:"key"`
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.Drain(ctx); err != nil {
+				t.Errorf("Drain: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	for range w.Changes() {
+	}
+}
+
+func TestWatch_DrainContextDone(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("key", []byte("v0"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	w, err := r.Watch(context.Background(), &cfg,
+		WatchInterval(10*time.Millisecond),
+		WatchBufferSize(0),
+		WatchDelivery(DeliveryBlock),
+	)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	// Nobody receives, so the poll loop waits to deliver the change.
+	store.Store("key", []byte("v1"))
+	waitForValue(t, context.Background(), w, &cfg.Val, "v1")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := w.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Drain = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestWatch_Rotations(t *testing.T) {
+	store := &versionedSyncMapProvider{}
+	store.Store("enc", []byte("k1"))
+	store.Store("enc@previous", []byte("k0"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		EncKey Versioned[string] `secret:"enc"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+	rotations := w.Rotations()
+
+	store.Store("enc@previous", []byte("k1"))
+	store.Store("enc", []byte("k2"))
+
+	select {
+	case ev := <-rotations:
+		if ev.Field != "EncKey" || ev.Key != "enc" {
+			t.Errorf("event = %+v, want field EncKey, key enc", ev)
+		}
+		if string(ev.Current) != "k2" || string(ev.Previous) != "k1" || string(ev.OldCurrent) != "k1" {
+			t.Errorf("event generations = %q/%q (was %q), want k2/k1 (was k1)", ev.Current, ev.Previous, ev.OldCurrent)
+		}
+		if !ev.Sequential() {
+			t.Error("Sequential() = false, want true")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for rotation event")
+	}
+	select {
+	case ev := <-w.Changes():
+		if string(ev.NewValue) != "k2" {
+			t.Errorf("change NewValue = %q, want %q", ev.NewValue, "k2")
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change event")
+	}
+
+	w.Stop()
+	if _, ok := <-rotations; ok {
+		t.Error("Rotations channel not closed after Stop")
+	}
+}
+
+func TestRotationEvent_Sequential(t *testing.T) {
+	ev := RotationEvent{Current: []byte("k3"), Previous: []byte("k2"), OldCurrent: []byte("k1")}
+	if ev.Sequential() {
+		t.Error("Sequential() = true after a skipped generation, want false")
+	}
+}
+
+func TestWatch_StructuralDiff(t *testing.T) {
+	store := &syncMapProvider{}
+	store.Store("db", []byte(`{"host":"a","user":"app","password":"p1"}`))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		DB string `secret:"db"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(10*time.Millisecond), WatchStructuralDiff())
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.Store("db", []byte(`{"host":"a","user":"app","password":"p2"}`))
+
+	select {
+	case ev := <-w.Changes():
+		if ev.Field != "DB" || ev.Fragment != "password" {
+			t.Errorf("event field/fragment = %q/%q, want DB/password", ev.Field, ev.Fragment)
+		}
+		if string(ev.OldValue) != "p1" || string(ev.NewValue) != "p2" {
+			t.Errorf("event values = %q -> %q, want p1 -> p2", ev.OldValue, ev.NewValue)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change event")
+	}
+	select {
+	case ev := <-w.Changes():
+		t.Errorf("unexpected extra event %+v", ev)
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	// Non-JSON values fall back to whole-value events.
+	store.Store("db", []byte("not json"))
+	select {
+	case ev := <-w.Changes():
+		if ev.Fragment != "" || string(ev.NewValue) != "not json" {
+			t.Errorf("event = %+v, want whole-value event", ev)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change event")
+	}
+}
+
+func TestWatch_BackoffAfterFailures(t *testing.T) {
+	store := &toggleProvider{}
+	store.Store("key", []byte("v"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(10*time.Millisecond), WatchMaxBackoff(80*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer w.Stop()
+
+	store.fail.Store(true)
+	start := store.attempts.Load()
+	time.Sleep(300 * time.Millisecond)
+	// Without backoff ~30 polls would fail; with it the delays are
+	// 20ms, 40ms, 80ms, 80ms, ...
+	if n := store.attempts.Load() - start; n > 10 {
+		t.Errorf("attempts while failing = %d, want at most 10", n)
+	}
+
+	store.Store("key", []byte("v2"))
+	store.fail.Store(false)
+	select {
+	case <-w.Changes():
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for change after recovery")
+	}
+}
+
+func TestWatch_Refresh(t *testing.T) {
+	store := &toggleProvider{}
+	store.Store("key", []byte("v0"))
+	r := NewResolver(WithDefault(store))
+
+	type Config struct {
+		Val string `secret:"key"`
+	}
+	var cfg Config
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	w, err := r.Watch(ctx, &cfg, WatchInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	store.Store("key", []byte("v1"))
+	if err := w.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	// The change is applied and its event buffered before Refresh returns.
+	w.RLock()
+	val := cfg.Val
+	w.RUnlock()
+	if val != "v1" {
+		t.Errorf("Val = %q, want %q", val, "v1")
+	}
+	if len(w.Changes()) != 1 {
+		t.Errorf("buffered events = %d, want 1", len(w.Changes()))
+	}
+
+	store.fail.Store(true)
+	if err := w.Refresh(ctx); !errors.Is(err, errFlaky) {
+		t.Errorf("Refresh while failing = %v, want %v", err, errFlaky)
+	}
+
+	w.Stop()
+	if err := w.Refresh(ctx); !errors.Is(err, ErrWatcherStopped) {
+		t.Errorf("Refresh after Stop = %v, want ErrWatcherStopped", err)
+	}
+}
+
+func TestWatcherConfig_Delay(t *testing.T) {
+	c := watcherConfig{maxBackoff: time.Second}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, w := range want {
+		if got := c.delay(100*time.Millisecond, i); got != w {
+			t.Errorf("delay(100ms, %d) = %v, want %v", i, got, w)
+		}
+	}
+	if got := (&watcherConfig{}).delay(time.Second, 5); got != 10*time.Second {
+		t.Errorf("default max backoff = %v, want 10s", got)
+	}
+	if got := (&watcherConfig{maxBackoff: time.Millisecond}).delay(time.Second, 1); got != time.Second {
+		t.Errorf("max backoff below interval = %v, want 1s", got)
+	}
+
+	c = watcherConfig{jitter: 0.1}
+	for range 100 {
+		if got := c.delay(time.Second, 0); got < 900*time.Millisecond || got > 1100*time.Millisecond {
+			t.Fatalf("jittered delay = %v, want within 1s ± 10%%", got)
+		}
+	}
+	var cfg watcherConfig
+	WatchJitter(5)(&cfg)
+	if cfg.jitter != 1 {
+		t.Errorf("WatchJitter(5) = %v, want clamped to 1", cfg.jitter)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialInterval: 100 * time.Millisecond, MaxInterval: time.Second, Multiplier: 3}
+	want := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := (RetryPolicy{}).backoff(1); got != time.Second {
+		t.Errorf("default backoff(1) = %v, want 1s", got)
+	}
+}
+
 // --- helpers ---
 
+// waitForValue waits until the watched field *field equals want.
+func waitForValue(t *testing.T, ctx context.Context, w *Watcher, field *string, want string) {
+	t.Helper()
+	for {
+		w.RLock()
+		got := *field
+		w.RUnlock()
+		if got == want {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for value %q (got %q)", want, got)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
 // syncMapProvider is a thread-safe map-based Provider for watcher testing.
 type syncMapProvider struct {
 	data sync.Map
@@ -183,3 +964,110 @@ func (p *syncMapProvider) Get(_ context.Context, key string) ([]byte, error) {
 	}
 	return v.([]byte), nil
 }
+
+// countingSyncMapProvider is a syncMapProvider that counts Get calls per key.
+type countingSyncMapProvider struct {
+	syncMapProvider
+	counts sync.Map // key -> *atomic.Int64
+}
+
+func (p *countingSyncMapProvider) Get(ctx context.Context, key string) ([]byte, error) {
+	c, _ := p.counts.LoadOrStore(key, new(atomic.Int64))
+	c.(*atomic.Int64).Add(1)
+	return p.syncMapProvider.Get(ctx, key)
+}
+
+func (p *countingSyncMapProvider) gets(key string) int64 {
+	c, ok := p.counts.Load(key)
+	if !ok {
+		return 0
+	}
+	return c.(*atomic.Int64).Load()
+}
+
+var errFlaky = errors.New("flaky: unavailable")
+
+// flakyProvider fails the first failures calls to Get, then behaves like syncMapProvider.
+type flakyProvider struct {
+	syncMapProvider
+	failures int64
+	attempts atomic.Int64
+}
+
+func (p *flakyProvider) Get(ctx context.Context, key string) ([]byte, error) {
+	if p.attempts.Add(1) <= p.failures {
+		return nil, errFlaky
+	}
+	return p.syncMapProvider.Get(ctx, key)
+}
+
+// toggleProvider is a syncMapProvider whose Get fails while fail is set.
+type toggleProvider struct {
+	syncMapProvider
+	fail     atomic.Bool
+	attempts atomic.Int64
+}
+
+func (p *toggleProvider) Get(ctx context.Context, key string) ([]byte, error) {
+	p.attempts.Add(1)
+	if p.fail.Load() {
+		return nil, errFlaky
+	}
+	return p.syncMapProvider.Get(ctx, key)
+}
+
+// versionedSyncMapProvider is a syncMapProvider that serves versions stored
+// under "key@version".
+type versionedSyncMapProvider struct {
+	syncMapProvider
+}
+
+func (p *versionedSyncMapProvider) GetVersion(ctx context.Context, key, version string) ([]byte, error) {
+	return p.Get(ctx, key+"@"+version)
+}
+
+// pushProvider is a syncMapProvider that implements WatchableProvider.
+type pushProvider struct {
+	syncMapProvider
+	watchErr error
+	gets     atomic.Int64
+
+	mu       sync.Mutex
+	watchers map[string]chan []byte
+}
+
+func newPushProvider() *pushProvider {
+	return &pushProvider{watchers: make(map[string]chan []byte)}
+}
+
+func (p *pushProvider) Get(ctx context.Context, key string) ([]byte, error) {
+	p.gets.Add(1)
+	return p.syncMapProvider.Get(ctx, key)
+}
+
+func (p *pushProvider) Watch(_ context.Context, key string) (<-chan []byte, error) {
+	if p.watchErr != nil {
+		return nil, p.watchErr
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ch := make(chan []byte, 1)
+	p.watchers[key] = ch
+	return ch, nil
+}
+
+// Push stores val and notifies the subscriber for key.
+func (p *pushProvider) Push(key string, val []byte) {
+	p.Store(key, val)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.watchers[key] <- val
+}
+
+// CloseWatch ends the subscription for key.
+func (p *pushProvider) CloseWatch(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	close(p.watchers[key])
+	delete(p.watchers, key)
+}
@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// WriterProvider is implemented by providers that can create, update, and
+// delete secrets. Resolver.Store and Resolver.Delete use it.
+type WriterProvider interface {
+	Provider
+	// Set stores value under key, creating the secret if it does not exist.
+	// On versioned backends the value becomes the secret's new current
+	// version.
+	Set(ctx context.Context, key string, value []byte) error
+	// Delete deletes the secret at key. Returns ErrNotFound (wrapped) if the
+	// key does not exist.
+	Delete(ctx context.Context, key string) error
+}
+
+// Store stores value under key with p, which must implement WriterProvider;
+// otherwise an error wrapping errors.ErrUnsupported is returned. Use it to
+// seed secrets from setup code that has no Resolver:
+//
+//	err := secrets.Store(ctx, awssmProvider, "prod/api-key", key)
+func Store(ctx context.Context, p Provider, key string, value []byte) error {
+	wp, ok := p.(WriterProvider)
+	if !ok {
+		return fmt.Errorf("secrets: store %q: %w", key, errors.ErrUnsupported)
+	}
+	if err := wp.Set(ctx, key, value); err != nil {
+		return fmt.Errorf("secrets: store %q: %w", key, err)
+	}
+	return nil
+}
+
+// Store stores value under key with the provider registered for scheme, or
+// with the default provider if scheme is empty, so that secrets can be seeded
+// and rotated with the same configuration they are read with. The provider
+// must implement WriterProvider; otherwise an error wrapping
+// errors.ErrUnsupported is returned. value is registered with the Resolver's
+// Redactor.
+func (r *Resolver) Store(ctx context.Context, scheme, key string, value []byte) error {
+	wp, name, err := r.writerFor(scheme, key)
+	if err != nil {
+		return err
+	}
+	if !r.acquire() {
+		return ErrClosed
+	}
+	defer r.inflight.Done()
+	r.redactor.addResolved(value)
+	if err := wp.Set(ctx, key, value); err != nil {
+		return fmt.Errorf("secrets: store %q: %w", name, err)
+	}
+	return nil
+}
+
+// Delete deletes key with the provider registered for scheme, or with the
+// default provider if scheme is empty. The provider must implement
+// WriterProvider; otherwise an error wrapping errors.ErrUnsupported is
+// returned.
+func (r *Resolver) Delete(ctx context.Context, scheme, key string) error {
+	wp, name, err := r.writerFor(scheme, key)
+	if err != nil {
+		return err
+	}
+	if !r.acquire() {
+		return ErrClosed
+	}
+	defer r.inflight.Done()
+	if err := wp.Delete(ctx, key); err != nil {
+		return fmt.Errorf("secrets: delete %q: %w", name, err)
+	}
+	return nil
+}
+
+// writerFor returns the WriterProvider registered for scheme and a name for
+// key in errors.
+func (r *Resolver) writerFor(scheme, key string) (WriterProvider, string, error) {
+	tag := parsedTag{Scheme: scheme, Key: key}
+	p, providerName, err := r.providerFor("", tag)
+	if err != nil {
+		return nil, "", err
+	}
+	wp, ok := p.(WriterProvider)
+	if !ok {
+		return nil, "", fmt.Errorf("secrets: provider %s: %w", providerName, errors.ErrUnsupported)
+	}
+	return wp, tag.URI(), nil
+}
@@ -0,0 +1,108 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// writableProvider is a syncMapProvider that implements WriterProvider.
+type writableProvider struct {
+	syncMapProvider
+}
+
+func (p *writableProvider) Set(_ context.Context, key string, value []byte) error {
+	p.data.Store(key, value)
+	return nil
+}
+
+func (p *writableProvider) Delete(_ context.Context, key string) error {
+	if _, ok := p.data.LoadAndDelete(key); !ok {
+		return fmt.Errorf("writable: %q: %w", key, ErrNotFound)
+	}
+	return nil
+}
+
+func TestResolver_Store(t *testing.T) {
+	p := &writableProvider{}
+	r := NewResolver(WithDefault(p), WithProvider("w", p))
+
+	if err := r.Store(context.Background(), "w", "api-key", []byte("sk-live-123456")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	var cfg struct {
+		APIKey string `secret:"api-key"`
+	}
+	if err := r.Resolve(context.Background(), &cfg); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.APIKey != "sk-live-123456" {
+		t.Errorf("APIKey = %q, want %q", cfg.APIKey, "sk-live-123456")
+	}
+	if got := r.Redactor().Redact("key sk-live-123456"); got != "key [REDACTED]" {
+		t.Errorf("Redact = %q, want stored value redacted", got)
+	}
+
+	if err := r.Delete(context.Background(), "", "api-key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := r.Delete(context.Background(), "", "api-key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestResolver_Store_Unsupported(t *testing.T) {
+	r := NewResolver(WithDefault(&mockProvider{}))
+
+	if err := r.Store(context.Background(), "", "key", []byte("v")); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Store: expected ErrUnsupported, got %v", err)
+	}
+	var up *ErrUnknownProvider
+	if err := r.Delete(context.Background(), "nope", "key"); !errors.As(err, &up) {
+		t.Errorf("Delete: expected ErrUnknownProvider, got %v", err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	p := &writableProvider{}
+	if err := Store(context.Background(), p, "key", []byte("value")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if v, err := p.Get(context.Background(), "key"); err != nil || string(v) != "value" {
+		t.Errorf("Get = %q, %v, want %q", v, err, "value")
+	}
+	if err := Store(context.Background(), &mockProvider{}, "key", nil); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Store: expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestCachedProvider_SetInvalidates(t *testing.T) {
+	p := &writableProvider{}
+	p.Store("key", []byte("old"))
+	c := NewCachedProvider(p, time.Minute)
+	defer c.Close()
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, "key"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if err := c.Set(ctx, "key", []byte("new")); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := c.Get(ctx, "key"); err != nil || string(got) != "new" {
+		t.Errorf("Get after Set = %q, %v, want %q", got, err, "new")
+	}
+	if err := c.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: expected ErrNotFound, got %v", err)
+	}
+
+	ro := NewCachedProvider(&mockProvider{}, time.Minute)
+	if err := ro.Set(ctx, "key", nil); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Set on read-only provider: expected ErrUnsupported, got %v", err)
+	}
+}